@@ -15,8 +15,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"time"
@@ -32,6 +35,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/test/loadtest"
 )
 
 func generateKeys(_ *cli.Context) error {
@@ -102,35 +106,9 @@ func createToken(c *cli.Context) error {
 		return err
 	}
 
-	// use the first API key from config
-	if len(conf.Keys) == 0 {
-		// try to load from file
-		if _, err := os.Stat(conf.KeyFile); err != nil {
-			return err
-		}
-		f, err := os.Open(conf.KeyFile)
-		if err != nil {
-			return err
-		}
-		defer func() {
-			_ = f.Close()
-		}()
-		decoder := yaml.NewDecoder(f)
-		if err = decoder.Decode(conf.Keys); err != nil {
-			return err
-		}
-
-		if len(conf.Keys) == 0 {
-			return fmt.Errorf("keys are not configured")
-		}
-	}
-
-	var apiKey string
-	var apiSecret string
-	for k, v := range conf.Keys {
-		apiKey = k
-		apiSecret = v
-		break
+	apiKey, apiSecret, err := getFirstKeyPair(conf)
+	if err != nil {
+		return err
 	}
 
 	grant := &auth.VideoGrant{
@@ -245,3 +223,104 @@ func listNodes(c *cli.Context) error {
 
 	return nil
 }
+
+func loadTest(c *cli.Context) error {
+	apiKey, apiSecret := c.String("api-key"), c.String("api-secret")
+	if apiKey == "" || apiSecret == "" {
+		conf, err := getConfig(c)
+		if err != nil {
+			return err
+		}
+		if apiKey, apiSecret, err = getFirstKeyPair(conf); err != nil {
+			return err
+		}
+	}
+
+	lt, err := loadtest.NewLoadTest(loadtest.Params{
+		URL:          c.String("url"),
+		APIKey:       apiKey,
+		APISecret:    apiSecret,
+		Room:         c.String("room"),
+		Publishers:   c.Int("publishers"),
+		Subscribers:  c.Int("subscribers"),
+		VideoFile:    c.String("video-file"),
+		AudioFile:    c.String("audio-file"),
+		VideoBitrate: c.Int("video-bitrate"),
+		Duration:     c.Duration("duration"),
+	})
+	if err != nil {
+		return err
+	}
+
+	// stop early on interrupt, reporting what was measured so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := lt.Run(ctx)
+	if result == nil {
+		return err
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Subscriber", "Tracks", "Bitrate", "Packets", "Loss"})
+	table.SetColumnAlignment([]int{
+		tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT,
+	})
+	for _, s := range result.Subscribers {
+		table.Append([]string{
+			s.Identity,
+			fmt.Sprintf("%d/%d", s.Tracks, result.ExpectedTracks),
+			formatBitrate(s.Bitrate),
+			strconv.FormatUint(s.PacketsReceived, 10),
+			fmt.Sprintf("%.2f%%", s.LossRate()*100),
+		})
+	}
+	table.Render()
+
+	minBitrate, avgBitrate, lossRate, missingTracks := result.Summary()
+	fmt.Printf("%d publishers, %d subscribers, measured for %s\n", c.Int("publishers"), len(result.Subscribers), result.Duration.Round(time.Second))
+	fmt.Printf("bitrate per subscriber: min %s, avg %s\n", formatBitrate(minBitrate), formatBitrate(avgBitrate))
+	fmt.Printf("loss: %.2f%%, missing tracks: %d\n", lossRate*100, missingTracks)
+	return nil
+}
+
+func formatBitrate(bps float64) string {
+	return humanize.SIWithDigits(bps, 1, "bps")
+}
+
+// getFirstKeyPair returns the first API key from config
+func getFirstKeyPair(conf *config.Config) (string, string, error) {
+	if len(conf.Keys) == 0 {
+		// try to load from file
+		if _, err := os.Stat(conf.KeyFile); err != nil {
+			return "", "", err
+		}
+		f, err := os.Open(conf.KeyFile)
+		if err != nil {
+			return "", "", err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		decoder := yaml.NewDecoder(f)
+		if err = decoder.Decode(conf.Keys); err != nil {
+			return "", "", err
+		}
+
+		if len(conf.Keys) == 0 {
+			return "", "", fmt.Errorf("keys are not configured")
+		}
+	}
+
+	var apiKey string
+	var apiSecret string
+	for k, v := range conf.Keys {
+		apiKey = k
+		apiSecret = v
+		break
+	}
+	return apiKey, apiSecret, nil
+}
@@ -174,6 +174,58 @@ func main() {
 				Usage:  "list all nodes",
 				Action: listNodes,
 			},
+			{
+				Name:   "load-test",
+				Usage:  "publishes and subscribes synthetic participants to a room, reporting the bitrates and loss received",
+				Action: loadTest,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "url",
+						Usage: "address of the server",
+						Value: "ws://localhost:7880",
+					},
+					&cli.StringFlag{
+						Name:  "api-key",
+						Usage: "defaults to the first key of the config",
+					},
+					&cli.StringFlag{
+						Name: "api-secret",
+					},
+					&cli.StringFlag{
+						Name:  "room",
+						Usage: "name of room to join",
+						Value: "load-test",
+					},
+					&cli.IntFlag{
+						Name:  "publishers",
+						Usage: "number of participants publishing audio and video",
+						Value: 1,
+					},
+					&cli.IntFlag{
+						Name:  "subscribers",
+						Usage: "number of participants subscribing to every publisher",
+						Value: 5,
+					},
+					&cli.StringFlag{
+						Name:  "video-file",
+						Usage: "VP8 IVF file published in a loop, synthetic video is published when not set",
+					},
+					&cli.StringFlag{
+						Name:  "audio-file",
+						Usage: "Opus OGG file published in a loop, synthetic audio is published when not set",
+					},
+					&cli.IntFlag{
+						Name:  "video-bitrate",
+						Usage: "bitrate of the synthetic video in bps, 0 to publish audio only",
+						Value: 500_000,
+					},
+					&cli.DurationFlag{
+						Name:  "duration",
+						Usage: "time to measure once subscribed",
+						Value: 30 * time.Second,
+					},
+				},
+			},
 			{
 				Name:   "help-verbose",
 				Usage:  "prints app help, including all generated configuration flags",
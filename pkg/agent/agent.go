@@ -0,0 +1,321 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package agent lets in-process agents, such as transcription or moderation, receive the audio published in rooms
+// hosted on this node without joining as participants, and publish their results back into the room.
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+type AudioFormat int
+
+const (
+	// AudioFormatRTP delivers the RTP packets as received from the publisher
+	AudioFormatRTP AudioFormat = iota
+	// AudioFormatOpus delivers the Opus payloads, one frame per packet
+	AudioFormatOpus
+	// AudioFormatPCM delivers samples decoded by the agent's decoder
+	AudioFormatPCM
+)
+
+const (
+	// packets queued for an agent that is slower than real time before they are dropped
+	audioQueueSize = 100
+	// Opus DTX comfort noise frames are at most this long
+	opusDTXMaxSize = 2
+	// lost frames concealed by the decoder, a longer gap is not worth the latency of catching up
+	maxConcealedFrames = 2
+)
+
+var (
+	ErrNameRequired    = errors.New("agent name is required")
+	ErrHandlerRequired = errors.New("agent audio handler is required")
+	ErrNoDecoder       = errors.New("PCM audio requires a decoder")
+	ErrAgentExists     = errors.New("agent is already registered")
+)
+
+// TrackInfo identifies a published audio track
+type TrackInfo struct {
+	Room     livekit.RoomName
+	Identity livekit.ParticipantIdentity
+	TrackID  livekit.TrackID
+	MimeType string
+}
+
+// PublishedTrack is an audio track published in a room hosted on this node
+type PublishedTrack struct {
+	TrackInfo
+	Receiver sfu.TrackReceiver
+}
+
+type AudioFrame struct {
+	Track   TrackInfo
+	Arrival time.Time
+	// RTP packet, for AudioFormatRTP
+	Packet *rtp.Packet
+	// Opus frame and its position, for AudioFormatOpus and AudioFormatPCM
+	SequenceNumber uint16
+	Timestamp      uint32
+	Payload        []byte
+	// the publisher is silent and sends comfort noise (DTX)
+	Silence bool
+	// decoded samples, for AudioFormatPCM
+	PCM []int16
+}
+
+// RoomMetadata is an entry of a room's composite metadata, such as its layout, watermark or captions
+type RoomMetadata struct {
+	Room livekit.RoomName
+	// increases by one for every entry pushed to the room
+	Seq       uint64
+	Kind      string
+	Payload   []byte
+	Timestamp time.Time
+}
+
+// Decoder decodes the Opus frames of one track. A nil payload asks for concealment of a lost frame
+type Decoder interface {
+	Decode(payload []byte) ([]int16, error)
+}
+
+type DecoderFactory func(track TrackInfo) (Decoder, error)
+
+// Results publishes an agent's results into the room of the track it processed
+type Results interface {
+	// SetParticipantAttributes merges the attributes into the participant's metadata JSON object,
+	// an empty value removes the attribute
+	SetParticipantAttributes(ctx context.Context, room livekit.RoomName, identity livekit.ParticipantIdentity, attributes map[string]string, actor string) error
+	// SendData sends a data message to the given participants, or all participants when none are given
+	SendData(ctx context.Context, room livekit.RoomName, payload []byte, topic string, destinations []livekit.ParticipantIdentity, reliable bool) error
+}
+
+type Registration struct {
+	Name   string
+	Format AudioFormat
+	// rooms the agent listens to, all rooms when nil
+	RoomFilter func(room livekit.RoomName) bool
+	// required for AudioFormatPCM, called for every track
+	NewDecoder DecoderFactory
+	// called from a goroutine per track, frames of a track are delivered in order
+	OnAudio func(frame *AudioFrame)
+	// called when the track is unpublished or the agent is closed
+	OnTrackEnded func(track TrackInfo)
+	// optional, called in order with the composite metadata pushed to the rooms the agent listens to
+	OnRoomMetadata func(metadata *RoomMetadata)
+}
+
+// Registry attaches registered agents to the audio tracks published on this node
+type Registry struct {
+	results Results
+	tracks  func() []PublishedTrack
+	logger  logger.Logger
+
+	lock   sync.RWMutex
+	agents map[string]*Agent
+}
+
+// NewRegistry creates a registry, tracks lists the audio tracks already published when an agent registers
+func NewRegistry(results Results, tracks func() []PublishedTrack) *Registry {
+	return &Registry{
+		results: results,
+		tracks:  tracks,
+		logger:  logger.GetLogger().WithComponent("agent"),
+		agents:  make(map[string]*Agent),
+	}
+}
+
+// Register starts delivering audio to the agent, including the tracks that are already published
+func (r *Registry) Register(reg Registration) (*Agent, error) {
+	switch {
+	case reg.Name == "":
+		return nil, ErrNameRequired
+	case reg.OnAudio == nil:
+		return nil, ErrHandlerRequired
+	case reg.Format == AudioFormatPCM && reg.NewDecoder == nil:
+		return nil, ErrNoDecoder
+	}
+
+	a := &Agent{
+		reg:           reg,
+		registry:      r,
+		logger:        r.logger.WithValues("agent", reg.Name),
+		subscriptions: make(map[livekit.TrackID]*subscription),
+	}
+	r.lock.Lock()
+	if r.agents[reg.Name] != nil {
+		r.lock.Unlock()
+		return nil, ErrAgentExists
+	}
+	r.agents[reg.Name] = a
+	r.lock.Unlock()
+
+	a.logger.Infow("agent registered")
+	if r.tracks != nil {
+		for _, track := range r.tracks() {
+			a.subscribe(track)
+		}
+	}
+	return a, nil
+}
+
+// Agents returns the names of the registered agents
+func (r *Registry) Agents() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	names := make([]string, 0, len(r.agents))
+	for name := range r.agents {
+		names = append(names, name)
+	}
+	return names
+}
+
+// TrackPublished attaches the registered agents to a newly published audio track
+func (r *Registry) TrackPublished(track PublishedTrack) {
+	r.lock.RLock()
+	agents := make([]*Agent, 0, len(r.agents))
+	for _, a := range r.agents {
+		agents = append(agents, a)
+	}
+	r.lock.RUnlock()
+
+	for _, a := range agents {
+		a.subscribe(track)
+	}
+}
+
+// RoomMetadata delivers composite metadata pushed to a room to the agents listening to it
+func (r *Registry) RoomMetadata(metadata *RoomMetadata) {
+	r.lock.RLock()
+	agents := make([]*Agent, 0, len(r.agents))
+	for _, a := range r.agents {
+		agents = append(agents, a)
+	}
+	r.lock.RUnlock()
+
+	for _, a := range agents {
+		if a.reg.OnRoomMetadata == nil || (a.reg.RoomFilter != nil && !a.reg.RoomFilter(metadata.Room)) {
+			continue
+		}
+		a.reg.OnRoomMetadata(metadata)
+	}
+}
+
+// Agent is a registered agent
+type Agent struct {
+	reg      Registration
+	registry *Registry
+	logger   logger.Logger
+
+	lock          sync.Mutex
+	closed        bool
+	subscriptions map[livekit.TrackID]*subscription
+}
+
+func (a *Agent) Name() string {
+	return a.reg.Name
+}
+
+// Tracks returns the tracks the agent is receiving
+func (a *Agent) Tracks() []TrackInfo {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	tracks := make([]TrackInfo, 0, len(a.subscriptions))
+	for _, s := range a.subscriptions {
+		tracks = append(tracks, s.track)
+	}
+	return tracks
+}
+
+// SetParticipantAttributes publishes results as attributes of a participant, the change is recorded with the agent as actor
+func (a *Agent) SetParticipantAttributes(ctx context.Context, room livekit.RoomName, identity livekit.ParticipantIdentity, attributes map[string]string) error {
+	return a.registry.results.SetParticipantAttributes(ctx, room, identity, attributes, a.reg.Name)
+}
+
+// SendData publishes results as a data message
+func (a *Agent) SendData(ctx context.Context, room livekit.RoomName, payload []byte, topic string, destinations []livekit.ParticipantIdentity, reliable bool) error {
+	return a.registry.results.SendData(ctx, room, payload, topic, destinations, reliable)
+}
+
+// Close unregisters the agent and stops delivering audio
+func (a *Agent) Close() {
+	a.registry.lock.Lock()
+	if a.registry.agents[a.reg.Name] == a {
+		delete(a.registry.agents, a.reg.Name)
+	}
+	a.registry.lock.Unlock()
+
+	a.lock.Lock()
+	a.closed = true
+	subscriptions := a.subscriptions
+	a.subscriptions = make(map[livekit.TrackID]*subscription)
+	a.lock.Unlock()
+
+	for _, s := range subscriptions {
+		s.close()
+	}
+	a.logger.Infow("agent unregistered")
+}
+
+func (a *Agent) subscribe(track PublishedTrack) {
+	if track.Receiver == nil || (a.reg.RoomFilter != nil && !a.reg.RoomFilter(track.Room)) {
+		return
+	}
+
+	a.lock.Lock()
+	if a.closed || a.subscriptions[track.TrackID] != nil {
+		a.lock.Unlock()
+		return
+	}
+	var decoder Decoder
+	if a.reg.Format == AudioFormatPCM {
+		var err error
+		if decoder, err = a.reg.NewDecoder(track.TrackInfo); err != nil {
+			a.lock.Unlock()
+			a.logger.Warnw("could not create decoder", err, "trackID", track.TrackID)
+			return
+		}
+	}
+	s := newSubscription(a, track, decoder)
+	a.subscriptions[track.TrackID] = s
+	a.lock.Unlock()
+
+	track.Receiver.AddDownTrack(s.sink)
+	a.logger.Debugw("agent subscribed to track", "room", track.Room, "participant", track.Identity, "trackID", track.TrackID)
+}
+
+func (a *Agent) unsubscribed(s *subscription) {
+	a.lock.Lock()
+	if a.subscriptions[s.track.TrackID] == s {
+		delete(a.subscriptions, s.track.TrackID)
+	}
+	a.lock.Unlock()
+
+	if a.reg.OnTrackEnded != nil {
+		a.reg.OnTrackEnded(s.track)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+type testReceiver struct {
+	sfu.TrackReceiver
+
+	lock      sync.Mutex
+	downTrack sfu.TrackSender
+}
+
+func (t *testReceiver) AddDownTrack(track sfu.TrackSender) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.downTrack = track
+	return nil
+}
+
+func (t *testReceiver) DeleteDownTrack(_ livekit.ParticipantID) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.downTrack = nil
+}
+
+func (t *testReceiver) getDownTrack() sfu.TrackSender {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.downTrack
+}
+
+// testDecoder returns one sample holding the payload size, or -1 for concealed frames
+type testDecoder struct{}
+
+func (testDecoder) Decode(payload []byte) ([]int16, error) {
+	if payload == nil {
+		return []int16{-1}, nil
+	}
+	return []int16{int16(len(payload))}, nil
+}
+
+type testResults struct {
+	attributes map[string]string
+}
+
+func (t *testResults) SetParticipantAttributes(_ context.Context, _ livekit.RoomName, _ livekit.ParticipantIdentity, attributes map[string]string, _ string) error {
+	t.attributes = attributes
+	return nil
+}
+
+func (t *testResults) SendData(_ context.Context, _ livekit.RoomName, _ []byte, _ string, _ []livekit.ParticipantIdentity, _ bool) error {
+	return nil
+}
+
+func writePacket(t *testing.T, track sfu.TrackSender, sn uint16, size int) {
+	err := track.WriteRTP(&buffer.ExtPacket{
+		ExtSequenceNumber: uint64(sn),
+		Packet: &rtp.Packet{
+			Header:  rtp.Header{Version: 2, SequenceNumber: sn, Timestamp: uint32(sn) * 960},
+			Payload: make([]byte, size),
+		},
+	}, 0)
+	require.NoError(t, err)
+}
+
+func TestRegister(t *testing.T) {
+	registry := NewRegistry(&testResults{}, nil)
+
+	_, err := registry.Register(Registration{Name: "transcriber"})
+	require.ErrorIs(t, err, ErrHandlerRequired)
+	_, err = registry.Register(Registration{Name: "transcriber", Format: AudioFormatPCM, OnAudio: func(*AudioFrame) {}})
+	require.ErrorIs(t, err, ErrNoDecoder)
+
+	a, err := registry.Register(Registration{Name: "transcriber", OnAudio: func(*AudioFrame) {}})
+	require.NoError(t, err)
+	_, err = registry.Register(Registration{Name: "transcriber", OnAudio: func(*AudioFrame) {}})
+	require.ErrorIs(t, err, ErrAgentExists)
+	require.Equal(t, []string{"transcriber"}, registry.Agents())
+
+	a.Close()
+	require.Empty(t, registry.Agents())
+}
+
+func TestRoomMetadata(t *testing.T) {
+	registry := NewRegistry(&testResults{}, nil)
+
+	var received []*RoomMetadata
+	_, err := registry.Register(Registration{
+		Name:           "captioner",
+		RoomFilter:     func(room livekit.RoomName) bool { return room == "studio" },
+		OnAudio:        func(*AudioFrame) {},
+		OnRoomMetadata: func(metadata *RoomMetadata) { received = append(received, metadata) },
+	})
+	require.NoError(t, err)
+	_, err = registry.Register(Registration{Name: "transcriber", OnAudio: func(*AudioFrame) {}})
+	require.NoError(t, err)
+
+	registry.RoomMetadata(&RoomMetadata{Room: "studio", Seq: 1, Kind: "layout"})
+	registry.RoomMetadata(&RoomMetadata{Room: "lobby", Seq: 1, Kind: "layout"})
+	registry.RoomMetadata(&RoomMetadata{Room: "studio", Seq: 2, Kind: "caption"})
+	require.Len(t, received, 2)
+	require.Equal(t, uint64(2), received[1].Seq)
+}
+
+func TestAgentAudio(t *testing.T) {
+	existing := &testReceiver{}
+	results := &testResults{}
+	registry := NewRegistry(results, func() []PublishedTrack {
+		return []PublishedTrack{{
+			TrackInfo: TrackInfo{Room: "other", Identity: "bob", TrackID: "TR_bob"},
+			Receiver:  existing,
+		}}
+	})
+
+	frames := make(chan *AudioFrame, 10)
+	ended := make(chan TrackInfo, 1)
+	a, err := registry.Register(Registration{
+		Name:       "transcriber",
+		Format:     AudioFormatPCM,
+		RoomFilter: func(room livekit.RoomName) bool { return room == "myroom" },
+		NewDecoder: func(TrackInfo) (Decoder, error) { return testDecoder{}, nil },
+		OnAudio:    func(frame *AudioFrame) { frames <- frame },
+		OnTrackEnded: func(track TrackInfo) {
+			ended <- track
+		},
+	})
+	require.NoError(t, err)
+	// tracks of rooms outside the filter are not subscribed
+	require.Nil(t, existing.getDownTrack())
+
+	receiver := &testReceiver{}
+	registry.TrackPublished(PublishedTrack{
+		TrackInfo: TrackInfo{Room: "myroom", Identity: "alice", TrackID: "TR_alice"},
+		Receiver:  receiver,
+	})
+	sink := receiver.getDownTrack()
+	require.NotNil(t, sink)
+	require.Len(t, a.Tracks(), 1)
+
+	writePacket(t, sink, 1, 100)
+	// retransmission
+	writePacket(t, sink, 1, 100)
+	// comfort noise after a lost packet
+	writePacket(t, sink, 3, 1)
+
+	expected := []struct {
+		pcm     int16
+		silence bool
+	}{{100, false}, {-1, false}, {1, true}}
+	for _, e := range expected {
+		select {
+		case frame := <-frames:
+			require.Equal(t, livekit.ParticipantIdentity("alice"), frame.Track.Identity)
+			require.Equal(t, []int16{e.pcm}, frame.PCM)
+			require.Equal(t, e.silence, frame.Silence)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for audio")
+		}
+	}
+
+	// unpublishing closes the down track
+	sink.Close()
+	select {
+	case track := <-ended:
+		require.Equal(t, livekit.TrackID("TR_alice"), track.TrackID)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for track end")
+	}
+	require.Nil(t, receiver.getDownTrack())
+	require.Eventually(t, func() bool { return len(a.Tracks()) == 0 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, a.SetParticipantAttributes(context.Background(), "myroom", "alice", map[string]string{"speaking": "true"}))
+	require.Equal(t, map[string]string{"speaking": "true"}, results.attributes)
+}
@@ -0,0 +1,197 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+type queuedPacket struct {
+	packet  *rtp.Packet
+	arrival time.Time
+}
+
+// subscription delivers the audio of one track to one agent
+type subscription struct {
+	agent   *Agent
+	track   TrackInfo
+	decoder Decoder
+	sink    *audioSink
+	packets chan queuedPacket
+
+	started bool
+	lastSN  uint16
+}
+
+func newSubscription(a *Agent, track PublishedTrack, decoder Decoder) *subscription {
+	s := &subscription{
+		agent:   a,
+		track:   track.TrackInfo,
+		decoder: decoder,
+		packets: make(chan queuedPacket, audioQueueSize),
+	}
+	s.sink = &audioSink{
+		id:      "AG_" + a.reg.Name + "_" + string(track.TrackID),
+		packets: s.packets,
+		done:    make(chan struct{}),
+	}
+	s.sink.onClose = func() {
+		track.Receiver.DeleteDownTrack(livekit.ParticipantID(s.sink.id))
+	}
+	go s.run()
+	return s
+}
+
+func (s *subscription) close() {
+	s.sink.Close()
+}
+
+func (s *subscription) run() {
+	defer s.agent.unsubscribed(s)
+
+	for {
+		select {
+		case <-s.sink.done:
+			return
+		case p := <-s.packets:
+			s.deliver(p)
+		}
+	}
+}
+
+func (s *subscription) deliver(p queuedPacket) {
+	frame := &AudioFrame{
+		Track:   s.track,
+		Arrival: p.arrival,
+	}
+	if s.agent.reg.Format == AudioFormatRTP {
+		frame.Packet = p.packet
+		s.agent.reg.OnAudio(frame)
+		return
+	}
+
+	if s.decoder != nil && s.started {
+		if gap := p.packet.SequenceNumber - s.lastSN - 1; gap < 0x8000 {
+			s.conceal(min(gap, maxConcealedFrames), p.arrival)
+		}
+	}
+	s.started = true
+	s.lastSN = p.packet.SequenceNumber
+	if len(p.packet.Payload) == 0 {
+		// padding only, used for probing
+		return
+	}
+
+	frame.SequenceNumber = p.packet.SequenceNumber
+	frame.Timestamp = p.packet.Timestamp
+	frame.Payload = p.packet.Payload
+	frame.Silence = len(p.packet.Payload) <= opusDTXMaxSize
+	if s.decoder != nil {
+		pcm, err := s.decoder.Decode(p.packet.Payload)
+		if err != nil {
+			s.agent.logger.Debugw("could not decode audio", "error", err, "trackID", s.track.TrackID)
+			return
+		}
+		frame.PCM = pcm
+	}
+	s.agent.reg.OnAudio(frame)
+}
+
+func (s *subscription) conceal(frames uint16, arrival time.Time) {
+	for ; frames > 0; frames-- {
+		pcm, err := s.decoder.Decode(nil)
+		if err != nil {
+			return
+		}
+		s.agent.reg.OnAudio(&AudioFrame{Track: s.track, Arrival: arrival, PCM: pcm})
+	}
+}
+
+// audioSink takes the place of a down track on the audio receiver
+type audioSink struct {
+	id      string
+	packets chan<- queuedPacket
+	onClose func()
+
+	lastSN  atomic.Uint64
+	started atomic.Bool
+	closed  atomic.Bool
+	done    chan struct{}
+}
+
+var _ sfu.TrackSender = (*audioSink)(nil)
+
+func (s *audioSink) UpTrackLayersChange()                           {}
+func (s *audioSink) UpTrackBitrateAvailabilityChange()              {}
+func (s *audioSink) UpTrackMaxPublishedLayerChange(_ int32)         {}
+func (s *audioSink) UpTrackMaxTemporalLayerSeenChange(_ int32)      {}
+func (s *audioSink) UpTrackBitrateReport(_ []int32, _ sfu.Bitrates) {}
+func (s *audioSink) UpTrackSSRCChange(_ uint32)                     {}
+func (s *audioSink) TrackInfoAvailable()                            {}
+
+func (s *audioSink) HandleRTCPSenderReportData(_ webrtc.PayloadType, _ int32, _ *buffer.RTCPSenderReportData) error {
+	return nil
+}
+
+func (s *audioSink) WriteRTP(pkt *buffer.ExtPacket, _ int32) error {
+	if s.closed.Load() {
+		return nil
+	}
+	// retransmissions arrive too late to be useful in real time
+	if s.started.Load() && pkt.ExtSequenceNumber <= s.lastSN.Load() {
+		return nil
+	}
+	s.started.Store(true)
+	s.lastSN.Store(pkt.ExtSequenceNumber)
+
+	select {
+	case s.packets <- queuedPacket{packet: pkt.Packet.Clone(), arrival: time.Now()}:
+	default:
+		// the agent is falling behind, dropping keeps the latency of the rest low
+	}
+	return nil
+}
+
+// Close is called by the receiver when the track is unpublished, or when the agent unsubscribes
+func (s *audioSink) Close() {
+	if s.closed.Swap(true) {
+		return
+	}
+	close(s.done)
+	if s.onClose != nil {
+		s.onClose()
+	}
+}
+
+func (s *audioSink) IsClosed() bool {
+	return s.closed.Load()
+}
+
+func (s *audioSink) ID() string {
+	return s.id
+}
+
+func (s *audioSink) SubscriberID() livekit.ParticipantID {
+	return livekit.ParticipantID(s.id)
+}
@@ -16,6 +16,7 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"reflect"
 	"strings"
@@ -28,8 +29,11 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/livekit/mediatransportutil/pkg/rtcconfig"
+	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 	redisLiveKit "github.com/livekit/protocol/redis"
+
+	"github.com/livekit/livekit-server/pkg/utils"
 )
 
 type CongestionControlProbeMode string
@@ -51,6 +55,7 @@ const (
 var (
 	ErrKeyFileIncorrectPermission = errors.New("key file others permissions must be set to 0")
 	ErrKeysNotSet                 = errors.New("one of key-file or keys must be provided")
+	ErrDebugKeysNotSet            = errors.New("debug.api_keys must be set when debug endpoints are enabled")
 )
 
 type Config struct {
@@ -66,15 +71,26 @@ type Config struct {
 	TURN           TURNConfig               `yaml:"turn,omitempty"`
 	Ingress        IngressConfig            `yaml:"ingress,omitempty"`
 	WebHook        WebHookConfig            `yaml:"webhook,omitempty"`
+	APIRateLimit   APIRateLimitConfig       `yaml:"api_rate_limit,omitempty"`
+	CORS           CORSConfig               `yaml:"cors,omitempty"`
+	Debug          DebugConfig              `yaml:"debug,omitempty"`
+	Recorder       RecorderConfig           `yaml:"recorder,omitempty"`
 	NodeSelector   NodeSelectorConfig       `yaml:"node_selector,omitempty"`
 	KeyFile        string                   `yaml:"key_file,omitempty"`
 	Keys           map[string]string        `yaml:"keys,omitempty"`
 	Region         string                   `yaml:"region,omitempty"`
 	SignalRelay    SignalRelayConfig        `yaml:"signal_relay,omitempty"`
+	WebTransport   WebTransportConfig       `yaml:"webtransport,omitempty"`
+	Signal         SignalConfig             `yaml:"signal,omitempty"`
 	// LogLevel is deprecated
 	LogLevel string        `yaml:"log_level,omitempty"`
 	Logging  LoggingConfig `yaml:"logging,omitempty"`
 	Limit    LimitConfig   `yaml:"limit,omitempty"`
+	Auth     AuthConfig    `yaml:"auth,omitempty"`
+	// mutual TLS to the message bus, which also relays signal traffic between nodes
+	InternalTLS InternalTLSConfig `yaml:"internal_tls,omitempty"`
+	// fault injection for resilience testing
+	Chaos ChaosConfig `yaml:"chaos,omitempty"`
 
 	Development bool `yaml:"development,omitempty"`
 }
@@ -84,6 +100,9 @@ type RTCConfig struct {
 
 	TURNServers []TURNServer `yaml:"turn_servers,omitempty"`
 
+	// probes turn_servers and stun_servers, unhealthy ones are not given to clients
+	ICEServerHealth ICEServerHealthConfig `yaml:"ice_server_health,omitempty"`
+
 	StrictACKs bool `yaml:"strict_acks,omitempty"`
 
 	// Number of packets to buffer for NACK
@@ -92,6 +111,22 @@ type RTCConfig struct {
 	// Throttle periods for pli/fir rtcp packets
 	PLIThrottle PLIThrottleConfig `yaml:"pli_throttle,omitempty"`
 
+	// limits on key frame requests and NACKs sent by subscribers
+	FeedbackThrottle FeedbackThrottleConfig `yaml:"feedback_throttle,omitempty"`
+	PacketWorkers    PacketWorkersConfig    `yaml:"packet_workers,omitempty"`
+
+	// spacing of sender and receiver reports
+	RTCP RTCPConfig `yaml:"rtcp,omitempty"`
+
+	// per interval stats exported along with the lifetime RTP stats of tracks
+	RTPStatsHistory RTPStatsHistoryConfig `yaml:"rtp_stats_history,omitempty"`
+
+	// reordering of published packets waited out before they are NACKed
+	ReorderTolerance ReorderToleranceConfig `yaml:"reorder_tolerance,omitempty"`
+
+	// cadence of transport wide congestion control feedback sent to publishers
+	TWCC TWCCConfig `yaml:"twcc,omitempty"`
+
 	CongestionControl CongestionControlConfig `yaml:"congestion_control,omitempty"`
 
 	// allow TCP and TURN/TLS fallback
@@ -105,6 +140,193 @@ type RTCConfig struct {
 
 	// force a reconnect on a data channel error
 	ReconnectOnDataChannelError *bool `yaml:"reconnect_on_data_channel_error,omitempty"`
+
+	// order in which subscribed media is restored when a subscriber reconnects
+	Resume ResumeConfig `yaml:"resume,omitempty"`
+
+	// dedicated ports or interfaces for rooms by room name prefix, the longest matching prefix wins
+	RoomTransports map[string]RoomTransportConfig `yaml:"room_transports,omitempty"`
+
+	// limits the candidates advertised to clients
+	CandidateFilter CandidateFilterConfig `yaml:"candidate_filter,omitempty"`
+
+	DTLS DTLSConfig `yaml:"dtls,omitempty"`
+
+	// candidate preference on dual-stack hosts
+	AddressFamily AddressFamilyConfig `yaml:"address_family,omitempty"`
+
+	// batched socket I/O for the udp_port mux, writes are batched according to batch_io
+	UDPBatch UDPBatchConfig `yaml:"udp_batch,omitempty"`
+
+	// watches UDP sockets for dropped packets and grows their buffers, linux only
+	SocketBuffers SocketBuffersConfig `yaml:"socket_buffers,omitempty"`
+
+	// RTP header extensions left out of subscriber connections
+	SubscriberHeaderExtensions SubscriberHeaderExtensionsConfig `yaml:"subscriber_header_extensions,omitempty"`
+
+	// degrades media of matching transports, for testing only
+	Impairments []ImpairmentConfig `yaml:"impairments,omitempty"`
+}
+
+// SubscriberHeaderExtensionsConfig drops RTP header extensions from the ones offered on subscriber connections,
+// by URI, to save their bytes in every packet on constrained links. Dropping the extension used by the
+// configured bandwidth estimation, transport-cc or abs-send-time, leaves subscribers without estimates.
+type SubscriberHeaderExtensionsConfig struct {
+	// extensions dropped for all subscribers
+	Drop []string `yaml:"drop,omitempty"`
+	// extensions dropped for subscribers whose client info matches a rule
+	Clients []HeaderExtensionsClientRule `yaml:"clients,omitempty"`
+}
+
+type HeaderExtensionsClientRule struct {
+	// expression on the client info, e.g. c.browser == "safari" && c.os == "ios"
+	Match string   `yaml:"match,omitempty"`
+	Drop  []string `yaml:"drop,omitempty"`
+}
+
+func (c *SubscriberHeaderExtensionsConfig) validate() error {
+	for _, rule := range c.Clients {
+		if rule.Match == "" {
+			return errors.New("subscriber_header_extensions client rules require a match expression")
+		}
+	}
+	return nil
+}
+
+// ImpairmentConfig injects loss, reordering, duplication and jitter into the RTP of matching transports so that
+// retransmission, FEC and bandwidth allocation can be exercised deterministically. Never enable it in production.
+type ImpairmentConfig struct {
+	// participants whose identity starts with the prefix, all when empty
+	IdentityPrefix string `yaml:"identity_prefix,omitempty"`
+	// publisher impairs media received from participants, subscriber media sent to them, both when empty
+	Transport string `yaml:"transport,omitempty"`
+	// probabilities of a packet being dropped, duplicated or swapped with the next, between 0 and 1
+	Loss      float64 `yaml:"loss,omitempty"`
+	Duplicate float64 `yaml:"duplicate,omitempty"`
+	Reorder   float64 `yaml:"reorder,omitempty"`
+	// maximum random delay of a packet
+	Jitter time.Duration `yaml:"jitter,omitempty"`
+	// seeds the decisions of each stream, combined with its SSRC, so that runs impair the same packets
+	Seed int64 `yaml:"seed,omitempty"`
+}
+
+func (c *ImpairmentConfig) validate() error {
+	if c.Transport != "" && c.Transport != "publisher" && c.Transport != "subscriber" {
+		return fmt.Errorf("invalid impairment transport %q, expected publisher or subscriber", c.Transport)
+	}
+	for _, p := range []float64{c.Loss, c.Duplicate, c.Reorder} {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("invalid impairment probability %v, expected a value between 0 and 1", p)
+		}
+	}
+	if c.Jitter < 0 {
+		return errors.New("impairment jitter cannot be negative")
+	}
+	return nil
+}
+
+// SocketBuffersConfig monitors the UDP sockets of the process for packets dropped by the kernel
+// because their buffers were full. Drops are invisible to RTP stats until they show up as loss.
+type SocketBuffersConfig struct {
+	Enabled       bool          `yaml:"enabled,omitempty"`
+	CheckInterval time.Duration `yaml:"check_interval,omitempty"`
+	// buffers of sockets dropping packets are doubled up to this size in bytes, not tuned when 0.
+	// raising them beyond net.core.rmem_max/wmem_max requires CAP_NET_ADMIN
+	MaxBufferSize int `yaml:"max_buffer_size,omitempty"`
+	// consecutive checks with drops before warning
+	WarnAfter int `yaml:"warn_after,omitempty"`
+}
+
+type UDPBatchConfig struct {
+	// packets read per recvmmsg call, reads are not batched when 0
+	ReadBatchSize int `yaml:"read_batch_size,omitempty"`
+	// coalesce consecutive packets to the same destination with UDP GSO, linux only, requires batch_io.batch_size
+	GSO bool `yaml:"gso,omitempty"`
+}
+
+func (c *UDPBatchConfig) Enabled() bool {
+	return c.ReadBatchSize > 0 || c.GSO
+}
+
+type AddressFamilyConfig struct {
+	// prefer_v6, prefer_v4 or happy_eyeballs, candidate priorities are left as they are when empty
+	Preference string `yaml:"preference,omitempty"`
+	// prefixes of IPv6 addresses embedding IPv4 addresses, 64:ff9b::/96 when empty
+	NAT64Prefixes []string `yaml:"nat64_prefixes,omitempty"`
+}
+
+type DTLSConfig struct {
+	// SRTP protection profiles, in order of preference: aes128_cm_hmac_sha1_80, aead_aes_128_gcm, aead_aes_256_gcm
+	SRTPProfiles []string `yaml:"srtp_profiles,omitempty"`
+	// curves for the key exchange, in order of preference: x25519, p256, p384
+	EllipticCurves []string `yaml:"elliptic_curves,omitempty"`
+	// certificate shared by all peer connections, one is generated per connection when not set.
+	// its key type selects the cipher suites, TLS_ECDHE_ECDSA_* for ECDSA keys and TLS_ECDHE_RSA_* for RSA keys
+	CertFile string `yaml:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty"`
+	// refuse to start when AES-GCM profiles are allowed but the CPU has no AES instructions
+	RequireAESHardware bool `yaml:"require_aes_hardware,omitempty"`
+}
+
+type CandidateFilterConfig struct {
+	// address families to advertise, ipv4 and/or ipv6, all when empty
+	AddressFamilies []string `yaml:"address_families,omitempty"`
+	// candidate types to advertise, host and/or srflx, all when empty
+	CandidateTypes []string `yaml:"candidate_types,omitempty"`
+	// do not advertise private, loopback and link-local addresses
+	ExcludePrivate bool `yaml:"exclude_private,omitempty"`
+	// rooms, by name prefix, in which clients are required to connect through TURN
+	ForceRelayRooms []string `yaml:"force_relay_rooms,omitempty"`
+}
+
+func (c *CandidateFilterConfig) IsForceRelayRoom(roomName string) bool {
+	for _, prefix := range c.ForceRelayRooms {
+		if strings.HasPrefix(roomName, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// RoomTransportConfig pins rooms to their own UDP ports or network interfaces. Each entry opens its own
+// listeners, so its ports must not overlap with the rtc ports or those of other entries.
+type RoomTransportConfig struct {
+	UDPPort           rtcconfig.PortRange `yaml:"udp_port,omitempty"`
+	ICEPortRangeStart uint32              `yaml:"port_range_start,omitempty"`
+	ICEPortRangeEnd   uint32              `yaml:"port_range_end,omitempty"`
+	// ICE-TCP is disabled for the rooms unless set
+	TCPPort    uint32                     `yaml:"tcp_port,omitempty"`
+	Interfaces rtcconfig.InterfacesConfig `yaml:"interfaces,omitempty"`
+	IPs        rtcconfig.IPsConfig        `yaml:"ips,omitempty"`
+}
+
+// RoomTransportForRoom returns the key of the room transport used by the room, empty when it uses the default
+func (c *RTCConfig) RoomTransportForRoom(roomName string) string {
+	key := ""
+	for prefix := range c.RoomTransports {
+		if len(prefix) > len(key) && strings.HasPrefix(roomName, prefix) {
+			key = prefix
+		}
+	}
+	return key
+}
+
+// ApplyTo returns the rtc config with the room transport's ports and interfaces
+func (t *RoomTransportConfig) ApplyTo(rtcConf rtcconfig.RTCConfig) (rtcconfig.RTCConfig, error) {
+	if !t.UDPPort.Valid() && (t.ICEPortRangeStart == 0 || t.ICEPortRangeEnd == 0) {
+		return rtcConf, errors.New("room transport requires udp_port or port_range_start/port_range_end")
+	}
+	rtcConf.UDPPort = t.UDPPort
+	rtcConf.ICEPortRangeStart = t.ICEPortRangeStart
+	rtcConf.ICEPortRangeEnd = t.ICEPortRangeEnd
+	rtcConf.TCPPort = t.TCPPort
+	if len(t.Interfaces.Includes) != 0 || len(t.Interfaces.Excludes) != 0 {
+		rtcConf.Interfaces = t.Interfaces
+	}
+	if len(t.IPs.Includes) != 0 || len(t.IPs.Excludes) != 0 {
+		rtcConf.IPs = t.IPs
+	}
+	return rtcConf, nil
 }
 
 type TURNServer struct {
@@ -115,12 +337,157 @@ type TURNServer struct {
 	Credential string `yaml:"credential,omitempty"`
 }
 
+type ICEServerHealthConfig struct {
+	Enabled  bool          `yaml:"enabled,omitempty"`
+	Interval time.Duration `yaml:"interval,omitempty"`
+	Timeout  time.Duration `yaml:"timeout,omitempty"`
+	// consecutive failed probes before a server is considered unhealthy
+	FailureThreshold int `yaml:"failure_threshold,omitempty"`
+	// report the node as not ready while all of the probed servers are unhealthy
+	RequiredForReadiness bool `yaml:"required_for_readiness,omitempty"`
+}
+
 type PLIThrottleConfig struct {
 	LowQuality  time.Duration `yaml:"low_quality,omitempty"`
 	MidQuality  time.Duration `yaml:"mid_quality,omitempty"`
 	HighQuality time.Duration `yaml:"high_quality,omitempty"`
 }
 
+// PacketWorkersConfig sends subscriber packets on a bounded pool of workers shared by all connections
+// instead of on the goroutines forwarding from publishers. Each connection is served by a single worker.
+type PacketWorkersConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// number of workers, defaults to the number of CPUs
+	Workers int `yaml:"workers,omitempty"`
+	// packets queued per connection before the oldest are dropped
+	QueueSize int `yaml:"queue_size,omitempty"`
+}
+
+// ResumeConfig orders the subscribed tracks restored after a subscriber transport interruption so that the
+// most important media returns first. Priority lists track sources (e.g. screen_share, camera) and
+// active_speaker, which matches the video of publishers that are currently speaking. Tracks not matching
+// any entry are restored last. Key frame requests for video that cannot be replayed are spaced by
+// KeyFrameStagger so that publishers are not asked for all key frames at once.
+type ResumeConfig struct {
+	Priority        []string      `yaml:"priority,omitempty"`
+	KeyFrameStagger time.Duration `yaml:"key_frame_stagger,omitempty"`
+}
+
+// FeedbackThrottleConfig protects publishers from subscribers sending pathological rates of RTCP feedback.
+// A down track exceeding a limit is first throttled, then has its feedback ignored, and steps back down
+// one level after each DecayInterval without a violation.
+type FeedbackThrottleConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// PLI/FIR packets allowed per second per down track
+	KeyFrameRequestsPerSecond int `yaml:"key_frame_requests_per_second,omitempty"`
+	// NACKed sequence numbers allowed per second per down track
+	NacksPerSecond int `yaml:"nacks_per_second,omitempty"`
+	// minimum interval between key frame requests forwarded while throttled
+	ThrottledKeyFrameInterval time.Duration `yaml:"throttled_key_frame_interval,omitempty"`
+	// minimum interval between key frame requests forwarded while ignored, so that a subscriber can always
+	// recover from decoding errors
+	IgnoredKeyFrameInterval time.Duration `yaml:"ignored_key_frame_interval,omitempty"`
+	// time without a violation before stepping down one level
+	DecayInterval time.Duration `yaml:"decay_interval,omitempty"`
+}
+
+// RTCPConfig spaces the RTCP reports the SFU sends, following RFC 3550 section 6.2. Intervals are stretched when
+// reports would use more than BandwidthFraction of the media bitrate, and randomized by Jitter so that the reports
+// of the many tracks of a large room don't synchronize into bursts.
+type RTCPConfig struct {
+	// minimum interval between sender reports of a track sent to a subscriber
+	SenderReportInterval time.Duration `yaml:"sender_report_interval,omitempty"`
+	// minimum interval between receiver reports of a track received from a publisher
+	ReceiverReportInterval time.Duration `yaml:"receiver_report_interval,omitempty"`
+	// share of a track's bitrate its reports may use, 0 disables bandwidth based intervals
+	BandwidthFraction float64 `yaml:"bandwidth_fraction,omitempty"`
+	// each interval is drawn from [1-jitter, 1+jitter] times its nominal value, between 0 and 1
+	Jitter float64 `yaml:"jitter,omitempty"`
+}
+
+func (c *RTCPConfig) validate() error {
+	if c.SenderReportInterval <= 0 || c.ReceiverReportInterval <= 0 {
+		return errors.New("rtcp report intervals must be positive")
+	}
+	if c.BandwidthFraction < 0 || c.BandwidthFraction > 1 {
+		return fmt.Errorf("invalid rtcp bandwidth_fraction %v, expected a value between 0 and 1", c.BandwidthFraction)
+	}
+	if c.Jitter < 0 || c.Jitter >= 1 {
+		return fmt.Errorf("invalid rtcp jitter %v, expected a value between 0 and 1", c.Jitter)
+	}
+	return nil
+}
+
+// RTPStatsHistoryConfig keeps the RTP stats of consecutive intervals of each published and subscribed track,
+// exported to analytics along with the lifetime stats when the track ends, for billing and quality graphs
+// with a finer granularity than whole tracks
+type RTPStatsHistoryConfig struct {
+	// length of an interval, 0 disables the history
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// number of most recent intervals kept
+	Size int `yaml:"size,omitempty"`
+}
+
+func (c *RTPStatsHistoryConfig) validate() error {
+	if c.Interval < 0 || c.Size < 0 {
+		return errors.New("rtp_stats_history interval and size cannot be negative")
+	}
+	if c.Interval > 0 && c.Size == 0 {
+		return errors.New("rtp_stats_history size must be set with an interval")
+	}
+	return nil
+}
+
+// ReorderToleranceConfig sets how much reordering the receive buffers of published tracks of each kind wait out
+// before NACKing missing packets. Publishers on high RTT paths, such as satellite or cross-continent links, see more
+// reordering and would otherwise be asked to retransmit packets that are only late
+type ReorderToleranceConfig struct {
+	Audio ReorderTolerance `yaml:"audio,omitempty"`
+	Video ReorderTolerance `yaml:"video,omitempty"`
+}
+
+type ReorderTolerance struct {
+	// time a missing packet is waited for, 0 for the default of 20ms
+	Time time.Duration `yaml:"time,omitempty"`
+	// packets received after a missing one before it is NACKed
+	Packets int `yaml:"packets,omitempty"`
+}
+
+func (c *ReorderToleranceConfig) validate() error {
+	for _, t := range []ReorderTolerance{c.Audio, c.Video} {
+		if t.Time < 0 || t.Packets < 0 {
+			return errors.New("reorder_tolerance time and packets cannot be negative")
+		}
+	}
+	return nil
+}
+
+// TWCCConfig sets how often publishers receive transport wide congestion control feedback. Frequent feedback lets
+// their delay based estimators react faster, while rooms of audio only publishers can do with much less.
+type TWCCConfig struct {
+	// feedback is sent once this long has passed since the previous one
+	FeedbackInterval time.Duration `yaml:"feedback_interval,omitempty"`
+	// feedback is sent at the end of a video frame once this long has passed since the previous one, 0 disables it
+	FeedbackIntervalAfterMarker time.Duration `yaml:"feedback_interval_after_marker,omitempty"`
+	// feedback is held back until more than this many packets were received
+	MinPacketsPerFeedback int `yaml:"min_packets_per_feedback,omitempty"`
+	// feedback is sent as soon as more than this many packets were received
+	MaxPacketsPerFeedback int `yaml:"max_packets_per_feedback,omitempty"`
+}
+
+func (c *TWCCConfig) validate() error {
+	if c.FeedbackInterval <= 0 {
+		return errors.New("twcc feedback_interval must be positive")
+	}
+	if c.FeedbackIntervalAfterMarker < 0 {
+		return errors.New("twcc feedback_interval_after_marker cannot be negative")
+	}
+	if c.MinPacketsPerFeedback < 0 || c.MaxPacketsPerFeedback < c.MinPacketsPerFeedback {
+		return fmt.Errorf("invalid twcc packets per feedback, min %d, max %d", c.MinPacketsPerFeedback, c.MaxPacketsPerFeedback)
+	}
+	return nil
+}
+
 type CongestionControlProbeConfig struct {
 	BaseInterval  time.Duration `yaml:"base_interval,omitempty"`
 	BackoffFactor float64       `yaml:"backoff_factor,omitempty"`
@@ -163,6 +530,34 @@ type CongestionControlConfig struct {
 	ChannelObserverProbeConfig       CongestionControlChannelObserverConfig `yaml:"channel_observer_probe_config,omitempty"`
 	ChannelObserverNonProbeConfig    CongestionControlChannelObserverConfig `yaml:"channel_observer_non_probe_config,omitempty"`
 	DisableEstimationUnmanagedTracks bool                                   `yaml:"disable_etimation_unmanaged_tracks,omitempty"`
+	// receive side estimation of publisher uplinks
+	Uplink CongestionControlUplinkConfig `yaml:"uplink,omitempty"`
+	// padding probe when a subscriber connects, seeding the channel capacity tracks are first allocated with
+	JoinProbe CongestionControlJoinProbeConfig `yaml:"join_probe,omitempty"`
+}
+
+// CongestionControlJoinProbeConfig controls probing a subscriber's downlink with padding right after it connects,
+// holding back the allocation of its video tracks until the probe is done
+type CongestionControlJoinProbeConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// bitrate the probe ramps up to, in bps
+	GoalBps int64 `yaml:"goal_bps,omitempty"`
+	// how long padding is sent for
+	Duration time.Duration `yaml:"duration,omitempty"`
+	// how long to wait for a video track to carry the padding after connecting
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// CongestionControlUplinkConfig controls estimating a publisher's uplink from the media received, asking the
+// publisher with REMB to lower its encode bitrate while the uplink is congested
+type CongestionControlUplinkConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// how often the estimate is updated and sent
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// fraction of packets lost in an interval at which the uplink is considered congested
+	LossThreshold float64 `yaml:"loss_threshold,omitempty"`
+	// lowest bitrate publishers are asked to lower to, in bps
+	MinBitrate int64 `yaml:"min_bitrate,omitempty"`
 }
 
 type AudioConfig struct {
@@ -178,6 +573,16 @@ type AudioConfig struct {
 	SmoothIntervals uint32 `yaml:"smooth_intervals,omitempty"`
 	// enable red encoding downtrack for opus only audio up track
 	ActiveREDEncoding bool `yaml:"active_red_encoding,omitempty"`
+	// server side mixing for SIP participants
+	SIPMix SIPMixConfig `yaml:"sip_mix,omitempty"`
+}
+
+type SIPMixConfig struct {
+	// participants whose identity starts with the prefix receive one mix of everyone else instead of separate
+	// tracks, requires an Opus codec to be registered with the server
+	IdentityPrefix string `yaml:"identity_prefix,omitempty"`
+	// Ogg Opus file played to SIP participants on hold, in a loop
+	HoldMusic string `yaml:"hold_music,omitempty"`
 }
 
 type StreamTrackerPacketConfig struct {
@@ -206,11 +611,91 @@ type PlayoutDelayConfig struct {
 	Enabled bool `yaml:"enabled,omitempty"`
 	Min     int  `yaml:"min,omitempty"`
 	Max     int  `yaml:"max,omitempty"`
+	// bounds replacing min/max for tracks of a source, keyed by source name (camera, screen_share)
+	Sources map[string]PlayoutDelayBounds `yaml:"sources,omitempty"`
+}
+
+type PlayoutDelayBounds struct {
+	Min int `yaml:"min,omitempty"`
+	Max int `yaml:"max,omitempty"`
+}
+
+func (c *PlayoutDelayConfig) validate() error {
+	for source, bounds := range c.Sources {
+		if _, ok := livekit.TrackSource_value[strings.ToUpper(source)]; !ok {
+			return fmt.Errorf("unknown track source %q", source)
+		}
+		if bounds.Min < 0 || bounds.Max < 0 || (bounds.Max > 0 && bounds.Min > bounds.Max) {
+			return fmt.Errorf("invalid bounds for track source %s: min %d, max %d", source, bounds.Min, bounds.Max)
+		}
+	}
+	return nil
 }
 
 type VideoConfig struct {
 	DynacastPauseDelay time.Duration        `yaml:"dynacast_pause_delay,omitempty"`
 	StreamTracker      StreamTrackersConfig `yaml:"stream_tracker,omitempty"`
+	// a key frame is requested from a published layer going longer than this without one, 0 to disable
+	MaxKeyFrameInterval time.Duration       `yaml:"max_key_frame_interval,omitempty"`
+	FrameAssembly       FrameAssemblyConfig `yaml:"frame_assembly,omitempty"`
+	// subscribers receiving a single spatial layer of SVC video
+	SingleLayerSVC SingleLayerSVCConfig `yaml:"single_layer_svc,omitempty"`
+	// publishers whose layers are requested according to what subscribers are forwarded
+	AllocationDemand AllocationDemandConfig `yaml:"allocation_demand,omitempty"`
+}
+
+// SingleLayerSVCConfig selects subscribers that receive a single operating point of VP9 and AV1 SVC video, without
+// the dependency descriptor, for decoders handling only single layer streams well
+type SingleLayerSVCConfig struct {
+	// enabled for participants whose identity starts with one of these prefixes
+	IdentityPrefixes []string `yaml:"identity_prefixes,omitempty"`
+}
+
+// EnabledFor returns true when identity receives single layer SVC video
+func (c *SingleLayerSVCConfig) EnabledFor(identity livekit.ParticipantIdentity) bool {
+	for _, prefix := range c.IdentityPrefixes {
+		if strings.HasPrefix(string(identity), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllocationDemandConfig selects publishers, typically ingress and transcoders, whose simulcast layers are
+// requested according to what the stream allocators of subscribers are able to forward rather than what
+// subscribers ask for, so that they stop encoding layers no subscriber receives
+type AllocationDemandConfig struct {
+	// enabled for tracks published by participants whose identity starts with one of these prefixes
+	IdentityPrefixes []string `yaml:"identity_prefixes,omitempty"`
+}
+
+// EnabledFor returns true when layers of tracks published by identity follow subscriber allocations
+func (c *AllocationDemandConfig) EnabledFor(identity livekit.ParticipantIdentity) bool {
+	for _, prefix := range c.IdentityPrefixes {
+		if strings.HasPrefix(string(identity), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// FrameAssemblyConfig holds back packets of a video track until their frame is complete, absorbing
+// reordering on jittery publisher uplinks at the cost of added latency
+type FrameAssemblyConfig struct {
+	// longest a packet is held back waiting for the rest of its frame
+	MaxDelay time.Duration `yaml:"max_delay,omitempty"`
+	// enabled for tracks published by participants whose identity starts with one of these prefixes
+	IdentityPrefixes []string `yaml:"identity_prefixes,omitempty"`
+}
+
+// EnabledFor returns true when frame assembly applies to tracks published by identity
+func (c *FrameAssemblyConfig) EnabledFor(identity livekit.ParticipantIdentity) bool {
+	for _, prefix := range c.IdentityPrefixes {
+		if strings.HasPrefix(string(identity), prefix) {
+			return true
+		}
+	}
+	return false
 }
 
 type RoomConfig struct {
@@ -222,6 +707,444 @@ type RoomConfig struct {
 	EnableRemoteUnmute bool               `yaml:"enable_remote_unmute,omitempty"`
 	MaxMetadataSize    uint32             `yaml:"max_metadata_size,omitempty"`
 	PlayoutDelay       PlayoutDelayConfig `yaml:"playout_delay,omitempty"`
+	// JSON schemas that room and participant metadata must conform to
+	MetadataSchema MetadataSchemaConfig `yaml:"metadata_schema,omitempty"`
+	// named sets of room settings, applied to rooms whose names match one of the preset's prefixes
+	Presets map[string]RoomPresetConfig `yaml:"presets,omitempty"`
+	// timing of notifications for rooms with an open/close window
+	Schedule RoomScheduleConfig `yaml:"schedule,omitempty"`
+	// how participants are warned before a room is closed with them in it
+	Close RoomCloseConfig `yaml:"close,omitempty"`
+	// how long participants are banned from a room when a ban doesn't specify it
+	BanDuration time.Duration `yaml:"ban_duration,omitempty"`
+	// end-to-end encryption defaults of rooms
+	E2EE RoomE2EEConfig `yaml:"e2ee,omitempty"`
+	// networks publishers may send media from
+	PublisherIPs PublisherIPsConfig `yaml:"publisher_ips,omitempty"`
+	// resources a single room may use on a node
+	Budget RoomBudgetConfig `yaml:"budget,omitempty"`
+	// how long published tracks may go without media before subscribers are told they stalled
+	TrackInactivity TrackInactivityConfig `yaml:"track_inactivity,omitempty"`
+	// tracks whose losses are not retransmitted
+	NACK NACKPolicy `yaml:"nack,omitempty"`
+	// who may write participant attributes
+	Attributes ParticipantAttributesConfig `yaml:"attributes,omitempty"`
+	// offer and stamp abs-send-time on subscriber connections even when send side bandwidth estimation is used,
+	// for clients that estimate bandwidth on the receive side
+	AbsSendTime bool `yaml:"abs_send_time,omitempty"`
+	// audio is only forwarded from participants who are speaking
+	SpeakerAudio SpeakerAudioConfig `yaml:"speaker_audio,omitempty"`
+	// how long unmuted tracks may carry silent audio or static video before publishers are advised of it
+	TrackSilence TrackSilenceConfig `yaml:"track_silence,omitempty"`
+}
+
+// SpeakerAudioConfig forwards audio only from participants who are speaking, for rooms such as webinars with hundreds
+// of potential speakers. Audio tracks are subscribed as usual, so their transports are negotiated ahead of time, but
+// their down tracks are paused while the publisher's audio level stays below audio.active_level. Resuming does not
+// need a negotiation, forwarding starts again with the next packet after voice activity is detected.
+type SpeakerAudioConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// how often voice activity is checked, defaults to 20ms
+	CheckInterval time.Duration `yaml:"check_interval,omitempty"`
+	// how long audio keeps being forwarded after the publisher was last heard, defaults to 1s
+	Hangover time.Duration `yaml:"hangover,omitempty"`
+}
+
+func (c *SpeakerAudioConfig) validate() error {
+	if c.CheckInterval < 0 || c.Hangover < 0 {
+		return errors.New("speaker_audio check_interval and hangover cannot be negative")
+	}
+	return nil
+}
+
+// NACKPolicy turns off retransmission of lost packets, for latency sensitive rooms where a late packet is as good
+// as a lost one. NACK is then not negotiated, no retransmission history is kept for the tracks, and audio losses
+// are only recovered by opus in-band FEC and RED.
+type NACKPolicy struct {
+	// all tracks of the room, NACK is removed from the SDP of its participants
+	Disabled bool `yaml:"disabled,omitempty"`
+	// tracks of these sources, e.g. microphone or screen_share_audio
+	DisabledSources []string `yaml:"disabled_sources,omitempty"`
+}
+
+// DisabledFor returns whether retransmissions are off for tracks of source
+func (p *NACKPolicy) DisabledFor(source livekit.TrackSource) bool {
+	if p.Disabled {
+		return true
+	}
+	for _, s := range p.DisabledSources {
+		if strings.EqualFold(s, source.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *NACKPolicy) validate() error {
+	for _, source := range p.DisabledSources {
+		if _, ok := livekit.TrackSource_value[strings.ToUpper(source)]; !ok {
+			return fmt.Errorf("unknown track source %q", source)
+		}
+	}
+	return nil
+}
+
+// RoomBudgetConfig bounds the approximate resources of a single room so that it cannot threaten the stability
+// of the node hosting it. Zero disables a limit
+type RoomBudgetConfig struct {
+	// packet buffers of published tracks and history of down tracks
+	MemoryBytes int64 `yaml:"memory_bytes,omitempty"`
+	// CPU cores, the room's share of the node's CPU usage by packets forwarded
+	CPU float64 `yaml:"cpu,omitempty"`
+	// fraction of a budget at which a warning is logged
+	WarningThreshold float64 `yaml:"warning_threshold,omitempty"`
+	// refuse new tracks while over budget
+	RefuseTracks bool `yaml:"refuse_tracks,omitempty"`
+	// limit subscribers to the lowest video quality while over budget
+	DegradeVideo  bool          `yaml:"degrade_video,omitempty"`
+	CheckInterval time.Duration `yaml:"check_interval,omitempty"`
+}
+
+func (c *RoomBudgetConfig) Enabled() bool {
+	return c.MemoryBytes > 0 || c.CPU > 0
+}
+
+func (c *RoomBudgetConfig) validate() error {
+	if c.MemoryBytes < 0 || c.CPU < 0 {
+		return errors.New("budget memory_bytes and cpu cannot be negative")
+	}
+	if c.WarningThreshold < 0 || c.WarningThreshold > 1 {
+		return fmt.Errorf("invalid budget warning_threshold %v, expected a fraction between 0 and 1", c.WarningThreshold)
+	}
+	if c.Enabled() && c.CheckInterval <= 0 {
+		return errors.New("budget check_interval must be positive")
+	}
+	return nil
+}
+
+// TrackInactivityConfig holds the time an unmuted track of each kind may go without media before it is
+// considered stalled, 0 disables the check for that kind
+type TrackInactivityConfig struct {
+	Audio         time.Duration `yaml:"audio,omitempty"`
+	Video         time.Duration `yaml:"video,omitempty"`
+	CheckInterval time.Duration `yaml:"check_interval,omitempty"`
+}
+
+func (c *TrackInactivityConfig) Enabled() bool {
+	return c.Audio > 0 || c.Video > 0
+}
+
+func (c *TrackInactivityConfig) validate() error {
+	if c.Audio < 0 || c.Video < 0 {
+		return errors.New("track_inactivity audio and video cannot be negative")
+	}
+	if c.Enabled() && c.CheckInterval <= 0 {
+		return errors.New("track_inactivity check_interval must be positive")
+	}
+	return nil
+}
+
+// TrackSilenceConfig holds the time an unmuted track of each kind may carry silent audio, or static video, before
+// its publisher is advised it may have meant to mute it or may be talking into a muted device, 0 disables the check
+// for that kind. Audio is silent while its level stays below audio.active_level, video is static while its average
+// frame size over the whole period stays below StaticFrameBytes, as frames of a black or frozen picture encode to
+// almost nothing
+type TrackSilenceConfig struct {
+	Audio            time.Duration `yaml:"audio,omitempty"`
+	Video            time.Duration `yaml:"video,omitempty"`
+	StaticFrameBytes uint32        `yaml:"static_frame_bytes,omitempty"`
+	CheckInterval    time.Duration `yaml:"check_interval,omitempty"`
+}
+
+func (c *TrackSilenceConfig) Enabled() bool {
+	return c.Audio > 0 || c.Video > 0
+}
+
+func (c *TrackSilenceConfig) validate() error {
+	if c.Audio < 0 || c.Video < 0 {
+		return errors.New("track_silence audio and video cannot be negative")
+	}
+	if c.Enabled() && c.CheckInterval <= 0 {
+		return errors.New("track_silence check_interval must be positive")
+	}
+	if c.Video > 0 && c.StaticFrameBytes == 0 {
+		return errors.New("track_silence static_frame_bytes must be positive when video is checked")
+	}
+	return nil
+}
+
+// ParticipantAttributesConfig holds who may write each participant attribute key: self for the participant
+// itself, moderator for participants with a roomAdmin grant, or server for the server API only.
+// Moderators may write keys open to the participant, and the server API may write any key.
+type ParticipantAttributesConfig struct {
+	// permission of keys without an entry in Permissions. default: self
+	DefaultPermission string `yaml:"default_permission,omitempty"`
+	// permission by key, a key ending with * applies to all keys with that prefix
+	Permissions map[string]string `yaml:"permissions,omitempty"`
+	// limits on the attributes of a participant, 0 for no limit
+	MaxKeys      uint32 `yaml:"max_keys,omitempty"`
+	MaxKeySize   uint32 `yaml:"max_key_size,omitempty"`
+	MaxValueSize uint32 `yaml:"max_value_size,omitempty"`
+}
+
+func (c *ParticipantAttributesConfig) validate() error {
+	if !isAttributePermission(c.DefaultPermission) {
+		return fmt.Errorf("invalid attributes default_permission %q, expected self, moderator or server", c.DefaultPermission)
+	}
+	for key, permission := range c.Permissions {
+		if !isAttributePermission(permission) {
+			return fmt.Errorf("invalid attributes permission %q for key %s, expected self, moderator or server", permission, key)
+		}
+	}
+	return nil
+}
+
+func isAttributePermission(permission string) bool {
+	switch permission {
+	case "", "self", "moderator", "server":
+		return true
+	}
+	return false
+}
+
+// PublisherIPsConfig restricts the addresses publishers connect their transport from.
+// entries are CIDRs or single addresses
+type PublisherIPsConfig struct {
+	// when not empty, publishers must connect from one of these networks
+	Allow []string `yaml:"allow,omitempty"`
+	// publishers connecting from these networks are rejected, even when allowed
+	Deny []string `yaml:"deny,omitempty"`
+}
+
+func (c *PublisherIPsConfig) IsEmpty() bool {
+	return len(c.Allow) == 0 && len(c.Deny) == 0
+}
+
+// Networks parses the allow and deny lists
+func (c *PublisherIPsConfig) Networks() (allow []*net.IPNet, deny []*net.IPNet, err error) {
+	parse := func(entries []string) ([]*net.IPNet, error) {
+		networks := make([]*net.IPNet, 0, len(entries))
+		for _, entry := range entries {
+			if !strings.Contains(entry, "/") {
+				ip := net.ParseIP(entry)
+				if ip == nil {
+					return nil, fmt.Errorf("invalid address %q", entry)
+				}
+				bits := 8 * net.IPv6len
+				if ip4 := ip.To4(); ip4 != nil {
+					ip, bits = ip4, 8*net.IPv4len
+				}
+				networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+				continue
+			}
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, err
+			}
+			networks = append(networks, network)
+		}
+		return networks, nil
+	}
+
+	if allow, err = parse(c.Allow); err != nil {
+		return
+	}
+	deny, err = parse(c.Deny)
+	return
+}
+
+type RoomE2EEConfig struct {
+	// reject publishers of unencrypted media, rooms can be changed through the admin API
+	Required bool `yaml:"required,omitempty"`
+	// number of keys clients keep, key indexes in rotation hints wrap around at this size
+	KeyRingSize int `yaml:"key_ring_size,omitempty"`
+}
+
+type RoomScheduleConfig struct {
+	// how long before a scheduled room opens to send the room_starting_soon webhook
+	StartingSoonNotice time.Duration `yaml:"starting_soon_notice,omitempty"`
+	// how long before a scheduled room closes participants are notified
+	CloseGracePeriod time.Duration `yaml:"close_grace_period,omitempty"`
+}
+
+type RoomCloseConfig struct {
+	// how long participants stay connected after their room is deleted through the API, 0 disconnects them at once.
+	// joins are refused in the meantime
+	DeleteGracePeriod time.Duration `yaml:"delete_grace_period,omitempty"`
+	// remaining times before a room closes at which participants receive a room_closing message,
+	// in addition to the one sent when the countdown starts
+	CountdownNotices []time.Duration `yaml:"countdown_notices,omitempty"`
+}
+
+type RoomPresetConfig struct {
+	// rooms with names starting with any of these prefixes use the preset, the longest matching prefix wins
+	RoomPrefixes    []string            `yaml:"room_prefixes,omitempty"`
+	EnabledCodecs   []CodecSpec         `yaml:"enabled_codecs,omitempty"`
+	MaxParticipants uint32              `yaml:"max_participants,omitempty"`
+	EmptyTimeout    uint32              `yaml:"empty_timeout,omitempty"`
+	PlayoutDelay    *PlayoutDelayConfig `yaml:"playout_delay,omitempty"`
+	ScreenShare     *ScreenSharePolicy  `yaml:"screen_share,omitempty"`
+	Egress          RoomPresetEgress    `yaml:"egress,omitempty"`
+	// participants connect through TURN only, like rooms in rtc.candidate_filter.force_relay_rooms
+	ForceRelay bool `yaml:"force_relay,omitempty"`
+	// replaces room.publisher_ips
+	PublisherIPs *PublisherIPsConfig `yaml:"publisher_ips,omitempty"`
+	// replaces room.nack
+	NACK *NACKPolicy `yaml:"nack,omitempty"`
+	// replaces room.abs_send_time
+	AbsSendTime *bool `yaml:"abs_send_time,omitempty"`
+	// replaces room.speaker_audio
+	SpeakerAudio *SpeakerAudioConfig `yaml:"speaker_audio,omitempty"`
+}
+
+type RoomPresetEgress struct {
+	// record each published track, uploading to the egress service's default storage
+	Tracks *RoomPresetTrackEgress `yaml:"tracks,omitempty"`
+}
+
+type RoomPresetTrackEgress struct {
+	Filepath        string `yaml:"filepath,omitempty"`
+	DisableManifest bool   `yaml:"disable_manifest,omitempty"`
+}
+
+// PresetForRoom returns the name and settings of the preset that applies to a room, ok is false when none does
+func (c *RoomConfig) PresetForRoom(roomName string) (name string, preset RoomPresetConfig, ok bool) {
+	matched := -1
+	for n, p := range c.Presets {
+		for _, prefix := range p.RoomPrefixes {
+			if len(prefix) > matched && strings.HasPrefix(roomName, prefix) {
+				name, preset, ok = n, p, true
+				matched = len(prefix)
+			}
+		}
+	}
+	return
+}
+
+// PublisherIPsForRoom returns the publisher networks of the room's preset, falling back to room.publisher_ips
+func (c *RoomConfig) PublisherIPsForRoom(roomName string) PublisherIPsConfig {
+	if _, preset, ok := c.PresetForRoom(roomName); ok && preset.PublisherIPs != nil {
+		return *preset.PublisherIPs
+	}
+	return c.PublisherIPs
+}
+
+// NACKPolicyForRoom returns the NACK policy of the room's preset, falling back to room.nack
+func (c *RoomConfig) NACKPolicyForRoom(roomName string) NACKPolicy {
+	if _, preset, ok := c.PresetForRoom(roomName); ok && preset.NACK != nil {
+		return *preset.NACK
+	}
+	return c.NACK
+}
+
+// AbsSendTimeForRoom returns whether subscribers of the room are always sent abs-send-time
+func (c *RoomConfig) AbsSendTimeForRoom(roomName string) bool {
+	if _, preset, ok := c.PresetForRoom(roomName); ok && preset.AbsSendTime != nil {
+		return *preset.AbsSendTime
+	}
+	return c.AbsSendTime
+}
+
+// SpeakerAudioForRoom returns the speaker audio settings of the room's preset, falling back to room.speaker_audio
+func (c *RoomConfig) SpeakerAudioForRoom(roomName string) SpeakerAudioConfig {
+	if _, preset, ok := c.PresetForRoom(roomName); ok && preset.SpeakerAudio != nil {
+		return *preset.SpeakerAudio
+	}
+	return c.SpeakerAudio
+}
+
+// ScreenSharePolicyForRoom returns the screen share policy of the room's preset, rooms without one have no policy
+func (c *RoomConfig) ScreenSharePolicyForRoom(roomName string) ScreenSharePolicy {
+	if _, preset, ok := c.PresetForRoom(roomName); ok && preset.ScreenShare != nil {
+		return *preset.ScreenShare
+	}
+	return ScreenSharePolicy{}
+}
+
+// PlayoutDelaySourcesForRoom returns the per track source playout delay bounds of the room's preset, falling back to room.playout_delay
+func (c *RoomConfig) PlayoutDelaySourcesForRoom(roomName string) map[string]PlayoutDelayBounds {
+	if _, preset, ok := c.PresetForRoom(roomName); ok && preset.PlayoutDelay != nil {
+		return preset.PlayoutDelay.Sources
+	}
+	return c.PlayoutDelay.Sources
+}
+
+func (c *RoomConfig) validatePresets() error {
+	prefixes := make(map[string]string)
+	for name, p := range c.Presets {
+		if len(p.RoomPrefixes) == 0 {
+			return fmt.Errorf("room preset %s has no room_prefixes", name)
+		}
+		for _, prefix := range p.RoomPrefixes {
+			if other, ok := prefixes[prefix]; ok {
+				return fmt.Errorf("room prefix %q is used by presets %s and %s", prefix, other, name)
+			}
+			prefixes[prefix] = name
+		}
+		if p.PublisherIPs != nil {
+			if _, _, err := p.PublisherIPs.Networks(); err != nil {
+				return fmt.Errorf("room preset %s has invalid publisher_ips: %v", name, err)
+			}
+		}
+		if p.PlayoutDelay != nil {
+			if err := p.PlayoutDelay.validate(); err != nil {
+				return fmt.Errorf("room preset %s has invalid playout_delay: %v", name, err)
+			}
+		}
+		if p.NACK != nil {
+			if err := p.NACK.validate(); err != nil {
+				return fmt.Errorf("room preset %s has invalid nack: %v", name, err)
+			}
+		}
+		if p.SpeakerAudio != nil {
+			if err := p.SpeakerAudio.validate(); err != nil {
+				return fmt.Errorf("room preset %s has invalid speaker_audio: %v", name, err)
+			}
+		}
+	}
+	if _, _, err := c.PublisherIPs.Networks(); err != nil {
+		return fmt.Errorf("invalid publisher_ips: %v", err)
+	}
+	if err := c.PlayoutDelay.validate(); err != nil {
+		return fmt.Errorf("invalid playout_delay: %v", err)
+	}
+	if err := c.NACK.validate(); err != nil {
+		return fmt.Errorf("invalid nack: %v", err)
+	}
+	return nil
+}
+
+const (
+	ScreenShareContentHintMotion = "motion"
+	ScreenShareContentHintDetail = "detail"
+	ScreenShareContentHintText   = "text"
+)
+
+// ScreenSharePolicy is sent to publishers of screen shares as publish constraints
+type ScreenSharePolicy struct {
+	// publishers send a single layer
+	DisableSimulcast bool `yaml:"disable_simulcast,omitempty"`
+	// publishers send at most this many layers, the highest ones. 0 means all layers
+	MaxLayers int32 `yaml:"max_layers,omitempty"`
+	// one of motion, detail or text, set on the track by publishers. detail and text keep resolution
+	// and give up frame rate under constraints, motion keeps frame rate and gives up resolution
+	ContentHint string `yaml:"content_hint,omitempty"`
+}
+
+type MetadataSchemaConfig struct {
+	// path to a JSON schema file for room metadata
+	RoomSchemaFile string `yaml:"room_schema_file,omitempty"`
+	// path to a JSON schema file for participant metadata
+	ParticipantSchemaFile string `yaml:"participant_schema_file,omitempty"`
+}
+
+func (c *MetadataSchemaConfig) validate() error {
+	for _, file := range []string{c.RoomSchemaFile, c.ParticipantSchemaFile} {
+		if _, err := utils.ReadJSONSchemaFile(file); err != nil {
+			return fmt.Errorf("invalid metadata_schema: %v", err)
+		}
+	}
+	return nil
 }
 
 type CodecSpec struct {
@@ -244,12 +1167,149 @@ type TURNConfig struct {
 	RelayPortRangeStart uint16 `yaml:"relay_range_start,omitempty"`
 	RelayPortRangeEnd   uint16 `yaml:"relay_range_end,omitempty"`
 	ExternalTLS         bool   `yaml:"external_tls,omitempty"`
+	// ACME provisions the TLS certificate for Domain instead of cert_file/key_file
+	ACME TURNACMEConfig `yaml:"acme,omitempty"`
+}
+
+type TURNACMEConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// contact for expiration and account notices
+	Email string `yaml:"email,omitempty"`
+	// where certificates and the account key are kept across restarts
+	CacheDir string `yaml:"cache_dir,omitempty"`
+	// ACME directory, defaults to Let's Encrypt production
+	DirectoryURL string `yaml:"directory_url,omitempty"`
+	// port serving HTTP-01 challenges, 0 to rely on TLS-ALPN-01, which requires tls_port to be 443
+	HTTPPort int `yaml:"http_port,omitempty"`
+}
+
+// WebTransportConfig enables the experimental WebTransport (HTTP/3) signal listener
+type WebTransportConfig struct {
+	// UDP port to listen on, disabled when 0
+	Port     uint32 `yaml:"port,omitempty"`
+	CertFile string `yaml:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty"`
+}
+
+// SignalConfig controls the framing of signal messages on WebSocket connections
+type SignalConfig struct {
+	// negotiate permessage-deflate with clients that offer it
+	Compression bool `yaml:"compression,omitempty"`
+	// compress/flate level of outgoing messages, -2 (huffman only) to 9 (best compression), 0 keeps the default (best speed)
+	CompressionLevel int `yaml:"compression_level,omitempty"`
+	// only accept binary protobuf messages, connections sending JSON are closed
+	BinaryOnly bool `yaml:"binary_only,omitempty"`
+}
+
+func (c *SignalConfig) validate() error {
+	if c.CompressionLevel < -2 || c.CompressionLevel > 9 {
+		return fmt.Errorf("compression_level must be between -2 and 9, got %d", c.CompressionLevel)
+	}
+	return nil
 }
 
 type WebHookConfig struct {
 	URLs []string `yaml:"urls,omitempty"`
 	// key to use for webhook
 	APIKey string `yaml:"api_key,omitempty"`
+	// additional keys signing webhooks, receivers accept a signature of any of them so secrets can be rotated
+	SigningKeys []string `yaml:"signing_keys,omitempty"`
+	// receivers reject deliveries signed longer ago than this, or delivered again within it
+	ReplayWindow time.Duration `yaml:"replay_window,omitempty"`
+	// connection quality events
+	Quality QualityWebHookConfig `yaml:"quality,omitempty"`
+}
+
+type QualityWebHookConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// participants at or below this connection quality are degraded, poor or good
+	Threshold string `yaml:"threshold,omitempty"`
+	// a room is degraded when at least this fraction of its active participants are
+	RoomDegradedRatio float64 `yaml:"room_degraded_ratio,omitempty"`
+	// minimum time between events for the same participant or room
+	MinInterval time.Duration `yaml:"min_interval,omitempty"`
+}
+
+// APIRateLimitConfig limits requests to the server APIs with token buckets, zero rates disable a limit
+type APIRateLimitConfig struct {
+	PerAPIKey RateLimitConfig `yaml:"per_api_key,omitempty"`
+	PerIP     RateLimitConfig `yaml:"per_ip,omitempty"`
+	// use the first address of X-Forwarded-For as the client IP, for servers behind a proxy
+	TrustForwardedFor bool `yaml:"trust_forwarded_for,omitempty"`
+}
+
+// CORSConfig restricts the browser origins allowed to use signaling and the server APIs.
+// Requests without an Origin header, such as those from server SDKs, are not affected
+type CORSConfig struct {
+	// scheme://host[:port], * matches any characters within the host, e.g. https://*.example.com.
+	// all origins are allowed when empty
+	AllowedOrigins []string `yaml:"allowed_origins,omitempty"`
+}
+
+func (c *CORSConfig) validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || scheme == "" || host == "" || strings.ContainsAny(host, "/?#") {
+			return fmt.Errorf("invalid origin %s, expected scheme://host[:port]", origin)
+		}
+	}
+	return nil
+}
+
+// DebugConfig serves pprof profiles and runtime trace captures on the HTTP port,
+// requests need a token signed with one of the debug API keys
+type DebugConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// API keys that can access the debug endpoints, they should not be used for anything else
+	APIKeys []string `yaml:"api_keys,omitempty"`
+	// longest CPU profile or runtime trace that can be requested
+	MaxCaptureDuration time.Duration `yaml:"max_capture_duration,omitempty"`
+	// directory traces captured to a file are written to, the system temp directory when empty.
+	// RTP captures started with /admin/start_rtp_capture are written here as well
+	TraceDirectory string `yaml:"trace_directory,omitempty"`
+	// size of an RTP capture file after which further packets are not captured
+	MaxRTPCaptureSize int64 `yaml:"max_rtp_capture_size,omitempty"`
+	// when a bucket is set, traces can be captured and uploaded to it
+	S3 S3Config `yaml:"s3,omitempty"`
+}
+
+// RecorderConfig configures the in-process outputs, track recording is disabled when no directory is set
+type RecorderConfig struct {
+	// local directory recordings are written to
+	Directory string `yaml:"directory,omitempty"`
+	// when a bucket is set, finished recordings are uploaded and removed from the directory
+	S3  S3Config        `yaml:"s3,omitempty"`
+	HLS HLSOutputConfig `yaml:"hls,omitempty"`
+}
+
+// HLSOutputConfig configures low-latency HLS streams served by the node
+type HLSOutputConfig struct {
+	// segments are cut at the first key frame after this duration
+	SegmentDuration time.Duration `yaml:"segment_duration,omitempty"`
+	PartDuration    time.Duration `yaml:"part_duration,omitempty"`
+	// number of segments in the playlist
+	PlaylistSize int `yaml:"playlist_size,omitempty"`
+}
+
+type S3Config struct {
+	AccessKey string `yaml:"access_key,omitempty"`
+	Secret    string `yaml:"secret,omitempty"`
+	Region    string `yaml:"region,omitempty"`
+	// for S3 compatible storage, https://s3.<region>.amazonaws.com when empty
+	Endpoint string `yaml:"endpoint,omitempty"`
+	Bucket   string `yaml:"bucket,omitempty"`
+	// prepended to the keys of uploaded files
+	Prefix         string `yaml:"prefix,omitempty"`
+	ForcePathStyle bool   `yaml:"force_path_style,omitempty"`
+}
+
+type RateLimitConfig struct {
+	// requests per second
+	Rate  float64 `yaml:"rate,omitempty"`
+	Burst int     `yaml:"burst,omitempty"`
 }
 
 type NodeSelectorConfig struct {
@@ -283,6 +1343,96 @@ type LimitConfig struct {
 	SubscriptionLimitAudio int32   `yaml:"subscription_limit_audio,omitempty"`
 }
 
+// ChaosConfig randomly breaks transports and delays the message bus, checking that the server recovers
+// without leaking goroutines or corrupting stats. For resilience testing, never enable it in production.
+type ChaosConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// how often transports are considered for killing and invariants are checked
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// probability, at every interval, of a participant's DTLS transport being closed
+	TransportKill float64 `yaml:"transport_kill,omitempty"`
+	// probability of a published bus message being delayed, by up to bus_max_delay
+	BusDelay    float64       `yaml:"bus_delay,omitempty"`
+	BusMaxDelay time.Duration `yaml:"bus_max_delay,omitempty"`
+	// probability of an RTCP sender or receiver report being dropped, sent or received
+	ReportDrop float64 `yaml:"report_drop,omitempty"`
+	// seeds the random decisions, so that runs inject the same faults
+	Seed int64 `yaml:"seed,omitempty"`
+	// goroutines allowed above the count at startup once the node has no rooms, more are reported as a leak
+	GoroutineLeakThreshold int `yaml:"goroutine_leak_threshold,omitempty"`
+}
+
+func (c *ChaosConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	for _, p := range []float64{c.TransportKill, c.BusDelay, c.ReportDrop} {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("invalid probability %v, expected a value between 0 and 1", p)
+		}
+	}
+	if c.BusMaxDelay < 0 {
+		return errors.New("bus_max_delay cannot be negative")
+	}
+	return nil
+}
+
+type InternalTLSConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// certificate presented by this node, reloaded when the files change
+	CertFile string `yaml:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty"`
+	// CAs that peer certificates must chain to, reloaded with the certificate
+	CAFile string `yaml:"ca_file,omitempty"`
+	// name expected in peer certificates, defaults to the host each redis connection is made to
+	ServerName string `yaml:"server_name,omitempty"`
+	// when set, peers are identified by the SPIFFE ID in their certificate instead of by name.
+	// an ID ending in /* matches any ID below that path
+	SPIFFEIDs []string `yaml:"spiffe_ids,omitempty"`
+}
+
+func (c *InternalTLSConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" || c.CAFile == "" {
+		return errors.New("cert_file, key_file and ca_file are required")
+	}
+	for _, id := range c.SPIFFEIDs {
+		if !strings.HasPrefix(id, "spiffe://") {
+			return fmt.Errorf("invalid SPIFFE ID %s", id)
+		}
+	}
+	return nil
+}
+
+type AuthConfig struct {
+	Revocation TokenRevocationConfig `yaml:"revocation,omitempty"`
+	Refresh    TokenRefreshConfig    `yaml:"refresh,omitempty"`
+}
+
+type TokenRefreshConfig struct {
+	// how often connected participants are sent a new token
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// how long tokens sent to participants are valid, must be longer than the interval
+	TokenTTL time.Duration `yaml:"token_ttl,omitempty"`
+	// resumes must present the latest token sent to the participant, or the one it replaced within resume_grace
+	RequireLatest bool `yaml:"require_latest,omitempty"`
+	// how long a session can still be resumed with an expired or replaced token, on top of the minute of clock skew
+	// allowed for all tokens
+	ResumeGrace time.Duration `yaml:"resume_grace,omitempty"`
+}
+
+type TokenRevocationConfig struct {
+	// how often tokens of connected participants are checked against revocations, disabled when 0
+	CheckInterval time.Duration `yaml:"check_interval,omitempty"`
+	// how long a revocation is kept when the token's expiry is not known
+	DefaultTTL time.Duration `yaml:"default_ttl,omitempty"`
+}
+
 type IngressConfig struct {
 	RTMPBaseURL string `yaml:"rtmp_base_url,omitempty"`
 	WHIPBaseURL string `yaml:"whip_base_url,omitempty"`
@@ -313,14 +1463,54 @@ var DefaultConfig = Config{
 			ICEPortRangeStart: 0,
 			ICEPortRangeEnd:   0,
 			STUNServers:       []string{},
+			BatchIO: rtcconfig.BatchIOConfig{
+				MaxFlushInterval: 2 * time.Millisecond,
+			},
 		},
 		PacketBufferSize: 500,
 		StrictACKs:       true,
+		ICEServerHealth: ICEServerHealthConfig{
+			Interval:         30 * time.Second,
+			Timeout:          5 * time.Second,
+			FailureThreshold: 2,
+		},
 		PLIThrottle: PLIThrottleConfig{
 			LowQuality:  500 * time.Millisecond,
 			MidQuality:  time.Second,
 			HighQuality: time.Second,
 		},
+		FeedbackThrottle: FeedbackThrottleConfig{
+			KeyFrameRequestsPerSecond: 5,
+			NacksPerSecond:            1000,
+			ThrottledKeyFrameInterval: 2 * time.Second,
+			IgnoredKeyFrameInterval:   10 * time.Second,
+			DecayInterval:             10 * time.Second,
+		},
+		Resume: ResumeConfig{
+			Priority:        []string{"screen_share", "active_speaker"},
+			KeyFrameStagger: 50 * time.Millisecond,
+		},
+		PacketWorkers: PacketWorkersConfig{
+			Enabled:   true,
+			QueueSize: 1024,
+		},
+		RTCP: RTCPConfig{
+			SenderReportInterval:   3 * time.Second,
+			ReceiverReportInterval: time.Second,
+			BandwidthFraction:      0.05,
+			Jitter:                 0.5,
+		},
+		TWCC: TWCCConfig{
+			FeedbackInterval:            100 * time.Millisecond,
+			FeedbackIntervalAfterMarker: 50 * time.Millisecond,
+			MinPacketsPerFeedback:       20,
+			MaxPacketsPerFeedback:       100,
+		},
+		SocketBuffers: SocketBuffersConfig{
+			Enabled:       true,
+			CheckInterval: 10 * time.Second,
+			WarnAfter:     3,
+		},
 		CongestionControl: CongestionControlConfig{
 			Enabled:                true,
 			AllowPause:             false,
@@ -366,6 +1556,31 @@ var DefaultConfig = Config{
 				NackWindowMaxDuration:          3 * time.Second,
 				NackRatioThreshold:             0.08,
 			},
+			Uplink: CongestionControlUplinkConfig{
+				Interval:      time.Second,
+				LossThreshold: 0.1,
+				MinBitrate:    150_000,
+			},
+			JoinProbe: CongestionControlJoinProbeConfig{
+				GoalBps:  3_000_000,
+				Duration: 500 * time.Millisecond,
+				Timeout:  5 * time.Second,
+			},
+		},
+	},
+	Recorder: RecorderConfig{
+		HLS: HLSOutputConfig{
+			SegmentDuration: 2 * time.Second,
+			PartDuration:    500 * time.Millisecond,
+			PlaylistSize:    6,
+		},
+	},
+	WebHook: WebHookConfig{
+		ReplayWindow: 5 * time.Minute,
+		Quality: QualityWebHookConfig{
+			Threshold:         "poor",
+			RoomDegradedRatio: 0.5,
+			MinInterval:       time.Minute,
 		},
 	},
 	Audio: AudioConfig{
@@ -375,7 +1590,11 @@ var DefaultConfig = Config{
 		SmoothIntervals: 2,
 	},
 	Video: VideoConfig{
-		DynacastPauseDelay: 5 * time.Second,
+		DynacastPauseDelay:  5 * time.Second,
+		MaxKeyFrameInterval: 30 * time.Second,
+		FrameAssembly: FrameAssemblyConfig{
+			MaxDelay: 50 * time.Millisecond,
+		},
 		StreamTracker: StreamTrackersConfig{
 			Video: StreamTrackerConfig{
 				StreamTrackerType: StreamTrackerTypePacket,
@@ -463,6 +1682,30 @@ var DefaultConfig = Config{
 			// {Mime: webrtc.MimeTypeVP9},
 		},
 		EmptyTimeout: 5 * 60,
+		Schedule: RoomScheduleConfig{
+			StartingSoonNotice: 5 * time.Minute,
+			CloseGracePeriod:   time.Minute,
+		},
+		Close: RoomCloseConfig{
+			CountdownNotices: []time.Duration{time.Minute, 10 * time.Second},
+		},
+		BanDuration: time.Hour,
+		Attributes: ParticipantAttributesConfig{
+			MaxKeys:      64,
+			MaxKeySize:   256,
+			MaxValueSize: 4096,
+		},
+		Budget: RoomBudgetConfig{
+			WarningThreshold: 0.8,
+			CheckInterval:    5 * time.Second,
+		},
+		TrackInactivity: TrackInactivityConfig{
+			CheckInterval: time.Second,
+		},
+		TrackSilence: TrackSilenceConfig{
+			StaticFrameBytes: 200,
+			CheckInterval:    time.Second,
+		},
 	},
 	Logging: LoggingConfig{
 		PionLevel: "error",
@@ -483,6 +1726,26 @@ var DefaultConfig = Config{
 		MaxRetryInterval: 4 * time.Second,
 		StreamBufferSize: 1000,
 	},
+	Auth: AuthConfig{
+		Revocation: TokenRevocationConfig{
+			CheckInterval: time.Minute,
+			DefaultTTL:    24 * time.Hour,
+		},
+		Refresh: TokenRefreshConfig{
+			Interval:    5 * time.Minute,
+			TokenTTL:    10 * time.Minute,
+			ResumeGrace: 2 * time.Minute,
+		},
+	},
+	Chaos: ChaosConfig{
+		Interval:               10 * time.Second,
+		BusMaxDelay:            500 * time.Millisecond,
+		GoroutineLeakThreshold: 100,
+	},
+	Debug: DebugConfig{
+		MaxCaptureDuration: time.Minute,
+		MaxRTPCaptureSize:  256 * 1024 * 1024,
+	},
 	Keys: map[string]string{},
 }
 
@@ -511,6 +1774,86 @@ func NewConfig(confString string, strictMode bool, c *cli.Context, baseFlags []c
 		return nil, fmt.Errorf("could not validate RTC config: %v", err)
 	}
 
+	if err := conf.Room.validatePresets(); err != nil {
+		return nil, fmt.Errorf("could not validate room config: %v", err)
+	}
+
+	for _, impairment := range conf.RTC.Impairments {
+		if err := impairment.validate(); err != nil {
+			return nil, fmt.Errorf("could not validate RTC config: %v", err)
+		}
+	}
+
+	if err := conf.RTC.RTCP.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate RTC config: %v", err)
+	}
+
+	if err := conf.RTC.TWCC.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate RTC config: %v", err)
+	}
+
+	if err := conf.RTC.RTPStatsHistory.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate RTC config: %v", err)
+	}
+
+	if err := conf.RTC.ReorderTolerance.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate RTC config: %v", err)
+	}
+
+	if err := conf.RTC.SubscriberHeaderExtensions.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate RTC config: %v", err)
+	}
+
+	if conf.RTC.SocketBuffers.Enabled && conf.RTC.SocketBuffers.CheckInterval <= 0 {
+		return nil, fmt.Errorf("could not validate RTC config: socket_buffers check_interval must be positive")
+	}
+
+	if err := conf.Room.Budget.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate room config: %v", err)
+	}
+	if err := conf.Room.TrackInactivity.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate room config: %v", err)
+	}
+	if err := conf.Room.TrackSilence.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate room config: %v", err)
+	}
+	if err := conf.Room.Attributes.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate room config: %v", err)
+	}
+	if err := conf.Room.SpeakerAudio.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate room config: %v", err)
+	}
+	if err := conf.Room.MetadataSchema.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate room config: %v", err)
+	}
+
+	if refresh := conf.Auth.Refresh; refresh.Interval <= 0 || refresh.TokenTTL <= refresh.Interval {
+		return nil, fmt.Errorf("could not validate auth config: refresh token_ttl must be longer than interval")
+	}
+
+	if err := conf.InternalTLS.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate internal_tls config: %v", err)
+	}
+	if conf.InternalTLS.Enabled && !conf.Redis.IsConfigured() {
+		// nodes only talk to each other through the redis message bus
+		return nil, fmt.Errorf("could not validate internal_tls config: redis is required")
+	}
+
+	if err := conf.CORS.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate cors config: %v", err)
+	}
+
+	if err := conf.Signal.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate signal config: %v", err)
+	}
+
+	if err := conf.Chaos.validate(); err != nil {
+		return nil, fmt.Errorf("could not validate chaos config: %v", err)
+	}
+	if conf.Video.FrameAssembly.MaxDelay <= 0 {
+		return nil, fmt.Errorf("could not validate video config: frame_assembly max_delay must be positive")
+	}
+
 	if c != nil {
 		if err := conf.updateFromCLI(c, baseFlags); err != nil {
 			return nil, err
@@ -650,6 +1993,17 @@ func (conf *Config) ValidateKeys() error {
 		return ErrKeysNotSet
 	}
 
+	if conf.Debug.Enabled {
+		if len(conf.Debug.APIKeys) == 0 {
+			return ErrDebugKeysNotSet
+		}
+		for _, key := range conf.Debug.APIKeys {
+			if _, ok := conf.Keys[key]; !ok {
+				return fmt.Errorf("debug API key %s is not one of the keys", key)
+			}
+		}
+	}
+
 	if !conf.Development {
 		for key, secret := range conf.Keys {
 			if len(secret) < 32 {
@@ -16,10 +16,15 @@ package config
 
 import (
 	"flag"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/urfave/cli/v2"
+
+	"github.com/livekit/protocol/livekit"
 )
 
 func TestConfig_UnmarshalKeys(t *testing.T) {
@@ -37,6 +42,35 @@ func TestConfig_DefaultsKept(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, true, conf.Room.AutoCreate)
 	require.Equal(t, uint32(10), conf.Room.EmptyTimeout)
+	// feedback throttling is opt in
+	require.False(t, conf.RTC.FeedbackThrottle.Enabled)
+}
+
+func TestConfig_BatchIODefaults(t *testing.T) {
+	const content = `rtc:
+  batch_io:
+    batch_size: 128`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 128, conf.RTC.BatchIO.BatchSize)
+	require.Equal(t, 2*time.Millisecond, conf.RTC.BatchIO.MaxFlushInterval)
+}
+
+func TestConfig_DebugKeys(t *testing.T) {
+	const content = `keys:
+  app: secret
+  debug: debugsecret
+debug:
+  enabled: true`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+	require.ErrorIs(t, conf.ValidateKeys(), ErrDebugKeysNotSet)
+
+	conf.Debug.APIKeys = []string{"other"}
+	require.Error(t, conf.ValidateKeys())
+
+	conf.Debug.APIKeys = []string{"debug"}
+	require.NoError(t, conf.ValidateKeys())
 }
 
 func TestConfig_UnknownKeys(t *testing.T) {
@@ -47,6 +81,197 @@ room:
 	require.Error(t, err)
 }
 
+func TestConfig_ScreenSharePolicy(t *testing.T) {
+	const content = `room:
+  presets:
+    webinar:
+      room_prefixes:
+        - webinar-
+      screen_share:
+        max_layers: 2
+        content_hint: detail
+    sports:
+      room_prefixes:
+        - webinar-sports-
+      screen_share:
+        content_hint: motion`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, ScreenSharePolicy{}, conf.Room.ScreenSharePolicyForRoom("standup"))
+	require.Equal(t, ScreenSharePolicy{MaxLayers: 2, ContentHint: ScreenShareContentHintDetail}, conf.Room.ScreenSharePolicyForRoom("webinar-1"))
+	require.Equal(t, ScreenSharePolicy{ContentHint: ScreenShareContentHintMotion}, conf.Room.ScreenSharePolicyForRoom("webinar-sports-1"))
+}
+
+func TestConfig_PlayoutDelaySources(t *testing.T) {
+	const content = `room:
+  playout_delay:
+    enabled: true
+    max: 2000
+    sources:
+      screen_share:
+        min: 400
+        max: 4000
+  presets:
+    live:
+      room_prefixes:
+        - live-
+      playout_delay:
+        enabled: true
+        sources:
+          camera:
+            max: 100`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]PlayoutDelayBounds{"screen_share": {Min: 400, Max: 4000}}, conf.Room.PlayoutDelaySourcesForRoom("standup"))
+	require.Equal(t, map[string]PlayoutDelayBounds{"camera": {Max: 100}}, conf.Room.PlayoutDelaySourcesForRoom("live-1"))
+
+	_, err = NewConfig(`room:
+  playout_delay:
+    sources:
+      webcam:
+        min: 100`, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(`room:
+  playout_delay:
+    sources:
+      camera:
+        min: 500
+        max: 100`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_NACKPolicy(t *testing.T) {
+	const content = `room:
+  nack:
+    disabled_sources:
+      - microphone
+  presets:
+    stage:
+      room_prefixes:
+        - stage-
+      nack:
+        disabled: true`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+
+	policy := conf.Room.NACKPolicyForRoom("standup")
+	require.True(t, policy.DisabledFor(livekit.TrackSource_MICROPHONE))
+	require.False(t, policy.DisabledFor(livekit.TrackSource_CAMERA))
+
+	policy = conf.Room.NACKPolicyForRoom("stage-1")
+	require.True(t, policy.DisabledFor(livekit.TrackSource_CAMERA))
+
+	_, err = NewConfig(`room:
+  nack:
+    disabled_sources:
+      - mic`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_AbsSendTime(t *testing.T) {
+	const content = `room:
+  abs_send_time: true
+  presets:
+    stage:
+      room_prefixes:
+        - stage-
+      abs_send_time: false`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+
+	require.True(t, conf.Room.AbsSendTimeForRoom("standup"))
+	require.False(t, conf.Room.AbsSendTimeForRoom("stage-1"))
+}
+
+func TestConfig_SpeakerAudio(t *testing.T) {
+	const content = `room:
+  presets:
+    webinar:
+      room_prefixes:
+        - webinar-
+      speaker_audio:
+        enabled: true
+        hangover: 2s`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+
+	require.False(t, conf.Room.SpeakerAudioForRoom("standup").Enabled)
+	speakerAudio := conf.Room.SpeakerAudioForRoom("webinar-1")
+	require.True(t, speakerAudio.Enabled)
+	require.Equal(t, 2*time.Second, speakerAudio.Hangover)
+}
+
+func TestConfig_RoomTransports(t *testing.T) {
+	const content = `rtc:
+  udp_port: 7882
+  tcp_port: 7881
+  room_transports:
+    premium-:
+      udp_port: 7890
+      interfaces:
+        includes:
+          - eth1
+    invalid-:
+      tcp_port: 7893`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, "", conf.RTC.RoomTransportForRoom("standup"))
+	require.Equal(t, "premium-", conf.RTC.RoomTransportForRoom("premium-1"))
+
+	premium := conf.RTC.RoomTransports["premium-"]
+	rtcConf, err := premium.ApplyTo(conf.RTC.RTCConfig)
+	require.NoError(t, err)
+	require.Equal(t, 7890, rtcConf.UDPPort.Start)
+	require.Zero(t, rtcConf.TCPPort)
+	require.Equal(t, []string{"eth1"}, rtcConf.Interfaces.Includes)
+
+	invalid := conf.RTC.RoomTransports["invalid-"]
+	_, err = invalid.ApplyTo(conf.RTC.RTCConfig)
+	require.Error(t, err)
+}
+
+func TestConfig_InternalTLSRequiresRedis(t *testing.T) {
+	const tlsContent = `internal_tls:
+  enabled: true
+  cert_file: node.crt
+  key_file: node.key
+  ca_file: ca.crt`
+	_, err := NewConfig(tlsContent, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(tlsContent+`
+redis:
+  address: redis.internal:6379`, true, nil, nil)
+	require.NoError(t, err)
+}
+
+func TestConfig_MetadataSchema(t *testing.T) {
+	dir := t.TempDir()
+	supported := filepath.Join(dir, "supported.json")
+	require.NoError(t, os.WriteFile(supported, []byte(`{"title": "participant", "type": "object"}`), 0644))
+	unsupported := filepath.Join(dir, "unsupported.json")
+	require.NoError(t, os.WriteFile(unsupported, []byte(`{"oneOf": [{"type": "object"}, {"type": "null"}]}`), 0644))
+
+	_, err := NewConfig(`room:
+  metadata_schema:
+    participant_schema_file: `+supported, true, nil, nil)
+	require.NoError(t, err)
+
+	_, err = NewConfig(`room:
+  metadata_schema:
+    room_schema_file: `+unsupported, true, nil, nil)
+	require.ErrorContains(t, err, "unsupported schema keyword")
+
+	_, err = NewConfig(`room:
+  metadata_schema:
+    room_schema_file: `+filepath.Join(dir, "missing.json"), true, nil, nil)
+	require.Error(t, err)
+}
+
 func TestGeneratedFlags(t *testing.T) {
 	generatedFlags, err := GenerateCLIFlags(nil, false)
 	require.NoError(t, err)
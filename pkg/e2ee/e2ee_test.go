@@ -0,0 +1,137 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2ee
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+func encryptedFrame(size int, keyIndex uint8) []byte {
+	frame := make([]byte, size)
+	frame[size-2] = IVLength
+	frame[size-1] = keyIndex
+	return frame
+}
+
+func framePacket(payload []byte, marker bool) *buffer.ExtPacket {
+	return &buffer.ExtPacket{
+		Packet: &rtp.Packet{
+			Header:  rtp.Header{Marker: marker},
+			Payload: payload,
+		},
+	}
+}
+
+func TestParseTrailer(t *testing.T) {
+	keyIndex, encrypted := ParseTrailer(encryptedFrame(40, 3))
+	require.True(t, encrypted)
+	require.Equal(t, uint8(3), keyIndex)
+
+	_, encrypted = ParseTrailer(make([]byte, 40))
+	require.False(t, encrypted)
+
+	// too short to hold an IV and a trailer
+	_, encrypted = ParseTrailer(encryptedFrame(IVLength+TrailerLength, 1))
+	require.False(t, encrypted)
+}
+
+func TestMonitor(t *testing.T) {
+	t.Run("announces confirmed key index", func(t *testing.T) {
+		var announced []uint8
+		m := NewMonitor(MonitorParams{
+			TrackID:    "TR_audio",
+			OnKeyIndex: func(keyIndex uint8) { announced = append(announced, keyIndex) },
+		})
+		for i := 0; i < keyIndexConfirmFrames; i++ {
+			require.NoError(t, m.WriteRTP(framePacket(encryptedFrame(40, 0), false), 0))
+		}
+		// a single frame with another index is not enough
+		require.NoError(t, m.WriteRTP(framePacket(encryptedFrame(40, 7), false), 0))
+		require.NoError(t, m.WriteRTP(framePacket(encryptedFrame(40, 0), false), 0))
+		for i := 0; i < keyIndexConfirmFrames; i++ {
+			require.NoError(t, m.WriteRTP(framePacket(encryptedFrame(40, 1), false), 0))
+		}
+		require.Equal(t, []uint8{0, 1}, announced)
+		require.Equal(t, 1, m.KeyIndex())
+		require.False(t, m.Unencrypted())
+	})
+
+	t.Run("reports unencrypted frames once", func(t *testing.T) {
+		reported := 0
+		m := NewMonitor(MonitorParams{
+			TrackID:       "TR_audio",
+			OnUnencrypted: func() { reported++ },
+		})
+		for i := 0; i < 2*monitorWindow; i++ {
+			require.NoError(t, m.WriteRTP(framePacket(make([]byte, 40), false), 0))
+		}
+		require.Equal(t, 1, reported)
+		require.True(t, m.Unencrypted())
+		require.Equal(t, -1, m.KeyIndex())
+	})
+
+	t.Run("checks the last packet of video frames", func(t *testing.T) {
+		reported := false
+		m := NewMonitor(MonitorParams{
+			TrackID:       "TR_video",
+			IsVideo:       true,
+			OnUnencrypted: func() { reported = true },
+		})
+		for i := 0; i < monitorWindow; i++ {
+			// only the last packet of a frame carries the trailer
+			require.NoError(t, m.WriteRTP(framePacket(make([]byte, 1000), false), 0))
+			require.NoError(t, m.WriteRTP(framePacket(encryptedFrame(400, 2), true), 0))
+		}
+		require.False(t, reported)
+		require.Equal(t, 2, m.KeyIndex())
+	})
+}
+
+func TestRoomState(t *testing.T) {
+	s := NewRoomState(4)
+	require.False(t, s.InUse())
+
+	require.True(t, s.Join("alice"))
+	require.False(t, s.Join("alice"))
+
+	s.SetKeyIndex("alice", 2)
+	require.True(t, s.InUse())
+	require.Equal(t, uint8(2), s.KeyIndex())
+
+	// rotation wraps around the ring
+	require.Equal(t, uint8(3), s.Rotate())
+	require.Equal(t, uint8(0), s.Rotate())
+
+	s.SetUnencrypted("bob", "TR_bob")
+	s.SetUnencrypted("bob", "TR_bob")
+	require.True(t, s.IsUnencrypted("bob"))
+	require.False(t, s.IsUnencrypted("alice"))
+
+	require.Equal(t, []ParticipantState{
+		{Identity: "alice", KeyIndex: 2},
+		{Identity: "bob", KeyIndex: -1, UnencryptedTracks: []livekit.TrackID{"TR_bob"}},
+	}, s.Participants())
+
+	require.True(t, s.Leave("alice"))
+	require.False(t, s.Leave("alice"))
+	require.False(t, s.InUse())
+}
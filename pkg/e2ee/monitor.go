@@ -0,0 +1,177 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2ee
+
+import (
+	"sync"
+
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const (
+	// MonitorPrefix is the prefix of the subscriber ID monitors use on receivers
+	MonitorPrefix = "EM_"
+
+	// frames checked before deciding whether a track is encrypted
+	monitorWindow = 50
+	// a window with fewer encrypted frames than this is considered unencrypted, frames that happen to end
+	// like a trailer make it impossible to require all frames to be unencrypted
+	monitorMinEncrypted = monitorWindow / 2
+	// consecutive frames needed with a new key index before announcing it
+	keyIndexConfirmFrames = 3
+)
+
+type MonitorParams struct {
+	TrackID livekit.TrackID
+	// video frames are checked on the last packet of the frame, audio packets are frames
+	IsVideo bool
+	Logger  logger.Logger
+	// called when the publisher switches to a new key
+	OnKeyIndex func(keyIndex uint8)
+	// called once when the track is found to send unencrypted frames
+	OnUnencrypted func()
+}
+
+// Monitor takes the place of a down track on a receiver and checks the trailer of published frames
+type Monitor struct {
+	params MonitorParams
+
+	lock        sync.Mutex
+	frames      int
+	encrypted   int
+	keyIndex    int
+	candidate   int
+	confirmed   int
+	unencrypted bool
+
+	closed atomic.Bool
+}
+
+var _ sfu.TrackSender = (*Monitor)(nil)
+
+func NewMonitor(params MonitorParams) *Monitor {
+	if params.Logger == nil {
+		params.Logger = logger.GetLogger()
+	}
+	return &Monitor{
+		params:    params,
+		keyIndex:  -1,
+		candidate: -1,
+	}
+}
+
+// KeyIndex returns the key index the publisher is using, -1 until one has been seen
+func (m *Monitor) KeyIndex() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.keyIndex
+}
+
+// Unencrypted returns true once the track has been found to send unencrypted frames
+func (m *Monitor) Unencrypted() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.unencrypted
+}
+
+func (m *Monitor) UpTrackLayersChange()                           {}
+func (m *Monitor) UpTrackBitrateAvailabilityChange()              {}
+func (m *Monitor) UpTrackMaxPublishedLayerChange(_ int32)         {}
+func (m *Monitor) UpTrackMaxTemporalLayerSeenChange(_ int32)      {}
+func (m *Monitor) UpTrackBitrateReport(_ []int32, _ sfu.Bitrates) {}
+func (m *Monitor) UpTrackSSRCChange(_ uint32)                     {}
+func (m *Monitor) TrackInfoAvailable()                            {}
+
+func (m *Monitor) HandleRTCPSenderReportData(_ webrtc.PayloadType, _ int32, _ *buffer.RTCPSenderReportData) error {
+	return nil
+}
+
+func (m *Monitor) WriteRTP(pkt *buffer.ExtPacket, _ int32) error {
+	if m.closed.Load() || len(pkt.Packet.Payload) == 0 {
+		return nil
+	}
+	if m.params.IsVideo && !pkt.Packet.Marker {
+		return nil
+	}
+	m.checkFrame(pkt.Packet.Payload)
+	return nil
+}
+
+func (m *Monitor) checkFrame(payload []byte) {
+	keyIndex, encrypted := ParseTrailer(payload)
+
+	var onKeyIndex, onUnencrypted bool
+	m.lock.Lock()
+	m.frames++
+	if encrypted {
+		m.encrypted++
+		if int(keyIndex) == m.candidate {
+			m.confirmed++
+		} else {
+			m.candidate = int(keyIndex)
+			m.confirmed = 1
+		}
+		if m.confirmed == keyIndexConfirmFrames && m.candidate != m.keyIndex {
+			m.keyIndex = m.candidate
+			onKeyIndex = true
+		}
+	}
+	if m.frames == monitorWindow {
+		if m.encrypted < monitorMinEncrypted && !m.unencrypted {
+			m.unencrypted = true
+			onUnencrypted = true
+		}
+		m.frames = 0
+		m.encrypted = 0
+	}
+	m.lock.Unlock()
+
+	if onKeyIndex {
+		m.params.Logger.Debugw("publisher key index changed", "trackID", m.params.TrackID, "keyIndex", keyIndex)
+		if m.params.OnKeyIndex != nil {
+			m.params.OnKeyIndex(keyIndex)
+		}
+	}
+	if onUnencrypted {
+		m.params.Logger.Infow("track is sending unencrypted frames", "trackID", m.params.TrackID)
+		if m.params.OnUnencrypted != nil {
+			m.params.OnUnencrypted()
+		}
+	}
+}
+
+// Close is called by the receiver when the track is unpublished
+func (m *Monitor) Close() {
+	m.closed.Store(true)
+}
+
+func (m *Monitor) IsClosed() bool {
+	return m.closed.Load()
+}
+
+func (m *Monitor) ID() string {
+	return MonitorPrefix + string(m.params.TrackID)
+}
+
+func (m *Monitor) SubscriberID() livekit.ParticipantID {
+	return livekit.ParticipantID(m.ID())
+}
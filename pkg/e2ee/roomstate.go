@@ -0,0 +1,184 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2ee
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	// RotateKeyTopic is the data topic of hints asking participants to move to a new key
+	RotateKeyTopic = "lk.e2ee.rotate_key"
+	// KeyIndexTopic is the data topic of announcements of the key a publisher is using
+	KeyIndexTopic = "lk.e2ee.key_index"
+)
+
+type RotationReason string
+
+const (
+	RotationReasonParticipantJoined RotationReason = "participant_joined"
+	RotationReasonParticipantLeft   RotationReason = "participant_left"
+	RotationReasonRequested         RotationReason = "requested"
+)
+
+// RotateKeyHint asks participants to derive or distribute the key at KeyIndex and switch to it,
+// so that participants joining cannot decrypt earlier media and participants leaving cannot decrypt later media
+type RotateKeyHint struct {
+	KeyIndex uint8          `json:"key_index"`
+	Reason   RotationReason `json:"reason"`
+	// participant that joined or left
+	Identity string `json:"identity,omitempty"`
+}
+
+// KeyIndexAnnouncement tells subscribers which key a publisher encrypts with
+type KeyIndexAnnouncement struct {
+	Identity string `json:"identity"`
+	KeyIndex uint8  `json:"key_index"`
+}
+
+type ParticipantState struct {
+	Identity livekit.ParticipantIdentity `json:"identity"`
+	// key index seen in the participant's frames, -1 until one has been seen
+	KeyIndex int `json:"key_index"`
+	// published tracks found to send unencrypted frames
+	UnencryptedTracks []livekit.TrackID `json:"unencrypted_tracks,omitempty"`
+}
+
+// RoomState tracks the keys used by the participants of a room
+type RoomState struct {
+	ringSize int
+
+	lock         sync.Mutex
+	keyIndex     uint8
+	members      map[livekit.ParticipantIdentity]bool
+	participants map[livekit.ParticipantIdentity]*ParticipantState
+}
+
+func NewRoomState(ringSize int) *RoomState {
+	if ringSize <= 0 || ringSize > 256 {
+		ringSize = DefaultKeyRingSize
+	}
+	return &RoomState{
+		ringSize:     ringSize,
+		members:      make(map[livekit.ParticipantIdentity]bool),
+		participants: make(map[livekit.ParticipantIdentity]*ParticipantState),
+	}
+}
+
+// KeyIndex returns the key index participants are expected to use
+func (s *RoomState) KeyIndex() uint8 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.keyIndex
+}
+
+// Rotate moves the room to the next key index of the ring and returns it
+func (s *RoomState) Rotate() uint8 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.keyIndex = uint8((int(s.keyIndex) + 1) % s.ringSize)
+	return s.keyIndex
+}
+
+// InUse returns true when frames encrypted by any participant have been seen
+func (s *RoomState) InUse() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, p := range s.participants {
+		if p.KeyIndex >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SetKeyIndex records the key a participant encrypts with, the room follows the latest key seen
+func (s *RoomState) SetKeyIndex(identity livekit.ParticipantIdentity, keyIndex uint8) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.participantLocked(identity).KeyIndex = int(keyIndex)
+	s.keyIndex = keyIndex
+}
+
+func (s *RoomState) SetUnencrypted(identity livekit.ParticipantIdentity, trackID livekit.TrackID) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	p := s.participantLocked(identity)
+	for _, id := range p.UnencryptedTracks {
+		if id == trackID {
+			return
+		}
+	}
+	p.UnencryptedTracks = append(p.UnencryptedTracks, trackID)
+}
+
+// IsUnencrypted returns true when a track of the participant has been found to send unencrypted frames
+func (s *RoomState) IsUnencrypted(identity livekit.ParticipantIdentity) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	p := s.participants[identity]
+	return p != nil && len(p.UnencryptedTracks) != 0
+}
+
+// Join records a participant as present in the room, returning false when it already was
+func (s *RoomState) Join(identity livekit.ParticipantIdentity) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.members[identity] {
+		return false
+	}
+	s.members[identity] = true
+	return true
+}
+
+// Leave forgets a participant, returning false when it was not present
+func (s *RoomState) Leave(identity livekit.ParticipantIdentity) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.participants, identity)
+	if !s.members[identity] {
+		return false
+	}
+	delete(s.members, identity)
+	return true
+}
+
+// Participants returns the state of participants whose frames have been checked, ordered by identity
+func (s *RoomState) Participants() []ParticipantState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	states := make([]ParticipantState, 0, len(s.participants))
+	for _, p := range s.participants {
+		state := *p
+		state.UnencryptedTracks = append([]livekit.TrackID(nil), p.UnencryptedTracks...)
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Identity < states[j].Identity
+	})
+	return states
+}
+
+func (s *RoomState) participantLocked(identity livekit.ParticipantIdentity) *ParticipantState {
+	p := s.participants[identity]
+	if p == nil {
+		p = &ParticipantState{Identity: identity, KeyIndex: -1}
+		s.participants[identity] = p
+	}
+	return p
+}
@@ -0,0 +1,41 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package e2ee lets the server coordinate end-to-end encryption of media without having access to the keys.
+// Encrypted frames carry a trailer in the clear, which tells whether a frame is encrypted and with which key.
+package e2ee
+
+const (
+	// IVLength is the length of the initialisation vector appended to each encrypted frame
+	IVLength = 12
+	// TrailerLength is the length of the trailer following the IV, [IV length][key index]
+	TrailerLength = 2
+
+	// DefaultKeyRingSize is the number of keys participants keep, key indexes wrap around at this size
+	DefaultKeyRingSize = 16
+)
+
+// ParseTrailer returns the key index of a frame encrypted with frame encryption.
+// The payload is expected to be the end of a frame, i.e. an audio packet or the last packet of a video frame.
+// frames not ending in a valid trailer are reported as not encrypted
+func ParseTrailer(payload []byte) (keyIndex uint8, encrypted bool) {
+	if len(payload) <= IVLength+TrailerLength {
+		return 0, false
+	}
+	trailer := payload[len(payload)-TrailerLength:]
+	if trailer[0] != IVLength {
+		return 0, false
+	}
+	return trailer[1], true
+}
@@ -0,0 +1,233 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mixer mixes the decoded audio of a room's participants on the server
+package mixer
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/agent"
+)
+
+const (
+	SampleRate    = 48000
+	Channels      = 1
+	FrameDuration = 20 * time.Millisecond
+	FrameSamples  = SampleRate / int(time.Second/FrameDuration)
+
+	// frames buffered per source to absorb jitter, older frames are dropped to bound the latency
+	maxQueuedFrames = 3
+)
+
+// Encoder encodes mixed frames of FrameSamples mono samples at SampleRate
+type Encoder interface {
+	Encode(pcm []int16) ([]byte, error)
+}
+
+// Codec provides the Opus decoders and encoders used for mixing, such as a binding of libopus
+type Codec interface {
+	NewDecoder(sampleRate int, channels int) (agent.Decoder, error)
+	NewEncoder(sampleRate int, channels int) (Encoder, error)
+}
+
+// OutputFunc receives a mixed frame, silent is set when no source contributed to it
+type OutputFunc func(pcm []int16, silent bool)
+
+type source struct {
+	gain   float64
+	frames [][]int16
+	// contribution to the current mix, nil when the source is silent or its frame did not arrive in time
+	mixed []int32
+}
+
+type output struct {
+	// the source excluded from the output, empty for a full mix
+	exclude livekit.ParticipantIdentity
+	fn      OutputFunc
+}
+
+// Mixer mixes one frame of every source each FrameDuration. Outputs receive the mix of every source but their own,
+// which is computed by subtracting a source's contribution from the full mix instead of mixing each output separately.
+// Sources that send comfort noise during silence (DTX) or nothing at all are left out of the mix.
+type Mixer struct {
+	lock    sync.Mutex
+	sources map[livekit.ParticipantIdentity]*source
+	gains   map[livekit.ParticipantIdentity]float64
+	outputs map[string]*output
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+func NewMixer() *Mixer {
+	return &Mixer{
+		sources: make(map[livekit.ParticipantIdentity]*source),
+		gains:   make(map[livekit.ParticipantIdentity]float64),
+		outputs: make(map[string]*output),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start mixes in real time until Stop
+func (m *Mixer) Start() {
+	go func() {
+		ticker := time.NewTicker(FrameDuration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				m.mix()
+			}
+		}
+	}()
+}
+
+func (m *Mixer) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+}
+
+// Push queues a decoded frame of a source, silence marks comfort noise frames
+func (m *Mixer) Push(identity livekit.ParticipantIdentity, pcm []int16, silence bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	s := m.sources[identity]
+	if s == nil {
+		s = &source{gain: 1}
+		if gain, ok := m.gains[identity]; ok {
+			s.gain = gain
+		}
+		m.sources[identity] = s
+	}
+	if silence {
+		// keeps the queue timing without adding noise to the mix
+		pcm = nil
+	}
+	if len(s.frames) == maxQueuedFrames {
+		s.frames = s.frames[1:]
+	}
+	s.frames = append(s.frames, pcm)
+}
+
+func (m *Mixer) RemoveSource(identity livekit.ParticipantIdentity) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.sources, identity)
+}
+
+// SetGain sets the linear gain applied to a source, 1 keeps the level and 0 mutes it in every output
+func (m *Mixer) SetGain(identity livekit.ParticipantIdentity, gain float64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.gains[identity] = gain
+	if s := m.sources[identity]; s != nil {
+		s.gain = gain
+	}
+}
+
+// AddOutput adds an output receiving the mix of every source except the excluded one
+func (m *Mixer) AddOutput(id string, exclude livekit.ParticipantIdentity, fn OutputFunc) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.outputs[id] = &output{exclude: exclude, fn: fn}
+}
+
+func (m *Mixer) RemoveOutput(id string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.outputs, id)
+}
+
+// IsEmpty returns true when the mixer has neither sources nor outputs
+func (m *Mixer) IsEmpty() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.sources) == 0 && len(m.outputs) == 0
+}
+
+// mix produces one frame for every output
+func (m *Mixer) mix() {
+	m.lock.Lock()
+	total := make([]int32, FrameSamples)
+	active := 0
+	for _, s := range m.sources {
+		s.mixed = nil
+		if len(s.frames) == 0 {
+			continue
+		}
+		frame := s.frames[0]
+		s.frames = s.frames[1:]
+		if frame == nil || s.gain == 0 {
+			continue
+		}
+
+		s.mixed = make([]int32, FrameSamples)
+		for i := 0; i < FrameSamples && i < len(frame); i++ {
+			s.mixed[i] = int32(math.Round(float64(frame[i]) * s.gain))
+			total[i] += s.mixed[i]
+		}
+		active++
+	}
+
+	type result struct {
+		fn     OutputFunc
+		pcm    []int16
+		silent bool
+	}
+	results := make([]result, 0, len(m.outputs))
+	for _, o := range m.outputs {
+		var own []int32
+		contributors := active
+		if s := m.sources[o.exclude]; s != nil && s.mixed != nil {
+			own = s.mixed
+			contributors--
+		}
+		pcm := make([]int16, FrameSamples)
+		if contributors > 0 {
+			for i := range pcm {
+				sample := total[i]
+				if own != nil {
+					sample -= own[i]
+				}
+				pcm[i] = clip(sample)
+			}
+		}
+		results = append(results, result{fn: o.fn, pcm: pcm, silent: contributors == 0})
+	}
+	m.lock.Unlock()
+
+	for _, r := range results {
+		r.fn(r.pcm, r.silent)
+	}
+}
+
+func clip(sample int32) int16 {
+	switch {
+	case sample > math.MaxInt16:
+		return math.MaxInt16
+	case sample < math.MinInt16:
+		return math.MinInt16
+	}
+	return int16(sample)
+}
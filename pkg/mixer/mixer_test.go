@@ -0,0 +1,96 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mixer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func frame(value int16) []int16 {
+	pcm := make([]int16, FrameSamples)
+	for i := range pcm {
+		pcm[i] = value
+	}
+	return pcm
+}
+
+type testOutput struct {
+	pcm    []int16
+	silent bool
+}
+
+func (o *testOutput) write(pcm []int16, silent bool) {
+	o.pcm, o.silent = pcm, silent
+}
+
+func TestMixer(t *testing.T) {
+	m := NewMixer()
+	alice, bob := &testOutput{}, &testOutput{}
+	m.AddOutput("alice", "alice", alice.write)
+	m.AddOutput("bob", "bob", bob.write)
+
+	t.Run("mix minus", func(t *testing.T) {
+		m.Push("alice", frame(100), false)
+		m.Push("bob", frame(200), false)
+		m.Push("carol", frame(300), false)
+		m.mix()
+		require.Equal(t, frame(500), alice.pcm)
+		require.Equal(t, frame(400), bob.pcm)
+	})
+
+	t.Run("comfort noise and missing frames are left out", func(t *testing.T) {
+		m.Push("alice", frame(100), false)
+		m.Push("bob", frame(5), true)
+		m.mix()
+		require.False(t, bob.silent)
+		require.Equal(t, frame(100), bob.pcm)
+		require.True(t, alice.silent)
+		require.Equal(t, frame(0), alice.pcm)
+	})
+
+	t.Run("gain and clipping", func(t *testing.T) {
+		m.SetGain("carol", 0.5)
+		m.Push("carol", frame(300), false)
+		m.Push("dave", frame(32700), false)
+		m.mix()
+		require.Equal(t, frame(32767), alice.pcm)
+
+		m.SetGain("dave", 0)
+		m.Push("carol", frame(300), false)
+		m.Push("dave", frame(32700), false)
+		m.mix()
+		require.Equal(t, frame(150), alice.pcm)
+	})
+
+	t.Run("queue is bounded", func(t *testing.T) {
+		for i := int16(1); i <= 5; i++ {
+			m.Push("carol", frame(i*2), false)
+		}
+		m.mix()
+		// the first two frames were dropped, carol's gain is 0.5
+		require.Equal(t, frame(3), alice.pcm)
+	})
+
+	m.RemoveOutput("alice")
+	m.RemoveOutput("bob")
+	for _, identity := range []string{"alice", "bob", "carol", "dave"} {
+		m.RemoveSource(livekit.ParticipantIdentity(identity))
+	}
+	require.True(t, m.IsEmpty())
+}
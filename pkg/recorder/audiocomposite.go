@@ -0,0 +1,267 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3/pkg/media/oggwriter"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/mixer"
+)
+
+const (
+	AudioFileTypeOGG = "ogg"
+	AudioFileTypeMP3 = "mp3"
+
+	frameQueueSize = 50
+)
+
+var ErrUnsupportedFileType = errors.New("audio can only be written to OGG or MP3")
+
+// AudioFileType is the type of an audio composite file, according to its extension
+func AudioFileType(filePath string) (string, error) {
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filePath), ".")); ext {
+	case AudioFileTypeOGG, AudioFileTypeMP3:
+		return ext, nil
+	default:
+		return "", ErrUnsupportedFileType
+	}
+}
+
+// AudioCompositeInfo describes an audio composite file, Location is set once a finished file has been stored
+type AudioCompositeInfo struct {
+	Filepath  string
+	Location  string
+	Size      int64
+	StartedAt time.Time
+	EndedAt   time.Time
+	Error     string
+}
+
+type AudioCompositeParams struct {
+	Config config.RecorderConfig
+	Room   livekit.RoomName
+	// relative to the recorder directory, the extension selects OGG or MP3
+	Filepath string
+	// encodes mixed frames to Opus for OGG, or to MP3 frames. Encoders buffering samples can implement
+	// Flush() ([]byte, error) to return the remaining data when the file is closed
+	Encoder mixer.Encoder
+	Logger  logger.Logger
+}
+
+// AudioComposite writes mixed audio frames of a room to an OGG/Opus or MP3 file
+type AudioComposite struct {
+	params AudioCompositeParams
+	file   *os.File
+	ogg    *oggwriter.OggWriter
+
+	lock sync.Mutex
+	info AudioCompositeInfo
+
+	frames         chan []int16
+	closed         atomic.Bool
+	done           chan struct{}
+	finished       chan struct{}
+	onFinishedOnce sync.Once
+	onFinished     func(info AudioCompositeInfo)
+}
+
+func NewAudioComposite(params AudioCompositeParams) (*AudioComposite, error) {
+	if params.Config.Directory == "" {
+		return nil, ErrRecorderDisabled
+	}
+	fileType, err := AudioFileType(params.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := filepath.Join(params.Config.Directory, filepath.Clean("/"+params.Filepath))
+	if err = os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return nil, err
+	}
+	c := &AudioComposite{
+		params: params,
+		info: AudioCompositeInfo{
+			Filepath:  fileName,
+			StartedAt: time.Now(),
+		},
+		frames:   make(chan []int16, frameQueueSize),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+	if fileType == AudioFileTypeOGG {
+		c.ogg, err = oggwriter.New(fileName, mixer.SampleRate, mixer.Channels)
+	} else {
+		c.file, err = os.Create(fileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Start writes frames until Stop, f is called once the file has been stored
+func (c *AudioComposite) Start(f func(info AudioCompositeInfo)) {
+	c.onFinished = f
+	go c.writeWorker()
+	c.params.Logger.Infow("audio composite started", "filepath", c.info.Filepath)
+}
+
+// WriteFrame queues a mixed frame of mixer.FrameSamples samples, frames are dropped when encoding falls behind
+func (c *AudioComposite) WriteFrame(pcm []int16) {
+	if c.closed.Load() {
+		return
+	}
+	select {
+	case c.frames <- pcm:
+	default:
+		c.params.Logger.Debugw("audio composite queue full, dropping frame")
+	}
+}
+
+// Stop writes the queued frames and finishes the file
+func (c *AudioComposite) Stop() {
+	if c.closed.Swap(true) {
+		return
+	}
+	close(c.done)
+}
+
+func (c *AudioComposite) Info() AudioCompositeInfo {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.info
+}
+
+// Finished is closed once the file has been stored
+func (c *AudioComposite) Finished() <-chan struct{} {
+	return c.finished
+}
+
+func (c *AudioComposite) writeWorker() {
+	defer c.finish()
+
+	var (
+		sn uint16
+		ts uint32
+	)
+	write := func(data []byte) {
+		var err error
+		if c.ogg != nil {
+			err = c.ogg.WriteRTP(&rtp.Packet{
+				Header:  rtp.Header{Version: 2, SequenceNumber: sn, Timestamp: ts},
+				Payload: data,
+			})
+			sn++
+			ts += uint32(mixer.FrameSamples)
+		} else if len(data) != 0 {
+			_, err = c.file.Write(data)
+		}
+		if err != nil {
+			c.params.Logger.Debugw("could not write audio", "error", err)
+		}
+	}
+	encode := func(pcm []int16) {
+		data, err := c.params.Encoder.Encode(pcm)
+		if err != nil {
+			c.params.Logger.Debugw("could not encode audio", "error", err)
+			return
+		}
+		write(data)
+	}
+
+	for {
+		select {
+		case pcm := <-c.frames:
+			encode(pcm)
+		case <-c.done:
+			for {
+				select {
+				case pcm := <-c.frames:
+					encode(pcm)
+				default:
+					if flusher, ok := c.params.Encoder.(interface{ Flush() ([]byte, error) }); ok {
+						if data, err := flusher.Flush(); err == nil && len(data) != 0 {
+							write(data)
+						}
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *AudioComposite) finish() {
+	var err error
+	if c.ogg != nil {
+		err = c.ogg.Close()
+	} else {
+		err = c.file.Close()
+	}
+
+	c.lock.Lock()
+	c.info.EndedAt = time.Now()
+	info := c.info
+	c.lock.Unlock()
+
+	if err == nil {
+		if stat, statErr := os.Stat(info.Filepath); statErr == nil {
+			info.Size = stat.Size()
+		}
+	}
+	if err == nil && c.params.Config.S3.Bucket != "" {
+		key := path.Join(string(c.params.Room), filepath.Base(info.Filepath))
+		var location string
+		location, err = NewS3Uploader(c.params.Config.S3).Upload(context.Background(), info.Filepath, key)
+		if err == nil {
+			info.Location = location
+			_ = os.Remove(info.Filepath)
+		}
+	} else if err == nil {
+		info.Location = info.Filepath
+	}
+	if err != nil {
+		info.Error = err.Error()
+		c.params.Logger.Errorw("could not store audio composite", err)
+	} else {
+		c.params.Logger.Infow("audio composite stored", "location", info.Location)
+	}
+
+	c.lock.Lock()
+	c.info = info
+	c.lock.Unlock()
+	close(c.finished)
+
+	if c.onFinished != nil {
+		c.onFinishedOnce.Do(func() {
+			c.onFinished(info)
+		})
+	}
+}
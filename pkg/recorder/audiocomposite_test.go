@@ -0,0 +1,123 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/mixer"
+)
+
+type testEncoder struct {
+	frames  int
+	flushed bool
+}
+
+func (e *testEncoder) Encode(_ []int16) ([]byte, error) {
+	e.frames++
+	return []byte{0xf8, 0xff, 0xfe}, nil
+}
+
+func (e *testEncoder) Flush() ([]byte, error) {
+	e.flushed = true
+	return []byte{0x00}, nil
+}
+
+func newTestAudioComposite(t *testing.T, filePath string, encoder mixer.Encoder) (*AudioComposite, error) {
+	return NewAudioComposite(AudioCompositeParams{
+		Config:   config.RecorderConfig{Directory: t.TempDir()},
+		Room:     "myroom",
+		Filepath: filePath,
+		Encoder:  encoder,
+		Logger:   logger.GetLogger(),
+	})
+}
+
+func writeFrames(c *AudioComposite, count int) {
+	for i := 0; i < count; i++ {
+		c.WriteFrame(make([]int16, mixer.FrameSamples))
+	}
+}
+
+func waitAudioComposite(t *testing.T, c *AudioComposite) AudioCompositeInfo {
+	select {
+	case <-c.Finished():
+	case <-time.After(5 * time.Second):
+		t.Fatal("audio composite did not finish")
+	}
+	return c.Info()
+}
+
+func TestAudioComposite(t *testing.T) {
+	t.Run("writes ogg", func(t *testing.T) {
+		encoder := &testEncoder{}
+		c, err := newTestAudioComposite(t, "calls/myroom.ogg", encoder)
+		require.NoError(t, err)
+		c.Start(nil)
+		writeFrames(c, 10)
+		c.Stop()
+
+		info := waitAudioComposite(t, c)
+		require.Empty(t, info.Error)
+		require.Equal(t, info.Filepath, info.Location)
+		require.Equal(t, "myroom.ogg", filepath.Base(info.Filepath))
+		require.Equal(t, 10, encoder.frames)
+		require.True(t, encoder.flushed)
+
+		data, err := os.ReadFile(info.Filepath)
+		require.NoError(t, err)
+		require.Equal(t, "OggS", string(data[:4]))
+		require.Equal(t, int64(len(data)), info.Size)
+	})
+
+	t.Run("writes mp3 frames", func(t *testing.T) {
+		c, err := newTestAudioComposite(t, "myroom.mp3", &testEncoder{})
+		require.NoError(t, err)
+		called := make(chan AudioCompositeInfo, 1)
+		c.Start(func(info AudioCompositeInfo) {
+			called <- info
+		})
+		writeFrames(c, 4)
+		c.Stop()
+
+		info := <-called
+		require.Empty(t, info.Error)
+		data, err := os.ReadFile(info.Filepath)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0xf8, 0xff, 0xfe, 0xf8, 0xff, 0xfe, 0xf8, 0xff, 0xfe, 0xf8, 0xff, 0xfe, 0x00}, data)
+	})
+
+	t.Run("stays in the directory", func(t *testing.T) {
+		c, err := newTestAudioComposite(t, "../../myroom.ogg", &testEncoder{})
+		require.NoError(t, err)
+		c.Start(nil)
+		c.Stop()
+		info := waitAudioComposite(t, c)
+		require.Equal(t, c.params.Config.Directory, filepath.Dir(info.Filepath))
+	})
+
+	t.Run("unsupported file type", func(t *testing.T) {
+		_, err := newTestAudioComposite(t, "myroom.wav", &testEncoder{})
+		require.ErrorIs(t, err, ErrUnsupportedFileType)
+	})
+}
@@ -0,0 +1,240 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// fragmented MP4 (ISO/IEC 14496-12) as used by CMAF and HLS, limited to one H.264 and one Opus track
+
+const (
+	mp4MovieTimescale = 1000
+
+	mp4SampleFlagsSync    = 0x02000000
+	mp4SampleFlagsNonSync = 0x01010000
+
+	mp4TrunDataOffset   = 0x000001
+	mp4TrunSampleFlags  = 0x000400
+	mp4TrunSampleSize   = 0x000200
+	mp4TrunSampleLength = 0x000100
+	mp4TfhdBaseIsMoof   = 0x020000
+)
+
+var mp4Matrix = []uint32{0x00010000, 0, 0, 0, 0x00010000, 0, 0, 0, 0x40000000}
+
+type mp4Track struct {
+	id        uint32
+	timescale uint32
+	video     bool
+
+	// video
+	width  uint32
+	height uint32
+	avcC   []byte
+
+	// audio
+	channels uint16
+}
+
+type mp4Sample struct {
+	dts      int64
+	duration uint32
+	data     []byte
+	sync     bool
+}
+
+func mp4Box(boxType string, payloads ...[]byte) []byte {
+	size := 8
+	for _, p := range payloads {
+		size += len(p)
+	}
+	box := make([]byte, 0, size)
+	box = binary.BigEndian.AppendUint32(box, uint32(size))
+	box = append(box, boxType...)
+	for _, p := range payloads {
+		box = append(box, p...)
+	}
+	return box
+}
+
+func mp4FullBox(boxType string, version byte, flags uint32, payloads ...[]byte) []byte {
+	header := []byte{version, byte(flags >> 16), byte(flags >> 8), byte(flags)}
+	return mp4Box(boxType, append([][]byte{header}, payloads...)...)
+}
+
+func mp4Uint32s(values ...uint32) []byte {
+	b := make([]byte, 0, 4*len(values))
+	for _, v := range values {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+	return b
+}
+
+// mp4Codecs returns the RFC 6381 codecs of the tracks, as signalled in HLS and DASH manifests
+func mp4Codecs(tracks []*mp4Track) string {
+	codecs := make([]string, 0, len(tracks))
+	for _, t := range tracks {
+		if !t.video {
+			codecs = append(codecs, "opus")
+		} else if len(t.avcC) >= 4 {
+			// profile, constraint flags and level of the AVCDecoderConfigurationRecord
+			codecs = append(codecs, fmt.Sprintf("avc1.%02x%02x%02x", t.avcC[1], t.avcC[2], t.avcC[3]))
+		}
+	}
+	return strings.Join(codecs, ",")
+}
+
+// mp4InitSegment returns the ftyp and moov boxes describing the tracks
+func mp4InitSegment(tracks []*mp4Track) []byte {
+	brands := []byte("iso6cmfcisommp41")
+	for _, t := range tracks {
+		if !t.video {
+			// Opus in ISOBMFF files carry the Opus brand
+			brands = append(brands, "Opus"...)
+			break
+		}
+	}
+	ftyp := mp4Box("ftyp", []byte("iso6"), mp4Uint32s(0), brands)
+
+	var nextTrackID uint32
+	var traks, trexs []byte
+	for _, t := range tracks {
+		traks = append(traks, mp4Trak(t)...)
+		trexs = append(trexs, mp4FullBox("trex", 0, 0, mp4Uint32s(t.id, 1, 0, 0, 0))...)
+		if t.id >= nextTrackID {
+			nextTrackID = t.id + 1
+		}
+	}
+
+	mvhd := mp4FullBox("mvhd", 0, 0,
+		mp4Uint32s(0, 0, mp4MovieTimescale, 0, 0x00010000),
+		[]byte{0x01, 0x00, 0, 0}, mp4Uint32s(0, 0),
+		mp4Uint32s(mp4Matrix...),
+		make([]byte, 24),
+		mp4Uint32s(nextTrackID),
+	)
+	moov := mp4Box("moov", mvhd, traks, mp4Box("mvex", trexs))
+	return append(ftyp, moov...)
+}
+
+func mp4Trak(t *mp4Track) []byte {
+	var volume uint16
+	if !t.video {
+		volume = 0x0100
+	}
+	tkhd := mp4FullBox("tkhd", 0, 0x000003,
+		mp4Uint32s(0, 0, t.id, 0, 0, 0, 0),
+		[]byte{0, 0, 0, 0, byte(volume >> 8), byte(volume), 0, 0},
+		mp4Uint32s(mp4Matrix...),
+		mp4Uint32s(t.width<<16, t.height<<16),
+	)
+
+	// language "und"
+	mdhd := mp4FullBox("mdhd", 0, 0, mp4Uint32s(0, 0, t.timescale, 0), []byte{0x55, 0xc4, 0, 0})
+
+	handler, name, mediaHeader := "soun", "SoundHandler", mp4FullBox("smhd", 0, 0, mp4Uint32s(0))
+	if t.video {
+		handler, name, mediaHeader = "vide", "VideoHandler", mp4FullBox("vmhd", 0, 1, make([]byte, 8))
+	}
+	hdlr := mp4FullBox("hdlr", 0, 0, mp4Uint32s(0), []byte(handler), make([]byte, 12), append([]byte(name), 0))
+
+	dinf := mp4Box("dinf", mp4FullBox("dref", 0, 0, mp4Uint32s(1), mp4FullBox("url ", 0, 1)))
+	stbl := mp4Box("stbl",
+		mp4FullBox("stsd", 0, 0, mp4Uint32s(1), mp4SampleEntry(t)),
+		mp4FullBox("stts", 0, 0, mp4Uint32s(0)),
+		mp4FullBox("stsc", 0, 0, mp4Uint32s(0)),
+		mp4FullBox("stsz", 0, 0, mp4Uint32s(0, 0)),
+		mp4FullBox("stco", 0, 0, mp4Uint32s(0)),
+	)
+	minf := mp4Box("minf", mediaHeader, dinf, stbl)
+	return mp4Box("trak", tkhd, mp4Box("mdia", mdhd, hdlr, minf))
+}
+
+func mp4SampleEntry(t *mp4Track) []byte {
+	// reserved and data_reference_index
+	header := []byte{0, 0, 0, 0, 0, 0, 0, 1}
+	if t.video {
+		return mp4Box("avc1",
+			header,
+			make([]byte, 16),
+			[]byte{byte(t.width >> 8), byte(t.width), byte(t.height >> 8), byte(t.height)},
+			mp4Uint32s(0x00480000, 0x00480000, 0),
+			[]byte{0, 1},
+			make([]byte, 32),
+			[]byte{0, 0x18, 0xff, 0xff},
+			mp4Box("avcC", t.avcC),
+		)
+	}
+
+	// Opus in ISOBMFF, https://opus-codec.org/docs/opus_in_isobmff.html
+	channels := t.channels
+	if channels == 0 {
+		channels = 2
+	}
+	dOps := []byte{0, byte(channels), 0, 0}
+	dOps = binary.BigEndian.AppendUint32(dOps, 48000)
+	dOps = append(dOps, 0, 0, 0)
+	return mp4Box("Opus",
+		header,
+		mp4Uint32s(0, 0),
+		[]byte{byte(channels >> 8), byte(channels), 0, 16, 0, 0, 0, 0},
+		mp4Uint32s(48000<<16),
+		mp4Box("dOps", dOps),
+	)
+}
+
+// mp4Fragment returns a moof and mdat pair holding the samples of each track, samples of a track are contiguous
+func mp4Fragment(sequence uint32, tracks []*mp4Track, samples [][]mp4Sample) []byte {
+	build := func(offsets []uint32) []byte {
+		var trafs []byte
+		for i, t := range tracks {
+			if len(samples[i]) == 0 {
+				continue
+			}
+			trun := mp4Uint32s(uint32(len(samples[i])), offsets[i])
+			for _, s := range samples[i] {
+				flags := uint32(mp4SampleFlagsNonSync)
+				if s.sync {
+					flags = mp4SampleFlagsSync
+				}
+				trun = append(trun, mp4Uint32s(s.duration, uint32(len(s.data)), flags)...)
+			}
+			baseTime := make([]byte, 8)
+			binary.BigEndian.PutUint64(baseTime, uint64(samples[i][0].dts))
+			trafs = append(trafs, mp4Box("traf",
+				mp4FullBox("tfhd", 0, mp4TfhdBaseIsMoof, mp4Uint32s(t.id)),
+				mp4FullBox("tfdt", 1, 0, baseTime),
+				mp4FullBox("trun", 0, mp4TrunDataOffset|mp4TrunSampleLength|mp4TrunSampleSize|mp4TrunSampleFlags, trun),
+			)...)
+		}
+		return mp4Box("moof", mp4FullBox("mfhd", 0, 0, mp4Uint32s(sequence)), trafs)
+	}
+
+	// sizes do not depend on the offsets, so the moof is built twice to learn where the media data starts
+	offsets := make([]uint32, len(tracks))
+	moofSize := uint32(len(build(offsets)))
+
+	var mdat []byte
+	for i := range tracks {
+		offsets[i] = moofSize + 8 + uint32(len(mdat))
+		for _, s := range samples[i] {
+			mdat = append(mdat, s.data...)
+		}
+	}
+	return append(build(offsets), mp4Box("mdat", mdat)...)
+}
@@ -0,0 +1,413 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
+)
+
+const (
+	h264NALUIDR = 5
+	h264NALUSPS = 7
+	h264NALUPPS = 8
+	h264NALUAUD = 9
+)
+
+var errSPSTruncated = errors.New("truncated sequence parameter set")
+
+// h264Frame is an access unit of length prefixed NAL units, parameter sets are kept separately
+type h264Frame struct {
+	timestamp uint32
+	arrival   time.Time
+	data      []byte
+	key       bool
+}
+
+// h264Assembler depacketizes RTP into frames. After a packet is lost frames are dropped until the next key frame
+type h264Assembler struct {
+	depacketizer    *codecs.H264Packet
+	started         bool
+	lastSN          uint16
+	frame           h264Frame
+	broken          bool
+	waitingKeyFrame bool
+
+	sps []byte
+	pps []byte
+}
+
+func newH264Assembler() *h264Assembler {
+	return &h264Assembler{
+		depacketizer:    &codecs.H264Packet{IsAVC: true},
+		waitingKeyFrame: true,
+	}
+}
+
+// NeedsKeyFrame reports whether frames are being dropped until a key frame arrives
+func (a *h264Assembler) NeedsKeyFrame() bool {
+	return a.waitingKeyFrame || a.sps == nil || a.pps == nil
+}
+
+func (a *h264Assembler) SPS() []byte {
+	return a.sps
+}
+
+func (a *h264Assembler) PPS() []byte {
+	return a.pps
+}
+
+// Push adds a packet and returns the frames it completes, frames without a marker bit are completed by the next timestamp
+func (a *h264Assembler) Push(pkt *rtp.Packet, arrival time.Time) []h264Frame {
+	if a.started && pkt.SequenceNumber != a.lastSN+1 {
+		// a frame with a missing packet cannot be decoded, as well as frames referring to it
+		a.reset()
+	}
+	a.started = true
+	a.lastSN = pkt.SequenceNumber
+
+	var frames []h264Frame
+	if len(a.frame.data) != 0 && pkt.Timestamp != a.frame.timestamp {
+		frames = a.complete(frames)
+	}
+	if len(a.frame.data) == 0 {
+		a.frame.timestamp = pkt.Timestamp
+		a.frame.arrival = arrival
+	}
+
+	nalus, err := a.depacketizer.Unmarshal(pkt.Payload)
+	if err != nil {
+		a.reset()
+	} else {
+		a.appendNALUs(nalus)
+	}
+
+	if pkt.Marker {
+		frames = a.complete(frames)
+	}
+	return frames
+}
+
+func (a *h264Assembler) reset() {
+	a.depacketizer = &codecs.H264Packet{IsAVC: true}
+	a.broken = true
+	a.waitingKeyFrame = true
+}
+
+func (a *h264Assembler) appendNALUs(data []byte) {
+	for len(data) > 4 {
+		size := int(binary.BigEndian.Uint32(data))
+		if size == 0 || size > len(data)-4 {
+			return
+		}
+		nalu := data[4 : 4+size]
+		data = data[4+size:]
+
+		switch nalu[0] & 0x1f {
+		case h264NALUSPS:
+			a.sps = append([]byte{}, nalu...)
+			continue
+		case h264NALUPPS:
+			a.pps = append([]byte{}, nalu...)
+			continue
+		case h264NALUAUD:
+			continue
+		case h264NALUIDR:
+			a.frame.key = true
+		}
+		a.frame.data = binary.BigEndian.AppendUint32(a.frame.data, uint32(size))
+		a.frame.data = append(a.frame.data, nalu...)
+	}
+}
+
+func (a *h264Assembler) complete(frames []h264Frame) []h264Frame {
+	frame, broken := a.frame, a.broken
+	a.frame, a.broken = h264Frame{}, false
+	if len(frame.data) == 0 || broken {
+		return frames
+	}
+	if frame.key && len(a.sps) > 3 && len(a.pps) != 0 {
+		a.waitingKeyFrame = false
+	}
+	if a.waitingKeyFrame {
+		return frames
+	}
+	return append(frames, frame)
+}
+
+// avcDecoderConfigurationRecord as defined in ISO/IEC 14496-15
+func avcDecoderConfigurationRecord(sps []byte, pps []byte) []byte {
+	record := []byte{1, sps[1], sps[2], sps[3], 0xff, 0xe1}
+	record = binary.BigEndian.AppendUint16(record, uint16(len(sps)))
+	record = append(record, sps...)
+	record = append(record, 1)
+	record = binary.BigEndian.AppendUint16(record, uint16(len(pps)))
+	return append(record, pps...)
+}
+
+// trackClock maps RTP timestamps of a track to a timeline shared with the other tracks of an output.
+// Tracks are aligned by the arrival of their first packet
+type trackClock struct {
+	clockRate uint32
+	started   bool
+	offset    int64
+	lastTS    uint32
+	elapsed   int64
+}
+
+// Ticks returns the time since the output started in units of the clock rate
+func (c *trackClock) Ticks(timestamp uint32, arrival time.Time, startedAt time.Time) int64 {
+	if !c.started {
+		c.started = true
+		c.offset = int64(arrival.Sub(startedAt)) * int64(c.clockRate) / int64(time.Second)
+		c.lastTS = timestamp
+	}
+	c.elapsed += int64(int32(timestamp - c.lastTS))
+	c.lastTS = timestamp
+	if ticks := c.offset + c.elapsed; ticks > 0 {
+		return ticks
+	}
+	return 0
+}
+
+// Milliseconds returns the time since the output started in milliseconds
+func (c *trackClock) Milliseconds(timestamp uint32, arrival time.Time, startedAt time.Time) uint32 {
+	return uint32(c.Ticks(timestamp, arrival, startedAt) * 1000 / int64(c.clockRate))
+}
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) readBit() (uint32, error) {
+	if r.pos >= len(r.data)*8 {
+		return 0, errSPSTruncated
+	}
+	bit := uint32(r.data[r.pos/8]>>(7-r.pos%8)) & 1
+	r.pos++
+	return bit, nil
+}
+
+func (r *bitReader) readBits(n int) (uint32, error) {
+	var v uint32
+	for i := 0; i < n; i++ {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		v = v<<1 | bit
+	}
+	return v, nil
+}
+
+// readUE reads an Exp-Golomb coded unsigned integer
+func (r *bitReader) readUE() (uint32, error) {
+	zeros := 0
+	for {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if bit == 1 {
+			break
+		}
+		zeros++
+		if zeros > 31 {
+			return 0, errSPSTruncated
+		}
+	}
+	v, err := r.readBits(zeros)
+	return (1<<zeros - 1) + v, err
+}
+
+func (r *bitReader) readSE() (int32, error) {
+	v, err := r.readUE()
+	if v%2 == 0 {
+		return -int32(v / 2), err
+	}
+	return int32(v/2) + 1, err
+}
+
+// h264Dimensions parses the picture size from a sequence parameter set, ITU-T H.264 7.3.2.1.1
+func h264Dimensions(sps []byte) (width uint32, height uint32, err error) {
+	if len(sps) < 4 {
+		return 0, 0, errSPSTruncated
+	}
+	// remove emulation prevention bytes
+	rbsp := make([]byte, 0, len(sps))
+	for i := 1; i < len(sps); i++ {
+		if i >= 3 && sps[i] == 3 && sps[i-1] == 0 && sps[i-2] == 0 {
+			continue
+		}
+		rbsp = append(rbsp, sps[i])
+	}
+
+	r := &bitReader{data: rbsp[3:]}
+	profile := rbsp[0]
+	if _, err = r.readUE(); err != nil {
+		return
+	}
+	chromaFormat := uint32(1)
+	separateColourPlane := uint32(0)
+	switch profile {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		if chromaFormat, err = r.readUE(); err != nil {
+			return
+		}
+		if chromaFormat == 3 {
+			if separateColourPlane, err = r.readBit(); err != nil {
+				return
+			}
+		}
+		// bit depths and qpprime_y_zero_transform_bypass_flag
+		if _, err = r.readUE(); err != nil {
+			return
+		}
+		if _, err = r.readUE(); err != nil {
+			return
+		}
+		if _, err = r.readBit(); err != nil {
+			return
+		}
+		var scalingMatrix uint32
+		if scalingMatrix, err = r.readBit(); err != nil {
+			return
+		}
+		if scalingMatrix == 1 {
+			lists := 8
+			if chromaFormat == 3 {
+				lists = 12
+			}
+			for i := 0; i < lists; i++ {
+				var present uint32
+				if present, err = r.readBit(); err != nil {
+					return
+				}
+				if present == 0 {
+					continue
+				}
+				size := 16
+				if i >= 6 {
+					size = 64
+				}
+				last, next := int32(8), int32(8)
+				for j := 0; j < size; j++ {
+					if next != 0 {
+						var delta int32
+						if delta, err = r.readSE(); err != nil {
+							return
+						}
+						next = (last + delta + 256) % 256
+					}
+					if next != 0 {
+						last = next
+					}
+				}
+			}
+		}
+	}
+
+	// log2_max_frame_num_minus4
+	if _, err = r.readUE(); err != nil {
+		return
+	}
+	var pocType uint32
+	if pocType, err = r.readUE(); err != nil {
+		return
+	}
+	switch pocType {
+	case 0:
+		if _, err = r.readUE(); err != nil {
+			return
+		}
+	case 1:
+		if _, err = r.readBit(); err != nil {
+			return
+		}
+		if _, err = r.readSE(); err != nil {
+			return
+		}
+		if _, err = r.readSE(); err != nil {
+			return
+		}
+		var cycle uint32
+		if cycle, err = r.readUE(); err != nil {
+			return
+		}
+		for i := uint32(0); i < cycle; i++ {
+			if _, err = r.readSE(); err != nil {
+				return
+			}
+		}
+	}
+	// max_num_ref_frames and gaps_in_frame_num_value_allowed_flag
+	if _, err = r.readUE(); err != nil {
+		return
+	}
+	if _, err = r.readBit(); err != nil {
+		return
+	}
+
+	var widthInMbs, heightInMapUnits, frameMbsOnly uint32
+	if widthInMbs, err = r.readUE(); err != nil {
+		return
+	}
+	if heightInMapUnits, err = r.readUE(); err != nil {
+		return
+	}
+	if frameMbsOnly, err = r.readBit(); err != nil {
+		return
+	}
+	if frameMbsOnly == 0 {
+		if _, err = r.readBit(); err != nil {
+			return
+		}
+	}
+	// direct_8x8_inference_flag
+	if _, err = r.readBit(); err != nil {
+		return
+	}
+
+	width = (widthInMbs + 1) * 16
+	height = (2 - frameMbsOnly) * (heightInMapUnits + 1) * 16
+
+	var cropping uint32
+	if cropping, err = r.readBit(); err != nil || cropping == 0 {
+		return
+	}
+	var crop [4]uint32
+	for i := range crop {
+		if crop[i], err = r.readUE(); err != nil {
+			return
+		}
+	}
+	cropX, cropY := uint32(1), 2-frameMbsOnly
+	if chromaFormat != 0 && separateColourPlane == 0 {
+		if chromaFormat == 1 || chromaFormat == 2 {
+			cropX = 2
+		}
+		if chromaFormat == 1 {
+			cropY *= 2
+		}
+	}
+	width -= cropX * (crop[0] + crop[1])
+	height -= cropY * (crop[2] + crop[3])
+	return
+}
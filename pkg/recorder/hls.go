@@ -0,0 +1,853 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+const (
+	HLSStreamPrefix = "HS_"
+
+	hlsAudioTrack = 0
+	hlsVideoTrack = 1
+
+	mp4VideoTrackID = 1
+	mp4AudioTrackID = 2
+
+	hlsPlaylist             = "index.m3u8"
+	hlsMultivariantPlaylist = "master.m3u8"
+	hlsPlaylistType         = "application/vnd.apple.mpegurl"
+	hlsSegmentType          = "video/mp4"
+	hlsUploadQueueSize      = 100
+	hlsUploadTimeout        = time.Minute
+	hlsPartsInPlaylist      = 3
+	hlsBlockingWaitParts    = 3
+
+	// advertised until a segment has been completed
+	hlsDefaultVideoBandwidth = 2_500_000
+	hlsDefaultAudioBandwidth = 64_000
+)
+
+var ErrHLSNotFound = errors.New("hls resource not found")
+
+// HLSStreamInfo describes an HLS stream. PlaylistPath and Location, the copy uploaded to S3, are the multivariant
+// playlist, which signals the codecs of the stream
+type HLSStreamInfo struct {
+	ID           string    `json:"id"`
+	Room         string    `json:"room"`
+	Identity     string    `json:"identity"`
+	AudioTrackID string    `json:"audio_track_id,omitempty"`
+	VideoTrackID string    `json:"video_track_id,omitempty"`
+	PlaylistPath string    `json:"playlist_path"`
+	Location     string    `json:"location,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+	EndedAt      time.Time `json:"ended_at,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+type HLSStreamParams struct {
+	Config config.HLSOutputConfig
+	// segments are uploaded when a bucket is set
+	S3 config.S3Config
+	// path the stream is served under, followed by the stream ID
+	PathPrefix   string
+	Room         livekit.RoomName
+	Identity     livekit.ParticipantIdentity
+	AudioTrackID livekit.TrackID
+	Audio        sfu.TrackReceiver
+	VideoTrackID livekit.TrackID
+	Video        sfu.TrackReceiver
+	Logger       logger.Logger
+}
+
+type hlsPart struct {
+	data        []byte
+	duration    float64
+	independent bool
+}
+
+type hlsSegment struct {
+	sequence      int
+	init          int
+	discontinuity bool
+	parts         []*hlsPart
+	duration      float64
+	complete      bool
+}
+
+func (s *hlsSegment) data() []byte {
+	var data []byte
+	for _, p := range s.parts {
+		data = append(data, p.data...)
+	}
+	return data
+}
+
+type hlsUpload struct {
+	key         string
+	data        []byte
+	contentType string
+	playlist    bool
+}
+
+// HLSStream packages the audio and video track of a participant as low-latency HLS with fragmented MP4 segments
+// and partial segments, served by the node. Tracks are not transcoded, video has to be H.264 and audio is
+// packaged as Opus in fMP4, which players only select when the codecs are signalled, so streams are played
+// through their multivariant playlist. When S3 is configured, complete segments and a regular HLS playlist
+// are uploaded as well
+type HLSStream struct {
+	params   HLSStreamParams
+	sinks    [2]*trackSink
+	uploader *S3Uploader
+
+	packets        chan sinkPacket
+	uploads        chan hlsUpload
+	closed         atomic.Bool
+	done           chan struct{}
+	finished       chan struct{}
+	onFinishedOnce sync.Once
+	onFinished     func(info HLSStreamInfo)
+
+	// owned by writeWorker
+	startedAt    time.Time
+	clocks       [2]trackClock
+	assembler    *h264Assembler
+	tracks       []*mp4Track
+	trackIndex   [2]int
+	held         [2]*mp4Sample
+	lastDuration [2]uint32
+	pending      [][]mp4Sample
+	fragment     uint32
+	initSPS      []byte
+	initPPS      []byte
+	lastPLI      time.Time
+
+	lock                  sync.Mutex
+	info                  HLSStreamInfo
+	inits                 [][]byte
+	codecs                string
+	width                 uint32
+	height                uint32
+	peakBandwidth         int
+	segments              []*hlsSegment
+	nextSequence          int
+	discontinuityPending  bool
+	discontinuitySequence int
+	targetDuration        int
+	ended                 bool
+	updated               chan struct{}
+}
+
+func NewHLSStream(params HLSStreamParams) (*HLSStream, error) {
+	if params.Audio == nil && params.Video == nil {
+		return nil, ErrNoTracksToPush
+	}
+	if params.Audio != nil && !strings.EqualFold(params.Audio.Codec().MimeType, webrtc.MimeTypeOpus) {
+		return nil, ErrUnsupportedCodec
+	}
+	if params.Video != nil && !strings.EqualFold(params.Video.Codec().MimeType, webrtc.MimeTypeH264) {
+		return nil, ErrUnsupportedCodec
+	}
+
+	id := utils.NewGuid(HLSStreamPrefix)
+	h := &HLSStream{
+		params: params,
+		info: HLSStreamInfo{
+			ID:           id,
+			Room:         string(params.Room),
+			Identity:     string(params.Identity),
+			AudioTrackID: string(params.AudioTrackID),
+			VideoTrackID: string(params.VideoTrackID),
+			PlaylistPath: path.Join(params.PathPrefix, id, hlsMultivariantPlaylist),
+		},
+		packets:        make(chan sinkPacket, packetQueueSize),
+		done:           make(chan struct{}),
+		finished:       make(chan struct{}),
+		assembler:      newH264Assembler(),
+		trackIndex:     [2]int{-1, -1},
+		targetDuration: int(math.Ceil(params.Config.SegmentDuration.Seconds())),
+		updated:        make(chan struct{}),
+	}
+	if params.S3.Bucket != "" {
+		h.uploader = NewS3Uploader(params.S3)
+		h.uploads = make(chan hlsUpload, hlsUploadQueueSize)
+	}
+
+	// video is the first track of the fragments, parts and segments are cut on its frames
+	receivers := h.receivers()
+	for _, i := range []int{hlsVideoTrack, hlsAudioTrack} {
+		if receivers[i] == nil {
+			continue
+		}
+		h.sinks[i] = newTrackSink(id, i, h.packets, params.Logger, h.close)
+		h.clocks[i].clockRate = receivers[i].Codec().ClockRate
+		h.trackIndex[i] = len(h.tracks)
+		if i == hlsVideoTrack {
+			h.tracks = append(h.tracks, &mp4Track{id: mp4VideoTrackID, timescale: h.clocks[i].clockRate, video: true})
+		} else {
+			h.tracks = append(h.tracks, &mp4Track{id: mp4AudioTrackID, timescale: h.clocks[i].clockRate, channels: receivers[i].Codec().Channels})
+		}
+	}
+	h.pending = make([][]mp4Sample, len(h.tracks))
+	return h, nil
+}
+
+func (h *HLSStream) receivers() [2]sfu.TrackReceiver {
+	return [2]sfu.TrackReceiver{hlsAudioTrack: h.params.Audio, hlsVideoTrack: h.params.Video}
+}
+
+// Start attaches the stream to the receivers, f is called once the stream has ended and its uploads are done
+func (h *HLSStream) Start(f func(info HLSStreamInfo)) error {
+	h.onFinished = f
+	h.startedAt = time.Now()
+	h.lock.Lock()
+	h.info.StartedAt = h.startedAt
+	h.lock.Unlock()
+
+	if h.uploads != nil {
+		go h.uploadWorker()
+	}
+	go h.writeWorker()
+	for i, receiver := range h.receivers() {
+		if receiver == nil {
+			continue
+		}
+		if err := receiver.AddDownTrack(h.sinks[i]); err != nil {
+			h.close()
+			<-h.finished
+			return err
+		}
+	}
+	h.requestKeyFrame()
+	h.params.Logger.Infow("hls stream started", "streamID", h.info.ID)
+	return nil
+}
+
+// Stop ends the stream, the playlist stays available with an end tag
+func (h *HLSStream) Stop() {
+	h.close()
+}
+
+func (h *HLSStream) Info() HLSStreamInfo {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.info
+}
+
+// Finished is closed once the stream has ended and its uploads are done
+func (h *HLSStream) Finished() <-chan struct{} {
+	return h.finished
+}
+
+func (h *HLSStream) close() {
+	if h.closed.Swap(true) {
+		return
+	}
+	close(h.done)
+}
+
+func (h *HLSStream) writeWorker() {
+	defer h.finish()
+	for {
+		select {
+		case <-h.done:
+			return
+		case pkt := <-h.packets:
+			if pkt.track == hlsAudioTrack {
+				h.writeAudio(pkt)
+			} else {
+				h.writeVideo(pkt)
+			}
+		}
+	}
+}
+
+func (h *HLSStream) finish() {
+	for i, receiver := range h.receivers() {
+		if receiver != nil {
+			receiver.DeleteDownTrack(h.sinks[i].SubscriberID())
+			h.sinks[i].Close()
+		}
+	}
+
+	// samples held back for their duration are given the duration of the previous sample
+	for i, held := range h.held {
+		if held != nil {
+			held.duration = h.lastDuration[i]
+			h.pending[h.trackIndex[i]] = append(h.pending[h.trackIndex[i]], *held)
+		}
+	}
+	h.cutPart()
+	h.closeSegment()
+
+	h.lock.Lock()
+	h.ended = true
+	h.info.EndedAt = time.Now()
+	h.notifyLocked()
+	h.lock.Unlock()
+
+	if h.uploads == nil {
+		h.notifyFinished()
+		return
+	}
+	h.queuePlaylistUpload()
+	// with the bandwidth of the complete stream
+	h.queueMultivariantUpload()
+	close(h.uploads)
+}
+
+func (h *HLSStream) uploadWorker() {
+	var failed error
+	for upload := range h.uploads {
+		ctx, cancel := context.WithTimeout(context.Background(), hlsUploadTimeout)
+		location, err := h.uploader.UploadData(ctx, upload.data, path.Join(h.info.Room, h.info.ID, upload.key), upload.contentType)
+		cancel()
+		if err != nil {
+			h.params.Logger.Warnw("could not upload hls file", err, "streamID", h.info.ID, "key", upload.key)
+			failed = err
+			continue
+		}
+		if upload.playlist {
+			h.lock.Lock()
+			h.info.Location = location
+			h.lock.Unlock()
+		}
+	}
+
+	h.lock.Lock()
+	if failed != nil {
+		h.info.Error = failed.Error()
+	}
+	h.lock.Unlock()
+	h.notifyFinished()
+}
+
+func (h *HLSStream) notifyFinished() {
+	info := h.Info()
+	h.params.Logger.Infow("hls stream ended", "streamID", info.ID, "location", info.Location)
+	close(h.finished)
+	if h.onFinished != nil {
+		h.onFinishedOnce.Do(func() {
+			h.onFinished(info)
+		})
+	}
+}
+
+func (h *HLSStream) queueUpload(upload hlsUpload) {
+	select {
+	case h.uploads <- upload:
+	default:
+		h.params.Logger.Warnw("hls upload queue full", nil, "streamID", h.info.ID, "key", upload.key)
+	}
+}
+
+func (h *HLSStream) queuePlaylistUpload() {
+	h.lock.Lock()
+	playlist := h.playlistLocked(false)
+	h.lock.Unlock()
+	h.queueUpload(hlsUpload{key: hlsPlaylist, data: []byte(playlist), contentType: hlsPlaylistType})
+}
+
+func (h *HLSStream) queueMultivariantUpload() {
+	h.lock.Lock()
+	if h.codecs == "" {
+		h.lock.Unlock()
+		return
+	}
+	playlist := h.multivariantPlaylistLocked()
+	h.lock.Unlock()
+	h.queueUpload(hlsUpload{key: hlsMultivariantPlaylist, data: []byte(playlist), contentType: hlsPlaylistType, playlist: true})
+}
+
+func (h *HLSStream) writeAudio(pkt sinkPacket) {
+	if len(pkt.packet.Payload) == 0 {
+		return
+	}
+	if h.inits == nil {
+		if h.params.Video != nil {
+			// the stream starts with the first video key frame
+			return
+		}
+		h.createInit()
+	}
+	ticks := h.clocks[hlsAudioTrack].Ticks(pkt.packet.Timestamp, pkt.arrival, h.startedAt)
+	h.addSample(hlsAudioTrack, mp4Sample{dts: ticks, data: pkt.packet.Payload, sync: true})
+}
+
+func (h *HLSStream) writeVideo(pkt sinkPacket) {
+	for _, frame := range h.assembler.Push(pkt.packet, pkt.arrival) {
+		if frame.key {
+			sps, pps := h.assembler.SPS(), h.assembler.PPS()
+			if h.inits == nil || !bytes.Equal(sps, h.initSPS) || !bytes.Equal(pps, h.initPPS) {
+				if h.inits != nil {
+					// a new resolution needs a new initialization section
+					h.cutPart()
+					h.closeSegment()
+					h.lock.Lock()
+					h.discontinuityPending = true
+					h.lock.Unlock()
+				}
+				h.initSPS, h.initPPS = sps, pps
+				h.createInit()
+			}
+		}
+		if h.inits == nil {
+			continue
+		}
+		ticks := h.clocks[hlsVideoTrack].Ticks(frame.timestamp, frame.arrival, h.startedAt)
+		h.addSample(hlsVideoTrack, mp4Sample{dts: ticks, data: frame.data, sync: frame.key})
+	}
+	if h.assembler.NeedsKeyFrame() {
+		h.requestKeyFrame()
+	}
+}
+
+func (h *HLSStream) createInit() {
+	if idx := h.trackIndex[hlsVideoTrack]; idx >= 0 {
+		track := h.tracks[idx]
+		track.avcC = avcDecoderConfigurationRecord(h.initSPS, h.initPPS)
+		width, height, err := h264Dimensions(h.initSPS)
+		if err != nil {
+			h.params.Logger.Debugw("could not parse SPS", "error", err, "streamID", h.info.ID)
+		}
+		track.width, track.height = width, height
+	}
+	init := mp4InitSegment(h.tracks)
+
+	h.lock.Lock()
+	h.inits = append(h.inits, init)
+	version := len(h.inits) - 1
+	// the first initialization section decides the codecs, later ones only change the resolution
+	first := h.codecs == ""
+	if first {
+		h.codecs = mp4Codecs(h.tracks)
+	}
+	if idx := h.trackIndex[hlsVideoTrack]; idx >= 0 && h.tracks[idx].width > h.width {
+		h.width, h.height = h.tracks[idx].width, h.tracks[idx].height
+	}
+	h.notifyLocked()
+	h.lock.Unlock()
+
+	if h.uploads != nil {
+		h.queueUpload(hlsUpload{key: initName(version), data: init, contentType: hlsSegmentType})
+		if first {
+			h.queueMultivariantUpload()
+		}
+	}
+}
+
+// primaryTrack is the track parts and segments are cut on
+func (h *HLSStream) primaryTrack() int {
+	if h.trackIndex[hlsVideoTrack] >= 0 {
+		return hlsVideoTrack
+	}
+	return hlsAudioTrack
+}
+
+func (h *HLSStream) addSample(track int, sample mp4Sample) {
+	idx := h.trackIndex[track]
+	if held := h.held[track]; held != nil {
+		held.duration = 1
+		if sample.dts > held.dts {
+			held.duration = uint32(sample.dts - held.dts)
+		}
+		h.lastDuration[track] = held.duration
+		h.pending[idx] = append(h.pending[idx], *held)
+	}
+
+	if track == h.primaryTrack() {
+		pending := h.pendingDuration()
+		h.lock.Lock()
+		var segmentDuration float64
+		if len(h.segments) != 0 && !h.segments[len(h.segments)-1].complete {
+			segmentDuration = h.segments[len(h.segments)-1].duration
+		}
+		h.lock.Unlock()
+
+		switch {
+		case sample.sync && segmentDuration+pending >= h.params.Config.SegmentDuration.Seconds():
+			h.cutPart()
+			h.closeSegment()
+		case pending >= h.params.Config.PartDuration.Seconds():
+			h.cutPart()
+		}
+	}
+	h.held[track] = &sample
+}
+
+func (h *HLSStream) pendingDuration() float64 {
+	primary := h.primaryTrack()
+	var duration uint32
+	for _, s := range h.pending[h.trackIndex[primary]] {
+		duration += s.duration
+	}
+	return float64(duration) / float64(h.clocks[primary].clockRate)
+}
+
+func (h *HLSStream) cutPart() {
+	primary := h.pending[h.trackIndex[h.primaryTrack()]]
+	if len(primary) == 0 {
+		return
+	}
+	part := &hlsPart{
+		data:        mp4Fragment(h.fragment, h.tracks, h.pending),
+		duration:    h.pendingDuration(),
+		independent: primary[0].sync,
+	}
+	h.fragment++
+	for i := range h.pending {
+		h.pending[i] = nil
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.segments) == 0 || h.segments[len(h.segments)-1].complete {
+		h.segments = append(h.segments, &hlsSegment{
+			sequence:      h.nextSequence,
+			init:          len(h.inits) - 1,
+			discontinuity: h.discontinuityPending,
+		})
+		h.nextSequence++
+		h.discontinuityPending = false
+	}
+	segment := h.segments[len(h.segments)-1]
+	segment.parts = append(segment.parts, part)
+	segment.duration += part.duration
+	h.notifyLocked()
+}
+
+func (h *HLSStream) closeSegment() {
+	h.lock.Lock()
+	if len(h.segments) == 0 || h.segments[len(h.segments)-1].complete {
+		h.lock.Unlock()
+		return
+	}
+	segment := h.segments[len(h.segments)-1]
+	segment.complete = true
+	if duration := int(math.Ceil(segment.duration)); duration > h.targetDuration {
+		h.targetDuration = duration
+	}
+	if segment.duration > 0 {
+		size := 0
+		for _, p := range segment.parts {
+			size += len(p.data)
+		}
+		if bandwidth := int(float64(8*size) / segment.duration); bandwidth > h.peakBandwidth {
+			h.peakBandwidth = bandwidth
+		}
+	}
+	for len(h.segments) > 1 && len(h.segments) > h.params.Config.PlaylistSize {
+		if h.segments[0].discontinuity {
+			h.discontinuitySequence++
+		}
+		h.segments = h.segments[1:]
+	}
+	h.notifyLocked()
+	h.lock.Unlock()
+
+	if h.uploads != nil {
+		h.queueUpload(hlsUpload{key: segmentName(segment.sequence), data: segment.data(), contentType: hlsSegmentType})
+		h.queuePlaylistUpload()
+	}
+}
+
+func (h *HLSStream) notifyLocked() {
+	close(h.updated)
+	h.updated = make(chan struct{})
+}
+
+func (h *HLSStream) requestKeyFrame() {
+	if h.params.Video == nil || time.Since(h.lastPLI) < rtmpPLIInterval {
+		return
+	}
+	h.lastPLI = time.Now()
+	h.params.Video.SendPLI(0, true)
+}
+
+func initName(version int) string {
+	return fmt.Sprintf("init%d.mp4", version)
+}
+
+func segmentName(sequence int) string {
+	return fmt.Sprintf("seg%d.m4s", sequence)
+}
+
+func partName(sequence int, part int) string {
+	return fmt.Sprintf("part%d.%d.m4s", sequence, part)
+}
+
+// multivariantPlaylistLocked renders the playlist pointing to the media playlist, with the codecs, resolution and
+// peak bandwidth of the stream
+func (h *HLSStream) multivariantPlaylistLocked() string {
+	bandwidth := h.peakBandwidth
+	if bandwidth == 0 {
+		if h.params.Video != nil {
+			bandwidth += hlsDefaultVideoBandwidth
+		}
+		if h.params.Audio != nil {
+			bandwidth += hlsDefaultAudioBandwidth
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#EXT-X-VERSION:7\n")
+	b.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+	fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,CODECS=\"%s\"", bandwidth, h.codecs)
+	if h.width != 0 && h.height != 0 {
+		fmt.Fprintf(&b, ",RESOLUTION=%dx%d", h.width, h.height)
+	}
+	fmt.Fprintf(&b, "\n%s\n", hlsPlaylist)
+	return b.String()
+}
+
+// playlistLocked renders the media playlist, partial segments are only listed in the low-latency playlist
+func (h *HLSStream) playlistLocked(lowLatency bool) string {
+	partTarget := h.params.Config.PartDuration.Seconds()
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	if lowLatency {
+		b.WriteString("#EXT-X-VERSION:9\n")
+	} else {
+		b.WriteString("#EXT-X-VERSION:7\n")
+	}
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", h.targetDuration)
+	if lowLatency {
+		fmt.Fprintf(&b, "#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=%.3f\n", 3*partTarget)
+		fmt.Fprintf(&b, "#EXT-X-PART-INF:PART-TARGET=%.3f\n", partTarget)
+	}
+	if len(h.segments) != 0 {
+		fmt.Fprintf(&b, "#EXT-X-MEDIA-SEQUENCE:%d\n", h.segments[0].sequence)
+	}
+	if h.discontinuitySequence != 0 {
+		fmt.Fprintf(&b, "#EXT-X-DISCONTINUITY-SEQUENCE:%d\n", h.discontinuitySequence)
+	}
+
+	init := -1
+	for i, segment := range h.segments {
+		if !lowLatency && !segment.complete {
+			break
+		}
+		if segment.discontinuity {
+			b.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		if segment.init != init {
+			init = segment.init
+			fmt.Fprintf(&b, "#EXT-X-MAP:URI=\"%s\"\n", initName(init))
+		}
+		if lowLatency && i >= len(h.segments)-hlsPartsInPlaylist {
+			for p, part := range segment.parts {
+				fmt.Fprintf(&b, "#EXT-X-PART:DURATION=%.5f,URI=\"%s\"", part.duration, partName(segment.sequence, p))
+				if part.independent {
+					b.WriteString(",INDEPENDENT=YES")
+				}
+				b.WriteString("\n")
+			}
+		}
+		if segment.complete {
+			fmt.Fprintf(&b, "#EXTINF:%.5f,\n%s\n", segment.duration, segmentName(segment.sequence))
+		}
+	}
+
+	if h.ended {
+		b.WriteString("#EXT-X-ENDLIST\n")
+	} else if lowLatency {
+		sequence, part := h.nextPartLocked()
+		fmt.Fprintf(&b, "#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"%s\"\n", partName(sequence, part))
+	}
+	return b.String()
+}
+
+// nextPartLocked returns the part that will be added next
+func (h *HLSStream) nextPartLocked() (int, int) {
+	if len(h.segments) == 0 {
+		return h.nextSequence, 0
+	}
+	last := h.segments[len(h.segments)-1]
+	if last.complete {
+		return h.nextSequence, 0
+	}
+	return last.sequence, len(last.parts)
+}
+
+func (h *HLSStream) segmentLocked(sequence int) *hlsSegment {
+	for _, segment := range h.segments {
+		if segment.sequence == sequence {
+			return segment
+		}
+	}
+	return nil
+}
+
+// hasPartLocked reports whether the playlist includes the given part, or the segment when part is negative
+func (h *HLSStream) hasPartLocked(sequence int, part int) bool {
+	if h.ended || sequence < h.nextSequence-1 {
+		return true
+	}
+	segment := h.segmentLocked(sequence)
+	if segment == nil {
+		return false
+	}
+	if part < 0 {
+		return segment.complete
+	}
+	return segment.complete || part < len(segment.parts)
+}
+
+// waitFor blocks until cond is met, the stream is updated or the timeout expires
+func (h *HLSStream) waitFor(ctx context.Context, cond func() bool) bool {
+	timeout := time.NewTimer(time.Duration(hlsBlockingWaitParts) * time.Duration(h.targetDuration) * time.Second)
+	defer timeout.Stop()
+	for {
+		h.lock.Lock()
+		if cond() {
+			return true
+		}
+		updated := h.updated
+		h.lock.Unlock()
+
+		select {
+		case <-updated:
+		case <-timeout.C:
+			h.lock.Lock()
+			return false
+		case <-ctx.Done():
+			h.lock.Lock()
+			return false
+		}
+	}
+}
+
+// ServeFile serves the playlists, initialization sections, segments and parts of the stream.
+// The multivariant playlist blocks until the codecs are known from the first initialization section.
+// Playlist requests with _HLS_msn and _HLS_part block until the requested part is available,
+// as do requests for the part advertised by the preload hint
+func (h *HLSStream) ServeFile(w http.ResponseWriter, r *http.Request, name string) {
+	var data []byte
+	contentType := hlsSegmentType
+	initNumbers, isInit := hlsNameNumbers(name, "init", ".mp4", 1)
+	segmentNumbers, isSegment := hlsNameNumbers(name, "seg", ".m4s", 1)
+	partNumbers, isPart := hlsNameNumbers(name, "part", ".m4s", 2)
+
+	switch {
+	case name == hlsMultivariantPlaylist:
+		h.waitFor(r.Context(), func() bool { return h.codecs != "" || h.ended })
+		if h.codecs != "" {
+			data = []byte(h.multivariantPlaylistLocked())
+		}
+		h.lock.Unlock()
+		contentType = hlsPlaylistType
+
+	case name == hlsPlaylist:
+		msn, err := strconv.Atoi(r.URL.Query().Get("_HLS_msn"))
+		if err != nil {
+			msn = -1
+		}
+		partParam, err := strconv.Atoi(r.URL.Query().Get("_HLS_part"))
+		if err != nil {
+			partParam = -1
+		}
+		if msn >= 0 {
+			h.waitFor(r.Context(), func() bool { return h.hasPartLocked(msn, partParam) })
+		} else {
+			h.lock.Lock()
+		}
+		data = []byte(h.playlistLocked(true))
+		h.lock.Unlock()
+		contentType = hlsPlaylistType
+
+	case isInit:
+		h.lock.Lock()
+		if version := initNumbers[0]; version < len(h.inits) {
+			data = h.inits[version]
+		}
+		h.lock.Unlock()
+
+	case isSegment:
+		h.lock.Lock()
+		if segment := h.segmentLocked(segmentNumbers[0]); segment != nil && segment.complete {
+			data = segment.data()
+		}
+		h.lock.Unlock()
+
+	case isPart:
+		sequence, part := partNumbers[0], partNumbers[1]
+		h.lock.Lock()
+		if next, nextPart := h.nextPartLocked(); sequence == next && part == nextPart {
+			h.lock.Unlock()
+			h.waitFor(r.Context(), func() bool { return h.hasPartLocked(sequence, part) })
+		}
+		if segment := h.segmentLocked(sequence); segment != nil && part < len(segment.parts) {
+			data = segment.parts[part].data
+		}
+		h.lock.Unlock()
+	}
+
+	if data == nil {
+		http.Error(w, ErrHLSNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	if contentType == hlsPlaylistType {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	_, _ = w.Write(data)
+}
+
+// hlsNameNumbers parses the dot separated numbers between prefix and suffix, e.g. part3.1.m4s
+func hlsNameNumbers(name string, prefix string, suffix string, count int) ([]int, bool) {
+	name, ok := strings.CutPrefix(name, prefix)
+	if !ok {
+		return nil, false
+	}
+	if name, ok = strings.CutSuffix(name, suffix); !ok {
+		return nil, false
+	}
+	fields := strings.Split(name, ".")
+	if len(fields) != count {
+		return nil, false
+	}
+	numbers := make([]int, 0, count)
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, true
+}
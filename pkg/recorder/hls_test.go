@@ -0,0 +1,163 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+// 1280x720, high profile
+var testSPS = []byte{0x67, 0x64, 0x00, 0x1f, 0xac, 0xd9, 0x40, 0x50, 0x05, 0xbb, 0x01, 0x10, 0x00, 0x00, 0x03, 0x00, 0x10, 0x00, 0x00, 0x03, 0x03, 0xc0, 0xf1, 0x83, 0x19, 0x60}
+
+func writeH264Frames(t *testing.T, track sfu.TrackSender, from int, count int, keyInterval int) {
+	pps := []byte{0x68, 0xeb, 0xe3, 0xcb, 0x22, 0xc0}
+	sn := uint64(from * 2)
+	write := func(ts uint32, payload []byte, marker bool) {
+		sn++
+		require.NoError(t, track.WriteRTP(&buffer.ExtPacket{
+			ExtSequenceNumber: sn,
+			Packet: &rtp.Packet{
+				Header:  rtp.Header{Version: 2, SequenceNumber: uint16(sn), Timestamp: ts, Marker: marker},
+				Payload: payload,
+			},
+		}, 0))
+	}
+	for i := from; i < from+count; i++ {
+		ts := uint32(i * 3000)
+		if i%keyInterval == 0 {
+			stapA := []byte{0x78, 0, byte(len(testSPS))}
+			stapA = append(stapA, testSPS...)
+			stapA = append(stapA, 0, byte(len(pps)))
+			stapA = append(stapA, pps...)
+			write(ts, stapA, false)
+			write(ts, []byte{0x65, 0x88, 0x84, byte(i)}, true)
+		} else {
+			// an access unit delimiter keeps sequence numbers at two per frame
+			write(ts, []byte{0x09, 0x10}, false)
+			write(ts, []byte{0x41, 0x9a, byte(i)}, true)
+		}
+	}
+}
+
+func getHLS(t *testing.T, stream *HLSStream, target string) (int, []byte) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", target, nil)
+	stream.ServeFile(w, req, path.Base(req.URL.Path))
+	return w.Code, w.Body.Bytes()
+}
+
+func TestHLSStream(t *testing.T) {
+	video := &testReceiver{codec: webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000},
+	}}
+	stream, err := NewHLSStream(HLSStreamParams{
+		Config: config.HLSOutputConfig{
+			SegmentDuration: 900 * time.Millisecond,
+			PartDuration:    200 * time.Millisecond,
+			PlaylistSize:    2,
+		},
+		PathPrefix: "/hls",
+		Room:       "myroom",
+		Video:      video,
+		Logger:     logger.GetLogger(),
+	})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(stream.Info().PlaylistPath, "/hls/HS_"))
+	require.True(t, strings.HasSuffix(stream.Info().PlaylistPath, "/master.m3u8"))
+
+	finished := make(chan HLSStreamInfo, 1)
+	require.NoError(t, stream.Start(func(info HLSStreamInfo) {
+		finished <- info
+	}))
+
+	// segments are cut on the key frames at 1s and 2s
+	writeH264Frames(t, video.downTrack, 0, 61, 30)
+
+	// blocks until the second segment is complete
+	code, body := getHLS(t, stream, "/hls/id/index.m3u8?_HLS_msn=1")
+	require.Equal(t, 200, code)
+	playlist := string(body)
+	require.Contains(t, playlist, "#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=0.600\n")
+	require.Contains(t, playlist, "#EXT-X-PART-INF:PART-TARGET=0.200\n")
+	require.Contains(t, playlist, "#EXT-X-MAP:URI=\"init0.mp4\"\n")
+	require.Contains(t, playlist, "#EXT-X-PART:DURATION=0.20000,URI=\"part0.0.m4s\",INDEPENDENT=YES\n")
+	require.Contains(t, playlist, "#EXT-X-PART:DURATION=0.20000,URI=\"part0.1.m4s\"\n")
+	require.Contains(t, playlist, "#EXTINF:1.00000,\nseg0.m4s\n")
+	require.Contains(t, playlist, "#EXTINF:1.00000,\nseg1.m4s\n")
+	require.Contains(t, playlist, "#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"part2.0.m4s\"\n")
+
+	code, body = getHLS(t, stream, "/hls/id/master.m3u8")
+	require.Equal(t, 200, code)
+	require.Contains(t, string(body), "#EXT-X-STREAM-INF:BANDWIDTH=")
+	require.Contains(t, string(body), ",CODECS=\"avc1.64001f\",RESOLUTION=1280x720\nindex.m3u8\n")
+
+	code, body = getHLS(t, stream, "/hls/id/init0.mp4")
+	require.Equal(t, 200, code)
+	require.Equal(t, "ftyp", string(body[4:8]))
+	require.True(t, bytes.Contains(body, []byte("avc1")))
+	// width and height of the sample entry
+	idx := bytes.Index(body, []byte("avc1"))
+	require.Equal(t, []byte{0x05, 0x00, 0x02, 0xd0}, body[idx+4+24:idx+4+28])
+
+	code, body = getHLS(t, stream, "/hls/id/part0.0.m4s")
+	require.Equal(t, 200, code)
+	require.Equal(t, "moof", string(body[4:8]))
+
+	code, body = getHLS(t, stream, "/hls/id/seg1.m4s")
+	require.Equal(t, 200, code)
+	require.Equal(t, "moof", string(body[4:8]))
+
+	code, _ = getHLS(t, stream, "/hls/id/seg9.m4s")
+	require.Equal(t, 404, code)
+
+	stream.Stop()
+	info := <-finished
+	require.Empty(t, info.Error)
+	require.Nil(t, video.downTrack)
+
+	_, body = getHLS(t, stream, "/hls/id/index.m3u8")
+	playlist = string(body)
+	require.True(t, strings.HasSuffix(playlist, "#EXT-X-ENDLIST\n"))
+	require.NotContains(t, playlist, "PRELOAD-HINT")
+	// the first segment has been removed from the playlist
+	require.Contains(t, playlist, "#EXT-X-MEDIA-SEQUENCE:1\n")
+	require.NotContains(t, playlist, "seg0.m4s")
+	require.Contains(t, playlist, "seg2.m4s")
+}
+
+func TestMP4Codecs(t *testing.T) {
+	video := &mp4Track{id: mp4VideoTrackID, video: true, avcC: avcDecoderConfigurationRecord(testSPS, []byte{0x68, 0xeb})}
+	audio := &mp4Track{id: mp4AudioTrackID, channels: 2}
+	require.Equal(t, "avc1.64001f,opus", mp4Codecs([]*mp4Track{video, audio}))
+
+	init := mp4InitSegment([]*mp4Track{audio})
+	require.True(t, bytes.Contains(init[:36], []byte("Opus")))
+	require.True(t, bytes.Contains(init, []byte("dOps")))
+}
@@ -0,0 +1,185 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+)
+
+// mp4FragmentDuration is how much media a fragment of a recording holds, video fragments start on a key frame
+const mp4FragmentDuration = time.Second
+
+// mp4Writer writes a single H.264 or Opus track to a fragmented MP4 file, so that a recording that is cut short
+// stays playable up to its last fragment
+type mp4Writer struct {
+	file            *os.File
+	track           *mp4Track
+	clock           trackClock
+	assembler       *h264Assembler
+	requestKeyFrame func()
+
+	initialized  bool
+	sps          []byte
+	pps          []byte
+	held         *mp4Sample
+	lastDuration uint32
+	pending      []mp4Sample
+	sequence     uint32
+}
+
+func newMP4Writer(codec webrtc.RTPCodecParameters, fileName string, requestKeyFrame func()) (*mp4Writer, error) {
+	w := &mp4Writer{
+		clock:           trackClock{clockRate: codec.ClockRate},
+		requestKeyFrame: requestKeyFrame,
+	}
+	switch {
+	case strings.EqualFold(codec.MimeType, webrtc.MimeTypeH264):
+		w.track = &mp4Track{id: mp4VideoTrackID, timescale: codec.ClockRate, video: true}
+		w.assembler = newH264Assembler()
+	case strings.EqualFold(codec.MimeType, webrtc.MimeTypeOpus):
+		channels := codec.Channels
+		if channels == 0 {
+			channels = 2
+		}
+		w.track = &mp4Track{id: mp4AudioTrackID, timescale: codec.ClockRate, channels: channels}
+	default:
+		return nil, ErrUnsupportedCodec
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return nil, err
+	}
+	w.file = file
+	return w, nil
+}
+
+func (w *mp4Writer) WriteRTP(packet *rtp.Packet) error {
+	if w.assembler == nil {
+		if len(packet.Payload) == 0 {
+			return nil
+		}
+		if !w.initialized {
+			if err := w.writeInit(); err != nil {
+				return err
+			}
+		}
+		// a single track needs no alignment with other tracks, its timeline starts at the first packet
+		ticks := w.clock.Ticks(packet.Timestamp, time.Time{}, time.Time{})
+		return w.addSample(mp4Sample{dts: ticks, data: packet.Payload, sync: true})
+	}
+
+	for _, frame := range w.assembler.Push(packet, time.Now()) {
+		if frame.key {
+			sps, pps := w.assembler.SPS(), w.assembler.PPS()
+			switch {
+			case !w.initialized:
+				w.sps, w.pps = sps, pps
+				if err := w.writeInit(); err != nil {
+					return err
+				}
+			case !bytes.Equal(sps, w.sps) || !bytes.Equal(pps, w.pps):
+				// the sample description holds the first parameter sets, later ones are sent in band
+				frame.data = append(lengthPrefixed(sps, pps), frame.data...)
+			}
+		}
+		if !w.initialized {
+			continue
+		}
+		ticks := w.clock.Ticks(frame.timestamp, time.Time{}, time.Time{})
+		if err := w.addSample(mp4Sample{dts: ticks, data: frame.data, sync: frame.key}); err != nil {
+			return err
+		}
+	}
+	if w.assembler.NeedsKeyFrame() && w.requestKeyFrame != nil {
+		w.requestKeyFrame()
+	}
+	return nil
+}
+
+func (w *mp4Writer) Close() error {
+	var err error
+	if w.held != nil {
+		w.held.duration = w.lastDuration
+		if w.held.duration == 0 {
+			w.held.duration = 1
+		}
+		w.pending = append(w.pending, *w.held)
+		w.held = nil
+		err = w.writeFragment()
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (w *mp4Writer) writeInit() error {
+	if w.track.video {
+		w.track.avcC = avcDecoderConfigurationRecord(w.sps, w.pps)
+		// dimensions are informational, players take them from the parameter sets
+		w.track.width, w.track.height, _ = h264Dimensions(w.sps)
+	}
+	w.initialized = true
+	_, err := w.file.Write(mp4InitSegment([]*mp4Track{w.track}))
+	return err
+}
+
+func (w *mp4Writer) addSample(sample mp4Sample) error {
+	if held := w.held; held != nil {
+		held.duration = 1
+		if sample.dts > held.dts {
+			held.duration = uint32(sample.dts - held.dts)
+		}
+		w.lastDuration = held.duration
+		w.pending = append(w.pending, *held)
+	}
+	w.held = &sample
+
+	if !sample.sync || len(w.pending) == 0 {
+		return nil
+	}
+	if elapsed := sample.dts - w.pending[0].dts; elapsed < int64(mp4FragmentDuration.Seconds()*float64(w.track.timescale)) {
+		return nil
+	}
+	return w.writeFragment()
+}
+
+func (w *mp4Writer) writeFragment() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	w.sequence++
+	fragment := mp4Fragment(w.sequence, []*mp4Track{w.track}, [][]mp4Sample{w.pending})
+	w.pending = nil
+	_, err := w.file.Write(fragment)
+	return err
+}
+
+func lengthPrefixed(nalus ...[]byte) []byte {
+	var data []byte
+	for _, nalu := range nalus {
+		data = binary.BigEndian.AppendUint32(data, uint32(len(nalu)))
+		data = append(data, nalu...)
+	}
+	return data
+}
@@ -0,0 +1,648 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	rtmpDefaultPort    = "1935"
+	rtmpsDefaultPort   = "443"
+	rtmpHandshakeSize  = 1536
+	rtmpChunkSize      = 4096
+	rtmpMaxChunkSize   = 1 << 24
+	rtmpMaxMessageSize = 1 << 24
+	rtmpConnectTimeout = 10 * time.Second
+
+	rtmpMsgSetChunkSize = 1
+	rtmpMsgAudio        = 8
+	rtmpMsgVideo        = 9
+	rtmpMsgCommandAMF0  = 20
+
+	rtmpCSIDControl = 2
+	rtmpCSIDCommand = 3
+	rtmpCSIDAudio   = 4
+	rtmpCSIDVideo   = 6
+
+	amf0Number      = 0x00
+	amf0Boolean     = 0x01
+	amf0String      = 0x02
+	amf0Object      = 0x03
+	amf0Null        = 0x05
+	amf0Undefined   = 0x06
+	amf0ECMAArray   = 0x08
+	amf0ObjectEnd   = 0x09
+	amf0StrictArray = 0x0a
+)
+
+var (
+	ErrInvalidRTMPURL  = errors.New("invalid RTMP URL, expected rtmp(s)://host[:port]/app/stream_key")
+	ErrRTMPPublishFail = errors.New("RTMP server rejected publish")
+)
+
+type rtmpMessage struct {
+	typeID    uint8
+	streamID  uint32
+	timestamp uint32
+	payload   []byte
+}
+
+type rtmpChunkHeader struct {
+	timestamp uint32
+	length    uint32
+	typeID    uint8
+	streamID  uint32
+	extended  bool
+	buf       []byte
+}
+
+// rtmpConn is the publishing side of an RTMP connection. It implements only what pushing a single stream
+// requires: the simple handshake, connect, createStream and publish, then audio and video messages
+type rtmpConn struct {
+	conn     net.Conn
+	r        *bufio.Reader
+	w        *bufio.Writer
+	streamID uint32
+
+	inChunkSize  uint32
+	outChunkSize uint32
+	inHeaders    map[uint32]*rtmpChunkHeader
+	transaction  float64
+}
+
+type rtmpURL struct {
+	address   string
+	secure    bool
+	app       string
+	tcURL     string
+	streamKey string
+}
+
+func parseRTMPURL(rawURL string) (*rtmpURL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, ErrInvalidRTMPURL
+	}
+	var port string
+	switch u.Scheme {
+	case "rtmp":
+		port = rtmpDefaultPort
+	case "rtmps":
+		port = rtmpsDefaultPort
+	default:
+		return nil, ErrInvalidRTMPURL
+	}
+
+	path := strings.Trim(u.Path, "/")
+	idx := strings.LastIndex(path, "/")
+	if u.Hostname() == "" || idx <= 0 || idx == len(path)-1 {
+		return nil, ErrInvalidRTMPURL
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+
+	parsed := &rtmpURL{
+		address:   net.JoinHostPort(u.Hostname(), port),
+		secure:    u.Scheme == "rtmps",
+		app:       path[:idx],
+		streamKey: path[idx+1:],
+	}
+	if u.RawQuery != "" {
+		parsed.streamKey += "?" + u.RawQuery
+	}
+	parsed.tcURL = fmt.Sprintf("%s://%s/%s", u.Scheme, u.Host, parsed.app)
+	return parsed, nil
+}
+
+// dialRTMP connects and publishes to the stream given by the URL
+func dialRTMP(ctx context.Context, rawURL string) (*rtmpConn, error) {
+	u, err := parseRTMPURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rtmpConnectTimeout)
+	defer cancel()
+
+	var conn net.Conn
+	dialer := &net.Dialer{}
+	if u.secure {
+		host, _, _ := net.SplitHostPort(u.address)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", u.address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", u.address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := newRTMPConn(conn)
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if err = c.publish(u); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	// servers send acknowledgements and status messages while publishing, which are not needed
+	go func() {
+		for {
+			if _, err := c.readMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	return c, nil
+}
+
+func newRTMPConn(conn net.Conn) *rtmpConn {
+	return &rtmpConn{
+		conn:         conn,
+		r:            bufio.NewReader(conn),
+		w:            bufio.NewWriter(conn),
+		inChunkSize:  128,
+		outChunkSize: 128,
+		inHeaders:    make(map[uint32]*rtmpChunkHeader),
+	}
+}
+
+func (c *rtmpConn) handshake() error {
+	c0c1 := make([]byte, 1+rtmpHandshakeSize)
+	c0c1[0] = 3
+	if _, err := rand.Read(c0c1[9:]); err != nil {
+		return err
+	}
+	if _, err := c.w.Write(c0c1); err != nil {
+		return err
+	}
+	if err := c.w.Flush(); err != nil {
+		return err
+	}
+
+	s0s1s2 := make([]byte, 1+2*rtmpHandshakeSize)
+	if _, err := io.ReadFull(c.r, s0s1s2); err != nil {
+		return err
+	}
+	if s0s1s2[0] != 3 {
+		return fmt.Errorf("unsupported RTMP version %d", s0s1s2[0])
+	}
+	// C2 echoes S1
+	if _, err := c.w.Write(s0s1s2[1 : 1+rtmpHandshakeSize]); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+func (c *rtmpConn) publish(u *rtmpURL) error {
+	if err := c.handshake(); err != nil {
+		return err
+	}
+
+	chunkSize := make([]byte, 4)
+	binary.BigEndian.PutUint32(chunkSize, rtmpChunkSize)
+	if err := c.writeMessage(rtmpCSIDControl, &rtmpMessage{typeID: rtmpMsgSetChunkSize, payload: chunkSize}); err != nil {
+		return err
+	}
+	c.outChunkSize = rtmpChunkSize
+
+	if _, err := c.call("connect", map[string]interface{}{
+		"app":      u.app,
+		"type":     "nonprivate",
+		"flashVer": "FMLE/3.0 (compatible; livekit)",
+		"tcUrl":    u.tcURL,
+	}); err != nil {
+		return err
+	}
+	if err := c.send("releaseStream", nil, u.streamKey); err != nil {
+		return err
+	}
+	if err := c.send("FCPublish", nil, u.streamKey); err != nil {
+		return err
+	}
+	res, err := c.call("createStream", nil)
+	if err != nil {
+		return err
+	}
+	if len(res) < 2 {
+		return errors.New("invalid createStream response")
+	}
+	streamID, ok := res[1].(float64)
+	if !ok {
+		return errors.New("invalid createStream response")
+	}
+	c.streamID = uint32(streamID)
+
+	payload, err := amf0Encode("publish", float64(0), nil, u.streamKey, "live")
+	if err != nil {
+		return err
+	}
+	if err = c.writeMessage(rtmpCSIDCommand, &rtmpMessage{typeID: rtmpMsgCommandAMF0, streamID: c.streamID, payload: payload}); err != nil {
+		return err
+	}
+	for {
+		values, err := c.readCommand()
+		if err != nil {
+			return err
+		}
+		if name, _ := values[0].(string); name != "onStatus" || len(values) < 4 {
+			continue
+		}
+		status, _ := values[3].(map[string]interface{})
+		if level, _ := status["level"].(string); level == "error" {
+			return fmt.Errorf("%w: %v", ErrRTMPPublishFail, status["code"])
+		}
+		if code, _ := status["code"].(string); code == "NetStream.Publish.Start" {
+			return nil
+		}
+	}
+}
+
+// send issues a command that is not answered
+func (c *rtmpConn) send(name string, args ...interface{}) error {
+	c.transaction++
+	payload, err := amf0Encode(append([]interface{}{name, c.transaction}, args...)...)
+	if err != nil {
+		return err
+	}
+	return c.writeMessage(rtmpCSIDCommand, &rtmpMessage{typeID: rtmpMsgCommandAMF0, payload: payload})
+}
+
+// call issues a command and returns the values following the transaction ID of its _result
+func (c *rtmpConn) call(name string, args ...interface{}) ([]interface{}, error) {
+	if err := c.send(name, args...); err != nil {
+		return nil, err
+	}
+	transaction := c.transaction
+	for {
+		values, err := c.readCommand()
+		if err != nil {
+			return nil, err
+		}
+		if len(values) < 2 {
+			continue
+		}
+		if id, _ := values[1].(float64); id != transaction {
+			continue
+		}
+		switch values[0] {
+		case "_result":
+			return values[2:], nil
+		case "_error":
+			return nil, fmt.Errorf("RTMP %s failed: %v", name, values[2:])
+		}
+	}
+}
+
+func (c *rtmpConn) readCommand() ([]interface{}, error) {
+	for {
+		msg, err := c.readMessage()
+		if err != nil {
+			return nil, err
+		}
+		if msg.typeID != rtmpMsgCommandAMF0 {
+			continue
+		}
+		values, err := amf0Decode(msg.payload)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) > 0 {
+			return values, nil
+		}
+	}
+}
+
+func (c *rtmpConn) WriteAudio(timestamp uint32, payload []byte) error {
+	return c.writeMedia(rtmpCSIDAudio, rtmpMsgAudio, timestamp, payload)
+}
+
+func (c *rtmpConn) WriteVideo(timestamp uint32, payload []byte) error {
+	return c.writeMedia(rtmpCSIDVideo, rtmpMsgVideo, timestamp, payload)
+}
+
+func (c *rtmpConn) writeMedia(csid uint32, typeID uint8, timestamp uint32, payload []byte) error {
+	return c.writeMessage(csid, &rtmpMessage{
+		typeID:    typeID,
+		streamID:  c.streamID,
+		timestamp: timestamp,
+		payload:   payload,
+	})
+}
+
+func (c *rtmpConn) Close() error {
+	return c.conn.Close()
+}
+
+// writeMessage writes a message with a full (type 0) header, followed by type 3 continuation chunks
+func (c *rtmpConn) writeMessage(csid uint32, msg *rtmpMessage) error {
+	extended := msg.timestamp >= 0xffffff
+	header := make([]byte, 0, 16)
+	header = append(header, byte(csid&0x3f))
+	ts := msg.timestamp
+	if extended {
+		ts = 0xffffff
+	}
+	header = append(header, byte(ts>>16), byte(ts>>8), byte(ts))
+	length := len(msg.payload)
+	header = append(header, byte(length>>16), byte(length>>8), byte(length))
+	header = append(header, msg.typeID)
+	header = binary.LittleEndian.AppendUint32(header, msg.streamID)
+	if extended {
+		header = binary.BigEndian.AppendUint32(header, msg.timestamp)
+	}
+
+	continuation := []byte{0xc0 | byte(csid&0x3f)}
+	if extended {
+		continuation = binary.BigEndian.AppendUint32(continuation, msg.timestamp)
+	}
+
+	if _, err := c.w.Write(header); err != nil {
+		return err
+	}
+	payload := msg.payload
+	for {
+		n := len(payload)
+		if n > int(c.outChunkSize) {
+			n = int(c.outChunkSize)
+		}
+		if _, err := c.w.Write(payload[:n]); err != nil {
+			return err
+		}
+		payload = payload[n:]
+		if len(payload) == 0 {
+			break
+		}
+		if _, err := c.w.Write(continuation); err != nil {
+			return err
+		}
+	}
+	return c.w.Flush()
+}
+
+func (c *rtmpConn) readMessage() (*rtmpMessage, error) {
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		format := b >> 6
+		csid := uint32(b & 0x3f)
+		switch csid {
+		case 0:
+			next, err := c.r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			csid = 64 + uint32(next)
+		case 1:
+			var next [2]byte
+			if _, err = io.ReadFull(c.r, next[:]); err != nil {
+				return nil, err
+			}
+			csid = 64 + uint32(next[0]) + uint32(next[1])<<8
+		}
+
+		h := c.inHeaders[csid]
+		if h == nil {
+			if format != 0 {
+				return nil, fmt.Errorf("RTMP chunk stream %d starts without a full header", csid)
+			}
+			h = &rtmpChunkHeader{}
+			c.inHeaders[csid] = h
+		}
+
+		var raw [11]byte
+		headerSize := []int{11, 7, 3, 0}[format]
+		if _, err = io.ReadFull(c.r, raw[:headerSize]); err != nil {
+			return nil, err
+		}
+		if format <= 2 {
+			ts := uint32(raw[0])<<16 | uint32(raw[1])<<8 | uint32(raw[2])
+			h.extended = ts == 0xffffff
+			if !h.extended {
+				h.timestamp = ts
+			}
+		}
+		if format <= 1 {
+			h.length = uint32(raw[3])<<16 | uint32(raw[4])<<8 | uint32(raw[5])
+			h.typeID = raw[6]
+			if h.length > rtmpMaxMessageSize {
+				return nil, fmt.Errorf("RTMP message too large: %d", h.length)
+			}
+		}
+		if format == 0 {
+			h.streamID = binary.LittleEndian.Uint32(raw[7:11])
+		}
+		if h.extended {
+			var ext [4]byte
+			if _, err = io.ReadFull(c.r, ext[:]); err != nil {
+				return nil, err
+			}
+			if format <= 2 {
+				h.timestamp = binary.BigEndian.Uint32(ext[:])
+			}
+		}
+
+		remaining := int(h.length) - len(h.buf)
+		if remaining > int(c.inChunkSize) {
+			remaining = int(c.inChunkSize)
+		}
+		chunk := make([]byte, remaining)
+		if _, err = io.ReadFull(c.r, chunk); err != nil {
+			return nil, err
+		}
+		h.buf = append(h.buf, chunk...)
+		if len(h.buf) < int(h.length) {
+			continue
+		}
+
+		msg := &rtmpMessage{typeID: h.typeID, streamID: h.streamID, timestamp: h.timestamp, payload: h.buf}
+		h.buf = nil
+		if msg.typeID == rtmpMsgSetChunkSize && len(msg.payload) >= 4 {
+			size := binary.BigEndian.Uint32(msg.payload) & 0x7fffffff
+			if size == 0 || size > rtmpMaxChunkSize {
+				return nil, fmt.Errorf("invalid RTMP chunk size %d", size)
+			}
+			c.inChunkSize = size
+		}
+		return msg, nil
+	}
+}
+
+// AMF0
+
+func amf0Encode(values ...interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, v := range values {
+		if err := amf0EncodeValue(&buf, v); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func amf0EncodeValue(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(amf0Null)
+	case float64:
+		buf.WriteByte(amf0Number)
+		_ = binary.Write(buf, binary.BigEndian, math.Float64bits(v))
+	case bool:
+		buf.WriteByte(amf0Boolean)
+		if v {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case string:
+		buf.WriteByte(amf0String)
+		amf0WriteString(buf, v)
+	case map[string]interface{}:
+		buf.WriteByte(amf0Object)
+		for key, value := range v {
+			amf0WriteString(buf, key)
+			if err := amf0EncodeValue(buf, value); err != nil {
+				return err
+			}
+		}
+		buf.Write([]byte{0, 0, amf0ObjectEnd})
+	default:
+		return fmt.Errorf("unsupported AMF0 type %T", v)
+	}
+	return nil
+}
+
+func amf0WriteString(buf *bytes.Buffer, s string) {
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(s)))
+	buf.WriteString(s)
+}
+
+func amf0Decode(data []byte) ([]interface{}, error) {
+	r := bytes.NewReader(data)
+	var values []interface{}
+	for r.Len() > 0 {
+		v, err := amf0DecodeValue(r)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func amf0DecodeValue(r *bytes.Reader) (interface{}, error) {
+	marker, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch marker {
+	case amf0Number:
+		var bits uint64
+		if err = binary.Read(r, binary.BigEndian, &bits); err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(bits), nil
+	case amf0Boolean:
+		b, err := r.ReadByte()
+		return b != 0, err
+	case amf0String:
+		return amf0ReadString(r)
+	case amf0Object:
+		return amf0ReadProperties(r)
+	case amf0ECMAArray:
+		if _, err = r.Seek(4, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+		return amf0ReadProperties(r)
+	case amf0StrictArray:
+		var count uint32
+		if err = binary.Read(r, binary.BigEndian, &count); err != nil {
+			return nil, err
+		}
+		if int(count) > r.Len() {
+			return nil, io.ErrUnexpectedEOF
+		}
+		values := make([]interface{}, 0, count)
+		for i := uint32(0); i < count; i++ {
+			v, err := amf0DecodeValue(r)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case amf0Null, amf0Undefined:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported AMF0 marker %d", marker)
+	}
+}
+
+func amf0ReadString(r *bytes.Reader) (string, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return "", err
+	}
+	s := make([]byte, length)
+	if _, err := io.ReadFull(r, s); err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+func amf0ReadProperties(r *bytes.Reader) (map[string]interface{}, error) {
+	properties := make(map[string]interface{})
+	for {
+		key, err := amf0ReadString(r)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			marker, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			if marker == amf0ObjectEnd {
+				return properties, nil
+			}
+			if err = r.UnreadByte(); err != nil {
+				return nil, err
+			}
+		}
+		value, err := amf0DecodeValue(r)
+		if err != nil {
+			return nil, err
+		}
+		properties[key] = value
+	}
+}
@@ -0,0 +1,337 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+const (
+	RTMPPushPrefix = "RP_"
+
+	rtmpPushAudioTrack = 0
+	rtmpPushVideoTrack = 1
+
+	rtmpPLIInterval = time.Second
+
+	flvVideoKeyFrame    = 1
+	flvVideoInterFrame  = 2
+	flvVideoCodecAVC    = 7
+	flvAVCSequenceStart = 0
+	flvAVCNALU          = 1
+
+	// enhanced RTMP audio, see https://github.com/veovera/enhanced-rtmp
+	flvAudioExHeader      = 9
+	flvAudioSequenceStart = 0
+	flvAudioCodedFrames   = 1
+)
+
+var (
+	ErrNoTracksToPush = errors.New("participant has no tracks to push")
+
+	flvOpusFourCC = []byte("Opus")
+)
+
+// RTMPPushInfo describes a push, the URL does not include the stream key
+type RTMPPushInfo struct {
+	ID           string    `json:"id"`
+	Room         string    `json:"room"`
+	Identity     string    `json:"identity"`
+	URL          string    `json:"url"`
+	AudioTrackID string    `json:"audio_track_id,omitempty"`
+	VideoTrackID string    `json:"video_track_id,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+	EndedAt      time.Time `json:"ended_at,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+type RTMPPushParams struct {
+	URL          string
+	Room         livekit.RoomName
+	Identity     livekit.ParticipantIdentity
+	AudioTrackID livekit.TrackID
+	Audio        sfu.TrackReceiver
+	VideoTrackID livekit.TrackID
+	Video        sfu.TrackReceiver
+	Logger       logger.Logger
+}
+
+// RTMPPush forwards the audio and video track of a participant to an RTMP server, without the egress service.
+// Tracks are not transcoded, video has to be H.264 and audio is sent as Opus using enhanced RTMP,
+// which the destination has to support
+type RTMPPush struct {
+	params RTMPPushParams
+	conn   *rtmpConn
+	sinks  [2]*trackSink
+
+	lock sync.Mutex
+	info RTMPPushInfo
+
+	packets        chan sinkPacket
+	closed         atomic.Bool
+	done           chan struct{}
+	finished       chan struct{}
+	onFinishedOnce sync.Once
+	onFinished     func(info RTMPPushInfo)
+
+	// owned by writeWorker
+	startedAt       time.Time
+	clocks          [2]trackClock
+	audioHeaderSent bool
+	assembler       *h264Assembler
+	sentSPS         []byte
+	sentPPS         []byte
+	lastPLI         time.Time
+}
+
+func NewRTMPPush(params RTMPPushParams) (*RTMPPush, error) {
+	u, err := parseRTMPURL(params.URL)
+	if err != nil {
+		return nil, err
+	}
+	if params.Audio == nil && params.Video == nil {
+		return nil, ErrNoTracksToPush
+	}
+	if params.Audio != nil && !strings.EqualFold(params.Audio.Codec().MimeType, webrtc.MimeTypeOpus) {
+		return nil, ErrUnsupportedCodec
+	}
+	if params.Video != nil && !strings.EqualFold(params.Video.Codec().MimeType, webrtc.MimeTypeH264) {
+		return nil, ErrUnsupportedCodec
+	}
+
+	p := &RTMPPush{
+		params: params,
+		info: RTMPPushInfo{
+			ID:           utils.NewGuid(RTMPPushPrefix),
+			Room:         string(params.Room),
+			Identity:     string(params.Identity),
+			URL:          u.tcURL,
+			AudioTrackID: string(params.AudioTrackID),
+			VideoTrackID: string(params.VideoTrackID),
+		},
+		packets:   make(chan sinkPacket, packetQueueSize),
+		done:      make(chan struct{}),
+		finished:  make(chan struct{}),
+		assembler: newH264Assembler(),
+	}
+	for i, receiver := range p.receivers() {
+		if receiver != nil {
+			p.sinks[i] = newTrackSink(p.info.ID, i, p.packets, params.Logger, p.close)
+			p.clocks[i].clockRate = receiver.Codec().ClockRate
+		}
+	}
+	return p, nil
+}
+
+func (p *RTMPPush) receivers() [2]sfu.TrackReceiver {
+	return [2]sfu.TrackReceiver{rtmpPushAudioTrack: p.params.Audio, rtmpPushVideoTrack: p.params.Video}
+}
+
+// Start connects to the RTMP server and starts forwarding, f is called once the push has ended
+func (p *RTMPPush) Start(ctx context.Context, f func(info RTMPPushInfo)) error {
+	conn, err := dialRTMP(ctx, p.params.URL)
+	if err != nil {
+		return err
+	}
+	p.conn = conn
+	p.onFinished = f
+	p.startedAt = time.Now()
+	p.lock.Lock()
+	p.info.StartedAt = p.startedAt
+	p.lock.Unlock()
+
+	go p.writeWorker()
+	for i, receiver := range p.receivers() {
+		if receiver == nil {
+			continue
+		}
+		if err = receiver.AddDownTrack(p.sinks[i]); err != nil {
+			p.close()
+			<-p.finished
+			return err
+		}
+	}
+	p.requestKeyFrame()
+	p.params.Logger.Infow("rtmp push started", "pushID", p.info.ID, "url", p.info.URL)
+	return nil
+}
+
+// Stop ends the push
+func (p *RTMPPush) Stop() {
+	p.close()
+}
+
+func (p *RTMPPush) Info() RTMPPushInfo {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.info
+}
+
+// Finished is closed once the push has ended
+func (p *RTMPPush) Finished() <-chan struct{} {
+	return p.finished
+}
+
+func (p *RTMPPush) close() {
+	if p.closed.Swap(true) {
+		return
+	}
+	close(p.done)
+}
+
+func (p *RTMPPush) writeWorker() {
+	var err error
+	defer func() {
+		p.finish(err)
+	}()
+	for {
+		select {
+		case <-p.done:
+			return
+		case pkt := <-p.packets:
+			if pkt.track == rtmpPushAudioTrack {
+				err = p.writeAudio(pkt)
+			} else {
+				err = p.writeVideo(pkt)
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (p *RTMPPush) finish(err error) {
+	for i, receiver := range p.receivers() {
+		if receiver != nil {
+			receiver.DeleteDownTrack(p.sinks[i].SubscriberID())
+			p.sinks[i].Close()
+		}
+	}
+	_ = p.conn.Close()
+
+	p.lock.Lock()
+	p.info.EndedAt = time.Now()
+	if err != nil {
+		p.info.Error = err.Error()
+	}
+	info := p.info
+	p.lock.Unlock()
+
+	if err != nil {
+		p.params.Logger.Warnw("rtmp push failed", err, "pushID", info.ID)
+	} else {
+		p.params.Logger.Infow("rtmp push ended", "pushID", info.ID)
+	}
+	close(p.finished)
+
+	if p.onFinished != nil {
+		p.onFinishedOnce.Do(func() {
+			p.onFinished(info)
+		})
+	}
+}
+
+func (p *RTMPPush) writeAudio(pkt sinkPacket) error {
+	ts := p.clocks[rtmpPushAudioTrack].Milliseconds(pkt.packet.Timestamp, pkt.arrival, p.startedAt)
+	if len(pkt.packet.Payload) == 0 {
+		return nil
+	}
+	if !p.audioHeaderSent {
+		if err := p.conn.WriteAudio(ts, opusSequenceStart(p.params.Audio.Codec().Channels)); err != nil {
+			return err
+		}
+		p.audioHeaderSent = true
+	}
+
+	payload := make([]byte, 0, 5+len(pkt.packet.Payload))
+	payload = append(payload, flvAudioExHeader<<4|flvAudioCodedFrames)
+	payload = append(payload, flvOpusFourCC...)
+	payload = append(payload, pkt.packet.Payload...)
+	return p.conn.WriteAudio(ts, payload)
+}
+
+// opusSequenceStart carries an Opus identification header, https://datatracker.ietf.org/doc/html/rfc7845#section-5.1
+func opusSequenceStart(channels uint16) []byte {
+	if channels == 0 {
+		channels = 2
+	}
+	payload := []byte{flvAudioExHeader<<4 | flvAudioSequenceStart}
+	payload = append(payload, flvOpusFourCC...)
+	payload = append(payload, "OpusHead"...)
+	payload = append(payload, 1, byte(channels))
+	payload = binary.LittleEndian.AppendUint16(payload, 0)
+	payload = binary.LittleEndian.AppendUint32(payload, 48000)
+	payload = binary.LittleEndian.AppendUint16(payload, 0)
+	return append(payload, 0)
+}
+
+func (p *RTMPPush) writeVideo(pkt sinkPacket) error {
+	for _, frame := range p.assembler.Push(pkt.packet, pkt.arrival) {
+		ts := p.clocks[rtmpPushVideoTrack].Milliseconds(frame.timestamp, frame.arrival, p.startedAt)
+		if frame.key {
+			sps, pps := p.assembler.SPS(), p.assembler.PPS()
+			if !bytes.Equal(sps, p.sentSPS) || !bytes.Equal(pps, p.sentPPS) {
+				if err := p.conn.WriteVideo(ts, avcSequenceStart(sps, pps)); err != nil {
+					return err
+				}
+				p.sentSPS, p.sentPPS = sps, pps
+			}
+		}
+
+		frameType := byte(flvVideoInterFrame)
+		if frame.key {
+			frameType = flvVideoKeyFrame
+		}
+		payload := make([]byte, 0, 5+len(frame.data))
+		payload = append(payload, frameType<<4|flvVideoCodecAVC, flvAVCNALU, 0, 0, 0)
+		payload = append(payload, frame.data...)
+		if err := p.conn.WriteVideo(ts, payload); err != nil {
+			return err
+		}
+	}
+	if p.assembler.NeedsKeyFrame() {
+		p.requestKeyFrame()
+	}
+	return nil
+}
+
+func avcSequenceStart(sps []byte, pps []byte) []byte {
+	payload := []byte{flvVideoKeyFrame<<4 | flvVideoCodecAVC, flvAVCSequenceStart, 0, 0, 0}
+	return append(payload, avcDecoderConfigurationRecord(sps, pps)...)
+}
+
+func (p *RTMPPush) requestKeyFrame() {
+	if p.params.Video == nil || time.Since(p.lastPLI) < rtmpPLIInterval {
+		return
+	}
+	p.lastPLI = time.Now()
+	p.params.Video.SendPLI(0, true)
+}
@@ -0,0 +1,181 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+func TestParseRTMPURL(t *testing.T) {
+	u, err := parseRTMPURL("rtmp://a.rtmp.youtube.com/live2/abcd-efgh")
+	require.NoError(t, err)
+	require.Equal(t, "a.rtmp.youtube.com:1935", u.address)
+	require.Equal(t, "live2", u.app)
+	require.Equal(t, "rtmp://a.rtmp.youtube.com/live2", u.tcURL)
+	require.Equal(t, "abcd-efgh", u.streamKey)
+	require.False(t, u.secure)
+
+	u, err = parseRTMPURL("rtmps://live.example.com:4443/app/instance/key?token=1")
+	require.NoError(t, err)
+	require.Equal(t, "live.example.com:4443", u.address)
+	require.Equal(t, "app/instance", u.app)
+	require.Equal(t, "key?token=1", u.streamKey)
+	require.True(t, u.secure)
+
+	for _, invalid := range []string{"http://example.com/app/key", "rtmp://example.com/key", "rtmp://example.com/app/", "rtmp:///app/key"} {
+		_, err = parseRTMPURL(invalid)
+		require.ErrorIs(t, err, ErrInvalidRTMPURL, invalid)
+	}
+}
+
+// serveRTMP accepts a single publisher and forwards its media messages
+func serveRTMP(t *testing.T, listener net.Listener, media chan<- *rtmpMessage) {
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	c := newRTMPConn(conn)
+	defer c.Close()
+
+	c0c1 := make([]byte, 1+rtmpHandshakeSize)
+	_, err = io.ReadFull(c.r, c0c1)
+	require.NoError(t, err)
+	s0s1s2 := append([]byte{3}, make([]byte, rtmpHandshakeSize)...)
+	s0s1s2 = append(s0s1s2, c0c1[1:]...)
+	_, err = conn.Write(s0s1s2)
+	require.NoError(t, err)
+	_, err = io.ReadFull(c.r, make([]byte, rtmpHandshakeSize))
+	require.NoError(t, err)
+
+	reply := func(values ...interface{}) {
+		payload, err := amf0Encode(values...)
+		require.NoError(t, err)
+		require.NoError(t, c.writeMessage(rtmpCSIDCommand, &rtmpMessage{typeID: rtmpMsgCommandAMF0, payload: payload}))
+	}
+	for {
+		msg, err := c.readMessage()
+		if err != nil {
+			close(media)
+			return
+		}
+		switch msg.typeID {
+		case rtmpMsgAudio, rtmpMsgVideo:
+			media <- msg
+		case rtmpMsgCommandAMF0:
+			values, err := amf0Decode(msg.payload)
+			require.NoError(t, err)
+			switch values[0] {
+			case "connect":
+				reply("_result", values[1], nil, map[string]interface{}{"code": "NetConnection.Connect.Success"})
+			case "createStream":
+				reply("_result", values[1], nil, float64(1))
+			case "publish":
+				require.Equal(t, uint32(1), msg.streamID)
+				require.Equal(t, "stream-key", values[3])
+				reply("onStatus", float64(0), nil, map[string]interface{}{"level": "status", "code": "NetStream.Publish.Start"})
+			}
+		}
+	}
+}
+
+func TestRTMPPush(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	media := make(chan *rtmpMessage, 100)
+	go serveRTMP(t, listener, media)
+
+	audio := &testReceiver{codec: webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+	}}
+	video := &testReceiver{codec: webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000},
+	}}
+	push, err := NewRTMPPush(RTMPPushParams{
+		URL:    "rtmp://" + listener.Addr().String() + "/live/stream-key",
+		Room:   "myroom",
+		Audio:  audio,
+		Video:  video,
+		Logger: logger.GetLogger(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "rtmp://"+listener.Addr().String()+"/live", push.Info().URL)
+
+	finished := make(chan RTMPPushInfo, 1)
+	require.NoError(t, push.Start(context.Background(), func(info RTMPPushInfo) {
+		finished <- info
+	}))
+	require.NotNil(t, audio.downTrack)
+	require.NotNil(t, video.downTrack)
+
+	writeOpusPackets(t, audio.downTrack, 1)
+
+	sps := []byte{0x67, 0x42, 0xc0, 0x1f, 0xda, 0x01}
+	pps := []byte{0x68, 0xce, 0x3c, 0x80}
+	stapA := []byte{0x78, 0, byte(len(sps))}
+	stapA = append(stapA, sps...)
+	stapA = append(stapA, 0, byte(len(pps)))
+	stapA = append(stapA, pps...)
+	for i, payload := range [][]byte{stapA, {0x65, 0x88, 0x84}} {
+		require.NoError(t, video.downTrack.WriteRTP(&buffer.ExtPacket{
+			ExtSequenceNumber: uint64(i + 1),
+			Packet: &rtp.Packet{
+				Header:  rtp.Header{Version: 2, SequenceNumber: uint16(i + 1), Timestamp: 3000, Marker: i == 1},
+				Payload: payload,
+			},
+		}, 0))
+	}
+
+	receive := func() *rtmpMessage {
+		select {
+		case msg := <-media:
+			return msg
+		case <-time.After(5 * time.Second):
+			t.Fatal("no media received")
+			return nil
+		}
+	}
+
+	msg := receive()
+	require.Equal(t, uint8(rtmpMsgAudio), msg.typeID)
+	require.Equal(t, append([]byte{0x90}, "OpusOpusHead"...), msg.payload[:13])
+	msg = receive()
+	require.Equal(t, uint8(rtmpMsgAudio), msg.typeID)
+	require.Equal(t, append([]byte{0x91}, "Opus"...), msg.payload[:5])
+
+	msg = receive()
+	require.Equal(t, uint8(rtmpMsgVideo), msg.typeID)
+	require.Equal(t, avcSequenceStart(sps, pps), msg.payload)
+	msg = receive()
+	require.Equal(t, uint8(rtmpMsgVideo), msg.typeID)
+	require.Equal(t, []byte{0x17, 1, 0, 0, 0, 0, 0, 0, 3, 0x65, 0x88, 0x84}, msg.payload)
+
+	push.Stop()
+	info := <-finished
+	require.Empty(t, info.Error)
+	require.False(t, info.EndedAt.IsZero())
+	require.Nil(t, audio.downTrack)
+	require.Nil(t, video.downTrack)
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	s3Service        = "s3"
+	s3SigningAlgo    = "AWS4-HMAC-SHA256"
+	s3AmzDateFormat  = "20060102T150405Z"
+	s3DateFormat     = "20060102"
+	s3SignedHeaders  = "host;x-amz-content-sha256;x-amz-date"
+	s3UploadTimeout  = 5 * time.Minute
+	s3DefaultRegion  = "us-east-1"
+	s3UnreservedByte = "-_.~"
+)
+
+// S3Uploader uploads files with a single SigV4 signed PUT request
+type S3Uploader struct {
+	conf   config.S3Config
+	client *http.Client
+}
+
+func NewS3Uploader(conf config.S3Config) *S3Uploader {
+	if conf.Region == "" {
+		conf.Region = s3DefaultRegion
+	}
+	return &S3Uploader{
+		conf:   conf,
+		client: &http.Client{Timeout: s3UploadTimeout},
+	}
+}
+
+// Upload stores the file under the configured prefix and returns its location
+func (u *S3Uploader) Upload(ctx context.Context, filePath string, key string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return u.UploadData(ctx, data, key, "")
+}
+
+// UploadData stores data under the configured prefix and returns its location
+func (u *S3Uploader) UploadData(ctx context.Context, data []byte, key string, contentType string) (string, error) {
+	objectURL := u.objectURL(u.conf.Prefix + key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL.String(), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = int64(len(data))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	u.sign(req, data, time.Now().UTC())
+
+	res, err := u.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return "", fmt.Errorf("upload failed with status %d: %s", res.StatusCode, body)
+	}
+	return objectURL.String(), nil
+}
+
+func (u *S3Uploader) objectURL(key string) *url.URL {
+	endpoint := u.conf.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", u.conf.Region)
+	}
+	objectURL, err := url.Parse(endpoint)
+	if err != nil {
+		objectURL = &url.URL{Scheme: "https", Host: endpoint}
+	}
+
+	if u.conf.ForcePathStyle {
+		objectURL.Path = "/" + u.conf.Bucket + "/" + key
+	} else {
+		objectURL.Host = u.conf.Bucket + "." + objectURL.Host
+		objectURL.Path = "/" + key
+	}
+	objectURL.RawPath = s3EscapePath(objectURL.Path)
+	return objectURL
+}
+
+func (u *S3Uploader) sign(req *http.Request, payload []byte, now time.Time) {
+	payloadHash := sha256.Sum256(payload)
+	payloadHex := hex.EncodeToString(payloadHash[:])
+	amzDate := now.Format(s3AmzDateFormat)
+	date := now.Format(s3DateFormat)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHex)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHex,
+		"x-amz-date:" + amzDate,
+		"",
+		s3SignedHeaders,
+		payloadHex,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := strings.Join([]string{date, u.conf.Region, s3Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		s3SigningAlgo,
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+u.conf.Secret), date)
+	key = hmacSHA256(key, u.conf.Region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3SigningAlgo, u.conf.AccessKey, scope, s3SignedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3EscapePath percent-encodes everything but unreserved characters and path separators
+func s3EscapePath(path string) string {
+	var sb strings.Builder
+	for _, b := range []byte(path) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '/' || strings.IndexByte(s3UnreservedByte, b) >= 0 {
+			sb.WriteByte(b)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,114 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+type sinkPacket struct {
+	track   int
+	packet  *rtp.Packet
+	arrival time.Time
+}
+
+// trackSink takes the place of a down track on a receiver and queues cloned packets for a writer goroutine.
+// Only the lowest spatial layer is forwarded and packets arriving out of order are dropped
+type trackSink struct {
+	id      string
+	track   int
+	packets chan<- sinkPacket
+	logger  logger.Logger
+	onClose func()
+
+	lastSN  atomic.Uint64
+	started atomic.Bool
+	closed  atomic.Bool
+}
+
+var _ sfu.TrackSender = (*trackSink)(nil)
+
+func newTrackSink(id string, track int, packets chan<- sinkPacket, logger logger.Logger, onClose func()) *trackSink {
+	return &trackSink{
+		id:      id,
+		track:   track,
+		packets: packets,
+		logger:  logger,
+		onClose: onClose,
+	}
+}
+
+func (s *trackSink) UpTrackLayersChange()                           {}
+func (s *trackSink) UpTrackBitrateAvailabilityChange()              {}
+func (s *trackSink) UpTrackMaxPublishedLayerChange(_ int32)         {}
+func (s *trackSink) UpTrackMaxTemporalLayerSeenChange(_ int32)      {}
+func (s *trackSink) UpTrackBitrateReport(_ []int32, _ sfu.Bitrates) {}
+func (s *trackSink) UpTrackSSRCChange(_ uint32)                     {}
+func (s *trackSink) TrackInfoAvailable()                            {}
+
+func (s *trackSink) HandleRTCPSenderReportData(_ webrtc.PayloadType, _ int32, _ *buffer.RTCPSenderReportData) error {
+	return nil
+}
+
+func (s *trackSink) WriteRTP(pkt *buffer.ExtPacket, layer int32) error {
+	if s.closed.Load() || layer > 0 {
+		return nil
+	}
+	// late packets, e.g. retransmissions, cannot be written in place
+	if s.started.Load() && pkt.ExtSequenceNumber <= s.lastSN.Load() {
+		return nil
+	}
+	s.started.Store(true)
+	s.lastSN.Store(pkt.ExtSequenceNumber)
+
+	select {
+	case s.packets <- sinkPacket{track: s.track, packet: pkt.Packet.Clone(), arrival: time.Now()}:
+	default:
+		s.logger.Debugw("sink queue full, dropping packet", "id", s.id)
+	}
+	return nil
+}
+
+// Close is called by the receiver when the track is unpublished
+func (s *trackSink) Close() {
+	if s.closed.Swap(true) {
+		return
+	}
+	if s.onClose != nil {
+		s.onClose()
+	}
+}
+
+func (s *trackSink) IsClosed() bool {
+	return s.closed.Load()
+}
+
+func (s *trackSink) ID() string {
+	return s.id
+}
+
+func (s *trackSink) SubscriberID() livekit.ParticipantID {
+	return livekit.ParticipantID(s.id)
+}
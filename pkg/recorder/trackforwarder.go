@@ -0,0 +1,307 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pion/srtp/v2"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+const (
+	TrackForwarderPrefix = "TF_"
+
+	SRTPProfileAES128CMSHA1_80 = "AES_CM_128_HMAC_SHA1_80"
+	SRTPProfileAES128CMSHA1_32 = "AES_CM_128_HMAC_SHA1_32"
+	SRTPProfileAEADAES128GCM   = "AEAD_AES_128_GCM"
+	SRTPProfileAEADAES256GCM   = "AEAD_AES_256_GCM"
+
+	forwarderMaxPayloadType = 127
+)
+
+var (
+	ErrInvalidForwardAddress = errors.New("forwarding address has to be host:port")
+	ErrInvalidPayloadType    = errors.New("payload type has to be between 0 and 127")
+	ErrInvalidSRTPProfile    = errors.New("unsupported SRTP protection profile")
+	ErrInvalidSRTPKey        = errors.New("SRTP master key and salt do not match the protection profile")
+)
+
+type srtpProfile struct {
+	profile srtp.ProtectionProfile
+	keyLen  int
+	saltLen int
+}
+
+var srtpProfiles = map[string]srtpProfile{
+	SRTPProfileAES128CMSHA1_80: {srtp.ProtectionProfileAes128CmHmacSha1_80, 16, 14},
+	SRTPProfileAES128CMSHA1_32: {srtp.ProtectionProfileAes128CmHmacSha1_32, 16, 14},
+	SRTPProfileAEADAES128GCM:   {srtp.ProtectionProfileAeadAes128Gcm, 16, 12},
+	SRTPProfileAEADAES256GCM:   {srtp.ProtectionProfileAeadAes256Gcm, 32, 12},
+}
+
+// SRTPParams protects forwarded packets, the master key and salt are given the way SDES (RFC 4568) does
+type SRTPParams struct {
+	// AES_CM_128_HMAC_SHA1_80 when empty
+	Profile string
+	// master key followed by the master salt
+	KeyAndSalt []byte
+}
+
+// TrackForwarderInfo describes a forwarder, SSRC and PayloadType are the values written to forwarded packets
+type TrackForwarderInfo struct {
+	ID          string    `json:"id"`
+	Room        string    `json:"room"`
+	TrackID     string    `json:"track_id"`
+	MimeType    string    `json:"mime_type"`
+	Address     string    `json:"address"`
+	SSRC        uint32    `json:"ssrc"`
+	PayloadType uint8     `json:"payload_type"`
+	SRTPProfile string    `json:"srtp_profile,omitempty"`
+	Packets     uint64    `json:"packets"`
+	StartedAt   time.Time `json:"started_at"`
+	EndedAt     time.Time `json:"ended_at,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+type TrackForwarderParams struct {
+	// UDP host:port packets are sent to
+	Address  string
+	Room     livekit.RoomName
+	TrackID  livekit.TrackID
+	Receiver sfu.TrackReceiver
+	// random when zero
+	SSRC uint32
+	// the payload type negotiated with the publisher when nil
+	PayloadType *uint8
+	// packets are sent as plain RTP when nil
+	SRTP   *SRTPParams
+	Logger logger.Logger
+}
+
+// TrackForwarder sends a copy of the RTP packets of a published track to a UDP address, taking the place of a down
+// track on the track's receiver. Packets keep their payload, sequence numbers and timestamps while the SSRC and
+// payload type are rewritten. Header extensions are removed, their IDs are only meaningful to the publisher's
+// session. Only the lowest spatial layer of simulcast and SVC video is forwarded.
+type TrackForwarder struct {
+	params TrackForwarderParams
+	conn   *net.UDPConn
+	srtp   *srtp.Context
+	sink   *trackSink
+
+	lock sync.Mutex
+	info TrackForwarderInfo
+
+	packets        chan sinkPacket
+	sent           atomic.Uint64
+	closed         atomic.Bool
+	done           chan struct{}
+	finished       chan struct{}
+	onFinishedOnce sync.Once
+	onFinished     func(info TrackForwarderInfo)
+}
+
+func NewTrackForwarder(params TrackForwarderParams) (*TrackForwarder, error) {
+	if _, _, err := net.SplitHostPort(params.Address); err != nil {
+		return nil, ErrInvalidForwardAddress
+	}
+
+	codec := params.Receiver.Codec()
+	payloadType := uint8(codec.PayloadType)
+	if params.PayloadType != nil {
+		if *params.PayloadType > forwarderMaxPayloadType {
+			return nil, ErrInvalidPayloadType
+		}
+		payloadType = *params.PayloadType
+	}
+	ssrc := params.SSRC
+	for ssrc == 0 {
+		ssrc = rand.Uint32()
+	}
+
+	f := &TrackForwarder{
+		params: params,
+		info: TrackForwarderInfo{
+			ID:          utils.NewGuid(TrackForwarderPrefix),
+			Room:        string(params.Room),
+			TrackID:     string(params.TrackID),
+			MimeType:    codec.MimeType,
+			Address:     params.Address,
+			SSRC:        ssrc,
+			PayloadType: payloadType,
+		},
+		packets:  make(chan sinkPacket, packetQueueSize),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+	if params.SRTP != nil {
+		name := params.SRTP.Profile
+		if name == "" {
+			name = SRTPProfileAES128CMSHA1_80
+		}
+		profile, ok := srtpProfiles[name]
+		if !ok {
+			return nil, ErrInvalidSRTPProfile
+		}
+		if len(params.SRTP.KeyAndSalt) != profile.keyLen+profile.saltLen {
+			return nil, ErrInvalidSRTPKey
+		}
+		key := params.SRTP.KeyAndSalt
+		var err error
+		if f.srtp, err = srtp.CreateContext(key[:profile.keyLen], key[profile.keyLen:], profile.profile); err != nil {
+			return nil, err
+		}
+		f.info.SRTPProfile = name
+	}
+	f.sink = newTrackSink(f.info.ID, 0, f.packets, params.Logger, f.close)
+	return f, nil
+}
+
+// Start attaches the forwarder to the receiver, f is called once forwarding has ended
+func (f *TrackForwarder) Start(onFinished func(info TrackForwarderInfo)) error {
+	addr, err := net.ResolveUDPAddr("udp", f.params.Address)
+	if err != nil {
+		return err
+	}
+	if f.conn, err = net.DialUDP("udp", nil, addr); err != nil {
+		return err
+	}
+	f.onFinished = onFinished
+	f.lock.Lock()
+	f.info.StartedAt = time.Now()
+	f.lock.Unlock()
+
+	go f.writeWorker()
+	if err = f.params.Receiver.AddDownTrack(f.sink); err != nil {
+		f.close()
+		<-f.finished
+		return err
+	}
+	if strings.HasPrefix(strings.ToLower(f.params.Receiver.Codec().MimeType), "video/") {
+		f.params.Receiver.SendPLI(0, true)
+	}
+	f.params.Logger.Infow("track forwarding started", "forwarderID", f.info.ID, "address", f.info.Address)
+	return nil
+}
+
+// Stop detaches the forwarder from the receiver
+func (f *TrackForwarder) Stop() {
+	f.close()
+}
+
+func (f *TrackForwarder) Info() TrackForwarderInfo {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	info := f.info
+	info.Packets = f.sent.Load()
+	return info
+}
+
+// Finished is closed once forwarding has ended
+func (f *TrackForwarder) Finished() <-chan struct{} {
+	return f.finished
+}
+
+func (f *TrackForwarder) close() {
+	if f.closed.Swap(true) {
+		return
+	}
+	close(f.done)
+}
+
+func (f *TrackForwarder) writeWorker() {
+	var err error
+	defer func() {
+		f.finish(err)
+	}()
+	for {
+		select {
+		case <-f.done:
+			return
+		case pkt := <-f.packets:
+			if err = f.write(pkt); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (f *TrackForwarder) write(pkt sinkPacket) error {
+	packet := pkt.packet
+	packet.SSRC = f.info.SSRC
+	packet.PayloadType = f.info.PayloadType
+	packet.Extension = false
+	packet.Extensions = nil
+	packet.ExtensionProfile = 0
+
+	data, err := packet.Marshal()
+	if err != nil {
+		f.params.Logger.Debugw("could not marshal packet", "error", err, "forwarderID", f.info.ID)
+		return nil
+	}
+	if f.srtp != nil {
+		if data, err = f.srtp.EncryptRTP(nil, data, nil); err != nil {
+			return err
+		}
+	}
+	if _, err = f.conn.Write(data); err != nil {
+		// the destination not listening yet is reported as refused by some systems, keep sending
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return nil
+		}
+		return err
+	}
+	f.sent.Inc()
+	return nil
+}
+
+func (f *TrackForwarder) finish(err error) {
+	f.params.Receiver.DeleteDownTrack(f.sink.SubscriberID())
+	f.sink.Close()
+	_ = f.conn.Close()
+
+	f.lock.Lock()
+	f.info.EndedAt = time.Now()
+	if err != nil {
+		f.info.Error = err.Error()
+	}
+	f.lock.Unlock()
+	info := f.Info()
+
+	if err != nil {
+		f.params.Logger.Warnw("track forwarding failed", err, "forwarderID", info.ID)
+	} else {
+		f.params.Logger.Infow("track forwarding ended", "forwarderID", info.ID, "packets", info.Packets)
+	}
+	close(f.finished)
+
+	if f.onFinished != nil {
+		f.onFinishedOnce.Do(func() {
+			f.onFinished(info)
+		})
+	}
+}
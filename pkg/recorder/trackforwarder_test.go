@@ -0,0 +1,126 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/srtp/v2"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+func newTestForwarder(t *testing.T, params TrackForwarderParams) (*TrackForwarder, *testReceiver, *net.UDPConn) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	receiver := &testReceiver{
+		codec: webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+			PayloadType:        111,
+		},
+	}
+	params.Address = conn.LocalAddr().String()
+	params.Room = "myroom"
+	params.TrackID = "TR_audio"
+	params.Receiver = receiver
+	params.Logger = logger.GetLogger()
+	f, err := NewTrackForwarder(params)
+	require.NoError(t, err)
+	require.NoError(t, f.Start(nil))
+	require.NotNil(t, receiver.downTrack)
+	return f, receiver, conn
+}
+
+func writeForwardedPacket(t *testing.T, receiver *testReceiver, sn uint16) {
+	packet := &rtp.Packet{
+		Header:  rtp.Header{Version: 2, PayloadType: 111, SequenceNumber: sn, Timestamp: uint32(sn) * 960, SSRC: 1234},
+		Payload: []byte{0xf8, 0xff, 0xfe},
+	}
+	require.NoError(t, packet.SetExtension(1, []byte{0x01}))
+	require.NoError(t, receiver.downTrack.WriteRTP(&buffer.ExtPacket{ExtSequenceNumber: uint64(sn), Packet: packet}, 0))
+}
+
+func readForwardedPacket(t *testing.T, conn *net.UDPConn) []byte {
+	buf := make([]byte, 1500)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	return buf[:n]
+}
+
+func TestTrackForwarder(t *testing.T) {
+	t.Run("rewrites ssrc and payload type", func(t *testing.T) {
+		payloadType := uint8(96)
+		f, receiver, conn := newTestForwarder(t, TrackForwarderParams{SSRC: 5678, PayloadType: &payloadType})
+		writeForwardedPacket(t, receiver, 1)
+
+		var packet rtp.Packet
+		require.NoError(t, packet.Unmarshal(readForwardedPacket(t, conn)))
+		require.Equal(t, uint32(5678), packet.SSRC)
+		require.Equal(t, uint8(96), packet.PayloadType)
+		require.Equal(t, uint16(1), packet.SequenceNumber)
+		require.False(t, packet.Extension)
+		require.Equal(t, []byte{0xf8, 0xff, 0xfe}, packet.Payload)
+
+		f.Stop()
+		<-f.Finished()
+		require.Nil(t, receiver.downTrack)
+		require.Equal(t, uint64(1), f.Info().Packets)
+	})
+
+	t.Run("encrypts with srtp", func(t *testing.T) {
+		key := make([]byte, 30)
+		for i := range key {
+			key[i] = byte(i)
+		}
+		f, receiver, conn := newTestForwarder(t, TrackForwarderParams{SRTP: &SRTPParams{KeyAndSalt: key}})
+		defer f.Stop()
+		require.Equal(t, uint8(111), f.Info().PayloadType)
+		require.Equal(t, SRTPProfileAES128CMSHA1_80, f.Info().SRTPProfile)
+		writeForwardedPacket(t, receiver, 1)
+
+		ctx, err := srtp.CreateContext(key[:16], key[16:], srtp.ProtectionProfileAes128CmHmacSha1_80)
+		require.NoError(t, err)
+		decrypted, err := ctx.DecryptRTP(nil, readForwardedPacket(t, conn), nil)
+		require.NoError(t, err)
+
+		var packet rtp.Packet
+		require.NoError(t, packet.Unmarshal(decrypted))
+		require.Equal(t, f.Info().SSRC, packet.SSRC)
+		require.Equal(t, []byte{0xf8, 0xff, 0xfe}, packet.Payload)
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		receiver := &testReceiver{}
+		_, err := NewTrackForwarder(TrackForwarderParams{Address: "localhost", Receiver: receiver})
+		require.ErrorIs(t, err, ErrInvalidForwardAddress)
+
+		_, err = NewTrackForwarder(TrackForwarderParams{
+			Address:  "localhost:5004",
+			Receiver: receiver,
+			SRTP:     &SRTPParams{Profile: SRTPProfileAEADAES128GCM, KeyAndSalt: make([]byte, 30)},
+		})
+		require.ErrorIs(t, err, ErrInvalidSRTPKey)
+	})
+}
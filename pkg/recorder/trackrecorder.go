@@ -0,0 +1,303 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media/h264writer"
+	"github.com/pion/webrtc/v3/pkg/media/ivfwriter"
+	"github.com/pion/webrtc/v3/pkg/media/oggwriter"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+const (
+	RecordingPrefix = "TR_"
+
+	// RecordingFormatMP4 writes H.264 and Opus tracks to fragmented MP4 instead of their native container
+	RecordingFormatMP4 = "mp4"
+
+	packetQueueSize = 500
+)
+
+var (
+	ErrRecorderDisabled = errors.New("recorder is not configured")
+	ErrUnsupportedCodec = errors.New("codec cannot be recorded")
+	ErrUnknownFormat    = errors.New("unknown recording format")
+)
+
+type packetWriter interface {
+	WriteRTP(packet *rtp.Packet) error
+	Close() error
+}
+
+// RecordingInfo describes a recording, Location is set once a finished recording has been stored
+type RecordingInfo struct {
+	ID        string    `json:"id"`
+	Room      string    `json:"room"`
+	TrackID   string    `json:"track_id"`
+	MimeType  string    `json:"mime_type"`
+	Filepath  string    `json:"filepath"`
+	Location  string    `json:"location,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type TrackRecorderParams struct {
+	Config   config.RecorderConfig
+	Room     livekit.RoomName
+	TrackID  livekit.TrackID
+	Receiver sfu.TrackReceiver
+	// empty for the native container of the codec, or RecordingFormatMP4
+	Format string
+	Logger logger.Logger
+}
+
+// TrackRecorder writes the packets of a published track to a file, taking the place of a down track on the
+// track's receiver. Opus is written to OGG, VP8 and AV1 to IVF and H.264 to an Annex B stream, or H.264 and
+// Opus to fragmented MP4 when requested. Only the lowest spatial layer of simulcast and SVC video is recorded.
+type TrackRecorder struct {
+	params TrackRecorderParams
+	writer packetWriter
+
+	lock sync.Mutex
+	info RecordingInfo
+
+	sink           *trackSink
+	packets        chan sinkPacket
+	closed         atomic.Bool
+	done           chan struct{}
+	finished       chan struct{}
+	onFinishedOnce sync.Once
+	onFinished     func(info RecordingInfo)
+
+	// owned by writeWorker
+	lastPLI time.Time
+}
+
+func NewTrackRecorder(params TrackRecorderParams) (*TrackRecorder, error) {
+	if params.Config.Directory == "" {
+		return nil, ErrRecorderDisabled
+	}
+
+	codec := params.Receiver.Codec()
+	ext, err := fileExtension(codec.MimeType, params.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	id := utils.NewGuid(RecordingPrefix)
+	fileName := filepath.Join(params.Config.Directory, string(params.Room), fmt.Sprintf("%s-%s.%s", params.TrackID, id, ext))
+	if err = os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return nil, err
+	}
+
+	r := &TrackRecorder{
+		params: params,
+		info: RecordingInfo{
+			ID:        id,
+			Room:      string(params.Room),
+			TrackID:   string(params.TrackID),
+			MimeType:  codec.MimeType,
+			Filepath:  fileName,
+			StartedAt: time.Now(),
+		},
+		packets:  make(chan sinkPacket, packetQueueSize),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+	if params.Format == RecordingFormatMP4 {
+		r.writer, err = newMP4Writer(codec, fileName, r.requestKeyFrame)
+	} else {
+		r.writer, err = newPacketWriter(codec, fileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.sink = newTrackSink(id, 0, r.packets, params.Logger, r.close)
+	return r, nil
+}
+
+func fileExtension(mimeType string, format string) (string, error) {
+	switch format {
+	case "":
+	case RecordingFormatMP4:
+		if !strings.EqualFold(mimeType, webrtc.MimeTypeOpus) && !strings.EqualFold(mimeType, webrtc.MimeTypeH264) {
+			return "", ErrUnsupportedCodec
+		}
+		return "mp4", nil
+	default:
+		return "", ErrUnknownFormat
+	}
+
+	switch {
+	case strings.EqualFold(mimeType, webrtc.MimeTypeOpus):
+		return "ogg", nil
+	case strings.EqualFold(mimeType, webrtc.MimeTypeVP8), strings.EqualFold(mimeType, webrtc.MimeTypeAV1):
+		return "ivf", nil
+	case strings.EqualFold(mimeType, webrtc.MimeTypeH264):
+		return "h264", nil
+	default:
+		return "", ErrUnsupportedCodec
+	}
+}
+
+func newPacketWriter(codec webrtc.RTPCodecParameters, fileName string) (packetWriter, error) {
+	switch {
+	case strings.EqualFold(codec.MimeType, webrtc.MimeTypeOpus):
+		channels := codec.Channels
+		if channels == 0 {
+			channels = 2
+		}
+		return oggwriter.New(fileName, codec.ClockRate, channels)
+	case strings.EqualFold(codec.MimeType, webrtc.MimeTypeVP8):
+		return ivfwriter.New(fileName, ivfwriter.WithCodec(webrtc.MimeTypeVP8))
+	case strings.EqualFold(codec.MimeType, webrtc.MimeTypeAV1):
+		return ivfwriter.New(fileName, ivfwriter.WithCodec(webrtc.MimeTypeAV1))
+	case strings.EqualFold(codec.MimeType, webrtc.MimeTypeH264):
+		return h264writer.New(fileName)
+	default:
+		return nil, ErrUnsupportedCodec
+	}
+}
+
+// Start attaches the recorder to the receiver, f is called once the recording has been stored
+func (r *TrackRecorder) Start(f func(info RecordingInfo)) error {
+	r.onFinished = f
+	go r.writeWorker()
+	if err := r.params.Receiver.AddDownTrack(r.sink); err != nil {
+		r.sink.Close()
+		return err
+	}
+	if strings.HasPrefix(strings.ToLower(r.params.Receiver.Codec().MimeType), "video/") {
+		r.params.Receiver.SendPLI(0, true)
+	}
+	r.params.Logger.Infow("track recording started", "recordingID", r.info.ID, "filepath", r.info.Filepath)
+	return nil
+}
+
+// Stop detaches the recorder from the receiver and finishes the recording
+func (r *TrackRecorder) Stop() {
+	r.params.Receiver.DeleteDownTrack(r.sink.SubscriberID())
+	r.sink.Close()
+}
+
+func (r *TrackRecorder) Info() RecordingInfo {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.info
+}
+
+// Finished is closed once the recording has been stored
+func (r *TrackRecorder) Finished() <-chan struct{} {
+	return r.finished
+}
+
+func (r *TrackRecorder) writeWorker() {
+	defer r.finish()
+	for {
+		select {
+		case <-r.done:
+			// packets queued before the sink was closed belong to the recording
+			for {
+				select {
+				case pkt := <-r.packets:
+					r.writePacket(pkt)
+				default:
+					return
+				}
+			}
+		case pkt := <-r.packets:
+			r.writePacket(pkt)
+		}
+	}
+}
+
+func (r *TrackRecorder) writePacket(pkt sinkPacket) {
+	if err := r.writer.WriteRTP(pkt.packet); err != nil {
+		r.params.Logger.Debugw("could not write packet", "error", err, "recordingID", r.info.ID)
+	}
+}
+
+func (r *TrackRecorder) requestKeyFrame() {
+	if time.Since(r.lastPLI) < rtmpPLIInterval {
+		return
+	}
+	r.lastPLI = time.Now()
+	r.params.Receiver.SendPLI(0, true)
+}
+
+func (r *TrackRecorder) finish() {
+	err := r.writer.Close()
+
+	r.lock.Lock()
+	r.info.EndedAt = time.Now()
+	info := r.info
+	r.lock.Unlock()
+
+	if err == nil && r.params.Config.S3.Bucket != "" {
+		key := path.Join(info.Room, filepath.Base(info.Filepath))
+		var location string
+		location, err = NewS3Uploader(r.params.Config.S3).Upload(context.Background(), info.Filepath, key)
+		if err == nil {
+			info.Location = location
+			_ = os.Remove(info.Filepath)
+		}
+	} else if err == nil {
+		info.Location = info.Filepath
+	}
+	if err != nil {
+		info.Error = err.Error()
+		r.params.Logger.Errorw("could not store track recording", err, "recordingID", info.ID)
+	} else {
+		r.params.Logger.Infow("track recording stored", "recordingID", info.ID, "location", info.Location)
+	}
+
+	r.lock.Lock()
+	r.info = info
+	r.lock.Unlock()
+	close(r.finished)
+
+	if r.onFinished != nil {
+		r.onFinishedOnce.Do(func() {
+			r.onFinished(info)
+		})
+	}
+}
+
+func (r *TrackRecorder) close() {
+	if r.closed.Swap(true) {
+		return
+	}
+	close(r.done)
+}
@@ -0,0 +1,224 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+type testReceiver struct {
+	sfu.TrackReceiver
+
+	codec     webrtc.RTPCodecParameters
+	downTrack sfu.TrackSender
+}
+
+func (t *testReceiver) Codec() webrtc.RTPCodecParameters { return t.codec }
+
+func (t *testReceiver) SendPLI(_ int32, _ bool) {}
+
+func (t *testReceiver) AddDownTrack(track sfu.TrackSender) error {
+	t.downTrack = track
+	return nil
+}
+
+func (t *testReceiver) DeleteDownTrack(_ livekit.ParticipantID) {
+	t.downTrack = nil
+}
+
+func newTestRecorder(t *testing.T, conf config.RecorderConfig, mimeType string) (*TrackRecorder, *testReceiver) {
+	receiver := &testReceiver{
+		codec: webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: mimeType, ClockRate: 48000, Channels: 2},
+		},
+	}
+	rec, err := NewTrackRecorder(TrackRecorderParams{
+		Config:   conf,
+		Room:     "myroom",
+		TrackID:  "TR_audio",
+		Receiver: receiver,
+		Logger:   logger.GetLogger(),
+	})
+	require.NoError(t, err)
+	return rec, receiver
+}
+
+func writeOpusPackets(t *testing.T, track sfu.TrackSender, count int) {
+	for i := 0; i < count; i++ {
+		err := track.WriteRTP(&buffer.ExtPacket{
+			ExtSequenceNumber: uint64(i + 1),
+			Packet: &rtp.Packet{
+				Header:  rtp.Header{Version: 2, SequenceNumber: uint16(i + 1), Timestamp: uint32(i * 960), SSRC: 1234},
+				Payload: []byte{0xf8, 0xff, 0xfe},
+			},
+		}, 0)
+		require.NoError(t, err)
+	}
+}
+
+func waitFinished(t *testing.T, rec *TrackRecorder) RecordingInfo {
+	select {
+	case <-rec.Finished():
+	case <-time.After(5 * time.Second):
+		t.Fatal("recording did not finish")
+	}
+	return rec.Info()
+}
+
+func TestTrackRecorder(t *testing.T) {
+	t.Run("writes opus to ogg", func(t *testing.T) {
+		rec, receiver := newTestRecorder(t, config.RecorderConfig{Directory: t.TempDir()}, webrtc.MimeTypeOpus)
+		require.NoError(t, rec.Start(nil))
+		require.NotNil(t, receiver.downTrack)
+
+		writeOpusPackets(t, receiver.downTrack, 50)
+		rec.Stop()
+		require.Nil(t, receiver.downTrack)
+
+		info := waitFinished(t, rec)
+		require.Empty(t, info.Error)
+		require.Equal(t, info.Filepath, info.Location)
+		require.True(t, strings.HasSuffix(info.Filepath, ".ogg"))
+		require.False(t, info.EndedAt.IsZero())
+
+		data, err := os.ReadFile(info.Filepath)
+		require.NoError(t, err)
+		require.Equal(t, "OggS", string(data[:4]))
+	})
+
+	t.Run("writes opus to fragmented mp4 including queued packets", func(t *testing.T) {
+		receiver := &testReceiver{
+			codec: webrtc.RTPCodecParameters{
+				RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+			},
+		}
+		rec, err := NewTrackRecorder(TrackRecorderParams{
+			Config:   config.RecorderConfig{Directory: t.TempDir()},
+			Room:     "myroom",
+			TrackID:  "TR_audio",
+			Receiver: receiver,
+			Format:   RecordingFormatMP4,
+			Logger:   logger.GetLogger(),
+		})
+		require.NoError(t, err)
+		require.NoError(t, rec.Start(nil))
+
+		// stopping right after writing leaves packets queued, they are written before the file is closed
+		writeOpusPackets(t, receiver.downTrack, 400)
+		rec.Stop()
+
+		info := waitFinished(t, rec)
+		require.Empty(t, info.Error)
+		require.True(t, strings.HasSuffix(info.Filepath, ".mp4"))
+
+		data, err := os.ReadFile(info.Filepath)
+		require.NoError(t, err)
+		require.Equal(t, "ftyp", string(data[4:8]))
+		samples := 0
+		for len(data) >= 8 {
+			size := binary.BigEndian.Uint32(data)
+			if string(data[4:8]) == "moof" {
+				trun := bytes.Index(data[:size], []byte("trun"))
+				require.Greater(t, trun, 0)
+				samples += int(binary.BigEndian.Uint32(data[trun+8:]))
+			}
+			data = data[size:]
+		}
+		require.Equal(t, 400, samples)
+	})
+
+	t.Run("mp4 is limited to h264 and opus", func(t *testing.T) {
+		_, err := NewTrackRecorder(TrackRecorderParams{
+			Config: config.RecorderConfig{Directory: t.TempDir()},
+			Receiver: &testReceiver{
+				codec: webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}},
+			},
+			Format: RecordingFormatMP4,
+		})
+		require.ErrorIs(t, err, ErrUnsupportedCodec)
+	})
+
+	t.Run("unsupported codec", func(t *testing.T) {
+		_, err := NewTrackRecorder(TrackRecorderParams{
+			Config: config.RecorderConfig{Directory: t.TempDir()},
+			Receiver: &testReceiver{
+				codec: webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9}},
+			},
+		})
+		require.ErrorIs(t, err, ErrUnsupportedCodec)
+	})
+
+	t.Run("uploads to s3", func(t *testing.T) {
+		var (
+			path          string
+			authorization string
+			body          []byte
+		)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			authorization = r.Header.Get("Authorization")
+			body, _ = io.ReadAll(r.Body)
+		}))
+		defer server.Close()
+
+		called := make(chan RecordingInfo, 1)
+		rec, receiver := newTestRecorder(t, config.RecorderConfig{
+			Directory: t.TempDir(),
+			S3: config.S3Config{
+				AccessKey:      "key",
+				Secret:         "secret",
+				Endpoint:       server.URL,
+				Bucket:         "recordings",
+				Prefix:         "calls/",
+				ForcePathStyle: true,
+			},
+		}, webrtc.MimeTypeOpus)
+		require.NoError(t, rec.Start(func(info RecordingInfo) {
+			called <- info
+		}))
+		writeOpusPackets(t, receiver.downTrack, 10)
+		rec.Stop()
+
+		info := <-called
+		require.Empty(t, info.Error)
+		require.True(t, strings.HasPrefix(path, "/recordings/calls/myroom/TR_audio-"))
+		require.Equal(t, server.URL+path, info.Location)
+		require.True(t, strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=key/"))
+		require.Contains(t, authorization, "/us-east-1/s3/aws4_request")
+		require.Equal(t, "OggS", string(body[:4]))
+
+		_, err := os.Stat(info.Filepath)
+		require.True(t, os.IsNotExist(err))
+	})
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	addressFamilyPreferV6       = "prefer_v6"
+	addressFamilyPreferV4       = "prefer_v4"
+	addressFamilyHappyEyeballs  = "happy_eyeballs"
+	defaultNAT64Prefix          = "64:ff9b::/96"
+	candidatePriorityFieldIndex = 3
+	candidateAddressFieldIndex  = 4
+)
+
+// AddressFamilyPolicy adjusts the priority of local candidates advertised to clients by address family,
+// and classifies addresses, recognizing IPv4 addresses reached through NAT64
+type AddressFamilyPolicy struct {
+	preference    string
+	nat64Prefixes []*net.IPNet
+}
+
+func NewAddressFamilyPolicy(conf config.AddressFamilyConfig) (*AddressFamilyPolicy, error) {
+	p := &AddressFamilyPolicy{
+		preference: strings.ToLower(conf.Preference),
+	}
+	switch p.preference {
+	case "", addressFamilyPreferV6, addressFamilyPreferV4, addressFamilyHappyEyeballs:
+	default:
+		return nil, fmt.Errorf("unknown address family preference %q", conf.Preference)
+	}
+
+	prefixes := conf.NAT64Prefixes
+	if len(prefixes) == 0 {
+		prefixes = []string{defaultNAT64Prefix}
+	}
+	for _, prefix := range prefixes {
+		_, ipNet, err := net.ParseCIDR(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NAT64 prefix %q: %w", prefix, err)
+		}
+		if ipNet.IP.To4() != nil {
+			return nil, fmt.Errorf("NAT64 prefix %q is not an IPv6 prefix", prefix)
+		}
+		p.nat64Prefixes = append(p.nat64Prefixes, ipNet)
+	}
+	return p, nil
+}
+
+// Family classifies an address, nil policies recognize the well-known NAT64 prefix only
+func (p *AddressFamilyPolicy) Family(address string) types.ICEAddressFamily {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return types.ICEAddressFamilyUnknown
+	}
+	if ip.To4() != nil {
+		return types.ICEAddressFamilyIPv4
+	}
+
+	var prefixes []*net.IPNet
+	if p != nil {
+		prefixes = p.nat64Prefixes
+	} else {
+		_, wellKnown, _ := net.ParseCIDR(defaultNAT64Prefix)
+		prefixes = []*net.IPNet{wellKnown}
+	}
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return types.ICEAddressFamilyNAT64
+		}
+	}
+	return types.ICEAddressFamilyIPv6
+}
+
+// AdjustPriority changes the local preference of a candidate priority (RFC 8445, section 5.1.2.1),
+// keeping the type preference so that the candidate type still ranks first.
+//
+//   - prefer_v6/prefer_v4: candidates of the other family rank below all candidates of the preferred one of the same type
+//   - happy_eyeballs: IPv6 candidates rank one step above IPv4 ones, so that connectivity checks alternate between families
+//
+// Addresses in NAT64 prefixes are IPv4 paths and are treated as such.
+func (p *AddressFamilyPolicy) AdjustPriority(address string, priority uint32) uint32 {
+	if p == nil || p.preference == "" {
+		return priority
+	}
+
+	family := p.Family(address)
+	if family == types.ICEAddressFamilyUnknown {
+		return priority
+	}
+	isIPv6 := family == types.ICEAddressFamilyIPv6
+
+	localPreference := (priority >> 8) & 0xffff
+	switch p.preference {
+	case addressFamilyPreferV6:
+		if !isIPv6 {
+			localPreference >>= 1
+		}
+	case addressFamilyPreferV4:
+		if isIPv6 {
+			localPreference >>= 1
+		}
+	case addressFamilyHappyEyeballs:
+		if !isIPv6 && localPreference > 0 {
+			localPreference--
+		}
+	}
+	return priority&0xff000000 | localPreference<<8 | priority&0xff
+}
+
+// AdjustCandidate rewrites the priority of a candidate as it appears in SDP, with or without the "candidate:" prefix
+func (p *AddressFamilyPolicy) AdjustCandidate(candidate string) string {
+	if p == nil || p.preference == "" {
+		return candidate
+	}
+
+	fields := strings.Fields(candidate)
+	if len(fields) <= candidateAddressFieldIndex {
+		return candidate
+	}
+	priority, err := strconv.ParseUint(fields[candidatePriorityFieldIndex], 10, 32)
+	if err != nil {
+		return candidate
+	}
+	fields[candidatePriorityFieldIndex] = strconv.FormatUint(uint64(p.AdjustPriority(fields[candidateAddressFieldIndex], uint32(priority))), 10)
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+func TestAddressFamilyPolicy(t *testing.T) {
+	const (
+		// host, local preference 65535, component 1
+		hostPriority  = uint32(126<<24 | 65535<<8 | 255)
+		srflxPriority = uint32(100<<24 | 65535<<8 | 255)
+	)
+
+	t.Run("family", func(t *testing.T) {
+		p, err := NewAddressFamilyPolicy(config.AddressFamilyConfig{NAT64Prefixes: []string{"2001:db8:64::/96"}})
+		require.NoError(t, err)
+		require.Equal(t, types.ICEAddressFamilyIPv4, p.Family("203.0.113.1"))
+		require.Equal(t, types.ICEAddressFamilyIPv6, p.Family("2001:db8::1"))
+		require.Equal(t, types.ICEAddressFamilyNAT64, p.Family("2001:db8:64::cb00:7101"))
+		require.Equal(t, types.ICEAddressFamilyIPv6, p.Family("64:ff9b::cb00:7101"))
+		require.Equal(t, types.ICEAddressFamilyUnknown, p.Family("abcd.local"))
+
+		var nilPolicy *AddressFamilyPolicy
+		require.Equal(t, types.ICEAddressFamilyNAT64, nilPolicy.Family("64:ff9b::cb00:7101"))
+	})
+
+	t.Run("no preference", func(t *testing.T) {
+		p, err := NewAddressFamilyPolicy(config.AddressFamilyConfig{})
+		require.NoError(t, err)
+		require.Equal(t, hostPriority, p.AdjustPriority("203.0.113.1", hostPriority))
+	})
+
+	t.Run("prefer v6", func(t *testing.T) {
+		p, err := NewAddressFamilyPolicy(config.AddressFamilyConfig{Preference: "prefer_v6"})
+		require.NoError(t, err)
+		v6 := p.AdjustPriority("2001:db8::1", hostPriority)
+		v4 := p.AdjustPriority("203.0.113.1", hostPriority)
+		nat64 := p.AdjustPriority("64:ff9b::cb00:7101", hostPriority)
+		require.Equal(t, hostPriority, v6)
+		require.Less(t, v4, v6)
+		require.Equal(t, v4, nat64)
+		// type preference still ranks first
+		require.Greater(t, v4, p.AdjustPriority("2001:db8::1", srflxPriority))
+	})
+
+	t.Run("happy eyeballs", func(t *testing.T) {
+		p, err := NewAddressFamilyPolicy(config.AddressFamilyConfig{Preference: "happy_eyeballs"})
+		require.NoError(t, err)
+		require.Equal(t, hostPriority-256, p.AdjustPriority("203.0.113.1", hostPriority))
+		require.Equal(t, hostPriority, p.AdjustPriority("2001:db8::1", hostPriority))
+	})
+
+	t.Run("candidate", func(t *testing.T) {
+		p, err := NewAddressFamilyPolicy(config.AddressFamilyConfig{Preference: "prefer_v4"})
+		require.NoError(t, err)
+		require.Equal(t,
+			"candidate:1 1 udp 2122317823 2001:db8::1 7882 typ host",
+			p.AdjustCandidate("candidate:1 1 udp 2130706431 2001:db8::1 7882 typ host"),
+		)
+		require.Equal(t,
+			"candidate:2 1 udp 2130706431 203.0.113.1 7882 typ host",
+			p.AdjustCandidate("candidate:2 1 udp 2130706431 203.0.113.1 7882 typ host"),
+		)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewAddressFamilyPolicy(config.AddressFamilyConfig{Preference: "v6"})
+		require.Error(t, err)
+		_, err = NewAddressFamilyPolicy(config.AddressFamilyConfig{NAT64Prefixes: []string{"10.0.0.0/8"}})
+		require.Error(t, err)
+	})
+}
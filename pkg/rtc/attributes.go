@@ -0,0 +1,339 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// AttributesTopicPrefix is reserved for attribute messages, participants may only send on AttributesUpdateTopic
+	AttributesTopicPrefix = "lk.attributes."
+	// AttributesUpdateTopic is the topic of messages participants send to set or delete attributes, they are
+	// handled by the server and not forwarded
+	AttributesUpdateTopic = "lk.attributes.update"
+	// AttributesChangedTopic is the topic of messages telling participants which attributes of a participant changed
+	AttributesChangedTopic = "lk.attributes.changed"
+)
+
+// AttributePermission is who may write an attribute key
+type AttributePermission string
+
+const (
+	// the participant itself, moderators and the server API
+	AttributePermissionSelf AttributePermission = "self"
+	// participants with a roomAdmin grant and the server API
+	AttributePermissionModerator AttributePermission = "moderator"
+	// the server API only
+	AttributePermissionServer AttributePermission = "server"
+)
+
+// AttributePermissions resolves the permission of attribute keys from config.
+// A nil AttributePermissions lets participants write all of their attributes.
+type AttributePermissions struct {
+	defaultPermission AttributePermission
+	keys              map[string]AttributePermission
+	// prefix entries, longest first
+	prefixes []string
+
+	maxKeys      int
+	maxKeySize   int
+	maxValueSize int
+}
+
+func NewAttributePermissions(conf config.ParticipantAttributesConfig) *AttributePermissions {
+	p := &AttributePermissions{
+		defaultPermission: AttributePermissionSelf,
+		keys:              make(map[string]AttributePermission),
+		maxKeys:           int(conf.MaxKeys),
+		maxKeySize:        int(conf.MaxKeySize),
+		maxValueSize:      int(conf.MaxValueSize),
+	}
+	if conf.DefaultPermission != "" {
+		p.defaultPermission = AttributePermission(conf.DefaultPermission)
+	}
+	for key, permission := range conf.Permissions {
+		p.keys[key] = AttributePermission(permission)
+		if strings.HasSuffix(key, "*") {
+			p.prefixes = append(p.prefixes, key)
+		}
+	}
+	sort.Slice(p.prefixes, func(i, j int) bool {
+		return len(p.prefixes[i]) > len(p.prefixes[j])
+	})
+	return p
+}
+
+// PermissionFor returns the permission of key, an exact entry takes precedence over the longest matching prefix
+func (p *AttributePermissions) PermissionFor(key string) AttributePermission {
+	if p == nil {
+		return AttributePermissionSelf
+	}
+	if permission, ok := p.keys[key]; ok {
+		return permission
+	}
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(key, strings.TrimSuffix(prefix, "*")) {
+			return p.keys[prefix]
+		}
+	}
+	return p.defaultPermission
+}
+
+// CanWrite returns whether actor may write key of participant, a nil actor is the server API
+func (p *AttributePermissions) CanWrite(key string, participant types.LocalParticipant, actor types.LocalParticipant) bool {
+	if actor == nil {
+		return true
+	}
+	switch p.PermissionFor(key) {
+	case AttributePermissionSelf:
+		return actor.Identity() == participant.Identity() || isModerator(actor)
+	case AttributePermissionModerator:
+		return isModerator(actor)
+	}
+	return false
+}
+
+// withinLimits returns whether the attributes of a participant stay within the configured limits after
+// applying set and del
+func (p *AttributePermissions) withinLimits(attributes map[string]string, set map[string]string, del []string) bool {
+	if p == nil {
+		return true
+	}
+	for key, value := range set {
+		if (p.maxKeySize > 0 && len(key) > p.maxKeySize) || (p.maxValueSize > 0 && len(value) > p.maxValueSize) {
+			return false
+		}
+	}
+	if p.maxKeys <= 0 {
+		return true
+	}
+
+	numKeys := len(attributes)
+	for key := range set {
+		if _, ok := attributes[key]; !ok {
+			numKeys++
+		}
+	}
+	for _, key := range del {
+		if _, ok := attributes[key]; ok {
+			if _, ok := set[key]; !ok {
+				numKeys--
+			}
+		}
+	}
+	return numKeys <= p.maxKeys
+}
+
+func isModerator(p types.LocalParticipant) bool {
+	grants := p.ClaimGrants()
+	return grants != nil && grants.Video != nil && grants.Video.RoomAdmin
+}
+
+// AttributesUpdate is the payload of messages on AttributesUpdateTopic
+type AttributesUpdate struct {
+	// participant whose attributes are written, the sender when empty
+	Identity string            `json:"identity,omitempty"`
+	Set      map[string]string `json:"set,omitempty"`
+	Delete   []string          `json:"delete,omitempty"`
+}
+
+// AttributesChanged is the payload of messages on AttributesChangedTopic. It holds only the attributes that
+// changed, except for the messages sent to a participant when it joins, which hold all attributes.
+type AttributesChanged struct {
+	ParticipantSid      string            `json:"participant_sid"`
+	ParticipantIdentity string            `json:"participant_identity"`
+	Set                 map[string]string `json:"set,omitempty"`
+	Delete              []string          `json:"delete,omitempty"`
+}
+
+func (r *Room) SetAttributePermissions(permissions *AttributePermissions) {
+	r.lock.Lock()
+	r.attributePermissions = permissions
+	r.lock.Unlock()
+}
+
+// GetParticipantAttributes returns a copy of the attributes of a participant
+func (r *Room) GetParticipantAttributes(identity livekit.ParticipantIdentity) map[string]string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	attributes := make(map[string]string, len(r.attributes[identity]))
+	for key, value := range r.attributes[identity] {
+		attributes[key] = value
+	}
+	return attributes
+}
+
+// UpdateParticipantAttributes sets and deletes attributes of a participant on behalf of actor, nil for the
+// server API. The update is rejected as a whole when actor may not write one of the keys or when the attributes
+// would exceed the configured limits. Only the attributes that changed are sent to the room.
+func (r *Room) UpdateParticipantAttributes(
+	participant types.LocalParticipant,
+	set map[string]string,
+	del []string,
+	actor types.LocalParticipant,
+) error {
+	r.lock.Lock()
+	for key := range set {
+		if key == "" {
+			r.lock.Unlock()
+			return ErrEmptyAttributeKey
+		}
+		if !r.attributePermissions.CanWrite(key, participant, actor) {
+			r.lock.Unlock()
+			return ErrAttributePermissionDenied
+		}
+	}
+	for _, key := range del {
+		if !r.attributePermissions.CanWrite(key, participant, actor) {
+			r.lock.Unlock()
+			return ErrAttributePermissionDenied
+		}
+	}
+
+	attributes := r.attributes[participant.Identity()]
+	if !r.attributePermissions.withinLimits(attributes, set, del) {
+		r.lock.Unlock()
+		return ErrAttributesExceedLimits
+	}
+	if attributes == nil {
+		attributes = make(map[string]string)
+		r.attributes[participant.Identity()] = attributes
+	}
+	changed := AttributesChanged{
+		ParticipantSid:      string(participant.ID()),
+		ParticipantIdentity: string(participant.Identity()),
+	}
+	for key, value := range set {
+		if prev, ok := attributes[key]; ok && prev == value {
+			continue
+		}
+		attributes[key] = value
+		if changed.Set == nil {
+			changed.Set = make(map[string]string)
+		}
+		changed.Set[key] = value
+	}
+	for _, key := range del {
+		if _, ok := attributes[key]; !ok {
+			continue
+		}
+		if _, ok := set[key]; ok {
+			continue
+		}
+		delete(attributes, key)
+		changed.Delete = append(changed.Delete, key)
+	}
+	r.lock.Unlock()
+
+	if len(changed.Set) == 0 && len(changed.Delete) == 0 {
+		return nil
+	}
+
+	var actorIdentity livekit.ParticipantIdentity
+	if actor != nil {
+		actorIdentity = actor.Identity()
+	}
+	r.Logger.Infow("participant attributes updated",
+		"participant", participant.Identity(),
+		"actor", actorIdentity,
+		"set", changed.Set,
+		"delete", changed.Delete,
+	)
+	r.sendAttributesChanged(&changed, nil)
+	r.appendJournal(JournalEntry{
+		Type:              JournalEventParticipantAttributesChanged,
+		Identity:          participant.Identity(),
+		ParticipantID:     participant.ID(),
+		Attributes:        changed.Set,
+		DeletedAttributes: changed.Delete,
+	})
+	return nil
+}
+
+// handleAttributesUpdate applies an update sent by a participant on AttributesUpdateTopic
+func (r *Room) handleAttributesUpdate(source types.LocalParticipant, payload []byte) {
+	var update AttributesUpdate
+	if err := json.Unmarshal(payload, &update); err != nil {
+		r.Logger.Warnw("could not decode attributes update", err, "participant", source.Identity())
+		return
+	}
+
+	participant := source
+	if update.Identity != "" && update.Identity != string(source.Identity()) {
+		if participant = r.GetParticipant(livekit.ParticipantIdentity(update.Identity)); participant == nil {
+			r.Logger.Infow("attributes update for unknown participant",
+				"participant", update.Identity, "actor", source.Identity())
+			return
+		}
+	}
+	if err := r.UpdateParticipantAttributes(participant, update.Set, update.Delete, source); err != nil {
+		r.Logger.Warnw("rejecting participant attributes update", err,
+			"participant", participant.Identity(), "actor", source.Identity())
+	}
+}
+
+// sendParticipantAttributes sends the attributes of all other participants to a participant that has just joined
+func (r *Room) sendParticipantAttributes(p types.LocalParticipant) {
+	var snapshots []*AttributesChanged
+	r.lock.RLock()
+	for identity, attributes := range r.attributes {
+		op := r.participants[identity]
+		if identity == p.Identity() || op == nil || op.Hidden() || len(attributes) == 0 {
+			continue
+		}
+		snapshot := &AttributesChanged{
+			ParticipantSid:      string(op.ID()),
+			ParticipantIdentity: string(identity),
+			Set:                 make(map[string]string, len(attributes)),
+		}
+		for key, value := range attributes {
+			snapshot.Set[key] = value
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	r.lock.RUnlock()
+
+	for _, snapshot := range snapshots {
+		r.sendAttributesChanged(snapshot, []livekit.ParticipantIdentity{p.Identity()})
+	}
+}
+
+func (r *Room) sendAttributesChanged(changed *AttributesChanged, destinations []livekit.ParticipantIdentity) {
+	payload, err := json.Marshal(changed)
+	if err != nil {
+		r.Logger.Errorw("could not marshal attributes change", err)
+		return
+	}
+	topic := AttributesChangedTopic
+	BroadcastDataPacketForRoom(r, nil, &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				Payload:               payload,
+				Topic:                 &topic,
+				DestinationIdentities: livekit.IDsAsStrings(destinations),
+			},
+		},
+	}, r.Logger)
+}
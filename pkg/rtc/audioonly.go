@@ -0,0 +1,40 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// AudioOnlyTopic is the topic of messages participants send to pause or resume all of their subscribed video,
+// they are handled by the server and not forwarded
+const AudioOnlyTopic = "lk.audio_only"
+
+// AudioOnlyRequest is the payload of messages on AudioOnlyTopic
+type AudioOnlyRequest struct {
+	AudioOnly bool `json:"audio_only"`
+}
+
+// handleAudioOnlyRequest switches the audio only mode of the participant sending the request
+func (r *Room) handleAudioOnlyRequest(source types.LocalParticipant, payload []byte) {
+	var req AudioOnlyRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		r.Logger.Warnw("could not decode audio only request", err, "participant", source.Identity())
+		return
+	}
+	source.SetAudioOnly(req.AudioOnly)
+}
@@ -0,0 +1,342 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"errors"
+	"net"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/pion/ice/v2"
+	"github.com/pion/logging"
+	"go.uber.org/atomic"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/livekit/mediatransportutil/pkg/rtcconfig"
+	"github.com/livekit/mediatransportutil/pkg/transport"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu/pacer"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+const (
+	batchReadMTU      = 8192
+	batchWriteMTU     = 1500
+	batchSocketBuffer = 16 * 1024 * 1024
+	// used when batch_io.max_flush_interval is not set
+	defaultBatchFlushInterval = 2 * time.Millisecond
+
+	// kernel limits of a UDP GSO send
+	gsoMaxSegments = 64
+	gsoMaxBytes    = 65000
+)
+
+type batchConn interface {
+	ReadBatch(ms []ipv4.Message, flags int) (int, error)
+	WriteBatch(ms []ipv4.Message, flags int) (int, error)
+}
+
+// batchUDPConn reads and writes packets in batches with recvmmsg/sendmmsg, coalescing writes to the same
+// destination with UDP GSO when enabled. Without batch support, it behaves as the underlying connection.
+type batchUDPConn struct {
+	*net.UDPConn
+	batch batchConn
+
+	readLock sync.Mutex
+	readMsgs []ipv4.Message
+	readPos  int
+	readN    int
+
+	writeLock     sync.Mutex
+	writeMsgs     []ipv4.Message
+	writePos      int
+	writeLast     time.Time
+	flushInterval time.Duration
+	gso           atomic.Bool
+	gsoMsgs       []ipv4.Message
+	gsoOOB        [][]byte
+	// pacer flushes in progress, queued writes are sent when the last one finishes
+	holds atomic.Int32
+
+	closed atomic.Bool
+}
+
+func newBatchUDPConn(conn *net.UDPConn, batchIO rtcconfig.BatchIOConfig, udpBatch config.UDPBatchConfig) *batchUDPConn {
+	c := &batchUDPConn{
+		UDPConn:       conn,
+		writeLast:     time.Now(),
+		flushInterval: batchIO.MaxFlushInterval,
+	}
+	if c.flushInterval <= 0 {
+		c.flushInterval = defaultBatchFlushInterval
+	}
+	if runtime.GOOS != "linux" {
+		return c
+	}
+
+	if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok && addr.IP.To4() == nil {
+		c.batch = ipv6.NewPacketConn(conn)
+	} else {
+		c.batch = ipv4.NewPacketConn(conn)
+	}
+
+	if udpBatch.ReadBatchSize > 0 {
+		c.readMsgs = make([]ipv4.Message, udpBatch.ReadBatchSize)
+		for i := range c.readMsgs {
+			c.readMsgs[i].Buffers = [][]byte{make([]byte, batchReadMTU)}
+		}
+	}
+
+	if batchIO.BatchSize > 0 {
+		c.writeMsgs = make([]ipv4.Message, batchIO.BatchSize)
+		for i := range c.writeMsgs {
+			c.writeMsgs[i].Buffers = [][]byte{make([]byte, batchWriteMTU)}
+		}
+		if udpBatch.GSO && gsoSupported(conn) {
+			c.gso.Store(true)
+			c.gsoMsgs = make([]ipv4.Message, batchIO.BatchSize)
+			c.gsoOOB = make([][]byte, batchIO.BatchSize)
+		}
+		go c.flushWorker()
+		pacer.RegisterBatchWriter(c)
+	}
+	return c
+}
+
+func (c *batchUDPConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	if c.readMsgs == nil {
+		return c.UDPConn.ReadFrom(b)
+	}
+
+	c.readLock.Lock()
+	defer c.readLock.Unlock()
+
+	if c.readPos >= c.readN {
+		n, err := c.batch.ReadBatch(c.readMsgs, 0)
+		if err != nil {
+			return 0, nil, err
+		}
+		c.readPos, c.readN = 0, n
+		prometheus.AddBatchRead(n)
+	}
+
+	msg := &c.readMsgs[c.readPos]
+	c.readPos++
+	return copy(b, msg.Buffers[0][:msg.N]), msg.Addr, nil
+}
+
+func (c *batchUDPConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	if c.writeMsgs == nil {
+		return c.UDPConn.WriteTo(b, addr)
+	}
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	msg := &c.writeMsgs[c.writePos]
+	c.writePos++
+	msg.Addr = addr
+	if len(b) > cap(msg.Buffers[0]) {
+		msg.Buffers[0] = make([]byte, len(b))
+	}
+	msg.Buffers[0] = msg.Buffers[0][:len(b)]
+	copy(msg.Buffers[0], b)
+
+	var err error
+	if c.writePos == len(c.writeMsgs) {
+		err = c.flush()
+	}
+	return len(b), err
+}
+
+// HoldWrites keeps queued writes from being flushed by the flush worker until ReleaseWrites
+func (c *batchUDPConn) HoldWrites() {
+	c.holds.Inc()
+}
+
+func (c *batchUDPConn) ReleaseWrites() {
+	if c.holds.Dec() > 0 {
+		return
+	}
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	if c.writePos > 0 && !c.closed.Load() {
+		_ = c.flush()
+	}
+}
+
+func (c *batchUDPConn) Close() error {
+	if c.writeMsgs != nil {
+		pacer.UnregisterBatchWriter(c)
+	}
+	c.closed.Store(true)
+	c.writeLock.Lock()
+	if c.writePos > 0 {
+		_ = c.flush()
+	}
+	c.writeLock.Unlock()
+	return c.UDPConn.Close()
+}
+
+func (c *batchUDPConn) flushWorker() {
+	ticker := time.NewTicker(c.flushInterval / 2)
+	defer ticker.Stop()
+
+	for !c.closed.Load() {
+		<-ticker.C
+		c.writeLock.Lock()
+		// held writes are flushed by the pacer, unless overlapping flushes hold them for too long
+		since := time.Since(c.writeLast)
+		if c.writePos > 0 && since >= c.flushInterval && (c.holds.Load() == 0 || since >= 2*c.flushInterval) {
+			_ = c.flush()
+		}
+		c.writeLock.Unlock()
+	}
+}
+
+// flush sends the pending packets, must be called with writeLock held
+func (c *batchUDPConn) flush() error {
+	packets := c.writePos
+	msgs := c.writeMsgs[:packets]
+	if c.gso.Load() {
+		msgs = c.coalesce(msgs)
+	}
+	c.writePos = 0
+	c.writeLast = time.Now()
+
+	calls, err := c.writeBatch(msgs)
+	if err != nil && c.gso.Load() && len(msgs) < packets {
+		// not all devices support segmentation offload, fall back to plain batches
+		logger.Warnw("UDP GSO write failed, disabling", err, "local", c.LocalAddr())
+		c.gso.Store(false)
+		var retryCalls int
+		retryCalls, err = c.writeBatch(c.writeMsgs[:packets])
+		calls += retryCalls
+	}
+	prometheus.AddBatchWrite(calls, packets, packets-len(msgs))
+	return err
+}
+
+func (c *batchUDPConn) writeBatch(msgs []ipv4.Message) (int, error) {
+	calls := 0
+	for sent := 0; sent < len(msgs); {
+		n, err := c.batch.WriteBatch(msgs[sent:], 0)
+		calls++
+		if err != nil {
+			return calls, err
+		}
+		sent += n
+	}
+	return calls, nil
+}
+
+// coalesce merges runs of packets of the same size to the same destination into GSO messages,
+// the last packet of a run may be shorter
+func (c *batchUDPConn) coalesce(msgs []ipv4.Message) []ipv4.Message {
+	out := c.gsoMsgs[:0]
+	for i := 0; i < len(msgs); {
+		segmentSize := len(msgs[i].Buffers[0])
+		total := segmentSize
+		j := i + 1
+		for ; j < len(msgs) && j-i < gsoMaxSegments; j++ {
+			size := len(msgs[j].Buffers[0])
+			if size > segmentSize || total+size > gsoMaxBytes || !sameUDPAddr(msgs[i].Addr, msgs[j].Addr) {
+				break
+			}
+			total += size
+			if size < segmentSize {
+				j++
+				break
+			}
+		}
+
+		idx := len(out)
+		out = append(out, ipv4.Message{Addr: msgs[i].Addr, Buffers: out[:cap(out)][idx].Buffers[:0]})
+		for k := i; k < j; k++ {
+			out[idx].Buffers = append(out[idx].Buffers, msgs[k].Buffers[0])
+		}
+		if j-i > 1 {
+			c.gsoOOB[idx] = gsoControl(c.gsoOOB[idx], segmentSize)
+			out[idx].OOB = c.gsoOOB[idx]
+		}
+		i = j
+	}
+	return out
+}
+
+func sameUDPAddr(a, b net.Addr) bool {
+	ua, ok := a.(*net.UDPAddr)
+	if !ok {
+		return false
+	}
+	ub, ok := b.(*net.UDPAddr)
+	if !ok {
+		return false
+	}
+	return ua.Port == ub.Port && ua.IP.Equal(ub.IP)
+}
+
+// newBatchUDPMux replaces the sockets of the rtc.udp_port mux with batching ones, listening on the
+// same addresses so that interface and IP filtering stay as configured
+func newBatchUDPMux(
+	udpMux ice.UDPMux,
+	batchIO rtcconfig.BatchIOConfig,
+	udpBatch config.UDPBatchConfig,
+	loggerFactory logging.LoggerFactory,
+) (ice.UDPMux, error) {
+	if udpBatch.GSO && batchIO.BatchSize == 0 {
+		return nil, errors.New("rtc.udp_batch.gso requires rtc.batch_io.batch_size")
+	}
+
+	var addrs []net.Addr
+	if multiPortsMux, ok := udpMux.(*transport.MultiPortsUDPMux); ok {
+		addrs = multiPortsMux.MultiUDPMuxDefault.GetListenAddresses()
+	} else {
+		addrs = udpMux.GetListenAddresses()
+	}
+	if err := udpMux.Close(); err != nil {
+		return nil, err
+	}
+
+	muxes := make([]ice.UDPMux, 0, len(addrs))
+	for _, addr := range addrs {
+		udpAddr, ok := addr.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
+		conn, err := net.ListenUDP("udp", udpAddr)
+		if err != nil {
+			for _, mux := range muxes {
+				_ = mux.Close()
+			}
+			return nil, err
+		}
+		_ = conn.SetReadBuffer(batchSocketBuffer)
+		_ = conn.SetWriteBuffer(batchSocketBuffer)
+
+		muxes = append(muxes, ice.NewUDPMuxDefault(ice.UDPMuxParams{
+			Logger:  loggerFactory.NewLogger("udp_mux"),
+			UDPConn: newBatchUDPConn(conn, batchIO, udpBatch),
+		}))
+	}
+	return transport.NewMultiPortsUDPMux(muxes...), nil
+}
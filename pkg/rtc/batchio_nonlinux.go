@@ -11,23 +11,19 @@
 // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 // See the License for the specific language governing permissions and
 // limitations under the License.
+//go:build !linux
 
-package sfu
+package rtc
 
 import (
-	"sync"
+	"net"
 )
 
-var (
-	PacketFactory *sync.Pool
-)
+func gsoSupported(_ *net.UDPConn) bool {
+	// linux only
+	return false
+}
 
-func init() {
-	// Init packet factory
-	PacketFactory = &sync.Pool{
-		New: func() interface{} {
-			b := make([]byte, 1460)
-			return &b
-		},
-	}
+func gsoControl(b []byte, _ int) []byte {
+	return b
 }
@@ -0,0 +1,158 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package rtc
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/ipv4"
+
+	"github.com/livekit/mediatransportutil/pkg/rtcconfig"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestBatchUDPConn(t *testing.T) {
+	listen := func(t *testing.T) *net.UDPConn {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		require.NoError(t, err)
+		return conn
+	}
+
+	conn := newBatchUDPConn(
+		listen(t),
+		rtcconfig.BatchIOConfig{BatchSize: 8, MaxFlushInterval: 5 * time.Millisecond},
+		config.UDPBatchConfig{ReadBatchSize: 4, GSO: true},
+	)
+	defer conn.Close()
+	peer := listen(t)
+	defer peer.Close()
+
+	packets := [][]byte{
+		bytes.Repeat([]byte{1}, 100),
+		bytes.Repeat([]byte{2}, 100),
+		bytes.Repeat([]byte{3}, 100),
+		bytes.Repeat([]byte{4}, 60),
+		bytes.Repeat([]byte{5}, 200),
+	}
+
+	t.Run("write", func(t *testing.T) {
+		for _, p := range packets {
+			_, err := conn.WriteTo(p, peer.LocalAddr())
+			require.NoError(t, err)
+		}
+
+		buf := make([]byte, 1500)
+		for _, p := range packets {
+			require.NoError(t, peer.SetReadDeadline(time.Now().Add(time.Second)))
+			n, _, err := peer.ReadFrom(buf)
+			require.NoError(t, err)
+			require.Equal(t, p, buf[:n])
+		}
+	})
+
+	t.Run("read", func(t *testing.T) {
+		for _, p := range packets {
+			_, err := peer.WriteTo(p, conn.LocalAddr())
+			require.NoError(t, err)
+		}
+
+		buf := make([]byte, 1500)
+		for _, p := range packets {
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+			n, addr, err := conn.ReadFrom(buf)
+			require.NoError(t, err)
+			require.Equal(t, p, buf[:n])
+			require.True(t, sameUDPAddr(peer.LocalAddr(), addr))
+		}
+	})
+}
+
+func TestBatchUDPConnDefaultFlushInterval(t *testing.T) {
+	listen, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	peer, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer peer.Close()
+
+	// max_flush_interval unset, a single queued packet is still flushed
+	conn := newBatchUDPConn(listen, rtcconfig.BatchIOConfig{BatchSize: 8}, config.UDPBatchConfig{})
+	defer conn.Close()
+	require.Equal(t, defaultBatchFlushInterval, conn.flushInterval)
+
+	_, err = conn.WriteTo([]byte{1, 2, 3}, peer.LocalAddr())
+	require.NoError(t, err)
+	require.NoError(t, peer.SetReadDeadline(time.Now().Add(time.Second)))
+	buf := make([]byte, 1500)
+	n, _, err := peer.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3}, buf[:n])
+}
+
+func TestBatchUDPConnHoldWrites(t *testing.T) {
+	listen, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	peer, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer peer.Close()
+
+	conn := newBatchUDPConn(listen, rtcconfig.BatchIOConfig{BatchSize: 8, MaxFlushInterval: 50 * time.Millisecond}, config.UDPBatchConfig{})
+	defer conn.Close()
+
+	// writes of a held flush are queued, and sent when it is released
+	conn.HoldWrites()
+	_, err = conn.WriteTo([]byte{1, 2, 3}, peer.LocalAddr())
+	require.NoError(t, err)
+	buf := make([]byte, 1500)
+	require.NoError(t, peer.SetReadDeadline(time.Now().Add(20*time.Millisecond)))
+	_, _, err = peer.ReadFrom(buf)
+	require.Error(t, err)
+
+	conn.ReleaseWrites()
+	require.NoError(t, peer.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := peer.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3}, buf[:n])
+}
+
+func TestBatchUDPConnCoalesce(t *testing.T) {
+	a := &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1000}
+	b := &net.UDPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 1000}
+	msg := func(addr net.Addr, size int) ipv4.Message {
+		return ipv4.Message{Addr: addr, Buffers: [][]byte{make([]byte, size)}}
+	}
+
+	c := &batchUDPConn{
+		gsoMsgs: make([]ipv4.Message, 8),
+		gsoOOB:  make([][]byte, 8),
+	}
+	out := c.coalesce([]ipv4.Message{
+		msg(a, 100), msg(a, 100), msg(a, 50), // run ending with a shorter packet
+		msg(a, 100), // new run after the shorter one
+		msg(b, 100), // different destination
+		msg(b, 200), // larger than the segment size
+	})
+
+	segments := make([]int, 0, len(out))
+	for _, m := range out {
+		segments = append(segments, len(m.Buffers))
+	}
+	require.Equal(t, []int{3, 1, 1, 1}, segments)
+	require.NotNil(t, out[0].OOB)
+	require.Nil(t, out[1].OOB)
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/pion/ice/v2"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	addressFamilyIPv4 = "ipv4"
+	addressFamilyIPv6 = "ipv6"
+)
+
+// CandidateFilter decides which local candidates are advertised to clients
+type CandidateFilter struct {
+	families       map[string]bool
+	types          map[ice.CandidateType]bool
+	excludePrivate bool
+}
+
+// NewCandidateFilter returns nil when the config does not filter anything
+func NewCandidateFilter(conf config.CandidateFilterConfig) (*CandidateFilter, error) {
+	if len(conf.AddressFamilies) == 0 && len(conf.CandidateTypes) == 0 && !conf.ExcludePrivate {
+		return nil, nil
+	}
+
+	f := &CandidateFilter{
+		excludePrivate: conf.ExcludePrivate,
+	}
+	if len(conf.AddressFamilies) != 0 {
+		f.families = make(map[string]bool)
+		for _, family := range conf.AddressFamilies {
+			family = strings.ToLower(family)
+			if family != addressFamilyIPv4 && family != addressFamilyIPv6 {
+				return nil, fmt.Errorf("unknown address family %q", family)
+			}
+			f.families[family] = true
+		}
+	}
+	if len(conf.CandidateTypes) != 0 {
+		f.types = make(map[ice.CandidateType]bool)
+		for _, typ := range conf.CandidateTypes {
+			switch strings.ToLower(typ) {
+			case "host":
+				f.types[ice.CandidateTypeHost] = true
+			case "srflx":
+				f.types[ice.CandidateTypeServerReflexive] = true
+			default:
+				return nil, fmt.Errorf("unsupported candidate type %q", typ)
+			}
+		}
+	}
+	return f, nil
+}
+
+// Allow takes a candidate as it appears in SDP, with or without the "candidate:" prefix
+func (f *CandidateFilter) Allow(candidate string) bool {
+	if f == nil {
+		return true
+	}
+	c, err := ice.UnmarshalCandidate(strings.TrimPrefix(candidate, "candidate:"))
+	if err != nil {
+		return true
+	}
+
+	if f.types != nil && !f.types[c.Type()] {
+		return false
+	}
+
+	ip := net.ParseIP(c.Address())
+	if ip == nil {
+		// mDNS name
+		return true
+	}
+	if f.families != nil {
+		family := addressFamilyIPv6
+		if ip.To4() != nil {
+			family = addressFamilyIPv4
+		}
+		if !f.families[family] {
+			return false
+		}
+	}
+	if f.excludePrivate && (ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast()) {
+		return false
+	}
+	return true
+}
+
+// IsRelayCandidate takes a candidate as it appears in SDP, with or without the "candidate:" prefix.
+// candidates that cannot be parsed are not relay candidates
+func IsRelayCandidate(candidate string) bool {
+	c, err := ice.UnmarshalCandidate(strings.TrimPrefix(candidate, "candidate:"))
+	if err != nil {
+		return false
+	}
+	return c.Type() == ice.CandidateTypeRelay
+}
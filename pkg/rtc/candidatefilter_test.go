@@ -0,0 +1,71 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestCandidateFilter(t *testing.T) {
+	const (
+		hostPrivate = "candidate:1 1 udp 2130706431 10.0.0.1 7882 typ host"
+		hostPublic  = "candidate:2 1 udp 2130706431 203.0.113.1 7882 typ host"
+		hostIPv6    = "candidate:3 1 udp 2130706431 2001:db8::1 7882 typ host"
+		srflx       = "candidate:4 1 udp 1694498815 203.0.113.2 7882 typ srflx raddr 10.0.0.1 rport 7882"
+	)
+
+	t.Run("no filter", func(t *testing.T) {
+		f, err := NewCandidateFilter(config.CandidateFilterConfig{})
+		require.NoError(t, err)
+		require.Nil(t, f)
+		require.True(t, f.Allow(hostPrivate))
+	})
+
+	t.Run("exclude private", func(t *testing.T) {
+		f, err := NewCandidateFilter(config.CandidateFilterConfig{ExcludePrivate: true})
+		require.NoError(t, err)
+		require.False(t, f.Allow(hostPrivate))
+		require.True(t, f.Allow(hostPublic))
+		require.True(t, f.Allow(srflx))
+	})
+
+	t.Run("address families and types", func(t *testing.T) {
+		f, err := NewCandidateFilter(config.CandidateFilterConfig{
+			AddressFamilies: []string{"ipv4"},
+			CandidateTypes:  []string{"srflx"},
+		})
+		require.NoError(t, err)
+		require.False(t, f.Allow(hostPublic))
+		require.False(t, f.Allow(hostIPv6))
+		require.True(t, f.Allow(srflx))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewCandidateFilter(config.CandidateFilterConfig{CandidateTypes: []string{"relay"}})
+		require.Error(t, err)
+	})
+}
+
+func TestIsRelayCandidate(t *testing.T) {
+	require.True(t, IsRelayCandidate("candidate:5 1 udp 41885439 198.51.100.1 50000 typ relay raddr 203.0.113.2 rport 7882"))
+	require.True(t, IsRelayCandidate("5 1 udp 41885439 198.51.100.1 50000 typ relay raddr 203.0.113.2 rport 7882"))
+	require.False(t, IsRelayCandidate("candidate:1 1 udp 2130706431 10.0.0.1 7882 typ host"))
+	require.False(t, IsRelayCandidate("candidate:4 1 udp 1694498815 203.0.113.2 7882 typ srflx raddr 10.0.0.1 rport 7882"))
+	require.False(t, IsRelayCandidate("not a candidate"))
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+// ReportDropInterceptorFactory drops RTCP sender and receiver reports, sent and received, with the
+// report_drop probability of the chaos config. Other RTCP in the same compound packet is kept.
+type ReportDropInterceptorFactory struct {
+	conf config.ChaosConfig
+}
+
+func NewReportDropInterceptorFactory(conf config.ChaosConfig) *ReportDropInterceptorFactory {
+	return &ReportDropInterceptorFactory{conf: conf}
+}
+
+func (f *ReportDropInterceptorFactory) NewInterceptor(_ string) (interceptor.Interceptor, error) {
+	return &ReportDropInterceptor{
+		probability: f.conf.ReportDrop,
+		rand:        rand.New(rand.NewSource(f.conf.Seed)),
+	}, nil
+}
+
+type ReportDropInterceptor struct {
+	interceptor.NoOp
+
+	probability float64
+
+	lock sync.Mutex
+	rand *rand.Rand
+}
+
+func (i *ReportDropInterceptor) BindRTCPWriter(writer interceptor.RTCPWriter) interceptor.RTCPWriter {
+	return interceptor.RTCPWriterFunc(func(pkts []rtcp.Packet, attributes interceptor.Attributes) (int, error) {
+		kept, dropped := i.filter(pkts)
+		if dropped == 0 {
+			return writer.Write(pkts, attributes)
+		}
+		if len(kept) == 0 {
+			return 0, nil
+		}
+		return writer.Write(kept, attributes)
+	})
+}
+
+func (i *ReportDropInterceptor) BindRTCPReader(reader interceptor.RTCPReader) interceptor.RTCPReader {
+	return interceptor.RTCPReaderFunc(func(b []byte, attributes interceptor.Attributes) (int, interceptor.Attributes, error) {
+		for {
+			n, attr, err := reader.Read(b, attributes)
+			if err != nil {
+				return n, attr, err
+			}
+			pkts, err := rtcp.Unmarshal(b[:n])
+			if err != nil {
+				return n, attr, nil
+			}
+			kept, dropped := i.filter(pkts)
+			if dropped == 0 {
+				return n, attr, nil
+			}
+			if len(kept) == 0 {
+				continue
+			}
+			raw, err := rtcp.Marshal(kept)
+			if err != nil {
+				return n, attr, nil
+			}
+			// the attributes may hold the packets as they were before filtering
+			return copy(b, raw), make(interceptor.Attributes), nil
+		}
+	})
+}
+
+func (i *ReportDropInterceptor) filter(pkts []rtcp.Packet) ([]rtcp.Packet, int) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	var kept []rtcp.Packet
+	dropped := 0
+	for idx, pkt := range pkts {
+		switch pkt.(type) {
+		case *rtcp.SenderReport, *rtcp.ReceiverReport:
+			if i.rand.Float64() < i.probability {
+				if dropped == 0 {
+					kept = append(kept, pkts[:idx]...)
+				}
+				dropped++
+				prometheus.IncrementChaosFault("report_drop")
+				continue
+			}
+		}
+		if dropped != 0 {
+			kept = append(kept, pkt)
+		}
+	}
+	if dropped == 0 {
+		return pkts, 0
+	}
+	return kept, dropped
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestReportDropInterceptor(t *testing.T) {
+	compound := func() []rtcp.Packet {
+		return []rtcp.Packet{
+			&rtcp.SenderReport{SSRC: 1},
+			&rtcp.PictureLossIndication{SenderSSRC: 1, MediaSSRC: 2},
+			&rtcp.ReceiverReport{SSRC: 1},
+		}
+	}
+	newInterceptor := func(probability float64) interceptor.Interceptor {
+		i, err := NewReportDropInterceptorFactory(config.ChaosConfig{ReportDrop: probability}).NewInterceptor("")
+		require.NoError(t, err)
+		return i
+	}
+
+	t.Run("write", func(t *testing.T) {
+		var written []rtcp.Packet
+		w := newInterceptor(1).BindRTCPWriter(interceptor.RTCPWriterFunc(func(pkts []rtcp.Packet, _ interceptor.Attributes) (int, error) {
+			written = pkts
+			return 0, nil
+		}))
+		_, err := w.Write(compound(), nil)
+		require.NoError(t, err)
+		require.Equal(t, []rtcp.Packet{&rtcp.PictureLossIndication{SenderSSRC: 1, MediaSSRC: 2}}, written)
+
+		written = nil
+		w = newInterceptor(0).BindRTCPWriter(interceptor.RTCPWriterFunc(func(pkts []rtcp.Packet, _ interceptor.Attributes) (int, error) {
+			written = pkts
+			return 0, nil
+		}))
+		_, err = w.Write(compound(), nil)
+		require.NoError(t, err)
+		require.Len(t, written, 3)
+	})
+
+	t.Run("read", func(t *testing.T) {
+		raw, err := rtcp.Marshal(compound())
+		require.NoError(t, err)
+		reportsOnly, err := rtcp.Marshal([]rtcp.Packet{&rtcp.ReceiverReport{SSRC: 1}})
+		require.NoError(t, err)
+
+		// a read of only reports is skipped entirely
+		reads := [][]byte{reportsOnly, raw}
+		r := newInterceptor(1).BindRTCPReader(interceptor.RTCPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+			n := copy(b, reads[0])
+			reads = reads[1:]
+			return n, a, nil
+		}))
+		b := make([]byte, 1500)
+		n, _, err := r.Read(b, nil)
+		require.NoError(t, err)
+		pkts, err := rtcp.Unmarshal(b[:n])
+		require.NoError(t, err)
+		require.Len(t, pkts, 1)
+		require.IsType(t, &rtcp.PictureLossIndication{}, pkts[0])
+		require.Empty(t, reads)
+	})
+}
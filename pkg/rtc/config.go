@@ -17,10 +17,13 @@ package rtc
 import (
 	"github.com/pion/sdp/v3"
 	"github.com/pion/webrtc/v3"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	dd "github.com/livekit/livekit-server/pkg/sfu/dependencydescriptor"
+	"github.com/livekit/livekit-server/pkg/sfu/pacer"
 	"github.com/livekit/mediatransportutil/pkg/rtcconfig"
 )
 
@@ -31,14 +34,26 @@ const (
 type WebRTCConfig struct {
 	rtcconfig.WebRTCConfig
 
-	BufferFactory *buffer.Factory
-	Receiver      ReceiverConfig
-	Publisher     DirectionConfig
-	Subscriber    DirectionConfig
+	BufferFactory   *buffer.Factory
+	Receiver        ReceiverConfig
+	Publisher       DirectionConfig
+	Subscriber      DirectionConfig
+	CandidateFilter *CandidateFilter
+	DTLS            *DTLSParams
+	AddressFamily   *AddressFamilyPolicy
+	// sends subscriber packets when set, shared by all connections of the node
+	PacketWorkers *pacer.WorkerPool
+	// media impairments injected for testing
+	Impairments []config.ImpairmentConfig
+	// faults injected for resilience testing
+	Chaos config.ChaosConfig
 }
 
 type ReceiverConfig struct {
 	PacketBufferSize int
+	FeedbackThrottle config.FeedbackThrottleConfig
+	RTPStatsHistory  config.RTPStatsHistoryConfig
+	ReorderTolerance config.ReorderToleranceConfig
 }
 
 type RTPHeaderExtensionConfig struct {
@@ -55,19 +70,51 @@ type DirectionConfig struct {
 	RTPHeaderExtension RTPHeaderExtensionConfig
 	RTCPFeedback       RTCPFeedbackConfig
 	StrictACKs         bool
+
+	// extensions never offered, including the ones a transport adds on its own such as playout delay
+	DroppedRTPHeaderExtensions []string
 }
 
 func NewWebRTCConfig(conf *config.Config) (*WebRTCConfig, error) {
-	rtcConf := conf.RTC
+	return newWebRTCConfig(conf.RTC, conf.Chaos, conf.Development)
+}
 
-	webRTCConfig, err := rtcconfig.NewWebRTCConfig(&rtcConf.RTCConfig, conf.Development)
+func newWebRTCConfig(rtcConf config.RTCConfig, chaos config.ChaosConfig, development bool) (*WebRTCConfig, error) {
+	webRTCConfig, err := rtcconfig.NewWebRTCConfig(&rtcConf.RTCConfig, development)
 	if err != nil {
 		return nil, err
 	}
 
+	if rtcConf.UDPBatch.Enabled() && webRTCConfig.UDPMux != nil {
+		udpMux, err := newBatchUDPMux(webRTCConfig.UDPMux, rtcConf.BatchIO, rtcConf.UDPBatch, webRTCConfig.SettingEngine.LoggerFactory)
+		if err != nil {
+			return nil, err
+		}
+		webRTCConfig.UDPMux = udpMux
+		webRTCConfig.SettingEngine.SetICEUDPMux(udpMux)
+	}
+
 	// we don't want to use active TCP on a server, clients should be dialing
 	webRTCConfig.SettingEngine.DisableActiveTCP(true)
 
+	candidateFilter, err := NewCandidateFilter(rtcConf.CandidateFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	addressFamily, err := NewAddressFamilyPolicy(rtcConf.AddressFamily)
+	if err != nil {
+		return nil, err
+	}
+
+	dtlsParams, err := newDTLSParams(rtcConf.DTLS, &webRTCConfig.SettingEngine)
+	if err != nil {
+		return nil, err
+	}
+	if dtlsParams.Certificate != nil {
+		webRTCConfig.Configuration.Certificates = []webrtc.Certificate{*dtlsParams.Certificate}
+	}
+
 	if rtcConf.PacketBufferSize == 0 {
 		rtcConf.PacketBufferSize = 500
 	}
@@ -84,6 +131,7 @@ func NewWebRTCConfig(conf *config.Config) (*WebRTCConfig, error) {
 			Video: []string{
 				sdp.SDESMidURI,
 				sdp.SDESRTPStreamIDURI,
+				buffer.RepairedRTPStreamIDURI,
 				sdp.TransportCCURI,
 				frameMarking,
 				dd.ExtensionURI,
@@ -102,9 +150,13 @@ func NewWebRTCConfig(conf *config.Config) (*WebRTCConfig, error) {
 		},
 	}
 
+	if rtcConf.CongestionControl.Uplink.Enabled {
+		publisherConfig.RTCPFeedback.Video = append(publisherConfig.RTCPFeedback.Video, webrtc.RTCPFeedback{Type: webrtc.TypeRTCPFBGoogREMB})
+	}
+
 	// subscriber configuration
 	subscriberConfig := DirectionConfig{
-		StrictACKs: conf.RTC.StrictACKs,
+		StrictACKs: rtcConf.StrictACKs,
 		RTPHeaderExtension: RTPHeaderExtensionConfig{
 			Video: []string{dd.ExtensionURI},
 		},
@@ -128,12 +180,104 @@ func NewWebRTCConfig(conf *config.Config) (*WebRTCConfig, error) {
 		WebRTCConfig: *webRTCConfig,
 		Receiver: ReceiverConfig{
 			PacketBufferSize: rtcConf.PacketBufferSize,
+			FeedbackThrottle: rtcConf.FeedbackThrottle,
+			RTPStatsHistory:  rtcConf.RTPStatsHistory,
+			ReorderTolerance: rtcConf.ReorderTolerance,
 		},
-		Publisher:  publisherConfig,
-		Subscriber: subscriberConfig,
+		Publisher:       publisherConfig,
+		Subscriber:      subscriberConfig,
+		CandidateFilter: candidateFilter,
+		DTLS:            dtlsParams,
+		AddressFamily:   addressFamily,
+		Impairments:     rtcConf.Impairments,
+		Chaos:           chaos,
 	}, nil
 }
 
+// NewRoomTransportWebRTCConfigs creates a config, with its own listeners, for each of rtc.room_transports
+func NewRoomTransportWebRTCConfigs(conf *config.Config) (map[string]*WebRTCConfig, error) {
+	configs := make(map[string]*WebRTCConfig, len(conf.RTC.RoomTransports))
+	for prefix, rt := range conf.RTC.RoomTransports {
+		rtcConf, err := rt.ApplyTo(conf.RTC.RTCConfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "room transport %q", prefix)
+		}
+
+		roomConf := conf.RTC
+		roomConf.RTCConfig = rtcConf
+		if configs[prefix], err = newWebRTCConfig(roomConf, conf.Chaos, conf.Development); err != nil {
+			return nil, errors.Wrapf(err, "room transport %q", prefix)
+		}
+	}
+	return configs, nil
+}
+
+// DisableNACK removes NACK from the feedback negotiated on both transports, PLI requests are kept
+func (c *WebRTCConfig) DisableNACK() {
+	c.Publisher.RTCPFeedback = c.Publisher.RTCPFeedback.withoutNACK()
+	c.Subscriber.RTCPFeedback = c.Subscriber.RTCPFeedback.withoutNACK()
+}
+
+// DisableSubscriberDependencyDescriptor stops offering the dependency descriptor to subscribers, for those
+// receiving single layer SVC video
+func (c *WebRTCConfig) DisableSubscriberDependencyDescriptor() {
+	c.DropSubscriberHeaderExtensions([]string{dd.ExtensionURI})
+}
+
+// EnableSubscriberAbsSendTime offers abs-send-time to subscribers, it is otherwise only offered when bandwidth
+// is estimated on the receive side. Packets are stamped by the pacer when they are sent.
+func (c *WebRTCConfig) EnableSubscriberAbsSendTime() {
+	if slices.Contains(c.Subscriber.RTPHeaderExtension.Video, sdp.ABSSendTimeURI) {
+		return
+	}
+	video := make([]string, 0, len(c.Subscriber.RTPHeaderExtension.Video)+1)
+	video = append(video, c.Subscriber.RTPHeaderExtension.Video...)
+	c.Subscriber.RTPHeaderExtension.Video = append(video, sdp.ABSSendTimeURI)
+}
+
+// DropSubscriberHeaderExtensions stops offering the extensions to subscribers
+func (c *WebRTCConfig) DropSubscriberHeaderExtensions(uris []string) {
+	if len(uris) == 0 {
+		return
+	}
+	c.Subscriber.RTPHeaderExtension = RTPHeaderExtensionConfig{
+		Audio: withoutExtensions(c.Subscriber.RTPHeaderExtension.Audio, uris),
+		Video: withoutExtensions(c.Subscriber.RTPHeaderExtension.Video, uris),
+	}
+	dropped := make([]string, 0, len(c.Subscriber.DroppedRTPHeaderExtensions)+len(uris))
+	dropped = append(dropped, c.Subscriber.DroppedRTPHeaderExtensions...)
+	c.Subscriber.DroppedRTPHeaderExtensions = append(dropped, uris...)
+}
+
+func withoutExtensions(extensions []string, uris []string) []string {
+	kept := make([]string, 0, len(extensions))
+	for _, ext := range extensions {
+		if !slices.Contains(uris, ext) {
+			kept = append(kept, ext)
+		}
+	}
+	return kept
+}
+
+func (c RTCPFeedbackConfig) withoutNACK() RTCPFeedbackConfig {
+	return RTCPFeedbackConfig{
+		Audio: withoutNACK(c.Audio),
+		Video: withoutNACK(c.Video),
+	}
+}
+
+// withoutNACK returns a copy of feedback without generic NACK
+func withoutNACK(feedback []webrtc.RTCPFeedback) []webrtc.RTCPFeedback {
+	filtered := make([]webrtc.RTCPFeedback, 0, len(feedback))
+	for _, fb := range feedback {
+		if fb.Type == webrtc.TypeRTCPFBNACK && fb.Parameter == "" {
+			continue
+		}
+		filtered = append(filtered, fb)
+	}
+	return filtered
+}
+
 func (c *WebRTCConfig) SetBufferFactory(factory *buffer.Factory) {
 	c.BufferFactory = factory
 	c.SettingEngine.BufferFactory = factory.GetOrNew
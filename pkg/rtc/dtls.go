@@ -0,0 +1,139 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/pion/dtls/v2"
+	"github.com/pion/dtls/v2/pkg/crypto/elliptic"
+	"github.com/pion/webrtc/v3"
+	"golang.org/x/sys/cpu"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+var (
+	srtpProfiles = map[string]dtls.SRTPProtectionProfile{
+		"aes128_cm_hmac_sha1_80": dtls.SRTP_AES128_CM_HMAC_SHA1_80,
+		"aead_aes_128_gcm":       dtls.SRTP_AEAD_AES_128_GCM,
+		"aead_aes_256_gcm":       dtls.SRTP_AEAD_AES_256_GCM,
+	}
+
+	ellipticCurves = map[string]elliptic.Curve{
+		"x25519": elliptic.X25519,
+		"p256":   elliptic.P256,
+		"p384":   elliptic.P384,
+	}
+
+	// X25519 first to improve connectivity, https://github.com/pion/dtls/pull/474
+	defaultEllipticCurves = []elliptic.Curve{elliptic.X25519, elliptic.P384, elliptic.P256}
+
+	// whether crypto/aes runs AES-GCM on hardware instructions, it falls back to a much slower
+	// constant time implementation otherwise
+	aesGCMHardware = func() bool {
+		switch runtime.GOARCH {
+		case "amd64":
+			return cpu.X86.HasAES && cpu.X86.HasPCLMULQDQ
+		case "arm64":
+			return cpu.ARM64.HasAES && cpu.ARM64.HasPMULL
+		case "s390x":
+			return cpu.S390X.HasAES && cpu.S390X.HasGHASH
+		default:
+			return false
+		}
+	}
+)
+
+// DTLSParams are the DTLS settings applied to every peer connection
+type DTLSParams struct {
+	SRTPProfiles   []string
+	EllipticCurves []elliptic.Curve
+	Certificate    *webrtc.Certificate
+	// key algorithm of the certificate, for auditing
+	CertificateKey string
+	// AES-GCM profiles are protected with hardware instructions
+	AESHardware bool
+}
+
+func newDTLSParams(conf config.DTLSConfig, se *webrtc.SettingEngine) (*DTLSParams, error) {
+	p := &DTLSParams{
+		EllipticCurves: defaultEllipticCurves,
+		CertificateKey: "ECDSA",
+		AESHardware:    aesGCMHardware(),
+	}
+	prometheus.SetSRTPAESHardware(p.AESHardware)
+
+	// GCM profiles are preferred by default
+	gcmAllowed := len(conf.SRTPProfiles) == 0
+	if len(conf.SRTPProfiles) != 0 {
+		profiles := make([]dtls.SRTPProtectionProfile, 0, len(conf.SRTPProfiles))
+		for _, name := range conf.SRTPProfiles {
+			profile, ok := srtpProfiles[strings.ToLower(name)]
+			if !ok {
+				return nil, fmt.Errorf("unknown SRTP profile %q", name)
+			}
+			profiles = append(profiles, profile)
+			p.SRTPProfiles = append(p.SRTPProfiles, strings.ToLower(name))
+			if profile != dtls.SRTP_AES128_CM_HMAC_SHA1_80 {
+				gcmAllowed = true
+			}
+		}
+		se.SetSRTPProtectionProfiles(profiles...)
+	}
+
+	if gcmAllowed && !p.AESHardware {
+		if conf.RequireAESHardware {
+			return nil, fmt.Errorf("AES-GCM SRTP profiles are allowed but %s CPU has no AES hardware support", runtime.GOARCH)
+		}
+		logger.Warnw("AES-GCM SRTP profiles are allowed without AES hardware support, protecting packets will be slow", nil,
+			"arch", runtime.GOARCH,
+		)
+	}
+
+	if len(conf.EllipticCurves) != 0 {
+		p.EllipticCurves = nil
+		for _, name := range conf.EllipticCurves {
+			curve, ok := ellipticCurves[strings.ToLower(name)]
+			if !ok {
+				return nil, fmt.Errorf("unknown elliptic curve %q", name)
+			}
+			p.EllipticCurves = append(p.EllipticCurves, curve)
+		}
+	}
+
+	if conf.CertFile != "" || conf.KeyFile != "" {
+		pair, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load DTLS certificate: %w", err)
+		}
+		cert, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse DTLS certificate: %w", err)
+		}
+		c := webrtc.CertificateFromX509(pair.PrivateKey, cert)
+		p.Certificate = &c
+		p.CertificateKey = cert.PublicKeyAlgorithm.String()
+	}
+
+	return p, nil
+}
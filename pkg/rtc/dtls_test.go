@@ -0,0 +1,80 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/pion/dtls/v2/pkg/crypto/elliptic"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestDTLSParams(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		p, err := newDTLSParams(config.DTLSConfig{}, &webrtc.SettingEngine{})
+		require.NoError(t, err)
+		require.Equal(t, defaultEllipticCurves, p.EllipticCurves)
+		require.Empty(t, p.SRTPProfiles)
+		require.Nil(t, p.Certificate)
+		require.Equal(t, "ECDSA", p.CertificateKey)
+	})
+
+	t.Run("profiles and curves", func(t *testing.T) {
+		p, err := newDTLSParams(config.DTLSConfig{
+			SRTPProfiles:   []string{"AEAD_AES_256_GCM", "aead_aes_128_gcm"},
+			EllipticCurves: []string{"p384"},
+		}, &webrtc.SettingEngine{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"aead_aes_256_gcm", "aead_aes_128_gcm"}, p.SRTPProfiles)
+		require.Equal(t, []elliptic.Curve{elliptic.P384}, p.EllipticCurves)
+	})
+
+	t.Run("unknown values", func(t *testing.T) {
+		_, err := newDTLSParams(config.DTLSConfig{SRTPProfiles: []string{"null"}}, &webrtc.SettingEngine{})
+		require.Error(t, err)
+
+		_, err = newDTLSParams(config.DTLSConfig{EllipticCurves: []string{"p521"}}, &webrtc.SettingEngine{})
+		require.Error(t, err)
+	})
+
+	t.Run("AES hardware", func(t *testing.T) {
+		hardware := aesGCMHardware
+		t.Cleanup(func() { aesGCMHardware = hardware })
+		aesGCMHardware = func() bool { return false }
+
+		_, err := newDTLSParams(config.DTLSConfig{RequireAESHardware: true}, &webrtc.SettingEngine{})
+		require.Error(t, err)
+
+		p, err := newDTLSParams(config.DTLSConfig{
+			SRTPProfiles:       []string{"aes128_cm_hmac_sha1_80"},
+			RequireAESHardware: true,
+		}, &webrtc.SettingEngine{})
+		require.NoError(t, err)
+		require.False(t, p.AESHardware)
+
+		aesGCMHardware = func() bool { return true }
+		p, err = newDTLSParams(config.DTLSConfig{RequireAESHardware: true}, &webrtc.SettingEngine{})
+		require.NoError(t, err)
+		require.True(t, p.AESHardware)
+	})
+
+	t.Run("missing certificate", func(t *testing.T) {
+		_, err := newDTLSParams(config.DTLSConfig{CertFile: "missing.crt", KeyFile: "missing.key"}, &webrtc.SettingEngine{})
+		require.Error(t, err)
+	})
+}
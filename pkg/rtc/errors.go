@@ -17,16 +17,23 @@ package rtc
 import "errors"
 
 var (
-	ErrRoomClosed              = errors.New("room has already closed")
-	ErrPermissionDenied        = errors.New("no permissions to access the room")
-	ErrMaxParticipantsExceeded = errors.New("room has exceeded its max participants")
-	ErrLimitExceeded           = errors.New("node has exceeded its configured limit")
-	ErrAlreadyJoined           = errors.New("a participant with the same identity is already in the room")
-	ErrDataChannelUnavailable  = errors.New("data channel is not available")
-	ErrTransportFailure        = errors.New("transport failure")
-	ErrEmptyIdentity           = errors.New("participant identity cannot be empty")
-	ErrEmptyParticipantID      = errors.New("participant ID cannot be empty")
-	ErrMissingGrants           = errors.New("VideoGrant is missing")
+	ErrRoomClosed                = errors.New("room has already closed")
+	ErrRoomLocked                = errors.New("room is locked")
+	ErrPermissionDenied          = errors.New("no permissions to access the room")
+	ErrMaxParticipantsExceeded   = errors.New("room has exceeded its max participants")
+	ErrLimitExceeded             = errors.New("node has exceeded its configured limit")
+	ErrAlreadyJoined             = errors.New("a participant with the same identity is already in the room")
+	ErrDataChannelUnavailable    = errors.New("data channel is not available")
+	ErrTransportFailure          = errors.New("transport failure")
+	ErrDataChannelBufferFull     = errors.New("data channel buffer full")
+	ErrDataChannelStalled        = errors.New("reliable data channel stalled")
+	ErrEmptyIdentity             = errors.New("participant identity cannot be empty")
+	ErrEmptyParticipantID        = errors.New("participant ID cannot be empty")
+	ErrMissingGrants             = errors.New("VideoGrant is missing")
+	ErrInvalidMetadata           = errors.New("metadata does not conform to schema")
+	ErrEmptyAttributeKey         = errors.New("attribute key cannot be empty")
+	ErrAttributePermissionDenied = errors.New("not allowed to write participant attribute")
+	ErrAttributesExceedLimits    = errors.New("participant attributes exceed limits")
 
 	// Track subscription related
 	ErrNoTrackPermission         = errors.New("participant is not allowed to subscribe to this track")
@@ -0,0 +1,79 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"fmt"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/clientconfiguration"
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// SubscriberHeaderExtensionPolicy selects the RTP header extensions left out of a subscriber's connection.
+// A nil policy drops nothing.
+type SubscriberHeaderExtensionPolicy struct {
+	drop  []string
+	rules []headerExtensionsRule
+}
+
+type headerExtensionsRule struct {
+	match clientconfiguration.Match
+	drop  []string
+}
+
+func NewSubscriberHeaderExtensionPolicy(conf config.SubscriberHeaderExtensionsConfig) (*SubscriberHeaderExtensionPolicy, error) {
+	if len(conf.Drop) == 0 && len(conf.Clients) == 0 {
+		return nil, nil
+	}
+
+	p := &SubscriberHeaderExtensionPolicy{
+		drop: conf.Drop,
+	}
+	for _, rule := range conf.Clients {
+		match := &clientconfiguration.ScriptMatch{Expr: rule.Match}
+		// catch invalid expressions on start rather than on join
+		if _, err := match.Match(&livekit.ClientInfo{}); err != nil {
+			return nil, fmt.Errorf("invalid subscriber header extensions match %q: %w", rule.Match, err)
+		}
+		p.rules = append(p.rules, headerExtensionsRule{match: match, drop: rule.Drop})
+	}
+	return p, nil
+}
+
+// DroppedFor returns the URIs of the extensions left out for a client
+func (p *SubscriberHeaderExtensionPolicy) DroppedFor(clientInfo *livekit.ClientInfo) []string {
+	if p == nil {
+		return nil
+	}
+
+	dropped := append([]string{}, p.drop...)
+	if clientInfo == nil {
+		return dropped
+	}
+	for _, rule := range p.rules {
+		matched, err := rule.match.Match(clientInfo)
+		if err != nil {
+			logger.Warnw("could not match client for header extensions", err, "clientInfo", clientInfo)
+			continue
+		}
+		if matched {
+			dropped = append(dropped, rule.drop...)
+		}
+	}
+	return dropped
+}
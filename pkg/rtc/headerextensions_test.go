@@ -0,0 +1,88 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	dd "github.com/livekit/livekit-server/pkg/sfu/dependencydescriptor"
+	"github.com/livekit/livekit-server/pkg/sfu/rtpextension"
+)
+
+func TestSubscriberHeaderExtensionPolicy(t *testing.T) {
+	p, err := NewSubscriberHeaderExtensionPolicy(config.SubscriberHeaderExtensionsConfig{})
+	require.NoError(t, err)
+	require.Nil(t, p)
+	require.Empty(t, p.DroppedFor(&livekit.ClientInfo{}))
+
+	p, err = NewSubscriberHeaderExtensionPolicy(config.SubscriberHeaderExtensionsConfig{
+		Drop: []string{rtpextension.PlayoutDelayURI},
+		Clients: []config.HeaderExtensionsClientRule{
+			{Match: `c.browser == "safari"`, Drop: []string{dd.ExtensionURI}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{rtpextension.PlayoutDelayURI}, p.DroppedFor(&livekit.ClientInfo{Browser: "chrome"}))
+	require.Equal(t, []string{rtpextension.PlayoutDelayURI, dd.ExtensionURI}, p.DroppedFor(&livekit.ClientInfo{Browser: "safari"}))
+
+	_, err = NewSubscriberHeaderExtensionPolicy(config.SubscriberHeaderExtensionsConfig{
+		Clients: []config.HeaderExtensionsClientRule{{Match: `c.browser ==`}},
+	})
+	require.Error(t, err)
+}
+
+func TestDropSubscriberHeaderExtensions(t *testing.T) {
+	conf := &WebRTCConfig{
+		Subscriber: DirectionConfig{
+			RTPHeaderExtension: RTPHeaderExtensionConfig{
+				Video: []string{dd.ExtensionURI, sdp.TransportCCURI},
+			},
+		},
+	}
+	shared := conf.Subscriber.RTPHeaderExtension.Video
+
+	copied := *conf
+	copied.DropSubscriberHeaderExtensions([]string{dd.ExtensionURI, rtpextension.PlayoutDelayURI})
+	require.Equal(t, []string{sdp.TransportCCURI}, copied.Subscriber.RTPHeaderExtension.Video)
+	require.Equal(t, []string{dd.ExtensionURI, rtpextension.PlayoutDelayURI}, copied.Subscriber.DroppedRTPHeaderExtensions)
+
+	// the config it was copied from is left untouched
+	require.Equal(t, []string{dd.ExtensionURI, sdp.TransportCCURI}, shared)
+	require.Empty(t, conf.Subscriber.DroppedRTPHeaderExtensions)
+}
+
+func TestEnableSubscriberAbsSendTime(t *testing.T) {
+	conf := &WebRTCConfig{
+		Subscriber: DirectionConfig{
+			RTPHeaderExtension: RTPHeaderExtensionConfig{
+				Video: []string{dd.ExtensionURI, sdp.TransportCCURI},
+			},
+		},
+	}
+
+	conf.EnableSubscriberAbsSendTime()
+	conf.EnableSubscriberAbsSendTime()
+	require.Equal(t, []string{dd.ExtensionURI, sdp.TransportCCURI, sdp.ABSSendTimeURI}, conf.Subscriber.RTPHeaderExtension.Video)
+
+	// dropping it takes precedence
+	conf.DropSubscriberHeaderExtensions([]string{sdp.ABSSendTimeURI})
+	require.Equal(t, []string{dd.ExtensionURI, sdp.TransportCCURI}, conf.Subscriber.RTPHeaderExtension.Video)
+}
@@ -0,0 +1,242 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// impairmentsForTransport returns the impairments of a participant's transport, a send side transport
+// carries media to a subscriber
+func impairmentsForTransport(impairments []config.ImpairmentConfig, identity livekit.ParticipantIdentity, isSendSide bool) []config.ImpairmentConfig {
+	transport := "publisher"
+	if isSendSide {
+		transport = "subscriber"
+	}
+
+	var matching []config.ImpairmentConfig
+	for _, impairment := range impairments {
+		if impairment.Transport != "" && impairment.Transport != transport {
+			continue
+		}
+		if !strings.HasPrefix(string(identity), impairment.IdentityPrefix) {
+			continue
+		}
+		matching = append(matching, impairment)
+	}
+	return matching
+}
+
+// ImpairmentInterceptorFactory degrades the RTP of a transport, sent packets in BindLocalStream and
+// received packets in BindRemoteStream. RTCP is left untouched.
+type ImpairmentInterceptorFactory struct {
+	conf config.ImpairmentConfig
+}
+
+func NewImpairmentInterceptorFactory(conf config.ImpairmentConfig) *ImpairmentInterceptorFactory {
+	return &ImpairmentInterceptorFactory{conf: conf}
+}
+
+func (f *ImpairmentInterceptorFactory) NewInterceptor(_ string) (interceptor.Interceptor, error) {
+	return &ImpairmentInterceptor{conf: f.conf}, nil
+}
+
+type ImpairmentInterceptor struct {
+	interceptor.NoOp
+	conf config.ImpairmentConfig
+}
+
+func (i *ImpairmentInterceptor) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	return &impairedRTPWriter{
+		impairer: newImpairer(i.conf, info.SSRC),
+		writer:   writer,
+	}
+}
+
+func (i *ImpairmentInterceptor) BindRemoteStream(info *interceptor.StreamInfo, reader interceptor.RTPReader) interceptor.RTPReader {
+	return &impairedRTPReader{
+		impairer: newImpairer(i.conf, info.SSRC),
+		reader:   reader,
+	}
+}
+
+// ------------------------------------------------
+
+type impairment struct {
+	drop      bool
+	duplicate bool
+	reorder   bool
+	delay     time.Duration
+}
+
+// impairer decides the fate of the packets of a stream, the same seed gives the same decisions
+type impairer struct {
+	conf config.ImpairmentConfig
+	rand *rand.Rand
+}
+
+func newImpairer(conf config.ImpairmentConfig, ssrc uint32) *impairer {
+	return &impairer{
+		conf: conf,
+		rand: rand.New(rand.NewSource(conf.Seed ^ int64(ssrc))),
+	}
+}
+
+func (i *impairer) next() impairment {
+	// always draw the same number of values so that a decision does not shift the following ones
+	drop := i.rand.Float64() < i.conf.Loss
+	duplicate := i.rand.Float64() < i.conf.Duplicate
+	reorder := i.rand.Float64() < i.conf.Reorder
+	delay := time.Duration(i.rand.Float64() * float64(i.conf.Jitter))
+	return impairment{
+		drop:      drop,
+		duplicate: duplicate,
+		reorder:   reorder,
+		delay:     delay,
+	}
+}
+
+// ------------------------------------------------
+
+type impairedPacket struct {
+	header     *rtp.Header
+	payload    []byte
+	attributes interceptor.Attributes
+}
+
+type impairedRTPWriter struct {
+	writer interceptor.RTPWriter
+
+	lock     sync.Mutex
+	impairer *impairer
+	// packet swapped with the next one
+	held *impairedPacket
+}
+
+func (w *impairedRTPWriter) Write(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+	size := header.MarshalSize() + len(payload)
+
+	w.lock.Lock()
+	imp := w.impairer.next()
+	if imp.drop {
+		w.lock.Unlock()
+		return size, nil
+	}
+	if imp.reorder && w.held == nil {
+		// the caller may reuse the packet once written
+		clone := header.Clone()
+		w.held = &impairedPacket{header: &clone, payload: append([]byte{}, payload...), attributes: attributes}
+		w.lock.Unlock()
+		return size, nil
+	}
+	held := w.held
+	w.held = nil
+	w.lock.Unlock()
+
+	packets := []impairedPacket{{header: header, payload: payload, attributes: attributes}}
+	if imp.duplicate {
+		packets = append(packets, packets[0])
+	}
+	if held != nil {
+		packets = append(packets, *held)
+	}
+
+	if imp.delay > 0 {
+		for i := range packets {
+			clone := packets[i].header.Clone()
+			packets[i].header = &clone
+			packets[i].payload = append([]byte{}, packets[i].payload...)
+		}
+		time.AfterFunc(imp.delay, func() {
+			for _, p := range packets {
+				_, _ = w.writer.Write(p.header, p.payload, p.attributes)
+			}
+		})
+		return size, nil
+	}
+
+	var written int
+	var err error
+	for i, p := range packets {
+		n, writeErr := w.writer.Write(p.header, p.payload, p.attributes)
+		if i == 0 {
+			written, err = n, writeErr
+		}
+	}
+	return written, err
+}
+
+// ------------------------------------------------
+
+type impairedRTPReader struct {
+	reader   interceptor.RTPReader
+	impairer *impairer
+
+	// packets to return before reading more
+	pending [][]byte
+	// packet swapped with the next one
+	held []byte
+}
+
+func (r *impairedRTPReader) Read(b []byte, attributes interceptor.Attributes) (int, interceptor.Attributes, error) {
+	for len(r.pending) != 0 {
+		p := r.pending[0]
+		r.pending = r.pending[1:]
+		if len(p) <= len(b) {
+			return copy(b, p), interceptor.Attributes{}, nil
+		}
+	}
+
+	for {
+		n, a, err := r.reader.Read(b, attributes)
+		if err != nil {
+			return n, a, err
+		}
+
+		imp := r.impairer.next()
+		if imp.drop {
+			// attributes may cache the header of the dropped packet
+			attributes = interceptor.Attributes{}
+			continue
+		}
+		if imp.reorder && r.held == nil {
+			r.held = append([]byte{}, b[:n]...)
+			attributes = interceptor.Attributes{}
+			continue
+		}
+
+		if imp.duplicate {
+			r.pending = append(r.pending, append([]byte{}, b[:n]...))
+		}
+		if r.held != nil {
+			r.pending = append(r.pending, r.held)
+			r.held = nil
+		}
+		if imp.delay > 0 {
+			time.Sleep(imp.delay)
+		}
+		return n, a, nil
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestImpairmentsForTransport(t *testing.T) {
+	impairments := []config.ImpairmentConfig{
+		{IdentityPrefix: "lossy-", Transport: "subscriber", Loss: 0.1},
+		{Transport: "publisher", Reorder: 0.1},
+	}
+
+	require.Len(t, impairmentsForTransport(impairments, "lossy-1", true), 1)
+	require.Empty(t, impairmentsForTransport(impairments, "clean", true))
+	require.Len(t, impairmentsForTransport(impairments, "lossy-1", false), 1)
+	require.Len(t, impairmentsForTransport(impairments, "clean", false), 1)
+}
+
+func TestImpairedRTPWriter(t *testing.T) {
+	write := func(conf config.ImpairmentConfig, count int) []uint16 {
+		var written []uint16
+		w := &impairedRTPWriter{
+			impairer: newImpairer(conf, 1234),
+			writer: interceptor.RTPWriterFunc(func(header *rtp.Header, _ []byte, _ interceptor.Attributes) (int, error) {
+				written = append(written, header.SequenceNumber)
+				return 0, nil
+			}),
+		}
+		for sn := uint16(1); sn <= uint16(count); sn++ {
+			_, err := w.Write(&rtp.Header{SequenceNumber: sn}, []byte{1, 2, 3}, nil)
+			require.NoError(t, err)
+		}
+		return written
+	}
+
+	require.Empty(t, write(config.ImpairmentConfig{Loss: 1}, 4))
+	require.Equal(t, []uint16{1, 1, 2, 2}, write(config.ImpairmentConfig{Duplicate: 1}, 2))
+	require.Equal(t, []uint16{2, 1, 4, 3}, write(config.ImpairmentConfig{Reorder: 1}, 4))
+
+	// the same seed impairs the same packets
+	lossy := config.ImpairmentConfig{Loss: 0.3, Seed: 42}
+	first := write(lossy, 100)
+	require.Less(t, len(first), 100)
+	require.Equal(t, first, write(lossy, 100))
+}
+
+func TestImpairedRTPReader(t *testing.T) {
+	read := func(conf config.ImpairmentConfig, count int) []uint16 {
+		sn := uint16(0)
+		r := &impairedRTPReader{
+			impairer: newImpairer(conf, 1234),
+			reader: interceptor.RTPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+				sn++
+				header := rtp.Header{Version: 2, SequenceNumber: sn}
+				n, err := header.MarshalTo(b)
+				return n, a, err
+			}),
+		}
+
+		var received []uint16
+		b := make([]byte, 1500)
+		for len(received) < count {
+			n, _, err := r.Read(b, nil)
+			require.NoError(t, err)
+
+			var header rtp.Header
+			_, err = header.Unmarshal(b[:n])
+			require.NoError(t, err)
+			received = append(received, header.SequenceNumber)
+		}
+		return received
+	}
+
+	require.Equal(t, []uint16{1, 1, 2, 2}, read(config.ImpairmentConfig{Duplicate: 1}, 4))
+	require.Equal(t, []uint16{2, 1, 4, 3}, read(config.ImpairmentConfig{Reorder: 1}, 4))
+
+	lossy := read(config.ImpairmentConfig{Loss: 0.5, Seed: 7}, 50)
+	require.Greater(t, lossy[len(lossy)-1], uint16(50))
+	require.Equal(t, lossy, read(config.ImpairmentConfig{Loss: 0.5, Seed: 7}, 50))
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"net"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// IPFilter decides which remote addresses a transport may connect from
+type IPFilter struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// NewPublisherIPFilter returns nil when the config does not restrict anything
+func NewPublisherIPFilter(conf config.PublisherIPsConfig) (*IPFilter, error) {
+	if conf.IsEmpty() {
+		return nil, nil
+	}
+	allow, deny, err := conf.Networks()
+	if err != nil {
+		return nil, err
+	}
+	return &IPFilter{allow: allow, deny: deny}, nil
+}
+
+// Allow takes the remote address of a candidate pair. addresses that cannot be parsed are only
+// allowed when there is no allow list
+func (f *IPFilter) Allow(address string) bool {
+	if f == nil {
+		return true
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return len(f.allow) == 0
+	}
+	for _, network := range f.deny {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	for _, network := range f.allow {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestPublisherIPFilter(t *testing.T) {
+	t.Run("no filter", func(t *testing.T) {
+		f, err := NewPublisherIPFilter(config.PublisherIPsConfig{})
+		require.NoError(t, err)
+		require.Nil(t, f)
+		require.True(t, f.Allow("203.0.113.1"))
+	})
+
+	t.Run("allow and deny", func(t *testing.T) {
+		f, err := NewPublisherIPFilter(config.PublisherIPsConfig{
+			Allow: []string{"203.0.113.0/24", "2001:db8::/32", "198.51.100.7"},
+			Deny:  []string{"203.0.113.128/25"},
+		})
+		require.NoError(t, err)
+		require.True(t, f.Allow("203.0.113.1"))
+		require.True(t, f.Allow("2001:db8::1"))
+		require.True(t, f.Allow("198.51.100.7"))
+		require.False(t, f.Allow("198.51.100.8"))
+		require.False(t, f.Allow("203.0.113.200"))
+		require.False(t, f.Allow("10.0.0.1"))
+		require.False(t, f.Allow("host.local"))
+	})
+
+	t.Run("deny only", func(t *testing.T) {
+		f, err := NewPublisherIPFilter(config.PublisherIPsConfig{Deny: []string{"10.0.0.0/8"}})
+		require.NoError(t, err)
+		require.False(t, f.Allow("10.1.2.3"))
+		require.True(t, f.Allow("203.0.113.1"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewPublisherIPFilter(config.PublisherIPsConfig{Allow: []string{"203.0.113.0/33"}})
+		require.Error(t, err)
+		_, err = NewPublisherIPFilter(config.PublisherIPsConfig{Deny: []string{"studio"}})
+		require.Error(t, err)
+	})
+
+	t.Run("room presets", func(t *testing.T) {
+		conf := config.RoomConfig{
+			PublisherIPs: config.PublisherIPsConfig{Deny: []string{"10.0.0.0/8"}},
+			Presets: map[string]config.RoomPresetConfig{
+				"ingest": {
+					RoomPrefixes: []string{"ingest-"},
+					PublisherIPs: &config.PublisherIPsConfig{Allow: []string{"203.0.113.0/24"}},
+				},
+			},
+		}
+		require.Equal(t, []string{"203.0.113.0/24"}, conf.PublisherIPsForRoom("ingest-1").Allow)
+		require.Equal(t, []string{"10.0.0.0/8"}, conf.PublisherIPsForRoom("meeting").Deny)
+	})
+}
+
+func TestCheckRemoteIP(t *testing.T) {
+	filter, err := NewPublisherIPFilter(config.PublisherIPsConfig{Allow: []string{"203.0.113.0/24"}})
+	require.NoError(t, err)
+	newTransport := func(signalAddress string) (*PCTransport, *[]string) {
+		transport := &PCTransport{
+			params: TransportParams{
+				Logger:         logger.GetLogger(),
+				RemoteIPFilter: filter,
+				ClientInfo:     ClientInfo{ClientInfo: &livekit.ClientInfo{Address: signalAddress}},
+			},
+		}
+		var rejected []string
+		transport.OnRemoteIPRejected(func(address string) {
+			rejected = append(rejected, address)
+		})
+		return transport, &rejected
+	}
+	pair := func(typ webrtc.ICECandidateType, address string) *webrtc.ICECandidatePair {
+		return webrtc.NewICECandidatePair(
+			&webrtc.ICECandidate{Typ: webrtc.ICECandidateTypeHost, Address: "10.0.0.1"},
+			&webrtc.ICECandidate{Typ: typ, Address: address},
+		)
+	}
+
+	t.Run("direct", func(t *testing.T) {
+		transport, rejected := newTransport("203.0.113.1")
+		transport.checkRemoteIP(pair(webrtc.ICECandidateTypeSrflx, "203.0.113.2"))
+		require.Empty(t, *rejected)
+		transport.checkRemoteIP(pair(webrtc.ICECandidateTypePrflx, "198.51.100.1"))
+		require.Equal(t, []string{"198.51.100.1"}, *rejected)
+		// reported once
+		transport.checkRemoteIP(pair(webrtc.ICECandidateTypePrflx, "198.51.100.2"))
+		require.Len(t, *rejected, 1)
+	})
+
+	t.Run("relayed pairs are checked by signal address", func(t *testing.T) {
+		transport, rejected := newTransport("198.51.100.1")
+		transport.checkRemoteIP(pair(webrtc.ICECandidateTypeRelay, "203.0.113.2"))
+		require.Equal(t, []string{"198.51.100.1"}, *rejected)
+
+		transport, rejected = newTransport("203.0.113.1")
+		transport.checkRemoteIP(pair(webrtc.ICECandidateTypeRelay, "192.0.2.1"))
+		require.Empty(t, *rejected)
+	})
+
+	t.Run("unknown signal address", func(t *testing.T) {
+		transport, rejected := newTransport("")
+		transport.checkRemoteIP(pair(webrtc.ICECandidateTypeHost, "203.0.113.2"))
+		require.Empty(t, *rejected)
+		transport.checkRemoteIP(pair(webrtc.ICECandidateTypeRelay, "203.0.113.2"))
+		require.Equal(t, []string{""}, *rejected)
+	})
+}
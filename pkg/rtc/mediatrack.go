@@ -18,13 +18,14 @@ import (
 	"context"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pion/rtcp"
 	"github.com/pion/webrtc/v3"
 	"go.uber.org/atomic"
+	"golang.org/x/exp/slices"
 	"google.golang.org/protobuf/proto"
 
-	"github.com/livekit/mediatransportutil/pkg/twcc"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 
@@ -33,9 +34,14 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
+	"github.com/livekit/livekit-server/pkg/sfu/twcc"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
+// layers of a track changing source within this interval are reported as one change
+const sourceChangeReportInterval = time.Second
+
 // MediaTrack represents a WebRTC track that needs to be forwarded
 // Implements MediaTrack and PublishedTrack interface
 type MediaTrack struct {
@@ -49,6 +55,9 @@ type MediaTrack struct {
 	dynacastManager *DynacastManager
 
 	lock sync.RWMutex
+
+	// when a source change of the track was last reported, layers changing together are reported once
+	sourceChangedAt atomic.Int64
 }
 
 type MediaTrackParams struct {
@@ -64,11 +73,14 @@ type MediaTrackParams struct {
 	ReceiverConfig    ReceiverConfig
 	SubscriberConfig  DirectionConfig
 	PLIThrottleConfig config.PLIThrottleConfig
-	AudioConfig       config.AudioConfig
-	VideoConfig       config.VideoConfig
-	Telemetry         telemetry.TelemetryService
-	Logger            logger.Logger
-	SimTracks         map[uint32]SimulcastTrackInfo
+	RTCPConfig        config.RTCPConfig
+	// lost packets are neither requested from the publisher nor retransmitted to subscribers
+	DisableNACK bool
+	AudioConfig config.AudioConfig
+	VideoConfig config.VideoConfig
+	Telemetry   telemetry.TelemetryService
+	Logger      logger.Logger
+	SimTracks   map[uint32]SimulcastTrackInfo
 }
 
 func NewMediaTrack(params MediaTrackParams) *MediaTrack {
@@ -77,17 +89,19 @@ func NewMediaTrack(params MediaTrackParams) *MediaTrack {
 	}
 
 	t.MediaTrackReceiver = NewMediaTrackReceiver(MediaTrackReceiverParams{
-		TrackInfo:           params.TrackInfo,
-		MediaTrack:          t,
-		IsRelayed:           false,
-		ParticipantID:       params.ParticipantID,
-		ParticipantIdentity: params.ParticipantIdentity,
-		ParticipantVersion:  params.ParticipantVersion,
-		ReceiverConfig:      params.ReceiverConfig,
-		SubscriberConfig:    params.SubscriberConfig,
-		AudioConfig:         params.AudioConfig,
-		Telemetry:           params.Telemetry,
-		Logger:              params.Logger,
+		TrackInfo:            params.TrackInfo,
+		MediaTrack:           t,
+		IsRelayed:            false,
+		ParticipantID:        params.ParticipantID,
+		ParticipantIdentity:  params.ParticipantIdentity,
+		ParticipantVersion:   params.ParticipantVersion,
+		ReceiverConfig:       params.ReceiverConfig,
+		SubscriberConfig:     params.SubscriberConfig,
+		DisableNACK:          params.DisableNACK,
+		DemandFromAllocation: params.VideoConfig.AllocationDemand.EnabledFor(params.ParticipantIdentity),
+		AudioConfig:          params.AudioConfig,
+		Telemetry:            params.Telemetry,
+		Logger:               params.Logger,
 	})
 	t.MediaTrackReceiver.OnVideoLayerUpdate(func(layers []*livekit.VideoLayer) {
 		t.params.Telemetry.TrackPublishedUpdate(context.Background(), t.PublisherID(),
@@ -229,6 +243,13 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 			// do nothing for now
 			case *rtcp.SenderReport:
 				buff.SetSenderReportData(pkt.RTPTime, pkt.NTPTime, pkt.PacketCount)
+			case *rtcp.Goodbye:
+				// publisher stopped sending, end the stream instead of waiting for it to time out
+				if slices.Contains(pkt.Sources, uint32(track.SSRC())) {
+					t.params.Logger.Infow("received RTCP BYE, closing stream", "ssrc", track.SSRC(), "rid", track.RID(), "reason", pkt.Reason)
+					prometheus.RecordTrackBye(t.Kind().String())
+					_ = buff.Close()
+				}
 			}
 		}
 	})
@@ -246,6 +267,10 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 				break
 			}
 		}
+		var frameAssemblyDelay time.Duration
+		if t.params.VideoConfig.FrameAssembly.EnabledFor(t.params.ParticipantIdentity) {
+			frameAssemblyDelay = t.params.VideoConfig.FrameAssembly.MaxDelay
+		}
 		newWR := sfu.NewWebRTCReceiver(
 			receiver,
 			track,
@@ -257,6 +282,13 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 			sfu.WithAudioConfig(t.params.AudioConfig),
 			sfu.WithLoadBalanceThreshold(20),
 			sfu.WithStreamTrackers(),
+			sfu.WithMaxKeyFrameInterval(t.params.VideoConfig.MaxKeyFrameInterval),
+			sfu.WithFrameAssembly(frameAssemblyDelay),
+			sfu.WithRTCPSchedule(buffer.RTCPSchedule{
+				Interval:          t.params.RTCPConfig.ReceiverReportInterval,
+				BandwidthFraction: t.params.RTCPConfig.BandwidthFraction,
+				Jitter:            t.params.RTCPConfig.Jitter,
+			}),
 		)
 		newWR.SetRTCPCh(t.params.RTCPChan)
 		newWR.OnCloseHandler(func() {
@@ -293,6 +325,9 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 		}
 
 		newWR.OnMaxLayerChange(t.onMaxLayerChange)
+		newWR.OnSourceChange(func(layer int32) {
+			t.onSourceChange(mime, layer)
+		})
 
 		t.buffer = buff
 
@@ -308,34 +343,57 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 	}
 	t.lock.Unlock()
 
-	wr.(*sfu.WebRTCReceiver).AddUpTrack(track, buff)
+	rebound := wr.(*sfu.WebRTCReceiver).AddUpTrack(track, buff)
 
 	// LK-TODO: can remove this completely when VideoLayers protocol becomes the default as it has info from client or if we decide to use TrackInfo.Simulcast
-	if t.numUpTracks.Inc() > 1 || track.RID() != "" {
+	if (!rebound && t.numUpTracks.Inc() > 1) || track.RID() != "" {
 		// cannot only rely on numUpTracks since we fire metadata events immediately after the first layer
 		t.SetSimulcast(true)
 	}
 
-	if t.IsSimulcast() {
+	if t.Kind() == livekit.TrackType_VIDEO {
 		t.MediaTrackReceiver.SetLayerSsrc(mime, track.RID(), uint32(track.SSRC()))
+		t.sendLayerSsrcs()
 	}
 
-	buff.Bind(receiver.GetParameters(), track.Codec().RTPCodecCapability)
+	buff.SetStreamIdentity(mid, track.RID())
+	reorderTolerance := t.params.ReceiverConfig.ReorderTolerance.Audio
+	if t.Kind() == livekit.TrackType_VIDEO {
+		reorderTolerance = t.params.ReceiverConfig.ReorderTolerance.Video
+	}
+	buff.SetReorderTolerance(buffer.ReorderTolerance{
+		Time:    reorderTolerance.Time,
+		Packets: reorderTolerance.Packets,
+	})
+	codec := track.Codec().RTPCodecCapability
+	if t.params.DisableNACK {
+		// NACK may still be negotiated when only some tracks of the room have it disabled
+		codec.RTCPFeedback = withoutNACK(codec.RTCPFeedback)
+	}
+	buff.Bind(receiver.GetParameters(), codec)
 
 	// if subscriber request fps before fps calculated, update them after fps updated.
 	buff.OnFpsChanged(func() {
 		t.MediaTrackSubscriptions.UpdateVideoLayers()
 	})
 
-	buff.OnFinalRtpStats(func(stats *livekit.RTPStats) {
-		t.params.Telemetry.TrackPublishRTPStats(
-			context.Background(),
-			t.params.ParticipantID,
-			t.ID(),
-			mime,
-			int(layer),
-			stats,
-		)
+	buff.OnLayerSpecsChanged(func(specs []buffer.LayerSpec) {
+		t.onLayerSpecsChanged(mime, layer, specs)
+	})
+
+	buff.SetRTPStatsHistory(t.params.ReceiverConfig.RTPStatsHistory.Interval, t.params.ReceiverConfig.RTPStatsHistory.Size)
+	buff.OnFinalRtpStats(func(stats *livekit.RTPStats, history []*livekit.RTPStats) {
+		// lifetime stats first, followed by the stats of each interval
+		for _, s := range append([]*livekit.RTPStats{stats}, history...) {
+			t.params.Telemetry.TrackPublishRTPStats(
+				context.Background(),
+				t.params.ParticipantID,
+				t.ID(),
+				mime,
+				int(layer),
+				s,
+			)
+		}
 	})
 	return newCodec
 }
@@ -371,6 +429,85 @@ func (t *MediaTrack) onMaxLayerChange(maxLayer int32) {
 	t.params.Telemetry.TrackPublishedUpdate(context.Background(), t.PublisherID(), ti)
 }
 
+// onSourceChange reports the publisher replacing the source of the track, e. g. switching cameras.
+// Forwarding continues seamlessly, this only marks where stats of the track may show a discontinuity
+func (t *MediaTrack) onSourceChange(mime string, layer int32) {
+	now := time.Now()
+	if last := t.sourceChangedAt.Load(); last != 0 && now.Sub(time.Unix(0, last)) < sourceChangeReportInterval {
+		return
+	}
+	t.sourceChangedAt.Store(now.UnixNano())
+
+	t.params.Logger.Infow("track source changed", "mime", mime, "layer", layer)
+	t.params.Telemetry.TrackSourceChanged(context.Background(), t.PublisherID(), t.PublisherIdentity(), t.ToProto(), mime)
+}
+
+// sendLayerSsrcs updates analytics with the SSRC of each layer, so that stats reported by clients can be
+// matched with the layers of the track
+func (t *MediaTrack) sendLayerSsrcs() {
+	ti := t.ToProto()
+	t.params.Telemetry.TrackPublishedUpdate(context.Background(), t.PublisherID(), &livekit.TrackInfo{
+		Sid:       ti.Sid,
+		Type:      ti.Type,
+		Muted:     ti.Muted,
+		Simulcast: ti.Simulcast,
+		Layers:    ti.Layers,
+		Codecs:    ti.Codecs,
+	})
+}
+
+// onLayerSpecsChanged replaces the advertised dimensions of layers with the ones read from the codec headers,
+// so that layer selection and analytics use what the publisher actually sends
+func (t *MediaTrack) onLayerSpecsChanged(mime string, bufferLayer int32, specs []buffer.LayerSpec) {
+	if primary := t.PrimaryReceiver(); primary == nil || !strings.EqualFold(primary.Codec().MimeType, mime) {
+		return
+	}
+
+	ti := t.ToProto()
+	current := make(map[livekit.VideoQuality]*livekit.VideoLayer)
+	for _, layer := range t.MediaTrackReceiver.GetVideoLayers() {
+		current[layer.Quality] = layer
+	}
+
+	var updated []*livekit.VideoLayer
+	for _, spec := range specs {
+		if spec.Width == 0 || spec.Height == 0 {
+			continue
+		}
+		spatial := spec.Spatial
+		if spatial == buffer.InvalidLayerSpatial {
+			spatial = bufferLayer
+		}
+		quality := buffer.SpatialLayerToVideoQuality(spatial, ti)
+		if quality == livekit.VideoQuality_OFF {
+			continue
+		}
+
+		layer, ok := current[quality]
+		if !ok {
+			layer = &livekit.VideoLayer{Quality: quality}
+		}
+		if layer.Width == spec.Width && layer.Height == spec.Height {
+			continue
+		}
+		t.params.Logger.Debugw(
+			"measured layer dimensions differ from advertised",
+			"quality", quality,
+			"advertisedWidth", layer.Width,
+			"advertisedHeight", layer.Height,
+			"width", spec.Width,
+			"height", spec.Height,
+			"frameRate", spec.FrameRate,
+		)
+		layer.Width = spec.Width
+		layer.Height = spec.Height
+		updated = append(updated, layer)
+	}
+	if len(updated) != 0 {
+		t.MediaTrackReceiver.UpdateVideoLayers(updated)
+	}
+}
+
 func (t *MediaTrack) Restart() {
 	t.MediaTrackReceiver.Restart()
 
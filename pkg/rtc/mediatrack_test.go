@@ -17,9 +17,11 @@ package rtc
 import (
 	"testing"
 
+	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/require"
 
 	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
 )
 
 func TestTrackInfo(t *testing.T) {
@@ -174,3 +176,31 @@ func TestGetQualityForDimension(t *testing.T) {
 	})
 
 }
+
+func TestTrackInfoLayerSsrc(t *testing.T) {
+	ti := &livekit.TrackInfo{
+		Sid:  "testsid",
+		Type: livekit.TrackType_VIDEO,
+		Layers: []*livekit.VideoLayer{
+			{Quality: livekit.VideoQuality_LOW, Width: 320, Height: 180},
+			{Quality: livekit.VideoQuality_HIGH, Width: 1280, Height: 720},
+		},
+	}
+	mt := NewMediaTrack(MediaTrackParams{TrackInfo: ti, Logger: logger.GetLogger()})
+	mt.SetupReceiver(NewDummyReceiver("testsid", "stream", webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8},
+	}, nil), 0, "0")
+
+	// the second spatial layer of a two layer track is the high quality one
+	mt.SetLayerSsrc(webrtc.MimeTypeVP8, "q", 1000)
+	mt.SetLayerSsrc(webrtc.MimeTypeVP8, "h", 2000)
+
+	ssrcs := make(map[livekit.VideoQuality]uint32)
+	for _, layer := range mt.ToProto().Layers {
+		ssrcs[layer.Quality] = layer.Ssrc
+	}
+	require.Equal(t, map[livekit.VideoQuality]uint32{
+		livekit.VideoQuality_LOW:  1000,
+		livekit.VideoQuality_HIGH: 2000,
+	}, ssrcs)
+}
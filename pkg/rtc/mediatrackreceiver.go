@@ -90,9 +90,13 @@ type MediaTrackReceiverParams struct {
 	ParticipantVersion  uint32
 	ReceiverConfig      ReceiverConfig
 	SubscriberConfig    DirectionConfig
+	DisableNACK         bool
 	AudioConfig         config.AudioConfig
 	Telemetry           telemetry.TelemetryService
 	Logger              logger.Logger
+
+	// layers requested from the publisher follow subscriber stream allocations
+	DemandFromAllocation bool
 }
 
 type MediaTrackReceiver struct {
@@ -125,12 +129,14 @@ func NewMediaTrackReceiver(params MediaTrackReceiverParams) *MediaTrackReceiver
 	}
 
 	t.MediaTrackSubscriptions = NewMediaTrackSubscriptions(MediaTrackSubscriptionsParams{
-		MediaTrack:       params.MediaTrack,
-		IsRelayed:        params.IsRelayed,
-		ReceiverConfig:   params.ReceiverConfig,
-		SubscriberConfig: params.SubscriberConfig,
-		Telemetry:        params.Telemetry,
-		Logger:           params.Logger,
+		MediaTrack:           params.MediaTrack,
+		IsRelayed:            params.IsRelayed,
+		ReceiverConfig:       params.ReceiverConfig,
+		SubscriberConfig:     params.SubscriberConfig,
+		DisableNACK:          params.DisableNACK,
+		DemandFromAllocation: params.DemandFromAllocation,
+		Telemetry:            params.Telemetry,
+		Logger:               params.Logger,
 	})
 	t.MediaTrackSubscriptions.OnDownTrackCreated(t.onDownTrackCreated)
 
@@ -272,9 +278,12 @@ func (t *MediaTrackReceiver) SetLayerSsrc(mime string, rid string, ssrc uint32)
 		// non-simulcast case will not have `rid`
 		layer = 0
 	}
+	// layers of TrackInfo are looked up by quality, which differs from the spatial layer when
+	// fewer than three layers are published
+	quality := buffer.SpatialLayerToVideoQuality(layer, t.params.TrackInfo)
 	for _, receiver := range t.receiversShadow {
-		if strings.EqualFold(receiver.Codec().MimeType, mime) && int(layer) < len(receiver.layerSSRCs) {
-			receiver.layerSSRCs[layer] = ssrc
+		if strings.EqualFold(receiver.Codec().MimeType, mime) && int(quality) < len(receiver.layerSSRCs) {
+			receiver.layerSSRCs[quality] = ssrc
 			return
 		}
 	}
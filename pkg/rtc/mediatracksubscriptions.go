@@ -53,6 +53,10 @@ type MediaTrackSubscriptionsParams struct {
 
 	ReceiverConfig   ReceiverConfig
 	SubscriberConfig DirectionConfig
+	// subscribers are not offered NACK and no retransmission history is kept
+	DisableNACK bool
+	// max layers of subscribers follow their stream allocation, see sfu.Forwarder.SetDemandFromAllocation
+	DemandFromAllocation bool
 
 	Telemetry telemetry.TelemetryService
 
@@ -125,16 +129,20 @@ func (t *MediaTrackSubscriptions) AddSubscriber(sub types.LocalParticipant, wr *
 	}
 
 	downTrack, err := sfu.NewDownTrack(sfu.DowntrackParams{
-		Codecs:            codecs,
-		Receiver:          wr,
-		BufferFactory:     sub.GetBufferFactory(),
-		SubID:             subscriberID,
-		StreamID:          streamID,
-		MaxTrack:          t.params.ReceiverConfig.PacketBufferSize,
-		PlayoutDelayLimit: sub.GetPlayoutDelayConfig(),
-		Pacer:             sub.GetPacer(),
-		Trailer:           trailer,
-		Logger:            LoggerWithTrack(sub.GetLogger().WithComponent(sutils.ComponentSub), trackID, t.params.IsRelayed),
+		Codecs:               codecs,
+		Receiver:             wr,
+		BufferFactory:        sub.GetBufferFactory(),
+		SubID:                subscriberID,
+		StreamID:             streamID,
+		MaxTrack:             t.params.ReceiverConfig.PacketBufferSize,
+		PlayoutDelayLimit:    sub.GetPlayoutDelayConfig(t.params.MediaTrack.Source()),
+		Pacer:                sub.GetPacer(),
+		Trailer:              trailer,
+		FeedbackThrottle:     t.params.ReceiverConfig.FeedbackThrottle,
+		DisableNACK:          t.params.DisableNACK,
+		DemandFromAllocation: t.params.DemandFromAllocation,
+		RTPStatsHistory:      t.params.ReceiverConfig.RTPStatsHistory,
+		Logger:               LoggerWithTrack(sub.GetLogger().WithComponent(sutils.ComponentSub), trackID, t.params.IsRelayed),
 	})
 	if err != nil {
 		return nil, err
@@ -234,12 +242,22 @@ func (t *MediaTrackSubscriptions) AddSubscriber(sub types.LocalParticipant, wr *
 	}
 	reusingTransceiver.Store(false)
 
+	// subscribers that cannot renegotiate receive tracks on spare transceivers negotiated up front
+	if transceiver == nil {
+		sender, transceiver, err = sub.ReplaceSpareSubscriberTrack(downTrack, downTrack.SetTransceiver)
+		if err != nil {
+			return nil, err
+		}
+		replacedTrack = transceiver != nil
+	}
+
 	// if cannot replace, find an unused transceiver or add new one
 	if transceiver == nil {
 		info := t.params.MediaTrack.ToProto()
 		addTrackParams := types.AddTrackParams{
-			Stereo: info.Stereo,
-			Red:    !info.DisableRed,
+			Stereo:      info.Stereo,
+			Red:         !info.DisableRed,
+			DisableNACK: t.params.DisableNACK,
 		}
 		if addTrackParams.Red && (len(codecs) == 1 && codecs[0].MimeType == webrtc.MimeTypeOpus) {
 			addTrackParams.Red = false
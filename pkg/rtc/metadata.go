@@ -0,0 +1,110 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+// MetadataRejectedTopic is the topic of messages telling a participant that its metadata update was rejected
+const MetadataRejectedTopic = "lk.metadata_rejected"
+
+// MetadataRejected is the payload of messages on MetadataRejectedTopic
+type MetadataRejected struct {
+	Error string `json:"error"`
+}
+
+// MetadataValidator validates room and participant metadata against the schemas registered in config.
+// A nil validator, or one without a schema for a kind of metadata, accepts anything.
+type MetadataValidator struct {
+	roomSchema        *utils.JSONSchema
+	participantSchema *utils.JSONSchema
+}
+
+func NewMetadataValidator(conf config.MetadataSchemaConfig) (*MetadataValidator, error) {
+	if conf.RoomSchemaFile == "" && conf.ParticipantSchemaFile == "" {
+		return nil, nil
+	}
+
+	v := &MetadataValidator{}
+	var err error
+	if v.roomSchema, err = utils.ReadJSONSchemaFile(conf.RoomSchemaFile); err != nil {
+		return nil, err
+	}
+	if v.participantSchema, err = utils.ReadJSONSchemaFile(conf.ParticipantSchemaFile); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (v *MetadataValidator) ValidateRoomMetadata(metadata string) error {
+	if v == nil {
+		return nil
+	}
+	return validateMetadata(v.roomSchema, metadata)
+}
+
+func (v *MetadataValidator) ValidateParticipantMetadata(metadata string) error {
+	if v == nil {
+		return nil
+	}
+	return validateMetadata(v.participantSchema, metadata)
+}
+
+func validateMetadata(schema *utils.JSONSchema, metadata string) error {
+	// empty metadata clears it, and is always allowed
+	if schema == nil || metadata == "" {
+		return nil
+	}
+	if err := schema.Validate([]byte(metadata)); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidMetadata, err.Error())
+	}
+	return nil
+}
+
+// the signal protocol has no error response to metadata updates, the participant is told on a reserved data topic
+func sendMetadataRejected(participant types.LocalParticipant, reason error, pLogger logger.Logger) {
+	payload, err := json.Marshal(&MetadataRejected{Error: reason.Error()})
+	if err != nil {
+		return
+	}
+	topic := MetadataRejectedTopic
+	dp := &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				Payload: payload,
+				Topic:   &topic,
+			},
+		},
+	}
+	data, err := proto.Marshal(dp)
+	if err != nil {
+		return
+	}
+	if err := participant.SendDataPacket(dp, data); err != nil {
+		pLogger.Debugw("could not send metadata rejected", "error", err)
+	}
+}
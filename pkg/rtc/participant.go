@@ -42,10 +42,10 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
 	"github.com/livekit/livekit-server/pkg/sfu/pacer"
 	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
+	"github.com/livekit/livekit-server/pkg/sfu/twcc"
 	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 	sutils "github.com/livekit/livekit-server/pkg/utils"
-	"github.com/livekit/mediatransportutil/pkg/twcc"
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
@@ -56,6 +56,9 @@ const (
 	sdBatchSize       = 30
 	rttUpdateInterval = 5 * time.Second
 
+	senderReportInterval    = 3 * time.Second
+	senderReportTickMinimum = 100 * time.Millisecond
+
 	disconnectCleanupDuration = 5 * time.Second
 	migrationWaitDuration     = 3 * time.Second
 )
@@ -96,6 +99,9 @@ type ParticipantParams struct {
 	Telemetry                    telemetry.TelemetryService
 	Trailer                      []byte
 	PLIThrottleConfig            config.PLIThrottleConfig
+	RTCPConfig                   config.RTCPConfig
+	TWCCConfig                   config.TWCCConfig
+	NACKPolicy                   config.NACKPolicy
 	CongestionControlConfig      config.CongestionControlConfig
 	EnabledCodecs                []*livekit.Codec
 	Logger                       logger.Logger
@@ -115,6 +121,7 @@ type ParticipantParams struct {
 	ReconnectOnPublicationError  bool
 	ReconnectOnSubscriptionError bool
 	ReconnectOnDataChannelError  bool
+	ResumeConfig                 config.ResumeConfig
 	VersionGenerator             utils.TimedVersionGenerator
 	TrackResolver                types.MediaTrackResolver
 	DisableDynacast              bool
@@ -122,6 +129,17 @@ type ParticipantParams struct {
 	SubscriptionLimitAudio       int32
 	SubscriptionLimitVideo       int32
 	PlayoutDelay                 *livekit.PlayoutDelay
+	// playout delay bounds replacing PlayoutDelay's for tracks of a source, keyed by lower case source name
+	PlayoutDelaySources map[string]config.PlayoutDelayBounds
+	ScreenSharePolicy   config.ScreenSharePolicy
+	// client is required to connect through TURN
+	ForceRelay bool
+	// addresses publishers may connect from
+	PublisherIPFilter *IPFilter
+	// new tracks are refused while this returns true, e.g. when the room is over its resource budget
+	RefuseNewTracks func() bool
+	// the subscriber connection is negotiated once, see ParticipantOptions.SingleNegotiation
+	SingleNegotiation bool
 }
 
 type ParticipantImpl struct {
@@ -133,6 +151,11 @@ type ParticipantImpl struct {
 	resSink     routing.MessageSink
 	grants      *auth.ClaimGrants
 	isPublisher atomic.Bool
+	audioOnly   atomic.Bool
+	// subscribed SVC video is forwarded as a single spatial layer
+	singleLayerSVC atomic.Bool
+	// incremented on each subscriber reconnect, stops key frame requests of an earlier reconnect
+	resumeGeneration atomic.Uint32
 
 	// when first connected
 	connectedAt time.Time
@@ -237,6 +260,7 @@ func NewParticipant(params ParticipantParams) (*ParticipantImpl, error) {
 	p.timedVersion.Update(params.VersionGenerator.New())
 	p.migrateState.Store(types.MigrateStateInit)
 	p.state.Store(livekit.ParticipantInfo_JOINING)
+	p.singleLayerSVC.Store(params.VideoConfig.SingleLayerSVC.EnabledFor(params.Identity))
 	p.grants = params.Grants
 	p.SetResponseSink(params.Sink)
 
@@ -273,6 +297,50 @@ func (p *ParticipantImpl) GetAdaptiveStream() bool {
 	return p.params.AdaptiveStream
 }
 
+// SetAudioOnly pauses all subscribed video when enabled. When disabled, video is resumed with
+// screen shares ahead of other video so that shared content comes back first.
+func (p *ParticipantImpl) SetAudioOnly(audioOnly bool) {
+	if p.audioOnly.Swap(audioOnly) == audioOnly {
+		return
+	}
+
+	var screenShares, others []types.SubscribedTrack
+	for _, st := range p.SubscriptionManager.GetSubscribedTracks() {
+		if st.MediaTrack().Kind() != livekit.TrackType_VIDEO {
+			continue
+		}
+		if st.MediaTrack().Source() == livekit.TrackSource_SCREEN_SHARE {
+			screenShares = append(screenShares, st)
+		} else {
+			others = append(others, st)
+		}
+	}
+
+	p.subLogger.Infow("updating audio only mode", "audioOnly", audioOnly, "videoTracks", len(screenShares)+len(others))
+	for _, st := range append(screenShares, others...) {
+		st.UpdateVideoLayer()
+	}
+}
+
+func (p *ParticipantImpl) IsAudioOnly() bool {
+	return p.audioOnly.Load()
+}
+
+// SetSingleLayerSVC toggles forwarding a single operating point of subscribed SVC video, for subscribers
+// whose decoders handle only single layer streams well
+func (p *ParticipantImpl) SetSingleLayerSVC(enabled bool) {
+	if p.singleLayerSVC.Swap(enabled) == enabled {
+		return
+	}
+
+	p.subLogger.Infow("updating single layer SVC mode", "enabled", enabled)
+	for _, st := range p.SubscriptionManager.GetSubscribedTracks() {
+		if st.MediaTrack().Kind() == livekit.TrackType_VIDEO {
+			st.DownTrack().SetSingleLayerSVC(enabled)
+		}
+	}
+}
+
 func (p *ParticipantImpl) GetPacer() pacer.Pacer {
 	return p.TransportManager.GetSubscriberPacer()
 }
@@ -341,6 +409,10 @@ func (p *ParticipantImpl) GetICEConnectionType() types.ICEConnectionType {
 	return p.TransportManager.GetICEConnectionType()
 }
 
+func (p *ParticipantImpl) GetICEAddressFamily() types.ICEAddressFamily {
+	return p.TransportManager.GetICEAddressFamily()
+}
+
 func (p *ParticipantImpl) GetBufferFactory() *buffer.Factory {
 	return p.params.Config.BufferFactory
 }
@@ -440,6 +512,7 @@ func (p *ParticipantImpl) SetPermission(permission *livekit.ParticipantPermissio
 		// revoke all subscriptions
 		for _, st := range p.SubscriptionManager.GetSubscribedTracks() {
 			st.MediaTrack().RemoveSubscriber(p.ID(), false)
+			p.SubscriptionRevoked(st.ID(), types.SubscriptionRevokedReasonSubscribePermission)
 		}
 	}
 
@@ -576,6 +649,7 @@ func (p *ParticipantImpl) HandleOffer(offer webrtc.SessionDescription) {
 	}
 
 	offer = p.setCodecPreferencesForPublisher(offer)
+	p.setRTXPairsForPublisher(offer)
 
 	p.TransportManager.HandleOffer(offer, shouldPend)
 }
@@ -596,6 +670,13 @@ func (p *ParticipantImpl) HandleAnswer(answer webrtc.SessionDescription) {
 	p.TransportManager.HandleAnswer(answer)
 }
 
+// HandleSubscriberOffer handles an offer for the subscriber connection, made by clients that cannot renegotiate
+// instead of answering the server's offer
+func (p *ParticipantImpl) HandleSubscriberOffer(offer webrtc.SessionDescription) {
+	p.subLogger.Debugw("received offer", "transport", livekit.SignalTarget_SUBSCRIBER)
+	p.TransportManager.HandleSubscriberOffer(offer)
+}
+
 func (p *ParticipantImpl) onPublisherAnswer(answer webrtc.SessionDescription) error {
 	if p.IsClosed() || p.IsDisconnected() {
 		return nil
@@ -676,6 +757,13 @@ func (p *ParticipantImpl) AddTrack(req *livekit.AddTrackRequest) {
 		return
 	}
 
+	// codecs added to a published track (req.Sid set) are not new tracks
+	if req.Sid == "" && p.params.RefuseNewTracks != nil && p.params.RefuseNewTracks() {
+		p.pubLogger.Warnw("refusing track, room is over its resource budget", nil, "cid", req.Cid, "source", req.Source)
+		p.sendTrackRefused(req)
+		return
+	}
+
 	p.lock.Lock()
 	defer p.lock.Unlock()
 	ti := p.addPendingTrackLocked(req)
@@ -684,6 +772,9 @@ func (p *ParticipantImpl) AddTrack(req *livekit.AddTrackRequest) {
 	}
 
 	p.sendTrackPublished(req.Cid, ti)
+	if constraints := publishConstraintsForTrack(p.params.ScreenSharePolicy, ti); constraints != nil {
+		p.sendPublishConstraints(constraints)
+	}
 }
 
 func (p *ParticipantImpl) SetMigrateInfo(
@@ -1043,6 +1134,9 @@ func (p *ParticipantImpl) onTrackSubscribed(subTrack types.SubscribedTrack) {
 	if p.params.ClientInfo.FireTrackByRTPPacket() {
 		subTrack.DownTrack().SetActivePaddingOnMuteUpTrack()
 	}
+	if p.singleLayerSVC.Load() && subTrack.MediaTrack().Kind() == livekit.TrackType_VIDEO {
+		subTrack.DownTrack().SetSingleLayerSVC(true)
+	}
 
 	subTrack.AddOnBind(func(err error) {
 		if err != nil {
@@ -1076,6 +1170,10 @@ func (p *ParticipantImpl) SubscriptionPermissionUpdate(publisherID livekit.Parti
 	}
 }
 
+func (p *ParticipantImpl) SubscriptionRevoked(trackID livekit.TrackID, reason types.SubscriptionRevokedReason) {
+	p.SubscriptionManager.handleSubscriptionRevoked(trackID, reason)
+}
+
 func (p *ParticipantImpl) UpdateMediaRTT(rtt uint32) {
 	now := time.Now()
 	p.lock.Lock()
@@ -1114,6 +1212,9 @@ func (p *ParticipantImpl) setupTransportManager() error {
 		AllowUDPUnstableFallback: p.params.AllowUDPUnstableFallback,
 		TURNSEnabled:             p.params.TURNSEnabled,
 		AllowPlayoutDelay:        p.params.PlayoutDelay.GetEnabled() && p.SupportSyncStreamID(),
+		ForceRelay:               p.params.ForceRelay,
+		PublisherIPFilter:        p.params.PublisherIPFilter,
+		SingleNegotiation:        p.params.SingleNegotiation,
 		Logger:                   p.params.Logger.WithComponent(sutils.ComponentTransport),
 	})
 	if err != nil {
@@ -1127,7 +1228,7 @@ func (p *ParticipantImpl) setupTransportManager() error {
 		if p.params.ClientConf == nil {
 			p.params.ClientConf = &livekit.ClientConfiguration{}
 		}
-		if iceConfig.PreferenceSubscriber == livekit.ICECandidateType_ICT_TLS {
+		if iceConfig.PreferenceSubscriber == livekit.ICECandidateType_ICT_TLS || p.params.ForceRelay {
 			p.params.ClientConf.ForceRelay = livekit.ClientConfigSetting_ENABLED
 		} else {
 			// UNSET indicates that clients could override RTCConfiguration to forceRelay
@@ -1146,12 +1247,15 @@ func (p *ParticipantImpl) setupTransportManager() error {
 	tm.OnPublisherAnswer(p.onPublisherAnswer)
 	tm.OnPublisherTrack(p.onMediaTrack)
 	tm.OnPublisherInitialConnected(p.onPublisherInitialConnected)
+	tm.OnPublisherRemoteIPRejected(p.onPublisherIPRejected)
 
 	tm.OnSubscriberOffer(p.onSubscriberOffer)
+	tm.OnSubscriberAnswer(p.onSubscriberAnswer)
 	tm.OnSubscriberICECandidate(func(c *webrtc.ICECandidate) error {
 		return p.onICECandidate(c, livekit.SignalTarget_SUBSCRIBER)
 	})
 	tm.OnSubscriberInitialConnected(p.onSubscriberInitialConnected)
+	tm.OnSubscriberReconnected(p.onSubscriberReconnected)
 	tm.OnSubscriberStreamStateChange(p.onStreamStateChange)
 
 	tm.OnPrimaryTransportInitialConnected(p.onPrimaryTransportInitialConnected)
@@ -1160,6 +1264,7 @@ func (p *ParticipantImpl) setupTransportManager() error {
 	tm.OnAnyTransportNegotiationFailed(p.onAnyTransportNegotiationFailed)
 
 	tm.OnDataMessage(p.onDataMessage)
+	tm.OnReliableDataStalled(p.onReliableDataStalled)
 
 	tm.SetSubscriberAllowPause(p.params.SubscriberAllowPause)
 	p.TransportManager = tm
@@ -1259,6 +1364,16 @@ func (p *ParticipantImpl) onSubscriberOffer(offer webrtc.SessionDescription) err
 	})
 }
 
+// when the server answers an offer for the subscriber connection from a client that cannot renegotiate
+func (p *ParticipantImpl) onSubscriberAnswer(answer webrtc.SessionDescription) error {
+	p.subLogger.Debugw("sending answer", "transport", livekit.SignalTarget_SUBSCRIBER)
+	return p.writeMessage(&livekit.SignalResponse{
+		Message: &livekit.SignalResponse_Answer{
+			Answer: ToProtoSessionDescription(answer),
+		},
+	})
+}
+
 func (p *ParticipantImpl) removePublishedTrack(track types.MediaTrack) {
 	p.RemovePublishedTrack(track, false, false)
 	if p.ProtocolVersion().SupportsUnpublish() {
@@ -1366,6 +1481,24 @@ func (p *ParticipantImpl) onICECandidate(c *webrtc.ICECandidate, target livekit.
 func (p *ParticipantImpl) onPublisherInitialConnected() {
 	p.supervisor.SetPublisherPeerConnectionConnected(true)
 	go p.publisherRTCPWorker()
+	if p.params.CongestionControlConfig.Uplink.Enabled {
+		go p.uplinkEstimatorWorker()
+	}
+}
+
+// onPublisherIPRejected removes a participant allowed to publish whose publisher transport connected from an address
+// that is not allowed
+func (p *ParticipantImpl) onPublisherIPRejected(address string) {
+	p.lock.RLock()
+	canPublish := p.grants.Video.GetCanPublish()
+	p.lock.RUnlock()
+	if !canPublish {
+		return
+	}
+
+	p.params.Logger.Infow("rejecting publisher connecting from address that is not allowed", "address", address)
+	p.params.Telemetry.PublisherIPRejected(context.Background(), p.ID(), p.ToProto(), address)
+	go p.Close(true, types.ParticipantCloseReasonPublisherIPRejected, false)
 }
 
 func (p *ParticipantImpl) onSubscriberInitialConnected() {
@@ -1374,6 +1507,49 @@ func (p *ParticipantImpl) onSubscriberInitialConnected() {
 	p.setDowntracksConnected()
 }
 
+// on a quick reconnect, conceal the gap in media by replaying packets sent since the subscriber last
+// acknowledged receiving media, falling back to a key frame for video whose gap cannot be replayed.
+// Tracks are restored in the configured priority order and key frame requests are staggered so that
+// the most important media returns first.
+func (p *ParticipantImpl) onSubscriberReconnected() {
+	generation := p.resumeGeneration.Inc()
+
+	var replayed, keyFramed []livekit.TrackID
+	var needKeyFrame []types.SubscribedTrack
+	for _, t := range orderTracksForResume(p.params.ResumeConfig.Priority, p.SubscriptionManager.GetSubscribedTracks()) {
+		dt := t.DownTrack()
+		if dt == nil {
+			continue
+		}
+
+		if dt.ReplayGap(dt.GetLastReceiverReportTime()) {
+			replayed = append(replayed, t.ID())
+		} else if t.MediaTrack().Kind() == livekit.TrackType_VIDEO {
+			keyFramed = append(keyFramed, t.ID())
+			needKeyFrame = append(needKeyFrame, t)
+		}
+	}
+	p.subLogger.Infow("subscriber reconnected, concealing media gap", "replayed", replayed, "keyFramed", keyFramed)
+
+	if len(needKeyFrame) != 0 {
+		go p.requestGapKeyFrames(generation, needKeyFrame)
+	}
+}
+
+func (p *ParticipantImpl) requestGapKeyFrames(generation uint32, tracks []types.SubscribedTrack) {
+	for i, t := range tracks {
+		if i != 0 && p.params.ResumeConfig.KeyFrameStagger > 0 {
+			time.Sleep(p.params.ResumeConfig.KeyFrameStagger)
+		}
+		if p.IsClosed() || p.resumeGeneration.Load() != generation {
+			return
+		}
+		if dt := t.DownTrack(); dt != nil {
+			dt.RequestGapKeyFrame()
+		}
+	}
+}
+
 func (p *ParticipantImpl) onPrimaryTransportInitialConnected() {
 	if !p.hasPendingMigratedTrack() && p.MigrateState() == types.MigrateStateSync {
 		p.SetMigrateState(types.MigrateStateComplete)
@@ -1418,32 +1594,72 @@ func (p *ParticipantImpl) onAnyTransportFailed() {
 	p.setupDisconnectTimer()
 }
 
+// senderReportState tracks when the next SenderReport of a subscribed track is due
+type senderReportState struct {
+	due    time.Time
+	at     time.Time
+	octets uint32
+}
+
 // subscriberRTCPWorker sends SenderReports periodically when the participant is subscribed to
-// other publishedTracks in the room.
+// other publishedTracks in the room. Each track is on its own randomized schedule so that reports
+// of many tracks are spread out instead of being sent in a burst.
 func (p *ParticipantImpl) subscriberRTCPWorker() {
 	defer func() {
 		if r := Recover(p.GetLogger()); r != nil {
 			os.Exit(1)
 		}
 	}()
+
+	schedule := buffer.RTCPSchedule{
+		Interval:          p.params.RTCPConfig.SenderReportInterval,
+		BandwidthFraction: p.params.RTCPConfig.BandwidthFraction,
+		Jitter:            p.params.RTCPConfig.Jitter,
+	}
+	if schedule.Interval <= 0 {
+		schedule.Interval = senderReportInterval
+	}
+	ticker := time.NewTicker(max(schedule.Interval/10, senderReportTickMinimum))
+	defer ticker.Stop()
+
+	states := make(map[livekit.TrackID]*senderReportState)
 	for {
 		if p.IsDisconnected() {
 			return
 		}
 
+		now := time.Now()
 		subscribedTracks := p.SubscriptionManager.GetSubscribedTracks()
+		subscribedStates := make(map[livekit.TrackID]*senderReportState, len(subscribedTracks))
 
 		// send in batches of sdBatchSize
 		batchSize := 0
 		var pkts []rtcp.Packet
 		var sd []rtcp.SourceDescriptionChunk
 		for _, subTrack := range subscribedTracks {
+			state := states[subTrack.ID()]
+			if state == nil {
+				state = &senderReportState{}
+			}
+			subscribedStates[subTrack.ID()] = state
+			if now.Before(state.due) {
+				continue
+			}
+
 			sr := subTrack.DownTrack().CreateSenderReport()
 			chunks := subTrack.DownTrack().CreateSourceDescriptionChunks()
 			if sr == nil || chunks == nil {
 				continue
 			}
 
+			var bitrate float64
+			if elapsed := now.Sub(state.at); !state.at.IsZero() && elapsed > 0 {
+				bitrate = float64(sr.OctetCount-state.octets) * 8 / elapsed.Seconds()
+			}
+			state.at = now
+			state.octets = sr.OctetCount
+			state.due = now.Add(schedule.Next(buffer.RTCPSize([]rtcp.Packet{sr, &rtcp.SourceDescription{Chunks: chunks}}), bitrate))
+
 			pkts = append(pkts, sr)
 			sd = append(sd, chunks...)
 			batchSize = batchSize + 1 + len(chunks)
@@ -1463,6 +1679,7 @@ func (p *ParticipantImpl) subscriberRTCPWorker() {
 				batchSize = 0
 			}
 		}
+		states = subscribedStates
 
 		if len(pkts) != 0 || len(sd) != 0 {
 			if len(sd) != 0 {
@@ -1476,7 +1693,7 @@ func (p *ParticipantImpl) subscriberRTCPWorker() {
 			}
 		}
 
-		time.Sleep(3 * time.Second)
+		<-ticker.C
 	}
 }
 
@@ -1514,9 +1731,14 @@ func (p *ParticipantImpl) onSubscribedMaxQualityChange(trackID livekit.TrackID,
 		return nil
 	}
 
-	// normalize the codec name
+	track := p.UpTrackManager.GetPublishedTrack(trackID)
 	for _, subscribedQuality := range subscribedQualities {
+		// normalize the codec name
 		subscribedQuality.Codec = strings.ToLower(strings.TrimLeft(subscribedQuality.Codec, "video/"))
+		// screen shares are sent within the room's publish constraints
+		if track != nil && track.Source() == livekit.TrackSource_SCREEN_SHARE {
+			constrainSubscribedQualities(subscribedQuality.Qualities, maxScreenShareLayers(p.params.ScreenSharePolicy))
+		}
 	}
 
 	subscribedQualityUpdate := &livekit.SubscribedQualityUpdate{
@@ -1526,7 +1748,6 @@ func (p *ParticipantImpl) onSubscribedMaxQualityChange(trackID livekit.TrackID,
 	}
 
 	// send layer info about max subscription changes to telemetry
-	track := p.UpTrackManager.GetPublishedTrack(trackID)
 	var layerInfo map[livekit.VideoQuality]*livekit.VideoLayer
 	if track != nil {
 		layers := track.ToProto().Layers
@@ -1660,6 +1881,24 @@ func (p *ParticipantImpl) sendTrackPublished(cid string, ti *livekit.TrackInfo)
 	})
 }
 
+// sendTrackRefused lets the client know that a track it asked to publish was refused. Clients learn the sid of
+// a track from the published response only, so the track is published and unpublished right away.
+func (p *ParticipantImpl) sendTrackRefused(req *livekit.AddTrackRequest) {
+	ti := &livekit.TrackInfo{
+		Sid:    utils.NewGuid(utils.TrackPrefix),
+		Type:   req.Type,
+		Name:   req.Name,
+		Source: req.Source,
+	}
+	p.sendTrackPublished(req.Cid, ti)
+	if p.ProtocolVersion().SupportsUnpublish() {
+		p.sendTrackUnpublished(livekit.TrackID(ti.Sid))
+	} else {
+		// for older clients that don't support unpublish, mute to avoid them sending data
+		p.sendTrackMuted(livekit.TrackID(ti.Sid), true)
+	}
+}
+
 func (p *ParticipantImpl) SetTrackMuted(trackID livekit.TrackID, muted bool, fromAdmin bool) {
 	// when request is coming from admin, send message to current participant
 	if fromAdmin {
@@ -1741,7 +1980,16 @@ func (p *ParticipantImpl) mediaTrackReceived(track *webrtc.TrackRemote, rtpRecei
 
 	ssrc := uint32(track.SSRC())
 	if p.twcc == nil {
-		p.twcc = twcc.NewTransportWideCCResponder(ssrc)
+		twccParams := twcc.ResponderParamsDefault
+		if conf := p.params.TWCCConfig; conf.FeedbackInterval > 0 {
+			twccParams = twcc.ResponderParams{
+				Interval:            conf.FeedbackInterval,
+				IntervalAfterMarker: conf.FeedbackIntervalAfterMarker,
+				MinPackets:          conf.MinPacketsPerFeedback,
+				MaxPackets:          conf.MaxPacketsPerFeedback,
+			}
+		}
+		p.twcc = twcc.NewTransportWideCCResponder(ssrc, twccParams)
 		p.twcc.OnFeedback(func(pkts []rtcp.Packet) {
 			p.postRtcp(pkts)
 		})
@@ -1828,6 +2076,8 @@ func (p *ParticipantImpl) addMediaTrack(signalCid string, sdpCid string, ti *liv
 		Logger:              LoggerWithTrack(p.pubLogger, livekit.TrackID(ti.Sid), false),
 		SubscriberConfig:    p.params.Config.Subscriber,
 		PLIThrottleConfig:   p.params.PLIThrottleConfig,
+		RTCPConfig:          p.params.RTCPConfig,
+		DisableNACK:         p.params.NACKPolicy.DisabledFor(ti.Source),
 		SimTracks:           p.params.SimTracks,
 	})
 
@@ -2084,6 +2334,65 @@ func (p *ParticipantImpl) publisherRTCPWorker() {
 	}
 }
 
+// uplinkEstimatorWorker asks the publisher to lower its encode bitrate with REMB while its uplink is congested
+func (p *ParticipantImpl) uplinkEstimatorWorker() {
+	conf := p.params.CongestionControlConfig.Uplink
+	estimator := NewUplinkEstimator(conf)
+	ticker := time.NewTicker(conf.Interval)
+	defer ticker.Stop()
+
+	for !p.IsDisconnected() {
+		<-ticker.C
+
+		sample, ssrcs := p.uplinkSample()
+		if len(ssrcs) == 0 {
+			// REMB only adapts video
+			continue
+		}
+		wasCongested := estimator.Congested()
+		bitrate, ok := estimator.Update(time.Now(), sample)
+		if !ok {
+			continue
+		}
+		if congested := estimator.Congested(); congested != wasCongested {
+			p.pubLogger.Infow("publisher uplink congestion changed", "congested", congested, "bitrate", bitrate)
+		}
+		p.postRtcp([]rtcp.Packet{
+			&rtcp.ReceiverEstimatedMaximumBitrate{
+				Bitrate: float32(bitrate),
+				SSRCs:   ssrcs,
+			},
+		})
+	}
+}
+
+// uplinkSample returns the counters of media received from the participant, and the SSRCs of its video
+func (p *ParticipantImpl) uplinkSample() (UplinkSample, []uint32) {
+	var sample UplinkSample
+	var ssrcs []uint32
+	for _, track := range p.GetPublishedTracks() {
+		for _, r := range track.Receivers() {
+			wr, ok := r.(*sfu.WebRTCReceiver)
+			if !ok {
+				continue
+			}
+			for _, buff := range wr.Buffers() {
+				stats := buff.GetStats()
+				if stats == nil {
+					continue
+				}
+				sample.Packets += uint64(stats.Packets)
+				sample.PacketsLost += uint64(stats.PacketsLost)
+				sample.Bytes += stats.Bytes + stats.HeaderBytes
+				if track.Kind() == livekit.TrackType_VIDEO {
+					ssrcs = append(ssrcs, buff.GetMediaSSRC())
+				}
+			}
+		}
+	}
+	return sample, ssrcs
+}
+
 func (p *ParticipantImpl) DebugInfo() map[string]interface{} {
 	info := map[string]interface{}{
 		"ID":    p.params.SID,
@@ -2246,8 +2555,20 @@ func (p *ParticipantImpl) UpdateMediaLoss(nodeID livekit.NodeID, trackID livekit
 	return nil
 }
 
-func (p *ParticipantImpl) GetPlayoutDelayConfig() *livekit.PlayoutDelay {
-	return p.params.PlayoutDelay
+// GetPlayoutDelayConfig returns the playout delay limits of subscribed tracks published from a source
+func (p *ParticipantImpl) GetPlayoutDelayConfig(source livekit.TrackSource) *livekit.PlayoutDelay {
+	if !p.params.PlayoutDelay.GetEnabled() {
+		return p.params.PlayoutDelay
+	}
+	bounds, ok := p.params.PlayoutDelaySources[strings.ToLower(source.String())]
+	if !ok {
+		return p.params.PlayoutDelay
+	}
+	return &livekit.PlayoutDelay{
+		Enabled: true,
+		Min:     uint32(bounds.Min),
+		Max:     uint32(bounds.Max),
+	}
 }
 
 func (p *ParticipantImpl) SupportSyncStreamID() bool {
@@ -2279,6 +2600,12 @@ func codecsFromMediaDescription(m *sdp.MediaDescription) (out []sdp.Codec, err e
 	return out, nil
 }
 
+// reliable data is never dropped, a participant that does not keep up with it reconnects and resyncs
+func (p *ParticipantImpl) onReliableDataStalled() {
+	p.params.Logger.Infow("issuing full reconnect on stalled reliable data channel")
+	p.IssueFullReconnect(types.ParticipantCloseReasonDataChannelError)
+}
+
 func (p *ParticipantImpl) SendDataPacket(dp *livekit.DataPacket, data []byte) error {
 	if p.State() != livekit.ParticipantInfo_ACTIVE {
 		return ErrDataChannelUnavailable
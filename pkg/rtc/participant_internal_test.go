@@ -216,6 +216,26 @@ func TestTrackPublishing(t *testing.T) {
 		})
 		require.Equal(t, 1, sink.WriteMessageCallCount())
 	})
+
+	t.Run("refused tracks are published and unpublished right away", func(t *testing.T) {
+		p := newParticipantForTestWithOpts("test", &participantOpts{protocolVersion: types.CurrentProtocol})
+		p.params.RefuseNewTracks = func() bool { return true }
+		sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+		p.AddTrack(&livekit.AddTrackRequest{
+			Cid:  "cid",
+			Name: "webcam",
+			Type: livekit.TrackType_VIDEO,
+		})
+
+		require.Equal(t, 2, sink.WriteMessageCallCount())
+		published := sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse).GetTrackPublished()
+		require.NotNil(t, published)
+		require.Equal(t, "cid", published.Cid)
+		unpublished := sink.WriteMessageArgsForCall(1).(*livekit.SignalResponse).GetTrackUnpublished()
+		require.NotNil(t, unpublished)
+		require.Equal(t, published.Track.Sid, unpublished.TrackSid)
+		require.Empty(t, p.pendingTracks)
+	})
 }
 
 func TestOutOfOrderUpdates(t *testing.T) {
@@ -290,6 +310,46 @@ func TestMuteSetting(t *testing.T) {
 	})
 }
 
+func TestAudioOnly(t *testing.T) {
+	p := newParticipantForTest("test")
+
+	var updated []livekit.TrackID
+	addSubscribedTrack := func(trackID livekit.TrackID, kind livekit.TrackType, source livekit.TrackSource) {
+		mt := &typesfakes.FakeMediaTrack{}
+		mt.KindReturns(kind)
+		mt.SourceReturns(source)
+		st := &typesfakes.FakeSubscribedTrack{}
+		st.IDReturns(trackID)
+		st.MediaTrackReturns(mt)
+		st.UpdateVideoLayerCalls(func() {
+			updated = append(updated, trackID)
+		})
+
+		s := newTrackSubscription(p.ID(), trackID, p.GetLogger())
+		s.subscribedTrack = st
+		p.SubscriptionManager.lock.Lock()
+		p.SubscriptionManager.subscriptions[trackID] = s
+		p.SubscriptionManager.lock.Unlock()
+	}
+	addSubscribedTrack("camera", livekit.TrackType_VIDEO, livekit.TrackSource_CAMERA)
+	addSubscribedTrack("audio", livekit.TrackType_AUDIO, livekit.TrackSource_MICROPHONE)
+	addSubscribedTrack("screen", livekit.TrackType_VIDEO, livekit.TrackSource_SCREEN_SHARE)
+
+	p.SetAudioOnly(true)
+	require.True(t, p.IsAudioOnly())
+	require.ElementsMatch(t, []livekit.TrackID{"camera", "screen"}, updated)
+
+	// setting the same mode again is a no-op
+	updated = nil
+	p.SetAudioOnly(true)
+	require.Empty(t, updated)
+
+	// screen share resumes first
+	p.SetAudioOnly(false)
+	require.False(t, p.IsAudioOnly())
+	require.Equal(t, []livekit.TrackID{"screen", "camera"}, updated)
+}
+
 func TestSubscriberAsPrimary(t *testing.T) {
 	t.Run("protocol 4 uses subs as primary", func(t *testing.T) {
 		p := newParticipantForTestWithOpts("test", &participantOpts{
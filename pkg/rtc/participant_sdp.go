@@ -281,3 +281,40 @@ func (p *ParticipantImpl) configurePublisherAnswer(answer webrtc.SessionDescript
 	answer.SDP = string(bytes)
 	return answer
 }
+
+// associate RTX streams with the streams they repair from the offer's FID ssrc-groups. Simulcast layers without
+// signalled SSRCs are associated by the buffer factory from the repaired RID of RTX packets
+func (p *ParticipantImpl) setRTXPairsForPublisher(offer webrtc.SessionDescription) {
+	bufferFactory := p.params.Config.BufferFactory
+	if bufferFactory == nil {
+		return
+	}
+
+	parsed, err := offer.Unmarshal()
+	if err != nil {
+		return
+	}
+
+	for _, m := range parsed.MediaDescriptions {
+		for _, attr := range m.Attributes {
+			if attr.Key != sdp.AttrKeySSRCGroup {
+				continue
+			}
+
+			// a=ssrc-group:FID <primary ssrc> <rtx ssrc>
+			fields := strings.Fields(attr.Value)
+			if len(fields) != 3 || fields[0] != sdp.SemanticTokenFlowIdentification {
+				continue
+			}
+			primarySSRC, err := strconv.ParseUint(fields[1], 10, 32)
+			if err != nil {
+				continue
+			}
+			repairSSRC, err := strconv.ParseUint(fields[2], 10, 32)
+			if err != nil {
+				continue
+			}
+			bufferFactory.SetRTXPair(uint32(repairSSRC), uint32(primarySSRC))
+		}
+	}
+}
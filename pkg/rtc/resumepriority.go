@@ -0,0 +1,69 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// resume priority entry matching the video of publishers that are currently speaking
+const resumePriorityActiveSpeaker = "active_speaker"
+
+// orderTracksForResume sorts subscribed tracks in the order they should be restored after a subscriber
+// reconnect, following the configured priority. Tracks of equal priority keep their order.
+func orderTracksForResume(priority []string, tracks []types.SubscribedTrack) []types.SubscribedTrack {
+	speaking := make(map[livekit.ParticipantID]bool)
+	for _, t := range tracks {
+		mt := t.MediaTrack()
+		if mt.Kind() != livekit.TrackType_AUDIO {
+			continue
+		}
+		if _, active := mt.GetAudioLevel(); active {
+			speaking[mt.PublisherID()] = true
+		}
+	}
+
+	ranks := make(map[livekit.TrackID]int, len(tracks))
+	for _, t := range tracks {
+		ranks[t.ID()] = resumeRank(priority, t.MediaTrack(), speaking)
+	}
+
+	ordered := make([]types.SubscribedTrack, len(tracks))
+	copy(ordered, tracks)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ranks[ordered[i].ID()] < ranks[ordered[j].ID()]
+	})
+	return ordered
+}
+
+func resumeRank(priority []string, mt types.MediaTrack, speaking map[livekit.ParticipantID]bool) int {
+	for i, entry := range priority {
+		if entry == resumePriorityActiveSpeaker {
+			if mt.Kind() == livekit.TrackType_VIDEO && speaking[mt.PublisherID()] {
+				return i
+			}
+			continue
+		}
+		if strings.EqualFold(entry, mt.Source().String()) {
+			return i
+		}
+	}
+	return len(priority)
+}
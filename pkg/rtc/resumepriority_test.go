@@ -0,0 +1,69 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestOrderTracksForResume(t *testing.T) {
+	newTrack := func(id string, publisherID string, kind livekit.TrackType, source livekit.TrackSource, speaking bool) types.SubscribedTrack {
+		mt := &typesfakes.FakeMediaTrack{}
+		mt.IDReturns(livekit.TrackID(id))
+		mt.KindReturns(kind)
+		mt.SourceReturns(source)
+		mt.PublisherIDReturns(livekit.ParticipantID(publisherID))
+		mt.GetAudioLevelReturns(0, speaking)
+		st := &typesfakes.FakeSubscribedTrack{}
+		st.IDReturns(livekit.TrackID(id))
+		st.MediaTrackReturns(mt)
+		return st
+	}
+
+	tracks := []types.SubscribedTrack{
+		newTrack("quietCamera", "PA", livekit.TrackType_VIDEO, livekit.TrackSource_CAMERA, false),
+		newTrack("quietMic", "PA", livekit.TrackType_AUDIO, livekit.TrackSource_MICROPHONE, false),
+		newTrack("speakerCamera", "PB", livekit.TrackType_VIDEO, livekit.TrackSource_CAMERA, false),
+		newTrack("speakerMic", "PB", livekit.TrackType_AUDIO, livekit.TrackSource_MICROPHONE, true),
+		newTrack("screen", "PA", livekit.TrackType_VIDEO, livekit.TrackSource_SCREEN_SHARE, false),
+	}
+	ids := func(tracks []types.SubscribedTrack) []livekit.TrackID {
+		var ids []livekit.TrackID
+		for _, t := range tracks {
+			ids = append(ids, t.ID())
+		}
+		return ids
+	}
+
+	require.Equal(t,
+		[]livekit.TrackID{"screen", "speakerCamera", "quietCamera", "quietMic", "speakerMic"},
+		ids(orderTracksForResume([]string{"screen_share", resumePriorityActiveSpeaker}, tracks)),
+	)
+
+	// sources are matched regardless of case, unmatched tracks keep their order
+	require.Equal(t,
+		[]livekit.TrackID{"quietMic", "speakerMic", "quietCamera", "speakerCamera", "screen"},
+		ids(orderTracksForResume([]string{"MICROPHONE"}, tracks)),
+	)
+
+	require.Equal(t, ids(tracks), ids(orderTracksForResume(nil, tracks)))
+}
@@ -20,13 +20,16 @@ import (
 	"io"
 	"math"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"go.uber.org/atomic"
+	"golang.org/x/exp/slices"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/pion/sctp"
+	"github.com/pion/webrtc/v3"
 
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
@@ -47,6 +50,8 @@ const (
 	AudioLevelQuantization    = 8      // ideally power of 2 to minimize float decimal
 	invAudioLevelQuantization = 1.0 / AudioLevelQuantization
 	subscriberUpdateInterval  = 3 * time.Second
+	// bound on how long the first offer of a single negotiation participant waits for its subscriptions
+	singleNegotiationSubscribeTimeout = 3 * time.Second
 
 	dataForwardLoadBalanceThreshold = 20
 )
@@ -77,6 +82,8 @@ type Room struct {
 	egressLauncher EgressLauncher
 	trackManager   *RoomTrackManager
 
+	metadataValidator *MetadataValidator
+
 	// map of identity -> Participant
 	participants              map[livekit.ParticipantIdentity]types.LocalParticipant
 	participantOpts           map[livekit.ParticipantIdentity]*ParticipantOptions
@@ -84,6 +91,24 @@ type Room struct {
 	hasPublished              sync.Map // map of identity -> bool
 	bufferFactory             *buffer.FactoryOfBufferFactory
 
+	// map of egressID -> EgressInfo, for egresses that are still running
+	egresses map[string]*livekit.EgressInfo
+	// an egress is streaming the room's media, sent alongside ActiveRecording on EgressStateTopic
+	streaming bool
+
+	// name and metadata changes made during the session
+	participantChanges []ParticipantChange
+
+	// sequence numbered room events, for backends reconciling state
+	journal roomJournal
+
+	// map of identity -> attributes
+	attributes           map[livekit.ParticipantIdentity]map[string]string
+	attributePermissions *AttributePermissions
+
+	// publish constraints for screen shares
+	screenSharePolicy config.ScreenSharePolicy
+
 	// batch update participant info for non-publishers
 	batchedUpdates   map[livekit.ParticipantIdentity]*livekit.ParticipantInfo
 	batchedUpdatesMu sync.Mutex
@@ -93,17 +118,37 @@ type Room struct {
 	holds    atomic.Int32
 	// time that the last participant left the room
 	leftAt atomic.Int64
-	closed chan struct{}
+	// locked rooms do not accept new participants, changed while holding lock so that joins are ordered with it
+	locked atomic.Bool
+	// mutes tracks published after a MuteAllExcept, guarded by lock
+	muteAll *muteAllPolicy
+	// rooms requiring end-to-end encryption reject publishers of unencrypted media
+	e2eeRequired atomic.Bool
+	forceRelay   atomic.Bool
+	closed       chan struct{}
+	// state of the room's resource budget, RoomBudgetState
+	budgetState  atomic.Int32
+	refuseTracks atomic.Bool
+	capVideo     atomic.Bool
 
 	trailer []byte
 
-	onParticipantChanged func(p types.LocalParticipant)
-	onRoomUpdated        func()
-	onClose              func()
+	onParticipantChanged    func(p types.LocalParticipant)
+	onTrackPublishedHandler func(p types.LocalParticipant, track types.MediaTrack)
+	onRoomUpdated           func()
+	onConnectionQuality     func(qualities map[livekit.ParticipantIdentity]livekit.ConnectionQuality)
+	onClose                 func()
 }
 
 type ParticipantOptions struct {
 	AutoSubscribe bool
+	// AutoSubscribePublisher limits auto subscription to the tracks of a single publisher
+	AutoSubscribePublisher livekit.ParticipantIdentity
+	// SingleNegotiation is for clients that cannot renegotiate, such as WHEP players. The first offer waits
+	// for existing tracks to be subscribed, and tracks published afterwards are sent on spare transceivers.
+	SingleNegotiation bool
+	// SubscriberOffer is answered in place of the server's offer for a client offering to receive media
+	SubscriberOffer *webrtc.SessionDescription
 }
 
 func NewRoom(
@@ -114,6 +159,7 @@ func NewRoom(
 	serverInfo *livekit.ServerInfo,
 	telemetry telemetry.TelemetryService,
 	egressLauncher EgressLauncher,
+	metadataValidator *MetadataValidator,
 ) *Room {
 	r := &Room{
 		protoRoom: proto.Clone(room).(*livekit.Room),
@@ -127,11 +173,14 @@ func NewRoom(
 		audioConfig:               audioConfig,
 		telemetry:                 telemetry,
 		egressLauncher:            egressLauncher,
+		metadataValidator:         metadataValidator,
 		trackManager:              NewRoomTrackManager(),
 		serverInfo:                serverInfo,
 		participants:              make(map[livekit.ParticipantIdentity]types.LocalParticipant),
 		participantOpts:           make(map[livekit.ParticipantIdentity]*ParticipantOptions),
 		participantRequestSources: make(map[livekit.ParticipantIdentity]routing.MessageSource),
+		egresses:                  make(map[string]*livekit.EgressInfo),
+		attributes:                make(map[livekit.ParticipantIdentity]map[string]string),
 		bufferFactory:             buffer.NewFactoryOfBufferFactory(config.Receiver.PacketBufferSize),
 		batchedUpdates:            make(map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
 		closed:                    make(chan struct{}),
@@ -195,6 +244,10 @@ func (r *Room) GetParticipantByID(participantID livekit.ParticipantID) types.Loc
 func (r *Room) GetParticipants() []types.LocalParticipant {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
+	return r.getParticipantsLocked()
+}
+
+func (r *Room) getParticipantsLocked() []types.LocalParticipant {
 	participants := make([]types.LocalParticipant, 0, len(r.participants))
 	for _, p := range r.participants {
 		participants = append(participants, p)
@@ -277,6 +330,10 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 		return ErrAlreadyJoined
 	}
 
+	if r.locked.Load() && !participant.IsRecorder() {
+		return ErrRoomLocked
+	}
+
 	if r.protoRoom.MaxParticipants > 0 && !participant.IsRecorder() {
 		numParticipants := uint32(0)
 		for _, p := range r.participants {
@@ -310,16 +367,21 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 		if state == livekit.ParticipantInfo_ACTIVE {
 			// subscribe participant to existing published tracks
 			r.subscribeToExistingTracks(p)
+			r.sendParticipantAttributes(p)
+			r.sendEgressStateTo(p)
 
 			// start the workers once connectivity is established
 			p.Start()
+			r.journalParticipantEvent(JournalEventParticipantJoined, p)
 
+			connectionType := p.GetICEConnectionType()
+			prometheus.RecordParticipantConnection(string(connectionType), string(p.GetICEAddressFamily()))
 			r.telemetry.ParticipantActive(context.Background(),
 				r.ToProto(),
 				p.ToProto(),
 				&livekit.AnalyticsClientMeta{
 					ClientConnectTime: uint32(time.Since(p.ConnectedAt()).Milliseconds()),
-					ConnectionType:    string(p.GetICEConnectionType()),
+					ConnectionType:    string(connectionType),
 				},
 				false,
 			)
@@ -405,7 +467,19 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 
 	if participant.SubscriberAsPrimary() {
 		// initiates sub connection as primary
-		if participant.ProtocolVersion().SupportFastStart() {
+		if opts != nil && opts.SingleNegotiation {
+			go func() {
+				r.subscribeToExistingTracks(participant)
+				if err := participant.WaitUntilSubscribed(singleNegotiationSubscribeTimeout); err != nil {
+					participant.GetLogger().Warnw("not all tracks subscribed before first offer", err)
+				}
+				if opts.SubscriberOffer != nil {
+					participant.HandleSubscriberOffer(*opts.SubscriberOffer)
+				} else {
+					participant.Negotiate(true)
+				}
+			}()
+		} else if participant.ProtocolVersion().SupportFastStart() {
 			go func() {
 				r.subscribeToExistingTracks(participant)
 				participant.Negotiate(true)
@@ -461,6 +535,117 @@ func (r *Room) ResumeParticipant(p types.LocalParticipant, requestSource routing
 	return nil
 }
 
+// SetLocked prevents participants, other than recorders, from joining the room while it is locked.
+// participants already in the room are not affected
+func (r *Room) SetLocked(locked bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.setLockedLocked(locked)
+}
+
+func (r *Room) setLockedLocked(locked bool) {
+	if r.locked.Swap(locked) != locked {
+		r.Logger.Infow("room lock changed", "locked", locked)
+	}
+}
+
+func (r *Room) IsLocked() bool {
+	return r.locked.Load()
+}
+
+// SetE2EERequired marks the room as requiring end-to-end encryption, enforcement is left to the room manager
+// which is able to inspect published media
+func (r *Room) SetE2EERequired(required bool) {
+	if r.e2eeRequired.Swap(required) != required {
+		r.Logger.Infow("room end-to-end encryption requirement changed", "required", required)
+	}
+}
+
+func (r *Room) IsE2EERequired() bool {
+	return r.e2eeRequired.Load()
+}
+
+// SetForceRelay makes participants joining the room connect through TURN only
+func (r *Room) SetForceRelay(forceRelay bool) {
+	if r.forceRelay.Swap(forceRelay) != forceRelay {
+		r.Logger.Infow("room forced relay changed", "forceRelay", forceRelay)
+	}
+}
+
+func (r *Room) IsForceRelay() bool {
+	return r.forceRelay.Load()
+}
+
+type muteAllPolicy struct {
+	except  []livekit.ParticipantIdentity
+	sources []livekit.TrackSource
+}
+
+func (m *muteAllPolicy) mutes(identity livekit.ParticipantIdentity, source livekit.TrackSource) bool {
+	if slices.Contains(m.except, identity) {
+		return false
+	}
+	return len(m.sources) == 0 || slices.Contains(m.sources, source)
+}
+
+// MuteAllExcept mutes the tracks published by every participant other than the excepted ones, as well as the tracks
+// they publish later, until ClearMuteAll. when sources is not empty, only tracks from those sources are muted.
+// when lock is set, the room is locked along with taking the participants to mute, so that none joining is missed
+func (r *Room) MuteAllExcept(except []livekit.ParticipantIdentity, sources []livekit.TrackSource, lock bool) []livekit.TrackID {
+	policy := &muteAllPolicy{except: except, sources: sources}
+
+	r.lock.Lock()
+	if lock {
+		r.setLockedLocked(true)
+	}
+	r.muteAll = policy
+	participants := r.getParticipantsLocked()
+	r.lock.Unlock()
+
+	var muted []livekit.TrackID
+	for _, p := range participants {
+		for _, track := range p.GetPublishedTracks() {
+			if track.IsMuted() || !policy.mutes(p.Identity(), track.Source()) {
+				continue
+			}
+			p.SetTrackMuted(track.ID(), true, true)
+			muted = append(muted, track.ID())
+		}
+	}
+	return muted
+}
+
+// ClearMuteAll stops muting tracks published after MuteAllExcept, tracks muted already stay muted
+func (r *Room) ClearMuteAll() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.muteAll = nil
+}
+
+// RemoveParticipantsMatching removes every participant for which match returns true.
+// when lock is set, the room is locked along with taking the participants to match, so that none joining is missed
+func (r *Room) RemoveParticipantsMatching(
+	match func(p types.LocalParticipant) bool,
+	reason types.ParticipantCloseReason,
+	lock bool,
+) []livekit.ParticipantIdentity {
+	r.lock.Lock()
+	if lock {
+		r.setLockedLocked(true)
+	}
+	participants := r.getParticipantsLocked()
+	r.lock.Unlock()
+
+	var removed []livekit.ParticipantIdentity
+	for _, p := range participants {
+		if match(p) {
+			r.RemoveParticipant(p.Identity(), p.ID(), reason)
+			removed = append(removed, p.Identity())
+		}
+	}
+	return removed
+}
+
 func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livekit.ParticipantID, reason types.ParticipantCloseReason) {
 	r.lock.Lock()
 	p, ok := r.participants[identity]
@@ -474,6 +659,7 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 		delete(r.participants, identity)
 		delete(r.participantOpts, identity)
 		delete(r.participantRequestSources, identity)
+		delete(r.attributes, identity)
 		if !p.Hidden() {
 			r.protoRoom.NumParticipants--
 		}
@@ -481,22 +667,13 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 
 	immediateChange := false
 	if (p != nil && p.IsRecorder()) || r.protoRoom.ActiveRecording {
-		activeRecording := false
-		for _, op := range r.participants {
-			if op.IsRecorder() {
-				activeRecording = true
-				break
-			}
-		}
-
-		if r.protoRoom.ActiveRecording != activeRecording {
-			r.protoRoom.ActiveRecording = activeRecording
-			immediateChange = true
-
-		}
+		immediateChange = r.updateEgressStateLocked()
 	}
 	r.lock.Unlock()
 	r.protoProxy.MarkDirty(immediateChange)
+	if immediateChange {
+		r.broadcastEgressState()
+	}
 
 	if !ok {
 		return
@@ -522,6 +699,7 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 	// close participant as well
 	r.Logger.Debugw("closing participant for removal", "pID", p.ID(), "participant", p.Identity())
 	_ = p.Close(true, reason, false)
+	r.journalParticipantEvent(JournalEventParticipantLeft, p)
 
 	r.leftAt.Store(time.Now().Unix())
 
@@ -716,6 +894,11 @@ func (r *Room) OnParticipantChanged(f func(participant types.LocalParticipant))
 	r.onParticipantChanged = f
 }
 
+// OnTrackPublished is called after a track is published and existing participants have subscribed to it
+func (r *Room) OnTrackPublished(f func(participant types.LocalParticipant, track types.MediaTrack)) {
+	r.onTrackPublishedHandler = f
+}
+
 func (r *Room) SendDataPacket(up *livekit.UserPacket, kind livekit.DataPacket_Kind) {
 	dp := &livekit.DataPacket{
 		Kind: kind,
@@ -731,15 +914,67 @@ func (r *Room) SetMetadata(metadata string) {
 	r.protoRoom.Metadata = metadata
 	r.lock.Unlock()
 	r.protoProxy.MarkDirty(true)
+	r.appendJournal(JournalEntry{
+		Type:     JournalEventRoomMetadataChanged,
+		Metadata: metadata,
+	})
 }
 
-func (r *Room) UpdateParticipantMetadata(participant types.LocalParticipant, name string, metadata string) {
-	if metadata != "" {
+// UpdateParticipantMetadata updates name and/or metadata of a participant. actor is the participant
+// making the change, or empty when the change is made through the server API.
+// Nothing is updated when the metadata does not conform to the participant metadata schema.
+func (r *Room) UpdateParticipantMetadata(participant types.LocalParticipant, name string, metadata string, actor livekit.ParticipantIdentity) error {
+	prev := participant.ToProto()
+	var changes []ParticipantChange
+	if metadata != "" && metadata != prev.Metadata {
+		if err := r.metadataValidator.ValidateParticipantMetadata(metadata); err != nil {
+			return err
+		}
 		participant.SetMetadata(metadata)
+		changes = append(changes, ParticipantChange{
+			Field:    ParticipantChangeFieldMetadata,
+			OldValue: prev.Metadata,
+			NewValue: metadata,
+		})
 	}
-	if name != "" {
+	if name != "" && name != prev.Name {
 		participant.SetName(name)
+		changes = append(changes, ParticipantChange{
+			Field:    ParticipantChangeFieldName,
+			OldValue: prev.Name,
+			NewValue: name,
+		})
+	}
+	if len(changes) == 0 {
+		return nil
 	}
+
+	now := time.Now()
+	for i := range changes {
+		changes[i].At = now
+		changes[i].Identity = participant.Identity()
+		changes[i].Actor = actor
+	}
+	r.recordParticipantChanges(changes)
+	info := participant.ToProto()
+	r.appendJournal(JournalEntry{
+		Type:          JournalEventParticipantMetadataChanged,
+		Identity:      participant.Identity(),
+		ParticipantID: participant.ID(),
+		Participant:   info,
+		Metadata:      info.Metadata,
+	})
+	metadataChanges := make([]telemetry.ParticipantMetadataChange, 0, len(changes))
+	for _, c := range changes {
+		metadataChanges = append(metadataChanges, telemetry.ParticipantMetadataChange{
+			Field:    c.Field,
+			OldValue: c.OldValue,
+			NewValue: c.NewValue,
+			Actor:    c.Actor,
+		})
+	}
+	r.telemetry.ParticipantMetadataUpdated(context.Background(), r.ToProto(), info, metadataChanges)
+	return nil
 }
 
 func (r *Room) sendRoomUpdate() {
@@ -761,6 +996,11 @@ func (r *Room) OnRoomUpdated(f func()) {
 	r.onRoomUpdated = f
 }
 
+// OnConnectionQuality is called with the connection quality of active participants at every quality update interval
+func (r *Room) OnConnectionQuality(f func(qualities map[livekit.ParticipantIdentity]livekit.ConnectionQuality)) {
+	r.onConnectionQuality = f
+}
+
 func (r *Room) SimulateScenario(participant types.LocalParticipant, simulateScenario *livekit.SimulateScenario) error {
 	switch scenario := simulateScenario.Scenario.(type) {
 	case *livekit.SimulateScenario_SpeakerUpdate:
@@ -825,13 +1065,20 @@ func (r *Room) getOtherParticipantInfo(identity livekit.ParticipantIdentity) []*
 }
 
 // checks if participant should be autosubscribed to new tracks, assumes lock is already acquired
-func (r *Room) autoSubscribe(participant types.LocalParticipant) bool {
+func (r *Room) autoSubscribe(participant types.LocalParticipant, publisher types.Participant) bool {
+	// hidden publishers are not known to other participants, their tracks are only subscribed to explicitly
+	if publisher.Hidden() {
+		return false
+	}
 	opts := r.participantOpts[participant.Identity()]
 	// default to true if no options are set
-	if opts != nil && !opts.AutoSubscribe {
+	if opts == nil {
+		return true
+	}
+	if !opts.AutoSubscribe {
 		return false
 	}
-	return true
+	return opts.AutoSubscribePublisher == "" || opts.AutoSubscribePublisher == publisher.Identity()
 }
 
 func (r *Room) createJoinResponseLocked(participant types.LocalParticipant, iceServers []*livekit.ICEServer) *livekit.JoinResponse {
@@ -863,6 +1110,15 @@ func (r *Room) createJoinResponseLocked(participant types.LocalParticipant, iceS
 
 // a ParticipantImpl in the room added a new track, subscribe other participants to it
 func (r *Room) onTrackPublished(participant types.LocalParticipant, track types.MediaTrack) {
+	r.lock.RLock()
+	muteAll := r.muteAll
+	r.lock.RUnlock()
+	if muteAll != nil && !track.IsMuted() && muteAll.mutes(participant.Identity(), track.Source()) {
+		participant.SetTrackMuted(track.ID(), true, true)
+	}
+
+	r.journalTrackEvent(JournalEventTrackPublished, participant, track)
+
 	// publish participant update, since track state is changed
 	r.broadcastParticipantState(participant, broadcastOptions{skipSource: true})
 
@@ -877,7 +1133,7 @@ func (r *Room) onTrackPublished(participant types.LocalParticipant, track types.
 			// not fully joined. don't subscribe yet
 			continue
 		}
-		if !r.autoSubscribe(existingParticipant) {
+		if !r.autoSubscribe(existingParticipant, participant) {
 			continue
 		}
 
@@ -897,6 +1153,9 @@ func (r *Room) onTrackPublished(participant types.LocalParticipant, track types.
 	}
 
 	r.trackManager.AddTrack(track, participant.Identity(), participant.ID())
+	if r.onTrackPublishedHandler != nil {
+		r.onTrackPublishedHandler(participant, track)
+	}
 
 	// auto egress
 	if r.internal != nil {
@@ -941,6 +1200,7 @@ func (r *Room) onTrackUpdated(p types.LocalParticipant, _ types.MediaTrack) {
 
 func (r *Room) onTrackUnpublished(p types.LocalParticipant, track types.MediaTrack) {
 	r.trackManager.RemoveTrack(track)
+	r.journalTrackEvent(JournalEventTrackUnpublished, p, track)
 	if !p.IsClosed() {
 		r.broadcastParticipantState(p, broadcastOptions{skipSource: true})
 	}
@@ -959,17 +1219,28 @@ func (r *Room) onParticipantUpdate(p types.LocalParticipant) {
 }
 
 func (r *Room) onDataPacket(source types.LocalParticipant, dp *livekit.DataPacket) {
+	if topic := dp.GetUser().GetTopic(); source != nil && strings.HasPrefix(topic, AttributesTopicPrefix) {
+		if topic == AttributesUpdateTopic {
+			r.handleAttributesUpdate(source, dp.GetUser().GetPayload())
+		} else {
+			// attribute changes are only announced by the server
+			r.Logger.Warnw("dropping data on reserved attributes topic", nil, "participant", source.Identity(), "topic", topic)
+		}
+		return
+	}
+	if source != nil && dp.GetUser().GetTopic() == AudioOnlyTopic {
+		r.handleAudioOnlyRequest(source, dp.GetUser().GetPayload())
+		return
+	}
+	if source != nil && dp.GetUser().GetTopic() == EgressStateTopic {
+		// egress state is only announced by the server
+		r.Logger.Warnw("dropping data on reserved egress state topic", nil, "participant", source.Identity())
+		return
+	}
 	BroadcastDataPacketForRoom(r, source, dp, r.Logger)
 }
 
 func (r *Room) subscribeToExistingTracks(p types.LocalParticipant) {
-	r.lock.RLock()
-	shouldSubscribe := r.autoSubscribe(p)
-	r.lock.RUnlock()
-	if !shouldSubscribe {
-		return
-	}
-
 	var trackIDs []livekit.TrackID
 	for _, op := range r.GetParticipants() {
 		if p.ID() == op.ID() {
@@ -977,6 +1248,13 @@ func (r *Room) subscribeToExistingTracks(p types.LocalParticipant) {
 			continue
 		}
 
+		r.lock.RLock()
+		shouldSubscribe := r.autoSubscribe(p, op)
+		r.lock.RUnlock()
+		if !shouldSubscribe {
+			continue
+		}
+
 		// subscribe to all
 		for _, track := range op.GetPublishedTracks() {
 			trackIDs = append(trackIDs, track.ID())
@@ -1217,6 +1495,7 @@ func (r *Room) connectionQualityWorker() {
 
 		participants := r.GetParticipants()
 		nowConnectionInfos := make(map[livekit.ParticipantID]*livekit.ConnectionQualityInfo, len(participants))
+		qualities := make(map[livekit.ParticipantIdentity]livekit.ConnectionQuality, len(participants))
 
 		for _, p := range participants {
 			if p.State() != livekit.ParticipantInfo_ACTIVE {
@@ -1225,9 +1504,14 @@ func (r *Room) connectionQualityWorker() {
 
 			if q := p.GetConnectionQuality(); q != nil {
 				nowConnectionInfos[p.ID()] = q
+				qualities[p.Identity()] = q.Quality
 			}
 		}
 
+		if onConnectionQuality := r.onConnectionQuality; onConnectionQuality != nil {
+			onConnectionQuality(qualities)
+		}
+
 		// send an update if there is a change
 		//   - new participant
 		//   - quality change
@@ -1291,6 +1575,8 @@ func (r *Room) DebugInfo() map[string]interface{} {
 		"Name":      r.protoRoom.Name,
 		"Sid":       r.protoRoom.Sid,
 		"CreatedAt": r.protoRoom.CreationTime,
+		"Recording": r.IsRecording(),
+		"Streaming": r.IsStreaming(),
 	}
 
 	participants := r.GetParticipants()
@@ -1354,7 +1640,8 @@ func BroadcastDataPacketForRoom(r types.Room, source types.LocalParticipant, dp
 	utils.ParallelExec(destParticipants, dataForwardLoadBalanceThreshold, 1, func(op types.LocalParticipant) {
 		err := op.SendDataPacket(dp, dpData)
 		if err != nil && !errors.Is(err, io.ErrClosedPipe) && !errors.Is(err, sctp.ErrStreamClosed) &&
-			!errors.Is(err, ErrTransportFailure) {
+			!errors.Is(err, ErrTransportFailure) && !errors.Is(err, ErrDataChannelBufferFull) &&
+			!errors.Is(err, ErrDataChannelStalled) {
 			op.GetLogger().Infow("send data packet error", "error", err)
 		}
 	})
@@ -16,17 +16,28 @@ package rtc
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/protocol/egress"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/rpc"
 	"github.com/livekit/protocol/webhook"
 )
 
+// EgressStateTopic is the topic of messages telling participants whether the room is being recorded or streamed
+const EgressStateTopic = "lk.egress_state"
+
+// EgressState is the payload of messages on EgressStateTopic
+type EgressState struct {
+	Recording bool `json:"recording"`
+	Streaming bool `json:"streaming"`
+}
+
 type EgressLauncher interface {
 	StartEgress(context.Context, *rpc.StartEgressRequest) (*livekit.EgressInfo, error)
 	StartEgressWithClusterId(ctx context.Context, clusterId string, req *rpc.StartEgressRequest) (*livekit.EgressInfo, error)
@@ -161,6 +172,156 @@ func startTrackEgress(
 	return req, err
 }
 
+// UpdateEgressState tracks egresses running against the room, so that recording and streaming
+// state can be surfaced to participants without them having to query egress APIs
+func (r *Room) UpdateEgressState(info *livekit.EgressInfo) {
+	if info == nil || info.EgressId == "" {
+		return
+	}
+
+	r.lock.Lock()
+	if isEgressActive(info) {
+		r.egresses[info.EgressId] = info
+	} else {
+		delete(r.egresses, info.EgressId)
+	}
+	changed := r.updateEgressStateLocked()
+	r.lock.Unlock()
+
+	if changed {
+		r.Logger.Infow("room egress state changed",
+			"egressID", info.EgressId,
+			"status", info.Status,
+			"recording", r.IsRecording(),
+			"streaming", r.IsStreaming(),
+		)
+		r.protoProxy.MarkDirty(true)
+		r.broadcastEgressState()
+	}
+}
+
+// IsRecording returns true when an egress is writing the room's media to a file or segments
+func (r *Room) IsRecording() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, info := range r.egresses {
+		if recording, _ := getEgressOutputs(info); recording {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStreaming returns true when an egress is streaming the room's media to an external endpoint
+func (r *Room) IsStreaming() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, info := range r.egresses {
+		if _, streaming := getEgressOutputs(info); streaming {
+			return true
+		}
+	}
+	return false
+}
+
+// updates ActiveRecording from recorder participants and egresses writing files or segments, and streaming
+// from egresses with stream outputs, returns true if either changed.
+// assumes lock is already acquired
+func (r *Room) updateEgressStateLocked() bool {
+	var recording, streaming bool
+	for _, info := range r.egresses {
+		egressRecording, egressStreaming := getEgressOutputs(info)
+		recording = recording || egressRecording
+		streaming = streaming || egressStreaming
+	}
+	if !recording {
+		for _, p := range r.participants {
+			if p.IsRecorder() {
+				recording = true
+				break
+			}
+		}
+	}
+
+	if r.protoRoom.ActiveRecording == recording && r.streaming == streaming {
+		return false
+	}
+	r.protoRoom.ActiveRecording = recording
+	r.streaming = streaming
+	return true
+}
+
+func (r *Room) getEgressState() *EgressState {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return &EgressState{
+		Recording: r.protoRoom.ActiveRecording,
+		Streaming: r.streaming,
+	}
+}
+
+// sends the egress state to a joining participant, when the room is being recorded or streamed
+func (r *Room) sendEgressStateTo(p types.LocalParticipant) {
+	if state := r.getEgressState(); state.Recording || state.Streaming {
+		r.sendEgressState(state, []livekit.ParticipantIdentity{p.Identity()})
+	}
+}
+
+func (r *Room) broadcastEgressState() {
+	r.sendEgressState(r.getEgressState(), nil)
+}
+
+func (r *Room) sendEgressState(state *EgressState, destinations []livekit.ParticipantIdentity) {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		r.Logger.Errorw("could not marshal egress state", err)
+		return
+	}
+	topic := EgressStateTopic
+	BroadcastDataPacketForRoom(r, nil, &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				Payload:               payload,
+				Topic:                 &topic,
+				DestinationIdentities: livekit.IDsAsStrings(destinations),
+			},
+		},
+	}, r.Logger)
+}
+
+func isEgressActive(info *livekit.EgressInfo) bool {
+	return int32(info.Status) < int32(livekit.EgressStatus_EGRESS_COMPLETE)
+}
+
+// returns whether the egress is recording and/or streaming
+func getEgressOutputs(info *livekit.EgressInfo) (bool, bool) {
+	_, outputType := egress.GetTypes(info.Request)
+	switch outputType {
+	case egress.OutputTypeStream:
+		return false, true
+	case egress.OutputTypeMultiple:
+		var streaming bool
+		switch req := info.Request.(type) {
+		case *livekit.EgressInfo_RoomComposite:
+			streaming = len(req.RoomComposite.StreamOutputs) != 0
+		case *livekit.EgressInfo_Web:
+			streaming = len(req.Web.StreamOutputs) != 0
+		case *livekit.EgressInfo_Participant:
+			streaming = len(req.Participant.StreamOutputs) != 0
+		case *livekit.EgressInfo_TrackComposite:
+			streaming = len(req.TrackComposite.StreamOutputs) != 0
+		}
+		return true, streaming
+	default:
+		// unknown outputs are treated as recording to err on the side of notifying participants
+		return true, false
+	}
+}
+
 func getFilePath(filepath string) string {
 	if filepath == "" || strings.HasSuffix(filepath, "/") || strings.Contains(filepath, "{track_id}") {
 		return filepath
@@ -0,0 +1,72 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	maxParticipantChanges = 1000
+
+	ParticipantChangeFieldName     = "name"
+	ParticipantChangeFieldMetadata = "metadata"
+)
+
+// ParticipantChange records an update to a participant's name or metadata
+type ParticipantChange struct {
+	At       time.Time                   `json:"at"`
+	Identity livekit.ParticipantIdentity `json:"identity"`
+	// participant that made the change, empty when changed through the server API
+	Actor    livekit.ParticipantIdentity `json:"actor,omitempty"`
+	Field    string                      `json:"field"`
+	OldValue string                      `json:"old_value"`
+	NewValue string                      `json:"new_value"`
+}
+
+// GetParticipantChanges returns the changes made to a participant during the session, oldest first.
+// Changes for all participants are returned when identity is empty.
+func (r *Room) GetParticipantChanges(identity livekit.ParticipantIdentity) []ParticipantChange {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var changes []ParticipantChange
+	for _, c := range r.participantChanges {
+		if identity == "" || c.Identity == identity {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+func (r *Room) recordParticipantChanges(changes []ParticipantChange) {
+	for _, c := range changes {
+		// values may hold personal data, they are kept in the history but not logged
+		r.Logger.Debugw("participant updated",
+			"participant", c.Identity,
+			"actor", c.Actor,
+			"field", c.Field,
+		)
+	}
+
+	r.lock.Lock()
+	r.participantChanges = append(r.participantChanges, changes...)
+	if len(r.participantChanges) > maxParticipantChanges {
+		r.participantChanges = r.participantChanges[len(r.participantChanges)-maxParticipantChanges:]
+	}
+	r.lock.Unlock()
+}
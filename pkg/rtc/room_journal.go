@@ -0,0 +1,113 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	JournalEventParticipantJoined            = "participant_joined"
+	JournalEventParticipantLeft              = "participant_left"
+	JournalEventTrackPublished               = "track_published"
+	JournalEventTrackUnpublished             = "track_unpublished"
+	JournalEventParticipantMetadataChanged   = "participant_metadata_changed"
+	JournalEventParticipantAttributesChanged = "participant_attributes_changed"
+	JournalEventRoomMetadataChanged          = "room_metadata_changed"
+)
+
+// JournalEntry is a room event, numbered in the order it happened in the room. Numbering continues across
+// sessions of the room, RoomSID tells them apart
+type JournalEntry struct {
+	Seq           uint64                      `json:"seq"`
+	At            time.Time                   `json:"at"`
+	Type          string                      `json:"type"`
+	RoomSID       livekit.RoomID              `json:"room_sid"`
+	Identity      livekit.ParticipantIdentity `json:"identity,omitempty"`
+	ParticipantID livekit.ParticipantID       `json:"participant_id,omitempty"`
+	TrackID       livekit.TrackID             `json:"track_id,omitempty"`
+	Participant   *livekit.ParticipantInfo    `json:"participant,omitempty"`
+	Track         *livekit.TrackInfo          `json:"track,omitempty"`
+	// new room or participant metadata for metadata changes
+	Metadata string `json:"metadata,omitempty"`
+	// attributes set and deleted by an attributes change
+	Attributes        map[string]string `json:"attributes,omitempty"`
+	DeletedAttributes []string          `json:"deleted_attributes,omitempty"`
+}
+
+// roomJournal numbers room events and hands them to the journal handler, which retains them for backends
+// that missed webhooks. Events are handed over in order.
+type roomJournal struct {
+	lock    sync.Mutex
+	seq     uint64
+	onEntry func(entry JournalEntry)
+}
+
+func (j *roomJournal) append(entry JournalEntry) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	j.seq++
+	entry.Seq = j.seq
+	entry.At = time.Now()
+	if j.onEntry != nil {
+		j.onEntry(entry)
+	}
+}
+
+// OnJournalEntry is called with every room event, in order
+func (r *Room) OnJournalEntry(f func(entry JournalEntry)) {
+	r.journal.lock.Lock()
+	r.journal.onEntry = f
+	r.journal.lock.Unlock()
+}
+
+// ResumeJournal continues the numbering of room events after the last event of an earlier session
+func (r *Room) ResumeJournal(lastSeq uint64) {
+	r.journal.lock.Lock()
+	if lastSeq > r.journal.seq {
+		r.journal.seq = lastSeq
+	}
+	r.journal.lock.Unlock()
+}
+
+func (r *Room) appendJournal(entry JournalEntry) {
+	entry.RoomSID = r.ID()
+	r.journal.append(entry)
+}
+
+func (r *Room) journalParticipantEvent(eventType string, p types.LocalParticipant) {
+	r.appendJournal(JournalEntry{
+		Type:          eventType,
+		Identity:      p.Identity(),
+		ParticipantID: p.ID(),
+		Participant:   p.ToProto(),
+	})
+}
+
+func (r *Room) journalTrackEvent(eventType string, p types.LocalParticipant, track types.MediaTrack) {
+	r.appendJournal(JournalEntry{
+		Type:          eventType,
+		Identity:      p.Identity(),
+		ParticipantID: p.ID(),
+		TrackID:       track.ID(),
+		Track:         track.ToProto(),
+	})
+}
@@ -15,14 +15,20 @@
 package rtc
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/pion/webrtc/v3"
+	promclient "github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/livekit/livekit-server/version"
+	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/webhook"
 
@@ -120,6 +126,48 @@ func TestRoomJoin(t *testing.T) {
 		require.Equal(t, numTracks, p.SubscribeToTrackCallCount())
 	})
 
+	t.Run("single negotiation subscribes to publisher before first offer", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
+		pub := rm.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+		p := newMockParticipant("viewer", types.CurrentProtocol, false, false)
+		p.SubscriberAsPrimaryReturns(true)
+
+		err := rm.Join(p, nil, &ParticipantOptions{
+			AutoSubscribe:          true,
+			AutoSubscribePublisher: pub.Identity(),
+			SingleNegotiation:      true,
+		}, iceServersForRoom)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool { return p.NegotiateCallCount() == 1 }, time.Second, 10*time.Millisecond)
+		require.Equal(t, 1, p.WaitUntilSubscribedCallCount())
+		require.Equal(t, len(pub.GetPublishedTracks()), p.SubscribeToTrackCallCount())
+
+		// tracks published later are subscribed too, they are sent on spare transceivers
+		p.StateReturns(livekit.ParticipantInfo_ACTIVE)
+		trackCB := pub.OnTrackPublishedArgsForCall(0)
+		trackCB(pub, newMockTrack(livekit.TrackType_VIDEO, "webcam"))
+		require.Equal(t, len(pub.GetPublishedTracks())+1, p.SubscribeToTrackCallCount())
+	})
+
+	t.Run("single negotiation answers the client's offer instead of offering", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		p := newMockParticipant("viewer", types.CurrentProtocol, false, false)
+		p.SubscriberAsPrimaryReturns(true)
+
+		offer := webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: "v=0"}
+		err := rm.Join(p, nil, &ParticipantOptions{
+			AutoSubscribe:     true,
+			SingleNegotiation: true,
+			SubscriberOffer:   &offer,
+		}, iceServersForRoom)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool { return p.HandleSubscriberOfferCallCount() == 1 }, time.Second, 10*time.Millisecond)
+		require.Equal(t, offer, p.HandleSubscriberOfferArgsForCall(0))
+		require.Zero(t, p.NegotiateCallCount())
+	})
+
 	t.Run("participant state change is broadcasted to others", func(t *testing.T) {
 		rm := newRoomWithParticipants(t, testRoomOpts{num: numParticipants})
 		var changedParticipant types.Participant
@@ -159,6 +207,146 @@ func TestRoomJoin(t *testing.T) {
 		err := rm.Join(p, nil, nil, iceServersForRoom)
 		require.Equal(t, ErrMaxParticipantsExceeded, err)
 	})
+
+	t.Run("connection is counted once participant is active", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 0})
+		p := newMockParticipant("new", types.CurrentProtocol, false, false)
+		p.GetICEConnectionTypeReturns(types.ICEConnectionTypeTCP)
+		p.GetICEAddressFamilyReturns(types.ICEAddressFamilyIPv6)
+		before := participantConnectionCount(t, types.ICEConnectionTypeTCP, types.ICEAddressFamilyIPv6)
+
+		require.NoError(t, rm.Join(p, nil, nil, iceServersForRoom))
+		require.Equal(t, before, participantConnectionCount(t, types.ICEConnectionTypeTCP, types.ICEAddressFamilyIPv6))
+
+		stateChangeCB := p.OnStateChangeArgsForCall(0)
+		p.StateReturns(livekit.ParticipantInfo_ACTIVE)
+		stateChangeCB(p, livekit.ParticipantInfo_JOINED)
+		require.Equal(t, before+1, participantConnectionCount(t, types.ICEConnectionTypeTCP, types.ICEAddressFamilyIPv6))
+	})
+
+	t.Run("connection is not counted when participant does not connect", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		rm.lock.Lock()
+		rm.protoRoom.MaxParticipants = 2
+		rm.lock.Unlock()
+		before := participantConnectionCount(t, types.ICEConnectionTypeTURN, types.ICEAddressFamilyIPv4)
+
+		// fails to connect after joining
+		p := newMockParticipant("second", types.CurrentProtocol, false, false)
+		p.GetICEConnectionTypeReturns(types.ICEConnectionTypeTURN)
+		p.GetICEAddressFamilyReturns(types.ICEAddressFamilyIPv4)
+		require.NoError(t, rm.Join(p, nil, nil, iceServersForRoom))
+		stateChangeCB := p.OnStateChangeArgsForCall(0)
+		p.StateReturns(livekit.ParticipantInfo_DISCONNECTED)
+		stateChangeCB(p, livekit.ParticipantInfo_JOINED)
+
+		// rejected by the room
+		rejected := newMockParticipant("third", types.CurrentProtocol, false, false)
+		rejected.GetICEConnectionTypeReturns(types.ICEConnectionTypeTURN)
+		rejected.GetICEAddressFamilyReturns(types.ICEAddressFamilyIPv4)
+		require.Equal(t, ErrMaxParticipantsExceeded, rm.Join(rejected, nil, nil, iceServersForRoom))
+		require.Equal(t, 0, rejected.OnStateChangeCallCount())
+
+		require.Equal(t, before, participantConnectionCount(t, types.ICEConnectionTypeTURN, types.ICEAddressFamilyIPv4))
+	})
+}
+
+// participantConnectionCount reads livekit_participant_connection_counter from the default registry, which is
+// shared by all tests, so tests compare against the count they started with
+func participantConnectionCount(t *testing.T, connectionType types.ICEConnectionType, family types.ICEAddressFamily) float64 {
+	families, err := promclient.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, mf := range families {
+		if mf.GetName() != "livekit_participant_connection_counter" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["type"] == string(connectionType) && labels["family"] == string(family) {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestRoomModeration(t *testing.T) {
+	t.Run("locked room rejects new participants", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		rm.SetLocked(true)
+
+		err := rm.Join(newMockParticipant("new", types.CurrentProtocol, false, false), nil, nil, iceServersForRoom)
+		require.Equal(t, ErrRoomLocked, err)
+
+		recorder := newMockParticipant("recorder", types.CurrentProtocol, true, false)
+		recorder.IsRecorderReturns(true)
+		require.NoError(t, rm.Join(recorder, nil, nil, iceServersForRoom))
+
+		rm.SetLocked(false)
+		require.NoError(t, rm.Join(newMockParticipant("new", types.CurrentProtocol, false, false), nil, nil, iceServersForRoom))
+	})
+
+	t.Run("mute all except", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
+		for _, p := range rm.GetParticipants() {
+			track := &typesfakes.FakeMediaTrack{}
+			track.IDReturns(livekit.TrackID("track-" + p.Identity()))
+			track.SourceReturns(livekit.TrackSource_MICROPHONE)
+			p.(*typesfakes.FakeLocalParticipant).GetPublishedTracksReturns([]types.MediaTrack{track})
+		}
+
+		muted := rm.MuteAllExcept([]livekit.ParticipantIdentity{"p0"}, nil, false)
+		require.ElementsMatch(t, []livekit.TrackID{"track-p1", "track-p2"}, muted)
+		require.Equal(t, 0, rm.GetParticipant("p0").(*typesfakes.FakeLocalParticipant).SetTrackMutedCallCount())
+		require.Equal(t, 1, rm.GetParticipant("p1").(*typesfakes.FakeLocalParticipant).SetTrackMutedCallCount())
+		require.False(t, rm.IsLocked())
+
+		muted = rm.MuteAllExcept(nil, []livekit.TrackSource{livekit.TrackSource_CAMERA}, true)
+		require.Empty(t, muted)
+		require.True(t, rm.IsLocked())
+	})
+
+	t.Run("mute all mutes tracks published later", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		rm.MuteAllExcept([]livekit.ParticipantIdentity{"p0"}, []livekit.TrackSource{livekit.TrackSource_MICROPHONE}, false)
+
+		publish := func(identity livekit.ParticipantIdentity, source livekit.TrackSource) *typesfakes.FakeLocalParticipant {
+			p := rm.GetParticipant(identity).(*typesfakes.FakeLocalParticipant)
+			track := &typesfakes.FakeMediaTrack{}
+			track.IDReturns(livekit.TrackID("track-" + string(identity) + "-" + source.String()))
+			track.SourceReturns(source)
+			rm.onTrackPublished(p, track)
+			return p
+		}
+
+		p1 := publish("p1", livekit.TrackSource_MICROPHONE)
+		require.Equal(t, 1, p1.SetTrackMutedCallCount())
+		trackID, muted, fromAdmin := p1.SetTrackMutedArgsForCall(0)
+		require.Equal(t, livekit.TrackID("track-p1-MICROPHONE"), trackID)
+		require.True(t, muted)
+		require.True(t, fromAdmin)
+
+		// other sources and excepted participants are not muted
+		require.Equal(t, 1, publish("p1", livekit.TrackSource_CAMERA).SetTrackMutedCallCount())
+		require.Equal(t, 0, publish("p0", livekit.TrackSource_MICROPHONE).SetTrackMutedCallCount())
+
+		rm.ClearMuteAll()
+		require.Equal(t, 1, publish("p1", livekit.TrackSource_MICROPHONE).SetTrackMutedCallCount())
+	})
+
+	t.Run("remove participants matching", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
+
+		removed := rm.RemoveParticipantsMatching(func(p types.LocalParticipant) bool {
+			return p.Identity() != "p0"
+		}, types.ParticipantCloseReasonServiceRequestRemoveParticipant, true)
+		require.ElementsMatch(t, []livekit.ParticipantIdentity{"p1", "p2"}, removed)
+		require.Len(t, rm.GetParticipants(), 1)
+		require.True(t, rm.IsLocked())
+	})
 }
 
 // various state changes to participant and that others are receiving update
@@ -219,6 +407,251 @@ func TestParticipantUpdate(t *testing.T) {
 	}
 }
 
+func TestParticipantChanges(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+	ts := &telemetryfakes.FakeTelemetryService{}
+	rm.telemetry = ts
+	participants := rm.GetParticipants()
+	p0, p1 := participants[0], participants[1]
+	p0.(*typesfakes.FakeLocalParticipant).ToProtoReturns(&livekit.ParticipantInfo{
+		Identity: string(p0.Identity()),
+		Name:     "old name",
+		Metadata: "old",
+	})
+
+	require.NoError(t, rm.UpdateParticipantMetadata(p0, "new name", "new", p0.Identity()))
+	require.NoError(t, rm.UpdateParticipantMetadata(p1, "", "from api", ""))
+	// no-op when nothing changes
+	require.NoError(t, rm.UpdateParticipantMetadata(p0, "old name", "old", p0.Identity()))
+
+	changes := rm.GetParticipantChanges(p0.Identity())
+	require.Len(t, changes, 2)
+	require.Equal(t, ParticipantChangeFieldMetadata, changes[0].Field)
+	require.Equal(t, "old", changes[0].OldValue)
+	require.Equal(t, "new", changes[0].NewValue)
+	require.Equal(t, p0.Identity(), changes[0].Actor)
+	require.Equal(t, ParticipantChangeFieldName, changes[1].Field)
+	require.Equal(t, "old name", changes[1].OldValue)
+	require.Equal(t, "new name", changes[1].NewValue)
+
+	changes = rm.GetParticipantChanges(p1.Identity())
+	require.Len(t, changes, 1)
+	require.Equal(t, "", changes[0].OldValue)
+	require.Equal(t, "from api", changes[0].NewValue)
+	require.Empty(t, changes[0].Actor)
+
+	require.Len(t, rm.GetParticipantChanges(""), 3)
+
+	// telemetry gets the previous values and the actor
+	require.Equal(t, 2, ts.ParticipantMetadataUpdatedCallCount())
+	_, _, _, metadataChanges := ts.ParticipantMetadataUpdatedArgsForCall(0)
+	require.Equal(t, []telemetry.ParticipantMetadataChange{
+		{Field: ParticipantChangeFieldMetadata, OldValue: "old", NewValue: "new", Actor: p0.Identity()},
+		{Field: ParticipantChangeFieldName, OldValue: "old name", NewValue: "new name", Actor: p0.Identity()},
+	}, metadataChanges)
+	_, _, _, metadataChanges = ts.ParticipantMetadataUpdatedArgsForCall(1)
+	require.Equal(t, []telemetry.ParticipantMetadataChange{
+		{Field: ParticipantChangeFieldMetadata, NewValue: "from api"},
+	}, metadataChanges)
+}
+
+func TestParticipantMetadataSchema(t *testing.T) {
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaFile, []byte(`{
+		"type": "object",
+		"properties": {"topic": {"type": "string"}}
+	}`), 0644))
+	validator, err := NewMetadataValidator(config.MetadataSchemaConfig{ParticipantSchemaFile: schemaFile})
+	require.NoError(t, err)
+
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+	rm.metadataValidator = validator
+	p0 := rm.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+	p0.ToProtoReturns(&livekit.ParticipantInfo{Identity: string(p0.Identity())})
+
+	// nothing is updated when the metadata is rejected
+	err = rm.UpdateParticipantMetadata(p0, "new name", `{"topic": 1}`, p0.Identity())
+	require.ErrorIs(t, err, ErrInvalidMetadata)
+	require.Zero(t, p0.SetMetadataCallCount())
+	require.Zero(t, p0.SetNameCallCount())
+	require.Empty(t, rm.GetParticipantChanges(p0.Identity()))
+
+	require.NoError(t, rm.UpdateParticipantMetadata(p0, "new name", `{"topic": "ok"}`, p0.Identity()))
+	require.Equal(t, 1, p0.SetMetadataCallCount())
+	require.Equal(t, 1, p0.SetNameCallCount())
+
+	t.Run("participant is told its update was rejected", func(t *testing.T) {
+		room := &typesfakes.FakeRoom{}
+		room.UpdateParticipantMetadataReturns(ErrInvalidMetadata)
+		p := newMockParticipant("p", types.CurrentProtocol, false, false)
+		grant := &auth.VideoGrant{}
+		grant.SetCanUpdateOwnMetadata(true)
+		p.ClaimGrantsReturns(&auth.ClaimGrants{Video: grant})
+
+		require.NoError(t, HandleParticipantSignal(room, p, &livekit.SignalRequest{
+			Message: &livekit.SignalRequest_UpdateMetadata{
+				UpdateMetadata: &livekit.UpdateParticipantMetadata{Metadata: `{"topic": 1}`},
+			},
+		}, rm.Logger))
+		require.Equal(t, 1, p.SendDataPacketCallCount())
+		dp, _ := p.SendDataPacketArgsForCall(0)
+		require.Equal(t, MetadataRejectedTopic, dp.GetUser().GetTopic())
+		var rejected MetadataRejected
+		require.NoError(t, json.Unmarshal(dp.GetUser().GetPayload(), &rejected))
+		require.Equal(t, ErrInvalidMetadata.Error(), rejected.Error)
+	})
+}
+
+func TestRoomJournal(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+	participants := rm.GetParticipants()
+	p0, p1 := participants[0], participants[1]
+	p0.(*typesfakes.FakeLocalParticipant).ToProtoReturns(&livekit.ParticipantInfo{
+		Identity: string(p0.Identity()),
+		Metadata: "old",
+	})
+
+	var entries []JournalEntry
+	rm.OnJournalEntry(func(entry JournalEntry) {
+		entries = append(entries, entry)
+	})
+	// numbering continues from an earlier session
+	rm.ResumeJournal(10)
+
+	rm.SetMetadata("room")
+	require.NoError(t, rm.UpdateParticipantMetadata(p0, "", "new", p0.Identity()))
+	require.NoError(t, rm.UpdateParticipantAttributes(p0, map[string]string{"role": "host"}, nil, nil))
+	rm.RemoveParticipant(p1.Identity(), p1.ID(), types.ParticipantCloseReasonClientRequestLeave)
+
+	require.Len(t, entries, 4)
+	for i, entry := range entries {
+		require.Equal(t, uint64(11+i), entry.Seq)
+		require.Equal(t, rm.ID(), entry.RoomSID)
+	}
+	require.Equal(t, JournalEventRoomMetadataChanged, entries[0].Type)
+	require.Equal(t, "room", entries[0].Metadata)
+	require.Equal(t, JournalEventParticipantMetadataChanged, entries[1].Type)
+	require.Equal(t, p0.Identity(), entries[1].Identity)
+	require.Equal(t, JournalEventParticipantAttributesChanged, entries[2].Type)
+	require.Equal(t, map[string]string{"role": "host"}, entries[2].Attributes)
+	require.Equal(t, JournalEventParticipantLeft, entries[3].Type)
+	require.Equal(t, p1.Identity(), entries[3].Identity)
+
+	// unchanged attributes are not journaled
+	require.NoError(t, rm.UpdateParticipantAttributes(p0, map[string]string{"role": "host"}, nil, nil))
+	require.Len(t, entries, 4)
+}
+
+func TestParticipantAttributes(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
+	defer rm.Close()
+	rm.SetAttributePermissions(NewAttributePermissions(config.ParticipantAttributesConfig{
+		Permissions: map[string]string{
+			"role":    "moderator",
+			"agent.*": "server",
+		},
+		MaxKeys:      4,
+		MaxKeySize:   16,
+		MaxValueSize: 8,
+	}))
+	participants := rm.GetParticipants()
+	p0 := participants[0].(*typesfakes.FakeLocalParticipant)
+	p1 := participants[1].(*typesfakes.FakeLocalParticipant)
+	p2 := participants[2].(*typesfakes.FakeLocalParticipant)
+	p2.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true}})
+
+	sendUpdate := func(source *typesfakes.FakeLocalParticipant, update AttributesUpdate) {
+		payload, err := json.Marshal(update)
+		require.NoError(t, err)
+		topic := AttributesUpdateTopic
+		source.OnDataPacketArgsForCall(0)(source, &livekit.DataPacket{
+			Kind: livekit.DataPacket_RELIABLE,
+			Value: &livekit.DataPacket_User{
+				User: &livekit.UserPacket{Payload: payload, Topic: &topic},
+			},
+		})
+	}
+	lastChange := func(p *typesfakes.FakeLocalParticipant) AttributesChanged {
+		require.NotZero(t, p.SendDataPacketCallCount())
+		dp, _ := p.SendDataPacketArgsForCall(p.SendDataPacketCallCount() - 1)
+		require.Equal(t, AttributesChangedTopic, dp.GetUser().GetTopic())
+		var changed AttributesChanged
+		require.NoError(t, json.Unmarshal(dp.GetUser().GetPayload(), &changed))
+		return changed
+	}
+
+	// participants write their own attributes, the update is not forwarded and only the delta is sent
+	sendUpdate(p0, AttributesUpdate{Set: map[string]string{"hand": "raised", "mood": "happy"}})
+	require.Equal(t, map[string]string{"hand": "raised", "mood": "happy"}, rm.GetParticipantAttributes(p0.Identity()))
+	sendUpdate(p0, AttributesUpdate{Set: map[string]string{"mood": "happy"}, Delete: []string{"hand"}})
+	require.Equal(t, map[string]string{"mood": "happy"}, rm.GetParticipantAttributes(p0.Identity()))
+	require.Equal(t, 2, p1.SendDataPacketCallCount())
+	changed := lastChange(p1)
+	require.Equal(t, string(p0.Identity()), changed.ParticipantIdentity)
+	require.Empty(t, changed.Set)
+	require.Equal(t, []string{"hand"}, changed.Delete)
+
+	// moderator keys are rejected for the participant itself and other participants' keys are off limits
+	sendUpdate(p0, AttributesUpdate{Set: map[string]string{"role": "host"}})
+	sendUpdate(p1, AttributesUpdate{Identity: string(p0.Identity()), Set: map[string]string{"mood": "sad"}})
+	require.Equal(t, map[string]string{"mood": "happy"}, rm.GetParticipantAttributes(p0.Identity()))
+	require.Equal(t, 2, p1.SendDataPacketCallCount())
+
+	// moderators write moderator and self keys of others, but not server keys
+	sendUpdate(p2, AttributesUpdate{Identity: string(p0.Identity()), Set: map[string]string{"role": "host"}})
+	require.Equal(t, "host", rm.GetParticipantAttributes(p0.Identity())["role"])
+	require.ErrorIs(t, rm.UpdateParticipantAttributes(p0, map[string]string{"agent.topic": "x"}, nil, p2), ErrAttributePermissionDenied)
+
+	// the server API writes any key
+	require.NoError(t, rm.UpdateParticipantAttributes(p0, map[string]string{"agent.topic": "x"}, nil, nil))
+	require.ErrorIs(t, rm.UpdateParticipantAttributes(p0, map[string]string{"": "x"}, nil, nil), ErrEmptyAttributeKey)
+	require.Equal(t, map[string]string{"agent.topic": "x"}, lastChange(p1).Set)
+
+	// participants cannot forge attribute changes
+	numSent := p1.SendDataPacketCallCount()
+	changedTopic := AttributesChangedTopic
+	p2.OnDataPacketArgsForCall(0)(p2, &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{Payload: []byte(`{"participant_identity":"p0","set":{"role":"host"}}`), Topic: &changedTopic},
+		},
+	})
+	require.Equal(t, numSent, p1.SendDataPacketCallCount())
+
+	// updates exceeding the limits are rejected as a whole, deleted keys make room for new ones
+	require.ErrorIs(t, rm.UpdateParticipantAttributes(p0, map[string]string{"mood": "ecstatic!"}, nil, nil), ErrAttributesExceedLimits)
+	require.ErrorIs(t, rm.UpdateParticipantAttributes(p0, map[string]string{"a.very.long.key.x": "x"}, nil, nil), ErrAttributesExceedLimits)
+	require.ErrorIs(t, rm.UpdateParticipantAttributes(p0, map[string]string{"a": "x", "b": "x"}, nil, nil), ErrAttributesExceedLimits)
+	require.NoError(t, rm.UpdateParticipantAttributes(p0, map[string]string{"a": "x", "b": "x"}, []string{"mood"}, nil))
+	require.Len(t, rm.GetParticipantAttributes(p0.Identity()), 4)
+
+	// attributes are dropped with the participant
+	rm.RemoveParticipant(p0.Identity(), p0.ID(), types.ParticipantCloseReasonClientRequestLeave)
+	require.Empty(t, rm.GetParticipantAttributes(p0.Identity()))
+}
+
+func TestAudioOnlyRequest(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+	defer rm.Close()
+	participants := rm.GetParticipants()
+	p0 := participants[0].(*typesfakes.FakeLocalParticipant)
+	p1 := participants[1].(*typesfakes.FakeLocalParticipant)
+
+	topic := AudioOnlyTopic
+	p0.OnDataPacketArgsForCall(0)(p0, &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{Payload: []byte(`{"audio_only":true}`), Topic: &topic},
+		},
+	})
+
+	// the request applies to the sender and is not forwarded
+	require.Equal(t, 1, p0.SetAudioOnlyCallCount())
+	require.True(t, p0.SetAudioOnlyArgsForCall(0))
+	require.Zero(t, p1.SetAudioOnlyCallCount())
+	require.Zero(t, p1.SendDataPacketCallCount())
+}
+
 func TestPushAndDequeueUpdates(t *testing.T) {
 	identity := "test_user"
 	publisher1v1 := &livekit.ParticipantInfo{
@@ -713,6 +1146,84 @@ func TestRoomUpdate(t *testing.T) {
 			require.GreaterOrEqual(t, fp.SendRoomUpdateCallCount(), 1)
 		}
 	})
+
+	t.Run("participants should receive recording state from egress", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		defer rm.Close()
+
+		p1 := rm.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+		lastEgressState := func() EgressState {
+			require.NotZero(t, p1.SendDataPacketCallCount())
+			dp, _ := p1.SendDataPacketArgsForCall(p1.SendDataPacketCallCount() - 1)
+			require.Equal(t, EgressStateTopic, dp.GetUser().GetTopic())
+			var state EgressState
+			require.NoError(t, json.Unmarshal(dp.GetUser().GetPayload(), &state))
+			return state
+		}
+
+		// streaming is not recording
+		stream := &livekit.EgressInfo{
+			EgressId: "EG_stream",
+			Status:   livekit.EgressStatus_EGRESS_ACTIVE,
+			Request: &livekit.EgressInfo_RoomComposite{
+				RoomComposite: &livekit.RoomCompositeEgressRequest{
+					StreamOutputs: []*livekit.StreamOutput{{Urls: []string{"rtmp://localhost/live"}}},
+				},
+			},
+		}
+		rm.UpdateEgressState(stream)
+		require.True(t, rm.IsStreaming())
+		require.False(t, rm.IsRecording())
+		require.Equal(t, EgressState{Streaming: true}, lastEgressState())
+
+		time.Sleep(2 * defaultDelay)
+		require.False(t, p1.SendRoomUpdateArgsForCall(p1.SendRoomUpdateCallCount()-1).ActiveRecording)
+
+		// file outputs are recording
+		file := &livekit.EgressInfo{
+			EgressId: "EG_file",
+			Status:   livekit.EgressStatus_EGRESS_ACTIVE,
+			Request: &livekit.EgressInfo_RoomComposite{
+				RoomComposite: &livekit.RoomCompositeEgressRequest{
+					FileOutputs: []*livekit.EncodedFileOutput{{Filepath: "room.mp4"}},
+				},
+			},
+		}
+		rm.UpdateEgressState(file)
+		require.True(t, rm.IsRecording())
+		require.Equal(t, EgressState{Recording: true, Streaming: true}, lastEgressState())
+
+		time.Sleep(2 * defaultDelay)
+		require.True(t, p1.SendRoomUpdateArgsForCall(p1.SendRoomUpdateCallCount()-1).ActiveRecording)
+
+		for _, info := range []*livekit.EgressInfo{stream, file} {
+			info = proto.Clone(info).(*livekit.EgressInfo)
+			info.Status = livekit.EgressStatus_EGRESS_COMPLETE
+			rm.UpdateEgressState(info)
+		}
+		require.False(t, rm.IsStreaming())
+		require.False(t, rm.IsRecording())
+		require.Equal(t, EgressState{}, lastEgressState())
+
+		time.Sleep(2 * defaultDelay)
+		require.False(t, p1.SendRoomUpdateArgsForCall(p1.SendRoomUpdateCallCount()-1).ActiveRecording)
+	})
+
+	t.Run("participants cannot send egress state", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close()
+
+		p0 := rm.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+		p1 := rm.GetParticipants()[1].(*typesfakes.FakeLocalParticipant)
+		topic := EgressStateTopic
+		p0.OnDataPacketArgsForCall(0)(p0, &livekit.DataPacket{
+			Kind: livekit.DataPacket_RELIABLE,
+			Value: &livekit.DataPacket_User{
+				User: &livekit.UserPacket{Payload: []byte(`{"recording":true}`), Topic: &topic},
+			},
+		})
+		require.Zero(t, p1.SendDataPacketCallCount())
+	})
 }
 
 type testRoomOpts struct {
@@ -740,6 +1251,7 @@ func newRoomWithParticipants(t *testing.T, opts testRoomOpts) *Room {
 		},
 		telemetry.NewTelemetryService(webhook.NewDefaultNotifier("", "", nil), &telemetryfakes.FakeAnalyticsService{}),
 		nil,
+		nil,
 	)
 	for i := 0; i < opts.num+opts.numHidden; i++ {
 		identity := livekit.ParticipantIdentity(fmt.Sprintf("p%d", i))
@@ -0,0 +1,139 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"fmt"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+type RoomBudgetState int32
+
+const (
+	RoomBudgetStateOK RoomBudgetState = iota
+	RoomBudgetStateWarning
+	RoomBudgetStateExceeded
+)
+
+func (s RoomBudgetState) String() string {
+	switch s {
+	case RoomBudgetStateOK:
+		return "OK"
+	case RoomBudgetStateWarning:
+		return "WARNING"
+	case RoomBudgetStateExceeded:
+		return "EXCEEDED"
+	default:
+		return fmt.Sprintf("%d", int(s))
+	}
+}
+
+// RoomUsage is the approximate share of the node used by a room
+type RoomUsage struct {
+	// packet buffers of published tracks and history of down tracks
+	MemoryBytes int64
+	// media packets forwarded by the current down tracks
+	ForwardedPackets uint64
+	// CPU cores, attributed by the caller from the room's share of forwarded packets
+	CPU float64
+}
+
+// EvaluateRoomBudget returns the state of a room using the given resources
+func EvaluateRoomBudget(conf config.RoomBudgetConfig, usage RoomUsage) RoomBudgetState {
+	state := RoomBudgetStateOK
+	check := func(used float64, budget float64) {
+		if budget <= 0 {
+			return
+		}
+		switch {
+		case used >= budget:
+			state = RoomBudgetStateExceeded
+		case conf.WarningThreshold > 0 && used >= budget*conf.WarningThreshold && state < RoomBudgetStateWarning:
+			state = RoomBudgetStateWarning
+		}
+	}
+	check(float64(usage.MemoryBytes), float64(conf.MemoryBytes))
+	check(usage.CPU, conf.CPU)
+	return state
+}
+
+// Usage walks the tracks of the room to approximate its memory and the packets it has forwarded
+func (r *Room) Usage() RoomUsage {
+	var usage RoomUsage
+	for _, p := range r.GetParticipants() {
+		for _, track := range p.GetPublishedTracks() {
+			for _, receiver := range track.Receivers() {
+				if wr, ok := receiver.(*sfu.WebRTCReceiver); ok {
+					usage.MemoryBytes += int64(wr.MemoryUsage())
+				}
+			}
+		}
+		for _, st := range p.GetSubscribedTracks() {
+			if dt := st.DownTrack(); dt != nil {
+				usage.MemoryBytes += int64(dt.MemoryUsage())
+				usage.ForwardedPackets += dt.ForwardedPackets()
+			}
+		}
+	}
+	return usage
+}
+
+// UpdateBudget evaluates the room's usage against its budget and applies the configured actions while
+// it is exceeded. Video caps are reapplied on every update so that new subscriptions are limited too
+func (r *Room) UpdateBudget(conf config.RoomBudgetConfig, usage RoomUsage) RoomBudgetState {
+	state := EvaluateRoomBudget(conf, usage)
+	prev := RoomBudgetState(r.budgetState.Swap(int32(state)))
+
+	exceeded := state == RoomBudgetStateExceeded
+	r.refuseTracks.Store(exceeded && conf.RefuseTracks)
+	capVideo := exceeded && conf.DegradeVideo
+	if capVideo || r.capVideo.Swap(capVideo) {
+		for _, p := range r.GetParticipants() {
+			for _, st := range p.GetSubscribedTracks() {
+				if subTrack, ok := st.(*SubscribedTrack); ok {
+					subTrack.SetVideoCapped(capVideo)
+				}
+			}
+		}
+	}
+
+	if state != prev {
+		values := []interface{}{
+			"state", state,
+			"previousState", prev,
+			"memoryBytes", usage.MemoryBytes,
+			"memoryBudget", conf.MemoryBytes,
+			"cpu", usage.CPU,
+			"cpuBudget", conf.CPU,
+		}
+		if state == RoomBudgetStateOK {
+			r.Logger.Infow("room back within resource budget", values...)
+		} else {
+			r.Logger.Warnw("room approaching or over resource budget", nil, append(values, "refuseTracks", r.refuseTracks.Load(), "degradeVideo", capVideo)...)
+		}
+	}
+	return state
+}
+
+// RefusesNewTracks is true while the room is over budget and configured to refuse tracks
+func (r *Room) RefusesNewTracks() bool {
+	return r.refuseTracks.Load()
+}
+
+func (r *Room) BudgetState() RoomBudgetState {
+	return RoomBudgetState(r.budgetState.Load())
+}
@@ -0,0 +1,45 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestEvaluateRoomBudget(t *testing.T) {
+	conf := config.RoomBudgetConfig{
+		MemoryBytes:      1000,
+		CPU:              2,
+		WarningThreshold: 0.8,
+	}
+
+	require.Equal(t, RoomBudgetStateOK, EvaluateRoomBudget(conf, RoomUsage{MemoryBytes: 500, CPU: 1}))
+	require.Equal(t, RoomBudgetStateWarning, EvaluateRoomBudget(conf, RoomUsage{MemoryBytes: 800, CPU: 1}))
+	require.Equal(t, RoomBudgetStateWarning, EvaluateRoomBudget(conf, RoomUsage{MemoryBytes: 500, CPU: 1.8}))
+	require.Equal(t, RoomBudgetStateExceeded, EvaluateRoomBudget(conf, RoomUsage{MemoryBytes: 1000, CPU: 1.8}))
+	require.Equal(t, RoomBudgetStateExceeded, EvaluateRoomBudget(conf, RoomUsage{MemoryBytes: 900, CPU: 3}))
+
+	// disabled limits are never exceeded
+	conf.CPU = 0
+	require.Equal(t, RoomBudgetStateOK, EvaluateRoomBudget(conf, RoomUsage{MemoryBytes: 500, CPU: 100}))
+
+	// no warnings without a threshold
+	conf.WarningThreshold = 0
+	require.Equal(t, RoomBudgetStateOK, EvaluateRoomBudget(conf, RoomUsage{MemoryBytes: 999}))
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// PublishConstraintsTopic is the topic of messages telling a publisher how to encode a screen share,
+// they are sent when the room has a screen share policy
+const PublishConstraintsTopic = "lk.publish_constraints"
+
+const (
+	degradationPreferenceMaintainFramerate  = "maintain-framerate"
+	degradationPreferenceMaintainResolution = "maintain-resolution"
+)
+
+// PublishConstraints is the payload of messages on PublishConstraintsTopic
+type PublishConstraints struct {
+	TrackSid  string `json:"track_sid"`
+	Simulcast bool   `json:"simulcast"`
+	// number of layers to send, the highest ones. 0 for all layers
+	MaxLayers int32 `json:"max_layers,omitempty"`
+	// content hint to set on the track, motion, detail or text
+	ContentHint string `json:"content_hint,omitempty"`
+	// degradation preference of the sender, maintain-framerate or maintain-resolution
+	DegradationPreference string `json:"degradation_preference,omitempty"`
+}
+
+func (r *Room) SetScreenSharePolicy(policy config.ScreenSharePolicy) {
+	r.lock.Lock()
+	r.screenSharePolicy = policy
+	r.lock.Unlock()
+}
+
+// ScreenSharePolicy returns the publish constraints for screen shares, set when the room is created
+func (r *Room) ScreenSharePolicy() config.ScreenSharePolicy {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.screenSharePolicy
+}
+
+// returns the constraints for a screen share, nil when the policy has none
+func publishConstraintsForTrack(policy config.ScreenSharePolicy, ti *livekit.TrackInfo) *PublishConstraints {
+	if policy == (config.ScreenSharePolicy{}) || ti.Type != livekit.TrackType_VIDEO || ti.Source != livekit.TrackSource_SCREEN_SHARE {
+		return nil
+	}
+
+	constraints := &PublishConstraints{
+		TrackSid:    ti.Sid,
+		Simulcast:   !policy.DisableSimulcast,
+		MaxLayers:   maxScreenShareLayers(policy),
+		ContentHint: policy.ContentHint,
+	}
+	switch policy.ContentHint {
+	case config.ScreenShareContentHintMotion:
+		constraints.DegradationPreference = degradationPreferenceMaintainFramerate
+	case config.ScreenShareContentHintDetail, config.ScreenShareContentHintText:
+		constraints.DegradationPreference = degradationPreferenceMaintainResolution
+	}
+	return constraints
+}
+
+func maxScreenShareLayers(policy config.ScreenSharePolicy) int32 {
+	if policy.DisableSimulcast {
+		return 1
+	}
+	return policy.MaxLayers
+}
+
+// constrainSubscribedQualities keeps the qualities publishers are asked to send within the highest maxLayers.
+// When subscribers only want lower qualities, the lowest allowed one is sent instead.
+// qualities are ordered from LOW to HIGH
+func constrainSubscribedQualities(qualities []*livekit.SubscribedQuality, maxLayers int32) {
+	if maxLayers <= 0 || int(maxLayers) >= len(qualities) {
+		return
+	}
+
+	lowestAllowed := len(qualities) - int(maxLayers)
+	anyEnabled := false
+	for i, q := range qualities {
+		if i < lowestAllowed && q.Enabled {
+			anyEnabled = true
+			q.Enabled = false
+		}
+	}
+	if anyEnabled {
+		qualities[lowestAllowed].Enabled = true
+	}
+}
+
+func (p *ParticipantImpl) sendPublishConstraints(constraints *PublishConstraints) {
+	payload, err := json.Marshal(constraints)
+	if err != nil {
+		return
+	}
+	topic := PublishConstraintsTopic
+	dp := &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				Payload: payload,
+				Topic:   &topic,
+			},
+		},
+	}
+	data, err := proto.Marshal(dp)
+	if err != nil {
+		return
+	}
+	if err := p.SendDataPacket(dp, data); err != nil {
+		p.pubLogger.Debugw("could not send publish constraints", "error", err)
+	}
+}
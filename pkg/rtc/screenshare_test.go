@@ -0,0 +1,120 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestPublishConstraints(t *testing.T) {
+	screenShare := &livekit.TrackInfo{Sid: "TR_screen", Type: livekit.TrackType_VIDEO, Source: livekit.TrackSource_SCREEN_SHARE}
+
+	require.Nil(t, publishConstraintsForTrack(config.ScreenSharePolicy{}, screenShare))
+	require.Nil(t, publishConstraintsForTrack(config.ScreenSharePolicy{DisableSimulcast: true}, &livekit.TrackInfo{
+		Type:   livekit.TrackType_VIDEO,
+		Source: livekit.TrackSource_CAMERA,
+	}))
+
+	require.Equal(t, &PublishConstraints{
+		TrackSid:              "TR_screen",
+		Simulcast:             false,
+		MaxLayers:             1,
+		ContentHint:           config.ScreenShareContentHintText,
+		DegradationPreference: degradationPreferenceMaintainResolution,
+	}, publishConstraintsForTrack(config.ScreenSharePolicy{DisableSimulcast: true, ContentHint: config.ScreenShareContentHintText}, screenShare))
+
+	require.Equal(t, &PublishConstraints{
+		TrackSid:              "TR_screen",
+		Simulcast:             true,
+		MaxLayers:             2,
+		ContentHint:           config.ScreenShareContentHintMotion,
+		DegradationPreference: degradationPreferenceMaintainFramerate,
+	}, publishConstraintsForTrack(config.ScreenSharePolicy{MaxLayers: 2, ContentHint: config.ScreenShareContentHintMotion}, screenShare))
+}
+
+func TestConstrainSubscribedQualities(t *testing.T) {
+	qualities := func(enabled ...bool) []*livekit.SubscribedQuality {
+		var q []*livekit.SubscribedQuality
+		for i, e := range enabled {
+			q = append(q, &livekit.SubscribedQuality{Quality: livekit.VideoQuality(i), Enabled: e})
+		}
+		return q
+	}
+	enabled := func(q []*livekit.SubscribedQuality) []bool {
+		var e []bool
+		for _, sq := range q {
+			e = append(e, sq.Enabled)
+		}
+		return e
+	}
+
+	testCases := []struct {
+		name      string
+		maxLayers int32
+		in        []bool
+		out       []bool
+	}{
+		{"all layers", 0, []bool{true, true, false}, []bool{true, true, false}},
+		{"single layer, subscribers want low", 1, []bool{true, false, false}, []bool{false, false, true}},
+		{"single layer, subscribers want high", 1, []bool{true, true, true}, []bool{false, false, true}},
+		{"two layers, subscribers want low", 2, []bool{true, false, false}, []bool{false, true, false}},
+		{"two layers, subscribers want high", 2, []bool{true, true, true}, []bool{false, true, true}},
+		{"nothing subscribed", 1, []bool{false, false, false}, []bool{false, false, false}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := qualities(tc.in...)
+			constrainSubscribedQualities(q, tc.maxLayers)
+			require.Equal(t, tc.out, enabled(q))
+		})
+	}
+}
+
+func TestScreenShareSubscribedQualities(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.params.ScreenSharePolicy = config.ScreenSharePolicy{DisableSimulcast: true}
+	sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+
+	track := &typesfakes.FakeLocalMediaTrack{}
+	track.SourceReturns(livekit.TrackSource_SCREEN_SHARE)
+	track.ToProtoReturns(&livekit.TrackInfo{})
+	// directly add to publishedTracks without lock - for testing purpose only
+	p.UpTrackManager.publishedTracks["TR_screen"] = track
+
+	require.NoError(t, p.onSubscribedMaxQualityChange("TR_screen", []*livekit.SubscribedCodec{{
+		Codec: "video/vp8",
+		Qualities: []*livekit.SubscribedQuality{
+			{Quality: livekit.VideoQuality_LOW, Enabled: true},
+			{Quality: livekit.VideoQuality_MEDIUM, Enabled: false},
+			{Quality: livekit.VideoQuality_HIGH, Enabled: false},
+		},
+	}}, nil))
+
+	// the publisher is asked for the highest layer only
+	require.Equal(t, 1, sink.WriteMessageCallCount())
+	update := sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse).GetSubscribedQualityUpdate()
+	require.NotNil(t, update)
+	for _, q := range update.SubscribedQualities {
+		require.Equal(t, q.Quality == livekit.VideoQuality_HIGH, q.Enabled, q.Quality)
+	}
+}
@@ -90,7 +90,10 @@ func HandleParticipantSignal(room types.Room, participant types.LocalParticipant
 
 	case *livekit.SignalRequest_UpdateMetadata:
 		if participant.ClaimGrants().Video.GetCanUpdateOwnMetadata() {
-			room.UpdateParticipantMetadata(participant, msg.UpdateMetadata.Name, msg.UpdateMetadata.Metadata)
+			if err := room.UpdateParticipantMetadata(participant, msg.UpdateMetadata.Name, msg.UpdateMetadata.Metadata, participant.Identity()); err != nil {
+				pLogger.Warnw("could not update metadata", err)
+				sendMetadataRejected(participant, err, pLogger)
+			}
 		}
 	}
 	return nil
@@ -0,0 +1,120 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+const (
+	defaultSpeakerAudioCheckInterval = 20 * time.Millisecond
+	defaultSpeakerAudioHangover      = time.Second
+
+	// all audio subscriptions are paused or resumed at this interval, including ones made since the last change
+	speakerAudioSyncInterval = time.Second
+)
+
+// SetSpeakerAudio makes the room forward audio only from participants who are speaking, see config.SpeakerAudioConfig.
+// It should be called once, after the room is created
+func (r *Room) SetSpeakerAudio(conf config.SpeakerAudioConfig) {
+	if !conf.Enabled {
+		return
+	}
+	if conf.CheckInterval <= 0 {
+		conf.CheckInterval = defaultSpeakerAudioCheckInterval
+	}
+	if conf.Hangover <= 0 {
+		conf.Hangover = defaultSpeakerAudioHangover
+	}
+
+	r.Logger.Infow("forwarding speaker audio only", "checkInterval", conf.CheckInterval, "hangover", conf.Hangover)
+	go r.speakerAudioWorker(conf)
+}
+
+func (r *Room) speakerAudioWorker(conf config.SpeakerAudioConfig) {
+	ticker := time.NewTicker(conf.CheckInterval)
+	defer ticker.Stop()
+
+	var speaking map[livekit.ParticipantID]bool
+	var lastSync time.Time
+	for {
+		select {
+		case <-r.closed:
+			return
+
+		case now := <-ticker.C:
+			participants := r.GetParticipants()
+			nowSpeaking := make(map[livekit.ParticipantID]bool, len(participants))
+			changed := make(map[livekit.ParticipantID]bool)
+			for _, p := range participants {
+				isSpeaking := now.Sub(lastVoiceActivity(p)) < conf.Hangover
+				nowSpeaking[p.ID()] = isSpeaking
+				if isSpeaking != speaking[p.ID()] {
+					changed[p.ID()] = true
+				}
+			}
+			speaking = nowSpeaking
+
+			if now.Sub(lastSync) >= speakerAudioSyncInterval {
+				lastSync = now
+				changed = nil
+			} else if len(changed) == 0 {
+				continue
+			}
+			r.pauseSilentAudio(speaking, changed)
+		}
+	}
+}
+
+// pauseSilentAudio pauses the audio subscriptions of publishers who are not speaking, and resumes the others.
+// Only subscriptions to the publishers in changed are updated, all of them when it is nil
+func (r *Room) pauseSilentAudio(speaking map[livekit.ParticipantID]bool, changed map[livekit.ParticipantID]bool) {
+	for _, p := range r.GetParticipants() {
+		for _, st := range p.GetSubscribedTracks() {
+			subTrack, ok := st.(*SubscribedTrack)
+			if !ok || subTrack.MediaTrack().Kind() != livekit.TrackType_AUDIO {
+				continue
+			}
+			if changed != nil && !changed[subTrack.PublisherID()] {
+				continue
+			}
+			subTrack.SetSpeakerPaused(!speaking[subTrack.PublisherID()])
+		}
+	}
+}
+
+// lastVoiceActivity returns when any local receiver of the participant's audio tracks last had voice activity
+func lastVoiceActivity(p types.Participant) time.Time {
+	var last time.Time
+	for _, track := range p.GetPublishedTracks() {
+		if track.Kind() != livekit.TrackType_AUDIO {
+			continue
+		}
+		for _, receiver := range track.Receivers() {
+			if wr, ok := receiver.(*sfu.WebRTCReceiver); ok {
+				if at := wr.LastVoiceActivity(); at.After(last) {
+					last = at
+				}
+			}
+		}
+	}
+	return last
+}
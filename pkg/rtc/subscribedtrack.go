@@ -58,6 +58,8 @@ type SubscribedTrack struct {
 	onBindCallbacks []func(error)
 	onClose         atomic.Value // func(bool)
 	bound           atomic.Bool
+	videoCapped     atomic.Bool
+	speakerPaused   atomic.Bool
 
 	debouncer func(func())
 }
@@ -101,26 +103,7 @@ func (t *SubscribedTrack) Bound(err error) {
 	t.bindLock.Unlock()
 
 	if err == nil && t.MediaTrack().Kind() == livekit.TrackType_VIDEO {
-		// When AdaptiveStream is enabled, default the subscriber to LOW quality stream
-		// we would want LOW instead of OFF for a couple of reasons
-		// 1. when a subscriber unsubscribes from a track, we would forget their previously defined settings
-		//    depending on client implementation, subscription on/off is kept separately from adaptive stream
-		//    So when there are no changes to desired resolution, but the user re-subscribes, we may leave stream at OFF
-		// 2. when interacting with dynacast *and* adaptive stream. If the publisher was not publishing at the
-		//    time of subscription, we might not be able to trigger adaptive stream updates on the client side
-		//    (since there isn't any video frames coming through). this will leave the stream "stuck" on off, without
-		//    a trigger to re-enable it
-		var desiredLayer int32
-		if t.params.AdaptiveStream {
-			desiredLayer = buffer.VideoQualityToSpatialLayer(livekit.VideoQuality_LOW, t.params.MediaTrack.ToProto())
-		} else {
-			desiredLayer = buffer.VideoQualityToSpatialLayer(livekit.VideoQuality_HIGH, t.params.MediaTrack.ToProto())
-		}
-		settings := t.settings.Load()
-		if settings != nil {
-			desiredLayer = t.spatialLayerFromSettings(settings)
-		}
-		t.DownTrack().SetMaxSpatialLayer(desiredLayer)
+		t.DownTrack().SetMaxSpatialLayer(t.applyVideoCap(t.desiredSpatialLayer()))
 	}
 
 	for _, cb := range callbacks {
@@ -128,6 +111,57 @@ func (t *SubscribedTrack) Bound(err error) {
 	}
 }
 
+func (t *SubscribedTrack) desiredSpatialLayer() int32 {
+	// When AdaptiveStream is enabled, default the subscriber to LOW quality stream
+	// we would want LOW instead of OFF for a couple of reasons
+	// 1. when a subscriber unsubscribes from a track, we would forget their previously defined settings
+	//    depending on client implementation, subscription on/off is kept separately from adaptive stream
+	//    So when there are no changes to desired resolution, but the user re-subscribes, we may leave stream at OFF
+	// 2. when interacting with dynacast *and* adaptive stream. If the publisher was not publishing at the
+	//    time of subscription, we might not be able to trigger adaptive stream updates on the client side
+	//    (since there isn't any video frames coming through). this will leave the stream "stuck" on off, without
+	//    a trigger to re-enable it
+	var desiredLayer int32
+	if t.params.AdaptiveStream {
+		desiredLayer = buffer.VideoQualityToSpatialLayer(livekit.VideoQuality_LOW, t.params.MediaTrack.ToProto())
+	} else {
+		desiredLayer = buffer.VideoQualityToSpatialLayer(livekit.VideoQuality_HIGH, t.params.MediaTrack.ToProto())
+	}
+	settings := t.settings.Load()
+	if settings != nil {
+		desiredLayer = t.spatialLayerFromSettings(settings)
+	}
+	return desiredLayer
+}
+
+// SetVideoCapped limits the subscription to the lowest spatial layer, used when the room is over its resource budget
+func (t *SubscribedTrack) SetVideoCapped(capped bool) {
+	if t.videoCapped.Swap(capped) == capped {
+		return
+	}
+
+	if !t.bound.Load() || t.DownTrack().Kind() != webrtc.RTPCodecTypeVideo {
+		return
+	}
+
+	t.logger.Debugw("updating video cap", "capped", capped)
+	t.DownTrack().SetMaxSpatialLayer(t.applyVideoCap(t.desiredSpatialLayer()))
+}
+
+// SetSpeakerPaused pauses an audio subscription while the publisher is not speaking, in rooms forwarding speaker audio only
+func (t *SubscribedTrack) SetSpeakerPaused(paused bool) {
+	if t.speakerPaused.Swap(paused) == paused {
+		return
+	}
+
+	if t.DownTrack().Kind() != webrtc.RTPCodecTypeAudio {
+		return
+	}
+
+	t.logger.Debugw("updating speaker pause", "paused", paused)
+	t.updateDownTrackMute()
+}
+
 // for DownTrack callback to notify us that it's closed
 func (t *SubscribedTrack) Close(willBeResumed bool) {
 	if onClose := t.onClose.Load(); onClose != nil {
@@ -217,7 +251,7 @@ func (t *SubscribedTrack) UpdateVideoLayer() {
 	}
 
 	t.logger.Debugw("updating video layer", "settings", settings)
-	spatial := t.spatialLayerFromSettings(settings)
+	spatial := t.applyVideoCap(t.spatialLayerFromSettings(settings))
 	t.DownTrack().SetMaxSpatialLayer(spatial)
 	if settings.Fps > 0 {
 		t.DownTrack().SetMaxTemporalLayer(t.MediaTrack().GetTemporalLayerForSpatialFps(spatial, settings.Fps, t.DownTrack().Codec().MimeType))
@@ -241,7 +275,9 @@ func (t *SubscribedTrack) SetRTPSender(sender *webrtc.RTPSender) {
 }
 
 func (t *SubscribedTrack) updateDownTrackMute() {
-	t.DownTrack().Mute(t.subMuted.Load())
+	audioOnly := t.DownTrack().Kind() == webrtc.RTPCodecTypeVideo && t.params.Subscriber.IsAudioOnly()
+	speakerPaused := t.DownTrack().Kind() == webrtc.RTPCodecTypeAudio && t.speakerPaused.Load()
+	t.DownTrack().Mute(t.subMuted.Load() || audioOnly || speakerPaused)
 	t.DownTrack().PubMute(t.pubMuted.Load())
 }
 
@@ -253,3 +289,10 @@ func (t *SubscribedTrack) spatialLayerFromSettings(settings *livekit.UpdateTrack
 
 	return buffer.VideoQualityToSpatialLayer(quality, t.params.MediaTrack.ToProto())
 }
+
+func (t *SubscribedTrack) applyVideoCap(spatial int32) int32 {
+	if t.videoCapped.Load() && spatial > 0 {
+		return 0
+	}
+	return spatial
+}
@@ -18,11 +18,13 @@ package rtc
 
 import (
 	"context"
+	"encoding/json"
 	"sync"
 	"time"
 
 	"github.com/pion/webrtc/v3/pkg/rtcerr"
 	"go.uber.org/atomic"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
@@ -45,8 +47,19 @@ var (
 
 const (
 	trackIDForReconcileSubscriptions = livekit.TrackID("subscriptions_reconcile")
+
+	SubscriptionRevokedTopic = "lk.subscription_revoked"
 )
 
+// SubscriptionRevokedMessage is sent to a subscriber when a subscription is torn down because of a permission change.
+// The subscription remains desired and is restored if permission is granted again
+type SubscriptionRevokedMessage struct {
+	TrackSid            string `json:"track_sid"`
+	ParticipantSid      string `json:"participant_sid"`
+	ParticipantIdentity string `json:"participant_identity"`
+	Reason              string `json:"reason"`
+}
+
 type SubscriptionManagerParams struct {
 	Logger              logger.Logger
 	Participant         types.LocalParticipant
@@ -650,13 +663,16 @@ func (m *SubscriptionManager) handleSubscribedTrackClose(s *trackSubscription, w
 		if dt != nil {
 			stats := dt.GetTrackStats()
 			if stats != nil {
-				m.params.Telemetry.TrackSubscribeRTPStats(
-					context.Background(),
-					m.params.Participant.ID(),
-					s.trackID,
-					subTrack.DownTrack().Codec().MimeType,
-					stats,
-				)
+				// lifetime stats first, followed by the stats of each interval
+				for _, st := range append([]*livekit.RTPStats{stats}, dt.GetTrackStatsHistory()...) {
+					m.params.Telemetry.TrackSubscribeRTPStats(
+						context.Background(),
+						m.params.Participant.ID(),
+						s.trackID,
+						subTrack.DownTrack().Codec().MimeType,
+						st,
+					)
+				}
 			}
 		}
 	}
@@ -690,6 +706,53 @@ func (m *SubscriptionManager) handleSubscribedTrackClose(s *trackSubscription, w
 	}
 }
 
+// handleSubscriptionRevoked reports a subscription torn down because of a permission change to the subscriber and analytics
+func (m *SubscriptionManager) handleSubscriptionRevoked(trackID livekit.TrackID, reason types.SubscriptionRevokedReason) {
+	m.lock.RLock()
+	s := m.subscriptions[trackID]
+	m.lock.RUnlock()
+	if s == nil {
+		return
+	}
+
+	s.logger.Infow("subscription revoked", "reason", reason)
+	kind, _ := s.getKind()
+	m.params.Telemetry.TrackSubscriptionRevoked(
+		context.Background(),
+		m.params.Participant.ID(),
+		&livekit.TrackInfo{Sid: string(trackID), Type: kind},
+		string(reason),
+	)
+
+	publisherIdentity, publisherID := s.getPublisher()
+	payload, err := json.Marshal(&SubscriptionRevokedMessage{
+		TrackSid:            string(trackID),
+		ParticipantSid:      string(publisherID),
+		ParticipantIdentity: string(publisherIdentity),
+		Reason:              string(reason),
+	})
+	if err != nil {
+		return
+	}
+	topic := SubscriptionRevokedTopic
+	dp := &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				Payload: payload,
+				Topic:   &topic,
+			},
+		},
+	}
+	data, err := proto.Marshal(dp)
+	if err != nil {
+		return
+	}
+	if err := m.params.Participant.SendDataPacket(dp, data); err != nil {
+		s.logger.Debugw("could not send subscription revoked", "error", err)
+	}
+}
+
 // --------------------------------------------------------------------------------------
 
 type trackSubscription struct {
@@ -734,6 +797,12 @@ func (s *trackSubscription) setPublisher(publisherIdentity livekit.ParticipantId
 	s.publisherIdentity = publisherIdentity
 }
 
+func (s *trackSubscription) getPublisher() (livekit.ParticipantIdentity, livekit.ParticipantID) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.publisherIdentity, s.publisherID
+}
+
 func (s *trackSubscription) getPublisherID() livekit.ParticipantID {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
@@ -15,6 +15,7 @@
 package rtc
 
 import (
+	"crypto/x509"
 	"fmt"
 	"net"
 	"strings"
@@ -22,7 +23,6 @@ import (
 	"time"
 
 	"github.com/bep/debounce"
-	"github.com/pion/dtls/v2/pkg/crypto/elliptic"
 	"github.com/pion/ice/v2"
 	"github.com/pion/interceptor"
 	"github.com/pion/interceptor/pkg/cc"
@@ -34,6 +34,7 @@ import (
 	"github.com/pion/webrtc/v3"
 	"github.com/pkg/errors"
 	"go.uber.org/atomic"
+	"golang.org/x/exp/slices"
 
 	sutils "github.com/livekit/livekit-server/pkg/utils"
 	"github.com/livekit/protocol/livekit"
@@ -71,7 +72,17 @@ const (
 
 	maxICECandidates = 20
 
+	forceRelayCheckDelay = 2 * time.Second
+
 	shortConnectionThreshold = 90 * time.Second
+
+	// flow control of data channels, messages to slow subscribers are not queued indefinitely.
+	// lossy messages are dropped once too much is buffered. reliable ones are queued per transport
+	// behind the SCTP buffer, a subscriber overflowing the queue is flagged as stalled.
+	lossyDataChannelMaxBufferedAmount    = 64 * 1024
+	reliableDataChannelMaxBufferedAmount = 1024 * 1024
+	reliableDataChannelLowBufferedAmount = 512 * 1024
+	reliableDataChannelMaxQueued         = 4 * 1024 * 1024
 )
 
 var (
@@ -79,6 +90,7 @@ var (
 	ErrIceRestartOnClosedPeerConnection = errors.New("ICE restart on closed peer connection")
 	ErrNoTransceiver                    = errors.New("no transceiver")
 	ErrNoSender                         = errors.New("no sender")
+	ErrNoSpareTransceiver               = errors.New("no spare transceiver")
 	ErrNoICECandidateHandler            = errors.New("no ICE candidate handler")
 	ErrNoOfferHandler                   = errors.New("no offer handler")
 	ErrNoAnswerHandler                  = errors.New("no answer handler")
@@ -176,12 +188,22 @@ type PCTransport struct {
 
 	lock sync.RWMutex
 
+	// serializes claiming spare transceivers
+	spareLock sync.Mutex
+
 	reliableDC       *webrtc.DataChannel
 	reliableDCOpened bool
 	lossyDC          *webrtc.DataChannel
 	lossyDCOpened    bool
 	onDataPacket     func(kind livekit.DataPacket_Kind, data []byte)
 
+	// reliable messages waiting for the SCTP buffer to drain, in order
+	reliableQueueLock     sync.Mutex
+	reliableQueue         [][]byte
+	reliableQueued        int
+	reliableStalled       bool
+	onReliableDataStalled func()
+
 	iceStartedAt               time.Time
 	iceConnectedAt             time.Time
 	firstConnectedAt           time.Time
@@ -200,7 +222,11 @@ type PCTransport struct {
 	onOffer                   func(offer webrtc.SessionDescription) error
 	onAnswer                  func(answer webrtc.SessionDescription) error
 	onInitialConnected        func()
+	onReconnected             func()
 	onFailed                  func(isShortLived bool)
+	onRemoteIPRejected        func(address string)
+	remoteIPWatch             sync.Once
+	remoteIPRejected          atomic.Bool
 	onNegotiationStateChanged func(state NegotiationState)
 	onNegotiationFailed       func()
 
@@ -255,12 +281,16 @@ type TransportParams struct {
 	IsOfferer               bool
 	IsSendSide              bool
 	AllowPlayoutDelay       bool
+	// media must flow through TURN, remote candidates other than relay are ignored
+	ForceRelay bool
+	// remote addresses the transport may connect from
+	RemoteIPFilter *IPFilter
 }
 
 func newPeerConnection(params TransportParams, onBandwidthEstimator func(estimator cc.BandwidthEstimator)) (*webrtc.PeerConnection, *webrtc.MediaEngine, error) {
 	directionConfig := params.DirectionConfig
 
-	if params.AllowPlayoutDelay {
+	if params.AllowPlayoutDelay && !slices.Contains(directionConfig.DroppedRTPHeaderExtensions, rtpextension.PlayoutDelayURI) {
 		directionConfig.RTPHeaderExtension.Video = append(directionConfig.RTPHeaderExtension.Video, rtpextension.PlayoutDelayURI)
 	}
 
@@ -275,9 +305,11 @@ func newPeerConnection(params TransportParams, onBandwidthEstimator func(estimat
 	se := params.Config.SettingEngine
 	se.DisableMediaEngineCopy(true)
 
-	// Change elliptic curve to improve connectivity
-	// https://github.com/pion/dtls/pull/474
-	se.SetDTLSEllipticCurves(elliptic.X25519, elliptic.P384, elliptic.P256)
+	if params.Config.DTLS != nil {
+		se.SetDTLSEllipticCurves(params.Config.DTLS.EllipticCurves...)
+	} else {
+		se.SetDTLSEllipticCurves(defaultEllipticCurves...)
+	}
 
 	//
 	// Disable SRTP replay protection (https://datatracker.ietf.org/doc/html/rfc3711#page-15).
@@ -304,8 +336,9 @@ func newPeerConnection(params TransportParams, onBandwidthEstimator func(estimat
 	se.SetDTLSRetransmissionInterval(dtlsRetransmissionInterval)
 	se.SetICETimeouts(iceDisconnectedTimeout, iceFailedTimeout, iceKeepaliveInterval)
 
-	// if client don't support prflx over relay, we should not expose private address to it, use single external ip as host candidate
-	if !params.ClientInfo.SupportPrflxOverRelay() && len(params.Config.NAT1To1IPs) > 0 {
+	// if client don't support prflx over relay, we should not expose private address to it, use single external ip as host candidate.
+	// same when relay is forced, the only address the relay needs is the external one
+	if (!params.ClientInfo.SupportPrflxOverRelay() || params.ForceRelay) && len(params.Config.NAT1To1IPs) > 0 {
 		var nat1to1Ips []string
 		var includeIps []string
 		for _, mapping := range params.Config.NAT1To1IPs {
@@ -368,6 +401,18 @@ func newPeerConnection(params TransportParams, onBandwidthEstimator func(estimat
 			ir.Add(f)
 		}
 	}
+	for _, impairment := range impairmentsForTransport(params.Config.Impairments, params.ParticipantIdentity, params.IsSendSide) {
+		params.Logger.Warnw("impairing media of transport, for testing only", nil,
+			"loss", impairment.Loss,
+			"duplicate", impairment.Duplicate,
+			"reorder", impairment.Reorder,
+			"jitter", impairment.Jitter,
+		)
+		ir.Add(NewImpairmentInterceptorFactory(impairment))
+	}
+	if chaos := params.Config.Chaos; chaos.Enabled && chaos.ReportDrop > 0 {
+		ir.Add(NewReportDropInterceptorFactory(chaos))
+	}
 	api := webrtc.NewAPI(
 		webrtc.WithMediaEngine(me),
 		webrtc.WithSettingEngine(se),
@@ -399,7 +444,11 @@ func NewPCTransport(params TransportParams) (*PCTransport, error) {
 			Logger: params.Logger.WithComponent(sutils.ComponentCongestionControl),
 		})
 		t.streamAllocator.Start()
-		t.pacer = pacer.NewPassThrough(params.Logger)
+		if params.Config != nil && params.Config.PacketWorkers != nil {
+			t.pacer = params.Config.PacketWorkers.NewQueue(params.Logger)
+		} else {
+			t.pacer = pacer.NewPassThrough(params.Logger)
+		}
 	}
 
 	if err := t.createPeerConnection(); err != nil {
@@ -540,6 +589,22 @@ func (t *PCTransport) IsShortConnection(at time.Time) (bool, time.Duration) {
 	return duration < shortConnectionThreshold, duration
 }
 
+// GetStats returns the stats pion collects for the peer connection: transport, ICE candidates and pairs, codecs
+func (t *PCTransport) GetStats() webrtc.StatsReport {
+	return t.pc.GetStats()
+}
+
+// KillDTLS closes the DTLS transport for chaos testing, media stops flowing and the client has to reconnect
+func (t *PCTransport) KillDTLS() error {
+	sctp := t.pc.SCTP()
+	if sctp == nil || sctp.Transport() == nil {
+		return errors.New("no DTLS transport")
+	}
+
+	t.params.Logger.Infow("killing DTLS transport")
+	return sctp.Transport().Stop()
+}
+
 func (t *PCTransport) getSelectedPair() (*webrtc.ICECandidatePair, error) {
 	sctp := t.pc.SCTP()
 	if sctp == nil {
@@ -629,6 +694,13 @@ func (t *PCTransport) onICEConnectionStateChange(state webrtc.ICEConnectionState
 		} else {
 			t.params.Logger.Infow("selected ICE candidate pair", "pair", pair)
 		}
+		if t.params.ForceRelay {
+			// the relay candidate may be trickled after checks from its address have already succeeded
+			time.AfterFunc(forceRelayCheckDelay, t.checkRelayed)
+		}
+		if t.params.RemoteIPFilter != nil {
+			t.watchRemoteIP()
+		}
 
 	case webrtc.ICEConnectionStateChecking:
 		t.setICEStartedAt(time.Now())
@@ -640,6 +712,60 @@ func (t *PCTransport) onICEConnectionStateChange(state webrtc.ICEConnectionState
 	}
 }
 
+// checkRelayed fails the connection when relay is forced and the selected pair does not go through TURN
+func (t *PCTransport) checkRelayed() {
+	if t.pc.ICEConnectionState() != webrtc.ICEConnectionStateConnected {
+		return
+	}
+	if connType := t.GetICEConnectionType(); connType != types.ICEConnectionTypeTURN {
+		pair, _ := t.getSelectedPair()
+		t.params.Logger.Warnw("connected without relay in forced relay mode", nil, "connectionType", connType, "pair", pair)
+		t.handleConnectionFailed(false)
+	}
+}
+
+// watchRemoteIP checks the selected pair, and again whenever another pair is selected
+func (t *PCTransport) watchRemoteIP() {
+	t.remoteIPWatch.Do(func() {
+		if sctp := t.pc.SCTP(); sctp != nil && sctp.Transport() != nil && sctp.Transport().ICETransport() != nil {
+			sctp.Transport().ICETransport().OnSelectedCandidatePairChange(t.checkRemoteIP)
+		}
+	})
+	if pair, err := t.getSelectedPair(); err == nil && pair != nil {
+		t.checkRemoteIP(pair)
+	}
+}
+
+// checkRemoteIP reports a selected pair whose remote address is not allowed. The address of the client's
+// signal connection is checked as well when known. A pair relayed by TURN has the address of the TURN server,
+// the client is only identified by its signal connection then.
+func (t *PCTransport) checkRemoteIP(pair *webrtc.ICECandidatePair) {
+	if pair == nil || pair.Remote == nil {
+		return
+	}
+	addresses := make([]string, 0, 2)
+	if pair.Remote.Typ != webrtc.ICECandidateTypeRelay {
+		addresses = append(addresses, pair.Remote.Address)
+	}
+	if signalAddress := t.params.ClientInfo.GetAddress(); signalAddress != "" || len(addresses) == 0 {
+		addresses = append(addresses, signalAddress)
+	}
+
+	for _, address := range addresses {
+		if t.params.RemoteIPFilter.Allow(address) {
+			continue
+		}
+		t.params.Logger.Warnw("remote address not allowed", nil, "address", address, "pair", pair)
+		if t.remoteIPRejected.Swap(true) {
+			return
+		}
+		if onRemoteIPRejected := t.getOnRemoteIPRejected(); onRemoteIPRejected != nil {
+			onRemoteIPRejected(address)
+		}
+		return
+	}
+}
+
 func (t *PCTransport) onPeerConnectionStateChange(state webrtc.PeerConnectionState) {
 	t.params.Logger.Debugw("peer connection state change", "state", state.String())
 	switch state {
@@ -651,8 +777,15 @@ func (t *PCTransport) onPeerConnectionStateChange(state webrtc.PeerConnectionSta
 				onInitialConnected()
 			}
 
+			if t.streamAllocator != nil {
+				t.streamAllocator.ProbeOnJoin()
+			}
+
 			t.maybeNotifyFullyEstablished()
 			t.logICECandidates()
+			t.logDTLS()
+		} else if onReconnected := t.getOnReconnected(); onReconnected != nil {
+			onReconnected()
 		}
 	case webrtc.PeerConnectionStateFailed:
 		t.params.Logger.Infow("peer connection failed")
@@ -662,6 +795,29 @@ func (t *PCTransport) onPeerConnectionStateChange(state webrtc.PeerConnectionSta
 	}
 }
 
+// logDTLS records the security parameters of the connection for compliance audits
+func (t *PCTransport) logDTLS() {
+	values := []interface{}{"localCertificateKey", "ECDSA", "srtpProfiles", "default"}
+	if dtlsParams := t.params.Config.DTLS; dtlsParams != nil {
+		values = []interface{}{"localCertificateKey", dtlsParams.CertificateKey, "aesHardware", dtlsParams.AESHardware}
+		if len(dtlsParams.SRTPProfiles) != 0 {
+			values = append(values, "srtpProfiles", dtlsParams.SRTPProfiles)
+		} else {
+			values = append(values, "srtpProfiles", "default")
+		}
+	}
+
+	if sctp := t.pc.SCTP(); sctp != nil && sctp.Transport() != nil {
+		if remote, err := x509.ParseCertificate(sctp.Transport().GetRemoteCertificate()); err == nil {
+			values = append(values,
+				"remoteCertificateKey", remote.PublicKeyAlgorithm.String(),
+				"remoteCertificateSignature", remote.SignatureAlgorithm.String(),
+			)
+		}
+	}
+	t.params.Logger.Infow("DTLS established", values...)
+}
+
 func (t *PCTransport) onDataChannel(dc *webrtc.DataChannel) {
 	t.params.Logger.Debugw(dc.Label() + " data channel open")
 	switch dc.Label() {
@@ -670,6 +826,7 @@ func (t *PCTransport) onDataChannel(dc *webrtc.DataChannel) {
 		t.reliableDC = dc
 		t.reliableDCOpened = true
 		t.lock.Unlock()
+		t.setupReliableFlowControl(dc)
 		dc.OnMessage(func(msg webrtc.DataChannelMessage) {
 			if onDataPacket := t.getOnDataPacket(); onDataPacket != nil {
 				onDataPacket(livekit.DataPacket_RELIABLE, msg.Data)
@@ -761,7 +918,7 @@ func (t *PCTransport) AddTrack(trackLocal webrtc.TrackLocal, params types.AddTra
 		return
 	}
 
-	configureAudioTransceiver(transceiver, params.Stereo, !params.Red || !t.params.ClientInfo.SupportsAudioRED())
+	configureTransceiver(transceiver, params.Stereo, !params.Red || !t.params.ClientInfo.SupportsAudioRED(), params.DisableNACK)
 	return
 }
 
@@ -777,7 +934,7 @@ func (t *PCTransport) AddTransceiverFromTrack(trackLocal webrtc.TrackLocal, para
 		return
 	}
 
-	configureAudioTransceiver(transceiver, params.Stereo, !params.Red || !t.params.ClientInfo.SupportsAudioRED())
+	configureTransceiver(transceiver, params.Stereo, !params.Red || !t.params.ClientInfo.SupportsAudioRED(), params.DisableNACK)
 
 	return
 }
@@ -786,6 +943,58 @@ func (t *PCTransport) RemoveTrack(sender *webrtc.RTPSender) error {
 	return t.pc.RemoveTrack(sender)
 }
 
+// AddSpareTransceivers adds send only transceivers of each kind without a track, so that tracks can be sent
+// later by replacing the track of a negotiated transceiver instead of renegotiating
+func (t *PCTransport) AddSpareTransceivers(count int) error {
+	for _, kind := range []webrtc.RTPCodecType{webrtc.RTPCodecTypeAudio, webrtc.RTPCodecTypeVideo} {
+		for i := 0; i < count; i++ {
+			if _, err := t.pc.AddTransceiverFromKind(kind, webrtc.RTPTransceiverInit{
+				Direction: webrtc.RTPTransceiverDirectionSendonly,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReplaceSpareTrack sends the track on a negotiated transceiver that is not sending a track,
+// beforeBind is called with the transceiver before the track is bound to it
+func (t *PCTransport) ReplaceSpareTrack(
+	trackLocal webrtc.TrackLocal,
+	beforeBind func(transceiver *webrtc.RTPTransceiver),
+) (*webrtc.RTPSender, *webrtc.RTPTransceiver, error) {
+	t.spareLock.Lock()
+	defer t.spareLock.Unlock()
+
+	for _, tr := range t.pc.GetTransceivers() {
+		if tr.Kind() != trackLocal.Kind() || tr.Mid() == "" {
+			continue
+		}
+		if direction := tr.Direction(); direction != webrtc.RTPTransceiverDirectionSendonly && direction != webrtc.RTPTransceiverDirectionSendrecv {
+			continue
+		}
+		sender := tr.Sender()
+		if sender == nil {
+			continue
+		}
+		// spare transceivers carry no track, or the placeholder track pion creates for a transceiver added by kind
+		switch sender.Track().(type) {
+		case nil, *webrtc.TrackLocalStaticSample:
+		default:
+			continue
+		}
+
+		beforeBind(tr)
+		if err := sender.ReplaceTrack(trackLocal); err != nil {
+			return nil, nil, err
+		}
+		return sender, tr, nil
+	}
+
+	return nil, nil, ErrNoSpareTransceiver
+}
+
 func (t *PCTransport) GetMid(rtpReceiver *webrtc.RTPReceiver) string {
 	for _, tr := range t.pc.GetTransceivers() {
 		if tr.Receiver() == rtpReceiver {
@@ -862,6 +1071,10 @@ func (t *PCTransport) CreateDataChannel(label string, dci *webrtc.DataChannelIni
 	}
 	t.lock.Unlock()
 
+	if dc.Label() == ReliableDataChannel {
+		t.setupReliableFlowControl(dc)
+	}
+
 	return nil
 }
 
@@ -929,9 +1142,77 @@ func (t *PCTransport) SendDataPacket(dp *livekit.DataPacket, data []byte) error
 		return ErrTransportFailure
 	}
 
+	if dp.Kind == livekit.DataPacket_RELIABLE {
+		return t.sendReliable(dc, data)
+	}
+	if dc.BufferedAmount() > lossyDataChannelMaxBufferedAmount {
+		return ErrDataChannelBufferFull
+	}
+
 	return dc.Send(data)
 }
 
+// sendReliable sends a reliable message, or queues it behind earlier ones when the SCTP buffer is full.
+// Reliable messages are never dropped, once the queue overflows the transport is flagged as stalled and
+// refuses further messages.
+func (t *PCTransport) sendReliable(dc *webrtc.DataChannel, data []byte) error {
+	t.reliableQueueLock.Lock()
+	if t.reliableStalled {
+		t.reliableQueueLock.Unlock()
+		return ErrDataChannelStalled
+	}
+	if len(t.reliableQueue) == 0 && dc.BufferedAmount() <= reliableDataChannelMaxBufferedAmount {
+		t.reliableQueueLock.Unlock()
+		return dc.Send(data)
+	}
+	if t.reliableQueued+len(data) > reliableDataChannelMaxQueued {
+		t.reliableStalled = true
+		t.reliableQueue = nil
+		t.reliableQueued = 0
+		t.reliableQueueLock.Unlock()
+
+		t.params.Logger.Warnw("reliable data channel stalled", nil, "bufferedAmount", dc.BufferedAmount())
+		if onReliableDataStalled := t.getOnReliableDataStalled(); onReliableDataStalled != nil {
+			go onReliableDataStalled()
+		}
+		return ErrDataChannelStalled
+	}
+	t.reliableQueue = append(t.reliableQueue, data)
+	t.reliableQueued += len(data)
+	t.reliableQueueLock.Unlock()
+	return nil
+}
+
+// drainReliableQueue hands queued reliable messages to SCTP as its buffer drains
+func (t *PCTransport) drainReliableQueue(dc *webrtc.DataChannel) {
+	t.reliableQueueLock.Lock()
+	defer t.reliableQueueLock.Unlock()
+
+	for len(t.reliableQueue) != 0 && dc.BufferedAmount() <= reliableDataChannelMaxBufferedAmount {
+		data := t.reliableQueue[0]
+		if err := dc.Send(data); err != nil {
+			t.params.Logger.Warnw("could not send queued reliable data", err)
+			return
+		}
+		t.reliableQueue[0] = nil
+		t.reliableQueue = t.reliableQueue[1:]
+		t.reliableQueued -= len(data)
+	}
+}
+
+func (t *PCTransport) setupReliableFlowControl(dc *webrtc.DataChannel) {
+	t.reliableQueueLock.Lock()
+	t.reliableQueue = nil
+	t.reliableQueued = 0
+	t.reliableStalled = false
+	t.reliableQueueLock.Unlock()
+
+	dc.SetBufferedAmountLowThreshold(reliableDataChannelLowBufferedAmount)
+	dc.OnBufferedAmountLow(func() {
+		t.drainReliableQueue(dc)
+	})
+}
+
 func (t *PCTransport) Close() {
 	t.eventChMu.Lock()
 	if t.isClosed.Swap(true) {
@@ -1000,6 +1281,20 @@ func (t *PCTransport) getOnInitialConnected() func() {
 	return t.onInitialConnected
 }
 
+// OnReconnected is invoked when the peer connection connects again after being disconnected
+func (t *PCTransport) OnReconnected(f func()) {
+	t.lock.Lock()
+	t.onReconnected = f
+	t.lock.Unlock()
+}
+
+func (t *PCTransport) getOnReconnected() func() {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.onReconnected
+}
+
 func (t *PCTransport) OnFullyEstablished(f func()) {
 	t.lock.Lock()
 	t.onFullyEstablished = f
@@ -1013,6 +1308,33 @@ func (t *PCTransport) getOnFullyEstablished() func() {
 	return t.onFullyEstablished
 }
 
+func (t *PCTransport) OnRemoteIPRejected(f func(address string)) {
+	t.lock.Lock()
+	t.onRemoteIPRejected = f
+	t.lock.Unlock()
+}
+
+func (t *PCTransport) getOnRemoteIPRejected() func(address string) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.onRemoteIPRejected
+}
+
+// OnReliableDataStalled is called when the remote does not keep up with the reliable data sent to it
+func (t *PCTransport) OnReliableDataStalled(f func()) {
+	t.lock.Lock()
+	t.onReliableDataStalled = f
+	t.lock.Unlock()
+}
+
+func (t *PCTransport) getOnReliableDataStalled() func() {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.onReliableDataStalled
+}
+
 func (t *PCTransport) OnFailed(f func(isShortLived bool)) {
 	t.lock.Lock()
 	t.onFailed = f
@@ -1228,6 +1550,24 @@ func (t *PCTransport) GetICEConnectionType() types.ICEConnectionType {
 	return types.ICEConnectionTypeUDP
 }
 
+// GetICEAddressFamily returns the address family of the selected candidate pair, as seen from the client
+func (t *PCTransport) GetICEAddressFamily() types.ICEAddressFamily {
+	if t.pc == nil {
+		return types.ICEAddressFamilyUnknown
+	}
+	p, err := t.getSelectedPair()
+	if err != nil || p == nil {
+		return types.ICEAddressFamilyUnknown
+	}
+
+	family := t.params.Config.AddressFamily.Family(p.Remote.Address)
+	if family == types.ICEAddressFamilyUnknown {
+		// mDNS remote candidate
+		family = t.params.Config.AddressFamily.Family(p.Local.Address)
+	}
+	return family
+}
+
 func (t *PCTransport) preparePC(previousAnswer webrtc.SessionDescription) error {
 	// sticky data channel to first m-lines, if someday we don't send sdp without media streams to
 	// client's subscribe pc after joining, should change this step
@@ -1539,11 +1879,13 @@ func (t *PCTransport) handleLocalICECandidate(e *event) error {
 	c := e.data.(*webrtc.ICECandidate)
 
 	filtered := false
-	if t.preferTCP.Load() && c != nil && c.Protocol != webrtc.ICEProtocolTCP {
-		cstr := c.String()
-		t.params.Logger.Debugw("filtering out local candidate", "candidate", cstr)
-		t.filteredLocalCandidates.Add(cstr)
-		filtered = true
+	if c != nil {
+		if (t.preferTCP.Load() && c.Protocol != webrtc.ICEProtocolTCP) || !t.params.Config.CandidateFilter.Allow(c.ToJSON().Candidate) {
+			cstr := c.String()
+			t.params.Logger.Debugw("filtering out local candidate", "candidate", cstr)
+			t.filteredLocalCandidates.Add(cstr)
+			filtered = true
+		}
 	}
 
 	if filtered {
@@ -1551,6 +1893,7 @@ func (t *PCTransport) handleLocalICECandidate(e *event) error {
 	}
 
 	if c != nil {
+		c.Priority = t.params.Config.AddressFamily.AdjustPriority(c.Address, c.Priority)
 		t.allowedLocalCandidates.Add(c.String())
 	}
 	if t.cacheLocalCandidates {
@@ -1569,7 +1912,8 @@ func (t *PCTransport) handleRemoteICECandidate(e *event) error {
 	c := e.data.(*webrtc.ICECandidateInit)
 
 	filtered := false
-	if t.preferTCP.Load() && !strings.Contains(c.Candidate, "tcp") {
+	if (t.preferTCP.Load() && !strings.Contains(c.Candidate, "tcp")) ||
+		(t.params.ForceRelay && c.Candidate != "" && !IsRelayCandidate(c.Candidate)) {
 		t.params.Logger.Debugw("filtering out remote candidate", "candidate", c.Candidate)
 		t.filteredRemoteCandidates.Add(c.Candidate)
 		filtered = true
@@ -1625,6 +1969,10 @@ func (t *PCTransport) filterCandidates(sd webrtc.SessionDescription, preferTCP b
 		filteredAttrs := make([]sdp.Attribute, 0, len(attrs))
 		for _, a := range attrs {
 			if a.Key == sdp.AttrKeyCandidate {
+				if !t.params.Config.CandidateFilter.Allow(a.Value) {
+					continue
+				}
+				a.Value = t.params.Config.AddressFamily.AdjustCandidate(a.Value)
 				if preferTCP {
 					if strings.Contains(a.Value, "tcp") {
 						filteredAttrs = append(filteredAttrs, a)
@@ -1654,6 +2002,40 @@ func (t *PCTransport) filterCandidates(sd webrtc.SessionDescription, preferTCP b
 	return sd
 }
 
+// filterNonRelayCandidates removes the remote candidates that do not go through TURN
+func (t *PCTransport) filterNonRelayCandidates(sd webrtc.SessionDescription) webrtc.SessionDescription {
+	parsed, err := sd.Unmarshal()
+	if err != nil {
+		t.params.Logger.Errorw("could not unmarshal SDP to filter candidates", err)
+		return sd
+	}
+
+	filterAttributes := func(attrs []sdp.Attribute) []sdp.Attribute {
+		filteredAttrs := make([]sdp.Attribute, 0, len(attrs))
+		for _, a := range attrs {
+			if a.Key == sdp.AttrKeyCandidate && !IsRelayCandidate(a.Value) {
+				t.filteredRemoteCandidates.Add(a.Value)
+				continue
+			}
+			filteredAttrs = append(filteredAttrs, a)
+		}
+		return filteredAttrs
+	}
+
+	parsed.Attributes = filterAttributes(parsed.Attributes)
+	for _, m := range parsed.MediaDescriptions {
+		m.Attributes = filterAttributes(m.Attributes)
+	}
+
+	bytes, err := parsed.Marshal()
+	if err != nil {
+		t.params.Logger.Errorw("could not marshal SDP to filter candidates", err)
+		return sd
+	}
+	sd.SDP = string(bytes)
+	return sd
+}
+
 func (t *PCTransport) clearSignalStateCheckTimer() {
 	if t.signalStateCheckTimer != nil {
 		t.signalStateCheckTimer.Stop()
@@ -1819,6 +2201,9 @@ func (t *PCTransport) setRemoteDescription(sd webrtc.SessionDescription) error {
 		t.params.Logger.Debugw("remote description (unfiltered)", "type", sd.Type, "sdp", sd.SDP)
 	}
 	sd = t.filterCandidates(sd, preferTCP)
+	if t.params.ForceRelay {
+		sd = t.filterNonRelayCandidates(sd)
+	}
 	if preferTCP {
 		t.params.Logger.Debugw("remote description (filtered)", "type", sd.Type, "sdp", sd.SDP)
 	}
@@ -2016,8 +2401,9 @@ func (t *PCTransport) handleICERestart(e *event) error {
 	return t.doICERestart()
 }
 
-// configure subscriber transceiver for audio stereo and nack
-func configureAudioTransceiver(tr *webrtc.RTPTransceiver, stereo bool, nack bool) {
+// configure subscriber transceiver for audio stereo and nack, disableNACK removes NACK from all codecs and
+// leaves opus in-band FEC as the only recovery of audio losses
+func configureTransceiver(tr *webrtc.RTPTransceiver, stereo bool, nack bool, disableNACK bool) {
 	sender := tr.Sender()
 	if sender == nil {
 		return
@@ -2031,7 +2417,10 @@ func configureAudioTransceiver(tr *webrtc.RTPTransceiver, stereo bool, nack bool
 			if stereo {
 				c.SDPFmtpLine += ";sprop-stereo=1"
 			}
-			if nack {
+			if disableNACK && !strings.Contains(c.SDPFmtpLine, "useinbandfec=1") {
+				c.SDPFmtpLine += ";useinbandfec=1"
+			}
+			if nack && !disableNACK {
 				var nackFound bool
 				for _, fb := range c.RTCPFeedback {
 					if fb.Type == webrtc.TypeRTCPFBNACK {
@@ -2044,6 +2433,9 @@ func configureAudioTransceiver(tr *webrtc.RTPTransceiver, stereo bool, nack bool
 				}
 			}
 		}
+		if disableNACK {
+			c.RTCPFeedback = withoutNACK(c.RTCPFeedback)
+		}
 		configCodecs = append(configCodecs, c)
 	}
 
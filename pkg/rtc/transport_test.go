@@ -521,25 +521,85 @@ func connectTransports(t *testing.T, offerer, answerer *PCTransport, isICERestar
 	}, 10*time.Second, time.Millisecond*10, "answerer did not become connected")
 }
 
-func TestConfigureAudioTransceiver(t *testing.T) {
+func TestReplaceSpareTrack(t *testing.T) {
+	params := TransportParams{
+		ParticipantID:       "id",
+		ParticipantIdentity: "identity",
+		Config:              &WebRTCConfig{},
+		IsOfferer:           true,
+		EnabledCodecs:       []*livekit.Codec{{Mime: webrtc.MimeTypeOpus}, {Mime: webrtc.MimeTypeVP8}},
+	}
+	transportA, err := NewPCTransport(params)
+	require.NoError(t, err)
+	defer transportA.Close()
+	require.NoError(t, transportA.AddSpareTransceivers(2))
+
+	paramsB := params
+	paramsB.IsOfferer = false
+	transportB, err := NewPCTransport(paramsB)
+	require.NoError(t, err)
+	defer transportB.Close()
+
+	newTrack := func() webrtc.TrackLocal {
+		track, err := webrtc.NewTrackLocalStaticRTP(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}, "audio", "stream")
+		require.NoError(t, err)
+		return track
+	}
+	noop := func(*webrtc.RTPTransceiver) {}
+
+	// spares are not usable until negotiated
+	_, _, err = transportA.ReplaceSpareTrack(newTrack(), noop)
+	require.ErrorIs(t, err, ErrNoSpareTransceiver)
+
+	handleICEExchange(t, transportA, transportB)
+	connectTransports(t, transportA, transportB, false, 1, 1)
+
+	var bound []*webrtc.RTPTransceiver
+	var senders []*webrtc.RTPSender
+	for i := 0; i < 2; i++ {
+		track := newTrack()
+		sender, tr, err := transportA.ReplaceSpareTrack(track, func(tr *webrtc.RTPTransceiver) {
+			bound = append(bound, tr)
+		})
+		require.NoError(t, err)
+		require.Equal(t, webrtc.RTPCodecTypeAudio, tr.Kind())
+		require.Equal(t, track, sender.Track())
+		require.Equal(t, tr, bound[i])
+		senders = append(senders, sender)
+	}
+	require.NotEqual(t, bound[0], bound[1])
+
+	_, _, err = transportA.ReplaceSpareTrack(newTrack(), noop)
+	require.ErrorIs(t, err, ErrNoSpareTransceiver)
+
+	// a transceiver without a track is a spare again
+	require.NoError(t, senders[0].ReplaceTrack(nil))
+	_, tr, err := transportA.ReplaceSpareTrack(newTrack(), noop)
+	require.NoError(t, err)
+	require.Equal(t, bound[0], tr)
+}
+
+func TestConfigureTransceiver(t *testing.T) {
 	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
 	require.NoError(t, err)
 	defer pc.Close()
 
 	for _, testcase := range []struct {
-		nack   bool
-		stereo bool
+		nack        bool
+		stereo      bool
+		disableNACK bool
 	}{
-		{false, false},
-		{true, false},
-		{false, true},
-		{true, true},
+		{false, false, false},
+		{true, false, false},
+		{false, true, false},
+		{true, true, false},
+		{true, false, true},
 	} {
-		t.Run(fmt.Sprintf("nack=%v,stereo=%v", testcase.nack, testcase.stereo), func(t *testing.T) {
+		t.Run(fmt.Sprintf("nack=%v,stereo=%v,disableNACK=%v", testcase.nack, testcase.stereo, testcase.disableNACK), func(t *testing.T) {
 			tr, err := pc.AddTransceiverFromKind(webrtc.RTPCodecTypeAudio, webrtc.RtpTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly})
 			require.NoError(t, err)
 
-			configureAudioTransceiver(tr, testcase.stereo, testcase.nack)
+			configureTransceiver(tr, testcase.stereo, testcase.nack, testcase.disableNACK)
 			codecs := tr.Sender().GetParameters().Codecs
 			for _, codec := range codecs {
 				if strings.Contains(codec.MimeType, webrtc.MimeTypeOpus) {
@@ -551,7 +611,10 @@ func TestConfigureAudioTransceiver(t *testing.T) {
 							break
 						}
 					}
-					require.Equal(t, testcase.nack, nackEnabled)
+					require.Equal(t, testcase.nack && !testcase.disableNACK, nackEnabled)
+					if testcase.disableNACK {
+						require.Contains(t, codec.SDPFmtpLine, "useinbandfec=1")
+					}
 				}
 			}
 		})
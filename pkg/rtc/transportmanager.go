@@ -46,6 +46,9 @@ const (
 	udpLossFracUnstable = 25
 	// if in last 32 times RR, the unstable report count over this threshold, the connection is unstable
 	udpLossUnstableCountThreshold = 20
+
+	// transceivers of each kind negotiated up front with clients that cannot renegotiate, for tracks subscribed later
+	spareSubscriberTransceivers = 2
 )
 
 type TransportManagerParams struct {
@@ -66,7 +69,11 @@ type TransportManagerParams struct {
 	AllowUDPUnstableFallback bool
 	TURNSEnabled             bool
 	AllowPlayoutDelay        bool
-	Logger                   logger.Logger
+	ForceRelay               bool
+	PublisherIPFilter        *IPFilter
+	// the subscriber connection is negotiated once, see ParticipantOptions.SingleNegotiation
+	SingleNegotiation bool
+	Logger            logger.Logger
 }
 
 type TransportManager struct {
@@ -81,6 +88,7 @@ type TransportManager struct {
 	lastFailure             time.Time
 	lastSignalAt            time.Time
 	signalSourceValid       atomic.Bool
+	subscriberNegotiated    atomic.Bool
 
 	pendingOfferPublisher        *webrtc.SessionDescription
 	pendingDataChannelsPublisher []*livekit.DataChannelInfo
@@ -94,6 +102,7 @@ type TransportManager struct {
 
 	onPublisherInitialConnected        func()
 	onSubscriberInitialConnected       func()
+	onSubscriberReconnected            func()
 	onPrimaryTransportInitialConnected func()
 	onAnyTransportFailed               func()
 
@@ -152,6 +161,8 @@ func NewTransportManager(params TransportManagerParams) (*TransportManager, erro
 		Logger:                  LoggerWithPCTarget(params.Logger, livekit.SignalTarget_PUBLISHER),
 		SimTracks:               params.SimTracks,
 		ClientInfo:              params.ClientInfo,
+		ForceRelay:              params.ForceRelay,
+		RemoteIPFilter:          params.PublisherIPFilter,
 	})
 	if err != nil {
 		return nil, err
@@ -183,6 +194,7 @@ func NewTransportManager(params TransportManagerParams) (*TransportManager, erro
 		EnabledCodecs:           subscribeCodecs,
 		Logger:                  LoggerWithPCTarget(params.Logger, livekit.SignalTarget_SUBSCRIBER),
 		ClientInfo:              params.ClientInfo,
+		ForceRelay:              params.ForceRelay,
 		IsOfferer:               true,
 		IsSendSide:              true,
 		AllowPlayoutDelay:       params.AllowPlayoutDelay,
@@ -199,6 +211,11 @@ func NewTransportManager(params TransportManagerParams) (*TransportManager, erro
 			t.onPrimaryTransportInitialConnected()
 		}
 	})
+	t.subscriber.OnReconnected(func() {
+		if t.onSubscriberReconnected != nil {
+			t.onSubscriberReconnected()
+		}
+	})
 	t.subscriber.OnFailed(func(isShortLived bool) {
 		t.handleConnectionFailed(isShortLived)
 		if t.onAnyTransportFailed != nil {
@@ -240,6 +257,10 @@ func (t *TransportManager) OnPublisherInitialConnected(f func()) {
 	t.onPublisherInitialConnected = f
 }
 
+func (t *TransportManager) OnPublisherRemoteIPRejected(f func(address string)) {
+	t.publisher.OnRemoteIPRejected(f)
+}
+
 func (t *TransportManager) OnPublisherTrack(f func(track *webrtc.TrackRemote, rtpReceiver *webrtc.RTPReceiver)) {
 	t.publisher.OnTrack(f)
 }
@@ -272,10 +293,18 @@ func (t *TransportManager) OnSubscriberOffer(f func(offer webrtc.SessionDescript
 	t.subscriber.OnOffer(f)
 }
 
+func (t *TransportManager) OnSubscriberAnswer(f func(answer webrtc.SessionDescription) error) {
+	t.subscriber.OnAnswer(f)
+}
+
 func (t *TransportManager) OnSubscriberInitialConnected(f func()) {
 	t.onSubscriberInitialConnected = f
 }
 
+func (t *TransportManager) OnSubscriberReconnected(f func()) {
+	t.onSubscriberReconnected = f
+}
+
 func (t *TransportManager) OnSubscriberStreamStateChange(f func(update *streamallocator.StreamStateUpdate) error) {
 	t.subscriber.OnStreamStateChange(f)
 }
@@ -292,7 +321,23 @@ func (t *TransportManager) AddTransceiverFromTrackToSubscriber(trackLocal webrtc
 	return t.subscriber.AddTransceiverFromTrack(trackLocal, params)
 }
 
+// ReplaceSpareSubscriberTrack sends the track on a spare transceiver of a subscriber connection that cannot be
+// renegotiated, returning no transceiver when the track should be added instead
+func (t *TransportManager) ReplaceSpareSubscriberTrack(
+	trackLocal webrtc.TrackLocal,
+	beforeBind func(transceiver *webrtc.RTPTransceiver),
+) (*webrtc.RTPSender, *webrtc.RTPTransceiver, error) {
+	if !t.params.SingleNegotiation || !t.subscriberNegotiated.Load() {
+		return nil, nil, nil
+	}
+	return t.subscriber.ReplaceSpareTrack(trackLocal, beforeBind)
+}
+
 func (t *TransportManager) RemoveTrackFromSubscriber(sender *webrtc.RTPSender) error {
+	if t.params.SingleNegotiation && t.subscriberNegotiated.Load() {
+		// keep the transceiver negotiated, it is a spare for the next track
+		return sender.ReplaceTrack(nil)
+	}
 	return t.subscriber.RemoveTrack(sender)
 }
 
@@ -334,6 +379,11 @@ func (t *TransportManager) OnDataMessage(f func(kind livekit.DataPacket_Kind, da
 	t.publisher.OnDataPacket(f)
 }
 
+func (t *TransportManager) OnReliableDataStalled(f func()) {
+	t.publisher.OnReliableDataStalled(f)
+	t.subscriber.OnReliableDataStalled(f)
+}
+
 func (t *TransportManager) SendDataPacket(dp *livekit.DataPacket, data []byte) error {
 	// downstream data is sent via primary peer connection
 	return t.getTransport(true).SendDataPacket(dp, data)
@@ -463,6 +513,20 @@ func (t *TransportManager) HandleAnswer(answer webrtc.SessionDescription) {
 	t.subscriber.HandleRemoteDescription(answer)
 }
 
+// HandleSubscriberOffer answers an offer for the subscriber connection, made by a client that cannot renegotiate
+// in place of the server's offer
+func (t *TransportManager) HandleSubscriberOffer(offer webrtc.SessionDescription) {
+	if !t.subscriberNegotiated.CompareAndSwap(false, true) {
+		t.params.Logger.Warnw("ignoring subscriber offer, already negotiated", nil)
+		return
+	}
+	// spares added after the subscribed tracks are only used by media sections not matched by a track
+	if err := t.subscriber.AddSpareTransceivers(spareSubscriberTransceivers); err != nil {
+		t.params.Logger.Warnw("could not add spare transceivers", err)
+	}
+	t.subscriber.HandleRemoteDescription(offer)
+}
+
 // AddICECandidate adds candidates for remote peer
 func (t *TransportManager) AddICECandidate(candidate webrtc.ICECandidateInit, target livekit.SignalTarget) {
 	if !t.params.Config.UseMDNS {
@@ -490,6 +554,15 @@ func (t *TransportManager) AddICECandidate(candidate webrtc.ICECandidateInit, ta
 }
 
 func (t *TransportManager) NegotiateSubscriber(force bool) {
+	if t.params.SingleNegotiation {
+		// only the first forced negotiation is sent, with spares for tracks subscribed afterwards
+		if !force || !t.subscriberNegotiated.CompareAndSwap(false, true) {
+			return
+		}
+		if err := t.subscriber.AddSpareTransceivers(spareSubscriberTransceivers); err != nil {
+			t.params.Logger.Warnw("could not add spare transceivers", err)
+		}
+	}
 	t.subscriber.Negotiate(force)
 }
 
@@ -585,6 +658,25 @@ func (t *TransportManager) GetICEConnectionType() types.ICEConnectionType {
 	return t.getTransport(true).GetICEConnectionType()
 }
 
+func (t *TransportManager) GetICEAddressFamily() types.ICEAddressFamily {
+	return t.getTransport(true).GetICEAddressFamily()
+}
+
+// KillTransport closes the DTLS transport of the publisher or subscriber PeerConnection, for chaos testing
+func (t *TransportManager) KillTransport(target livekit.SignalTarget) error {
+	if target == livekit.SignalTarget_PUBLISHER {
+		return t.publisher.KillDTLS()
+	}
+	return t.subscriber.KillDTLS()
+}
+
+func (t *TransportManager) GetTransportStats(target livekit.SignalTarget) webrtc.StatsReport {
+	if target == livekit.SignalTarget_PUBLISHER {
+		return t.publisher.GetStats()
+	}
+	return t.subscriber.GetStats()
+}
+
 func (t *TransportManager) getTransport(isPrimary bool) *PCTransport {
 	pcTransport := t.publisher
 	if (isPrimary && t.params.SubscriberAsPrimary) || (!isPrimary && !t.params.SubscriberAsPrimary) {
@@ -39,6 +39,7 @@ type WebsocketClient interface {
 	ReadMessage() (messageType int, p []byte, err error)
 	WriteMessage(messageType int, data []byte) error
 	WriteControl(messageType int, data []byte, deadline time.Time) error
+	Close() error
 }
 
 type AddSubscriberParams struct {
@@ -103,6 +104,8 @@ const (
 	ParticipantCloseReasonPublicationError
 	ParticipantCloseReasonSubscriptionError
 	ParticipantCloseReasonDataChannelError
+	ParticipantCloseReasonRelayRequired
+	ParticipantCloseReasonPublisherIPRejected
 )
 
 func (p ParticipantCloseReason) String() string {
@@ -151,6 +154,10 @@ func (p ParticipantCloseReason) String() string {
 		return "SUBSCRIPTION_ERROR"
 	case ParticipantCloseReasonDataChannelError:
 		return "DATA_CHANNEL_ERROR"
+	case ParticipantCloseReasonRelayRequired:
+		return "RELAY_REQUIRED"
+	case ParticipantCloseReasonPublisherIPRejected:
+		return "PUBLISHER_IP_REJECTED"
 	default:
 		return fmt.Sprintf("%d", int(p))
 	}
@@ -169,7 +176,7 @@ func (p ParticipantCloseReason) ToDisconnectReason() livekit.DisconnectReason {
 		return livekit.DisconnectReason_STATE_MISMATCH
 	case ParticipantCloseReasonDuplicateIdentity, ParticipantCloseReasonMigrationComplete, ParticipantCloseReasonStale:
 		return livekit.DisconnectReason_DUPLICATE_IDENTITY
-	case ParticipantCloseReasonServiceRequestRemoveParticipant:
+	case ParticipantCloseReasonServiceRequestRemoveParticipant, ParticipantCloseReasonPublisherIPRejected:
 		return livekit.DisconnectReason_PARTICIPANT_REMOVED
 	case ParticipantCloseReasonServiceRequestDeleteRoom:
 		return livekit.DisconnectReason_ROOM_DELETED
@@ -181,7 +188,7 @@ func (p ParticipantCloseReason) ToDisconnectReason() livekit.DisconnectReason {
 		return livekit.DisconnectReason_SERVER_SHUTDOWN
 	case ParticipantCloseReasonOvercommitted:
 		return livekit.DisconnectReason_SERVER_SHUTDOWN
-	case ParticipantCloseReasonNegotiateFailed, ParticipantCloseReasonPublicationError, ParticipantCloseReasonSubscriptionError, ParticipantCloseReasonDataChannelError:
+	case ParticipantCloseReasonNegotiateFailed, ParticipantCloseReasonPublicationError, ParticipantCloseReasonSubscriptionError, ParticipantCloseReasonDataChannelError, ParticipantCloseReasonRelayRequired:
 		return livekit.DisconnectReason_STATE_MISMATCH
 	default:
 		// the other types will map to unknown reason
@@ -191,6 +198,17 @@ func (p ParticipantCloseReason) ToDisconnectReason() livekit.DisconnectReason {
 
 // ---------------------------------------------
 
+type SubscriptionRevokedReason string
+
+const (
+	// the publisher no longer allows the subscriber to subscribe to the track
+	SubscriptionRevokedReasonTrackPermission SubscriptionRevokedReason = "track_permission"
+	// the subscriber is no longer granted canSubscribe
+	SubscriptionRevokedReasonSubscribePermission SubscriptionRevokedReason = "subscribe_permission"
+)
+
+// ---------------------------------------------
+
 type SignallingCloseReason int
 
 const (
@@ -287,9 +305,19 @@ const (
 	ICEConnectionTypeUnknown ICEConnectionType = "unknown"
 )
 
+type ICEAddressFamily string
+
+const (
+	ICEAddressFamilyIPv4    ICEAddressFamily = "ipv4"
+	ICEAddressFamilyIPv6    ICEAddressFamily = "ipv6"
+	ICEAddressFamilyNAT64   ICEAddressFamily = "nat64"
+	ICEAddressFamilyUnknown ICEAddressFamily = "unknown"
+)
+
 type AddTrackParams struct {
-	Stereo bool
-	Red    bool
+	Stereo      bool
+	Red         bool
+	DisableNACK bool
 }
 
 //counterfeiter:generate . LocalParticipant
@@ -313,8 +341,9 @@ type LocalParticipant interface {
 	GetClientInfo() *livekit.ClientInfo
 	GetClientConfiguration() *livekit.ClientConfiguration
 	GetICEConnectionType() ICEConnectionType
+	GetICEAddressFamily() ICEAddressFamily
 	GetBufferFactory() *buffer.Factory
-	GetPlayoutDelayConfig() *livekit.PlayoutDelay
+	GetPlayoutDelayConfig(source livekit.TrackSource) *livekit.PlayoutDelay
 	GetPendingTrack(trackID livekit.TrackID) *livekit.TrackInfo
 
 	SetResponseSink(sink routing.MessageSink)
@@ -337,10 +366,12 @@ type LocalParticipant interface {
 	SetTrackMuted(trackID livekit.TrackID, muted bool, fromAdmin bool)
 
 	HandleAnswer(sdp webrtc.SessionDescription)
+	HandleSubscriberOffer(sdp webrtc.SessionDescription)
 	Negotiate(force bool)
 	ICERestart(iceConfig *livekit.ICEConfig)
 	AddTrackToSubscriber(trackLocal webrtc.TrackLocal, params AddTrackParams) (*webrtc.RTPSender, *webrtc.RTPTransceiver, error)
 	AddTransceiverFromTrackToSubscriber(trackLocal webrtc.TrackLocal, params AddTrackParams) (*webrtc.RTPSender, *webrtc.RTPTransceiver, error)
+	ReplaceSpareSubscriberTrack(trackLocal webrtc.TrackLocal, beforeBind func(transceiver *webrtc.RTPTransceiver)) (*webrtc.RTPSender, *webrtc.RTPTransceiver, error)
 	RemoveTrackFromSubscriber(sender *webrtc.RTPSender) error
 
 	// subscriptions
@@ -367,6 +398,8 @@ type LocalParticipant interface {
 	SendRoomUpdate(room *livekit.Room) error
 	SendConnectionQualityUpdate(update *livekit.ConnectionQualityUpdate) error
 	SubscriptionPermissionUpdate(publisherID livekit.ParticipantID, trackID livekit.TrackID, allowed bool)
+	// SubscriptionRevoked notifies the participant that a subscription it had was torn down because of a permission change
+	SubscriptionRevoked(trackID livekit.TrackID, reason SubscriptionRevokedReason)
 	SendRefreshToken(token string) error
 	HandleReconnectAndSendResponse(reconnectReason livekit.ReconnectReason, reconnectResponse *livekit.ReconnectResponse) error
 	IssueFullReconnect(reason ParticipantCloseReason)
@@ -411,6 +444,10 @@ type LocalParticipant interface {
 	SetSubscriberAllowPause(allowPause bool)
 	SetSubscriberChannelCapacity(channelCapacity int64)
 
+	// audio only mode pauses all subscribed video
+	SetAudioOnly(audioOnly bool)
+	IsAudioOnly() bool
+
 	GetPacer() pacer.Pacer
 }
 
@@ -428,7 +465,7 @@ type Room interface {
 	UpdateVideoLayers(participant Participant, updateVideoLayers *livekit.UpdateVideoLayers) error
 	ResolveMediaTrackForSubscriber(subIdentity livekit.ParticipantIdentity, trackID livekit.TrackID) MediaResolverResult
 	GetLocalParticipants() []LocalParticipant
-	UpdateParticipantMetadata(participant LocalParticipant, name string, metadata string)
+	UpdateParticipantMetadata(participant LocalParticipant, name string, metadata string, actor livekit.ParticipantIdentity) error
 }
 
 // MediaTrack represents a media track
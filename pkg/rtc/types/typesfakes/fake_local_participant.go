@@ -233,6 +233,16 @@ type FakeLocalParticipant struct {
 	getConnectionQualityReturnsOnCall map[int]struct {
 		result1 *livekit.ConnectionQualityInfo
 	}
+	GetICEAddressFamilyStub        func() types.ICEAddressFamily
+	getICEAddressFamilyMutex       sync.RWMutex
+	getICEAddressFamilyArgsForCall []struct {
+	}
+	getICEAddressFamilyReturns struct {
+		result1 types.ICEAddressFamily
+	}
+	getICEAddressFamilyReturnsOnCall map[int]struct {
+		result1 types.ICEAddressFamily
+	}
 	GetICEConnectionTypeStub        func() types.ICEConnectionType
 	getICEConnectionTypeMutex       sync.RWMutex
 	getICEConnectionTypeArgsForCall []struct {
@@ -274,9 +284,10 @@ type FakeLocalParticipant struct {
 	getPendingTrackReturnsOnCall map[int]struct {
 		result1 *livekit.TrackInfo
 	}
-	GetPlayoutDelayConfigStub        func() *livekit.PlayoutDelay
+	GetPlayoutDelayConfigStub        func(livekit.TrackSource) *livekit.PlayoutDelay
 	getPlayoutDelayConfigMutex       sync.RWMutex
 	getPlayoutDelayConfigArgsForCall []struct {
+		arg1 livekit.TrackSource
 	}
 	getPlayoutDelayConfigReturns struct {
 		result1 *livekit.PlayoutDelay
@@ -361,6 +372,11 @@ type FakeLocalParticipant struct {
 	handleSignalSourceCloseMutex       sync.RWMutex
 	handleSignalSourceCloseArgsForCall []struct {
 	}
+	HandleSubscriberOfferStub        func(webrtc.SessionDescription)
+	handleSubscriberOfferMutex       sync.RWMutex
+	handleSubscriberOfferArgsForCall []struct {
+		arg1 webrtc.SessionDescription
+	}
 	HasPermissionStub        func(livekit.TrackID, livekit.ParticipantIdentity) bool
 	hasPermissionMutex       sync.RWMutex
 	hasPermissionArgsForCall []struct {
@@ -408,6 +424,16 @@ type FakeLocalParticipant struct {
 	identityReturnsOnCall map[int]struct {
 		result1 livekit.ParticipantIdentity
 	}
+	IsAudioOnlyStub        func() bool
+	isAudioOnlyMutex       sync.RWMutex
+	isAudioOnlyArgsForCall []struct {
+	}
+	isAudioOnlyReturns struct {
+		result1 bool
+	}
+	isAudioOnlyReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	IsClosedStub        func() bool
 	isClosedMutex       sync.RWMutex
 	isClosedArgsForCall []struct {
@@ -600,6 +626,22 @@ type FakeLocalParticipant struct {
 	removeTrackFromSubscriberReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ReplaceSpareSubscriberTrackStub        func(webrtc.TrackLocal, func(*webrtc.RTPTransceiver)) (*webrtc.RTPSender, *webrtc.RTPTransceiver, error)
+	replaceSpareSubscriberTrackMutex       sync.RWMutex
+	replaceSpareSubscriberTrackArgsForCall []struct {
+		arg1 webrtc.TrackLocal
+		arg2 func(*webrtc.RTPTransceiver)
+	}
+	replaceSpareSubscriberTrackReturns struct {
+		result1 *webrtc.RTPSender
+		result2 *webrtc.RTPTransceiver
+		result3 error
+	}
+	replaceSpareSubscriberTrackReturnsOnCall map[int]struct {
+		result1 *webrtc.RTPSender
+		result2 *webrtc.RTPTransceiver
+		result3 error
+	}
 	SendConnectionQualityUpdateStub        func(*livekit.ConnectionQualityUpdate) error
 	sendConnectionQualityUpdateMutex       sync.RWMutex
 	sendConnectionQualityUpdateArgsForCall []struct {
@@ -679,6 +721,11 @@ type FakeLocalParticipant struct {
 	sendSpeakerUpdateReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SetAudioOnlyStub        func(bool)
+	setAudioOnlyMutex       sync.RWMutex
+	setAudioOnlyArgsForCall []struct {
+		arg1 bool
+	}
 	SetICEConfigStub        func(*livekit.ICEConfig)
 	setICEConfigMutex       sync.RWMutex
 	setICEConfigArgsForCall []struct {
@@ -793,6 +840,12 @@ type FakeLocalParticipant struct {
 		arg2 livekit.TrackID
 		arg3 bool
 	}
+	SubscriptionRevokedStub        func(livekit.TrackID, types.SubscriptionRevokedReason)
+	subscriptionRevokedMutex       sync.RWMutex
+	subscriptionRevokedArgsForCall []struct {
+		arg1 livekit.TrackID
+		arg2 types.SubscriptionRevokedReason
+	}
 	SupportSyncStreamIDStub        func() bool
 	supportSyncStreamIDMutex       sync.RWMutex
 	supportSyncStreamIDArgsForCall []struct {
@@ -2021,6 +2074,59 @@ func (fake *FakeLocalParticipant) GetConnectionQualityReturnsOnCall(i int, resul
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) GetICEAddressFamily() types.ICEAddressFamily {
+	fake.getICEAddressFamilyMutex.Lock()
+	ret, specificReturn := fake.getICEAddressFamilyReturnsOnCall[len(fake.getICEAddressFamilyArgsForCall)]
+	fake.getICEAddressFamilyArgsForCall = append(fake.getICEAddressFamilyArgsForCall, struct {
+	}{})
+	stub := fake.GetICEAddressFamilyStub
+	fakeReturns := fake.getICEAddressFamilyReturns
+	fake.recordInvocation("GetICEAddressFamily", []interface{}{})
+	fake.getICEAddressFamilyMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetICEAddressFamilyCallCount() int {
+	fake.getICEAddressFamilyMutex.RLock()
+	defer fake.getICEAddressFamilyMutex.RUnlock()
+	return len(fake.getICEAddressFamilyArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetICEAddressFamilyCalls(stub func() types.ICEAddressFamily) {
+	fake.getICEAddressFamilyMutex.Lock()
+	defer fake.getICEAddressFamilyMutex.Unlock()
+	fake.GetICEAddressFamilyStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetICEAddressFamilyReturns(result1 types.ICEAddressFamily) {
+	fake.getICEAddressFamilyMutex.Lock()
+	defer fake.getICEAddressFamilyMutex.Unlock()
+	fake.GetICEAddressFamilyStub = nil
+	fake.getICEAddressFamilyReturns = struct {
+		result1 types.ICEAddressFamily
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetICEAddressFamilyReturnsOnCall(i int, result1 types.ICEAddressFamily) {
+	fake.getICEAddressFamilyMutex.Lock()
+	defer fake.getICEAddressFamilyMutex.Unlock()
+	fake.GetICEAddressFamilyStub = nil
+	if fake.getICEAddressFamilyReturnsOnCall == nil {
+		fake.getICEAddressFamilyReturnsOnCall = make(map[int]struct {
+			result1 types.ICEAddressFamily
+		})
+	}
+	fake.getICEAddressFamilyReturnsOnCall[i] = struct {
+		result1 types.ICEAddressFamily
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) GetICEConnectionType() types.ICEConnectionType {
 	fake.getICEConnectionTypeMutex.Lock()
 	ret, specificReturn := fake.getICEConnectionTypeReturnsOnCall[len(fake.getICEConnectionTypeArgsForCall)]
@@ -2241,17 +2347,18 @@ func (fake *FakeLocalParticipant) GetPendingTrackReturnsOnCall(i int, result1 *l
 	}{result1}
 }
 
-func (fake *FakeLocalParticipant) GetPlayoutDelayConfig() *livekit.PlayoutDelay {
+func (fake *FakeLocalParticipant) GetPlayoutDelayConfig(arg1 livekit.TrackSource) *livekit.PlayoutDelay {
 	fake.getPlayoutDelayConfigMutex.Lock()
 	ret, specificReturn := fake.getPlayoutDelayConfigReturnsOnCall[len(fake.getPlayoutDelayConfigArgsForCall)]
 	fake.getPlayoutDelayConfigArgsForCall = append(fake.getPlayoutDelayConfigArgsForCall, struct {
-	}{})
+		arg1 livekit.TrackSource
+	}{arg1})
 	stub := fake.GetPlayoutDelayConfigStub
 	fakeReturns := fake.getPlayoutDelayConfigReturns
-	fake.recordInvocation("GetPlayoutDelayConfig", []interface{}{})
+	fake.recordInvocation("GetPlayoutDelayConfig", []interface{}{arg1})
 	fake.getPlayoutDelayConfigMutex.Unlock()
 	if stub != nil {
-		return stub()
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
@@ -2265,12 +2372,19 @@ func (fake *FakeLocalParticipant) GetPlayoutDelayConfigCallCount() int {
 	return len(fake.getPlayoutDelayConfigArgsForCall)
 }
 
-func (fake *FakeLocalParticipant) GetPlayoutDelayConfigCalls(stub func() *livekit.PlayoutDelay) {
+func (fake *FakeLocalParticipant) GetPlayoutDelayConfigCalls(stub func(livekit.TrackSource) *livekit.PlayoutDelay) {
 	fake.getPlayoutDelayConfigMutex.Lock()
 	defer fake.getPlayoutDelayConfigMutex.Unlock()
 	fake.GetPlayoutDelayConfigStub = stub
 }
 
+func (fake *FakeLocalParticipant) GetPlayoutDelayConfigArgsForCall(i int) livekit.TrackSource {
+	fake.getPlayoutDelayConfigMutex.RLock()
+	defer fake.getPlayoutDelayConfigMutex.RUnlock()
+	argsForCall := fake.getPlayoutDelayConfigArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) GetPlayoutDelayConfigReturns(result1 *livekit.PlayoutDelay) {
 	fake.getPlayoutDelayConfigMutex.Lock()
 	defer fake.getPlayoutDelayConfigMutex.Unlock()
@@ -2717,6 +2831,38 @@ func (fake *FakeLocalParticipant) HandleSignalSourceCloseCalls(stub func()) {
 	fake.HandleSignalSourceCloseStub = stub
 }
 
+func (fake *FakeLocalParticipant) HandleSubscriberOffer(arg1 webrtc.SessionDescription) {
+	fake.handleSubscriberOfferMutex.Lock()
+	fake.handleSubscriberOfferArgsForCall = append(fake.handleSubscriberOfferArgsForCall, struct {
+		arg1 webrtc.SessionDescription
+	}{arg1})
+	stub := fake.HandleSubscriberOfferStub
+	fake.recordInvocation("HandleSubscriberOffer", []interface{}{arg1})
+	fake.handleSubscriberOfferMutex.Unlock()
+	if stub != nil {
+		fake.HandleSubscriberOfferStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) HandleSubscriberOfferCallCount() int {
+	fake.handleSubscriberOfferMutex.RLock()
+	defer fake.handleSubscriberOfferMutex.RUnlock()
+	return len(fake.handleSubscriberOfferArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) HandleSubscriberOfferCalls(stub func(webrtc.SessionDescription)) {
+	fake.handleSubscriberOfferMutex.Lock()
+	defer fake.handleSubscriberOfferMutex.Unlock()
+	fake.HandleSubscriberOfferStub = stub
+}
+
+func (fake *FakeLocalParticipant) HandleSubscriberOfferArgsForCall(i int) webrtc.SessionDescription {
+	fake.handleSubscriberOfferMutex.RLock()
+	defer fake.handleSubscriberOfferMutex.RUnlock()
+	argsForCall := fake.handleSubscriberOfferArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) HasPermission(arg1 livekit.TrackID, arg2 livekit.ParticipantIdentity) bool {
 	fake.hasPermissionMutex.Lock()
 	ret, specificReturn := fake.hasPermissionReturnsOnCall[len(fake.hasPermissionArgsForCall)]
@@ -2970,6 +3116,59 @@ func (fake *FakeLocalParticipant) IdentityReturnsOnCall(i int, result1 livekit.P
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) IsAudioOnly() bool {
+	fake.isAudioOnlyMutex.Lock()
+	ret, specificReturn := fake.isAudioOnlyReturnsOnCall[len(fake.isAudioOnlyArgsForCall)]
+	fake.isAudioOnlyArgsForCall = append(fake.isAudioOnlyArgsForCall, struct {
+	}{})
+	stub := fake.IsAudioOnlyStub
+	fakeReturns := fake.isAudioOnlyReturns
+	fake.recordInvocation("IsAudioOnly", []interface{}{})
+	fake.isAudioOnlyMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) IsAudioOnlyCallCount() int {
+	fake.isAudioOnlyMutex.RLock()
+	defer fake.isAudioOnlyMutex.RUnlock()
+	return len(fake.isAudioOnlyArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) IsAudioOnlyCalls(stub func() bool) {
+	fake.isAudioOnlyMutex.Lock()
+	defer fake.isAudioOnlyMutex.Unlock()
+	fake.IsAudioOnlyStub = stub
+}
+
+func (fake *FakeLocalParticipant) IsAudioOnlyReturns(result1 bool) {
+	fake.isAudioOnlyMutex.Lock()
+	defer fake.isAudioOnlyMutex.Unlock()
+	fake.IsAudioOnlyStub = nil
+	fake.isAudioOnlyReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) IsAudioOnlyReturnsOnCall(i int, result1 bool) {
+	fake.isAudioOnlyMutex.Lock()
+	defer fake.isAudioOnlyMutex.Unlock()
+	fake.IsAudioOnlyStub = nil
+	if fake.isAudioOnlyReturnsOnCall == nil {
+		fake.isAudioOnlyReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isAudioOnlyReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) IsClosed() bool {
 	fake.isClosedMutex.Lock()
 	ret, specificReturn := fake.isClosedReturnsOnCall[len(fake.isClosedArgsForCall)]
@@ -4061,6 +4260,74 @@ func (fake *FakeLocalParticipant) RemoveTrackFromSubscriberReturnsOnCall(i int,
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) ReplaceSpareSubscriberTrack(arg1 webrtc.TrackLocal, arg2 func(*webrtc.RTPTransceiver)) (*webrtc.RTPSender, *webrtc.RTPTransceiver, error) {
+	fake.replaceSpareSubscriberTrackMutex.Lock()
+	ret, specificReturn := fake.replaceSpareSubscriberTrackReturnsOnCall[len(fake.replaceSpareSubscriberTrackArgsForCall)]
+	fake.replaceSpareSubscriberTrackArgsForCall = append(fake.replaceSpareSubscriberTrackArgsForCall, struct {
+		arg1 webrtc.TrackLocal
+		arg2 func(*webrtc.RTPTransceiver)
+	}{arg1, arg2})
+	stub := fake.ReplaceSpareSubscriberTrackStub
+	fakeReturns := fake.replaceSpareSubscriberTrackReturns
+	fake.recordInvocation("ReplaceSpareSubscriberTrack", []interface{}{arg1, arg2})
+	fake.replaceSpareSubscriberTrackMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeLocalParticipant) ReplaceSpareSubscriberTrackCallCount() int {
+	fake.replaceSpareSubscriberTrackMutex.RLock()
+	defer fake.replaceSpareSubscriberTrackMutex.RUnlock()
+	return len(fake.replaceSpareSubscriberTrackArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) ReplaceSpareSubscriberTrackCalls(stub func(webrtc.TrackLocal, func(*webrtc.RTPTransceiver)) (*webrtc.RTPSender, *webrtc.RTPTransceiver, error)) {
+	fake.replaceSpareSubscriberTrackMutex.Lock()
+	defer fake.replaceSpareSubscriberTrackMutex.Unlock()
+	fake.ReplaceSpareSubscriberTrackStub = stub
+}
+
+func (fake *FakeLocalParticipant) ReplaceSpareSubscriberTrackArgsForCall(i int) (webrtc.TrackLocal, func(*webrtc.RTPTransceiver)) {
+	fake.replaceSpareSubscriberTrackMutex.RLock()
+	defer fake.replaceSpareSubscriberTrackMutex.RUnlock()
+	argsForCall := fake.replaceSpareSubscriberTrackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLocalParticipant) ReplaceSpareSubscriberTrackReturns(result1 *webrtc.RTPSender, result2 *webrtc.RTPTransceiver, result3 error) {
+	fake.replaceSpareSubscriberTrackMutex.Lock()
+	defer fake.replaceSpareSubscriberTrackMutex.Unlock()
+	fake.ReplaceSpareSubscriberTrackStub = nil
+	fake.replaceSpareSubscriberTrackReturns = struct {
+		result1 *webrtc.RTPSender
+		result2 *webrtc.RTPTransceiver
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeLocalParticipant) ReplaceSpareSubscriberTrackReturnsOnCall(i int, result1 *webrtc.RTPSender, result2 *webrtc.RTPTransceiver, result3 error) {
+	fake.replaceSpareSubscriberTrackMutex.Lock()
+	defer fake.replaceSpareSubscriberTrackMutex.Unlock()
+	fake.ReplaceSpareSubscriberTrackStub = nil
+	if fake.replaceSpareSubscriberTrackReturnsOnCall == nil {
+		fake.replaceSpareSubscriberTrackReturnsOnCall = make(map[int]struct {
+			result1 *webrtc.RTPSender
+			result2 *webrtc.RTPTransceiver
+			result3 error
+		})
+	}
+	fake.replaceSpareSubscriberTrackReturnsOnCall[i] = struct {
+		result1 *webrtc.RTPSender
+		result2 *webrtc.RTPTransceiver
+		result3 error
+	}{result1, result2, result3}
+}
+
 func (fake *FakeLocalParticipant) SendConnectionQualityUpdate(arg1 *livekit.ConnectionQualityUpdate) error {
 	fake.sendConnectionQualityUpdateMutex.Lock()
 	ret, specificReturn := fake.sendConnectionQualityUpdateReturnsOnCall[len(fake.sendConnectionQualityUpdateArgsForCall)]
@@ -4505,6 +4772,38 @@ func (fake *FakeLocalParticipant) SendSpeakerUpdateReturnsOnCall(i int, result1
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) SetAudioOnly(arg1 bool) {
+	fake.setAudioOnlyMutex.Lock()
+	fake.setAudioOnlyArgsForCall = append(fake.setAudioOnlyArgsForCall, struct {
+		arg1 bool
+	}{arg1})
+	stub := fake.SetAudioOnlyStub
+	fake.recordInvocation("SetAudioOnly", []interface{}{arg1})
+	fake.setAudioOnlyMutex.Unlock()
+	if stub != nil {
+		fake.SetAudioOnlyStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) SetAudioOnlyCallCount() int {
+	fake.setAudioOnlyMutex.RLock()
+	defer fake.setAudioOnlyMutex.RUnlock()
+	return len(fake.setAudioOnlyArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) SetAudioOnlyCalls(stub func(bool)) {
+	fake.setAudioOnlyMutex.Lock()
+	defer fake.setAudioOnlyMutex.Unlock()
+	fake.SetAudioOnlyStub = stub
+}
+
+func (fake *FakeLocalParticipant) SetAudioOnlyArgsForCall(i int) bool {
+	fake.setAudioOnlyMutex.RLock()
+	defer fake.setAudioOnlyMutex.RUnlock()
+	argsForCall := fake.setAudioOnlyArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) SetICEConfig(arg1 *livekit.ICEConfig) {
 	fake.setICEConfigMutex.Lock()
 	fake.setICEConfigArgsForCall = append(fake.setICEConfigArgsForCall, struct {
@@ -5153,6 +5452,39 @@ func (fake *FakeLocalParticipant) SubscriptionPermissionUpdateArgsForCall(i int)
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
+func (fake *FakeLocalParticipant) SubscriptionRevoked(arg1 livekit.TrackID, arg2 types.SubscriptionRevokedReason) {
+	fake.subscriptionRevokedMutex.Lock()
+	fake.subscriptionRevokedArgsForCall = append(fake.subscriptionRevokedArgsForCall, struct {
+		arg1 livekit.TrackID
+		arg2 types.SubscriptionRevokedReason
+	}{arg1, arg2})
+	stub := fake.SubscriptionRevokedStub
+	fake.recordInvocation("SubscriptionRevoked", []interface{}{arg1, arg2})
+	fake.subscriptionRevokedMutex.Unlock()
+	if stub != nil {
+		fake.SubscriptionRevokedStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeLocalParticipant) SubscriptionRevokedCallCount() int {
+	fake.subscriptionRevokedMutex.RLock()
+	defer fake.subscriptionRevokedMutex.RUnlock()
+	return len(fake.subscriptionRevokedArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) SubscriptionRevokedCalls(stub func(livekit.TrackID, types.SubscriptionRevokedReason)) {
+	fake.subscriptionRevokedMutex.Lock()
+	defer fake.subscriptionRevokedMutex.Unlock()
+	fake.SubscriptionRevokedStub = stub
+}
+
+func (fake *FakeLocalParticipant) SubscriptionRevokedArgsForCall(i int) (livekit.TrackID, types.SubscriptionRevokedReason) {
+	fake.subscriptionRevokedMutex.RLock()
+	defer fake.subscriptionRevokedMutex.RUnlock()
+	argsForCall := fake.subscriptionRevokedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeLocalParticipant) SupportSyncStreamID() bool {
 	fake.supportSyncStreamIDMutex.Lock()
 	ret, specificReturn := fake.supportSyncStreamIDReturnsOnCall[len(fake.supportSyncStreamIDArgsForCall)]
@@ -5895,6 +6227,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.getClientInfoMutex.RUnlock()
 	fake.getConnectionQualityMutex.RLock()
 	defer fake.getConnectionQualityMutex.RUnlock()
+	fake.getICEAddressFamilyMutex.RLock()
+	defer fake.getICEAddressFamilyMutex.RUnlock()
 	fake.getICEConnectionTypeMutex.RLock()
 	defer fake.getICEConnectionTypeMutex.RUnlock()
 	fake.getLoggerMutex.RLock()
@@ -5923,6 +6257,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.handleReconnectAndSendResponseMutex.RUnlock()
 	fake.handleSignalSourceCloseMutex.RLock()
 	defer fake.handleSignalSourceCloseMutex.RUnlock()
+	fake.handleSubscriberOfferMutex.RLock()
+	defer fake.handleSubscriberOfferMutex.RUnlock()
 	fake.hasPermissionMutex.RLock()
 	defer fake.hasPermissionMutex.RUnlock()
 	fake.hiddenMutex.RLock()
@@ -5933,6 +6269,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.iDMutex.RUnlock()
 	fake.identityMutex.RLock()
 	defer fake.identityMutex.RUnlock()
+	fake.isAudioOnlyMutex.RLock()
+	defer fake.isAudioOnlyMutex.RUnlock()
 	fake.isClosedMutex.RLock()
 	defer fake.isClosedMutex.RUnlock()
 	fake.isDisconnectedMutex.RLock()
@@ -5985,6 +6323,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.removePublishedTrackMutex.RUnlock()
 	fake.removeTrackFromSubscriberMutex.RLock()
 	defer fake.removeTrackFromSubscriberMutex.RUnlock()
+	fake.replaceSpareSubscriberTrackMutex.RLock()
+	defer fake.replaceSpareSubscriberTrackMutex.RUnlock()
 	fake.sendConnectionQualityUpdateMutex.RLock()
 	defer fake.sendConnectionQualityUpdateMutex.RUnlock()
 	fake.sendDataPacketMutex.RLock()
@@ -5999,6 +6339,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.sendRoomUpdateMutex.RUnlock()
 	fake.sendSpeakerUpdateMutex.RLock()
 	defer fake.sendSpeakerUpdateMutex.RUnlock()
+	fake.setAudioOnlyMutex.RLock()
+	defer fake.setAudioOnlyMutex.RUnlock()
 	fake.setICEConfigMutex.RLock()
 	defer fake.setICEConfigMutex.RUnlock()
 	fake.setMetadataMutex.RLock()
@@ -6033,6 +6375,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.subscriptionPermissionMutex.RUnlock()
 	fake.subscriptionPermissionUpdateMutex.RLock()
 	defer fake.subscriptionPermissionUpdateMutex.RUnlock()
+	fake.subscriptionRevokedMutex.RLock()
+	defer fake.subscriptionRevokedMutex.RUnlock()
 	fake.supportSyncStreamIDMutex.RLock()
 	defer fake.supportSyncStreamIDMutex.RUnlock()
 	fake.toProtoMutex.RLock()
@@ -0,0 +1,99 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	// loss below the congested threshold divided by this lets the estimate grow
+	uplinkRecoveryLossDivisor = 5
+	uplinkIncreaseFactor      = 1.08
+	// an estimate this many times the received bitrate no longer limits the publisher
+	uplinkReleaseFactor = 2.0
+	// sent to lift the limit of previous estimates, publishers keep the last REMB as a cap
+	uplinkReleaseBitrate = 100_000_000
+)
+
+// UplinkSample holds cumulative counters of the media received from a publisher
+type UplinkSample struct {
+	Packets     uint64
+	PacketsLost uint64
+	Bytes       uint64
+}
+
+// UplinkEstimator estimates a publisher's uplink capacity from the media received, using loss to detect
+// congestion. While congested, the estimate follows the received bitrate down, it then grows until it no longer
+// limits the publisher.
+type UplinkEstimator struct {
+	conf config.CongestionControlUplinkConfig
+
+	last     UplinkSample
+	lastAt   time.Time
+	estimate float64
+}
+
+func NewUplinkEstimator(conf config.CongestionControlUplinkConfig) *UplinkEstimator {
+	return &UplinkEstimator{
+		conf: conf,
+	}
+}
+
+// Update returns the bitrate in bps to send to the publisher, ok is false when there is nothing to send
+func (u *UplinkEstimator) Update(at time.Time, sample UplinkSample) (bitrate uint64, ok bool) {
+	last, lastAt := u.last, u.lastAt
+	u.last, u.lastAt = sample, at
+	if lastAt.IsZero() || sample.Packets < last.Packets || sample.PacketsLost < last.PacketsLost || sample.Bytes < last.Bytes {
+		// first sample, or streams went away
+		return 0, false
+	}
+
+	elapsed := at.Sub(lastAt).Seconds()
+	packets := sample.Packets - last.Packets
+	lost := sample.PacketsLost - last.PacketsLost
+	if elapsed <= 0 || packets == 0 {
+		return 0, false
+	}
+	received := float64(sample.Bytes-last.Bytes) * 8 / elapsed
+	loss := float64(lost) / float64(packets+lost)
+
+	switch {
+	case loss >= u.conf.LossThreshold:
+		base := received
+		if u.estimate != 0 && u.estimate < base {
+			base = u.estimate
+		}
+		u.estimate = max(base*(1-loss/2), float64(u.conf.MinBitrate))
+
+	case u.estimate == 0:
+		return 0, false
+
+	case loss < u.conf.LossThreshold/uplinkRecoveryLossDivisor:
+		u.estimate *= uplinkIncreaseFactor
+		if u.estimate >= received*uplinkReleaseFactor {
+			u.estimate = 0
+			return uplinkReleaseBitrate, true
+		}
+	}
+	return uint64(u.estimate), true
+}
+
+// Congested returns true while publishers are being limited
+func (u *UplinkEstimator) Congested() bool {
+	return u.estimate != 0
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestUplinkEstimator(t *testing.T) {
+	u := NewUplinkEstimator(config.CongestionControlUplinkConfig{
+		Interval:      time.Second,
+		LossThreshold: 0.1,
+		MinBitrate:    100_000,
+	})
+
+	at := time.Now()
+	var sample UplinkSample
+	// one second of 100 packets of 1250 bytes, 1 Mbps
+	step := func(lost uint64) (uint64, bool) {
+		at = at.Add(time.Second)
+		sample.Packets += 100 - lost
+		sample.PacketsLost += lost
+		sample.Bytes += (100 - lost) * 1250
+		return u.Update(at, sample)
+	}
+
+	_, ok := step(0)
+	require.False(t, ok)
+	// not congested, nothing to send
+	_, ok = step(1)
+	require.False(t, ok)
+
+	// congested, estimate follows the received bitrate down
+	bitrate, ok := step(20)
+	require.True(t, ok)
+	require.Equal(t, uint64(720_000), bitrate)
+	require.True(t, u.Congested())
+
+	bitrate, ok = step(20)
+	require.True(t, ok)
+	require.Equal(t, uint64(648_000), bitrate)
+
+	// moderate loss holds the estimate
+	bitrate, ok = step(5)
+	require.True(t, ok)
+	require.Equal(t, uint64(648_000), bitrate)
+
+	// recovering, estimate grows until it no longer limits
+	bitrate, ok = step(0)
+	require.True(t, ok)
+	require.Equal(t, uint64(699_840), bitrate)
+	for u.Congested() {
+		bitrate, ok = step(0)
+		require.True(t, ok)
+	}
+	require.Equal(t, uint64(uplinkReleaseBitrate), bitrate)
+
+	_, ok = step(0)
+	require.False(t, ok)
+
+	// streams going away reset the baseline
+	sample = UplinkSample{}
+	_, ok = step(50)
+	require.False(t, ok)
+}
@@ -383,8 +383,7 @@ func (u *UpTrackManager) getAllowedSubscribersLocked(trackID livekit.TrackID) []
 
 func (u *UpTrackManager) maybeRevokeSubscriptions(resolver func(participantIdentity livekit.ParticipantIdentity) types.LocalParticipant) {
 	u.lock.Lock()
-	defer u.lock.Unlock()
-
+	revoked := make(map[livekit.TrackID][]livekit.ParticipantIdentity)
 	for trackID, track := range u.publishedTracks {
 		allowed := u.getAllowedSubscribersLocked(trackID)
 		if allowed == nil {
@@ -392,7 +391,23 @@ func (u *UpTrackManager) maybeRevokeSubscriptions(resolver func(participantIdent
 			continue
 		}
 
-		track.RevokeDisallowedSubscribers(allowed)
+		if revokedSubscriberIdentities := track.RevokeDisallowedSubscribers(allowed); len(revokedSubscriberIdentities) != 0 {
+			revoked[trackID] = revokedSubscriberIdentities
+		}
+	}
+	u.lock.Unlock()
+
+	if resolver == nil {
+		return
+	}
+
+	// notify outside the lock, resolver may need to look up other participants
+	for trackID, subscriberIdentities := range revoked {
+		for _, subscriberIdentity := range subscriberIdentities {
+			if sub := resolver(subscriberIdentity); sub != nil {
+				sub.SubscriptionRevoked(trackID, types.SubscriptionRevokedReasonTrackPermission)
+			}
+		}
 	}
 }
 
@@ -214,6 +214,38 @@ func TestUpdateSubscriptionPermission(t *testing.T) {
 		um.UpdateSubscriptionPermission(&livekit.SubscriptionPermission{}, utils.TimedVersion{}, nil, nil)
 		require.True(t, um.subscriptionPermissionVersion.After(&v2), "zero version in updates should use next local version")
 	})
+
+	t.Run("notifies revoked subscribers", func(t *testing.T) {
+		um := NewUpTrackManager(defaultUptrackManagerParams)
+		vg := utils.NewDefaultTimedVersionGenerator()
+
+		trv := &typesfakes.FakeMediaTrack{}
+		trv.IDReturns("video")
+		trv.RevokeDisallowedSubscribersReturns([]livekit.ParticipantIdentity{"p2"})
+		um.publishedTracks["video"] = trv
+
+		p2 := &typesfakes.FakeLocalParticipant{}
+		identityResolver := func(participantIdentity livekit.ParticipantIdentity) types.LocalParticipant {
+			if participantIdentity == "p2" {
+				return p2
+			}
+			return nil
+		}
+
+		err := um.UpdateSubscriptionPermission(&livekit.SubscriptionPermission{
+			TrackPermissions: []*livekit.TrackPermission{
+				{ParticipantIdentity: "p1", AllTracks: true},
+			},
+		}, vg.Next(), identityResolver, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, trv.RevokeDisallowedSubscribersCallCount())
+		require.Equal(t, []livekit.ParticipantIdentity{"p1"}, trv.RevokeDisallowedSubscribersArgsForCall(0))
+		require.Equal(t, 1, p2.SubscriptionRevokedCallCount())
+		trackID, reason := p2.SubscriptionRevokedArgsForCall(0)
+		require.Equal(t, livekit.TrackID("video"), trackID)
+		require.Equal(t, types.SubscriptionRevokedReasonTrackPermission, reason)
+	})
 }
 
 func TestSubscriptionPermission(t *testing.T) {
@@ -280,6 +280,20 @@ func (d *DummyReceiver) DebugInfo() map[string]interface{} {
 	return nil
 }
 
+func (d *DummyReceiver) RTPStatsDebugInfo() map[string]interface{} {
+	if r, ok := d.receiver.Load().(sfu.TrackReceiver); ok {
+		return r.RTPStatsDebugInfo()
+	}
+	return nil
+}
+
+func (d *DummyReceiver) LayerMappings() []sfu.LayerMapping {
+	if r, ok := d.receiver.Load().(sfu.TrackReceiver); ok {
+		return r.LayerMappings()
+	}
+	return nil
+}
+
 func (d *DummyReceiver) GetTemporalLayerFpsForSpatial(spatial int32) []float32 {
 	if r, ok := d.receiver.Load().(sfu.TrackReceiver); ok {
 		return r.GetTemporalLayerFpsForSpatial(spatial)
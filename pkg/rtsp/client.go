@@ -0,0 +1,519 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtsp
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+)
+
+const (
+	defaultPort      = "554"
+	userAgent        = "livekit-server"
+	maxResponseBody  = 1 << 20
+	defaultTimeout   = 60 * time.Second
+	dialTimeout      = 10 * time.Second
+	readTimeout      = 10 * time.Second
+	interleavedMagic = '$'
+)
+
+var (
+	ErrInvalidURL       = errors.New("invalid RTSP URL, expected rtsp://[user:password@]host[:port]/path")
+	ErrUnauthorized     = errors.New("RTSP server rejected the credentials")
+	ErrNoSupportedMedia = errors.New("RTSP stream has no supported media")
+)
+
+// Media is a stream of the presentation described by the server
+type Media struct {
+	// audio or video
+	Kind string
+	// codec name as in the rtpmap, e.g. H264 or opus
+	Codec       string
+	ClockRate   uint32
+	Channels    uint16
+	Fmtp        string
+	PayloadType uint8
+
+	control string
+	channel int
+	setUp   bool
+}
+
+// Packet is an RTP packet of the media at Index
+type Packet struct {
+	Index  int
+	Packet *rtp.Packet
+}
+
+// Client pulls RTP over the RTSP connection (interleaved TCP transport), which works through NAT and firewalls
+type Client struct {
+	url      *url.URL
+	username string
+	password string
+
+	conn net.Conn
+	r    *bufio.Reader
+
+	writeLock sync.Mutex
+	cseq      int
+	session   string
+	timeout   time.Duration
+	auth      *authenticator
+	base      string
+
+	media []*Media
+}
+
+// Dial connects and describes the stream
+func Dial(ctx context.Context, rawURL string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "rtsp" || u.Hostname() == "" {
+		return nil, ErrInvalidURL
+	}
+	c := &Client{
+		url:     u,
+		timeout: defaultTimeout,
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	requestURL := *u
+	requestURL.User = nil
+	c.base = requestURL.String()
+
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if c.conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port)); err != nil {
+		return nil, err
+	}
+	c.r = bufio.NewReader(c.conn)
+
+	if err = c.describe(requestURL.String()); err != nil {
+		_ = c.conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Media returns the media of the presentation
+func (c *Client) Media() []*Media {
+	return c.media
+}
+
+func (c *Client) describe(requestURL string) error {
+	res, err := c.request("DESCRIBE", requestURL, map[string]string{"Accept": "application/sdp"})
+	if err != nil {
+		return err
+	}
+	for _, header := range []string{"Content-Base", "Content-Location"} {
+		if base := res.headers[header]; base != "" {
+			c.base = base
+			break
+		}
+	}
+
+	var parsed sdp.SessionDescription
+	if err = parsed.Unmarshal(res.body); err != nil {
+		return err
+	}
+	for _, md := range parsed.MediaDescriptions {
+		if md.MediaName.Media != "audio" && md.MediaName.Media != "video" || len(md.MediaName.Formats) == 0 {
+			continue
+		}
+		pt, err := strconv.Atoi(md.MediaName.Formats[0])
+		if err != nil {
+			continue
+		}
+		m := &Media{Kind: md.MediaName.Media, PayloadType: uint8(pt)}
+		if control, ok := md.Attribute("control"); ok {
+			m.control = control
+		}
+		for _, a := range md.Attributes {
+			value, ok := strings.CutPrefix(a.Value, md.MediaName.Formats[0]+" ")
+			if !ok {
+				continue
+			}
+			switch a.Key {
+			case "rtpmap":
+				fields := strings.Split(value, "/")
+				m.Codec = fields[0]
+				if len(fields) > 1 {
+					rate, _ := strconv.Atoi(fields[1])
+					m.ClockRate = uint32(rate)
+				}
+				if len(fields) > 2 {
+					channels, _ := strconv.Atoi(fields[2])
+					m.Channels = uint16(channels)
+				}
+			case "fmtp":
+				m.Fmtp = value
+			}
+		}
+		// static payload types
+		switch {
+		case m.Codec == "" && pt == 0:
+			m.Codec, m.ClockRate = "PCMU", 8000
+		case m.Codec == "" && pt == 8:
+			m.Codec, m.ClockRate = "PCMA", 8000
+		}
+		c.media = append(c.media, m)
+	}
+	if len(c.media) == 0 {
+		return ErrNoSupportedMedia
+	}
+	return nil
+}
+
+func (c *Client) controlURL(control string) string {
+	switch {
+	case control == "" || control == "*":
+		return c.base
+	case strings.HasPrefix(control, "rtsp://"):
+		return control
+	case strings.HasSuffix(c.base, "/"):
+		return c.base + control
+	default:
+		return c.base + "/" + control
+	}
+}
+
+// Play sets up the media at the given indices and starts the stream
+func (c *Client) Play(indices []int) error {
+	for i, index := range indices {
+		m := c.media[index]
+		m.channel = 2 * i
+		headers := map[string]string{"Transport": fmt.Sprintf("RTP/AVP/TCP;unicast;interleaved=%d-%d", m.channel, m.channel+1)}
+		res, err := c.request("SETUP", c.controlURL(m.control), headers)
+		if err != nil {
+			return err
+		}
+		if transport := res.headers["Transport"]; strings.Contains(transport, "interleaved=") {
+			for _, param := range strings.Split(transport, ";") {
+				if value, ok := strings.CutPrefix(param, "interleaved="); ok {
+					channel, _, _ := strings.Cut(value, "-")
+					if n, err := strconv.Atoi(channel); err == nil {
+						m.channel = n
+					}
+				}
+			}
+		}
+		m.setUp = true
+		if session := res.headers["Session"]; session != "" {
+			id, params, _ := strings.Cut(session, ";")
+			c.session = strings.TrimSpace(id)
+			if value, ok := strings.CutPrefix(strings.TrimSpace(params), "timeout="); ok {
+				if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+					c.timeout = time.Duration(seconds) * time.Second
+				}
+			}
+		}
+	}
+
+	_, err := c.request("PLAY", c.base, map[string]string{"Range": "npt=0.000-"})
+	return err
+}
+
+// KeepAlive sends requests at half the session timeout until the context is done
+func (c *Client) KeepAlive(ctx context.Context) {
+	ticker := time.NewTicker(c.timeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// the response is skipped by ReadPacket
+			if err := c.writeRequest("GET_PARAMETER", c.base, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ReadPacket returns the next RTP packet, RTCP and responses are skipped
+func (c *Client) ReadPacket() (*Packet, error) {
+	for {
+		_ = c.conn.SetReadDeadline(time.Now().Add(readTimeout))
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b != interleavedMagic {
+			if err = c.r.UnreadByte(); err != nil {
+				return nil, err
+			}
+			if _, err = c.readResponse(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var header [3]byte
+		if _, err = io.ReadFull(c.r, header[:]); err != nil {
+			return nil, err
+		}
+		data := make([]byte, int(header[1])<<8|int(header[2]))
+		if _, err = io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+
+		for index, m := range c.media {
+			if !m.setUp || m.channel != int(header[0]) {
+				continue
+			}
+			pkt := &rtp.Packet{}
+			if err = pkt.Unmarshal(data); err != nil {
+				break
+			}
+			return &Packet{Index: index, Packet: pkt}, nil
+		}
+	}
+}
+
+func (c *Client) Close() error {
+	_ = c.writeRequest("TEARDOWN", c.base, nil)
+	return c.conn.Close()
+}
+
+type response struct {
+	status  int
+	headers map[string]string
+	auth    []string
+	body    []byte
+}
+
+// request sends a request and reads its response, authenticating when challenged
+func (c *Client) request(method string, requestURL string, headers map[string]string) (*response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.writeRequest(method, requestURL, headers); err != nil {
+			return nil, err
+		}
+		_ = c.conn.SetReadDeadline(time.Now().Add(readTimeout))
+		res, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case res.status == 401 && attempt == 0 && c.username != "":
+			if c.auth, err = newAuthenticator(res.auth, c.username, c.password); err != nil {
+				return nil, err
+			}
+		case res.status == 401:
+			return nil, ErrUnauthorized
+		case res.status != 200:
+			return nil, fmt.Errorf("RTSP %s failed with status %d", method, res.status)
+		default:
+			return res, nil
+		}
+	}
+}
+
+func (c *Client) writeRequest(method string, requestURL string, headers map[string]string) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	c.cseq++
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s RTSP/1.0\r\nCSeq: %d\r\nUser-Agent: %s\r\n", method, requestURL, c.cseq, userAgent)
+	if c.session != "" {
+		fmt.Fprintf(&b, "Session: %s\r\n", c.session)
+	}
+	if c.auth != nil {
+		fmt.Fprintf(&b, "Authorization: %s\r\n", c.auth.authorization(method, requestURL))
+	}
+	for key, value := range headers {
+		fmt.Fprintf(&b, "%s: %s\r\n", key, value)
+	}
+	b.WriteString("\r\n")
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(readTimeout))
+	_, err := io.WriteString(c.conn, b.String())
+	return err
+}
+
+func (c *Client) readResponse() (*response, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "RTSP/") {
+		return nil, fmt.Errorf("invalid RTSP response: %q", strings.TrimSpace(line))
+	}
+	res := &response{headers: make(map[string]string)}
+	if res.status, err = strconv.Atoi(fields[1]); err != nil {
+		return nil, fmt.Errorf("invalid RTSP status: %q", fields[1])
+	}
+
+	for {
+		line, err = c.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = canonicalHeader(strings.TrimSpace(key)), strings.TrimSpace(value)
+		if key == "Www-Authenticate" {
+			res.auth = append(res.auth, value)
+		}
+		res.headers[key] = value
+	}
+
+	if length, _ := strconv.Atoi(res.headers["Content-Length"]); length > 0 {
+		if length > maxResponseBody {
+			return nil, fmt.Errorf("RTSP response too large: %d", length)
+		}
+		res.body = make([]byte, length)
+		if _, err = io.ReadFull(c.r, res.body); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func canonicalHeader(key string) string {
+	parts := strings.Split(strings.ToLower(key), "-")
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// authenticator answers Basic and Digest challenges, RFC 2617
+type authenticator struct {
+	username string
+	password string
+	digest   bool
+	realm    string
+	nonce    string
+	opaque   string
+	qop      bool
+	nc       int
+}
+
+func newAuthenticator(challenges []string, username string, password string) (*authenticator, error) {
+	a := &authenticator{username: username, password: password}
+	for _, challenge := range challenges {
+		scheme, params, _ := strings.Cut(challenge, " ")
+		switch strings.ToLower(scheme) {
+		case "digest":
+			a.digest = true
+			for key, value := range parseAuthParams(params) {
+				switch key {
+				case "realm":
+					a.realm = value
+				case "nonce":
+					a.nonce = value
+				case "opaque":
+					a.opaque = value
+				case "qop":
+					for _, qop := range strings.Split(value, ",") {
+						if strings.TrimSpace(qop) == "auth" {
+							a.qop = true
+						}
+					}
+				}
+			}
+			return a, nil
+		case "basic":
+			a.realm = parseAuthParams(params)["realm"]
+		}
+	}
+	if len(challenges) == 0 {
+		return nil, ErrUnauthorized
+	}
+	return a, nil
+}
+
+func parseAuthParams(params string) map[string]string {
+	values := make(map[string]string)
+	for len(params) > 0 {
+		params = strings.TrimLeft(params, " ,")
+		key, rest, ok := strings.Cut(params, "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				break
+			}
+			value, params = rest[1:end+1], rest[end+2:]
+		} else {
+			value, params, _ = strings.Cut(rest, ",")
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	return values
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *authenticator) authorization(method string, uri string) string {
+	if !a.digest {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(a.username+":"+a.password))
+	}
+
+	ha1 := md5Hex(a.username + ":" + a.realm + ":" + a.password)
+	ha2 := md5Hex(method + ":" + uri)
+	header := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s"`, a.username, a.realm, a.nonce, uri)
+	if a.qop {
+		a.nc++
+		nc := fmt.Sprintf("%08x", a.nc)
+		cnonceBytes := make([]byte, 8)
+		_, _ = rand.Read(cnonceBytes)
+		cnonce := hex.EncodeToString(cnonceBytes)
+		response := md5Hex(ha1 + ":" + a.nonce + ":" + nc + ":" + cnonce + ":auth:" + ha2)
+		header += fmt.Sprintf(`, response="%s", qop=auth, nc=%s, cnonce="%s"`, response, nc, cnonce)
+	} else {
+		header += fmt.Sprintf(`, response="%s"`, md5Hex(ha1+":"+a.nonce+":"+ha2))
+	}
+	if a.opaque != "" {
+		header += fmt.Sprintf(`, opaque="%s"`, a.opaque)
+	}
+	return header
+}
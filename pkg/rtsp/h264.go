@@ -0,0 +1,178 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtsp
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
+)
+
+const (
+	h264NALUTypeMask = 0x1f
+	h264NALUIDR      = 5
+	h264NALUSPS      = 7
+	h264NALUPPS      = 8
+	h264NALUSTAPA    = 24
+	h264NALUFUA      = 28
+
+	// leaves room for the RTP header extensions and SRTP within a typical path MTU
+	repacketizeMTU = 1200
+)
+
+var annexBStartCode = []byte{0, 0, 0, 1}
+
+// h264ParameterSets returns the SPS and PPS of the sprop-parameter-sets in the fmtp line
+func h264ParameterSets(fmtp string) (sps []byte, pps []byte) {
+	for _, param := range strings.Split(fmtp, ";") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(param), "sprop-parameter-sets=")
+		if !ok {
+			continue
+		}
+		for _, encoded := range strings.Split(value, ",") {
+			nalu, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil || len(nalu) == 0 {
+				continue
+			}
+			switch nalu[0] & h264NALUTypeMask {
+			case h264NALUSPS:
+				sps = nalu
+			case h264NALUPPS:
+				pps = nalu
+			}
+		}
+	}
+	return
+}
+
+// h264Repacketizer rewrites the camera's packetization into packets that fit the MTU, cameras commonly send
+// NAL units of tens of kilobytes over interleaved TCP. Parameter sets are repeated in front of every key frame,
+// many cameras only signal them in the SDP which WebRTC receivers never see.
+type h264Repacketizer struct {
+	payloader codecs.H264Payloader
+	sps       []byte
+	pps       []byte
+
+	lastSN   uint16
+	started  bool
+	fuBuffer []byte
+	fuDrop   bool
+}
+
+func newH264Repacketizer(fmtp string) *h264Repacketizer {
+	sps, pps := h264ParameterSets(fmtp)
+	return &h264Repacketizer{sps: sps, pps: pps}
+}
+
+// Push returns the packets for the NAL units completed by pkt, sequence numbers are left for the caller
+func (r *h264Repacketizer) Push(pkt *rtp.Packet) []*rtp.Packet {
+	if r.started && pkt.SequenceNumber != r.lastSN+1 {
+		// a fragment was lost, the rest of the fragmented unit cannot be decoded
+		r.fuBuffer = r.fuBuffer[:0]
+		r.fuDrop = true
+	}
+	r.started = true
+	r.lastSN = pkt.SequenceNumber
+
+	nalus := r.depacketize(pkt.Payload)
+	if len(nalus) == 0 {
+		return nil
+	}
+
+	var annexB []byte
+	for _, nalu := range nalus {
+		switch nalu[0] & h264NALUTypeMask {
+		case h264NALUSPS:
+			r.sps = nalu
+			continue
+		case h264NALUPPS:
+			r.pps = nalu
+			continue
+		case h264NALUIDR:
+			if r.sps != nil && r.pps != nil {
+				annexB = append(annexB, annexBStartCode...)
+				annexB = append(annexB, r.sps...)
+				annexB = append(annexB, annexBStartCode...)
+				annexB = append(annexB, r.pps...)
+			}
+		}
+		annexB = append(annexB, annexBStartCode...)
+		annexB = append(annexB, nalu...)
+	}
+	if len(annexB) == 0 {
+		return nil
+	}
+
+	payloads := r.payloader.Payload(repacketizeMTU, annexB)
+	packets := make([]*rtp.Packet, 0, len(payloads))
+	for i, payload := range payloads {
+		header := pkt.Header
+		header.Extension = false
+		header.Extensions = nil
+		header.Padding = false
+		header.Marker = pkt.Marker && i == len(payloads)-1
+		packets = append(packets, &rtp.Packet{Header: header, Payload: payload})
+	}
+	return packets
+}
+
+func (r *h264Repacketizer) depacketize(payload []byte) [][]byte {
+	if len(payload) == 0 {
+		return nil
+	}
+
+	switch payload[0] & h264NALUTypeMask {
+	case h264NALUSTAPA:
+		var nalus [][]byte
+		for offset := 1; offset+2 <= len(payload); {
+			size := int(payload[offset])<<8 | int(payload[offset+1])
+			offset += 2
+			if size == 0 || offset+size > len(payload) {
+				break
+			}
+			nalus = append(nalus, payload[offset:offset+size])
+			offset += size
+		}
+		return nalus
+
+	case h264NALUFUA:
+		if len(payload) < 2 {
+			return nil
+		}
+		start, end := payload[1]&0x80 != 0, payload[1]&0x40 != 0
+		if start {
+			r.fuDrop = false
+			r.fuBuffer = append(r.fuBuffer[:0], payload[0]&0xe0|payload[1]&h264NALUTypeMask)
+		} else if len(r.fuBuffer) == 0 {
+			r.fuDrop = true
+		}
+		if r.fuDrop {
+			return nil
+		}
+		r.fuBuffer = append(r.fuBuffer, payload[2:]...)
+		if !end {
+			return nil
+		}
+		nalu := make([]byte, len(r.fuBuffer))
+		copy(nalu, r.fuBuffer)
+		r.fuBuffer = r.fuBuffer[:0]
+		return [][]byte{nalu}
+
+	default:
+		return [][]byte{payload}
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtsp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	reconnectMinBackoff = time.Second
+	reconnectMaxBackoff = 30 * time.Second
+)
+
+var ErrSourceClosed = errors.New("RTSP source closed")
+
+type SourceParams struct {
+	URL string
+	// split camera packets to fit the MTU and repeat parameter sets before key frames
+	Repacketize bool
+	Logger      logger.Logger
+}
+
+// Source pulls the first H.264 video and Opus audio of an RTSP stream. It reconnects to the camera with backoff
+// when the stream fails and rewrites sequence numbers and timestamps, so downstream sees one continuous stream.
+type Source struct {
+	params SourceParams
+	client *Client
+	tracks []*SourceTrack
+}
+
+// SourceTrack is a media of the stream that is forwarded
+type SourceTrack struct {
+	Kind      string
+	MimeType  string
+	ClockRate uint32
+	Channels  uint16
+	Fmtp      string
+
+	codec       string
+	rewriter    rtpRewriter
+	repacketize *h264Repacketizer
+}
+
+// NewSource connects to the camera and selects the tracks to forward
+func NewSource(ctx context.Context, params SourceParams) (*Source, error) {
+	if params.Logger == nil {
+		params.Logger = logger.GetLogger()
+	}
+	client, err := Dial(ctx, params.URL)
+	if err != nil {
+		return nil, err
+	}
+	s := &Source{params: params, client: client}
+
+	for _, m := range client.Media() {
+		mimeType := supportedMimeType(m)
+		if mimeType == "" {
+			params.Logger.Infow("skipping unsupported RTSP media", "kind", m.Kind, "codec", m.Codec)
+			continue
+		}
+		if s.track(m.Kind) != nil {
+			continue
+		}
+		track := &SourceTrack{
+			Kind:      m.Kind,
+			MimeType:  mimeType,
+			ClockRate: m.ClockRate,
+			Channels:  m.Channels,
+			Fmtp:      m.Fmtp,
+			codec:     m.Codec,
+		}
+		if params.Repacketize && m.Kind == "video" {
+			track.repacketize = newH264Repacketizer(m.Fmtp)
+		}
+		s.tracks = append(s.tracks, track)
+	}
+	if len(s.tracks) == 0 {
+		_ = client.Close()
+		return nil, ErrNoSupportedMedia
+	}
+	return s, nil
+}
+
+// supportedMimeType returns the mime type of media that can be published without transcoding
+func supportedMimeType(m *Media) string {
+	switch {
+	case m.Kind == "video" && strings.EqualFold(m.Codec, "H264"):
+		return "video/H264"
+	case m.Kind == "audio" && strings.EqualFold(m.Codec, "opus"):
+		return "audio/opus"
+	}
+	return ""
+}
+
+func (s *Source) Tracks() []*SourceTrack {
+	return s.tracks
+}
+
+func (s *Source) track(kind string) *SourceTrack {
+	for _, t := range s.tracks {
+		if t.Kind == kind {
+			return t
+		}
+	}
+	return nil
+}
+
+// Run forwards packets to onPacket until the context is done
+func (s *Source) Run(ctx context.Context, onPacket func(track *SourceTrack, pkt *rtp.Packet), onReconnecting func(error)) {
+	backoff := reconnectMinBackoff
+	for {
+		started := time.Now()
+		err := s.play(ctx, onPacket)
+		_ = s.client.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > reconnectMaxBackoff {
+			backoff = reconnectMinBackoff
+		}
+		s.params.Logger.Infow("RTSP stream failed, reconnecting", "error", err, "backoff", backoff)
+		if onReconnecting != nil {
+			onReconnecting(err)
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, reconnectMaxBackoff)
+
+			client, err := Dial(ctx, s.params.URL)
+			if err != nil {
+				s.params.Logger.Infow("could not reconnect to RTSP stream", "error", err, "backoff", backoff)
+				continue
+			}
+			s.client = client
+			break
+		}
+		for _, t := range s.tracks {
+			t.rewriter.reset()
+		}
+	}
+}
+
+func (s *Source) play(ctx context.Context, onPacket func(track *SourceTrack, pkt *rtp.Packet)) error {
+	// match the tracks again, the camera may have changed the order or payload types after a reboot
+	indices := make([]int, 0, len(s.tracks))
+	byIndex := make(map[int]*SourceTrack, len(s.tracks))
+	for _, t := range s.tracks {
+		for i, m := range s.client.Media() {
+			if _, ok := byIndex[i]; !ok && m.Kind == t.Kind && strings.EqualFold(m.Codec, t.codec) {
+				indices = append(indices, i)
+				byIndex[i] = t
+				break
+			}
+		}
+	}
+	if len(indices) == 0 {
+		return ErrNoSupportedMedia
+	}
+	if err := s.client.Play(indices); err != nil {
+		return err
+	}
+
+	playCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.client.KeepAlive(playCtx)
+	conn := s.client.conn
+	go func() {
+		// unblocks the read when the source is stopped
+		<-playCtx.Done()
+		_ = conn.Close()
+	}()
+
+	for {
+		p, err := s.client.ReadPacket()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ErrSourceClosed
+			}
+			return err
+		}
+		t := byIndex[p.Index]
+		if t == nil {
+			continue
+		}
+
+		packets := []*rtp.Packet{p.Packet}
+		if t.repacketize != nil {
+			packets = t.repacketize.Push(p.Packet)
+		}
+		for _, pkt := range packets {
+			t.rewriter.rewrite(pkt, t.ClockRate, t.repacketize != nil)
+			onPacket(t, pkt)
+		}
+	}
+}
+
+func (s *Source) Close() {
+	_ = s.client.Close()
+}
+
+// rtpRewriter maps the sequence numbers and timestamps of each camera connection onto a continuous range
+type rtpRewriter struct {
+	initialized bool
+	resync      bool
+
+	snOffset uint16
+	tsOffset uint32
+
+	lastOutSN uint16
+	lastOutTS uint32
+	lastAt    time.Time
+}
+
+// reset starts a new range on the next packet, following the previous one in time
+func (r *rtpRewriter) reset() {
+	r.resync = r.initialized
+}
+
+// rewrite maps the packet, sequential numbering is used for repacketized streams that no longer map one to one
+func (r *rtpRewriter) rewrite(pkt *rtp.Packet, clockRate uint32, sequential bool) {
+	now := time.Now()
+	if r.resync {
+		r.resync = false
+		elapsed := uint32(now.Sub(r.lastAt).Seconds()*float64(clockRate)) + 1
+		r.snOffset = r.lastOutSN + 1 - pkt.SequenceNumber
+		r.tsOffset = r.lastOutTS + elapsed - pkt.Timestamp
+	}
+
+	if sequential && r.initialized {
+		pkt.SequenceNumber = r.lastOutSN + 1
+	} else {
+		pkt.SequenceNumber += r.snOffset
+	}
+	pkt.Timestamp += r.tsOffset
+	r.initialized = true
+	r.lastOutSN = pkt.SequenceNumber
+	r.lastOutTS = pkt.Timestamp
+	r.lastAt = now
+}
@@ -0,0 +1,226 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testUser     = "admin"
+	testPassword = "secret"
+	testRealm    = "camera"
+	testNonce    = "abc123"
+)
+
+var (
+	testSPS = []byte{0x67, 0x42, 0xc0, 0x1f, 0xda, 0x01, 0x40, 0x16, 0xe8}
+	testPPS = []byte{0x68, 0xce, 0x3c, 0x80}
+)
+
+// testCamera serves an H.264 and PCMU stream over interleaved TCP, closing each connection after a few packets
+type testCamera struct {
+	listener    net.Listener
+	connections atomic.Int32
+	packets     int
+}
+
+func newTestCamera(t *testing.T, packets int) *testCamera {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	c := &testCamera{listener: listener, packets: packets}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go c.serve(conn)
+		}
+	}()
+	return c
+}
+
+func (c *testCamera) url() string {
+	return fmt.Sprintf("rtsp://%s:%s@%s/stream", testUser, testPassword, c.listener.Addr())
+}
+
+func (c *testCamera) serve(conn net.Conn) {
+	defer conn.Close()
+	connection := c.connections.Add(1)
+	r := textproto.NewReader(bufio.NewReader(conn))
+	for {
+		line, err := r.ReadLine()
+		if err != nil {
+			return
+		}
+		method, uri, _ := strings.Cut(line, " ")
+		uri, _, _ = strings.Cut(uri, " ")
+		headers, err := r.ReadMIMEHeader()
+		if err != nil {
+			return
+		}
+		cseq := headers.Get("Cseq")
+
+		if !validDigest(headers.Get("Authorization"), method, uri) {
+			fmt.Fprintf(conn, "RTSP/1.0 401 Unauthorized\r\nCSeq: %s\r\nWWW-Authenticate: Digest realm=\"%s\", nonce=\"%s\"\r\n\r\n", cseq, testRealm, testNonce)
+			continue
+		}
+
+		switch method {
+		case "DESCRIBE":
+			sprop := base64.StdEncoding.EncodeToString(testSPS) + "," + base64.StdEncoding.EncodeToString(testPPS)
+			body := "v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\ns=camera\r\nt=0 0\r\n" +
+				"m=video 0 RTP/AVP 96\r\na=rtpmap:96 H264/90000\r\n" +
+				"a=fmtp:96 packetization-mode=1;profile-level-id=42c01f;sprop-parameter-sets=" + sprop + "\r\na=control:trackID=0\r\n" +
+				"m=audio 0 RTP/AVP 0\r\na=control:trackID=1\r\n"
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\nContent-Base: %s/\r\nContent-Type: application/sdp\r\nContent-Length: %d\r\n\r\n%s", cseq, uri, len(body), body)
+		case "SETUP":
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\nTransport: %s\r\nSession: 1234;timeout=60\r\n\r\n", cseq, headers.Get("Transport"))
+		case "PLAY":
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\nSession: 1234\r\n\r\n", cseq)
+			// every connection restarts the stream with new random-ish sequence numbers and timestamps
+			base := uint16(connection) * 1000
+			for i := 0; i < c.packets; i++ {
+				payload := make([]byte, 3000)
+				payload[0] = 0x65
+				pkt := &rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						PayloadType:    96,
+						SequenceNumber: base + uint16(i),
+						Timestamp:      uint32(base)*90 + uint32(i)*3000,
+						Marker:         true,
+					},
+					Payload: payload,
+				}
+				data, _ := pkt.Marshal()
+				if _, err = conn.Write(append([]byte{'$', 0, byte(len(data) >> 8), byte(len(data))}, data...)); err != nil {
+					return
+				}
+			}
+			return
+		default:
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\n\r\n", cseq)
+		}
+	}
+}
+
+func validDigest(header string, method string, uri string) bool {
+	params, ok := strings.CutPrefix(header, "Digest ")
+	if !ok {
+		return false
+	}
+	values := parseAuthParams(params)
+	ha1 := md5Hex(testUser + ":" + testRealm + ":" + testPassword)
+	ha2 := md5Hex(method + ":" + uri)
+	return values["uri"] == uri && values["response"] == md5Hex(ha1+":"+testNonce+":"+ha2)
+}
+
+func TestClient(t *testing.T) {
+	camera := newTestCamera(t, 1)
+
+	t.Run("rejects invalid URLs", func(t *testing.T) {
+		_, err := Dial(context.Background(), "http://camera/stream")
+		require.ErrorIs(t, err, ErrInvalidURL)
+	})
+
+	t.Run("rejects wrong credentials", func(t *testing.T) {
+		_, err := Dial(context.Background(), strings.Replace(camera.url(), testPassword, "wrong", 1))
+		require.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("describes and plays", func(t *testing.T) {
+		client, err := Dial(context.Background(), camera.url())
+		require.NoError(t, err)
+		defer client.Close()
+
+		media := client.Media()
+		require.Len(t, media, 2)
+		require.Equal(t, "H264", media[0].Codec)
+		require.Equal(t, uint32(90000), media[0].ClockRate)
+		require.Equal(t, "PCMU", media[1].Codec)
+		require.Equal(t, client.base+"trackID=0", client.controlURL(media[0].control))
+
+		require.NoError(t, client.Play([]int{0}))
+		p, err := client.ReadPacket()
+		require.NoError(t, err)
+		require.Equal(t, 0, p.Index)
+		require.Len(t, p.Packet.Payload, 3000)
+	})
+}
+
+func TestSource(t *testing.T) {
+	camera := newTestCamera(t, 5)
+
+	source, err := NewSource(context.Background(), SourceParams{URL: camera.url(), Repacketize: true})
+	require.NoError(t, err)
+	// PCMU cannot be published without transcoding
+	require.Len(t, source.Tracks(), 1)
+	require.Equal(t, "video/H264", source.Tracks()[0].MimeType)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	packets := make(chan *rtp.Packet, 100)
+	reconnects := atomic.Int32{}
+	go source.Run(ctx, func(_ *SourceTrack, pkt *rtp.Packet) {
+		packets <- pkt
+	}, func(error) {
+		reconnects.Add(1)
+	})
+
+	var received []*rtp.Packet
+	timeout := time.After(10 * time.Second)
+	for len(received) < 2*5*5 {
+		select {
+		case pkt := <-packets:
+			received = append(received, pkt)
+		case <-timeout:
+			t.Fatalf("received %d packets", len(received))
+		}
+	}
+	require.GreaterOrEqual(t, reconnects.Load(), int32(1))
+
+	// the first key frame carries the parameter sets from the SDP, the 3000 byte units are split to fit the MTU
+	require.Equal(t, byte(h264NALUSTAPA), received[0].Payload[0]&h264NALUTypeMask)
+	for i, pkt := range received {
+		require.LessOrEqual(t, len(pkt.Payload), repacketizeMTU)
+		if i > 0 {
+			require.Equal(t, received[i-1].SequenceNumber+1, pkt.SequenceNumber, "packet %d", i)
+			require.GreaterOrEqual(t, pkt.Timestamp, received[i-1].Timestamp, "packet %d", i)
+		}
+	}
+}
+
+func TestH264ParameterSets(t *testing.T) {
+	fmtp := "packetization-mode=1;sprop-parameter-sets=" +
+		base64.StdEncoding.EncodeToString(testSPS) + "," + base64.StdEncoding.EncodeToString(testPPS)
+	sps, pps := h264ParameterSets(fmtp)
+	require.Equal(t, testSPS, sps)
+	require.Equal(t, testPPS, pps)
+}
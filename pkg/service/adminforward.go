@@ -0,0 +1,231 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/psrpc"
+	"github.com/livekit/psrpc/pkg/client"
+	"github.com/livekit/psrpc/pkg/info"
+	"github.com/livekit/psrpc/pkg/server"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const (
+	adminForwardService = "AdminForward"
+	adminForwardMethod  = "Forward"
+
+	// admin requests can wait for recordings to be stored
+	adminForwardTimeout = 30 * time.Second
+	maxAdminRequestSize = 1 << 20
+)
+
+// adminForwardRequest is an admin API request forwarded to the node hosting its room.
+// the request was authenticated by the node that received it
+type adminForwardRequest struct {
+	Path   string            `json:"path"`
+	Body   []byte            `json:"body"`
+	Grants *auth.ClaimGrants `json:"grants,omitempty"`
+	APIKey string            `json:"api_key,omitempty"`
+}
+
+type adminForwardResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body"`
+}
+
+// adminForwarder sends admin requests of rooms hosted on other nodes to them over the message bus,
+// and serves the requests forwarded to this node
+type adminForwarder struct {
+	router      routing.Router
+	currentNode routing.LocalNode
+	client      *client.RPCClient
+	server      *server.RPCServer
+}
+
+func newAdminForwarder(
+	router routing.Router,
+	currentNode routing.LocalNode,
+	bus psrpc.MessageBus,
+	handler func(ctx context.Context, req *wrapperspb.BytesValue) (*wrapperspb.BytesValue, error),
+) (*adminForwarder, error) {
+	if bus == nil || router == nil || currentNode == nil {
+		return nil, nil
+	}
+
+	nodeID := currentNode.Id
+	clientSD := &info.ServiceDefinition{Name: adminForwardService, ID: nodeID}
+	clientSD.RegisterMethod(adminForwardMethod, false, false, true, true)
+	rpcClient, err := client.NewRPCClient(clientSD, bus, psrpc.WithClientTimeout(adminForwardTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	serverSD := &info.ServiceDefinition{Name: adminForwardService, ID: nodeID}
+	rpcServer := server.NewRPCServer(serverSD, bus, psrpc.WithServerTimeout(adminForwardTimeout))
+	serverSD.RegisterMethod(adminForwardMethod, false, false, true, true)
+	if err = server.RegisterHandler(rpcServer, adminForwardMethod, []string{nodeID}, handler, nil); err != nil {
+		rpcServer.Close(false)
+		return nil, err
+	}
+
+	return &adminForwarder{
+		router:      router,
+		currentNode: currentNode,
+		client:      rpcClient,
+		server:      rpcServer,
+	}, nil
+}
+
+// remoteNode returns the node hosting the room when it is not this one
+func (f *adminForwarder) remoteNode(ctx context.Context, roomName livekit.RoomName) (livekit.NodeID, bool) {
+	node, err := f.router.GetNodeForRoom(ctx, roomName)
+	if err != nil || node == nil || node.Id == f.currentNode.Id {
+		return "", false
+	}
+	return livekit.NodeID(node.Id), true
+}
+
+func (f *adminForwarder) forward(ctx context.Context, nodeID livekit.NodeID, req *adminForwardRequest) (*adminForwardResponse, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.RequestSingle[*wrapperspb.BytesValue](ctx, f.client, adminForwardMethod, []string{string(nodeID)}, wrapperspb.Bytes(data))
+	if err != nil {
+		return nil, err
+	}
+	var forwarded adminForwardResponse
+	if err = json.Unmarshal(res.Value, &forwarded); err != nil {
+		return nil, err
+	}
+	return &forwarded, nil
+}
+
+func (f *adminForwarder) Stop() {
+	f.server.Close(false)
+}
+
+// forwardToRoomNode forwards requests of rooms hosted on another node to it. it returns false when the request
+// should be handled by this node
+func (s *AdminService) forwardToRoomNode(w http.ResponseWriter, r *http.Request) bool {
+	if s.forwarder == nil || r.Method != http.MethodPost || r.Body == nil {
+		return false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxAdminRequestSize))
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return true
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req struct {
+		Room string `json:"room"`
+	}
+	if json.Unmarshal(body, &req) != nil || req.Room == "" {
+		return false
+	}
+	roomName := livekit.RoomName(req.Room)
+	if s.roomManager.GetRoom(r.Context(), roomName) != nil {
+		return false
+	}
+	nodeID, ok := s.forwarder.remoteNode(r.Context(), roomName)
+	if !ok {
+		return false
+	}
+
+	res, err := s.forwarder.forward(r.Context(), nodeID, &adminForwardRequest{
+		Path:   r.URL.Path,
+		Body:   body,
+		Grants: GetGrants(r.Context()),
+		APIKey: GetAPIKey(r.Context()),
+	})
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "nodeID", nodeID)
+		return true
+	}
+	if res.ContentType != "" {
+		w.Header().Set("Content-Type", res.ContentType)
+	}
+	w.WriteHeader(res.Status)
+	_, _ = w.Write(res.Body)
+	return true
+}
+
+// handleForwardedRequest serves an admin request forwarded by another node
+func (s *AdminService) handleForwardedRequest(ctx context.Context, req *wrapperspb.BytesValue) (*wrapperspb.BytesValue, error) {
+	var forwarded adminForwardRequest
+	if err := json.Unmarshal(req.Value, &forwarded); err != nil {
+		return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+	}
+
+	if forwarded.Grants != nil {
+		ctx = WithGrants(ctx, forwarded.Grants)
+	}
+	if forwarded.APIKey != "" {
+		ctx = context.WithValue(ctx, apiKeyKey{}, forwarded.APIKey)
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, forwarded.Path, bytes.NewReader(forwarded.Body))
+	if err != nil {
+		return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+	}
+
+	w := &adminResponseWriter{header: make(http.Header), status: http.StatusOK}
+	s.mux.ServeHTTP(w, r)
+	logger.Debugw("served forwarded admin request", "path", forwarded.Path, "status", w.status)
+
+	data, err := json.Marshal(&adminForwardResponse{
+		Status:      w.status,
+		ContentType: w.header.Get("Content-Type"),
+		Body:        w.body.Bytes(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wrapperspb.Bytes(data), nil
+}
+
+// adminResponseWriter buffers the response of a forwarded request
+type adminResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *adminResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *adminResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *adminResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
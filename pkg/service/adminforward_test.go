@@ -0,0 +1,94 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+)
+
+func TestAdminForwardToRoomNode(t *testing.T) {
+	bus := psrpc.NewLocalMessageBus()
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomReturns(&livekit.Node{Id: "node2"}, nil)
+
+	newAdminService := func(nodeID string) *AdminService {
+		s, err := NewAdminService(&RoomManager{}, NewLocalStore(), nil, nil, nil, nil, router, &livekit.Node{Id: nodeID}, bus)
+		require.NoError(t, err)
+		t.Cleanup(s.Stop)
+		return s
+	}
+	s1 := newAdminService("node1")
+	s2 := newAdminService("node2")
+
+	var handledBy string
+	handler := func(nodeID string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			handledBy = nodeID
+			if err := EnsureAdminPermission(r.Context(), "room"); err != nil {
+				handleError(w, http.StatusUnauthorized, err)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+			writeJSON(w, map[string]string{"node": nodeID, "apiKey": GetAPIKey(r.Context())})
+		}
+	}
+	s1.mux.HandleFunc(adminPathPrefix+"test", handler("node1"))
+	s2.mux.HandleFunc(adminPathPrefix+"test", handler("node2"))
+
+	serve := func(body string, grants *auth.ClaimGrants) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, adminPathPrefix+"test", bytes.NewBufferString(body))
+		if grants != nil {
+			r = r.WithContext(context.WithValue(WithGrants(r.Context(), grants), apiKeyKey{}, "key"))
+		}
+		w := httptest.NewRecorder()
+		s1.ServeHTTP(w, r)
+		return w
+	}
+	admin := &auth.ClaimGrants{Video: &auth.VideoGrant{Room: "room", RoomAdmin: true}}
+
+	// requests of a room hosted elsewhere are served by its node with the grants of the request
+	w := serve(`{"room":"room"}`, admin)
+	require.Equal(t, "node2", handledBy)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var res map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, map[string]string{"node": "node2", "apiKey": "key"}, res)
+
+	require.Equal(t, http.StatusUnauthorized, serve(`{"room":"room"}`, nil).Code)
+	require.Equal(t, "node2", handledBy)
+
+	// requests without a room are served locally
+	serve(`{}`, admin)
+	require.Equal(t, "node1", handledBy)
+
+	// as are requests of rooms hosted by this node
+	router.GetNodeForRoomReturns(&livekit.Node{Id: "node1"}, nil)
+	serve(`{"room":"room"}`, admin)
+	require.Equal(t, "node1", handledBy)
+}
@@ -0,0 +1,1487 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/recorder"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/psrpc"
+)
+
+const adminPathPrefix = "/admin/"
+
+// AdminService serves JSON APIs for operations that are not part of the RoomService protocol.
+// Requests are handled by the node hosting the room and require a roomAdmin grant for that room.
+type AdminService struct {
+	roomManager *RoomManager
+	roomStore   ObjectStore
+	rtspIngests *RTSPIngestManager
+	sipMix      *SIPMixManager
+	sipCalls    *SIPCallManager
+	composites  *AudioCompositeManager
+	forwarder   *adminForwarder
+	mux         *http.ServeMux
+}
+
+func NewAdminService(
+	roomManager *RoomManager,
+	roomStore ObjectStore,
+	rtspIngests *RTSPIngestManager,
+	sipMix *SIPMixManager,
+	sipCalls *SIPCallManager,
+	composites *AudioCompositeManager,
+	router routing.Router,
+	currentNode routing.LocalNode,
+	bus psrpc.MessageBus,
+) (*AdminService, error) {
+	s := &AdminService{
+		roomManager: roomManager,
+		roomStore:   roomStore,
+		rtspIngests: rtspIngests,
+		sipMix:      sipMix,
+		sipCalls:    sipCalls,
+		composites:  composites,
+		mux:         http.NewServeMux(),
+	}
+	s.mux.HandleFunc(adminPathPrefix+"audio_only", s.setAudioOnly)
+	s.mux.HandleFunc(adminPathPrefix+"participant_changes", s.getParticipantChanges)
+	s.mux.HandleFunc(adminPathPrefix+"room_journal", s.getRoomJournal)
+	s.mux.HandleFunc(adminPathPrefix+"set_attributes", s.setParticipantAttributes)
+	s.mux.HandleFunc(adminPathPrefix+"delete_attributes", s.deleteParticipantAttributes)
+	s.mux.HandleFunc(adminPathPrefix+"rtp_stats", s.getRTPStats)
+	s.mux.HandleFunc(adminPathPrefix+"webrtc_stats", s.getWebRTCStats)
+	s.mux.HandleFunc(adminPathPrefix+"list_participants", s.listParticipants)
+	s.mux.HandleFunc(adminPathPrefix+"room_stats", s.getRoomStats)
+	s.mux.HandleFunc(adminPathPrefix+"lock_room", s.lockRoom)
+	s.mux.HandleFunc(adminPathPrefix+"force_relay", s.forceRelay)
+	s.mux.HandleFunc(adminPathPrefix+"mute_all", s.muteAll)
+	s.mux.HandleFunc(adminPathPrefix+"remove_participants", s.removeParticipants)
+	s.mux.HandleFunc(adminPathPrefix+"schedule_room", s.scheduleRoom)
+	s.mux.HandleFunc(adminPathPrefix+"ban_participant", s.banParticipant)
+	s.mux.HandleFunc(adminPathPrefix+"unban_participant", s.unbanParticipant)
+	s.mux.HandleFunc(adminPathPrefix+"revoke_token", s.revokeToken)
+	s.mux.HandleFunc(adminPathPrefix+"unrevoke_token", s.unrevokeToken)
+	s.mux.HandleFunc(adminPathPrefix+"start_track_recording", s.startTrackRecording)
+	s.mux.HandleFunc(adminPathPrefix+"stop_track_recording", s.stopTrackRecording)
+	s.mux.HandleFunc(adminPathPrefix+"start_rtp_capture", s.startRTPCapture)
+	s.mux.HandleFunc(adminPathPrefix+"stop_rtp_capture", s.stopRTPCapture)
+	s.mux.HandleFunc(adminPathPrefix+"set_frame_assembly", s.setFrameAssembly)
+	s.mux.HandleFunc(adminPathPrefix+"set_single_layer_svc", s.setSingleLayerSVC)
+	s.mux.HandleFunc(adminPathPrefix+"start_rtmp_push", s.startRTMPPush)
+	s.mux.HandleFunc(adminPathPrefix+"stop_rtmp_push", s.stopRTMPPush)
+	s.mux.HandleFunc(adminPathPrefix+"start_track_forward", s.startTrackForward)
+	s.mux.HandleFunc(adminPathPrefix+"stop_track_forward", s.stopTrackForward)
+	s.mux.HandleFunc(adminPathPrefix+"start_hls", s.startHLSStream)
+	s.mux.HandleFunc(adminPathPrefix+"stop_hls", s.stopHLSStream)
+	s.mux.HandleFunc(adminPathPrefix+"create_rtsp_ingest", s.createRTSPIngest)
+	s.mux.HandleFunc(adminPathPrefix+"list_rtsp_ingests", s.listRTSPIngests)
+	s.mux.HandleFunc(adminPathPrefix+"delete_rtsp_ingest", s.deleteRTSPIngest)
+	s.mux.HandleFunc(adminPathPrefix+"set_mix_gain", s.setMixGain)
+	s.mux.HandleFunc(adminPathPrefix+"transfer_participant", s.transferParticipant)
+	s.mux.HandleFunc(adminPathPrefix+"hold_participant", s.holdParticipant)
+	s.mux.HandleFunc(adminPathPrefix+"require_e2ee", s.requireE2EE)
+	s.mux.HandleFunc(adminPathPrefix+"rotate_e2ee_key", s.rotateE2EEKey)
+	s.mux.HandleFunc(adminPathPrefix+"e2ee_status", s.e2eeStatus)
+	s.mux.HandleFunc(adminPathPrefix+"push_composite_metadata", s.pushCompositeMetadata)
+	s.mux.HandleFunc(adminPathPrefix+"composite_metadata", s.getCompositeMetadata)
+	s.mux.HandleFunc(adminPathPrefix+"client_diagnostics", s.getClientDiagnostics)
+
+	// rooms are hosted by a single node, requests for a room are forwarded to it
+	forwarder, err := newAdminForwarder(router, currentNode, bus, s.handleForwardedRequest)
+	if err != nil {
+		return nil, err
+	}
+	s.forwarder = forwarder
+	return s, nil
+}
+
+func (s *AdminService) PathPrefix() string {
+	return adminPathPrefix
+}
+
+func (s *AdminService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.forwardToRoomNode(w, r) {
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *AdminService) Stop() {
+	if s.forwarder != nil {
+		s.forwarder.Stop()
+	}
+}
+
+type AudioOnlyRequest struct {
+	Room      string `json:"room"`
+	Identity  string `json:"identity"`
+	AudioOnly bool   `json:"audio_only"`
+}
+
+func (s *AdminService) setAudioOnly(w http.ResponseWriter, r *http.Request) {
+	var req AudioOnlyRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if req.Identity == "" {
+		handleError(w, http.StatusBadRequest, ErrIdentityEmpty)
+		return
+	}
+
+	err := s.roomManager.SetParticipantAudioOnly(r.Context(), livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity), req.AudioOnly)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "participant", req.Identity)
+		return
+	}
+	writeJSON(w, &req)
+}
+
+type ParticipantChangesRequest struct {
+	Room string `json:"room"`
+	// optional, all participants when empty
+	Identity string `json:"identity"`
+}
+
+type ParticipantChangesResponse struct {
+	Changes []rtc.ParticipantChange `json:"changes"`
+}
+
+func (s *AdminService) getParticipantChanges(w http.ResponseWriter, r *http.Request) {
+	var req ParticipantChangesRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	changes, err := s.roomManager.GetParticipantChanges(r.Context(), livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity))
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room)
+		return
+	}
+	writeJSON(w, &ParticipantChangesResponse{Changes: changes})
+}
+
+const (
+	roomJournalDefaultLimit = 100
+	roomJournalMaxLimit     = 1000
+)
+
+type RoomJournalRequest struct {
+	Room string `json:"room"`
+	// sequence number of the last event already processed, 0 to start from the oldest retained event
+	Cursor uint64 `json:"cursor,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+type RoomJournalResponse struct {
+	Entries []rtc.JournalEntry `json:"entries"`
+	// cursor to pass in the next request
+	NextCursor uint64 `json:"next_cursor"`
+	// more events are available after next_cursor
+	HasMore bool `json:"has_more,omitempty"`
+	// events following the cursor are no longer retained, room state should be resynced
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+func (s *AdminService) getRoomJournal(w http.ResponseWriter, r *http.Request) {
+	var req RoomJournalRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	entries, lastSeq, truncated, err := s.roomManager.GetRoomJournal(r.Context(), livekit.RoomName(req.Room), req.Cursor, req.Limit)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room)
+		return
+	}
+	res := &RoomJournalResponse{
+		Entries:    entries,
+		NextCursor: req.Cursor,
+		Truncated:  truncated,
+	}
+	if len(entries) != 0 {
+		res.NextCursor = entries[len(entries)-1].Seq
+	} else if res.NextCursor > lastSeq {
+		// the journal has expired since the cursor was issued
+		res.NextCursor = lastSeq
+	}
+	res.HasMore = res.NextCursor < lastSeq
+	writeJSON(w, res)
+}
+
+type SetAttributesRequest struct {
+	Room       string            `json:"room"`
+	Identity   string            `json:"identity"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+type DeleteAttributesRequest struct {
+	Room     string   `json:"room"`
+	Identity string   `json:"identity"`
+	Keys     []string `json:"keys"`
+}
+
+type ParticipantAttributesResponse struct {
+	Attributes map[string]string `json:"attributes"`
+}
+
+func (s *AdminService) setParticipantAttributes(w http.ResponseWriter, r *http.Request) {
+	var req SetAttributesRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	s.updateParticipantAttributes(w, r, req.Room, req.Identity, req.Attributes, nil)
+}
+
+func (s *AdminService) deleteParticipantAttributes(w http.ResponseWriter, r *http.Request) {
+	var req DeleteAttributesRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	s.updateParticipantAttributes(w, r, req.Room, req.Identity, nil, req.Keys)
+}
+
+func (s *AdminService) updateParticipantAttributes(w http.ResponseWriter, r *http.Request, room string, identity string, set map[string]string, del []string) {
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if identity == "" {
+		handleError(w, http.StatusBadRequest, ErrIdentityEmpty)
+		return
+	}
+
+	attributes, err := s.roomManager.UpdateParticipantAttributes(r.Context(), livekit.RoomName(room), livekit.ParticipantIdentity(identity), set, del)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", room, "participant", identity)
+		return
+	}
+	writeJSON(w, &ParticipantAttributesResponse{Attributes: attributes})
+}
+
+// listParticipants is ListParticipants with filtering, sorting and pagination, served from the room store
+// so that it does not need to reach the node hosting the room
+func (s *AdminService) listParticipants(w http.ResponseWriter, r *http.Request) {
+	var req ListParticipantsQuery
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	participants, err := s.roomStore.ListParticipants(r.Context(), livekit.RoomName(req.Room))
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room)
+		return
+	}
+	page, err := req.Apply(participants)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room)
+		return
+	}
+	writeJSON(w, page)
+}
+
+// scheduleRoom sets the window during which participants can join a room.
+// a request without start and end times removes the schedule
+func (s *AdminService) scheduleRoom(w http.ResponseWriter, r *http.Request) {
+	var req RoomSchedule
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureCreatePermission(r.Context()); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var err error
+	if req.StartTime == 0 && req.EndTime == 0 {
+		err = s.roomStore.DeleteRoomSchedule(r.Context(), livekit.RoomName(req.Room))
+	} else if err = req.Validate(); err == nil {
+		req.StartingSoonNotified = false
+		err = s.roomStore.StoreRoomSchedule(r.Context(), &req)
+	}
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room)
+		return
+	}
+	writeJSON(w, &req)
+}
+
+type BanParticipantRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// also ban the IP address the participant is connected from
+	BanIP bool `json:"ban_ip"`
+	// seconds, room.ban_duration when 0
+	Duration int64 `json:"duration"`
+}
+
+// banParticipant removes a participant and prevents it from rejoining the room until the ban expires
+func (s *AdminService) banParticipant(w http.ResponseWriter, r *http.Request) {
+	var req BanParticipantRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if req.Identity == "" {
+		handleError(w, http.StatusBadRequest, ErrIdentityEmpty)
+		return
+	}
+
+	duration := time.Duration(req.Duration) * time.Second
+	if duration <= 0 {
+		duration = s.roomManager.config.Room.BanDuration
+	}
+	ban := &ParticipantBan{
+		Room:      req.Room,
+		Identity:  req.Identity,
+		BanIP:     req.BanIP,
+		ExpiresAt: time.Now().Add(duration).Unix(),
+	}
+	if err := s.roomManager.BanParticipant(r.Context(), ban); err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "participant", req.Identity)
+		return
+	}
+	writeJSON(w, ban)
+}
+
+func (s *AdminService) unbanParticipant(w http.ResponseWriter, r *http.Request) {
+	var req BanParticipantRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if req.Identity == "" {
+		handleError(w, http.StatusBadRequest, ErrIdentityEmpty)
+		return
+	}
+
+	if err := s.roomStore.DeleteParticipantBan(r.Context(), livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)); err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "participant", req.Identity)
+		return
+	}
+	writeJSON(w, &req)
+}
+
+type RevokeTokenRequest struct {
+	// ID of the token to revoke, its jti, or "sha256:" and the hex encoded hash of tokens without one
+	TokenID string `json:"token_id"`
+	// the token itself, its revocation ID and expiry are used when token_id or expires_at are not set
+	Token  string `json:"token"`
+	Reason string `json:"reason"`
+	// unix seconds, when the revocation can be forgotten. the token's expiry, or auth.revocation.default_ttl from now
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// revokeToken rejects a token that has not expired yet, participants connected with it are disconnected.
+// revocations apply to all rooms and require the room create permission
+func (s *AdminService) revokeToken(w http.ResponseWriter, r *http.Request) {
+	var req RevokeTokenRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureCreatePermission(r.Context()); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	revocation := &TokenRevocation{
+		TokenID:   req.TokenID,
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if req.Token != "" {
+		claims, err := parseTokenClaims(req.Token)
+		if err != nil {
+			handleError(w, http.StatusBadRequest, ErrInvalidAuthorizationToken)
+			return
+		}
+		if revocation.TokenID == "" {
+			revocation.TokenID = tokenRevocationID(req.Token)
+		}
+		if revocation.ExpiresAt == 0 && claims.Expiry != nil {
+			revocation.ExpiresAt = claims.Expiry.Time().Unix()
+		}
+	}
+	if revocation.TokenID == "" {
+		handleError(w, http.StatusBadRequest, ErrTokenIDMissing)
+		return
+	}
+	if revocation.ExpiresAt == 0 {
+		revocation.ExpiresAt = time.Now().Add(s.roomManager.config.Auth.Revocation.DefaultTTL).Unix()
+	}
+
+	if err := s.roomStore.StoreTokenRevocation(r.Context(), revocation); err != nil {
+		handleError(w, errorStatus(err), err, "tokenID", revocation.TokenID)
+		return
+	}
+	writeJSON(w, revocation)
+}
+
+func (s *AdminService) unrevokeToken(w http.ResponseWriter, r *http.Request) {
+	var req RevokeTokenRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureCreatePermission(r.Context()); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if req.TokenID == "" && req.Token != "" {
+		req.TokenID = tokenRevocationID(req.Token)
+	}
+	if req.TokenID == "" {
+		handleError(w, http.StatusBadRequest, ErrTokenIDMissing)
+		return
+	}
+
+	if err := s.roomStore.DeleteTokenRevocation(r.Context(), req.TokenID); err != nil {
+		handleError(w, errorStatus(err), err, "tokenID", req.TokenID)
+		return
+	}
+	writeJSON(w, &req)
+}
+
+type RoomStatsRequest struct {
+	Room string `json:"room"`
+}
+
+// getRoomStats returns per-participant bitrate, packet loss and quality of the most recent telemetry interval
+func (s *AdminService) getRoomStats(w http.ResponseWriter, r *http.Request) {
+	var req RoomStatsRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	stats, err := s.roomManager.GetRoomStats(r.Context(), livekit.RoomName(req.Room))
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room)
+		return
+	}
+	writeJSON(w, stats)
+}
+
+type LockRoomRequest struct {
+	Room   string `json:"room"`
+	Locked bool   `json:"locked"`
+}
+
+// lockRoom stops or resumes accepting new participants in a room
+func (s *AdminService) lockRoom(w http.ResponseWriter, r *http.Request) {
+	var req LockRoomRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if err := s.roomManager.SetRoomLocked(r.Context(), livekit.RoomName(req.Room), req.Locked); err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room)
+		return
+	}
+	writeJSON(w, &req)
+}
+
+type ForceRelayRequest struct {
+	Room       string `json:"room"`
+	ForceRelay bool   `json:"force_relay"`
+}
+
+type ForceRelayResponse struct {
+	Room       string `json:"room"`
+	ForceRelay bool   `json:"force_relay"`
+	// participants asked to reconnect through TURN
+	Reconnected []string `json:"reconnected"`
+}
+
+// forceRelay changes whether participants of a room must send and receive media through TURN
+func (s *AdminService) forceRelay(w http.ResponseWriter, r *http.Request) {
+	var req ForceRelayRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	reconnected, err := s.roomManager.SetRoomForceRelay(r.Context(), livekit.RoomName(req.Room), req.ForceRelay)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room)
+		return
+	}
+	writeJSON(w, &ForceRelayResponse{
+		Room:        req.Room,
+		ForceRelay:  req.ForceRelay,
+		Reconnected: livekit.IDsAsStrings(reconnected),
+	})
+}
+
+type MuteAllRequest struct {
+	Room string `json:"room"`
+	// identities of participants whose tracks are left as they are
+	Except []string `json:"except"`
+	// track sources to mute, e.g. MICROPHONE or CAMERA. all tracks when empty
+	Sources []string `json:"sources"`
+	// lock the room along with muting
+	Lock bool `json:"lock"`
+	// stop muting tracks published after an earlier request, nothing is muted
+	Clear bool `json:"clear,omitempty"`
+}
+
+type MuteAllResponse struct {
+	MutedTracks []livekit.TrackID `json:"muted_tracks"`
+}
+
+func (s *AdminService) muteAll(w http.ResponseWriter, r *http.Request) {
+	var req MuteAllRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if req.Clear {
+		if err := s.roomManager.ClearMuteAll(r.Context(), livekit.RoomName(req.Room)); err != nil {
+			handleError(w, errorStatus(err), err, "room", req.Room)
+			return
+		}
+		writeJSON(w, &MuteAllResponse{MutedTracks: []livekit.TrackID{}})
+		return
+	}
+
+	sources := make([]livekit.TrackSource, 0, len(req.Sources))
+	for _, source := range req.Sources {
+		v, ok := livekit.TrackSource_value[source]
+		if !ok {
+			handleError(w, http.StatusBadRequest, fmt.Errorf("unknown track source %s", source))
+			return
+		}
+		sources = append(sources, livekit.TrackSource(v))
+	}
+
+	muted, err := s.roomManager.MuteAllExcept(
+		r.Context(),
+		livekit.RoomName(req.Room),
+		livekit.StringsAsIDs[livekit.ParticipantIdentity](req.Except),
+		sources,
+		req.Lock,
+	)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room)
+		return
+	}
+	writeJSON(w, &MuteAllResponse{MutedTracks: muted})
+}
+
+type RemoveParticipantsRequest struct {
+	Room string `json:"room"`
+	// participants whose metadata is a JSON object with this key set to value are removed
+	MetadataKey   string `json:"metadata_key"`
+	MetadataValue string `json:"metadata_value"`
+	// lock the room along with removing participants
+	Lock bool `json:"lock"`
+}
+
+type RemoveParticipantsResponse struct {
+	Removed []livekit.ParticipantIdentity `json:"removed"`
+}
+
+func (s *AdminService) removeParticipants(w http.ResponseWriter, r *http.Request) {
+	var req RemoveParticipantsRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if req.MetadataKey == "" {
+		handleError(w, http.StatusBadRequest, errors.New("metadata_key is required"))
+		return
+	}
+
+	removed, err := s.roomManager.RemoveParticipantsWithAttribute(r.Context(), livekit.RoomName(req.Room), req.MetadataKey, req.MetadataValue, req.Lock)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room)
+		return
+	}
+	writeJSON(w, &RemoveParticipantsResponse{Removed: removed})
+}
+
+type StartTrackRecordingRequest struct {
+	Room  string `json:"room"`
+	Track string `json:"track"`
+	// record every audio track of the room, each to its own file, when track is empty
+	AudioOnly bool `json:"audio_only"`
+	// with audio_only, mix the room's audio into a single .ogg file with the built-in audio composite instead.
+	// the composite is stopped with its egress ID as recording_id
+	Mix bool `json:"mix,omitempty"`
+	// empty for the native container of each codec, or mp4 for H.264 and Opus tracks
+	Format string `json:"format,omitempty"`
+}
+
+type StartTrackRecordingResponse struct {
+	Recordings []recorder.RecordingInfo `json:"recordings"`
+	Composite  *livekit.EgressInfo      `json:"composite,omitempty"`
+}
+
+// startTrackRecording records tracks in-process, without the egress service
+func (s *AdminService) startTrackRecording(w http.ResponseWriter, r *http.Request) {
+	var req StartTrackRecordingRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if req.Track == "" && !req.AudioOnly {
+		handleError(w, http.StatusBadRequest, ErrTrackNotFound, "room", req.Room)
+		return
+	}
+	if req.Mix {
+		if req.Track != "" || !req.AudioOnly || req.Format != "" {
+			handleError(w, http.StatusBadRequest, ErrAudioOutputInvalid, "room", req.Room)
+			return
+		}
+		info, err := s.composites.Start(r.Context(), &livekit.RoomCompositeEgressRequest{
+			RoomName:    req.Room,
+			Layout:      AudioCompositeLayout,
+			AudioOnly:   true,
+			FileOutputs: []*livekit.EncodedFileOutput{{FileType: livekit.EncodedFileType_OGG}},
+		})
+		if err != nil {
+			handleError(w, errorStatus(err), err, "room", req.Room)
+			return
+		}
+		writeJSON(w, &StartTrackRecordingResponse{Composite: info})
+		return
+	}
+
+	infos, err := s.roomManager.StartTrackRecording(r.Context(), livekit.RoomName(req.Room), livekit.TrackID(req.Track), req.AudioOnly, req.Format)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "trackID", req.Track)
+		return
+	}
+	writeJSON(w, &StartTrackRecordingResponse{Recordings: infos})
+}
+
+type StopTrackRecordingRequest struct {
+	RecordingID string `json:"recording_id"`
+	// room it was started in, routes the request to the node hosting the room
+	Room string `json:"room,omitempty"`
+}
+
+// stopTrackRecording finishes a recording, responding once the file has been stored
+func (s *AdminService) stopTrackRecording(w http.ResponseWriter, r *http.Request) {
+	var req StopTrackRecordingRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if strings.HasPrefix(req.RecordingID, utils.EgressPrefix) {
+		s.stopRoomMix(w, r, req.RecordingID)
+		return
+	}
+	info, err := s.roomManager.GetTrackRecording(req.RecordingID)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "recordingID", req.RecordingID)
+		return
+	}
+	if err = EnsureAdminPermission(r.Context(), livekit.RoomName(info.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	info, err = s.roomManager.StopTrackRecording(r.Context(), req.RecordingID)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "recordingID", req.RecordingID)
+		return
+	}
+	writeJSON(w, &info)
+}
+
+// stopRoomMix ends a mix started with start_track_recording, the file is stored in the background
+func (s *AdminService) stopRoomMix(w http.ResponseWriter, r *http.Request, egressID string) {
+	info := s.composites.Get(egressID)
+	if info == nil {
+		handleError(w, http.StatusNotFound, ErrRecordingNotFound, "recordingID", egressID)
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(info.RoomName)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	info, err := s.composites.Stop(r.Context(), egressID)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "recordingID", egressID)
+		return
+	}
+	writeJSON(w, info)
+}
+
+type StartRTPCaptureRequest struct {
+	Room  string `json:"room"`
+	Track string `json:"track"`
+	// rtpdump (default) or pcap
+	Format string `json:"format"`
+	// capture payloads as well as headers, payloads may hold media of the participants
+	Payloads bool `json:"payloads"`
+}
+
+// startRTPCapture writes the RTP received for a track to files on this node, for reproducing issues offline
+func (s *AdminService) startRTPCapture(w http.ResponseWriter, r *http.Request) {
+	var req StartRTPCaptureRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	info, err := s.roomManager.StartRTPCapture(r.Context(), livekit.RoomName(req.Room), livekit.TrackID(req.Track), buffer.CaptureFormat(req.Format), req.Payloads)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "trackID", req.Track)
+		return
+	}
+	writeJSON(w, &info)
+}
+
+type StopRTPCaptureRequest struct {
+	CaptureID string `json:"capture_id"`
+	// room it was started in, routes the request to the node hosting the room
+	Room string `json:"room,omitempty"`
+}
+
+func (s *AdminService) stopRTPCapture(w http.ResponseWriter, r *http.Request) {
+	var req StopRTPCaptureRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	info, err := s.roomManager.GetRTPCapture(req.CaptureID)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "captureID", req.CaptureID)
+		return
+	}
+	if err = EnsureAdminPermission(r.Context(), livekit.RoomName(info.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	info, err = s.roomManager.StopRTPCapture(req.CaptureID)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "captureID", req.CaptureID)
+		return
+	}
+	writeJSON(w, &info)
+}
+
+type FrameAssemblyRequest struct {
+	Room    string `json:"room"`
+	Track   string `json:"track"`
+	Enabled bool   `json:"enabled"`
+}
+
+// setFrameAssembly toggles holding back packets of a video track until their frame is complete,
+// for publishers on very jittery uplinks
+func (s *AdminService) setFrameAssembly(w http.ResponseWriter, r *http.Request) {
+	var req FrameAssemblyRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	err := s.roomManager.SetTrackFrameAssembly(r.Context(), livekit.RoomName(req.Room), livekit.TrackID(req.Track), req.Enabled)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "trackID", req.Track)
+		return
+	}
+	writeJSON(w, &req)
+}
+
+type SingleLayerSVCRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// setSingleLayerSVC toggles forwarding a single spatial layer of SVC video to a subscriber, without the
+// dependency descriptor, for subscribers whose decoders handle SVC poorly
+func (s *AdminService) setSingleLayerSVC(w http.ResponseWriter, r *http.Request) {
+	var req SingleLayerSVCRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	err := s.roomManager.SetParticipantSingleLayerSVC(r.Context(), livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity), req.Enabled)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "participant", req.Identity)
+		return
+	}
+	writeJSON(w, &req)
+}
+
+type StartRTMPPushRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// rtmp(s)://host[:port]/app/stream_key
+	URL string `json:"url"`
+}
+
+// startRTMPPush forwards a participant's microphone and camera to an RTMP server, without the egress service
+func (s *AdminService) startRTMPPush(w http.ResponseWriter, r *http.Request) {
+	var req StartRTMPPushRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	info, err := s.roomManager.StartRTMPPush(r.Context(), livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity), req.URL)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "participant", req.Identity)
+		return
+	}
+	writeJSON(w, &info)
+}
+
+type StopRTMPPushRequest struct {
+	PushID string `json:"push_id"`
+	// room it was started in, routes the request to the node hosting the room
+	Room string `json:"room,omitempty"`
+}
+
+func (s *AdminService) stopRTMPPush(w http.ResponseWriter, r *http.Request) {
+	var req StopRTMPPushRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	info, err := s.roomManager.GetRTMPPush(req.PushID)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "pushID", req.PushID)
+		return
+	}
+	if err = EnsureAdminPermission(r.Context(), livekit.RoomName(info.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	info, err = s.roomManager.StopRTMPPush(r.Context(), req.PushID)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "pushID", req.PushID)
+		return
+	}
+	writeJSON(w, &info)
+}
+
+type StartTrackForwardRequest struct {
+	Room  string `json:"room"`
+	Track string `json:"track"`
+	// UDP host:port packets are sent to
+	Address string `json:"address"`
+	// written to forwarded packets, random when zero
+	SSRC uint32 `json:"ssrc"`
+	// written to forwarded packets, the payload type negotiated with the publisher when unset
+	PayloadType *uint8 `json:"payload_type"`
+	// base64 master key followed by the master salt, like an SDES inline key. Packets are sent as plain RTP without it
+	SRTPKey string `json:"srtp_key"`
+	// AES_CM_128_HMAC_SHA1_80 (default), AES_CM_128_HMAC_SHA1_32, AEAD_AES_128_GCM or AEAD_AES_256_GCM
+	SRTPProfile string `json:"srtp_profile"`
+}
+
+// startTrackForward sends a copy of a track's RTP packets to a UDP address
+func (s *AdminService) startTrackForward(w http.ResponseWriter, r *http.Request) {
+	var req StartTrackForwardRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := recorder.TrackForwarderParams{
+		Address:     req.Address,
+		SSRC:        req.SSRC,
+		PayloadType: req.PayloadType,
+	}
+	if req.SRTPKey != "" {
+		key, err := base64.StdEncoding.DecodeString(req.SRTPKey)
+		if err != nil {
+			handleError(w, http.StatusBadRequest, err)
+			return
+		}
+		params.SRTP = &recorder.SRTPParams{Profile: req.SRTPProfile, KeyAndSalt: key}
+	}
+	info, err := s.roomManager.StartTrackForward(r.Context(), livekit.RoomName(req.Room), livekit.TrackID(req.Track), params)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "trackID", req.Track)
+		return
+	}
+	writeJSON(w, &info)
+}
+
+type StopTrackForwardRequest struct {
+	ForwarderID string `json:"forwarder_id"`
+	// room it was started in, routes the request to the node hosting the room
+	Room string `json:"room,omitempty"`
+}
+
+func (s *AdminService) stopTrackForward(w http.ResponseWriter, r *http.Request) {
+	var req StopTrackForwardRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	info, err := s.roomManager.GetTrackForward(req.ForwarderID)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "forwarderID", req.ForwarderID)
+		return
+	}
+	if err = EnsureAdminPermission(r.Context(), livekit.RoomName(info.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	info, err = s.roomManager.StopTrackForward(r.Context(), req.ForwarderID)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "forwarderID", req.ForwarderID)
+		return
+	}
+	writeJSON(w, &info)
+}
+
+type StartHLSStreamRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// upload complete segments to the recorder's S3 bucket
+	Upload bool `json:"upload"`
+}
+
+// startHLSStream packages a participant's microphone and camera as low-latency HLS served by this node
+func (s *AdminService) startHLSStream(w http.ResponseWriter, r *http.Request) {
+	var req StartHLSStreamRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	info, err := s.roomManager.StartHLSStream(r.Context(), livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity), req.Upload)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "participant", req.Identity)
+		return
+	}
+	writeJSON(w, &info)
+}
+
+type StopHLSStreamRequest struct {
+	StreamID string `json:"stream_id"`
+	// room it was started in, routes the request to the node hosting the room
+	Room string `json:"room,omitempty"`
+}
+
+func (s *AdminService) stopHLSStream(w http.ResponseWriter, r *http.Request) {
+	var req StopHLSStreamRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	info, err := s.roomManager.GetHLSStream(req.StreamID)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "streamID", req.StreamID)
+		return
+	}
+	if err = EnsureAdminPermission(r.Context(), livekit.RoomName(info.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	info, err = s.roomManager.StopHLSStream(r.Context(), req.StreamID)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "streamID", req.StreamID)
+		return
+	}
+	writeJSON(w, &info)
+}
+
+type CreateRTSPIngestRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	Name     string `json:"name"`
+	// rtsp://[user:password@]host[:port]/path
+	URL string `json:"url"`
+	// split oversized camera packets and repeat parameter sets before key frames
+	Repacketize bool `json:"repacketize"`
+}
+
+// createRTSPIngest publishes an IP camera into a room, managed like an ingress with the ingressAdmin grant
+func (s *AdminService) createRTSPIngest(w http.ResponseWriter, r *http.Request) {
+	var req CreateRTSPIngestRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureIngressAdminPermission(r.Context()); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if req.Room == "" {
+		handleError(w, http.StatusBadRequest, ErrRoomNameEmpty)
+		return
+	}
+
+	info, err := s.rtspIngests.Create(r.Context(), RTSPIngestParams{
+		Room:        livekit.RoomName(req.Room),
+		Identity:    livekit.ParticipantIdentity(req.Identity),
+		Name:        livekit.ParticipantName(req.Name),
+		URL:         req.URL,
+		Repacketize: req.Repacketize,
+	})
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "participant", req.Identity)
+		return
+	}
+	writeJSON(w, &info)
+}
+
+type ListRTSPIngestsRequest struct {
+	// all ingests on this node when empty
+	Room string `json:"room"`
+}
+
+type ListRTSPIngestsResponse struct {
+	Ingests []RTSPIngestInfo `json:"ingests"`
+}
+
+func (s *AdminService) listRTSPIngests(w http.ResponseWriter, r *http.Request) {
+	var req ListRTSPIngestsRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureIngressAdminPermission(r.Context()); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	writeJSON(w, &ListRTSPIngestsResponse{Ingests: s.rtspIngests.List(livekit.RoomName(req.Room))})
+}
+
+type DeleteRTSPIngestRequest struct {
+	IngestID string `json:"ingest_id"`
+}
+
+func (s *AdminService) deleteRTSPIngest(w http.ResponseWriter, r *http.Request) {
+	var req DeleteRTSPIngestRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureIngressAdminPermission(r.Context()); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	info, err := s.rtspIngests.Delete(r.Context(), req.IngestID)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "ingestID", req.IngestID)
+		return
+	}
+	writeJSON(w, &info)
+}
+
+type MixGainRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// linear gain of the participant's audio in the SIP mixes of the room, 1 keeps the level and 0 mutes it
+	Gain float64 `json:"gain"`
+}
+
+func (s *AdminService) setMixGain(w http.ResponseWriter, r *http.Request) {
+	var req MixGainRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	err := s.sipMix.SetGain(r.Context(), livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity), req.Gain)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "participant", req.Identity)
+		return
+	}
+	writeJSON(w, &req)
+}
+
+type TransferParticipantRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// room the participant is asked to join, it leaves Room afterwards
+	ToRoom string `json:"to_room"`
+}
+
+func (s *AdminService) transferParticipant(w http.ResponseWriter, r *http.Request) {
+	var req TransferParticipantRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	// the participant is handed a token for the other room as well
+	for _, room := range []string{req.Room, req.ToRoom} {
+		if err := EnsureAdminPermission(r.Context(), livekit.RoomName(room)); err != nil {
+			handleError(w, http.StatusUnauthorized, err)
+			return
+		}
+	}
+
+	err := s.sipCalls.Transfer(r.Context(), livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity), livekit.RoomName(req.ToRoom))
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "participant", req.Identity, "toRoom", req.ToRoom)
+		return
+	}
+	writeJSON(w, &req)
+}
+
+type HoldParticipantRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	Hold     bool   `json:"hold"`
+}
+
+func (s *AdminService) holdParticipant(w http.ResponseWriter, r *http.Request) {
+	var req HoldParticipantRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	err := s.sipCalls.Hold(r.Context(), livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity), req.Hold)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "participant", req.Identity)
+		return
+	}
+	writeJSON(w, &req)
+}
+
+type RequireE2EERequest struct {
+	Room     string `json:"room"`
+	Required bool   `json:"required"`
+}
+
+type RequireE2EEResponse struct {
+	Room     string `json:"room"`
+	Required bool   `json:"required"`
+	// participants removed for publishing unencrypted media
+	Rejected []string `json:"rejected"`
+}
+
+// requireE2EE changes whether publishers of a room must encrypt their media end-to-end
+func (s *AdminService) requireE2EE(w http.ResponseWriter, r *http.Request) {
+	var req RequireE2EERequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	rejected, err := s.roomManager.SetRoomE2EERequired(r.Context(), livekit.RoomName(req.Room), req.Required)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room)
+		return
+	}
+	writeJSON(w, &RequireE2EEResponse{
+		Room:     req.Room,
+		Required: req.Required,
+		Rejected: livekit.IDsAsStrings(rejected),
+	})
+}
+
+type RotateE2EEKeyRequest struct {
+	Room string `json:"room"`
+}
+
+type RotateE2EEKeyResponse struct {
+	Room     string `json:"room"`
+	KeyIndex uint8  `json:"key_index"`
+}
+
+// rotateE2EEKey hints the participants of a room to move to the next key of their key ring
+func (s *AdminService) rotateE2EEKey(w http.ResponseWriter, r *http.Request) {
+	var req RotateE2EEKeyRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	keyIndex, err := s.roomManager.RotateE2EEKey(r.Context(), livekit.RoomName(req.Room))
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room)
+		return
+	}
+	writeJSON(w, &RotateE2EEKeyResponse{Room: req.Room, KeyIndex: keyIndex})
+}
+
+type E2EEStatusRequest struct {
+	Room string `json:"room"`
+}
+
+func (s *AdminService) e2eeStatus(w http.ResponseWriter, r *http.Request) {
+	var req E2EEStatusRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	status, err := s.roomManager.GetE2EEStatus(r.Context(), livekit.RoomName(req.Room))
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room)
+		return
+	}
+	writeJSON(w, status)
+}
+
+type PushCompositeMetadataRequest struct {
+	Room string `json:"room"`
+	// such as layout, watermark or caption, the latest payload of each kind is kept
+	Kind string `json:"kind"`
+	// JSON passed through to recorders and agents, null clears the kind
+	Payload json.RawMessage `json:"payload"`
+}
+
+// pushCompositeMetadata forwards application state to the recorders and agents of a room, in order
+func (s *AdminService) pushCompositeMetadata(w http.ResponseWriter, r *http.Request) {
+	var req PushCompositeMetadataRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	entry, err := s.roomManager.PushCompositeMetadata(r.Context(), livekit.RoomName(req.Room), req.Kind, req.Payload)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "kind", req.Kind)
+		return
+	}
+	writeJSON(w, entry)
+}
+
+type CompositeMetadataRequest struct {
+	Room string `json:"room"`
+}
+
+type CompositeMetadataResponse struct {
+	Room    string               `json:"room"`
+	Entries []*CompositeMetadata `json:"entries"`
+}
+
+func (s *AdminService) getCompositeMetadata(w http.ResponseWriter, r *http.Request) {
+	var req CompositeMetadataRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	entries, err := s.roomManager.GetCompositeMetadata(r.Context(), livekit.RoomName(req.Room))
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room)
+		return
+	}
+	writeJSON(w, &CompositeMetadataResponse{Room: req.Room, Entries: entries})
+}
+
+type ClientDiagnosticsListRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+}
+
+type ClientDiagnosticsListResponse struct {
+	Diagnostics []*telemetry.ClientDiagnostics `json:"diagnostics"`
+}
+
+// getClientDiagnostics returns the stats and event logs recently uploaded by a participant's client
+func (s *AdminService) getClientDiagnostics(w http.ResponseWriter, r *http.Request) {
+	var req ClientDiagnosticsListRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if req.Identity == "" {
+		handleError(w, http.StatusBadRequest, ErrIdentityEmpty)
+		return
+	}
+
+	writeJSON(w, &ClientDiagnosticsListResponse{
+		Diagnostics: s.roomManager.GetClientDiagnostics(livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)),
+	})
+}
+
+type RTPStatsRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	Track    string `json:"track"`
+}
+
+type TrackRTPStats struct {
+	// receive side stats by codec mime type, keyed by SSRC within
+	Receivers map[string]map[string]interface{} `json:"receivers"`
+	// send side stats by subscriber identity
+	Senders map[string]map[string]interface{} `json:"senders"`
+	// SSRC and rid of each received layer by codec mime type, empty for subscribed tracks
+	Layers map[string][]sfu.LayerMapping `json:"layers,omitempty"`
+}
+
+func (s *AdminService) getRTPStats(w http.ResponseWriter, r *http.Request) {
+	var req RTPStatsRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if req.Identity == "" {
+		handleError(w, http.StatusBadRequest, ErrIdentityEmpty)
+		return
+	}
+
+	stats, err := s.roomManager.GetTrackRTPStats(r.Context(), livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity), livekit.TrackID(req.Track))
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "participant", req.Identity, "track", req.Track)
+		return
+	}
+	writeJSON(w, stats)
+}
+
+type WebRTCStatsRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+}
+
+// getWebRTCStats returns the stats of a participant's peer connections in the shape of the W3C getStats() API
+func (s *AdminService) getWebRTCStats(w http.ResponseWriter, r *http.Request) {
+	var req WebRTCStatsRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), livekit.RoomName(req.Room)); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if req.Identity == "" {
+		handleError(w, http.StatusBadRequest, ErrIdentityEmpty)
+		return
+	}
+
+	stats, err := s.roomManager.GetParticipantRTCStats(r.Context(), livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity))
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", req.Room, "participant", req.Identity)
+		return
+	}
+	writeJSON(w, stats)
+}
+
+func (s *AdminService) decodeRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if r.Method != http.MethodPost {
+		handleError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+func errorStatus(err error) int {
+	var psrpcErr psrpc.Error
+	if errors.As(err, &psrpcErr) {
+		return psrpcErr.ToHttp()
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
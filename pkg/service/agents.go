@@ -0,0 +1,157 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/agent"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// Agents is the registry of in-process agents that receive the audio of rooms hosted on this node
+func (r *RoomManager) Agents() *agent.Registry {
+	return r.agents
+}
+
+// agentTrackPublished attaches the registered agents to an audio track when it is published
+func (r *RoomManager) agentTrackPublished(room *rtc.Room, participant types.LocalParticipant, track types.MediaTrack) {
+	if track.Kind() != livekit.TrackType_AUDIO || participant.Hidden() {
+		return
+	}
+	r.agents.TrackPublished(agentTrack(room, participant, track))
+}
+
+// agentTracks lists the audio tracks published in rooms hosted on this node, tracks of hidden participants such as
+// mixes published by the server are not processed by agents
+func (r *RoomManager) agentTracks() []agent.PublishedTrack {
+	r.lock.RLock()
+	rooms := make([]*rtc.Room, 0, len(r.rooms))
+	for _, room := range r.rooms {
+		rooms = append(rooms, room)
+	}
+	r.lock.RUnlock()
+
+	var tracks []agent.PublishedTrack
+	for _, room := range rooms {
+		for _, participant := range room.GetParticipants() {
+			if participant.Hidden() {
+				continue
+			}
+			for _, track := range participant.GetPublishedTracks() {
+				if track.Kind() == livekit.TrackType_AUDIO {
+					tracks = append(tracks, agentTrack(room, participant, track))
+				}
+			}
+		}
+	}
+	return tracks
+}
+
+func agentTrack(room *rtc.Room, participant types.LocalParticipant, track types.MediaTrack) agent.PublishedTrack {
+	receiver := trackReceiverForCodec(track, webrtc.MimeTypeOpus)
+	published := agent.PublishedTrack{
+		TrackInfo: agent.TrackInfo{
+			Room:     room.Name(),
+			Identity: participant.Identity(),
+			TrackID:  track.ID(),
+		},
+		Receiver: receiver,
+	}
+	if receiver != nil {
+		published.MimeType = receiver.Codec().MimeType
+	}
+	return published
+}
+
+// agentResults publishes agent results into rooms hosted on this node
+type agentResults struct {
+	roomManager *RoomManager
+}
+
+func (a *agentResults) SetParticipantAttributes(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	attributes map[string]string,
+	actor string,
+) error {
+	room := a.roomManager.GetRoom(ctx, roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return ErrParticipantNotFound
+	}
+
+	merged := make(map[string]interface{})
+	if metadata := participant.ToProto().Metadata; metadata != "" {
+		if err := json.Unmarshal([]byte(metadata), &merged); err != nil {
+			return ErrMetadataNotObject
+		}
+	}
+	for key, value := range attributes {
+		if value == "" {
+			delete(merged, key)
+		} else {
+			merged[key] = value
+		}
+	}
+	metadata, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	if err = room.UpdateParticipantMetadata(participant, "", string(metadata), livekit.ParticipantIdentity(actor)); errors.Is(err, rtc.ErrInvalidMetadata) {
+		return psrpc.NewError(psrpc.InvalidArgument, err)
+	}
+	return err
+}
+
+func (a *agentResults) SendData(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	payload []byte,
+	topic string,
+	destinations []livekit.ParticipantIdentity,
+	reliable bool,
+) error {
+	room := a.roomManager.GetRoom(ctx, roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+
+	up := &livekit.UserPacket{
+		Payload:               payload,
+		DestinationIdentities: livekit.IDsAsStrings(destinations),
+	}
+	if topic != "" {
+		up.Topic = &topic
+	}
+	kind := livekit.DataPacket_LOSSY
+	if reliable {
+		kind = livekit.DataPacket_RELIABLE
+	}
+	room.SendDataPacket(up, kind)
+	return nil
+}
@@ -0,0 +1,381 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/agent"
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/mixer"
+	"github.com/livekit/livekit-server/pkg/recorder"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+const (
+	// AudioCompositeLayout is the room composite layout of the built-in audio-only composite. Instead of launching
+	// an egress instance, the node hosting the room mixes its audio into an OGG/Opus or MP3 file
+	AudioCompositeLayout = "builtin-audio"
+
+	audioCompositeAgentPrefix       = "audio-composite-"
+	audioCompositeDefaultFilepath   = "{room_name}-{time}"
+	audioCompositeTimeFormat        = "2006-01-02T150405"
+	audioCompositeRoomCheckInterval = time.Second
+)
+
+// MP3EncoderFactory creates encoders of mixed frames to MP3 frames, such as a binding of LAME
+type MP3EncoderFactory func(sampleRate int, channels int) (mixer.Encoder, error)
+
+// AudioCompositeManager runs built-in audio-only room composites, for rooms hosted on this node. Opus is encoded with
+// the codec registered with the server, MP3 with a registered MP3 encoder
+type AudioCompositeManager struct {
+	conf        config.RecorderConfig
+	roomManager *RoomManager
+	es          EgressStore
+	telemetry   telemetry.TelemetryService
+	logger      logger.Logger
+
+	lock          sync.Mutex
+	codec         mixer.Codec
+	newMP3Encoder MP3EncoderFactory
+	composites    map[string]*audioComposite
+}
+
+type audioComposite struct {
+	info   *livekit.EgressInfo
+	agent  *agent.Agent
+	mixer  *mixer.Mixer
+	writer *recorder.AudioComposite
+	done   chan struct{}
+}
+
+func NewAudioCompositeManager(
+	conf *config.Config,
+	roomManager *RoomManager,
+	es EgressStore,
+	telemetry telemetry.TelemetryService,
+) *AudioCompositeManager {
+	return &AudioCompositeManager{
+		conf:        conf.Recorder,
+		roomManager: roomManager,
+		es:          es,
+		telemetry:   telemetry,
+		logger:      logger.GetLogger().WithComponent("audiocomposite"),
+		composites:  make(map[string]*audioComposite),
+	}
+}
+
+func (m *AudioCompositeManager) SetCodec(codec mixer.Codec) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.codec = codec
+}
+
+func (m *AudioCompositeManager) SetMP3Encoder(newEncoder MP3EncoderFactory) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.newMP3Encoder = newEncoder
+}
+
+// IsAudioComposite returns true when the request selects the built-in audio-only composite
+func IsAudioComposite(req *livekit.RoomCompositeEgressRequest) bool {
+	return req.Layout == AudioCompositeLayout
+}
+
+func (m *AudioCompositeManager) Start(ctx context.Context, req *livekit.RoomCompositeEgressRequest) (*livekit.EgressInfo, error) {
+	file := req.GetFile()
+	if file == nil && len(req.FileOutputs) == 1 {
+		file = req.FileOutputs[0]
+	}
+	if file == nil || req.GetStream() != nil || req.GetSegments() != nil ||
+		len(req.FileOutputs) > 1 || len(req.StreamOutputs) != 0 || len(req.SegmentOutputs) != 0 {
+		return nil, ErrAudioOutputInvalid
+	}
+
+	room := m.roomManager.GetRoom(ctx, livekit.RoomName(req.RoomName))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	roomInfo := room.ToProto()
+
+	conf := m.conf
+	switch output := file.Output.(type) {
+	case nil:
+	case *livekit.EncodedFileOutput_S3:
+		conf.S3 = config.S3Config{
+			AccessKey:      output.S3.AccessKey,
+			Secret:         output.S3.Secret,
+			Region:         output.S3.Region,
+			Endpoint:       output.S3.Endpoint,
+			Bucket:         output.S3.Bucket,
+			ForcePathStyle: output.S3.ForcePathStyle,
+		}
+	default:
+		return nil, ErrAudioOutputInvalid
+	}
+	filePath, err := audioCompositeFilepath(file, roomInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	m.lock.Lock()
+	codec, newMP3Encoder := m.codec, m.newMP3Encoder
+	m.lock.Unlock()
+	if codec == nil {
+		return nil, ErrAudioCodecUnavailable
+	}
+	var encoder mixer.Encoder
+	if fileType, _ := recorder.AudioFileType(filePath); fileType == recorder.AudioFileTypeMP3 {
+		if newMP3Encoder == nil {
+			return nil, ErrAudioCodecUnavailable
+		}
+		encoder, err = newMP3Encoder(mixer.SampleRate, mixer.Channels)
+	} else {
+		encoder, err = codec.NewEncoder(mixer.SampleRate, mixer.Channels)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	egressID := utils.NewGuid(utils.EgressPrefix)
+	l := m.logger.WithValues("room", req.RoomName, "egressID", egressID)
+	now := time.Now().UnixNano()
+	c := &audioComposite{
+		info: &livekit.EgressInfo{
+			EgressId:  egressID,
+			RoomId:    roomInfo.Sid,
+			RoomName:  req.RoomName,
+			Status:    livekit.EgressStatus_EGRESS_ACTIVE,
+			StartedAt: now,
+			UpdatedAt: now,
+			Request:   &livekit.EgressInfo_RoomComposite{RoomComposite: req},
+		},
+		mixer: mixer.NewMixer(),
+		done:  make(chan struct{}),
+	}
+	c.agent, err = m.roomManager.Agents().Register(agent.Registration{
+		Name:   audioCompositeAgentPrefix + egressID,
+		Format: agent.AudioFormatPCM,
+		RoomFilter: func(roomName livekit.RoomName) bool {
+			return roomName == livekit.RoomName(req.RoomName)
+		},
+		NewDecoder: func(_ agent.TrackInfo) (agent.Decoder, error) {
+			return codec.NewDecoder(mixer.SampleRate, mixer.Channels)
+		},
+		OnAudio: func(frame *agent.AudioFrame) {
+			c.mixer.Push(frame.Track.Identity, frame.PCM, frame.Silence)
+		},
+		OnTrackEnded: func(track agent.TrackInfo) {
+			c.mixer.RemoveSource(track.Identity)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.writer, err = recorder.NewAudioComposite(recorder.AudioCompositeParams{
+		Config:   conf,
+		Room:     livekit.RoomName(req.RoomName),
+		Filepath: filePath,
+		Encoder:  encoder,
+		Logger:   l,
+	})
+	if err != nil {
+		c.agent.Close()
+		if errors.Is(err, recorder.ErrRecorderDisabled) {
+			return nil, ErrRecorderDisabled
+		}
+		return nil, err
+	}
+	c.mixer.AddOutput(egressID, "", func(pcm []int16, _ bool) {
+		c.writer.WriteFrame(pcm)
+	})
+
+	m.lock.Lock()
+	m.composites[egressID] = c
+	info := proto.Clone(c.info).(*livekit.EgressInfo)
+	m.lock.Unlock()
+
+	c.mixer.Start()
+	c.writer.Start(func(result recorder.AudioCompositeInfo) {
+		m.finished(c, result)
+	})
+
+	m.telemetry.EgressStarted(ctx, info)
+	if m.es != nil {
+		go func() {
+			if err := m.es.StoreEgress(context.Background(), info); err != nil {
+				l.Errorw("could not write egress info", err)
+			}
+		}()
+	}
+	go m.watchRoom(c)
+	return info, nil
+}
+
+// Stop ends the composite, the file is stored in the background
+func (m *AudioCompositeManager) Stop(ctx context.Context, egressID string) (*livekit.EgressInfo, error) {
+	m.lock.Lock()
+	c := m.composites[egressID]
+	if c == nil {
+		m.lock.Unlock()
+		return nil, ErrEgressNotFound
+	}
+	if c.info.Status == livekit.EgressStatus_EGRESS_ACTIVE {
+		c.info.Status = livekit.EgressStatus_EGRESS_ENDING
+		c.info.UpdatedAt = time.Now().UnixNano()
+	}
+	info := proto.Clone(c.info).(*livekit.EgressInfo)
+	m.lock.Unlock()
+
+	m.stopComposite(c)
+	m.telemetry.EgressUpdated(ctx, info)
+	return info, nil
+}
+
+// Get returns the info of an active composite, nil when it is not running on this node
+func (m *AudioCompositeManager) Get(egressID string) *livekit.EgressInfo {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if c := m.composites[egressID]; c != nil {
+		return proto.Clone(c.info).(*livekit.EgressInfo)
+	}
+	return nil
+}
+
+// List returns the active composites of a room, or of every room when roomName is empty
+func (m *AudioCompositeManager) List(roomName livekit.RoomName) []*livekit.EgressInfo {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var infos []*livekit.EgressInfo
+	for _, c := range m.composites {
+		if roomName == "" || c.info.RoomName == string(roomName) {
+			infos = append(infos, proto.Clone(c.info).(*livekit.EgressInfo))
+		}
+	}
+	return infos
+}
+
+func (m *AudioCompositeManager) StopAll() {
+	m.lock.Lock()
+	composites := make([]*audioComposite, 0, len(m.composites))
+	for _, c := range m.composites {
+		composites = append(composites, c)
+	}
+	m.lock.Unlock()
+
+	for _, c := range composites {
+		m.stopComposite(c)
+		<-c.writer.Finished()
+	}
+}
+
+func (m *AudioCompositeManager) stopComposite(c *audioComposite) {
+	if c.agent != nil {
+		c.agent.Close()
+	}
+	c.mixer.Stop()
+	c.writer.Stop()
+}
+
+// watchRoom ends the composite when the room closes
+func (m *AudioCompositeManager) watchRoom(c *audioComposite) {
+	ticker := time.NewTicker(audioCompositeRoomCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			room := m.roomManager.GetRoom(context.Background(), livekit.RoomName(c.info.RoomName))
+			if room == nil || room.IsClosed() {
+				m.stopComposite(c)
+				return
+			}
+		}
+	}
+}
+
+func (m *AudioCompositeManager) finished(c *audioComposite, result recorder.AudioCompositeInfo) {
+	m.lock.Lock()
+	delete(m.composites, c.info.EgressId)
+	c.info.EndedAt = result.EndedAt.UnixNano()
+	c.info.UpdatedAt = c.info.EndedAt
+	if result.Error != "" {
+		c.info.Status = livekit.EgressStatus_EGRESS_FAILED
+		c.info.Error = result.Error
+	} else {
+		c.info.Status = livekit.EgressStatus_EGRESS_COMPLETE
+	}
+	fileInfo := &livekit.FileInfo{
+		Filename:  result.Filepath,
+		StartedAt: result.StartedAt.UnixNano(),
+		EndedAt:   result.EndedAt.UnixNano(),
+		Duration:  result.EndedAt.Sub(result.StartedAt).Nanoseconds(),
+		Size:      result.Size,
+		Location:  result.Location,
+	}
+	c.info.Result = &livekit.EgressInfo_File{File: fileInfo}
+	c.info.FileResults = []*livekit.FileInfo{fileInfo}
+	info := proto.Clone(c.info).(*livekit.EgressInfo)
+	m.lock.Unlock()
+	close(c.done)
+
+	m.telemetry.EgressEnded(context.Background(), info)
+	if m.es != nil {
+		if err := m.es.UpdateEgress(context.Background(), info); err != nil {
+			m.logger.Errorw("could not write egress info", err, "egressID", info.EgressId)
+		}
+	}
+}
+
+// audioCompositeFilepath fills in the egress filepath template, .ogg is appended when it has no .ogg or .mp3 extension
+func audioCompositeFilepath(file *livekit.EncodedFileOutput, room *livekit.Room) (string, error) {
+	switch file.FileType {
+	case livekit.EncodedFileType_DEFAULT_FILETYPE, livekit.EncodedFileType_OGG:
+	default:
+		return "", ErrAudioOutputInvalid
+	}
+
+	filePath := file.Filepath
+	if filePath == "" || strings.HasSuffix(filePath, "/") {
+		filePath += audioCompositeDefaultFilepath
+	}
+	filePath = strings.NewReplacer(
+		"{room_name}", room.Name,
+		"{room_id}", room.Sid,
+		"{time}", time.Now().Format(audioCompositeTimeFormat),
+	).Replace(filePath)
+
+	fileType, err := recorder.AudioFileType(filePath)
+	switch {
+	case err != nil:
+		filePath += "." + recorder.AudioFileTypeOGG
+	case file.FileType == livekit.EncodedFileType_OGG && fileType != recorder.AudioFileTypeOGG:
+		return "", ErrAudioOutputInvalid
+	}
+	return filePath, nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestAudioCompositeFilepath(t *testing.T) {
+	room := &livekit.Room{Name: "myroom", Sid: "RM_id"}
+
+	filePath, err := audioCompositeFilepath(&livekit.EncodedFileOutput{}, room)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(filePath, "myroom-"))
+	require.True(t, strings.HasSuffix(filePath, ".ogg"))
+
+	filePath, err = audioCompositeFilepath(&livekit.EncodedFileOutput{Filepath: "calls/{room_id}.mp3"}, room)
+	require.NoError(t, err)
+	require.Equal(t, "calls/RM_id.mp3", filePath)
+
+	filePath, err = audioCompositeFilepath(&livekit.EncodedFileOutput{Filepath: "calls/", FileType: livekit.EncodedFileType_OGG}, room)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(filePath, "calls/myroom-"))
+
+	_, err = audioCompositeFilepath(&livekit.EncodedFileOutput{Filepath: "podcast.mp3", FileType: livekit.EncodedFileType_OGG}, room)
+	require.ErrorIs(t, err, ErrAudioOutputInvalid)
+
+	_, err = audioCompositeFilepath(&livekit.EncodedFileOutput{FileType: livekit.EncodedFileType_MP4}, room)
+	require.ErrorIs(t, err, ErrAudioOutputInvalid)
+}
@@ -19,7 +19,9 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/twitchtv/twirp"
 
 	"github.com/livekit/protocol/auth"
@@ -34,6 +36,14 @@ const (
 
 type grantsKey struct{}
 
+type apiKeyKey struct{}
+
+type tokenIDKey struct{}
+
+type tokenRevocationIDKey struct{}
+
+type regionKey struct{}
+
 var (
 	ErrPermissionDenied          = errors.New("permissions denied")
 	ErrMissingAuthorization      = errors.New("invalid authorization header. Must start with " + bearerPrefix)
@@ -44,6 +54,10 @@ var (
 // authentication middleware
 type APIKeyAuthMiddleware struct {
 	provider auth.KeyProvider
+	// how long after expiry a token can resume a session
+	resumeGrace time.Duration
+	// revoked tokens are rejected when set
+	revocations ServiceStore
 }
 
 func NewAPIKeyAuthMiddleware(provider auth.KeyProvider) *APIKeyAuthMiddleware {
@@ -52,6 +66,17 @@ func NewAPIKeyAuthMiddleware(provider auth.KeyProvider) *APIKeyAuthMiddleware {
 	}
 }
 
+// SetResumeGrace accepts tokens that expired less than grace ago for resuming signal connections,
+// clients may have been disconnected when their token was refreshed
+func (m *APIKeyAuthMiddleware) SetResumeGrace(grace time.Duration) {
+	m.resumeGrace = grace
+}
+
+// SetTokenRevocations rejects tokens that have been revoked in store
+func (m *APIKeyAuthMiddleware) SetTokenRevocations(store ServiceStore) {
+	m.revocations = store
+}
+
 func (m *APIKeyAuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	if r.URL != nil && r.URL.Path == "/rtc/validate" {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -86,14 +111,37 @@ func (m *APIKeyAuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request,
 		}
 
 		grants, err := v.Verify(secret)
+		if errors.Is(err, jwt.ErrExpired) && m.resumeGrace > 0 && isResumeRequest(r) {
+			grants, err = verifyTokenWithLeeway(authToken, v.APIKey(), secret, m.resumeGrace)
+		}
 		if err != nil {
 			handleError(w, http.StatusUnauthorized, errors.New("invalid token: "+authToken+", error: "+err.Error()))
 			return
 		}
 
+		revocationID := tokenRevocationID(authToken)
+		if m.revocations != nil {
+			if err = checkTokenRevoked(r.Context(), m.revocations, revocationID); err != nil {
+				if errors.Is(err, ErrTokenRevoked) {
+					handleError(w, http.StatusUnauthorized, err)
+				} else {
+					handleError(w, http.StatusInternalServerError, err)
+				}
+				return
+			}
+		}
+
 		// set grants in context
-		ctx := r.Context()
-		r = r.WithContext(context.WithValue(ctx, grantsKey{}, grants))
+		ctx := context.WithValue(r.Context(), grantsKey{}, grants)
+		ctx = context.WithValue(ctx, apiKeyKey{}, v.APIKey())
+		if tokenID := parseTokenID(authToken); tokenID != "" {
+			ctx = context.WithValue(ctx, tokenIDKey{}, tokenID)
+		}
+		ctx = context.WithValue(ctx, tokenRevocationIDKey{}, revocationID)
+		if region := parseTokenRegion(authToken); region != "" {
+			ctx = WithRegion(ctx, region)
+		}
+		r = r.WithContext(ctx)
 	}
 
 	next.ServeHTTP(w, r)
@@ -108,6 +156,34 @@ func GetGrants(ctx context.Context) *auth.ClaimGrants {
 	return claims
 }
 
+// GetAPIKey returns the key of the verified token of the request
+func GetAPIKey(ctx context.Context) string {
+	apiKey, _ := ctx.Value(apiKeyKey{}).(string)
+	return apiKey
+}
+
+// GetTokenID returns the ID (jti) of the verified token of the request, empty when the token has none
+func GetTokenID(ctx context.Context) string {
+	tokenID, _ := ctx.Value(tokenIDKey{}).(string)
+	return tokenID
+}
+
+// GetTokenRevocationID returns the ID revocations of the verified token of the request are stored under
+func GetTokenRevocationID(ctx context.Context) string {
+	revocationID, _ := ctx.Value(tokenRevocationIDKey{}).(string)
+	return revocationID
+}
+
+// GetRegion returns the region the verified token of the request is pinned to, empty when it is not pinned
+func GetRegion(ctx context.Context) string {
+	region, _ := ctx.Value(regionKey{}).(string)
+	return region
+}
+
+func WithRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, regionKey{}, region)
+}
+
 func WithGrants(ctx context.Context, grants *auth.ClaimGrants) context.Context {
 	return context.WithValue(ctx, grantsKey{}, grants)
 }
@@ -0,0 +1,240 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+const (
+	chaosInvariantGoroutineLeak     = "goroutine_leak"
+	chaosInvariantStatsMonotonicity = "stats_monotonicity"
+)
+
+// ChaosMonitor kills the DTLS transports of random participants and checks that the node keeps its
+// invariants while faults are injected: no goroutines are left behind once rooms have closed, and the
+// cumulative stats of published tracks never go backwards
+type ChaosMonitor struct {
+	conf  config.ChaosConfig
+	rooms func() []*rtc.Room
+	rand  *rand.Rand
+
+	baselineGoroutines int
+	reportedGoroutines int
+	// cumulative packets and bytes of each receiver at the previous check
+	receiverStats map[*sfu.WebRTCReceiver]receiverStats
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+type receiverStats struct {
+	packets uint32
+	bytes   uint64
+}
+
+// NewChaosMonitor returns nil when chaos testing is not enabled
+func NewChaosMonitor(conf config.ChaosConfig, rooms func() []*rtc.Room) *ChaosMonitor {
+	if !conf.Enabled {
+		return nil
+	}
+
+	logger.Warnw("chaos testing enabled, faults will be injected", nil,
+		"transportKill", conf.TransportKill,
+		"busDelay", conf.BusDelay,
+		"reportDrop", conf.ReportDrop,
+		"seed", conf.Seed,
+	)
+	return &ChaosMonitor{
+		conf:          conf,
+		rooms:         rooms,
+		rand:          rand.New(rand.NewSource(conf.Seed)),
+		receiverStats: make(map[*sfu.WebRTCReceiver]receiverStats),
+		done:          make(chan struct{}),
+	}
+}
+
+func (m *ChaosMonitor) Start() {
+	if m == nil {
+		return
+	}
+	m.baselineGoroutines = runtime.NumGoroutine()
+	go m.worker()
+}
+
+func (m *ChaosMonitor) Stop() {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+}
+
+func (m *ChaosMonitor) worker() {
+	ticker := time.NewTicker(m.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			rooms := m.rooms()
+			m.killTransports(rooms)
+			m.checkInvariants(rooms)
+		}
+	}
+}
+
+func (m *ChaosMonitor) killTransports(rooms []*rtc.Room) {
+	if m.conf.TransportKill == 0 {
+		return
+	}
+
+	for _, room := range rooms {
+		for _, lp := range room.GetParticipants() {
+			if m.rand.Float64() >= m.conf.TransportKill || lp.State() != livekit.ParticipantInfo_ACTIVE {
+				continue
+			}
+			p, ok := lp.(*rtc.ParticipantImpl)
+			if !ok {
+				continue
+			}
+
+			target := livekit.SignalTarget_SUBSCRIBER
+			if m.rand.Intn(2) == 0 {
+				target = livekit.SignalTarget_PUBLISHER
+			}
+			if err := p.KillTransport(target); err != nil {
+				p.GetLogger().Debugw("could not kill transport", "error", err, "target", target)
+				continue
+			}
+			p.GetLogger().Infow("chaos killed transport", "target", target)
+			prometheus.IncrementChaosFault("transport_kill")
+		}
+	}
+}
+
+func (m *ChaosMonitor) checkInvariants(rooms []*rtc.Room) {
+	if len(rooms) == 0 {
+		// everything started for rooms should have ended with them, allowing for goroutines still winding down
+		goroutines := runtime.NumGoroutine()
+		if goroutines > m.baselineGoroutines+m.conf.GoroutineLeakThreshold && goroutines > m.reportedGoroutines {
+			m.violation(chaosInvariantGoroutineLeak, "goroutines", goroutines, "baseline", m.baselineGoroutines)
+			m.reportedGoroutines = goroutines
+		}
+	}
+
+	seen := make(map[*sfu.WebRTCReceiver]receiverStats, len(m.receiverStats))
+	for _, room := range rooms {
+		for _, p := range room.GetParticipants() {
+			for _, track := range p.GetPublishedTracks() {
+				for _, r := range track.Receivers() {
+					wr, ok := r.(*sfu.WebRTCReceiver)
+					if !ok {
+						continue
+					}
+					stats := wr.GetTrackStats()
+					if stats == nil {
+						continue
+					}
+					current := receiverStats{packets: stats.Packets, bytes: stats.Bytes}
+					if prev, ok := m.receiverStats[wr]; ok && (current.packets < prev.packets || current.bytes < prev.bytes) {
+						m.violation(chaosInvariantStatsMonotonicity,
+							"room", room.Name(),
+							"participant", p.Identity(),
+							"trackID", track.ID(),
+							"packets", current.packets,
+							"prevPackets", prev.packets,
+							"bytes", current.bytes,
+							"prevBytes", prev.bytes,
+						)
+					}
+					seen[wr] = current
+				}
+			}
+		}
+	}
+	m.receiverStats = seen
+}
+
+func (m *ChaosMonitor) violation(invariant string, keysAndValues ...interface{}) {
+	logger.Errorw("chaos invariant violated", nil, append([]interface{}{"invariant", invariant}, keysAndValues...)...)
+	prometheus.IncrementChaosViolation(invariant)
+}
+
+// ------------------------------------------------
+
+// chaosMessageBus delays published messages, holding up the publisher as a congested bus would
+type chaosMessageBus struct {
+	psrpc.MessageBus
+	conf config.ChaosConfig
+
+	lock sync.Mutex
+	rand *rand.Rand
+}
+
+func newChaosMessageBus(bus psrpc.MessageBus, conf config.ChaosConfig) psrpc.MessageBus {
+	if !conf.Enabled || conf.BusDelay == 0 || conf.BusMaxDelay == 0 {
+		return bus
+	}
+
+	return &chaosMessageBus{
+		MessageBus: bus,
+		conf:       conf,
+		rand:       rand.New(rand.NewSource(conf.Seed)),
+	}
+}
+
+func (b *chaosMessageBus) Publish(ctx context.Context, channel string, msg proto.Message) error {
+	if delay := b.delay(); delay > 0 {
+		prometheus.IncrementChaosFault("bus_delay")
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return b.MessageBus.Publish(ctx, channel, msg)
+}
+
+func (b *chaosMessageBus) delay() time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	// always draw both values so that a decision does not shift the following ones
+	delayed := b.rand.Float64() < b.conf.BusDelay
+	delay := time.Duration(b.rand.Float64() * float64(b.conf.BusMaxDelay))
+	if !delayed {
+		return 0
+	}
+	return delay
+}
@@ -0,0 +1,63 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestChaosMessageBus(t *testing.T) {
+	bus := psrpc.NewLocalMessageBus()
+	require.Equal(t, bus, newChaosMessageBus(bus, config.ChaosConfig{BusDelay: 1, BusMaxDelay: time.Second}))
+	require.Equal(t, bus, newChaosMessageBus(bus, config.ChaosConfig{Enabled: true, BusMaxDelay: time.Second}))
+
+	conf := config.ChaosConfig{Enabled: true, BusDelay: 0.5, BusMaxDelay: 20 * time.Millisecond, Seed: 7}
+	delays := func() []time.Duration {
+		b := newChaosMessageBus(bus, conf).(*chaosMessageBus)
+		var d []time.Duration
+		for i := 0; i < 20; i++ {
+			d = append(d, b.delay())
+		}
+		return d
+	}
+	first := delays()
+	require.Equal(t, first, delays())
+	var delayed int
+	for _, d := range first {
+		require.LessOrEqual(t, d, conf.BusMaxDelay)
+		if d > 0 {
+			delayed++
+		}
+	}
+	require.NotZero(t, delayed)
+	require.Less(t, delayed, len(first))
+
+	// publishing gives up with the context
+	conf.BusDelay = 1
+	conf.BusMaxDelay = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := newChaosMessageBus(bus, conf).Publish(ctx, "chaos", &livekit.Room{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
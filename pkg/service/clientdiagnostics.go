@@ -0,0 +1,233 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+const (
+	clientDiagnosticsPath = "/diagnostics"
+
+	maxClientDiagnosticsSize = 256 * 1024
+	// uploads of a participant's client closer together are rejected
+	clientDiagnosticsMinInterval = 10 * time.Second
+)
+
+// ClientDiagnosticsRequest is uploaded by client SDKs, authenticated with the token the participant joined with
+type ClientDiagnosticsRequest struct {
+	// W3C getStats() reports of the client's peer connections, keyed by stats object id
+	Publisher  map[string]map[string]interface{} `json:"publisher,omitempty"`
+	Subscriber map[string]map[string]interface{} `json:"subscriber,omitempty"`
+	Events     []telemetry.ClientEvent           `json:"events,omitempty"`
+}
+
+// ClientDiagnosticsService receives the stats and event logs of clients connected to rooms hosted on this node,
+// so that both sides of a connection can be looked at together
+type ClientDiagnosticsService struct {
+	roomManager *RoomManager
+
+	lock        sync.Mutex
+	lastUploads map[string]time.Time
+}
+
+func NewClientDiagnosticsService(roomManager *RoomManager) *ClientDiagnosticsService {
+	return &ClientDiagnosticsService{
+		roomManager: roomManager,
+		lastUploads: make(map[string]time.Time),
+	}
+}
+
+func (s *ClientDiagnosticsService) PathPrefix() string {
+	return clientDiagnosticsPath
+}
+
+func (s *ClientDiagnosticsService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		handleError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	roomName, err := EnsureJoinPermission(r.Context())
+	if err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	identity := livekit.ParticipantIdentity(GetGrants(r.Context()).Identity)
+	if !s.allowUpload(roomName, identity, time.Now()) {
+		handleError(w, http.StatusTooManyRequests, ErrDiagnosticsTooFrequent, "room", roomName, "participant", identity)
+		return
+	}
+
+	var req ClientDiagnosticsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxClientDiagnosticsSize)).Decode(&req); err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	diagnostics, err := s.roomManager.ReportClientDiagnostics(r.Context(), roomName, identity, &req)
+	if err != nil {
+		handleError(w, errorStatus(err), err, "room", roomName, "participant", identity)
+		return
+	}
+	writeJSON(w, diagnostics)
+}
+
+// allowUpload returns false when the participant's client uploaded within clientDiagnosticsMinInterval
+func (s *ClientDiagnosticsService) allowUpload(roomName livekit.RoomName, identity livekit.ParticipantIdentity, now time.Time) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for key, at := range s.lastUploads {
+		if now.Sub(at) >= clientDiagnosticsMinInterval {
+			delete(s.lastUploads, key)
+		}
+	}
+	key := string(roomName) + "/" + string(identity)
+	if _, ok := s.lastUploads[key]; ok {
+		return false
+	}
+	s.lastUploads[key] = now
+	return true
+}
+
+// ReportClientDiagnostics pairs the stats uploaded by the client of a participant hosted on this node with the
+// server's stats of the same RTP streams, matched by SSRC, and passes them on to telemetry
+func (r *RoomManager) ReportClientDiagnostics(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	req *ClientDiagnosticsRequest,
+) (*telemetry.ClientDiagnostics, error) {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return nil, ErrParticipantNotFound
+	}
+
+	diagnostics := &telemetry.ClientDiagnostics{
+		Room:          string(roomName),
+		Identity:      string(identity),
+		ParticipantID: string(participant.ID()),
+		ReceivedAt:    time.Now(),
+		Tracks:        []*telemetry.ClientTrackDiagnostics{},
+		Events:        req.Events,
+	}
+	correlateClientStats(diagnostics, "publisher", req.Publisher, "outbound-rtp", publishedStreams(participant))
+	correlateClientStats(diagnostics, "subscriber", req.Subscriber, "inbound-rtp", subscribedStreams(participant))
+	sort.Slice(diagnostics.Tracks, func(i, j int) bool {
+		ti, tj := diagnostics.Tracks[i], diagnostics.Tracks[j]
+		if ti.TrackID != tj.TrackID {
+			return ti.TrackID < tj.TrackID
+		}
+		return ti.SSRC < tj.SSRC
+	})
+
+	r.telemetry.ClientDiagnosticsReported(ctx, participant.ID(), participant.GetClientInfo(), diagnostics)
+	return diagnostics, nil
+}
+
+// GetClientDiagnostics returns the diagnostics recently uploaded by the client of a participant hosted on this node
+func (r *RoomManager) GetClientDiagnostics(roomName livekit.RoomName, identity livekit.ParticipantIdentity) []*telemetry.ClientDiagnostics {
+	diagnostics := r.telemetry.ClientDiagnostics(roomName, identity)
+	if diagnostics == nil {
+		diagnostics = []*telemetry.ClientDiagnostics{}
+	}
+	return diagnostics
+}
+
+// correlateClientStats pairs the RTP stream stats objects of the client's report with the server's streams of the
+// same SSRC, other stats objects are kept as they are, prefixed by the peer connection they belong to
+func correlateClientStats(
+	diagnostics *telemetry.ClientDiagnostics,
+	pc string,
+	report map[string]map[string]interface{},
+	rtpType string,
+	streams map[uint32]*telemetry.ClientTrackDiagnostics,
+) {
+	for id, stats := range report {
+		if stats["type"] == rtpType {
+			if ssrc, ok := stats["ssrc"].(float64); ok {
+				if stream := streams[uint32(ssrc)]; stream != nil {
+					track := *stream
+					track.Client = stats
+					diagnostics.Tracks = append(diagnostics.Tracks, &track)
+					continue
+				}
+			}
+		}
+		if diagnostics.Stats == nil {
+			diagnostics.Stats = make(map[string]interface{})
+		}
+		diagnostics.Stats[pc+"/"+id] = stats
+	}
+}
+
+// publishedStreams returns the server's receive side of the streams a participant publishes, by SSRC
+func publishedStreams(participant types.LocalParticipant) map[uint32]*telemetry.ClientTrackDiagnostics {
+	streams := make(map[uint32]*telemetry.ClientTrackDiagnostics)
+	for _, track := range participant.GetPublishedTracks() {
+		for _, receiver := range track.Receivers() {
+			wr, ok := receiver.(*sfu.WebRTCReceiver)
+			if !ok {
+				continue
+			}
+			for _, buff := range wr.Buffers() {
+				ssrc := buff.GetMediaSSRC()
+				streams[ssrc] = &telemetry.ClientTrackDiagnostics{
+					TrackID:   string(track.ID()),
+					Direction: telemetry.ClientTrackDirectionPublish,
+					Mime:      wr.Codec().MimeType,
+					SSRC:      ssrc,
+					Server:    buff.GetStats(),
+				}
+			}
+		}
+	}
+	return streams
+}
+
+// subscribedStreams returns the server's send side of the streams a participant subscribes to, by SSRC
+func subscribedStreams(participant types.LocalParticipant) map[uint32]*telemetry.ClientTrackDiagnostics {
+	streams := make(map[uint32]*telemetry.ClientTrackDiagnostics)
+	for _, st := range participant.GetSubscribedTracks() {
+		dt := st.DownTrack()
+		if dt == nil {
+			continue
+		}
+		streams[dt.SSRC()] = &telemetry.ClientTrackDiagnostics{
+			TrackID:   string(st.ID()),
+			Direction: telemetry.ClientTrackDirectionSubscribe,
+			Mime:      dt.Codec().MimeType,
+			SSRC:      dt.SSRC(),
+			Server:    dt.GetTrackStats(),
+		}
+	}
+	return streams
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestCorrelateClientStats(t *testing.T) {
+	var req ClientDiagnosticsRequest
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"subscriber": {
+			"IT01V1234": {"type": "inbound-rtp", "ssrc": 1234, "packetsReceived": 100, "packetsLost": 2, "jitter": 0.005},
+			"IT01V5678": {"type": "inbound-rtp", "ssrc": 5678, "packetsReceived": 50},
+			"CP1": {"type": "candidate-pair", "currentRoundTripTime": 0.05}
+		},
+		"events": [{"timestamp": 1700000000000, "type": "ice_restart"}]
+	}`), &req))
+
+	server := &livekit.RTPStats{Packets: 102}
+	streams := map[uint32]*telemetry.ClientTrackDiagnostics{
+		1234: {
+			TrackID:   "TR_video",
+			Direction: telemetry.ClientTrackDirectionSubscribe,
+			SSRC:      1234,
+			Server:    server,
+		},
+	}
+	diagnostics := &telemetry.ClientDiagnostics{Events: req.Events}
+	correlateClientStats(diagnostics, "subscriber", req.Subscriber, "inbound-rtp", streams)
+
+	require.Len(t, diagnostics.Tracks, 1)
+	track := diagnostics.Tracks[0]
+	require.Equal(t, "TR_video", track.TrackID)
+	require.Equal(t, server, track.Server)
+	require.Equal(t, float64(100), track.Client["packetsReceived"])
+	// the server's streams are not changed
+	require.Nil(t, streams[1234].Client)
+
+	// unmatched streams and other stats objects are kept
+	require.Len(t, diagnostics.Stats, 2)
+	require.Contains(t, diagnostics.Stats, "subscriber/IT01V5678")
+	require.Contains(t, diagnostics.Stats, "subscriber/CP1")
+	require.Len(t, diagnostics.Events, 1)
+	require.Equal(t, "ice_restart", diagnostics.Events[0].Type)
+}
+
+func TestClientDiagnosticsUploadInterval(t *testing.T) {
+	s := NewClientDiagnosticsService(nil)
+	now := time.Now()
+
+	require.True(t, s.allowUpload("room", "alice", now))
+	require.False(t, s.allowUpload("room", "alice", now.Add(clientDiagnosticsMinInterval/2)))
+	// limited per participant
+	require.True(t, s.allowUpload("room", "bob", now))
+	require.True(t, s.allowUpload("other", "alice", now))
+
+	require.True(t, s.allowUpload("room", "alice", now.Add(clientDiagnosticsMinInterval)))
+}
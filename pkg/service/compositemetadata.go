@@ -0,0 +1,222 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/agent"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// CompositeMetadataTopic is the data topic of the composite metadata forwarded to recorders
+const CompositeMetadataTopic = "lk.composite.metadata"
+
+// CompositeMetadata is application state, such as the layout, a watermark or captions, that composite recordings
+// of a room reflect. The latest entry of each kind is kept for the lifetime of the room
+type CompositeMetadata struct {
+	// increases by one for every entry pushed to the room, consumers ignore entries they have already seen
+	Seq     uint64          `json:"seq"`
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// unix milliseconds
+	Timestamp int64 `json:"timestamp"`
+	// set on entries resent to a consumer that joined after they were pushed
+	Replay bool `json:"replay,omitempty"`
+}
+
+// compositeMetadataState orders the composite metadata of a room and the recorders it is delivered to
+type compositeMetadataState struct {
+	lock      sync.Mutex
+	seq       uint64
+	latest    map[string]*CompositeMetadata
+	consumers map[livekit.ParticipantID]bool
+}
+
+func newCompositeMetadataState() *compositeMetadataState {
+	return &compositeMetadataState{
+		latest:    make(map[string]*CompositeMetadata),
+		consumers: make(map[livekit.ParticipantID]bool),
+	}
+}
+
+// push records an entry and delivers it to the current consumers, an empty or null payload clears the kind.
+// deliver is called with the lock held so that entries are delivered in order
+func (s *compositeMetadataState) push(
+	kind string,
+	payload json.RawMessage,
+	at time.Time,
+	deliver func(entry *CompositeMetadata, consumers []livekit.ParticipantID),
+) *CompositeMetadata {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.seq++
+	entry := &CompositeMetadata{
+		Seq:       s.seq,
+		Kind:      kind,
+		Payload:   payload,
+		Timestamp: at.UnixMilli(),
+	}
+	if len(payload) == 0 || bytes.Equal(payload, []byte("null")) {
+		entry.Payload = nil
+		delete(s.latest, kind)
+	} else {
+		s.latest[kind] = entry
+	}
+
+	consumers := make([]livekit.ParticipantID, 0, len(s.consumers))
+	for pID := range s.consumers {
+		consumers = append(consumers, pID)
+	}
+	deliver(entry, consumers)
+	return entry
+}
+
+// addConsumer replays the latest entries to a consumer joining the room, it receives the entries pushed afterwards
+func (s *compositeMetadataState) addConsumer(
+	pID livekit.ParticipantID,
+	deliver func(entry *CompositeMetadata, consumers []livekit.ParticipantID),
+) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.consumers[pID] {
+		return false
+	}
+	s.consumers[pID] = true
+	for _, entry := range s.entriesLocked() {
+		replay := *entry
+		replay.Replay = true
+		deliver(&replay, []livekit.ParticipantID{pID})
+	}
+	return true
+}
+
+func (s *compositeMetadataState) removeConsumer(pID livekit.ParticipantID) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.consumers, pID)
+}
+
+// entries returns the latest entry of each kind, in the order they were pushed
+func (s *compositeMetadataState) entries() []*CompositeMetadata {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.entriesLocked()
+}
+
+func (s *compositeMetadataState) entriesLocked() []*CompositeMetadata {
+	entries := make([]*CompositeMetadata, 0, len(s.latest))
+	for _, entry := range s.latest {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Seq < entries[j].Seq
+	})
+	return entries
+}
+
+func (r *RoomManager) compositeMetadataRoom(roomName livekit.RoomName) *compositeMetadataState {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.compositeMetadata[roomName]
+}
+
+// compositeMetadataParticipantChanged catches recorders up with the composite metadata of the room when they join
+func (r *RoomManager) compositeMetadataParticipantChanged(room *rtc.Room, participant types.LocalParticipant) {
+	state := r.compositeMetadataRoom(room.Name())
+	if state == nil || !participant.IsRecorder() {
+		return
+	}
+
+	switch participant.State() {
+	case livekit.ParticipantInfo_ACTIVE:
+		state.addConsumer(participant.ID(), func(entry *CompositeMetadata, consumers []livekit.ParticipantID) {
+			sendCompositeMetadata(room, entry, consumers)
+		})
+	case livekit.ParticipantInfo_DISCONNECTED:
+		state.removeConsumer(participant.ID())
+	}
+}
+
+// PushCompositeMetadata records composite metadata of a room hosted on this node and forwards it to the recorders
+// in the room and the in-process agents listening to it
+func (r *RoomManager) PushCompositeMetadata(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	kind string,
+	payload json.RawMessage,
+) (*CompositeMetadata, error) {
+	if kind == "" {
+		return nil, ErrMetadataKindEmpty
+	}
+	if len(payload) > maxDataMessageSize {
+		return nil, ErrDataExceedsLimits
+	}
+	room := r.GetRoom(ctx, roomName)
+	state := r.compositeMetadataRoom(roomName)
+	if room == nil || state == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	entry := state.push(kind, payload, time.Now(), func(entry *CompositeMetadata, consumers []livekit.ParticipantID) {
+		sendCompositeMetadata(room, entry, consumers)
+		r.agents.RoomMetadata(&agent.RoomMetadata{
+			Room:      roomName,
+			Seq:       entry.Seq,
+			Kind:      entry.Kind,
+			Payload:   entry.Payload,
+			Timestamp: time.UnixMilli(entry.Timestamp),
+		})
+	})
+	room.Logger.Debugw("composite metadata pushed", "kind", kind, "seq", entry.Seq, "size", len(payload))
+	return entry, nil
+}
+
+// GetCompositeMetadata returns the latest composite metadata of each kind of a room hosted on this node
+func (r *RoomManager) GetCompositeMetadata(ctx context.Context, roomName livekit.RoomName) ([]*CompositeMetadata, error) {
+	room := r.GetRoom(ctx, roomName)
+	state := r.compositeMetadataRoom(roomName)
+	if room == nil || state == nil {
+		return nil, ErrRoomNotFound
+	}
+	return state.entries(), nil
+}
+
+func sendCompositeMetadata(room *rtc.Room, entry *CompositeMetadata, consumers []livekit.ParticipantID) {
+	if len(consumers) == 0 {
+		return
+	}
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		room.Logger.Errorw("could not encode composite metadata", err, "kind", entry.Kind)
+		return
+	}
+	topic := CompositeMetadataTopic
+	room.SendDataPacket(&livekit.UserPacket{
+		Payload:         payload,
+		DestinationSids: livekit.IDsAsStrings(consumers),
+		Topic:           &topic,
+	}, livekit.DataPacket_RELIABLE)
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestCompositeMetadata(t *testing.T) {
+	type delivery struct {
+		entry     CompositeMetadata
+		consumers []livekit.ParticipantID
+	}
+	var delivered []delivery
+	deliver := func(entry *CompositeMetadata, consumers []livekit.ParticipantID) {
+		delivered = append(delivered, delivery{entry: *entry, consumers: consumers})
+	}
+	now := time.Now()
+	s := newCompositeMetadataState()
+
+	// entries are kept without consumers
+	s.push("layout", json.RawMessage(`{"grid":2}`), now, deliver)
+	s.push("watermark", json.RawMessage(`{"url":"logo.png"}`), now, deliver)
+	s.push("layout", json.RawMessage(`{"grid":3}`), now, deliver)
+	require.Len(t, delivered, 3)
+	require.Empty(t, delivered[2].consumers)
+	entries := s.entries()
+	require.Len(t, entries, 2)
+	require.Equal(t, "watermark", entries[0].Kind)
+	require.Equal(t, uint64(3), entries[1].Seq)
+	require.JSONEq(t, `{"grid":3}`, string(entries[1].Payload))
+
+	// a consumer joining is caught up in order
+	delivered = nil
+	require.True(t, s.addConsumer("PA_recorder", deliver))
+	require.False(t, s.addConsumer("PA_recorder", deliver))
+	require.Len(t, delivered, 2)
+	require.Equal(t, uint64(2), delivered[0].entry.Seq)
+	require.Equal(t, uint64(3), delivered[1].entry.Seq)
+	require.True(t, delivered[1].entry.Replay)
+	require.Equal(t, []livekit.ParticipantID{"PA_recorder"}, delivered[1].consumers)
+	require.False(t, s.entries()[1].Replay)
+
+	// later entries are delivered to it, a null payload clears the kind
+	delivered = nil
+	entry := s.push("watermark", json.RawMessage(`null`), now, deliver)
+	require.Equal(t, uint64(4), entry.Seq)
+	require.Nil(t, entry.Payload)
+	require.Equal(t, []livekit.ParticipantID{"PA_recorder"}, delivered[0].consumers)
+	require.Len(t, s.entries(), 1)
+
+	s.removeConsumer("PA_recorder")
+	delivered = nil
+	s.push("caption", json.RawMessage(`{"text":"hello"}`), now, deliver)
+	require.Empty(t, delivered[0].consumers)
+}
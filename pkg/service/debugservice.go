@@ -0,0 +1,216 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime/trace"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/recorder"
+)
+
+const (
+	debugPathPrefix      = "/debug/"
+	debugPprofPathPrefix = "/debug/pprof/"
+	debugTracePath       = "/debug/trace"
+
+	defaultTraceDuration = 5 * time.Second
+)
+
+var (
+	ErrCaptureTooLong        = errors.New("capture duration exceeds the configured maximum")
+	ErrInvalidTraceDest      = errors.New("dest must be response, file or upload")
+	ErrTraceUploadNotEnabled = errors.New("trace upload is not configured")
+	ErrTraceInProgress       = errors.New("a runtime trace is already being captured")
+)
+
+// DebugService serves pprof profiles and runtime traces of this node to tokens signed with a debug API key,
+// so that production nodes can be profiled without exposing an unauthenticated debug port
+type DebugService struct {
+	conf     config.DebugConfig
+	uploader *recorder.S3Uploader
+}
+
+// NewDebugService returns nil when debug endpoints are not enabled
+func NewDebugService(conf config.DebugConfig) *DebugService {
+	if !conf.Enabled {
+		return nil
+	}
+
+	s := &DebugService{
+		conf: conf,
+	}
+	if conf.S3.Bucket != "" {
+		s.uploader = recorder.NewS3Uploader(conf.S3)
+	}
+	return s
+}
+
+func (s *DebugService) PathPrefix() string {
+	return debugPathPrefix
+}
+
+// ServeHTTP serves the net/http/pprof endpoints under /debug/pprof/, and /debug/trace which captures a runtime trace
+// for ?seconds= and returns it in the response, writes it to the trace directory (dest=file) or uploads it (dest=upload)
+func (s *DebugService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := s.ensureDebugPermission(r.Context()); err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	duration, err := s.captureDuration(r)
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch {
+	case r.URL.Path == debugTracePath:
+		s.captureTrace(w, r, duration)
+	case strings.HasPrefix(r.URL.Path, debugPprofPathPrefix):
+		switch strings.TrimPrefix(r.URL.Path, debugPprofPathPrefix) {
+		case "cmdline":
+			// the command line can contain keys and secrets
+			http.NotFound(w, r)
+		case "profile":
+			pprof.Profile(w, r)
+		case "symbol":
+			pprof.Symbol(w, r)
+		case "trace":
+			pprof.Trace(w, r)
+		default:
+			pprof.Index(w, r)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// ensureDebugPermission only accepts tokens signed with a debug key, API keys of the application can't profile
+func (s *DebugService) ensureDebugPermission(ctx context.Context) error {
+	if GetGrants(ctx) == nil || !slices.Contains(s.conf.APIKeys, GetAPIKey(ctx)) {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+func (s *DebugService) captureDuration(r *http.Request) (time.Duration, error) {
+	seconds := r.FormValue("seconds")
+	if seconds == "" {
+		return defaultTraceDuration, nil
+	}
+
+	sec, err := strconv.ParseFloat(seconds, 64)
+	if err != nil || sec <= 0 {
+		return 0, fmt.Errorf("invalid seconds %s", seconds)
+	}
+	duration := time.Duration(sec * float64(time.Second))
+	if s.conf.MaxCaptureDuration > 0 && duration > s.conf.MaxCaptureDuration {
+		return 0, ErrCaptureTooLong
+	}
+	return duration, nil
+}
+
+func (s *DebugService) captureTrace(w http.ResponseWriter, r *http.Request, duration time.Duration) {
+	dest := r.FormValue("dest")
+	switch dest {
+	case "", "response":
+		var buf bytes.Buffer
+		if err := s.runTrace(r, &buf, duration); err != nil {
+			handleError(w, http.StatusConflict, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="trace"`)
+		_, _ = w.Write(buf.Bytes())
+
+	case "file":
+		dir := s.conf.TraceDirectory
+		if dir == "" {
+			dir = os.TempDir()
+		}
+		path := filepath.Join(dir, traceFileName())
+		f, err := os.Create(path)
+		if err != nil {
+			handleError(w, http.StatusInternalServerError, err)
+			return
+		}
+		err = s.runTrace(r, f, duration)
+		_ = f.Close()
+		if err != nil {
+			_ = os.Remove(path)
+			handleError(w, http.StatusConflict, err)
+			return
+		}
+		logger.Infow("captured runtime trace", "location", path, "duration", duration)
+		writeJSON(w, map[string]string{"location": path})
+
+	case "upload":
+		if s.uploader == nil {
+			handleError(w, http.StatusBadRequest, ErrTraceUploadNotEnabled)
+			return
+		}
+		var buf bytes.Buffer
+		if err := s.runTrace(r, &buf, duration); err != nil {
+			handleError(w, http.StatusConflict, err)
+			return
+		}
+		location, err := s.uploader.UploadData(r.Context(), buf.Bytes(), traceFileName(), "application/octet-stream")
+		if err != nil {
+			handleError(w, http.StatusBadGateway, err)
+			return
+		}
+		logger.Infow("captured runtime trace", "location", location, "duration", duration)
+		writeJSON(w, map[string]string{"location": location})
+
+	default:
+		handleError(w, http.StatusBadRequest, ErrInvalidTraceDest)
+	}
+}
+
+// runTrace traces for the duration or until the request is canceled, only one trace can run at a time
+func (s *DebugService) runTrace(r *http.Request, out io.Writer, duration time.Duration) error {
+	if err := trace.Start(out); err != nil {
+		return ErrTraceInProgress
+	}
+	defer trace.Stop()
+
+	select {
+	case <-time.After(duration):
+	case <-r.Context().Done():
+	}
+	return nil
+}
+
+// traceFileName is unique across the nodes uploading to a bucket
+func traceFileName() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("trace-%s-%s.out", hostname, time.Now().UTC().Format("20060102-150405"))
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestDebugService(t *testing.T) {
+	require.Nil(t, NewDebugService(config.DebugConfig{}))
+
+	s := NewDebugService(config.DebugConfig{
+		Enabled:            true,
+		APIKeys:            []string{"debug"},
+		MaxCaptureDuration: time.Second,
+		TraceDirectory:     t.TempDir(),
+	})
+
+	serve := func(path string, apiKey string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if apiKey != "" {
+			ctx := WithGrants(r.Context(), &auth.ClaimGrants{Video: &auth.VideoGrant{RoomCreate: true}})
+			r = r.WithContext(context.WithValue(ctx, apiKeyKey{}, apiKey))
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("requires a debug key", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, serve("/debug/pprof/", "").Code)
+		// roomCreate is not enough without a debug key
+		require.Equal(t, http.StatusUnauthorized, serve("/debug/pprof/", "app").Code)
+		require.Equal(t, http.StatusOK, serve("/debug/pprof/", "debug").Code)
+	})
+
+	t.Run("does not serve the command line", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, serve("/debug/pprof/cmdline", "debug").Code)
+	})
+
+	t.Run("limits capture duration", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, serve("/debug/pprof/profile?seconds=10", "debug").Code)
+		require.Equal(t, http.StatusBadRequest, serve("/debug/trace?seconds=10", "debug").Code)
+	})
+
+	t.Run("trace to response", func(t *testing.T) {
+		w := serve("/debug/trace?seconds=0.05", "debug")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NotZero(t, w.Body.Len())
+	})
+
+	t.Run("trace to file", func(t *testing.T) {
+		w := serve("/debug/trace?seconds=0.05&dest=file", "debug")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var res map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		info, err := os.Stat(res["location"])
+		require.NoError(t, err)
+		require.NotZero(t, info.Size())
+	})
+
+	t.Run("upload requires a bucket", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, serve("/debug/trace?seconds=0.05&dest=upload", "debug").Code)
+	})
+}
@@ -0,0 +1,221 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/e2ee"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+type E2EEStatus struct {
+	Room     string `json:"room"`
+	Required bool   `json:"required"`
+	// key index participants were last hinted or seen to use
+	KeyIndex     uint8                   `json:"key_index"`
+	Participants []e2ee.ParticipantState `json:"participants"`
+}
+
+func (r *RoomManager) e2eeRoom(roomName livekit.RoomName) *e2ee.RoomState {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.e2eeRooms[roomName]
+}
+
+// e2eeTrackPublished checks the frames of tracks published as encrypted, tracks published without encryption are
+// rejected right away in rooms requiring encryption
+func (r *RoomManager) e2eeTrackPublished(room *rtc.Room, participant types.LocalParticipant, track types.MediaTrack) {
+	state := r.e2eeRoom(room.Name())
+	if state == nil || participant.Hidden() {
+		return
+	}
+	if track.ToProto().Encryption == livekit.Encryption_NONE {
+		if room.IsE2EERequired() {
+			r.rejectUnencryptedPublisher(room, participant, track.ID())
+		}
+		return
+	}
+
+	receivers := track.Receivers()
+	if len(receivers) == 0 {
+		return
+	}
+	identity := participant.Identity()
+	trackID := track.ID()
+	monitor := e2ee.NewMonitor(e2ee.MonitorParams{
+		TrackID: trackID,
+		IsVideo: track.Kind() == livekit.TrackType_VIDEO,
+		Logger:  room.Logger,
+		OnKeyIndex: func(keyIndex uint8) {
+			state.SetKeyIndex(identity, keyIndex)
+			sendE2EEMessage(room, e2ee.KeyIndexTopic, &e2ee.KeyIndexAnnouncement{
+				Identity: string(identity),
+				KeyIndex: keyIndex,
+			})
+		},
+		OnUnencrypted: func() {
+			state.SetUnencrypted(identity, trackID)
+			if room.IsE2EERequired() {
+				// called from the receiver's forwarding, which closing the participant waits for
+				go r.rejectUnencryptedPublisher(room, participant, trackID)
+			}
+		},
+	})
+	if err := receivers[0].AddDownTrack(monitor); err != nil {
+		room.Logger.Warnw("could not monitor track encryption", err, "participant", identity, "trackID", trackID)
+	}
+}
+
+// e2eeParticipantChanged hints participants to rotate keys when someone joins or leaves a room using encryption
+func (r *RoomManager) e2eeParticipantChanged(room *rtc.Room, participant types.LocalParticipant) {
+	state := r.e2eeRoom(room.Name())
+	if state == nil || participant.Hidden() {
+		return
+	}
+	inUse := room.IsE2EERequired() || state.InUse()
+	identity := participant.Identity()
+
+	var reason e2ee.RotationReason
+	switch participant.State() {
+	case livekit.ParticipantInfo_ACTIVE:
+		if !state.Join(identity) {
+			return
+		}
+		reason = e2ee.RotationReasonParticipantJoined
+	case livekit.ParticipantInfo_DISCONNECTED:
+		if !state.Leave(identity) {
+			return
+		}
+		reason = e2ee.RotationReasonParticipantLeft
+	default:
+		return
+	}
+	if inUse {
+		sendE2EEMessage(room, e2ee.RotateKeyTopic, &e2ee.RotateKeyHint{
+			KeyIndex: state.Rotate(),
+			Reason:   reason,
+			Identity: string(identity),
+		})
+	}
+}
+
+func (r *RoomManager) rejectUnencryptedPublisher(room *rtc.Room, participant types.LocalParticipant, trackID livekit.TrackID) {
+	room.Logger.Infow("removing participant publishing unencrypted media",
+		"participant", participant.Identity(),
+		"pID", participant.ID(),
+		"trackID", trackID,
+	)
+	room.RemoveParticipant(participant.Identity(), participant.ID(), types.ParticipantCloseReasonPublicationError)
+}
+
+// SetRoomE2EERequired changes whether a room hosted on this node requires end-to-end encryption.
+// when required, participants already publishing unencrypted media are removed and returned
+func (r *RoomManager) SetRoomE2EERequired(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	required bool,
+) ([]livekit.ParticipantIdentity, error) {
+	room := r.GetRoom(ctx, roomName)
+	state := r.e2eeRoom(roomName)
+	if room == nil || state == nil {
+		return nil, ErrRoomNotFound
+	}
+	room.SetE2EERequired(required)
+	if !required {
+		return nil, nil
+	}
+
+	var rejected []livekit.ParticipantIdentity
+	for _, participant := range room.GetParticipants() {
+		if participant.Hidden() {
+			continue
+		}
+		for _, track := range participant.GetPublishedTracks() {
+			if track.ToProto().Encryption == livekit.Encryption_NONE || state.IsUnencrypted(participant.Identity()) {
+				r.rejectUnencryptedPublisher(room, participant, track.ID())
+				rejected = append(rejected, participant.Identity())
+				break
+			}
+		}
+	}
+	return rejected, nil
+}
+
+// RotateE2EEKey hints the participants of a room hosted on this node to move to the next key
+func (r *RoomManager) RotateE2EEKey(ctx context.Context, roomName livekit.RoomName) (uint8, error) {
+	room := r.GetRoom(ctx, roomName)
+	state := r.e2eeRoom(roomName)
+	if room == nil || state == nil {
+		return 0, ErrRoomNotFound
+	}
+	keyIndex := state.Rotate()
+	sendE2EEMessage(room, e2ee.RotateKeyTopic, &e2ee.RotateKeyHint{
+		KeyIndex: keyIndex,
+		Reason:   e2ee.RotationReasonRequested,
+	})
+	return keyIndex, nil
+}
+
+// GetE2EEStatus returns the encryption state of the participants of a room hosted on this node
+func (r *RoomManager) GetE2EEStatus(ctx context.Context, roomName livekit.RoomName) (*E2EEStatus, error) {
+	room := r.GetRoom(ctx, roomName)
+	state := r.e2eeRoom(roomName)
+	if room == nil || state == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	seen := make(map[livekit.ParticipantIdentity]e2ee.ParticipantState)
+	for _, p := range state.Participants() {
+		seen[p.Identity] = p
+	}
+	status := &E2EEStatus{
+		Room:         string(roomName),
+		Required:     room.IsE2EERequired(),
+		KeyIndex:     state.KeyIndex(),
+		Participants: []e2ee.ParticipantState{},
+	}
+	for _, participant := range room.GetParticipants() {
+		if participant.Hidden() {
+			continue
+		}
+		p, ok := seen[participant.Identity()]
+		if !ok {
+			p = e2ee.ParticipantState{Identity: participant.Identity(), KeyIndex: -1}
+		}
+		status.Participants = append(status.Participants, p)
+	}
+	sort.Slice(status.Participants, func(i, j int) bool {
+		return status.Participants[i].Identity < status.Participants[j].Identity
+	})
+	return status, nil
+}
+
+func sendE2EEMessage(room *rtc.Room, topic string, message interface{}) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		room.Logger.Errorw("could not encode encryption message", err, "topic", topic)
+		return
+	}
+	room.SendDataPacket(&livekit.UserPacket{
+		Payload: payload,
+		Topic:   &topic,
+	}, livekit.DataPacket_RELIABLE)
+}
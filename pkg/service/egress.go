@@ -32,12 +32,13 @@ import (
 )
 
 type EgressService struct {
-	client      rpc.EgressClient
-	store       ServiceStore
-	es          EgressStore
-	roomService livekit.RoomService
-	telemetry   telemetry.TelemetryService
-	launcher    rtc.EgressLauncher
+	client          rpc.EgressClient
+	store           ServiceStore
+	es              EgressStore
+	roomService     livekit.RoomService
+	telemetry       telemetry.TelemetryService
+	launcher        rtc.EgressLauncher
+	audioComposites *AudioCompositeManager
 }
 
 type egressLauncher struct {
@@ -68,14 +69,16 @@ func NewEgressService(
 	rs livekit.RoomService,
 	ts telemetry.TelemetryService,
 	launcher rtc.EgressLauncher,
+	audioComposites *AudioCompositeManager,
 ) *EgressService {
 	return &EgressService{
-		client:      client,
-		store:       store,
-		es:          es,
-		roomService: rs,
-		telemetry:   ts,
-		launcher:    launcher,
+		client:          client,
+		store:           store,
+		es:              es,
+		roomService:     rs,
+		telemetry:       ts,
+		launcher:        launcher,
+		audioComposites: audioComposites,
 	}
 }
 
@@ -88,6 +91,17 @@ func (s *EgressService) StartRoomCompositeEgress(ctx context.Context, req *livek
 	defer func() {
 		AppendLogFields(ctx, fields...)
 	}()
+	if IsAudioComposite(req) {
+		if err := EnsureRecordPermission(ctx); err != nil {
+			return nil, twirpAuthError(err)
+		}
+		ei, err := s.audioComposites.Start(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, "egressID", ei.EgressId)
+		return ei, nil
+	}
 	ei, err := s.startEgress(ctx, livekit.RoomName(req.RoomName), &rpc.StartEgressRequest{
 		Request: &rpc.StartEgressRequest_RoomComposite{
 			RoomComposite: req,
@@ -302,7 +316,16 @@ func (s *EgressService) ListEgress(ctx context.Context, req *livekit.ListEgressR
 	if err := EnsureRecordPermission(ctx); err != nil {
 		return nil, twirpAuthError(err)
 	}
+	if req.EgressId != "" {
+		if info := s.audioComposites.Get(req.EgressId); info != nil {
+			return &livekit.ListEgressResponse{Items: []*livekit.EgressInfo{info}}, nil
+		}
+	}
 	if s.client == nil {
+		// built-in audio composites run without an egress deployment
+		if req.EgressId == "" {
+			return &livekit.ListEgressResponse{Items: s.audioComposites.List(livekit.RoomName(req.RoomName))}, nil
+		}
 		return nil, ErrEgressNotConnected
 	}
 
@@ -324,6 +347,19 @@ func (s *EgressService) ListEgress(ctx context.Context, req *livekit.ListEgressR
 		}
 	}
 
+	// the stored info of active audio composites can be stale
+	for _, info := range s.audioComposites.List(livekit.RoomName(req.RoomName)) {
+		replaced := false
+		for i, item := range items {
+			if item.EgressId == info.EgressId {
+				items[i], replaced = info, true
+				break
+			}
+		}
+		if !replaced {
+			items = append(items, info)
+		}
+	}
 	return &livekit.ListEgressResponse{Items: items}, nil
 }
 
@@ -332,6 +368,9 @@ func (s *EgressService) StopEgress(ctx context.Context, req *livekit.StopEgressR
 	if err := EnsureRecordPermission(ctx); err != nil {
 		return nil, twirpAuthError(err)
 	}
+	if s.audioComposites.Get(req.EgressId) != nil {
+		return s.audioComposites.Stop(ctx, req.EgressId)
+	}
 
 	if s.client == nil {
 		return nil, ErrEgressNotConnected
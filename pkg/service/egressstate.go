@@ -0,0 +1,121 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/psrpc"
+	"github.com/livekit/psrpc/pkg/client"
+	"github.com/livekit/psrpc/pkg/info"
+	"github.com/livekit/psrpc/pkg/server"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const (
+	egressStateService = "RoomEgressState"
+	egressStateMethod  = "Update"
+
+	egressStateTimeout = 5 * time.Second
+)
+
+// egressStateRouter delivers egress updates to the node hosting the room of the egress. Updates are received by
+// whichever node the egress reached, the router picks the room's node and the update is sent to it over the
+// message bus, since node messages have no egress variant.
+type egressStateRouter struct {
+	router      routing.Router
+	currentNode routing.LocalNode
+	client      *client.RPCClient
+	server      *server.RPCServer
+	update      func(ctx context.Context, info *livekit.EgressInfo)
+}
+
+func newEgressStateRouter(
+	router routing.Router,
+	currentNode routing.LocalNode,
+	bus psrpc.MessageBus,
+	update func(ctx context.Context, info *livekit.EgressInfo),
+) (*egressStateRouter, error) {
+	e := &egressStateRouter{
+		router:      router,
+		currentNode: currentNode,
+		update:      update,
+	}
+	if bus == nil || router == nil || currentNode == nil {
+		return e, nil
+	}
+
+	nodeID := currentNode.Id
+	clientSD := &info.ServiceDefinition{Name: egressStateService, ID: nodeID}
+	clientSD.RegisterMethod(egressStateMethod, false, false, true, true)
+	rpcClient, err := client.NewRPCClient(clientSD, bus, psrpc.WithClientTimeout(egressStateTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	serverSD := &info.ServiceDefinition{Name: egressStateService, ID: nodeID}
+	rpcServer := server.NewRPCServer(serverSD, bus, psrpc.WithServerTimeout(egressStateTimeout))
+	serverSD.RegisterMethod(egressStateMethod, false, false, true, true)
+	if err = server.RegisterHandler(rpcServer, egressStateMethod, []string{nodeID}, e.handleUpdate, nil); err != nil {
+		rpcServer.Close(false)
+		return nil, err
+	}
+
+	e.client = rpcClient
+	e.server = rpcServer
+	return e, nil
+}
+
+// UpdateEgressState applies the update on this node when it hosts the room, and sends it to the hosting node otherwise
+func (e *egressStateRouter) UpdateEgressState(ctx context.Context, info *livekit.EgressInfo) {
+	if info.RoomName == "" {
+		return
+	}
+	if e.client == nil {
+		e.update(ctx, info)
+		return
+	}
+
+	node, err := e.router.GetNodeForRoom(ctx, livekit.RoomName(info.RoomName))
+	if err != nil || node == nil || node.Id == e.currentNode.Id {
+		// rooms that are not hosted anywhere have no state to update
+		if err == nil && node != nil {
+			e.update(ctx, info)
+		}
+		return
+	}
+
+	if _, err = client.RequestSingle[*emptypb.Empty](ctx, e.client, egressStateMethod, []string{node.Id}, info); err != nil {
+		logger.Warnw("could not send egress update to room node", err,
+			"room", info.RoomName, "egressID", info.EgressId, "nodeID", node.Id)
+	}
+}
+
+func (e *egressStateRouter) handleUpdate(ctx context.Context, info *livekit.EgressInfo) (*emptypb.Empty, error) {
+	e.update(ctx, info)
+	return &emptypb.Empty{}, nil
+}
+
+func (e *egressStateRouter) Stop() {
+	if e.server != nil {
+		e.server.Close(false)
+	}
+}
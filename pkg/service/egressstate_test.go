@@ -0,0 +1,58 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+)
+
+func TestEgressStateRouter(t *testing.T) {
+	bus := psrpc.NewLocalMessageBus()
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomReturns(&livekit.Node{Id: "node2"}, nil)
+
+	updated := make(map[string][]string)
+	newRouter := func(nodeID string) *egressStateRouter {
+		e, err := newEgressStateRouter(router, &livekit.Node{Id: nodeID}, bus, func(_ context.Context, info *livekit.EgressInfo) {
+			updated[nodeID] = append(updated[nodeID], info.EgressId)
+		})
+		require.NoError(t, err)
+		t.Cleanup(e.Stop)
+		return e
+	}
+	e1 := newRouter("node1")
+	newRouter("node2")
+
+	// updates received for a room hosted elsewhere are applied by its node
+	e1.UpdateEgressState(context.Background(), &livekit.EgressInfo{EgressId: "EG_1", RoomName: "room"})
+	require.Equal(t, map[string][]string{"node2": {"EG_1"}}, updated)
+
+	// and updates of rooms hosted by this node locally
+	router.GetNodeForRoomReturns(&livekit.Node{Id: "node1"}, nil)
+	e1.UpdateEgressState(context.Background(), &livekit.EgressInfo{EgressId: "EG_2", RoomName: "room"})
+	require.Equal(t, map[string][]string{"node1": {"EG_2"}, "node2": {"EG_1"}}, updated)
+
+	// egresses without a room have no state to update
+	e1.UpdateEgressState(context.Background(), &livekit.EgressInfo{EgressId: "EG_3"})
+	require.Len(t, updated["node1"], 1)
+}
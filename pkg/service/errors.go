@@ -19,18 +19,59 @@ import (
 )
 
 var (
-	ErrEgressNotFound        = psrpc.NewErrorf(psrpc.NotFound, "egress does not exist")
-	ErrEgressNotConnected    = psrpc.NewErrorf(psrpc.Internal, "egress not connected (redis required)")
-	ErrIdentityEmpty         = psrpc.NewErrorf(psrpc.InvalidArgument, "identity cannot be empty")
-	ErrIngressNotConnected   = psrpc.NewErrorf(psrpc.Internal, "ingress not connected (redis required)")
-	ErrIngressNotFound       = psrpc.NewErrorf(psrpc.NotFound, "ingress does not exist")
-	ErrIngressNonReusable    = psrpc.NewErrorf(psrpc.InvalidArgument, "ingress is not reusable and cannot be modified")
-	ErrMetadataExceedsLimits = psrpc.NewErrorf(psrpc.InvalidArgument, "metadata size exceeds limits")
-	ErrOperationFailed       = psrpc.NewErrorf(psrpc.Internal, "operation cannot be completed")
-	ErrParticipantNotFound   = psrpc.NewErrorf(psrpc.NotFound, "participant does not exist")
-	ErrRoomNotFound          = psrpc.NewErrorf(psrpc.NotFound, "requested room does not exist")
-	ErrRoomLockFailed        = psrpc.NewErrorf(psrpc.Internal, "could not lock room")
-	ErrRoomUnlockFailed      = psrpc.NewErrorf(psrpc.Internal, "could not unlock room, lock token does not match")
-	ErrTrackNotFound         = psrpc.NewErrorf(psrpc.NotFound, "track is not found")
-	ErrWebHookMissingAPIKey  = psrpc.NewErrorf(psrpc.InvalidArgument, "api_key is required to use webhooks")
+	ErrAttributeKeyEmpty         = psrpc.NewErrorf(psrpc.InvalidArgument, "attribute key cannot be empty")
+	ErrAttributesExceedLimits    = psrpc.NewErrorf(psrpc.InvalidArgument, "participant attributes exceed limits")
+	ErrAudioCodecUnavailable     = psrpc.NewErrorf(psrpc.Unavailable, "no audio codec has been registered with the server")
+	ErrAudioOutputInvalid        = psrpc.NewErrorf(psrpc.InvalidArgument, "audio composite requires a single .ogg or .mp3 file output, stored locally or on S3")
+	ErrDataExceedsLimits         = psrpc.NewErrorf(psrpc.InvalidArgument, "data size exceeds limits")
+	ErrDiagnosticsTooFrequent    = psrpc.NewErrorf(psrpc.ResourceExhausted, "client diagnostics were uploaded too recently")
+	ErrEgressNotFound            = psrpc.NewErrorf(psrpc.NotFound, "egress does not exist")
+	ErrEgressNotConnected        = psrpc.NewErrorf(psrpc.Internal, "egress not connected (redis required)")
+	ErrHLSStreamNotFound         = psrpc.NewErrorf(psrpc.NotFound, "hls stream does not exist")
+	ErrIdentityEmpty             = psrpc.NewErrorf(psrpc.InvalidArgument, "identity cannot be empty")
+	ErrIngressNotConnected       = psrpc.NewErrorf(psrpc.Internal, "ingress not connected (redis required)")
+	ErrIngressNotFound           = psrpc.NewErrorf(psrpc.NotFound, "ingress does not exist")
+	ErrIngressNonReusable        = psrpc.NewErrorf(psrpc.InvalidArgument, "ingress is not reusable and cannot be modified")
+	ErrInvalidCursor             = psrpc.NewErrorf(psrpc.InvalidArgument, "invalid cursor")
+	ErrInvalidSort               = psrpc.NewErrorf(psrpc.InvalidArgument, "invalid sort field")
+	ErrJSONSignalDisabled        = psrpc.NewErrorf(psrpc.InvalidArgument, "JSON signal messages are not accepted, use binary protobuf")
+	ErrMetadataExceedsLimits     = psrpc.NewErrorf(psrpc.InvalidArgument, "metadata size exceeds limits")
+	ErrMetadataKindEmpty         = psrpc.NewErrorf(psrpc.InvalidArgument, "metadata kind cannot be empty")
+	ErrMetadataNotObject         = psrpc.NewErrorf(psrpc.FailedPrecondition, "participant metadata is not a JSON object")
+	ErrMixGainInvalid            = psrpc.NewErrorf(psrpc.InvalidArgument, "mix gain must be between 0 and 4")
+	ErrOperationFailed           = psrpc.NewErrorf(psrpc.Internal, "operation cannot be completed")
+	ErrParticipantBanned         = psrpc.NewErrorf(psrpc.PermissionDenied, "participant is banned from the room")
+	ErrParticipantNotFound       = psrpc.NewErrorf(psrpc.NotFound, "participant does not exist")
+	ErrRecorderDisabled          = psrpc.NewErrorf(psrpc.Unavailable, "track recorder is not configured")
+	ErrRecordingCodecUnsupported = psrpc.NewErrorf(psrpc.InvalidArgument, "track codec cannot be recorded or pushed")
+	ErrRecordingFormatInvalid    = psrpc.NewErrorf(psrpc.InvalidArgument, "recording format must be empty or mp4")
+	ErrRecordingNotFound         = psrpc.NewErrorf(psrpc.NotFound, "recording does not exist")
+	ErrRTPCaptureDisabled        = psrpc.NewErrorf(psrpc.Unavailable, "rtp capture requires debug to be enabled")
+	ErrRTPCaptureFormatInvalid   = psrpc.NewErrorf(psrpc.InvalidArgument, "capture format must be rtpdump or pcap")
+	ErrRTPCaptureNotFound        = psrpc.NewErrorf(psrpc.NotFound, "rtp capture does not exist")
+	ErrRTMPPushNotFound          = psrpc.NewErrorf(psrpc.NotFound, "rtmp push does not exist")
+	ErrRTMPURLInvalid            = psrpc.NewErrorf(psrpc.InvalidArgument, "invalid RTMP URL, expected rtmp(s)://host[:port]/app/stream_key")
+	ErrRTSPIngestNotFound        = psrpc.NewErrorf(psrpc.NotFound, "rtsp ingest does not exist")
+	ErrRTSPMediaUnsupported      = psrpc.NewErrorf(psrpc.InvalidArgument, "rtsp stream has no H.264 video or Opus audio")
+	ErrRTSPURLInvalid            = psrpc.NewErrorf(psrpc.InvalidArgument, "invalid RTSP URL, expected rtsp://[user:password@]host[:port]/path")
+	ErrRegionMismatch            = psrpc.NewErrorf(psrpc.FailedPrecondition, "room is hosted outside of the region the token is pinned to")
+	ErrRegionUnavailable         = psrpc.NewErrorf(psrpc.Unavailable, "no node is available in the region the token is pinned to")
+	ErrRoomClosed                = psrpc.NewErrorf(psrpc.FailedPrecondition, "room has closed")
+	ErrRoomNameEmpty             = psrpc.NewErrorf(psrpc.InvalidArgument, "room name cannot be empty")
+	ErrRoomNotFound              = psrpc.NewErrorf(psrpc.NotFound, "requested room does not exist")
+	ErrRoomLockFailed            = psrpc.NewErrorf(psrpc.Internal, "could not lock room")
+	ErrRoomNotOpen               = psrpc.NewErrorf(psrpc.FailedPrecondition, "room is not open yet")
+	ErrRoomOnOtherNode           = psrpc.NewErrorf(psrpc.Unavailable, "room is hosted on another node")
+	ErrRoomScheduleInvalid       = psrpc.NewErrorf(psrpc.InvalidArgument, "room must close after it opens")
+	ErrRoomScheduleNotFound      = psrpc.NewErrorf(psrpc.NotFound, "room is not scheduled")
+	ErrRoomUnlockFailed          = psrpc.NewErrorf(psrpc.Internal, "could not unlock room, lock token does not match")
+	ErrTokenIDMissing            = psrpc.NewErrorf(psrpc.InvalidArgument, "token or token ID to revoke is required")
+	ErrTokenNotLatest            = psrpc.NewErrorf(psrpc.Unauthenticated, "session must be resumed with the latest token sent to the participant")
+	ErrTokenRevoked              = psrpc.NewErrorf(psrpc.Unauthenticated, "token has been revoked")
+	ErrTokenRolloverNotFound     = psrpc.NewErrorf(psrpc.NotFound, "no token has been sent to the participant")
+	ErrTrackForwarderNotFound    = psrpc.NewErrorf(psrpc.NotFound, "track forwarder does not exist")
+	ErrTrackNotFound             = psrpc.NewErrorf(psrpc.NotFound, "track is not found")
+	ErrTrackNotVideo             = psrpc.NewErrorf(psrpc.InvalidArgument, "track is not a video track")
+	ErrTransferRoomInvalid       = psrpc.NewErrorf(psrpc.InvalidArgument, "participant must be transferred to another room")
+	ErrWebHookMissingAPIKey      = psrpc.NewErrorf(psrpc.InvalidArgument, "api_key is required to use webhooks")
 )
@@ -0,0 +1,176 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/recorder"
+)
+
+const (
+	hlsPathPrefix = "/hls/"
+
+	// ended streams stay available so that players receive the end of the playlist
+	hlsStreamLinger = time.Minute
+)
+
+// HLSService serves the low-latency HLS streams of rooms hosted on this node. Streams are public,
+// their unguessable IDs are part of the URL
+type HLSService struct {
+	roomManager *RoomManager
+}
+
+func NewHLSService(roomManager *RoomManager) *HLSService {
+	return &HLSService{
+		roomManager: roomManager,
+	}
+}
+
+func (s *HLSService) PathPrefix() string {
+	return hlsPathPrefix
+}
+
+// ServeHTTP serves /hls/<stream_id>/<file>
+func (s *HLSService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	streamID, name, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, hlsPathPrefix), "/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	stream := s.roomManager.getHLSStream(streamID)
+	if stream == nil {
+		http.NotFound(w, r)
+		return
+	}
+	stream.ServeFile(w, r, name)
+}
+
+// StartHLSStream packages the microphone and camera (or screen share) of a participant in a room hosted on this node
+// as low-latency HLS. Video has to be published as H.264
+func (r *RoomManager) StartHLSStream(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	upload bool,
+) (recorder.HLSStreamInfo, error) {
+	if upload && r.config.Recorder.S3.Bucket == "" {
+		return recorder.HLSStreamInfo{}, ErrRecorderDisabled
+	}
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return recorder.HLSStreamInfo{}, ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return recorder.HLSStreamInfo{}, ErrParticipantNotFound
+	}
+
+	params := recorder.HLSStreamParams{
+		Config:     r.config.Recorder.HLS,
+		PathPrefix: hlsPathPrefix,
+		Room:       roomName,
+		Identity:   identity,
+		Logger:     logger.GetLogger().WithValues("room", roomName, "participant", identity),
+	}
+	if upload {
+		params.S3 = r.config.Recorder.S3
+	}
+	if track := publishedTrackBySource(participant, livekit.TrackSource_MICROPHONE); track != nil {
+		params.AudioTrackID = track.ID()
+		params.Audio = trackReceiverForCodec(track, webrtc.MimeTypeOpus)
+	}
+	video := publishedTrackBySource(participant, livekit.TrackSource_CAMERA)
+	if video == nil {
+		video = publishedTrackBySource(participant, livekit.TrackSource_SCREEN_SHARE)
+	}
+	if video != nil {
+		params.VideoTrackID = video.ID()
+		params.Video = trackReceiverForCodec(video, webrtc.MimeTypeH264)
+	}
+
+	stream, err := recorder.NewHLSStream(params)
+	switch {
+	case errors.Is(err, recorder.ErrNoTracksToPush):
+		return recorder.HLSStreamInfo{}, ErrTrackNotFound
+	case errors.Is(err, recorder.ErrUnsupportedCodec):
+		return recorder.HLSStreamInfo{}, ErrRecordingCodecUnsupported
+	case err != nil:
+		return recorder.HLSStreamInfo{}, err
+	}
+
+	id := stream.Info().ID
+	r.lock.Lock()
+	r.hlsStreams[id] = stream
+	r.lock.Unlock()
+
+	removeStream := func() {
+		r.lock.Lock()
+		delete(r.hlsStreams, id)
+		r.lock.Unlock()
+	}
+	err = stream.Start(func(_ recorder.HLSStreamInfo) {
+		time.AfterFunc(hlsStreamLinger, removeStream)
+	})
+	if err != nil {
+		removeStream()
+		return recorder.HLSStreamInfo{}, err
+	}
+	return stream.Info(), nil
+}
+
+// StopHLSStream ends a stream and waits for its uploads to be done
+func (r *RoomManager) StopHLSStream(ctx context.Context, streamID string) (recorder.HLSStreamInfo, error) {
+	stream := r.getHLSStream(streamID)
+	if stream == nil {
+		return recorder.HLSStreamInfo{}, ErrHLSStreamNotFound
+	}
+
+	stream.Stop()
+	select {
+	case <-stream.Finished():
+	case <-ctx.Done():
+		return recorder.HLSStreamInfo{}, ctx.Err()
+	}
+	return stream.Info(), nil
+}
+
+// GetHLSStream returns a stream hosted on this node
+func (r *RoomManager) GetHLSStream(streamID string) (recorder.HLSStreamInfo, error) {
+	stream := r.getHLSStream(streamID)
+	if stream == nil {
+		return recorder.HLSStreamInfo{}, ErrHLSStreamNotFound
+	}
+	return stream.Info(), nil
+}
+
+func (r *RoomManager) getHLSStream(streamID string) *recorder.HLSStream {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.hlsStreams[streamID]
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+const oggPageHeaderSize = 27
+
+var ErrHoldMusicInvalid = errors.New("hold music has to be an Ogg Opus file")
+
+type holdMusicPacket struct {
+	data     []byte
+	duration time.Duration
+}
+
+// loadHoldMusic reads the Opus packets of an Ogg file, the stream headers are skipped
+func loadHoldMusic(path string) ([]holdMusicPacket, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		packets []holdMusicPacket
+		partial []byte
+		headers int
+	)
+	for len(data) != 0 {
+		if len(data) < oggPageHeaderSize || !bytes.HasPrefix(data, []byte("OggS")) {
+			return nil, ErrHoldMusicInvalid
+		}
+		segments := int(data[26])
+		if len(data) < oggPageHeaderSize+segments {
+			return nil, io.ErrUnexpectedEOF
+		}
+		lacing := data[oggPageHeaderSize : oggPageHeaderSize+segments]
+		body := data[oggPageHeaderSize+segments:]
+		for _, size := range lacing {
+			if len(body) < int(size) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			partial = append(partial, body[:size]...)
+			body = body[size:]
+			// a lacing value below 255 ends the packet, packets may continue on the next page
+			if size == 255 {
+				continue
+			}
+
+			packet := partial
+			partial = nil
+			// OpusHead and OpusTags
+			if headers < 2 {
+				if headers == 0 && !bytes.HasPrefix(packet, []byte("OpusHead")) {
+					return nil, ErrHoldMusicInvalid
+				}
+				headers++
+				continue
+			}
+			if duration := opusPacketDuration(packet); duration > 0 {
+				packets = append(packets, holdMusicPacket{data: packet, duration: duration})
+			}
+		}
+		data = body
+	}
+	if len(packets) == 0 {
+		return nil, ErrHoldMusicInvalid
+	}
+	return packets, nil
+}
+
+// opusPacketDuration is the duration of the frames of an Opus packet, according to its TOC byte (RFC 6716, 3.1)
+func opusPacketDuration(packet []byte) time.Duration {
+	if len(packet) == 0 {
+		return 0
+	}
+	config := packet[0] >> 3
+	var frame time.Duration
+	switch {
+	case config < 12:
+		frame = []time.Duration{10, 20, 40, 60}[config%4] * time.Millisecond
+	case config < 16:
+		frame = []time.Duration{10, 20}[config%2] * time.Millisecond
+	default:
+		frame = []time.Duration{2500, 5000, 10000, 20000}[config%4] * time.Microsecond
+	}
+
+	switch packet[0] & 0x3 {
+	case 0:
+		return frame
+	case 1, 2:
+		return 2 * frame
+	default:
+		if len(packet) < 2 {
+			return 0
+		}
+		return time.Duration(packet[1]&0x3f) * frame
+	}
+}
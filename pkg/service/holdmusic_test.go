@@ -0,0 +1,63 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3/pkg/media/oggwriter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadHoldMusic(t *testing.T) {
+	t.Run("reads opus packets", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "hold.ogg")
+		writer, err := oggwriter.New(path, 48000, 2)
+		require.NoError(t, err)
+
+		long := make([]byte, 600)
+		long[0] = 0xfb // 20ms CELT frames, count in the next byte
+		long[1] = 3
+		// the writer can only close files ending with a short packet
+		payloads := [][]byte{{0xf8, 0xff, 0xfe}, long, {0x00, 0x01}}
+		for i, payload := range payloads {
+			require.NoError(t, writer.WriteRTP(&rtp.Packet{
+				Header:  rtp.Header{Version: 2, SequenceNumber: uint16(i), Timestamp: uint32(i * 960)},
+				Payload: payload,
+			}))
+		}
+		require.NoError(t, writer.Close())
+
+		packets, err := loadHoldMusic(path)
+		require.NoError(t, err)
+		require.Len(t, packets, 3)
+		require.Equal(t, payloads[0], packets[0].data)
+		require.Equal(t, 20*time.Millisecond, packets[0].duration)
+		require.Equal(t, long, packets[1].data)
+		require.Equal(t, 60*time.Millisecond, packets[1].duration)
+		require.Equal(t, 10*time.Millisecond, packets[2].duration)
+	})
+
+	t.Run("rejects other files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "hold.wav")
+		require.NoError(t, os.WriteFile(path, []byte("RIFF0000WAVEfmt "), 0o600))
+		_, err := loadHoldMusic(path)
+		require.ErrorIs(t, err, ErrHoldMusicInvalid)
+	})
+}
@@ -0,0 +1,301 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/turn/v2"
+
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/logger/pionlogger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+type iceServerProbe struct {
+	name       string
+	turnServer *config.TURNServer
+	stunServer string
+
+	failures  int
+	healthy   bool
+	checkedAt time.Time
+	lastError error
+}
+
+// ICEServerHealthMonitor periodically probes the external STUN and TURN servers given to clients.
+// TURN servers are probed with an authenticated allocation, STUN servers with a binding request.
+// Servers are healthy until they fail a number of consecutive probes.
+type ICEServerHealthMonitor struct {
+	conf  config.ICEServerHealthConfig
+	probe func(p *iceServerProbe) error
+
+	lock   sync.RWMutex
+	probes []*iceServerProbe
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewICEServerHealthMonitor returns nil when probing is disabled or there is nothing to probe
+func NewICEServerHealthMonitor(conf *config.Config) *ICEServerHealthMonitor {
+	healthConf := conf.RTC.ICEServerHealth
+	if !healthConf.Enabled || (len(conf.RTC.TURNServers) == 0 && len(conf.RTC.STUNServers) == 0) {
+		return nil
+	}
+
+	m := &ICEServerHealthMonitor{
+		conf: healthConf,
+		done: make(chan struct{}),
+	}
+	m.probe = m.probeServer
+	for i := range conf.RTC.TURNServers {
+		s := conf.RTC.TURNServers[i]
+		m.probes = append(m.probes, &iceServerProbe{
+			name:       turnServerURL(s),
+			turnServer: &s,
+			healthy:    true,
+		})
+	}
+	for _, s := range conf.RTC.STUNServers {
+		m.probes = append(m.probes, &iceServerProbe{
+			name:       "stun:" + s,
+			stunServer: s,
+			healthy:    true,
+		})
+	}
+	return m
+}
+
+func (m *ICEServerHealthMonitor) Start() {
+	if m == nil {
+		return
+	}
+	go m.worker()
+}
+
+func (m *ICEServerHealthMonitor) Stop() {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+}
+
+func (m *ICEServerHealthMonitor) IsTURNServerHealthy(s config.TURNServer) bool {
+	return m.isHealthy(turnServerURL(s))
+}
+
+func (m *ICEServerHealthMonitor) IsSTUNServerHealthy(s string) bool {
+	return m.isHealthy("stun:" + s)
+}
+
+func (m *ICEServerHealthMonitor) isHealthy(name string) bool {
+	if m == nil {
+		return true
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	for _, p := range m.probes {
+		if p.name == name {
+			return p.healthy
+		}
+	}
+	return true
+}
+
+// Ready returns false when probes are required for readiness and all servers are unhealthy,
+// along with a description of the servers that are
+func (m *ICEServerHealthMonitor) Ready() (bool, string) {
+	if m == nil {
+		return true, ""
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	var unhealthy []string
+	for _, p := range m.probes {
+		if !p.healthy {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s: %v, checked at %s", p.name, p.lastError, p.checkedAt.Format(time.RFC3339)))
+		}
+	}
+	ready := !m.conf.RequiredForReadiness || len(unhealthy) < len(m.probes)
+	return ready, strings.Join(unhealthy, "\n")
+}
+
+func (m *ICEServerHealthMonitor) worker() {
+	ticker := time.NewTicker(m.conf.Interval)
+	defer ticker.Stop()
+
+	m.probeAll()
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.probeAll()
+		}
+	}
+}
+
+func (m *ICEServerHealthMonitor) probeAll() {
+	m.lock.RLock()
+	probes := m.probes
+	m.lock.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, p := range probes {
+		wg.Add(1)
+		go func(p *iceServerProbe) {
+			defer wg.Done()
+			err := m.probe(p)
+
+			m.lock.Lock()
+			p.checkedAt = time.Now()
+			p.lastError = err
+			wasHealthy := p.healthy
+			if err == nil {
+				p.failures = 0
+				p.healthy = true
+			} else {
+				p.failures++
+				if p.failures >= m.conf.FailureThreshold {
+					p.healthy = false
+				}
+			}
+			healthy := p.healthy
+			m.lock.Unlock()
+
+			if healthy != wasHealthy {
+				if healthy {
+					logger.Infow("ICE server healthy again", "server", p.name)
+				} else {
+					logger.Warnw("ICE server unhealthy", err, "server", p.name)
+				}
+			}
+			prometheus.SetICEServerHealthy(p.name, healthy)
+		}(p)
+	}
+	wg.Wait()
+}
+
+func (m *ICEServerHealthMonitor) probeServer(p *iceServerProbe) error {
+	if p.turnServer != nil {
+		return probeTURNServer(*p.turnServer, m.conf.Timeout)
+	}
+	return probeSTUNServer(p.stunServer, m.conf.Timeout)
+}
+
+func probeTURNServer(s config.TURNServer, timeout time.Duration) error {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+
+	var conn net.PacketConn
+	switch s.Protocol {
+	case "udp":
+		udpConn, err := net.ListenPacket("udp4", "0.0.0.0:0")
+		if err != nil {
+			return err
+		}
+		conn = udpConn
+	case "tls":
+		tlsConn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", addr, &tls.Config{ServerName: s.Host})
+		if err != nil {
+			return err
+		}
+		conn = turn.NewSTUNConn(tlsConn)
+	default:
+		tcpConn, err := net.DialTimeout("tcp", addr, timeout)
+		if err != nil {
+			return err
+		}
+		conn = turn.NewSTUNConn(tcpConn)
+	}
+	defer conn.Close()
+
+	client, err := turn.NewClient(&turn.ClientConfig{
+		STUNServerAddr: addr,
+		TURNServerAddr: addr,
+		Username:       s.Username,
+		Password:       s.Credential,
+		Conn:           conn,
+		LoggerFactory:  pionlogger.NewLoggerFactory(logger.GetLogger()),
+	})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err = client.Listen(); err != nil {
+		return err
+	}
+
+	// pending transactions fail once the client is closed
+	timer := time.AfterFunc(timeout, client.Close)
+	defer timer.Stop()
+
+	relayConn, err := client.Allocate()
+	if err != nil {
+		return err
+	}
+	return relayConn.Close()
+}
+
+func probeSTUNServer(addr string, timeout time.Duration) error {
+	conn, err := net.ListenPacket("udp4", "0.0.0.0:0")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client, err := turn.NewClient(&turn.ClientConfig{
+		STUNServerAddr: addr,
+		Conn:           conn,
+		LoggerFactory:  pionlogger.NewLoggerFactory(logger.GetLogger()),
+	})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err = client.Listen(); err != nil {
+		return err
+	}
+
+	timer := time.AfterFunc(timeout, client.Close)
+	defer timer.Stop()
+
+	_, err = client.SendBindingRequest()
+	return err
+}
+
+func turnServerURL(s config.TURNServer) string {
+	scheme := "turn"
+	transport := "tcp"
+	if s.Protocol == "tls" {
+		scheme = "turns"
+	} else if s.Protocol == "udp" {
+		transport = "udp"
+	}
+	return fmt.Sprintf("%s:%s:%d?transport=%s", scheme, s.Host, s.Port, transport)
+}
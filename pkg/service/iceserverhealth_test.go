@@ -0,0 +1,85 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestICEServerHealthMonitor(t *testing.T) {
+	turnServer := config.TURNServer{Host: "turn.example.com", Port: 443, Protocol: "tls"}
+	conf := &config.Config{
+		RTC: config.RTCConfig{
+			TURNServers: []config.TURNServer{turnServer},
+			ICEServerHealth: config.ICEServerHealthConfig{
+				Enabled:              true,
+				Interval:             time.Second,
+				Timeout:              time.Second,
+				FailureThreshold:     2,
+				RequiredForReadiness: true,
+			},
+		},
+	}
+	conf.RTC.STUNServers = []string{"stun.example.com:3478"}
+
+	t.Run("disabled", func(t *testing.T) {
+		var m *ICEServerHealthMonitor
+		require.True(t, m.IsTURNServerHealthy(turnServer))
+		ready, _ := m.Ready()
+		require.True(t, ready)
+
+		require.Nil(t, NewICEServerHealthMonitor(&config.Config{RTC: config.RTCConfig{TURNServers: conf.RTC.TURNServers}}))
+	})
+
+	t.Run("consecutive failures", func(t *testing.T) {
+		m := NewICEServerHealthMonitor(conf)
+		turnErr := errors.New("allocation failed")
+		var stunErr error
+		m.probe = func(p *iceServerProbe) error {
+			if p.turnServer != nil {
+				return turnErr
+			}
+			return stunErr
+		}
+
+		m.probeAll()
+		require.True(t, m.IsTURNServerHealthy(turnServer))
+
+		m.probeAll()
+		require.False(t, m.IsTURNServerHealthy(turnServer))
+		require.True(t, m.IsSTUNServerHealthy("stun.example.com:3478"))
+		ready, _ := m.Ready()
+		require.True(t, ready)
+
+		stunErr = errors.New("binding timed out")
+		m.probeAll()
+		m.probeAll()
+		ready, report := m.Ready()
+		require.False(t, ready)
+		require.Contains(t, report, "turns:turn.example.com:443?transport=tcp")
+
+		turnErr = nil
+		m.probeAll()
+		require.True(t, m.IsTURNServerHealthy(turnServer))
+		ready, _ = m.Ready()
+		require.True(t, ready)
+	})
+}
@@ -19,6 +19,8 @@ import (
 	"time"
 
 	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
@@ -37,8 +39,27 @@ type ObjectStore interface {
 	StoreRoom(ctx context.Context, room *livekit.Room, internal *livekit.RoomInternal) error
 	DeleteRoom(ctx context.Context, roomName livekit.RoomName) error
 
+	// StoreRoomLocked marks a room as closed to new participants until DeleteRoomLocked, or the room is deleted
+	StoreRoomLocked(ctx context.Context, roomName livekit.RoomName) error
+	DeleteRoomLocked(ctx context.Context, roomName livekit.RoomName) error
+
 	StoreParticipant(ctx context.Context, roomName livekit.RoomName, participant *livekit.ParticipantInfo) error
 	DeleteParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error
+
+	StoreRoomSchedule(ctx context.Context, schedule *RoomSchedule) error
+	DeleteRoomSchedule(ctx context.Context, roomName livekit.RoomName) error
+
+	StoreParticipantBan(ctx context.Context, ban *ParticipantBan) error
+	DeleteParticipantBan(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error
+
+	StoreTokenRevocation(ctx context.Context, revocation *TokenRevocation) error
+	DeleteTokenRevocation(ctx context.Context, tokenID string) error
+
+	StoreTokenRollover(ctx context.Context, rollover *TokenRollover) error
+	DeleteTokenRollover(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error
+
+	// AppendRoomJournal retains a room event past the close of the room, entries are appended in sequence order
+	AppendRoomJournal(ctx context.Context, roomName livekit.RoomName, entry *rtc.JournalEntry) error
 }
 
 //counterfeiter:generate . ServiceStore
@@ -48,8 +69,23 @@ type ServiceStore interface {
 	// ListRooms returns currently active rooms. if names is not nil, it'll filter and return
 	// only rooms that match
 	ListRooms(ctx context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error)
+	IsRoomLocked(ctx context.Context, roomName livekit.RoomName) (bool, error)
 	LoadParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error)
 	ListParticipants(ctx context.Context, roomName livekit.RoomName) ([]*livekit.ParticipantInfo, error)
+
+	LoadRoomSchedule(ctx context.Context, roomName livekit.RoomName) (*RoomSchedule, error)
+	ListRoomSchedules(ctx context.Context) ([]*RoomSchedule, error)
+
+	// ListParticipantBans returns the bans of a room that have not expired
+	ListParticipantBans(ctx context.Context, roomName livekit.RoomName) ([]*ParticipantBan, error)
+
+	// IsTokenRevoked returns true when a revocation that has not expired exists for the token ID
+	IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	LoadTokenRollover(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*TokenRollover, error)
+
+	// LoadRoomJournal returns up to limit room events following sequence number after, and the sequence number of
+	// the latest event. truncated is set when events following after are no longer retained
+	LoadRoomJournal(ctx context.Context, roomName livekit.RoomName, after uint64, limit int) (entries []rtc.JournalEntry, lastSeq uint64, truncated bool, err error)
 }
 
 //counterfeiter:generate . EgressStore
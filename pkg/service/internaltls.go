@@ -0,0 +1,274 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/livekit/protocol/logger"
+	redisLiveKit "github.com/livekit/protocol/redis"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	internalCertCheckInterval = time.Minute
+	internalDialTimeout       = 5 * time.Second
+)
+
+// internalTLS provides mutual TLS for connections to redis, which carries the message bus and with it all traffic
+// between nodes. the certificate and CA files are reloaded when they change, so that short-lived certificates such
+// as SPIFFE SVIDs can be rotated without a restart
+type internalTLS struct {
+	conf config.InternalTLSConfig
+
+	lock        sync.Mutex
+	cert        *tls.Certificate
+	roots       *x509.CertPool
+	certModTime time.Time
+	checkedAt   time.Time
+}
+
+func newInternalTLS(conf config.InternalTLSConfig) (*internalTLS, error) {
+	t := &internalTLS{
+		conf: conf,
+	}
+	if err := t.loadFromFiles(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ClientConfig returns the TLS config of a connection to host, which the peer certificate is verified against
+// unless a server name or SPIFFE IDs are configured
+func (t *internalTLS) ClientConfig(host string) *tls.Config {
+	serverName := host
+	if t.conf.ServerName != "" {
+		serverName = t.conf.ServerName
+	}
+	return &tls.Config{
+		MinVersion:           tls.VersionTLS12,
+		ServerName:           serverName,
+		GetClientCertificate: t.getClientCertificate,
+		// peers are verified against the reloadable roots, by SPIFFE ID when configured
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			return t.verifyConnection(cs, serverName)
+		},
+	}
+}
+
+// Dial connects to addr over mutual TLS. each connection is verified against its own host, so that every member of
+// a sentinel or cluster deployment, including the ones learned from redis, is checked against its certificate
+func (t *internalTLS) Dial(ctx context.Context, network string, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	dialer := &net.Dialer{Timeout: internalDialTimeout, KeepAlive: 5 * time.Minute}
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, internalDialTimeout)
+	defer cancel()
+	tlsConn := tls.Client(conn, t.ClientConfig(host))
+	if err = tlsConn.HandshakeContext(ctx); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+func (t *internalTLS) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	cert, _ := t.reloadIfChanged()
+	return cert, nil
+}
+
+func (t *internalTLS) verifyConnection(cs tls.ConnectionState, serverName string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("peer did not present a certificate")
+	}
+	_, roots := t.reloadIfChanged()
+
+	leaf := cs.PeerCertificates[0]
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if len(t.conf.SPIFFEIDs) == 0 {
+		opts.DNSName = serverName
+	}
+	if _, err := leaf.Verify(opts); err != nil {
+		return err
+	}
+
+	if len(t.conf.SPIFFEIDs) != 0 {
+		return verifySPIFFEID(leaf, t.conf.SPIFFEIDs)
+	}
+	return nil
+}
+
+// verifySPIFFEID checks that the single URI SAN of a certificate is one of the allowed SPIFFE IDs
+func verifySPIFFEID(cert *x509.Certificate, allowed []string) error {
+	if len(cert.URIs) != 1 || cert.URIs[0].Scheme != "spiffe" {
+		return errors.New("peer certificate does not have a SPIFFE ID")
+	}
+	id := cert.URIs[0].String()
+	for _, a := range allowed {
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok {
+			if strings.HasPrefix(id, prefix+"/") {
+				return nil
+			}
+		} else if id == a {
+			return nil
+		}
+	}
+	return errors.Errorf("peer SPIFFE ID %s is not allowed", id)
+}
+
+func (t *internalTLS) loadFromFiles() error {
+	cert, err := tls.LoadX509KeyPair(t.conf.CertFile, t.conf.KeyFile)
+	if err != nil {
+		return errors.Wrap(err, "could not load internal TLS certificate")
+	}
+	ca, err := os.ReadFile(t.conf.CAFile)
+	if err != nil {
+		return errors.Wrap(err, "could not load internal TLS CA")
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca) {
+		return errors.New("internal TLS CA file does not contain certificates")
+	}
+	modTime, err := t.filesModTime()
+	if err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	t.cert = &cert
+	t.roots = roots
+	t.certModTime = modTime
+	t.checkedAt = time.Now()
+	t.lock.Unlock()
+	return nil
+}
+
+func (t *internalTLS) filesModTime() (time.Time, error) {
+	var modTime time.Time
+	for _, f := range []string{t.conf.CertFile, t.conf.KeyFile, t.conf.CAFile} {
+		info, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return modTime, nil
+}
+
+func (t *internalTLS) reloadIfChanged() (*tls.Certificate, *x509.CertPool) {
+	t.lock.Lock()
+	cert, roots := t.cert, t.roots
+	if time.Since(t.checkedAt) < internalCertCheckInterval {
+		t.lock.Unlock()
+		return cert, roots
+	}
+	t.checkedAt = time.Now()
+	certModTime := t.certModTime
+	t.lock.Unlock()
+
+	modTime, err := t.filesModTime()
+	if err != nil || !modTime.After(certModTime) {
+		return cert, roots
+	}
+	if err = t.loadFromFiles(); err != nil {
+		logger.Warnw("could not reload internal TLS certificate, keeping current one", err)
+		return cert, roots
+	}
+	logger.Infow("reloaded internal TLS certificate")
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.cert, t.roots
+}
+
+// createMTLSRedisClient connects to redis like redisLiveKit.GetRedisClient, presenting this node's certificate
+func createMTLSRedisClient(conf *redisLiveKit.RedisConfig, tlsConf config.InternalTLSConfig) (redis.UniversalClient, error) {
+	addrs := []string{conf.Address}
+	if len(conf.SentinelAddresses) > 0 {
+		addrs = conf.SentinelAddresses
+	} else if len(conf.ClusterAddresses) > 0 {
+		addrs = conf.ClusterAddresses
+	}
+	t, err := newInternalTLS(tlsConf)
+	if err != nil {
+		return nil, err
+	}
+
+	rcOptions := &redis.UniversalOptions{
+		Addrs:    addrs,
+		Username: conf.Username,
+		Password: conf.Password,
+		DB:       conf.DB,
+		// dials sentinels, masters and cluster nodes alike
+		Dialer: t.Dial,
+	}
+	if len(conf.SentinelAddresses) > 0 {
+		logger.Infow("connecting to redis", "sentinel", true, "addr", addrs, "masterName", conf.MasterName, "mtls", true)
+		rcOptions.SentinelUsername = conf.SentinelUsername
+		rcOptions.SentinelPassword = conf.SentinelPassword
+		rcOptions.MasterName = conf.MasterName
+		rcOptions.DialTimeout = 2 * time.Second
+		rcOptions.ReadTimeout = 200 * time.Millisecond
+		rcOptions.WriteTimeout = 200 * time.Millisecond
+		if conf.DialTimeout != 0 {
+			rcOptions.DialTimeout = time.Duration(conf.DialTimeout) * time.Millisecond
+		}
+		if conf.ReadTimeout != 0 {
+			rcOptions.ReadTimeout = time.Duration(conf.ReadTimeout) * time.Millisecond
+		}
+		if conf.WriteTimeout != 0 {
+			rcOptions.WriteTimeout = time.Duration(conf.WriteTimeout) * time.Millisecond
+		}
+	} else if len(conf.ClusterAddresses) > 0 {
+		logger.Infow("connecting to redis", "cluster", true, "addr", addrs, "mtls", true)
+		rcOptions.MaxRedirects = conf.GetMaxRedirects()
+	} else {
+		logger.Infow("connecting to redis", "simple", true, "addr", addrs, "mtls", true)
+	}
+
+	rc := redis.NewUniversalClient(rcOptions)
+	if err = rc.Ping(context.Background()).Err(); err != nil {
+		return nil, errors.Wrap(err, "unable to connect to redis")
+	}
+	return rc, nil
+}
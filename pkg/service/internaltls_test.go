@@ -0,0 +1,198 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (ca *testCA) issue(t *testing.T, dnsName, spiffeID string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if dnsName != "" {
+		template.DNSNames = []string{dnsName}
+	}
+	if spiffeID != "" {
+		u, err := url.Parse(spiffeID)
+		require.NoError(t, err)
+		template.URIs = []*url.URL{u}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func (ca *testCA) writeFiles(t *testing.T, dir string, cert tls.Certificate) config.InternalTLSConfig {
+	keyDER, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	require.NoError(t, err)
+	conf := config.InternalTLSConfig{
+		Enabled:  true,
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+		CAFile:   filepath.Join(dir, "ca.pem"),
+	}
+	require.NoError(t, os.WriteFile(conf.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600))
+	require.NoError(t, os.WriteFile(conf.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	require.NoError(t, os.WriteFile(conf.CAFile, ca.pem, 0600))
+	return conf
+}
+
+// handshake connects a client using the internal TLS config to a server requiring client certificates of the CA,
+// so that failures come from the client verifying the server
+func handshake(t *testing.T, client *internalTLS, host string, ca *testCA, serverCert tls.Certificate) error {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	c, s := net.Pipe()
+	defer c.Close()
+	defer s.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		server := tls.Server(s, &tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientCAs:    roots,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		})
+		serverErr <- server.Handshake()
+		_ = server.Close()
+	}()
+
+	err := tls.Client(c, client.ClientConfig(host)).Handshake()
+	_ = c.Close()
+	if err != nil {
+		return err
+	}
+	return <-serverErr
+}
+
+func TestInternalTLS(t *testing.T) {
+	ca := newTestCA(t)
+	conf := ca.writeFiles(t, t.TempDir(), ca.issue(t, "", "spiffe://example.org/livekit/node-1"))
+
+	t.Run("verifies each host", func(t *testing.T) {
+		client, err := newInternalTLS(conf)
+		require.NoError(t, err)
+		require.NoError(t, handshake(t, client, "redis-1.example.org", ca, ca.issue(t, "redis-1.example.org", "")))
+		require.NoError(t, handshake(t, client, "redis-2.example.org", ca, ca.issue(t, "redis-2.example.org", "")))
+		require.Error(t, handshake(t, client, "redis-2.example.org", ca, ca.issue(t, "redis-1.example.org", "")))
+	})
+
+	t.Run("verifies configured server name", func(t *testing.T) {
+		namedConf := conf
+		namedConf.ServerName = "redis.example.org"
+		client, err := newInternalTLS(namedConf)
+		require.NoError(t, err)
+		require.NoError(t, handshake(t, client, "10.0.0.1", ca, ca.issue(t, "redis.example.org", "")))
+		require.Error(t, handshake(t, client, "10.0.0.1", ca, ca.issue(t, "other.example.org", "")))
+	})
+
+	t.Run("verifies SPIFFE ID", func(t *testing.T) {
+		spiffeConf := conf
+		spiffeConf.SPIFFEIDs = []string{"spiffe://example.org/redis/*"}
+		client, err := newInternalTLS(spiffeConf)
+		require.NoError(t, err)
+		require.NoError(t, handshake(t, client, "redis.example.org", ca, ca.issue(t, "", "spiffe://example.org/redis/primary")))
+		require.Error(t, handshake(t, client, "redis.example.org", ca, ca.issue(t, "", "spiffe://example.org/other/primary")))
+		require.Error(t, handshake(t, client, "redis.example.org", ca, ca.issue(t, "redis.example.org", "")))
+	})
+
+	t.Run("rejects other CAs", func(t *testing.T) {
+		client, err := newInternalTLS(conf)
+		require.NoError(t, err)
+		other := newTestCA(t)
+		require.Error(t, handshake(t, client, "redis.example.org", ca, other.issue(t, "redis.example.org", "")))
+	})
+
+	t.Run("dials with the host of the address", func(t *testing.T) {
+		client, err := newInternalTLS(conf)
+		require.NoError(t, err)
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.cert)
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+			Certificates: []tls.Certificate{ca.issue(t, "localhost", "")},
+			ClientCAs:    roots,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		})
+		require.NoError(t, err)
+		defer listener.Close()
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				_ = conn.(*tls.Conn).Handshake()
+				_ = conn.Close()
+			}
+		}()
+		_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+		conn, err := client.Dial(context.Background(), "tcp", net.JoinHostPort("localhost", port))
+		require.NoError(t, err)
+		_ = conn.Close()
+		// the certificate is not issued for the IP address
+		_, err = client.Dial(context.Background(), "tcp", listener.Addr().String())
+		require.Error(t, err)
+	})
+}
@@ -29,10 +29,13 @@ import (
 
 type IOInfoService struct {
 	ioServer  rpc.IOInfoServer
+	bus       psrpc.MessageBus
 	es        EgressStore
 	is        IngressStore
 	telemetry telemetry.TelemetryService
 	shutdown  chan struct{}
+
+	onEgressUpdated func(ctx context.Context, info *livekit.EgressInfo)
 }
 
 func NewIOInfoService(
@@ -43,6 +46,7 @@ func NewIOInfoService(
 	ts telemetry.TelemetryService,
 ) (*IOInfoService, error) {
 	s := &IOInfoService{
+		bus:       bus,
 		es:        es,
 		is:        is,
 		telemetry: ts,
@@ -73,9 +77,17 @@ func (s *IOInfoService) Start() error {
 	return nil
 }
 
+func (s *IOInfoService) OnEgressUpdated(f func(ctx context.Context, info *livekit.EgressInfo)) {
+	s.onEgressUpdated = f
+}
+
 func (s *IOInfoService) UpdateEgressInfo(ctx context.Context, info *livekit.EgressInfo) (*emptypb.Empty, error) {
 	err := s.es.UpdateEgress(ctx, info)
 
+	if s.onEgressUpdated != nil {
+		s.onEgressUpdated(ctx, info)
+	}
+
 	switch info.Status {
 	case livekit.EgressStatus_EGRESS_ACTIVE:
 		s.telemetry.EgressUpdated(ctx, info)
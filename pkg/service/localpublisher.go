@@ -0,0 +1,195 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+const (
+	localICEGatheringTimeout = 5 * time.Second
+	localSubscribeTimeout    = 10 * time.Second
+	localSubscribeInterval   = 100 * time.Millisecond
+)
+
+type localTrack struct {
+	track  webrtc.TrackLocal
+	source livekit.TrackSource
+}
+
+// localPublisher publishes tracks produced in this process, such as ingested cameras or audio mixes, from a
+// client peer connection that joins the room as a participant signaled the same way as WHIP sessions
+type localPublisher struct {
+	pc      *webrtc.PeerConnection
+	session *httpSignalSession
+}
+
+func publishLocalTracks(
+	ctx context.Context,
+	rtcService *RTCService,
+	sessions *httpSignalSessions,
+	roomName livekit.RoomName,
+	pi routing.ParticipantInit,
+	tracks []localTrack,
+) (*localPublisher, error) {
+	me := &webrtc.MediaEngine{}
+	if err := me.RegisterDefaultCodecs(); err != nil {
+		return nil, err
+	}
+	ir := &interceptor.Registry{}
+	if err := webrtc.RegisterDefaultInterceptors(me, ir); err != nil {
+		return nil, err
+	}
+	pc, err := webrtc.NewAPI(webrtc.WithMediaEngine(me), webrtc.WithInterceptorRegistry(ir)).
+		NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []*livekit.SignalRequest
+	for _, t := range tracks {
+		sender, err := pc.AddTrack(t.track)
+		if err != nil {
+			_ = pc.Close()
+			return nil, err
+		}
+		go func() {
+			// RTCP is read for the interceptors only, key frames cannot be requested from local sources
+			buf := make([]byte, 1500)
+			for {
+				if _, _, err := sender.Read(buf); err != nil {
+					return
+				}
+			}
+		}()
+
+		trackType := livekit.TrackType_AUDIO
+		if t.track.Kind() == webrtc.RTPCodecTypeVideo {
+			trackType = livekit.TrackType_VIDEO
+		}
+		requests = append(requests, &livekit.SignalRequest{
+			Message: &livekit.SignalRequest_AddTrack{
+				AddTrack: &livekit.AddTrackRequest{
+					Cid:    t.track.ID(),
+					Name:   t.track.Kind().String(),
+					Type:   trackType,
+					Source: t.source,
+				},
+			},
+		})
+	}
+
+	offer, err := pc.CreateOffer(nil)
+	if err == nil {
+		gathered := webrtc.GatheringCompletePromise(pc)
+		if err = pc.SetLocalDescription(offer); err == nil {
+			select {
+			case <-gathered:
+			case <-time.After(localICEGatheringTimeout):
+			}
+		}
+	}
+	if err != nil {
+		_ = pc.Close()
+		return nil, err
+	}
+	requests = append(requests, &livekit.SignalRequest{
+		Message: &livekit.SignalRequest_Offer{
+			Offer: rtc.ToProtoSessionDescription(*pc.LocalDescription()),
+		},
+	})
+
+	session, answer, err := sessions.start(ctx, rtcService, roomName, pi, requests, livekit.SignalTarget_PUBLISHER)
+	if err != nil {
+		_ = pc.Close()
+		return nil, err
+	}
+	if err = pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: answer}); err != nil {
+		_ = pc.Close()
+		session.close()
+		return nil, err
+	}
+	return &localPublisher{pc: pc, session: session}, nil
+}
+
+// localParticipantInit is the participant of a local publisher, it publishes only
+func localParticipantInit(roomName livekit.RoomName, identity livekit.ParticipantIdentity, name livekit.ParticipantName, hidden bool) routing.ParticipantInit {
+	grants := &auth.ClaimGrants{
+		Identity: string(identity),
+		Name:     string(name),
+		Video:    &auth.VideoGrant{RoomJoin: true, Room: string(roomName), Hidden: hidden},
+	}
+	grants.Video.SetCanPublish(true)
+	grants.Video.SetCanSubscribe(false)
+	grants.Video.SetCanPublishData(false)
+	return routing.ParticipantInit{
+		Identity: identity,
+		Name:     name,
+		Client:   &livekit.ClientInfo{},
+		Grants:   grants,
+	}
+}
+
+// subscribeToLocalPublisher subscribes a participant to the first track of a local publisher. Tracks are published on
+// the server once media flows, it gives up when that does not happen in time or either participant leaves
+func subscribeToLocalPublisher(
+	roomManager *RoomManager,
+	publisher *localPublisher,
+	roomName livekit.RoomName,
+	publisherIdentity livekit.ParticipantIdentity,
+	identity livekit.ParticipantIdentity,
+) bool {
+	deadline := time.Now().Add(localSubscribeTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-publisher.Done():
+			return false
+		case <-time.After(localSubscribeInterval):
+		}
+		room := roomManager.GetRoom(context.Background(), roomName)
+		if room == nil {
+			return false
+		}
+		localParticipant, participant := room.GetParticipant(publisherIdentity), room.GetParticipant(identity)
+		if localParticipant == nil || participant == nil {
+			continue
+		}
+		if tracks := localParticipant.GetPublishedTracks(); len(tracks) != 0 {
+			participant.SubscribeToTrack(tracks[0].ID())
+			return true
+		}
+	}
+	return false
+}
+
+// Done is closed when the participant has left the room
+func (p *localPublisher) Done() <-chan struct{} {
+	return p.session.done
+}
+
+func (p *localPublisher) Close() {
+	_ = p.pc.Close()
+	p.session.close()
+}
@@ -22,6 +22,8 @@ import (
 	"github.com/thoas/go-funk"
 
 	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
 )
 
 // encapsulates CRUD operations for room settings
@@ -29,8 +31,20 @@ type LocalStore struct {
 	// map of roomName => room
 	rooms        map[livekit.RoomName]*livekit.Room
 	roomInternal map[livekit.RoomName]*livekit.RoomInternal
+	// rooms closed to new participants
+	lockedRooms map[livekit.RoomName]bool
 	// map of roomName => { identity: participant }
 	participants map[livekit.RoomName]map[livekit.ParticipantIdentity]*livekit.ParticipantInfo
+	// map of roomName => schedule
+	schedules map[livekit.RoomName]RoomSchedule
+	// map of roomName => { identity: ban }
+	bans map[livekit.RoomName]map[livekit.ParticipantIdentity]ParticipantBan
+	// map of token ID => revocation
+	revokedTokens map[string]TokenRevocation
+	// map of roomName => { identity: rollover }
+	tokenRollovers map[livekit.RoomName]map[livekit.ParticipantIdentity]TokenRollover
+	// map of roomName => journal
+	journals map[livekit.RoomName]*localRoomJournal
 
 	lock       sync.RWMutex
 	globalLock sync.Mutex
@@ -38,10 +52,16 @@ type LocalStore struct {
 
 func NewLocalStore() *LocalStore {
 	return &LocalStore{
-		rooms:        make(map[livekit.RoomName]*livekit.Room),
-		roomInternal: make(map[livekit.RoomName]*livekit.RoomInternal),
-		participants: make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
-		lock:         sync.RWMutex{},
+		rooms:          make(map[livekit.RoomName]*livekit.Room),
+		roomInternal:   make(map[livekit.RoomName]*livekit.RoomInternal),
+		lockedRooms:    make(map[livekit.RoomName]bool),
+		participants:   make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
+		schedules:      make(map[livekit.RoomName]RoomSchedule),
+		bans:           make(map[livekit.RoomName]map[livekit.ParticipantIdentity]ParticipantBan),
+		revokedTokens:  make(map[string]TokenRevocation),
+		tokenRollovers: make(map[livekit.RoomName]map[livekit.ParticipantIdentity]TokenRollover),
+		journals:       make(map[livekit.RoomName]*localRoomJournal),
+		lock:           sync.RWMutex{},
 	}
 }
 
@@ -102,6 +122,27 @@ func (s *LocalStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName)
 	delete(s.participants, livekit.RoomName(room.Name))
 	delete(s.rooms, livekit.RoomName(room.Name))
 	delete(s.roomInternal, livekit.RoomName(room.Name))
+	delete(s.lockedRooms, livekit.RoomName(room.Name))
+	return nil
+}
+
+func (s *LocalStore) StoreRoomLocked(_ context.Context, roomName livekit.RoomName) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.lockedRooms[roomName] = true
+	return nil
+}
+
+func (s *LocalStore) IsRoomLocked(_ context.Context, roomName livekit.RoomName) (bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.lockedRooms[roomName], nil
+}
+
+func (s *LocalStore) DeleteRoomLocked(_ context.Context, roomName livekit.RoomName) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.lockedRooms, roomName)
 	return nil
 }
 
@@ -171,3 +212,181 @@ func (s *LocalStore) DeleteParticipant(_ context.Context, roomName livekit.RoomN
 	}
 	return nil
 }
+
+func (s *LocalStore) StoreRoomSchedule(_ context.Context, schedule *RoomSchedule) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.schedules[livekit.RoomName(schedule.Room)] = *schedule
+	return nil
+}
+
+func (s *LocalStore) LoadRoomSchedule(_ context.Context, roomName livekit.RoomName) (*RoomSchedule, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	schedule, ok := s.schedules[roomName]
+	if !ok {
+		return nil, ErrRoomScheduleNotFound
+	}
+	return &schedule, nil
+}
+
+func (s *LocalStore) ListRoomSchedules(_ context.Context) ([]*RoomSchedule, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	schedules := make([]*RoomSchedule, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		schedule := schedule
+		schedules = append(schedules, &schedule)
+	}
+	return schedules, nil
+}
+
+func (s *LocalStore) DeleteRoomSchedule(_ context.Context, roomName livekit.RoomName) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.schedules, roomName)
+	return nil
+}
+
+func (s *LocalStore) StoreParticipantBan(_ context.Context, ban *ParticipantBan) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	roomBans := s.bans[livekit.RoomName(ban.Room)]
+	if roomBans == nil {
+		roomBans = make(map[livekit.ParticipantIdentity]ParticipantBan)
+		s.bans[livekit.RoomName(ban.Room)] = roomBans
+	}
+	roomBans[livekit.ParticipantIdentity(ban.Identity)] = *ban
+	return nil
+}
+
+func (s *LocalStore) ListParticipantBans(_ context.Context, roomName livekit.RoomName) ([]*ParticipantBan, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	roomBans := s.bans[roomName]
+	bans := make([]*ParticipantBan, 0, len(roomBans))
+	for identity, ban := range roomBans {
+		if ban.Expired(now) {
+			delete(roomBans, identity)
+			continue
+		}
+		ban := ban
+		bans = append(bans, &ban)
+	}
+	if len(roomBans) == 0 {
+		delete(s.bans, roomName)
+	}
+	return bans, nil
+}
+
+func (s *LocalStore) DeleteParticipantBan(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if roomBans := s.bans[roomName]; roomBans != nil {
+		delete(roomBans, identity)
+	}
+	return nil
+}
+
+func (s *LocalStore) StoreTokenRevocation(_ context.Context, revocation *TokenRevocation) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	for tokenID, r := range s.revokedTokens {
+		if r.Expired(now) {
+			delete(s.revokedTokens, tokenID)
+		}
+	}
+	if !revocation.Expired(now) {
+		s.revokedTokens[revocation.TokenID] = *revocation
+	}
+	return nil
+}
+
+func (s *LocalStore) IsTokenRevoked(_ context.Context, tokenID string) (bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	r, ok := s.revokedTokens[tokenID]
+	return ok && !r.Expired(time.Now()), nil
+}
+
+func (s *LocalStore) DeleteTokenRevocation(_ context.Context, tokenID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.revokedTokens, tokenID)
+	return nil
+}
+
+func (s *LocalStore) StoreTokenRollover(_ context.Context, rollover *TokenRollover) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	roomName := livekit.RoomName(rollover.Room)
+	roomRollovers := s.tokenRollovers[roomName]
+	if roomRollovers == nil {
+		roomRollovers = make(map[livekit.ParticipantIdentity]TokenRollover)
+		s.tokenRollovers[roomName] = roomRollovers
+	}
+	roomRollovers[livekit.ParticipantIdentity(rollover.Identity)] = *rollover
+	return nil
+}
+
+func (s *LocalStore) LoadTokenRollover(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*TokenRollover, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	rollover, ok := s.tokenRollovers[roomName][identity]
+	if !ok || rollover.Expired(time.Now()) {
+		return nil, ErrTokenRolloverNotFound
+	}
+	return &rollover, nil
+}
+
+func (s *LocalStore) DeleteTokenRollover(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if roomRollovers := s.tokenRollovers[roomName]; roomRollovers != nil {
+		delete(roomRollovers, identity)
+		if len(roomRollovers) == 0 {
+			delete(s.tokenRollovers, roomName)
+		}
+	}
+	return nil
+}
+
+type localRoomJournal struct {
+	entries   []rtc.JournalEntry
+	updatedAt time.Time
+}
+
+func (s *LocalStore) AppendRoomJournal(_ context.Context, roomName livekit.RoomName, entry *rtc.JournalEntry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	for name, j := range s.journals {
+		if now.Sub(j.updatedAt) > roomJournalTTL {
+			delete(s.journals, name)
+		}
+	}
+	j := s.journals[roomName]
+	if j == nil {
+		j = &localRoomJournal{}
+		s.journals[roomName] = j
+	}
+	j.entries = append(j.entries, *entry)
+	if len(j.entries) > roomJournalMaxEntries {
+		j.entries = j.entries[len(j.entries)-roomJournalMaxEntries:]
+	}
+	j.updatedAt = now
+	return nil
+}
+
+func (s *LocalStore) LoadRoomJournal(_ context.Context, roomName livekit.RoomName, after uint64, limit int) ([]rtc.JournalEntry, uint64, bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var entries []rtc.JournalEntry
+	if j := s.journals[roomName]; j != nil && time.Since(j.updatedAt) <= roomJournalTTL {
+		entries = j.entries
+	}
+	page, lastSeq, truncated := roomJournalPage(entries, after, limit)
+	return page, lastSeq, truncated, nil
+}
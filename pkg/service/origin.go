@@ -0,0 +1,105 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/twitchtv/twirp"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+// label for rejected origins, which are not bounded
+const originOther = "other"
+
+type originPattern struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// OriginValidator checks the Origin header of browser requests against the configured allowed origins.
+// Used as a middleware it rejects requests from other origins, so tokens leaked into third-party
+// pages can't be used from them.
+type OriginValidator struct {
+	patterns []originPattern
+}
+
+// NewOriginValidator returns nil when all origins are allowed, a nil validator allows every request
+func NewOriginValidator(conf config.CORSConfig) *OriginValidator {
+	if len(conf.AllowedOrigins) == 0 {
+		return nil
+	}
+
+	v := &OriginValidator{}
+	for _, origin := range conf.AllowedOrigins {
+		if origin == "*" {
+			return nil
+		}
+		origin = strings.ToLower(origin)
+		expr := strings.ReplaceAll(regexp.QuoteMeta(origin), `\*`, `[^/]*`)
+		v.patterns = append(v.patterns, originPattern{
+			pattern: origin,
+			re:      regexp.MustCompile("^" + expr + "$"),
+		})
+	}
+	return v
+}
+
+// Allowed returns whether the origin is allowed and the configured origin it matched.
+// Requests without an origin are not from browsers and always allowed.
+func (v *OriginValidator) Allowed(origin string) (bool, string) {
+	if v == nil || origin == "" {
+		return true, ""
+	}
+
+	origin = strings.ToLower(origin)
+	for _, p := range v.patterns {
+		if p.re.MatchString(origin) {
+			return true, p.pattern
+		}
+	}
+	return false, ""
+}
+
+// CheckOrigin validates the request origin, recording it in metrics
+func (v *OriginValidator) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if v == nil || origin == "" {
+		return true
+	}
+
+	allowed, pattern := v.Allowed(origin)
+	if !allowed {
+		pattern = originOther
+		logger.Infow("rejecting request from origin", "origin", origin, "path", r.URL.Path)
+	}
+	prometheus.IncrementOriginRequest(pattern, allowed)
+	return allowed
+}
+
+func (v *OriginValidator) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !v.CheckOrigin(r) {
+		_ = twirp.WriteError(w, twirp.NewError(twirp.PermissionDenied, "origin not allowed"))
+		return
+	}
+
+	next.ServeHTTP(w, r)
+}
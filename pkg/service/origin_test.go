@@ -0,0 +1,81 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestOriginValidator(t *testing.T) {
+	require.Nil(t, NewOriginValidator(config.CORSConfig{}))
+	require.Nil(t, NewOriginValidator(config.CORSConfig{AllowedOrigins: []string{"https://app.example.com", "*"}}))
+
+	v := NewOriginValidator(config.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org", "http://localhost:3000"},
+	})
+
+	t.Run("matching", func(t *testing.T) {
+		for origin, expected := range map[string]string{
+			"https://app.example.com": "https://app.example.com",
+			"HTTPS://App.Example.com": "https://app.example.com",
+			"https://a.example.org":   "https://*.example.org",
+			"https://a.b.example.org": "https://*.example.org",
+			"http://localhost:3000":   "http://localhost:3000",
+			"":                        "",
+		} {
+			allowed, pattern := v.Allowed(origin)
+			require.True(t, allowed, origin)
+			require.Equal(t, expected, pattern, origin)
+		}
+
+		for _, origin := range []string{
+			"http://app.example.com",
+			"https://app.example.com.evil.com",
+			"https://example.org",
+			"https://evil.com/.example.org",
+			"http://localhost:3001",
+			"null",
+		} {
+			allowed, _ := v.Allowed(origin)
+			require.False(t, allowed, origin)
+		}
+	})
+
+	t.Run("middleware", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		serve := func(origin string) int {
+			r := httptest.NewRequest(http.MethodPost, "/twirp/livekit.RoomService/ListRooms", nil)
+			if origin != "" {
+				r.Header.Set("Origin", origin)
+			}
+			w := httptest.NewRecorder()
+			v.ServeHTTP(w, r, handler)
+			return w.Code
+		}
+
+		require.Equal(t, http.StatusOK, serve("https://app.example.com"))
+		// server SDKs don't send an origin
+		require.Equal(t, http.StatusOK, serve(""))
+		require.Equal(t, http.StatusForbidden, serve("https://evil.com"))
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// ParticipantBan prevents an identity, and optionally the IP it connected from, from joining a room until it expires
+type ParticipantBan struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// the address is banned as well, IP is filled in by the node hosting the participant
+	BanIP bool   `json:"ban_ip,omitempty"`
+	IP    string `json:"ip,omitempty"`
+	// unix seconds
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+func (b *ParticipantBan) Expired(now time.Time) bool {
+	return now.Unix() >= b.ExpiresAt
+}
+
+// Matches returns true when the ban applies to a participant joining with the given identity from the given IP
+func (b *ParticipantBan) Matches(identity livekit.ParticipantIdentity, ip string) bool {
+	return b.Identity == string(identity) || (b.IP != "" && b.IP == ip)
+}
+
+// checkParticipantBanned returns ErrParticipantBanned when an active ban applies to the participant
+func checkParticipantBanned(ctx context.Context, store ServiceStore, roomName livekit.RoomName, identity livekit.ParticipantIdentity, ip string) error {
+	bans, err := store.ListParticipantBans(ctx, roomName)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, ban := range bans {
+		if !ban.Expired(now) && ban.Matches(identity, ip) {
+			return ErrParticipantBanned
+		}
+	}
+	return nil
+}
+
+// BanParticipant stores the ban and removes the participant from its room through the router, like RoomService
+// RemoveParticipant, so that it works from any node. when BanIP is set, the participant must be connected, the node
+// hosting it fills in the address it connected from when removing it
+func (r *RoomManager) BanParticipant(ctx context.Context, ban *ParticipantBan) error {
+	roomName := livekit.RoomName(ban.Room)
+	identity := livekit.ParticipantIdentity(ban.Identity)
+
+	_, err := r.roomStore.LoadParticipant(ctx, roomName, identity)
+	connected := err == nil
+	if err != nil && err != ErrParticipantNotFound {
+		return err
+	}
+	if ban.BanIP && !connected {
+		return ErrParticipantNotFound
+	}
+
+	if err := r.roomStore.StoreParticipantBan(ctx, ban); err != nil {
+		return err
+	}
+	if !connected {
+		return nil
+	}
+	return r.router.WriteParticipantRTC(ctx, roomName, identity, &livekit.RTCNodeMessage{
+		Message: &livekit.RTCNodeMessage_RemoveParticipant{
+			RemoveParticipant: &livekit.RoomParticipantIdentity{
+				Room:     ban.Room,
+				Identity: ban.Identity,
+			},
+		},
+	})
+}
+
+// resolveBanIP fills in the address of a participant that is being removed for an IP ban
+func (r *RoomManager) resolveBanIP(ctx context.Context, roomName livekit.RoomName, participant types.LocalParticipant) {
+	bans, err := r.roomStore.ListParticipantBans(ctx, roomName)
+	if err != nil {
+		logger.Warnw("could not load participant bans", err, "room", roomName)
+		return
+	}
+	for _, ban := range bans {
+		if !ban.BanIP || ban.IP != "" || ban.Identity != string(participant.Identity()) {
+			continue
+		}
+		ban.IP = participant.GetClientInfo().GetAddress()
+		if err := r.roomStore.StoreParticipantBan(ctx, ban); err != nil {
+			logger.Warnw("could not store participant ban", err, "room", roomName, "participant", ban.Identity)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestParticipantBans(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore()
+	now := time.Now()
+
+	require.NoError(t, store.StoreParticipantBan(ctx, &ParticipantBan{
+		Room:      "room",
+		Identity:  "banned",
+		IP:        "10.0.0.1",
+		ExpiresAt: now.Add(time.Hour).Unix(),
+	}))
+	require.NoError(t, store.StoreParticipantBan(ctx, &ParticipantBan{
+		Room:      "room",
+		Identity:  "expired",
+		ExpiresAt: now.Add(-time.Second).Unix(),
+	}))
+
+	bans, err := store.ListParticipantBans(ctx, "room")
+	require.NoError(t, err)
+	require.Len(t, bans, 1)
+	require.Equal(t, "banned", bans[0].Identity)
+
+	require.ErrorIs(t, checkParticipantBanned(ctx, store, "room", "banned", "10.0.0.2"), ErrParticipantBanned)
+	require.ErrorIs(t, checkParticipantBanned(ctx, store, "room", "other", "10.0.0.1"), ErrParticipantBanned)
+	require.NoError(t, checkParticipantBanned(ctx, store, "room", "expired", "10.0.0.2"))
+	require.NoError(t, checkParticipantBanned(ctx, store, "other-room", "banned", "10.0.0.1"))
+
+	require.NoError(t, store.DeleteParticipantBan(ctx, "room", "banned"))
+	require.NoError(t, checkParticipantBanned(ctx, store, "room", "banned", "10.0.0.1"))
+}
+
+func TestBanParticipant(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore()
+	router := &routingfakes.FakeRouter{}
+	r := &RoomManager{roomStore: store, router: router}
+	expiresAt := time.Now().Add(time.Hour).Unix()
+
+	// the address of participants that are not connected is not known
+	err := r.BanParticipant(ctx, &ParticipantBan{Room: "room", Identity: "absent", BanIP: true, ExpiresAt: expiresAt})
+	require.ErrorIs(t, err, ErrParticipantNotFound)
+
+	// participants that are not connected are banned without being removed
+	require.NoError(t, r.BanParticipant(ctx, &ParticipantBan{Room: "room", Identity: "absent", ExpiresAt: expiresAt}))
+	require.Equal(t, 0, router.WriteParticipantRTCCallCount())
+	require.ErrorIs(t, checkParticipantBanned(ctx, store, "room", "absent", ""), ErrParticipantBanned)
+
+	// connected participants are removed through the router, wherever they are hosted
+	require.NoError(t, store.StoreParticipant(ctx, "room", &livekit.ParticipantInfo{Identity: "banned"}))
+	require.NoError(t, r.BanParticipant(ctx, &ParticipantBan{Room: "room", Identity: "banned", BanIP: true, ExpiresAt: expiresAt}))
+	require.Equal(t, 1, router.WriteParticipantRTCCallCount())
+	_, roomName, identity, msg := router.WriteParticipantRTCArgsForCall(0)
+	require.EqualValues(t, "room", roomName)
+	require.EqualValues(t, "banned", identity)
+	require.NotNil(t, msg.GetRemoveParticipant())
+
+	// the hosting node fills in the address when removing the participant
+	participant := &typesfakes.FakeLocalParticipant{}
+	participant.IdentityReturns("banned")
+	participant.GetClientInfoReturns(&livekit.ClientInfo{Address: "10.0.0.1"})
+	r.resolveBanIP(ctx, "room", participant)
+	require.ErrorIs(t, checkParticipantBanned(ctx, store, "room", "other", "10.0.0.1"), ErrParticipantBanned)
+}
@@ -0,0 +1,197 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	participantListDefaultLimit = 100
+	participantListMaxLimit     = 1000
+
+	ParticipantSortIdentity = "identity"
+	ParticipantSortJoinedAt = "joined_at"
+	ParticipantSortName     = "name"
+)
+
+// ListParticipantsQuery filters, sorts and pages the participants of a room.
+// Filters are combined, empty filters match all participants.
+type ListParticipantsQuery struct {
+	Room string `json:"room"`
+
+	// participant states, e.g. ACTIVE
+	States         []string `json:"states,omitempty"`
+	IdentityPrefix string   `json:"identity_prefix,omitempty"`
+	// published track types, AUDIO, VIDEO or DATA, matches participants publishing any of them
+	Kinds      []string `json:"kinds,omitempty"`
+	Publishing *bool    `json:"publishing,omitempty"`
+
+	// identity (default), joined_at or name, ties are broken by identity
+	SortBy     string `json:"sort_by,omitempty"`
+	Descending bool   `json:"descending,omitempty"`
+
+	Limit int `json:"limit,omitempty"`
+	// next_cursor of the previous page
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type ListParticipantsPage struct {
+	Participants []*livekit.ParticipantInfo `json:"participants"`
+	// empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type participantCursor struct {
+	Identity string `json:"i"`
+	JoinedAt int64  `json:"j,omitempty"`
+	Name     string `json:"n,omitempty"`
+}
+
+// Apply returns the page of participants following the query cursor
+func (q *ListParticipantsQuery) Apply(participants []*livekit.ParticipantInfo) (*ListParticipantsPage, error) {
+	less, err := q.lessFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]*livekit.ParticipantInfo, 0, len(participants))
+	for _, p := range participants {
+		if q.matches(p) {
+			matched = append(matched, p)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return less(matched[i], matched[j])
+	})
+
+	if q.Cursor != "" {
+		after, err := decodeParticipantCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		start := sort.Search(len(matched), func(i int) bool {
+			return less(after, matched[i])
+		})
+		matched = matched[start:]
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = participantListDefaultLimit
+	} else if limit > participantListMaxLimit {
+		limit = participantListMaxLimit
+	}
+
+	page := &ListParticipantsPage{Participants: matched}
+	if len(matched) > limit {
+		page.Participants = matched[:limit]
+		page.NextCursor = encodeParticipantCursor(matched[limit-1])
+	}
+	return page, nil
+}
+
+func (q *ListParticipantsQuery) matches(p *livekit.ParticipantInfo) bool {
+	if len(q.States) != 0 && !containsFold(q.States, p.State.String()) {
+		return false
+	}
+	if q.IdentityPrefix != "" && !strings.HasPrefix(p.Identity, q.IdentityPrefix) {
+		return false
+	}
+	if q.Publishing != nil && *q.Publishing != (len(p.Tracks) != 0) {
+		return false
+	}
+	if len(q.Kinds) != 0 {
+		for _, t := range p.Tracks {
+			if containsFold(q.Kinds, t.Type.String()) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (q *ListParticipantsQuery) lessFunc() (func(a, b *livekit.ParticipantInfo) bool, error) {
+	var compare func(a, b *livekit.ParticipantInfo) int
+	switch q.SortBy {
+	case "", ParticipantSortIdentity:
+		compare = func(a, b *livekit.ParticipantInfo) int { return 0 }
+	case ParticipantSortJoinedAt:
+		compare = func(a, b *livekit.ParticipantInfo) int {
+			switch {
+			case a.JoinedAt < b.JoinedAt:
+				return -1
+			case a.JoinedAt > b.JoinedAt:
+				return 1
+			}
+			return 0
+		}
+	case ParticipantSortName:
+		compare = func(a, b *livekit.ParticipantInfo) int { return strings.Compare(a.Name, b.Name) }
+	default:
+		return nil, ErrInvalidSort
+	}
+
+	return func(a, b *livekit.ParticipantInfo) bool {
+		c := compare(a, b)
+		if c == 0 {
+			c = strings.Compare(a.Identity, b.Identity)
+		}
+		if q.Descending {
+			return c > 0
+		}
+		return c < 0
+	}, nil
+}
+
+func encodeParticipantCursor(p *livekit.ParticipantInfo) string {
+	data, _ := json.Marshal(&participantCursor{
+		Identity: p.Identity,
+		JoinedAt: p.JoinedAt,
+		Name:     p.Name,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeParticipantCursor(cursor string) (*livekit.ParticipantInfo, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c participantCursor
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &livekit.ParticipantInfo{
+		Identity: c.Identity,
+		JoinedAt: c.JoinedAt,
+		Name:     c.Name,
+	}, nil
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
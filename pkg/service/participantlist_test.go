@@ -0,0 +1,76 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestListParticipantsQuery(t *testing.T) {
+	participants := []*livekit.ParticipantInfo{
+		{Identity: "viewer-2", JoinedAt: 30, State: livekit.ParticipantInfo_ACTIVE},
+		{Identity: "host", JoinedAt: 10, State: livekit.ParticipantInfo_ACTIVE, Tracks: []*livekit.TrackInfo{{Type: livekit.TrackType_VIDEO}}},
+		{Identity: "viewer-1", JoinedAt: 20, State: livekit.ParticipantInfo_JOINING},
+		{Identity: "speaker", JoinedAt: 40, State: livekit.ParticipantInfo_ACTIVE, Tracks: []*livekit.TrackInfo{{Type: livekit.TrackType_AUDIO}}},
+	}
+	identities := func(page *ListParticipantsPage) []string {
+		var ids []string
+		for _, p := range page.Participants {
+			ids = append(ids, p.Identity)
+		}
+		return ids
+	}
+
+	t.Run("filters", func(t *testing.T) {
+		page, err := (&ListParticipantsQuery{IdentityPrefix: "viewer", States: []string{"active"}}).Apply(participants)
+		require.NoError(t, err)
+		require.Equal(t, []string{"viewer-2"}, identities(page))
+
+		publishing := true
+		page, err = (&ListParticipantsQuery{Publishing: &publishing}).Apply(participants)
+		require.NoError(t, err)
+		require.Equal(t, []string{"host", "speaker"}, identities(page))
+
+		page, err = (&ListParticipantsQuery{Kinds: []string{"AUDIO"}}).Apply(participants)
+		require.NoError(t, err)
+		require.Equal(t, []string{"speaker"}, identities(page))
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		q := &ListParticipantsQuery{SortBy: ParticipantSortJoinedAt, Descending: true, Limit: 3}
+		page, err := q.Apply(participants)
+		require.NoError(t, err)
+		require.Equal(t, []string{"speaker", "viewer-2", "viewer-1"}, identities(page))
+		require.NotEmpty(t, page.NextCursor)
+
+		q.Cursor = page.NextCursor
+		page, err = q.Apply(participants)
+		require.NoError(t, err)
+		require.Equal(t, []string{"host"}, identities(page))
+		require.Empty(t, page.NextCursor)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := (&ListParticipantsQuery{SortBy: "sid"}).Apply(participants)
+		require.ErrorIs(t, err, ErrInvalidSort)
+
+		_, err = (&ListParticipantsQuery{Cursor: "%"}).Apply(participants)
+		require.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+// qualityNotifier turns the connection quality updates of a room into webhook events.
+// A participant is degraded at or below the quality threshold, events are sent when that changes.
+// Changes within MinInterval of the previous event of a participant are held back until the interval passes.
+type qualityNotifier struct {
+	conf      config.QualityWebHookConfig
+	threshold livekit.ConnectionQuality
+	room      *rtc.Room
+	telemetry telemetry.TelemetryService
+
+	lock                sync.Mutex
+	degraded            map[livekit.ParticipantIdentity]bool
+	notifiedAt          map[livekit.ParticipantIdentity]time.Time
+	roomDegraded        bool
+	roomNotifiedAt      time.Time
+	clock               func() time.Time
+	participantNotifier func(identity livekit.ParticipantIdentity)
+	roomNotifier        func()
+}
+
+func validateQualityWebHookConfig(conf config.QualityWebHookConfig) error {
+	_, err := parseQualityThreshold(conf.Threshold)
+	return err
+}
+
+func parseQualityThreshold(threshold string) (livekit.ConnectionQuality, error) {
+	switch strings.ToLower(threshold) {
+	case "", "poor":
+		return livekit.ConnectionQuality_POOR, nil
+	case "good":
+		return livekit.ConnectionQuality_GOOD, nil
+	default:
+		return 0, fmt.Errorf("invalid webhook quality threshold %q", threshold)
+	}
+}
+
+// newQualityNotifier returns nil when quality events are disabled
+func newQualityNotifier(conf config.QualityWebHookConfig, room *rtc.Room, ts telemetry.TelemetryService) *qualityNotifier {
+	if !conf.Enabled {
+		return nil
+	}
+	threshold, err := parseQualityThreshold(conf.Threshold)
+	if err != nil {
+		return nil
+	}
+
+	q := &qualityNotifier{
+		conf:       conf,
+		threshold:  threshold,
+		room:       room,
+		telemetry:  ts,
+		degraded:   make(map[livekit.ParticipantIdentity]bool),
+		notifiedAt: make(map[livekit.ParticipantIdentity]time.Time),
+		clock:      time.Now,
+	}
+	q.participantNotifier = q.notifyParticipant
+	q.roomNotifier = q.notifyRoom
+	return q
+}
+
+func (q *qualityNotifier) Update(qualities map[livekit.ParticipantIdentity]livekit.ConnectionQuality) {
+	now := q.clock()
+
+	q.lock.Lock()
+	var changed []livekit.ParticipantIdentity
+	numDegraded := 0
+	for identity, quality := range qualities {
+		degraded := quality <= q.threshold
+		if degraded {
+			numDegraded++
+		}
+		if degraded == q.degraded[identity] {
+			continue
+		}
+		if now.Sub(q.notifiedAt[identity]) < q.conf.MinInterval {
+			continue
+		}
+		q.degraded[identity] = degraded
+		q.notifiedAt[identity] = now
+		changed = append(changed, identity)
+	}
+	for identity := range q.degraded {
+		if _, ok := qualities[identity]; !ok {
+			delete(q.degraded, identity)
+			delete(q.notifiedAt, identity)
+		}
+	}
+
+	notifyRoom := false
+	roomDegraded := len(qualities) != 0 && float64(numDegraded)/float64(len(qualities)) >= q.conf.RoomDegradedRatio
+	if roomDegraded && !q.roomDegraded && now.Sub(q.roomNotifiedAt) >= q.conf.MinInterval {
+		q.roomNotifiedAt = now
+		notifyRoom = true
+	}
+	if !roomDegraded || notifyRoom {
+		q.roomDegraded = roomDegraded
+	}
+	q.lock.Unlock()
+
+	for _, identity := range changed {
+		q.participantNotifier(identity)
+	}
+	if notifyRoom {
+		q.roomNotifier()
+	}
+}
+
+func (q *qualityNotifier) notifyParticipant(identity livekit.ParticipantIdentity) {
+	p := q.room.GetParticipant(identity)
+	if p == nil {
+		return
+	}
+	q.telemetry.ParticipantQualityChanged(context.Background(), q.room.ToProto(), p.ToProto())
+}
+
+func (q *qualityNotifier) notifyRoom() {
+	q.telemetry.RoomQualityDegraded(context.Background(), q.room.ToProto())
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestQualityNotifier(t *testing.T) {
+	q := newQualityNotifier(config.QualityWebHookConfig{
+		Enabled:           true,
+		Threshold:         "poor",
+		RoomDegradedRatio: 0.5,
+		MinInterval:       time.Minute,
+	}, nil, nil)
+	require.NotNil(t, q)
+
+	now := time.Now()
+	q.clock = func() time.Time { return now }
+	var changed []livekit.ParticipantIdentity
+	roomEvents := 0
+	q.participantNotifier = func(identity livekit.ParticipantIdentity) {
+		changed = append(changed, identity)
+	}
+	q.roomNotifier = func() {
+		roomEvents++
+	}
+
+	q.Update(map[livekit.ParticipantIdentity]livekit.ConnectionQuality{
+		"a": livekit.ConnectionQuality_EXCELLENT,
+		"b": livekit.ConnectionQuality_GOOD,
+	})
+	require.Empty(t, changed)
+	require.Zero(t, roomEvents)
+
+	q.Update(map[livekit.ParticipantIdentity]livekit.ConnectionQuality{
+		"a": livekit.ConnectionQuality_POOR,
+		"b": livekit.ConnectionQuality_GOOD,
+	})
+	require.Equal(t, []livekit.ParticipantIdentity{"a"}, changed)
+	require.Equal(t, 1, roomEvents)
+
+	// recovery within the interval is held back
+	changed = nil
+	now = now.Add(10 * time.Second)
+	q.Update(map[livekit.ParticipantIdentity]livekit.ConnectionQuality{
+		"a": livekit.ConnectionQuality_GOOD,
+		"b": livekit.ConnectionQuality_GOOD,
+	})
+	require.Empty(t, changed)
+
+	now = now.Add(time.Minute)
+	q.Update(map[livekit.ParticipantIdentity]livekit.ConnectionQuality{
+		"a": livekit.ConnectionQuality_GOOD,
+		"b": livekit.ConnectionQuality_GOOD,
+	})
+	require.Equal(t, []livekit.ParticipantIdentity{"a"}, changed)
+	require.Equal(t, 1, roomEvents)
+
+	require.Nil(t, newQualityNotifier(config.QualityWebHookConfig{}, nil, nil))
+	require.Error(t, validateQualityWebHookConfig(config.QualityWebHookConfig{Threshold: "excellent"}))
+}
@@ -0,0 +1,213 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twitchtv/twirp"
+	"github.com/urfave/negroni/v3"
+	"golang.org/x/time/rate"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+const (
+	rateLimitAPIKey = "api_key"
+	rateLimitIP     = "ip"
+
+	rateLimiterIdleTimeout = 10 * time.Minute
+)
+
+type rateLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type rateLimiterSet struct {
+	conf     config.RateLimitConfig
+	limiters map[string]*rateLimiter
+}
+
+func newRateLimiterSet(conf config.RateLimitConfig) *rateLimiterSet {
+	if conf.Rate <= 0 {
+		return nil
+	}
+	if conf.Burst <= 0 {
+		conf.Burst = int(math.Ceil(conf.Rate))
+	}
+	return &rateLimiterSet{
+		conf:     conf,
+		limiters: make(map[string]*rateLimiter),
+	}
+}
+
+// reserve takes a token for key, returning how long to wait before retrying when there is none.
+// the reservation can be canceled to give the token back
+func (s *rateLimiterSet) reserve(key string, now time.Time) (*rate.Reservation, time.Duration) {
+	l := s.limiters[key]
+	if l == nil {
+		l = &rateLimiter{limiter: rate.NewLimiter(rate.Limit(s.conf.Rate), s.conf.Burst)}
+		s.limiters[key] = l
+	}
+	l.lastSeen = now
+
+	r := l.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return nil, delay
+	}
+	return r, 0
+}
+
+func (s *rateLimiterSet) prune(now time.Time) {
+	for key, l := range s.limiters {
+		if now.Sub(l.lastSeen) > rateLimiterIdleTimeout {
+			delete(s.limiters, key)
+		}
+	}
+}
+
+type ipReservationKey struct{}
+
+// RateLimitMiddleware limits requests to the Twirp and admin APIs per client IP and per API key.
+// It runs before APIKeyAuthMiddleware so that requests with invalid tokens are limited by IP as well,
+// the per API key limit is applied by APIKeyLimiter after authentication.
+type RateLimitMiddleware struct {
+	trustForwardedFor bool
+
+	lock      sync.Mutex
+	perAPIKey *rateLimiterSet
+	perIP     *rateLimiterSet
+	prunedAt  time.Time
+	clock     func() time.Time
+}
+
+// NewRateLimitMiddleware returns nil when no limits are configured
+func NewRateLimitMiddleware(conf config.APIRateLimitConfig) *RateLimitMiddleware {
+	m := &RateLimitMiddleware{
+		trustForwardedFor: conf.TrustForwardedFor,
+		perAPIKey:         newRateLimiterSet(conf.PerAPIKey),
+		perIP:             newRateLimiterSet(conf.PerIP),
+		clock:             time.Now,
+	}
+	if m.perAPIKey == nil && m.perIP == nil {
+		return nil
+	}
+	return m
+}
+
+// ServeHTTP applies the per IP limit
+func (m *RateLimitMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if m.perIP == nil || !isRateLimitedPath(r) {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	ip := m.clientIP(r)
+	if ip == "" {
+		next.ServeHTTP(w, r)
+		return
+	}
+	reservation, delay := m.reserve(m.perIP, ip)
+	if reservation == nil {
+		writeRateLimited(w, rateLimitIP, delay)
+		return
+	}
+	next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ipReservationKey{}, reservation)))
+}
+
+// APIKeyLimiter applies the per API key limit, it runs after APIKeyAuthMiddleware. Requests it rejects
+// are not charged to the client IP.
+func (m *RateLimitMiddleware) APIKeyLimiter() negroni.Handler {
+	return negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		apiKey := GetAPIKey(r.Context())
+		if m.perAPIKey == nil || apiKey == "" || !isRateLimitedPath(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if reservation, delay := m.reserve(m.perAPIKey, apiKey); reservation == nil {
+			if ipReservation, ok := r.Context().Value(ipReservationKey{}).(*rate.Reservation); ok {
+				m.cancel(ipReservation)
+			}
+			writeRateLimited(w, rateLimitAPIKey, delay)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isRateLimitedPath(r *http.Request) bool {
+	return r.URL != nil && (strings.HasPrefix(r.URL.Path, "/twirp/") || strings.HasPrefix(r.URL.Path, adminPathPrefix))
+}
+
+func writeRateLimited(w http.ResponseWriter, limit string, delay time.Duration) {
+	prometheus.IncrementAPIRateLimited(limit)
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+	_ = twirp.WriteError(w, twirp.NewError(twirp.ResourceExhausted, fmt.Sprintf("%s rate limit exceeded", limit)))
+}
+
+// reserve takes a token for key from limiters, returning nil and the delay before retrying when there is none
+func (m *RateLimitMiddleware) reserve(limiters *rateLimiterSet, key string) (*rate.Reservation, time.Duration) {
+	now := m.clock()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if now.Sub(m.prunedAt) > rateLimiterIdleTimeout {
+		m.prunedAt = now
+		if m.perAPIKey != nil {
+			m.perAPIKey.prune(now)
+		}
+		if m.perIP != nil {
+			m.perIP.prune(now)
+		}
+	}
+	return limiters.reserve(key, now)
+}
+
+// cancel gives the token of a reservation back
+func (m *RateLimitMiddleware) cancel(reservation *rate.Reservation) {
+	now := m.clock()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	reservation.CancelAt(now)
+}
+
+func (m *RateLimitMiddleware) clientIP(r *http.Request) string {
+	if m.trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			ip, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(ip)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
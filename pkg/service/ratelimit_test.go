@@ -0,0 +1,97 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	require.Nil(t, NewRateLimitMiddleware(config.APIRateLimitConfig{}))
+
+	newMiddleware := func() (*RateLimitMiddleware, *time.Time) {
+		m := NewRateLimitMiddleware(config.APIRateLimitConfig{
+			PerAPIKey: config.RateLimitConfig{Rate: 1, Burst: 2},
+			PerIP:     config.RateLimitConfig{Rate: 1, Burst: 3},
+		})
+		now := time.Now()
+		m.clock = func() time.Time { return now }
+		return m, &now
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	// serve runs the request through the per IP limit, authentication and the per API key limit
+	serve := func(m *RateLimitMiddleware, path string, ip string, apiKey string) int {
+		r := httptest.NewRequest(http.MethodPost, path, nil)
+		r.RemoteAddr = ip + ":5000"
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+			if apiKey == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), apiKeyKey{}, apiKey))
+			m.APIKeyLimiter().ServeHTTP(w, r, ok)
+		})
+		return w.Code
+	}
+	const path = "/twirp/livekit.RoomService/ListRooms"
+
+	t.Run("per api key", func(t *testing.T) {
+		m, now := newMiddleware()
+		require.Equal(t, http.StatusOK, serve(m, path, "10.0.0.1", "key1"))
+		require.Equal(t, http.StatusOK, serve(m, path, "10.0.0.2", "key1"))
+		require.Equal(t, http.StatusTooManyRequests, serve(m, path, "10.0.0.3", "key1"))
+
+		// other keys have their own buckets
+		require.Equal(t, http.StatusOK, serve(m, path, "10.0.0.3", "key2"))
+
+		*now = now.Add(time.Second)
+		require.Equal(t, http.StatusOK, serve(m, path, "10.0.0.1", "key1"))
+	})
+
+	t.Run("per ip", func(t *testing.T) {
+		m, _ := newMiddleware()
+		// requests failing authentication are limited too
+		for i := 0; i < 3; i++ {
+			require.Equal(t, http.StatusUnauthorized, serve(m, path, "10.0.0.1", ""))
+		}
+		require.Equal(t, http.StatusTooManyRequests, serve(m, path, "10.0.0.1", ""))
+		require.Equal(t, http.StatusTooManyRequests, serve(m, path, "10.0.0.1", "key1"))
+		// signal connections are not limited
+		require.Equal(t, http.StatusUnauthorized, serve(m, "/rtc/validate", "10.0.0.1", ""))
+	})
+
+	t.Run("per api key rejects are not charged to the ip", func(t *testing.T) {
+		m, _ := newMiddleware()
+		require.Equal(t, http.StatusOK, serve(m, path, "10.0.0.1", "key1"))
+		require.Equal(t, http.StatusOK, serve(m, path, "10.0.0.1", "key1"))
+		for i := 0; i < 5; i++ {
+			require.Equal(t, http.StatusTooManyRequests, serve(m, path, "10.0.0.1", "key1"))
+		}
+		// the ip has one token left
+		require.Equal(t, http.StatusOK, serve(m, path, "10.0.0.1", "key2"))
+		require.Equal(t, http.StatusTooManyRequests, serve(m, path, "10.0.0.1", "key3"))
+	})
+}
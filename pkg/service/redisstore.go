@@ -16,6 +16,7 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -26,6 +27,7 @@ import (
 	"github.com/redis/go-redis/v9"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/version"
 	"github.com/livekit/protocol/ingress"
 	"github.com/livekit/protocol/livekit"
@@ -54,6 +56,24 @@ const (
 	// RoomParticipantsPrefix is hash of participant_name => ParticipantInfo
 	RoomParticipantsPrefix = "room_participants:"
 
+	// RoomSchedulesKey is a hash of room_name => RoomSchedule json
+	RoomSchedulesKey = "room_schedules"
+
+	// RoomBansPrefix is a hash of participant_identity => ParticipantBan json
+	RoomBansPrefix = "room_bans:"
+
+	// RevokedTokenPrefix is a key per revoked token ID containing TokenRevocation json, expiring with the token
+	RevokedTokenPrefix = "revoked_token:"
+
+	// TokenRolloverPrefix is a key per room and participant containing TokenRollover json, expiring with the rollover
+	TokenRolloverPrefix = "token_rollover:"
+
+	// LockedRoomsKey is a hash of room_name => unix time of rooms closed to new participants
+	LockedRoomsKey = "locked_rooms"
+
+	// RoomJournalPrefix is a sorted set of JournalEntry json scored by sequence number, expiring after the last event
+	RoomJournalPrefix = "room_journal:"
+
 	// RoomLockPrefix is a simple key containing a provided lock uid
 	RoomLockPrefix = "room_lock:"
 
@@ -229,12 +249,25 @@ func (s *RedisStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName)
 	pp := s.rc.Pipeline()
 	pp.HDel(s.ctx, RoomsKey, string(roomName))
 	pp.HDel(s.ctx, RoomInternalKey, string(roomName))
+	pp.HDel(s.ctx, LockedRoomsKey, string(roomName))
 	pp.Del(s.ctx, RoomParticipantsPrefix+string(roomName))
 
 	_, err = pp.Exec(s.ctx)
 	return err
 }
 
+func (s *RedisStore) StoreRoomLocked(_ context.Context, roomName livekit.RoomName) error {
+	return s.rc.HSet(s.ctx, LockedRoomsKey, string(roomName), time.Now().Unix()).Err()
+}
+
+func (s *RedisStore) IsRoomLocked(_ context.Context, roomName livekit.RoomName) (bool, error) {
+	return s.rc.HExists(s.ctx, LockedRoomsKey, string(roomName)).Result()
+}
+
+func (s *RedisStore) DeleteRoomLocked(_ context.Context, roomName livekit.RoomName) error {
+	return s.rc.HDel(s.ctx, LockedRoomsKey, string(roomName)).Err()
+}
+
 func (s *RedisStore) LockRoom(_ context.Context, roomName livekit.RoomName, duration time.Duration) (string, error) {
 	token := utils.NewGuid("LOCK")
 	key := RoomLockPrefix + string(roomName)
@@ -812,3 +845,202 @@ func (s *RedisStore) DeleteIngress(_ context.Context, info *livekit.IngressInfo)
 
 	return nil
 }
+
+func (s *RedisStore) StoreRoomSchedule(_ context.Context, schedule *RoomSchedule) error {
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return err
+	}
+	return s.rc.HSet(s.ctx, RoomSchedulesKey, schedule.Room, data).Err()
+}
+
+func (s *RedisStore) LoadRoomSchedule(_ context.Context, roomName livekit.RoomName) (*RoomSchedule, error) {
+	data, err := s.rc.HGet(s.ctx, RoomSchedulesKey, string(roomName)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			err = ErrRoomScheduleNotFound
+		}
+		return nil, err
+	}
+
+	schedule := &RoomSchedule{}
+	if err = json.Unmarshal([]byte(data), schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *RedisStore) ListRoomSchedules(_ context.Context) ([]*RoomSchedule, error) {
+	items, err := s.rc.HVals(s.ctx, RoomSchedulesKey).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	schedules := make([]*RoomSchedule, 0, len(items))
+	for _, item := range items {
+		schedule := &RoomSchedule{}
+		if err = json.Unmarshal([]byte(item), schedule); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+func (s *RedisStore) DeleteRoomSchedule(_ context.Context, roomName livekit.RoomName) error {
+	return s.rc.HDel(s.ctx, RoomSchedulesKey, string(roomName)).Err()
+}
+
+func (s *RedisStore) StoreParticipantBan(_ context.Context, ban *ParticipantBan) error {
+	data, err := json.Marshal(ban)
+	if err != nil {
+		return err
+	}
+	return s.rc.HSet(s.ctx, RoomBansPrefix+ban.Room, ban.Identity, data).Err()
+}
+
+func (s *RedisStore) ListParticipantBans(_ context.Context, roomName livekit.RoomName) ([]*ParticipantBan, error) {
+	key := RoomBansPrefix + string(roomName)
+	items, err := s.rc.HGetAll(s.ctx, key).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var expired []string
+	bans := make([]*ParticipantBan, 0, len(items))
+	for identity, item := range items {
+		ban := &ParticipantBan{}
+		if err = json.Unmarshal([]byte(item), ban); err != nil {
+			return nil, err
+		}
+		if ban.Expired(now) {
+			expired = append(expired, identity)
+			continue
+		}
+		bans = append(bans, ban)
+	}
+	if len(expired) != 0 {
+		if err = s.rc.HDel(s.ctx, key, expired...).Err(); err != nil {
+			logger.Warnw("could not delete expired bans", err, "room", roomName)
+		}
+	}
+	return bans, nil
+}
+
+func (s *RedisStore) DeleteParticipantBan(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	return s.rc.HDel(s.ctx, RoomBansPrefix+string(roomName), string(identity)).Err()
+}
+
+func (s *RedisStore) StoreTokenRevocation(_ context.Context, revocation *TokenRevocation) error {
+	ttl := time.Until(time.Unix(revocation.ExpiresAt, 0))
+	if ttl <= 0 {
+		// the token has expired already
+		return nil
+	}
+	data, err := json.Marshal(revocation)
+	if err != nil {
+		return err
+	}
+	return s.rc.Set(s.ctx, RevokedTokenPrefix+revocation.TokenID, data, ttl).Err()
+}
+
+func (s *RedisStore) IsTokenRevoked(_ context.Context, tokenID string) (bool, error) {
+	n, err := s.rc.Exists(s.ctx, RevokedTokenPrefix+tokenID).Result()
+	if err != nil {
+		return false, err
+	}
+	return n != 0, nil
+}
+
+func (s *RedisStore) DeleteTokenRevocation(_ context.Context, tokenID string) error {
+	return s.rc.Del(s.ctx, RevokedTokenPrefix+tokenID).Err()
+}
+
+func tokenRolloverKey(roomName livekit.RoomName, identity livekit.ParticipantIdentity) string {
+	return TokenRolloverPrefix + string(roomName) + ":" + string(identity)
+}
+
+func (s *RedisStore) StoreTokenRollover(_ context.Context, rollover *TokenRollover) error {
+	ttl := time.Until(time.Unix(rollover.ExpiresAt, 0))
+	if ttl <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(rollover)
+	if err != nil {
+		return err
+	}
+	key := tokenRolloverKey(livekit.RoomName(rollover.Room), livekit.ParticipantIdentity(rollover.Identity))
+	return s.rc.Set(s.ctx, key, data, ttl).Err()
+}
+
+func (s *RedisStore) LoadTokenRollover(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*TokenRollover, error) {
+	data, err := s.rc.Get(s.ctx, tokenRolloverKey(roomName, identity)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			err = ErrTokenRolloverNotFound
+		}
+		return nil, err
+	}
+
+	rollover := &TokenRollover{}
+	if err = json.Unmarshal([]byte(data), rollover); err != nil {
+		return nil, err
+	}
+	return rollover, nil
+}
+
+func (s *RedisStore) DeleteTokenRollover(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	return s.rc.Del(s.ctx, tokenRolloverKey(roomName, identity)).Err()
+}
+
+func (s *RedisStore) AppendRoomJournal(_ context.Context, roomName livekit.RoomName, entry *rtc.JournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	key := RoomJournalPrefix + string(roomName)
+	_, err = s.rc.TxPipelined(s.ctx, func(p redis.Pipeliner) error {
+		p.ZAdd(s.ctx, key, redis.Z{Score: float64(entry.Seq), Member: data})
+		p.ZRemRangeByRank(s.ctx, key, 0, -roomJournalMaxEntries-1)
+		p.Expire(s.ctx, key, roomJournalTTL)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) LoadRoomJournal(_ context.Context, roomName livekit.RoomName, after uint64, limit int) ([]rtc.JournalEntry, uint64, bool, error) {
+	key := RoomJournalPrefix + string(roomName)
+	var first, last *redis.ZSliceCmd
+	var items *redis.StringSliceCmd
+	_, err := s.rc.Pipelined(s.ctx, func(p redis.Pipeliner) error {
+		first = p.ZRangeWithScores(s.ctx, key, 0, 0)
+		last = p.ZRangeWithScores(s.ctx, key, -1, -1)
+		items = p.ZRangeByScore(s.ctx, key, &redis.ZRangeBy{
+			Min:   "(" + strconv.FormatUint(after, 10),
+			Max:   "+inf",
+			Count: int64(limit),
+		})
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, 0, false, err
+	}
+	if len(last.Val()) == 0 {
+		return nil, 0, after > 0, nil
+	}
+
+	firstSeq, lastSeq := uint64(first.Val()[0].Score), uint64(last.Val()[0].Score)
+	if after > lastSeq {
+		return nil, lastSeq, true, nil
+	}
+	entries := make([]rtc.JournalEntry, 0, len(items.Val()))
+	for _, item := range items.Val() {
+		var entry rtc.JournalEntry
+		if err = json.Unmarshal([]byte(item), &entry); err != nil {
+			return nil, 0, false, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, lastSeq, after+1 < firstSeq, nil
+}
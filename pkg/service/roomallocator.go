@@ -69,6 +69,10 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 			TurnPassword: utils.RandomSecret(),
 		}
 		applyDefaultRoomConfig(rm, &r.config.Room)
+		if name, preset, ok := r.config.Room.PresetForRoom(req.Name); ok {
+			logger.Debugw("applying room preset", "room", req.Name, "preset", name)
+			internal = applyRoomPreset(rm, &preset)
+		}
 	} else if err != nil {
 		return nil, err
 	}
@@ -105,6 +109,11 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 
 	// if already assigned and still available, keep it on that node
 	if err == nil && selector.IsAvailable(existing) {
+		// participants pinned to a region must not join a room hosted outside of it
+		if region := GetRegion(ctx); region != "" && existing.Region != region {
+			return nil, ErrRegionMismatch
+		}
+
 		// if node hosting the room is full, deny entry
 		if selector.LimitsReached(r.config.Limit, existing.Stats) {
 			return nil, routing.ErrNodeLimitReached
@@ -121,6 +130,12 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 			return nil, err
 		}
 
+		if region := GetRegion(ctx); region != "" {
+			if nodes = nodesInRegion(nodes, region); len(nodes) == 0 {
+				return nil, ErrRegionUnavailable
+			}
+		}
+
 		node, err := r.selector.SelectNode(nodes)
 		if err != nil {
 			return nil, err
@@ -139,6 +154,17 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 }
 
 func (r *StandardRoomAllocator) ValidateCreateRoom(ctx context.Context, roomName livekit.RoomName) error {
+	// scheduled rooms can only be joined during their window
+	schedule, err := r.roomStore.LoadRoomSchedule(ctx, roomName)
+	if err != nil && err != ErrRoomScheduleNotFound {
+		return err
+	}
+	if schedule != nil {
+		if err = schedule.CheckOpen(time.Now()); err != nil {
+			return err
+		}
+	}
+
 	// when auto create is disabled, we'll check to ensure it's already created
 	if !r.config.Room.AutoCreate {
 		_, _, err := r.roomStore.LoadRoom(ctx, roomName, false)
@@ -164,3 +190,38 @@ func applyDefaultRoomConfig(room *livekit.Room, conf *config.RoomConfig) {
 		Max:     uint32(conf.PlayoutDelay.Max),
 	}
 }
+
+// applyRoomPreset overrides the defaults with the settings the preset sets, returning the room's track egress if any
+func applyRoomPreset(room *livekit.Room, preset *config.RoomPresetConfig) *livekit.RoomInternal {
+	if preset.EmptyTimeout > 0 {
+		room.EmptyTimeout = preset.EmptyTimeout
+	}
+	if preset.MaxParticipants > 0 {
+		room.MaxParticipants = preset.MaxParticipants
+	}
+	if len(preset.EnabledCodecs) > 0 {
+		room.EnabledCodecs = nil
+		for _, codec := range preset.EnabledCodecs {
+			room.EnabledCodecs = append(room.EnabledCodecs, &livekit.Codec{
+				Mime:     codec.Mime,
+				FmtpLine: codec.FmtpLine,
+			})
+		}
+	}
+	if preset.PlayoutDelay != nil {
+		room.PlayoutDelay = &livekit.PlayoutDelay{
+			Enabled: preset.PlayoutDelay.Enabled,
+			Min:     uint32(preset.PlayoutDelay.Min),
+			Max:     uint32(preset.PlayoutDelay.Max),
+		}
+	}
+	if preset.Egress.Tracks != nil {
+		return &livekit.RoomInternal{
+			TrackEgress: &livekit.AutoTrackEgress{
+				Filepath:        preset.Egress.Tracks.Filepath,
+				DisableManifest: preset.Egress.Tracks.DisableManifest,
+			},
+		}
+	}
+	return nil
+}
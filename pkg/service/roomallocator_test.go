@@ -17,6 +17,7 @@ package service_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -45,6 +46,38 @@ func TestCreateRoom(t *testing.T) {
 		require.NotEmpty(t, room.EnabledCodecs)
 	})
 
+	t.Run("apply room preset matching the room name", func(t *testing.T) {
+		conf, err := config.NewConfig(`
+room:
+  presets:
+    webinar:
+      room_prefixes: [webinar-]
+      max_participants: 500
+      empty_timeout: 60
+      enabled_codecs:
+        - mime: audio/opus
+        - mime: video/h264
+`, true, nil, nil)
+		require.NoError(t, err)
+
+		node, err := routing.NewLocalNode(conf)
+		require.NoError(t, err)
+
+		ra, conf := newTestRoomAllocator(t, conf, node)
+
+		room, err := ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "webinar-1", EmptyTimeout: 30})
+		require.NoError(t, err)
+		require.Equal(t, uint32(500), room.MaxParticipants)
+		require.Equal(t, uint32(30), room.EmptyTimeout)
+		require.Len(t, room.EnabledCodecs, 2)
+		require.Equal(t, "video/h264", room.EnabledCodecs[1].Mime)
+
+		room, err = ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "myroom"})
+		require.NoError(t, err)
+		require.Equal(t, conf.Room.MaxParticipants, room.MaxParticipants)
+		require.Equal(t, conf.Room.EmptyTimeout, room.EmptyTimeout)
+	})
+
 	t.Run("reject new participants when track limit has been reached", func(t *testing.T) {
 		conf, err := config.NewConfig("", true, nil, nil)
 		require.NoError(t, err)
@@ -76,6 +109,25 @@ func TestCreateRoom(t *testing.T) {
 		_, err = ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "low-limit-room"})
 		require.ErrorIs(t, err, routing.ErrNodeLimitReached)
 	})
+
+	t.Run("reject participants pinned to another region", func(t *testing.T) {
+		conf, err := config.NewConfig("", true, nil, nil)
+		require.NoError(t, err)
+		conf.Region = "us-east"
+
+		node, err := routing.NewLocalNode(conf)
+		require.NoError(t, err)
+
+		ra, _ := newTestRoomAllocator(t, conf, node)
+
+		ctx := service.WithRegion(context.Background(), "eu-central")
+		_, err = ra.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: "myroom"})
+		require.ErrorIs(t, err, service.ErrRegionMismatch)
+
+		ctx = service.WithRegion(context.Background(), "us-east")
+		_, err = ra.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: "myroom"})
+		require.NoError(t, err)
+	})
 }
 
 func newTestRoomAllocator(t *testing.T, conf *config.Config, node *livekit.Node) (service.RoomAllocator, *config.Config) {
@@ -89,3 +141,27 @@ func newTestRoomAllocator(t *testing.T, conf *config.Config, node *livekit.Node)
 	require.NoError(t, err)
 	return ra, conf
 }
+
+func TestValidateCreateRoom(t *testing.T) {
+	conf, err := config.NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+
+	store := &servicefakes.FakeObjectStore{}
+	ra, err := service.NewRoomAllocator(conf, &routingfakes.FakeRouter{}, store)
+	require.NoError(t, err)
+
+	require.NoError(t, ra.ValidateCreateRoom(context.Background(), "myroom"))
+
+	store.LoadRoomScheduleReturns(&service.RoomSchedule{
+		Room:      "myroom",
+		StartTime: time.Now().Add(time.Hour).Unix(),
+	}, nil)
+	require.ErrorIs(t, ra.ValidateCreateRoom(context.Background(), "myroom"), service.ErrRoomNotOpen)
+
+	store.LoadRoomScheduleReturns(&service.RoomSchedule{
+		Room:      "myroom",
+		StartTime: time.Now().Add(-time.Hour).Unix(),
+		EndTime:   time.Now().Add(-time.Minute).Unix(),
+	}, nil)
+	require.ErrorIs(t, ra.ValidateCreateRoom(context.Background(), "myroom"), service.ErrRoomClosed)
+}
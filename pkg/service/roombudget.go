@@ -0,0 +1,152 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+const (
+	cpuTotalMetric = "/cpu/classes/total:cpu-seconds"
+	cpuIdleMetric  = "/cpu/classes/idle:cpu-seconds"
+)
+
+// RoomBudgetMonitor periodically checks every room against its memory and CPU budget.
+// Memory is approximated from the packet buffers of a room's tracks. CPU used by the process
+// is attributed to rooms by their share of packets forwarded since the previous check.
+type RoomBudgetMonitor struct {
+	conf  config.RoomBudgetConfig
+	rooms func() []*rtc.Room
+
+	forwarded  map[livekit.RoomID]uint64
+	cpuSamples []metrics.Sample
+	cpuSeconds float64
+	checkedAt  time.Time
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewRoomBudgetMonitor returns nil when no budget is configured
+func NewRoomBudgetMonitor(conf config.RoomBudgetConfig, rooms func() []*rtc.Room) *RoomBudgetMonitor {
+	if !conf.Enabled() {
+		return nil
+	}
+
+	return &RoomBudgetMonitor{
+		conf:      conf,
+		rooms:     rooms,
+		forwarded: make(map[livekit.RoomID]uint64),
+		cpuSamples: []metrics.Sample{
+			{Name: cpuTotalMetric},
+			{Name: cpuIdleMetric},
+		},
+		done: make(chan struct{}),
+	}
+}
+
+func (m *RoomBudgetMonitor) Start() {
+	if m == nil {
+		return
+	}
+	go m.worker()
+}
+
+func (m *RoomBudgetMonitor) Stop() {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+}
+
+func (m *RoomBudgetMonitor) worker() {
+	// establish the baseline for CPU and forwarded packets
+	m.check()
+
+	ticker := time.NewTicker(m.conf.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+func (m *RoomBudgetMonitor) check() {
+	cpu := m.processCPU()
+
+	rooms := m.rooms()
+	usages := make([]rtc.RoomUsage, len(rooms))
+	deltas := make([]uint64, len(rooms))
+	forwarded := make(map[livekit.RoomID]uint64, len(rooms))
+	var totalDelta uint64
+	for i, room := range rooms {
+		usages[i] = room.Usage()
+		// counters restart when down tracks are replaced
+		if prev := m.forwarded[room.ID()]; usages[i].ForwardedPackets > prev {
+			deltas[i] = usages[i].ForwardedPackets - prev
+		}
+		totalDelta += deltas[i]
+		forwarded[room.ID()] = usages[i].ForwardedPackets
+	}
+	m.forwarded = forwarded
+
+	for i, room := range rooms {
+		if totalDelta != 0 {
+			usages[i].CPU = cpu * float64(deltas[i]) / float64(totalDelta)
+		}
+		room.UpdateBudget(m.conf, usages[i])
+	}
+}
+
+// processCPU returns the number of cores used by the process since the previous call
+func (m *RoomBudgetMonitor) processCPU() float64 {
+	metrics.Read(m.cpuSamples)
+	var cpuSeconds float64
+	for i, s := range m.cpuSamples {
+		if s.Value.Kind() != metrics.KindFloat64 {
+			return 0
+		}
+		if i == 0 {
+			cpuSeconds += s.Value.Float64()
+		} else {
+			cpuSeconds -= s.Value.Float64()
+		}
+	}
+
+	now := time.Now()
+	var cores float64
+	if !m.checkedAt.IsZero() {
+		if elapsed := now.Sub(m.checkedAt).Seconds(); elapsed > 0 && cpuSeconds > m.cpuSeconds {
+			cores = (cpuSeconds - m.cpuSeconds) / elapsed
+		}
+	}
+	m.cpuSeconds = cpuSeconds
+	m.checkedAt = now
+	return cores
+}
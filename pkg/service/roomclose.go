@@ -0,0 +1,178 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// topic of the data messages sent to participants while a room counts down to closing
+	roomClosingTopic = "lk.room_closing"
+
+	roomClosingReasonDeleted       = "deleted"
+	roomClosingReasonScheduleEnded = "schedule_ended"
+)
+
+type RoomClosingMessage struct {
+	// unix seconds
+	CloseTime int64 `json:"close_time"`
+	// seconds left when the message was sent
+	RemainingSeconds int64  `json:"remaining_seconds"`
+	Reason           string `json:"reason,omitempty"`
+}
+
+// roomCloseCountdown keeps participants connected to a room until its close time, telling them how long they
+// have left when the countdown starts and at each of the notice times, then disconnects them and closes the room
+type roomCloseCountdown struct {
+	room    *rtc.Room
+	closeAt time.Time
+	reason  string
+	// remaining times at which participants are notified, longest first
+	notices []time.Duration
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func newRoomCloseCountdown(room *rtc.Room, closeAt time.Time, reason string, notices []time.Duration) *roomCloseCountdown {
+	sorted := make([]time.Duration, len(notices))
+	copy(sorted, notices)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+
+	return &roomCloseCountdown{
+		room:    room,
+		closeAt: closeAt,
+		reason:  reason,
+		notices: sorted,
+		done:    make(chan struct{}),
+	}
+}
+
+func (c *roomCloseCountdown) start() {
+	go c.run()
+}
+
+func (c *roomCloseCountdown) stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+func (c *roomCloseCountdown) run() {
+	remaining := time.Until(c.closeAt)
+	if remaining > 0 {
+		c.room.Logger.Infow("room closing", "reason", c.reason, "closeTime", c.closeAt)
+		c.notify(remaining)
+	}
+
+	for _, notice := range c.pendingNotices(remaining) {
+		if !c.wait(time.Until(c.closeAt) - notice) {
+			return
+		}
+		c.notify(notice)
+	}
+
+	if !c.wait(time.Until(c.closeAt)) {
+		return
+	}
+	closeRoom(c.room)
+}
+
+// pendingNotices returns the notices still ahead with the given time remaining, notices already
+// passed are covered by the message sent when the countdown starts
+func (c *roomCloseCountdown) pendingNotices(remaining time.Duration) []time.Duration {
+	for i, notice := range c.notices {
+		if notice < remaining {
+			return c.notices[i:]
+		}
+	}
+	return nil
+}
+
+// wait returns false when the countdown is stopped before d elapses
+func (c *roomCloseCountdown) wait(d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-c.done:
+		return false
+	}
+}
+
+func (c *roomCloseCountdown) notify(remaining time.Duration) {
+	payload, err := json.Marshal(&RoomClosingMessage{
+		CloseTime:        c.closeAt.Unix(),
+		RemainingSeconds: int64((remaining + time.Second/2) / time.Second),
+		Reason:           c.reason,
+	})
+	if err != nil {
+		return
+	}
+	topic := roomClosingTopic
+	c.room.SendDataPacket(&livekit.UserPacket{
+		Payload: payload,
+		Topic:   &topic,
+	}, livekit.DataPacket_RELIABLE)
+}
+
+func closeRoom(room *rtc.Room) {
+	for _, p := range room.GetParticipants() {
+		_ = p.Close(true, types.ParticipantCloseReasonServiceRequestDeleteRoom, false)
+	}
+	room.Close()
+}
+
+// closeRoomAt disconnects the participants of a room at closeAt, counting down to it with room_closing messages.
+// A room that is already counting down keeps the earlier close time
+func (r *RoomManager) closeRoomAt(room *rtc.Room, closeAt time.Time, reason string) {
+	r.lock.Lock()
+	if existing := r.closingRooms[room.Name()]; existing != nil {
+		if !closeAt.Before(existing.closeAt) {
+			r.lock.Unlock()
+			return
+		}
+		existing.stop()
+	}
+	c := newRoomCloseCountdown(room, closeAt, reason, r.config.Room.Close.CountdownNotices)
+	r.closingRooms[room.Name()] = c
+	r.lock.Unlock()
+
+	c.start()
+}
+
+// isRoomBeingDeleted returns true while a room deleted through the API waits out its grace period
+func (r *RoomManager) isRoomBeingDeleted(roomName livekit.RoomName) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	c := r.closingRooms[roomName]
+	return c != nil && c.reason == roomClosingReasonDeleted
+}
@@ -0,0 +1,38 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoomCloseCountdown(t *testing.T) {
+	c := newRoomCloseCountdown(nil, time.Now().Add(time.Minute), roomClosingReasonDeleted, []time.Duration{10 * time.Second, time.Minute})
+	require.Equal(t, []time.Duration{time.Minute, 10 * time.Second}, c.notices)
+
+	require.Equal(t, []time.Duration{time.Minute, 10 * time.Second}, c.pendingNotices(90*time.Second))
+	// a notice due when the countdown starts is covered by the first message
+	require.Equal(t, []time.Duration{10 * time.Second}, c.pendingNotices(time.Minute))
+	require.Empty(t, c.pendingNotices(5*time.Second))
+
+	// stopped countdowns do not wait out their timers
+	c.stop()
+	c.stop()
+	require.False(t, c.wait(time.Hour))
+	require.True(t, c.wait(0))
+}
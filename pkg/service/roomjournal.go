@@ -0,0 +1,54 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+const (
+	// room events are retained for this long after the last event of the room, so backends can catch up
+	// on a room after it has closed
+	roomJournalTTL = 24 * time.Hour
+	// number of the most recent events retained per room
+	roomJournalMaxEntries = 5000
+)
+
+// roomJournalPage returns up to limit entries with a sequence number greater than after from entries ordered by
+// sequence number, along with the sequence number of the last entry. truncated is set when entries following after
+// are no longer retained, or when after is ahead of the journal because it has expired.
+func roomJournalPage(entries []rtc.JournalEntry, after uint64, limit int) (page []rtc.JournalEntry, lastSeq uint64, truncated bool) {
+	if len(entries) == 0 {
+		return nil, 0, after > 0
+	}
+	first, last := entries[0].Seq, entries[len(entries)-1].Seq
+	if after > last {
+		return nil, last, true
+	}
+	truncated = after+1 < first
+	start := 0
+	for start < len(entries) && entries[start].Seq <= after {
+		start++
+	}
+	end := len(entries)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+	page = make([]rtc.JournalEntry, end-start)
+	copy(page, entries[start:end])
+	return page, last, truncated
+}
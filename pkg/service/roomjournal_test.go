@@ -0,0 +1,72 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+func TestRoomJournalStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore()
+
+	entries, lastSeq, truncated, err := store.LoadRoomJournal(ctx, "room", 0, 10)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	require.Zero(t, lastSeq)
+	require.False(t, truncated)
+
+	for seq := uint64(1); seq <= 3; seq++ {
+		require.NoError(t, store.AppendRoomJournal(ctx, "room", &rtc.JournalEntry{Seq: seq, Type: rtc.JournalEventParticipantJoined}))
+	}
+	// the journal outlives the room
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "room"}, nil))
+	require.NoError(t, store.DeleteRoom(ctx, "room"))
+
+	entries, lastSeq, truncated, err = store.LoadRoomJournal(ctx, "room", 1, 1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, uint64(2), entries[0].Seq)
+	require.Equal(t, uint64(3), lastSeq)
+	require.False(t, truncated)
+
+	// cursor issued before the journal expired
+	_, _, truncated, err = store.LoadRoomJournal(ctx, "other", 5, 10)
+	require.NoError(t, err)
+	require.True(t, truncated)
+
+	for seq := uint64(4); seq < 4+roomJournalMaxEntries; seq++ {
+		require.NoError(t, store.AppendRoomJournal(ctx, "room", &rtc.JournalEntry{Seq: seq}))
+	}
+	entries, lastSeq, truncated, err = store.LoadRoomJournal(ctx, "room", 2, 10)
+	require.NoError(t, err)
+	require.True(t, truncated)
+	require.Len(t, entries, 10)
+	require.Equal(t, lastSeq-roomJournalMaxEntries+1, entries[0].Seq)
+
+	store.journals["room"].updatedAt = time.Now().Add(-roomJournalTTL - time.Minute)
+	entries, lastSeq, _, err = store.LoadRoomJournal(ctx, "room", 0, 10)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	require.Zero(t, lastSeq)
+}
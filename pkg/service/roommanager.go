@@ -16,34 +16,42 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.uber.org/atomic"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 	"github.com/livekit/livekit-server/version"
 	"github.com/livekit/mediatransportutil/pkg/rtcconfig"
-	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 	"github.com/livekit/protocol/utils"
 
+	"github.com/livekit/livekit-server/pkg/agent"
 	"github.com/livekit/livekit-server/pkg/clientconfiguration"
 	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/e2ee"
+	"github.com/livekit/livekit-server/pkg/recorder"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/pacer"
 	"github.com/livekit/livekit-server/pkg/telemetry"
 )
 
 const (
-	roomPurgeSeconds     = 24 * 60 * 60
-	tokenRefreshInterval = 5 * time.Minute
-	tokenDefaultTTL      = 10 * time.Minute
-	iceConfigTTL         = 5 * time.Minute
+	roomPurgeSeconds = 24 * 60 * 60
+	tokenDefaultTTL  = 10 * time.Minute
+	iceConfigTTL     = 5 * time.Minute
+	joinOptionsTTL   = 30 * time.Second
 )
 
 type iceConfigCacheEntry struct {
@@ -51,6 +59,16 @@ type iceConfigCacheEntry struct {
 	modifiedAt time.Time
 }
 
+type joinOptionsKey struct {
+	roomName livekit.RoomName
+	identity livekit.ParticipantIdentity
+}
+
+type joinOptionsEntry struct {
+	opts      rtc.ParticipantOptions
+	createdAt time.Time
+}
+
 // RoomManager manages rooms and its interaction with participants.
 // It's responsible for creating, deleting rooms, as well as running sessions for participants
 type RoomManager struct {
@@ -58,6 +76,8 @@ type RoomManager struct {
 
 	config            *config.Config
 	rtcConfig         *rtc.WebRTCConfig
+	roomRTCConfigs    map[string]*rtc.WebRTCConfig
+	packetWorkers     *pacer.WorkerPool
 	serverInfo        *livekit.ServerInfo
 	currentNode       routing.LocalNode
 	router            routing.Router
@@ -67,10 +87,39 @@ type RoomManager struct {
 	egressLauncher    rtc.EgressLauncher
 	versionGenerator  utils.TimedVersionGenerator
 	turnAuthHandler   *TURNAuthHandler
+	metadataValidator *rtc.MetadataValidator
+	headerExtensions  *rtc.SubscriberHeaderExtensionPolicy
+	iceServerHealth   *ICEServerHealthMonitor
+	roomBudget        *RoomBudgetMonitor
+	socketBuffers     *SocketBufferMonitor
+	chaos             *ChaosMonitor
+	trackInactivity   *TrackInactivityMonitor
+	trackSilence      *TrackSilenceMonitor
 
 	rooms map[livekit.RoomName]*rtc.Room
+	// rooms counting down to being closed with participants in them
+	closingRooms map[livekit.RoomName]*roomCloseCountdown
+	// key rotation state of rooms, participants' keys are never seen by the server
+	e2eeRooms map[livekit.RoomName]*e2ee.RoomState
+	// composite metadata of the rooms hosted on this node
+	compositeMetadata map[livekit.RoomName]*compositeMetadataState
+	// in-process track recordings by recording ID
+	trackRecorders map[string]*recorder.TrackRecorder
+	// RTMP pushes by push ID
+	rtmpPushes map[string]*recorder.RTMPPush
+	// HLS streams by stream ID
+	hlsStreams map[string]*recorder.HLSStream
+	// RTP forwarders by forwarder ID
+	trackForwarders map[string]*recorder.TrackForwarder
+	// RTP captures of received tracks by capture ID
+	rtpCaptures map[string]*rtpCapture
+	// in-process agents receiving the audio of rooms on this node
+	agents *agent.Registry
+	// identity prefix of SIP participants receiving a mix, set while SIP mixing is running
+	sipMixPrefix atomic.String
 
 	iceConfigCache map[livekit.ParticipantIdentity]*iceConfigCacheEntry
+	joinOptions    map[joinOptionsKey]*joinOptionsEntry
 }
 
 func NewLocalRoomManager(
@@ -88,10 +137,40 @@ func NewLocalRoomManager(
 	if err != nil {
 		return nil, err
 	}
+	roomRTCConfigs, err := rtc.NewRoomTransportWebRTCConfigs(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var packetWorkers *pacer.WorkerPool
+	if conf.RTC.PacketWorkers.Enabled {
+		// one pool serves the connections of every room transport
+		packetWorkers = pacer.NewWorkerPool(conf.RTC.PacketWorkers.Workers, conf.RTC.PacketWorkers.QueueSize)
+		rtcConf.PacketWorkers = packetWorkers
+		for _, roomRTCConf := range roomRTCConfigs {
+			roomRTCConf.PacketWorkers = packetWorkers
+		}
+	}
+
+	metadataValidator, err := rtc.NewMetadataValidator(conf.Room.MetadataSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	headerExtensions, err := rtc.NewSubscriberHeaderExtensionPolicy(conf.RTC.SubscriberHeaderExtensions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validateQualityWebHookConfig(conf.WebHook.Quality); err != nil {
+		return nil, err
+	}
 
 	r := &RoomManager{
 		config:            conf,
 		rtcConfig:         rtcConf,
+		roomRTCConfigs:    roomRTCConfigs,
+		packetWorkers:     packetWorkers,
 		currentNode:       currentNode,
 		router:            router,
 		roomStore:         roomStore,
@@ -100,10 +179,23 @@ func NewLocalRoomManager(
 		egressLauncher:    egressLauncher,
 		versionGenerator:  versionGenerator,
 		turnAuthHandler:   turnAuthHandler,
+		metadataValidator: metadataValidator,
+		headerExtensions:  headerExtensions,
+		iceServerHealth:   NewICEServerHealthMonitor(conf),
 
-		rooms: make(map[livekit.RoomName]*rtc.Room),
+		rooms:             make(map[livekit.RoomName]*rtc.Room),
+		closingRooms:      make(map[livekit.RoomName]*roomCloseCountdown),
+		e2eeRooms:         make(map[livekit.RoomName]*e2ee.RoomState),
+		compositeMetadata: make(map[livekit.RoomName]*compositeMetadataState),
+
+		trackRecorders:  make(map[string]*recorder.TrackRecorder),
+		rtmpPushes:      make(map[string]*recorder.RTMPPush),
+		hlsStreams:      make(map[string]*recorder.HLSStream),
+		trackForwarders: make(map[string]*recorder.TrackForwarder),
+		rtpCaptures:     make(map[string]*rtpCapture),
 
 		iceConfigCache: make(map[livekit.ParticipantIdentity]*iceConfigCacheEntry),
+		joinOptions:    make(map[joinOptionsKey]*joinOptionsEntry),
 
 		serverInfo: &livekit.ServerInfo{
 			Edition:  livekit.ServerInfo_Standard,
@@ -113,6 +205,19 @@ func NewLocalRoomManager(
 			NodeId:   currentNode.Id,
 		},
 	}
+	r.agents = agent.NewRegistry(&agentResults{roomManager: r}, r.agentTracks)
+	r.roomBudget = NewRoomBudgetMonitor(conf.Room.Budget, r.getRooms)
+	r.socketBuffers = NewSocketBufferMonitor(conf.RTC.SocketBuffers)
+	r.chaos = NewChaosMonitor(conf.Chaos, r.getRooms)
+	r.trackInactivity = NewTrackInactivityMonitor(conf.Room.TrackInactivity, r.getRooms)
+	r.trackSilence = NewTrackSilenceMonitor(conf.Room.TrackSilence, r.telemetry, r.getRooms)
+
+	r.iceServerHealth.Start()
+	r.roomBudget.Start()
+	r.socketBuffers.Start()
+	r.chaos.Start()
+	r.trackInactivity.Start()
+	r.trackSilence.Start()
 
 	// hook up to router
 	router.OnNewParticipantRTC(r.StartSession)
@@ -126,11 +231,22 @@ func (r *RoomManager) GetRoom(_ context.Context, roomName livekit.RoomName) *rtc
 	return r.rooms[roomName]
 }
 
+// ICEServersReady reports whether the external ICE servers are healthy enough for the node to be ready
+func (r *RoomManager) ICEServersReady() (bool, string) {
+	return r.iceServerHealth.Ready()
+}
+
 // DeleteRoom completely deletes all room information, including active sessions, room store, and routing info
 func (r *RoomManager) DeleteRoom(ctx context.Context, roomName livekit.RoomName) error {
 	logger.Infow("deleting room state", "room", roomName)
 	r.lock.Lock()
 	delete(r.rooms, roomName)
+	if c := r.closingRooms[roomName]; c != nil {
+		c.stop()
+		delete(r.closingRooms, roomName)
+	}
+	delete(r.e2eeRooms, roomName)
+	delete(r.compositeMetadata, roomName)
 	r.lock.Unlock()
 
 	var err, err2 error
@@ -175,15 +291,19 @@ func (r *RoomManager) CleanupRooms() error {
 	return nil
 }
 
-func (r *RoomManager) CloseIdleRooms() {
+func (r *RoomManager) getRooms() []*rtc.Room {
 	r.lock.RLock()
+	defer r.lock.RUnlock()
+
 	rooms := make([]*rtc.Room, 0, len(r.rooms))
 	for _, rm := range r.rooms {
 		rooms = append(rooms, rm)
 	}
-	r.lock.RUnlock()
+	return rooms
+}
 
-	for _, room := range rooms {
+func (r *RoomManager) CloseIdleRooms() {
+	for _, room := range r.getRooms() {
 		room.CloseIfEmpty()
 	}
 }
@@ -201,6 +321,14 @@ func (r *RoomManager) HasParticipants() bool {
 }
 
 func (r *RoomManager) Stop() {
+	r.iceServerHealth.Stop()
+	r.roomBudget.Stop()
+	r.socketBuffers.Stop()
+	r.chaos.Stop()
+	r.trackInactivity.Stop()
+	r.trackSilence.Stop()
+	r.stopRTPCaptures()
+
 	// disconnect all clients
 	r.lock.RLock()
 	rooms := make([]*rtc.Room, 0, len(r.rooms))
@@ -216,14 +344,33 @@ func (r *RoomManager) Stop() {
 		room.Close()
 	}
 
-	if r.rtcConfig != nil {
-		if r.rtcConfig.UDPMux != nil {
-			_ = r.rtcConfig.UDPMux.Close()
+	rtcConfigs := []*rtc.WebRTCConfig{r.rtcConfig}
+	for _, rtcConfig := range r.roomRTCConfigs {
+		rtcConfigs = append(rtcConfigs, rtcConfig)
+	}
+	for _, rtcConfig := range rtcConfigs {
+		if rtcConfig == nil {
+			continue
+		}
+		if rtcConfig.UDPMux != nil {
+			_ = rtcConfig.UDPMux.Close()
 		}
-		if r.rtcConfig.TCPMuxListener != nil {
-			_ = r.rtcConfig.TCPMuxListener.Close()
+		if rtcConfig.TCPMuxListener != nil {
+			_ = rtcConfig.TCPMuxListener.Close()
 		}
 	}
+
+	if r.packetWorkers != nil {
+		r.packetWorkers.Stop()
+	}
+}
+
+// rtcConfigForRoom returns the config of the room transport the room is pinned to, or the default one
+func (r *RoomManager) rtcConfigForRoom(roomName livekit.RoomName) *rtc.WebRTCConfig {
+	if rtcConfig, ok := r.roomRTCConfigs[r.config.RTC.RoomTransportForRoom(string(roomName))]; ok {
+		return rtcConfig
+	}
+	return r.rtcConfig
 }
 
 // StartSession starts WebRTC session when a new participant is connected, takes place on RTC node
@@ -252,6 +399,9 @@ func (r *RoomManager) StartSession(
 	apiKey, _, _ := r.getFirstKeyPair()
 
 	participant := room.GetParticipant(pi.Identity)
+	if participant == nil && r.isRoomBeingDeleted(roomName) {
+		return ErrRoomClosed
+	}
 	if participant != nil {
 		// When reconnecting, it means WS has interrupted but underlying peer connection is still ok in this state,
 		// we'll keep the participant SID, and just swap the sink for the underlying connection
@@ -338,10 +488,36 @@ func (r *RoomManager) StartSession(
 	)
 
 	clientConf := r.clientConfManager.GetConfiguration(pi.Client)
+	forceRelay := room.IsForceRelay()
+	if forceRelay {
+		// configurations are shared between participants
+		if clientConf != nil {
+			clientConf = proto.Clone(clientConf).(*livekit.ClientConfiguration)
+		} else {
+			clientConf = &livekit.ClientConfiguration{}
+		}
+		clientConf.ForceRelay = livekit.ClientConfigSetting_ENABLED
+	}
+
+	publisherIPFilter, err := rtc.NewPublisherIPFilter(r.config.Room.PublisherIPsForRoom(string(roomName)))
+	if err != nil {
+		return err
+	}
 
 	pv := types.ProtocolVersion(pi.Client.Protocol)
-	rtcConf := *r.rtcConfig
+	rtcConf := *r.rtcConfigForRoom(roomName)
 	rtcConf.SetBufferFactory(room.GetBufferFactory())
+	nackPolicy := r.config.Room.NACKPolicyForRoom(string(roomName))
+	if nackPolicy.Disabled {
+		rtcConf.DisableNACK()
+	}
+	if r.config.Video.SingleLayerSVC.EnabledFor(pi.Identity) {
+		rtcConf.DisableSubscriberDependencyDescriptor()
+	}
+	if r.config.Room.AbsSendTimeForRoom(string(roomName)) {
+		rtcConf.EnableSubscriberAbsSendTime()
+	}
+	rtcConf.DropSubscriberHeaderExtensions(r.headerExtensions.DroppedFor(pi.Client))
 	sid := livekit.ParticipantID(utils.NewGuid(utils.ParticipantPrefix))
 	pLogger := rtc.LoggerWithParticipant(
 		rtc.LoggerWithRoom(logger.GetLogger(), room.Name(), room.ID()),
@@ -372,6 +548,12 @@ func (r *RoomManager) StartSession(
 	if pi.SubscriberAllowPause != nil {
 		subscriberAllowPause = *pi.SubscriberAllowPause
 	}
+	opts := rtc.ParticipantOptions{
+		AutoSubscribe: pi.AutoSubscribe,
+	}
+	if joinOpts, ok := r.takeJoinOptions(roomName, pi.Identity); ok {
+		opts = joinOpts
+	}
 	participant, err = rtc.NewParticipant(rtc.ParticipantParams{
 		Identity:                pi.Identity,
 		Name:                    pi.Name,
@@ -384,11 +566,16 @@ func (r *RoomManager) StartSession(
 		Telemetry:               r.telemetry,
 		Trailer:                 room.Trailer(),
 		PLIThrottleConfig:       r.config.RTC.PLIThrottle,
+		RTCPConfig:              r.config.RTC.RTCP,
+		TWCCConfig:              r.config.RTC.TWCC,
+		NACKPolicy:              nackPolicy,
 		CongestionControlConfig: r.config.RTC.CongestionControl,
 		EnabledCodecs:           protoRoom.EnabledCodecs,
 		Grants:                  pi.Grants,
 		Logger:                  pLogger,
 		ClientConf:              clientConf,
+		ForceRelay:              forceRelay,
+		PublisherIPFilter:       publisherIPFilter,
 		ClientInfo:              rtc.ClientInfo{ClientInfo: pi.Client},
 		Region:                  pi.Region,
 		AdaptiveStream:          pi.AdaptiveStream,
@@ -403,12 +590,17 @@ func (r *RoomManager) StartSession(
 		ReconnectOnPublicationError:  reconnectOnPublicationError,
 		ReconnectOnSubscriptionError: reconnectOnSubscriptionError,
 		ReconnectOnDataChannelError:  reconnectOnDataChannelError,
+		ResumeConfig:                 r.config.RTC.Resume,
 		VersionGenerator:             r.versionGenerator,
 		TrackResolver:                room.ResolveMediaTrackForSubscriber,
 		SubscriberAllowPause:         subscriberAllowPause,
 		SubscriptionLimitAudio:       r.config.Limit.SubscriptionLimitAudio,
 		SubscriptionLimitVideo:       r.config.Limit.SubscriptionLimitVideo,
 		PlayoutDelay:                 protoRoom.PlayoutDelay,
+		PlayoutDelaySources:          r.config.Room.PlayoutDelaySourcesForRoom(string(roomName)),
+		ScreenSharePolicy:            room.ScreenSharePolicy(),
+		RefuseNewTracks:              room.RefusesNewTracks,
+		SingleNegotiation:            opts.SingleNegotiation,
 	})
 	if err != nil {
 		return err
@@ -416,8 +608,9 @@ func (r *RoomManager) StartSession(
 	iceConfig := r.setIceConfig(participant)
 
 	// join room
-	opts := rtc.ParticipantOptions{
-		AutoSubscribe: pi.AutoSubscribe,
+	if prefix := r.sipMixPrefix.Load(); prefix != "" && strings.HasPrefix(string(pi.Identity), prefix) {
+		// receives the mix of the other participants instead
+		opts.AutoSubscribe = false
 	}
 	iceServers := r.iceServersForParticipant(apiKey, participant, iceConfig.PreferenceSubscriber == livekit.ICECandidateType_ICT_TLS)
 	if err = room.Join(participant, requestSource, &opts, iceServers); err != nil {
@@ -452,10 +645,15 @@ func (r *RoomManager) StartSession(
 		proto := room.ToProto()
 		persistRoomForParticipantCount(proto)
 		r.telemetry.ParticipantLeft(ctx, proto, p.ToProto(), true)
+		if r.config.Auth.Refresh.RequireLatest {
+			if err := r.roomStore.DeleteTokenRollover(ctx, roomName, p.Identity()); err != nil {
+				pLogger.Warnw("could not delete token rollover", err)
+			}
+		}
 	})
 	participant.OnClaimsChanged(func(participant types.LocalParticipant) {
 		pLogger.Debugw("refreshing client token after claims change")
-		if err := r.refreshToken(participant); err != nil {
+		if err := r.refreshToken(roomName, participant); err != nil {
 			logger.Errorw("could not refresh token", err)
 		}
 	})
@@ -487,6 +685,15 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 	if err != nil {
 		return nil, err
 	}
+	locked, err := r.roomStore.IsRoomLocked(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	// only the sequence number of the last event is needed
+	_, journalSeq, _, err := r.roomStore.LoadRoomJournal(ctx, roomName, 0, 1)
+	if err != nil {
+		return nil, err
+	}
 
 	r.lock.Lock()
 
@@ -503,7 +710,23 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 	}
 
 	// construct ice servers
-	newRoom := rtc.NewRoom(ri, internal, *r.rtcConfig, &r.config.Audio, r.serverInfo, r.telemetry, r.egressLauncher)
+	newRoom := rtc.NewRoom(ri, internal, *r.rtcConfigForRoom(roomName), &r.config.Audio, r.serverInfo, r.telemetry, r.egressLauncher, r.metadataValidator)
+
+	newRoom.SetE2EERequired(r.config.Room.E2EE.Required)
+	newRoom.SetAttributePermissions(rtc.NewAttributePermissions(r.config.Room.Attributes))
+	_, preset, _ := r.config.Room.PresetForRoom(string(roomName))
+	newRoom.SetForceRelay(preset.ForceRelay || r.config.RTC.CandidateFilter.IsForceRelayRoom(string(roomName)))
+	newRoom.SetSpeakerAudio(r.config.Room.SpeakerAudioForRoom(string(roomName)))
+	newRoom.SetScreenSharePolicy(r.config.Room.ScreenSharePolicyForRoom(string(roomName)))
+	// a room locked while hosted by another node stays locked
+	newRoom.SetLocked(locked)
+	// event numbers continue from earlier sessions of the room
+	newRoom.ResumeJournal(journalSeq)
+	newRoom.OnJournalEntry(func(entry rtc.JournalEntry) {
+		if err := r.roomStore.AppendRoomJournal(ctx, roomName, &entry); err != nil {
+			newRoom.Logger.Errorw("could not store room journal entry", err, "seq", entry.Seq)
+		}
+	})
 
 	newRoom.OnClose(func() {
 		roomInfo := newRoom.ToProto()
@@ -522,7 +745,18 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 		}
 	})
 
+	if qn := newQualityNotifier(r.config.WebHook.Quality, newRoom, r.telemetry); qn != nil {
+		newRoom.OnConnectionQuality(qn.Update)
+	}
+
+	newRoom.OnTrackPublished(func(p types.LocalParticipant, track types.MediaTrack) {
+		r.agentTrackPublished(newRoom, p, track)
+		r.e2eeTrackPublished(newRoom, p, track)
+	})
+
 	newRoom.OnParticipantChanged(func(p types.LocalParticipant) {
+		r.e2eeParticipantChanged(newRoom, p)
+		r.compositeMetadataParticipantChanged(newRoom, p)
 		if !p.IsDisconnected() {
 			if err := r.roomStore.StoreParticipant(ctx, roomName, p.ToProto()); err != nil {
 				newRoom.Logger.Errorw("could not handle participant change", err)
@@ -531,6 +765,8 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 	})
 
 	r.rooms[roomName] = newRoom
+	r.e2eeRooms[roomName] = e2ee.NewRoomState(r.config.Room.E2EE.KeyRingSize)
+	r.compositeMetadata[roomName] = newCompositeMetadataState()
 
 	r.lock.Unlock()
 
@@ -562,8 +798,8 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.LocalPa
 	}()
 
 	// send first refresh for cases when client token is close to expiring
-	_ = r.refreshToken(participant)
-	tokenTicker := time.NewTicker(tokenRefreshInterval)
+	_ = r.refreshToken(room.Name(), participant)
+	tokenTicker := time.NewTicker(tokenRefreshConfig(r.config).Interval)
 	defer tokenTicker.Stop()
 	stateCheckTicker := time.NewTicker(time.Millisecond * 500)
 	defer stateCheckTicker.Stop()
@@ -576,7 +812,7 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.LocalPa
 			}
 		case <-tokenTicker.C:
 			// refresh token with the first API Key/secret pair
-			if err := r.refreshToken(participant); err != nil {
+			if err := r.refreshToken(room.Name(), participant); err != nil {
 				pLogger.Errorw("could not refresh token", err, "connID", requestSource.ConnectionID())
 			}
 		case obj := <-requestSource.ReadChan():
@@ -638,6 +874,7 @@ func (r *RoomManager) handleRTCMessage(ctx context.Context, roomName livekit.Roo
 			return
 		}
 		pLogger.Infow("removing participant")
+		r.resolveBanIP(ctx, roomName, participant)
 		// remove participant by identity, any SID
 		room.RemoveParticipant(identity, "", types.ParticipantCloseReasonServiceRequestRemoveParticipant)
 	case *livekit.RTCNodeMessage_MuteTrack:
@@ -657,16 +894,19 @@ func (r *RoomManager) handleRTCMessage(ctx context.Context, roomName livekit.Roo
 		}
 		pLogger.Debugw("updating participant", "metadata", rm.UpdateParticipant.Metadata,
 			"permission", rm.UpdateParticipant.Permission)
-		room.UpdateParticipantMetadata(participant, rm.UpdateParticipant.Name, rm.UpdateParticipant.Metadata)
+		if err := room.UpdateParticipantMetadata(participant, rm.UpdateParticipant.Name, rm.UpdateParticipant.Metadata, ""); err != nil {
+			pLogger.Warnw("could not update participant metadata", err)
+		}
 		if rm.UpdateParticipant.Permission != nil {
 			participant.SetPermission(rm.UpdateParticipant.Permission)
 		}
 	case *livekit.RTCNodeMessage_DeleteRoom:
 		room.Logger.Infow("deleting room")
-		for _, p := range room.GetParticipants() {
-			_ = p.Close(true, types.ParticipantCloseReasonServiceRequestDeleteRoom, false)
+		if gracePeriod := r.config.Room.Close.DeleteGracePeriod; gracePeriod > 0 && len(room.GetParticipants()) != 0 {
+			r.closeRoomAt(room, time.Now().Add(gracePeriod), roomClosingReasonDeleted)
+		} else {
+			closeRoom(room)
 		}
-		room.Close()
 	case *livekit.RTCNodeMessage_UpdateSubscriptions:
 		if participant == nil {
 			return
@@ -693,6 +933,295 @@ func (r *RoomManager) handleRTCMessage(ctx context.Context, roomName livekit.Roo
 	}
 }
 
+// UpdateEgressState updates recording/streaming state of the room the egress is running against,
+// if that room is hosted on this node
+func (r *RoomManager) UpdateEgressState(_ context.Context, info *livekit.EgressInfo) {
+	if info.RoomName == "" {
+		return
+	}
+
+	r.lock.RLock()
+	room := r.rooms[livekit.RoomName(info.RoomName)]
+	r.lock.RUnlock()
+
+	if room == nil || (info.RoomId != "" && room.ID() != livekit.RoomID(info.RoomId)) {
+		return
+	}
+	room.UpdateEgressState(info)
+}
+
+// SetParticipantAudioOnly toggles audio only mode for a participant in a room hosted on this node
+func (r *RoomManager) SetParticipantAudioOnly(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, audioOnly bool) error {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return ErrParticipantNotFound
+	}
+
+	participant.SetAudioOnly(audioOnly)
+	return nil
+}
+
+// UpdateParticipantAttributes sets and deletes attributes of a participant in a room hosted on this node through
+// the server API, which may write any key. It returns the attributes after the update.
+func (r *RoomManager) UpdateParticipantAttributes(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	set map[string]string,
+	del []string,
+) (map[string]string, error) {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return nil, ErrParticipantNotFound
+	}
+
+	if err := room.UpdateParticipantAttributes(participant, set, del, nil); err != nil {
+		if errors.Is(err, rtc.ErrEmptyAttributeKey) {
+			return nil, ErrAttributeKeyEmpty
+		}
+		if errors.Is(err, rtc.ErrAttributesExceedLimits) {
+			return nil, ErrAttributesExceedLimits
+		}
+		return nil, err
+	}
+	return room.GetParticipantAttributes(identity), nil
+}
+
+// SetParticipantSingleLayerSVC toggles forwarding a single operating point of SVC video to a participant
+// in a room hosted on this node
+func (r *RoomManager) SetParticipantSingleLayerSVC(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, enabled bool) error {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	participant, ok := room.GetParticipant(identity).(*rtc.ParticipantImpl)
+	if !ok {
+		return ErrParticipantNotFound
+	}
+
+	participant.SetSingleLayerSVC(enabled)
+	return nil
+}
+
+// SetTrackFrameAssembly toggles holding back packets of a video track hosted on this node until their frame
+// is complete, for at most the configured video frame_assembly max_delay
+func (r *RoomManager) SetTrackFrameAssembly(ctx context.Context, roomName livekit.RoomName, trackID livekit.TrackID, enabled bool) error {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+
+	var track types.MediaTrack
+	for _, p := range room.GetParticipants() {
+		if track = p.GetPublishedTrack(trackID); track != nil {
+			break
+		}
+	}
+	if track == nil {
+		return ErrTrackNotFound
+	}
+	if track.Kind() != livekit.TrackType_VIDEO {
+		return ErrTrackNotVideo
+	}
+
+	var maxDelay time.Duration
+	if enabled {
+		maxDelay = r.config.Video.FrameAssembly.MaxDelay
+	}
+	for _, receiver := range track.Receivers() {
+		if wr, ok := receiver.(*sfu.WebRTCReceiver); ok {
+			wr.SetFrameAssembly(maxDelay)
+		}
+	}
+	return nil
+}
+
+// GetRoomStats returns participant stats of a room hosted on this node for the most recent reporting interval
+func (r *RoomManager) GetRoomStats(ctx context.Context, roomName livekit.RoomName) (*telemetry.RoomStats, error) {
+	if r.GetRoom(ctx, roomName) == nil {
+		return nil, ErrRoomNotFound
+	}
+	return r.telemetry.RoomStats(roomName), nil
+}
+
+// SetRoomLocked locks or unlocks a room hosted on this node. the lock is stored with the room, so that it is kept
+// when the room is hosted by another node
+func (r *RoomManager) SetRoomLocked(ctx context.Context, roomName livekit.RoomName, locked bool) error {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	var err error
+	if locked {
+		err = r.roomStore.StoreRoomLocked(ctx, roomName)
+	} else {
+		err = r.roomStore.DeleteRoomLocked(ctx, roomName)
+	}
+	if err != nil {
+		return err
+	}
+	room.SetLocked(locked)
+	return nil
+}
+
+// SetRoomForceRelay changes whether participants of a room hosted on this node must connect through TURN.
+// when enabled, participants connected otherwise are asked to reconnect, and returned
+func (r *RoomManager) SetRoomForceRelay(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	forceRelay bool,
+) ([]livekit.ParticipantIdentity, error) {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	room.SetForceRelay(forceRelay)
+	if !forceRelay {
+		return nil, nil
+	}
+
+	var reconnected []livekit.ParticipantIdentity
+	for _, participant := range room.GetParticipants() {
+		if participant.GetICEConnectionType() == types.ICEConnectionTypeTURN {
+			continue
+		}
+		participant.GetLogger().Infow("reconnecting participant through relay")
+		participant.IssueFullReconnect(types.ParticipantCloseReasonRelayRequired)
+		reconnected = append(reconnected, participant.Identity())
+	}
+	return reconnected, nil
+}
+
+// MuteAllExcept mutes the tracks of all participants of a room hosted on this node other than the excepted ones,
+// and the tracks they publish later. when lock is set, the room is locked as well so that no participant joins
+func (r *RoomManager) MuteAllExcept(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	except []livekit.ParticipantIdentity,
+	sources []livekit.TrackSource,
+	lock bool,
+) ([]livekit.TrackID, error) {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	if lock {
+		if err := r.roomStore.StoreRoomLocked(ctx, roomName); err != nil {
+			return nil, err
+		}
+	}
+	return room.MuteAllExcept(except, sources, lock), nil
+}
+
+// ClearMuteAll stops muting the tracks published in a room hosted on this node after MuteAllExcept
+func (r *RoomManager) ClearMuteAll(ctx context.Context, roomName livekit.RoomName) error {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	room.ClearMuteAll()
+	return nil
+}
+
+// RemoveParticipantsWithAttribute removes the participants of a room hosted on this node whose metadata
+// is a JSON object with the given key set to the given value
+func (r *RoomManager) RemoveParticipantsWithAttribute(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	key string,
+	value string,
+	lock bool,
+) ([]livekit.ParticipantIdentity, error) {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	if lock {
+		if err := r.roomStore.StoreRoomLocked(ctx, roomName); err != nil {
+			return nil, err
+		}
+	}
+	return room.RemoveParticipantsMatching(func(p types.LocalParticipant) bool {
+		attributes := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(p.ToProto().Metadata), &attributes); err != nil {
+			return false
+		}
+		v, ok := attributes[key]
+		return ok && fmt.Sprint(v) == value
+	}, types.ParticipantCloseReasonServiceRequestRemoveParticipant, lock), nil
+}
+
+// GetParticipantChanges returns name and metadata changes made in a room hosted on this node
+func (r *RoomManager) GetParticipantChanges(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) ([]rtc.ParticipantChange, error) {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	return room.GetParticipantChanges(identity), nil
+}
+
+// GetRoomJournal returns up to limit room events following the after cursor, along with the sequence number of
+// the latest event. truncated is set when some of the events following the cursor are no longer retained.
+// Events are read from the store, so they remain available on any node after the room has closed.
+func (r *RoomManager) GetRoomJournal(ctx context.Context, roomName livekit.RoomName, after uint64, limit int) ([]rtc.JournalEntry, uint64, bool, error) {
+	if limit <= 0 {
+		limit = roomJournalDefaultLimit
+	} else if limit > roomJournalMaxLimit {
+		limit = roomJournalMaxLimit
+	}
+	return r.roomStore.LoadRoomJournal(ctx, roomName, after, limit)
+}
+
+// GetTrackRTPStats returns the live RTP stats of a track hosted on this node. For a track published by the
+// participant, that is the stats of its receivers and of the down tracks of all subscribers on this node,
+// for a track the participant subscribes to, the stats of its down track.
+func (r *RoomManager) GetTrackRTPStats(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, trackID livekit.TrackID) (*TrackRTPStats, error) {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return nil, ErrParticipantNotFound
+	}
+
+	stats := &TrackRTPStats{
+		Receivers: make(map[string]map[string]interface{}),
+		Senders:   make(map[string]map[string]interface{}),
+	}
+	if track := participant.GetPublishedTrack(trackID); track != nil {
+		stats.Layers = make(map[string][]sfu.LayerMapping)
+		for _, receiver := range track.Receivers() {
+			stats.Receivers[receiver.Codec().MimeType] = receiver.RTPStatsDebugInfo()
+			stats.Layers[receiver.Codec().MimeType] = receiver.LayerMappings()
+		}
+		for _, p := range room.GetParticipants() {
+			for _, st := range p.GetSubscribedTracks() {
+				if st.ID() == trackID && st.PublisherIdentity() == identity {
+					stats.Senders[string(p.Identity())] = st.DownTrack().RTPStatsDebugInfo()
+				}
+			}
+		}
+		return stats, nil
+	}
+
+	for _, st := range participant.GetSubscribedTracks() {
+		if st.ID() == trackID {
+			stats.Senders[string(identity)] = st.DownTrack().RTPStatsDebugInfo()
+			return stats, nil
+		}
+	}
+	return nil, ErrTrackNotFound
+}
+
 func (r *RoomManager) iceServersForParticipant(apiKey string, participant types.LocalParticipant, tlsOnly bool) []*livekit.ICEServer {
 	var iceServers []*livekit.ICEServer
 	rtcConf := r.config.RTC
@@ -730,30 +1259,27 @@ func (r *RoomManager) iceServersForParticipant(apiKey string, participant types.
 		}
 	}
 
-	if len(rtcConf.TURNServers) > 0 {
-		hasSTUN = true
-		for _, s := range r.config.RTC.TURNServers {
-			scheme := "turn"
-			transport := "tcp"
-			if s.Protocol == "tls" {
-				scheme = "turns"
-			} else if s.Protocol == "udp" {
-				transport = "udp"
-			}
-			is := &livekit.ICEServer{
-				Urls: []string{
-					fmt.Sprintf("%s:%s:%d?transport=%s", scheme, s.Host, s.Port, transport),
-				},
-				Username:   s.Username,
-				Credential: s.Credential,
-			}
-			iceServers = append(iceServers, is)
+	for _, s := range rtcConf.TURNServers {
+		if !r.iceServerHealth.IsTURNServerHealthy(s) {
+			continue
 		}
+		hasSTUN = true
+		iceServers = append(iceServers, &livekit.ICEServer{
+			Urls:       []string{turnServerURL(s)},
+			Username:   s.Username,
+			Credential: s.Credential,
+		})
 	}
 
-	if len(rtcConf.STUNServers) > 0 {
+	var stunServers []string
+	for _, s := range rtcConf.STUNServers {
+		if r.iceServerHealth.IsSTUNServerHealthy(s) {
+			stunServers = append(stunServers, s)
+		}
+	}
+	if len(stunServers) > 0 {
 		hasSTUN = true
-		iceServers = append(iceServers, iceServerForStunServers(r.config.RTC.STUNServers))
+		iceServers = append(iceServers, iceServerForStunServers(stunServers))
 	}
 
 	if !hasSTUN {
@@ -762,28 +1288,25 @@ func (r *RoomManager) iceServersForParticipant(apiKey string, participant types.
 	return iceServers
 }
 
-func (r *RoomManager) refreshToken(participant types.LocalParticipant) error {
+func (r *RoomManager) refreshToken(roomName livekit.RoomName, participant types.LocalParticipant) error {
 	key, secret, err := r.getFirstKeyPair()
 	if err != nil {
 		return err
 	}
 
-	grants := participant.ClaimGrants()
-	token := auth.NewAccessToken(key, secret)
-	token.SetName(grants.Name).
-		SetIdentity(string(participant.Identity())).
-		SetValidFor(tokenDefaultTTL).
-		SetMetadata(grants.Metadata).
-		AddGrant(grants.Video)
-	jwt, err := token.ToJWT()
-	if err == nil {
-		err = participant.SendRefreshToken(jwt)
-	}
+	refresh := tokenRefreshConfig(r.config)
+	tokenID := utils.NewGuid(refreshTokenPrefix)
+	jwt, err := newRefreshToken(key, secret, tokenID, participant.Identity(), participant.ClaimGrants(), refresh.TokenTTL)
 	if err != nil {
 		return err
 	}
-
-	return nil
+	// the rollover is stored first, a client resuming with the new token must not be rejected
+	if refresh.RequireLatest {
+		if err = r.rollToken(context.Background(), roomName, participant.Identity(), tokenID, refresh); err != nil {
+			return err
+		}
+	}
+	return participant.SendRefreshToken(jwt)
 }
 
 func (r *RoomManager) setIceConfig(participant types.LocalParticipant) *livekit.ICEConfig {
@@ -806,6 +1329,34 @@ func (r *RoomManager) getIceConfig(participant types.LocalParticipant) *livekit.
 	return iceConfigCacheEntry.iceConfig
 }
 
+// SetJoinOptions overrides the options of the next session of the participant in a room hosted on this node,
+// for joins that need more than what the signal protocol carries
+func (r *RoomManager) SetJoinOptions(roomName livekit.RoomName, identity livekit.ParticipantIdentity, opts rtc.ParticipantOptions) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.joinOptions[joinOptionsKey{roomName, identity}] = &joinOptionsEntry{
+		opts:      opts,
+		createdAt: time.Now(),
+	}
+}
+
+func (r *RoomManager) takeJoinOptions(roomName livekit.RoomName, identity livekit.ParticipantIdentity) (rtc.ParticipantOptions, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := joinOptionsKey{roomName, identity}
+	entry, ok := r.joinOptions[key]
+	if !ok {
+		return rtc.ParticipantOptions{}, false
+	}
+	delete(r.joinOptions, key)
+	if time.Since(entry.createdAt) > joinOptionsTTL {
+		return rtc.ParticipantOptions{}, false
+	}
+	return entry.opts, true
+}
+
 func (r *RoomManager) getFirstKeyPair() (string, string, error) {
 	for key, secret := range r.config.Keys {
 		return key, secret, nil
@@ -0,0 +1,49 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestStoreRoomLocked(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore()
+	require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "room"}, nil))
+
+	locked, err := store.IsRoomLocked(ctx, "room")
+	require.NoError(t, err)
+	require.False(t, locked)
+
+	require.NoError(t, store.StoreRoomLocked(ctx, "room"))
+	locked, err = store.IsRoomLocked(ctx, "room")
+	require.NoError(t, err)
+	require.True(t, locked)
+
+	require.NoError(t, store.DeleteRoomLocked(ctx, "room"))
+	locked, _ = store.IsRoomLocked(ctx, "room")
+	require.False(t, locked)
+
+	// the lock goes with the room
+	require.NoError(t, store.StoreRoomLocked(ctx, "room"))
+	require.NoError(t, store.DeleteRoom(ctx, "room"))
+	locked, _ = store.IsRoomLocked(ctx, "room")
+	require.False(t, locked)
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	roomScheduleLockDuration  = 5 * time.Second
+	roomScheduleCheckInterval = 5 * time.Second
+)
+
+// RoomSchedule is the window during which participants can join a room, times are unix seconds
+type RoomSchedule struct {
+	Room string `json:"room"`
+	// the room is open from creation when 0
+	StartTime int64 `json:"start_time,omitempty"`
+	// the room stays open until it's deleted or empty when 0
+	EndTime int64 `json:"end_time,omitempty"`
+
+	StartingSoonNotified bool `json:"starting_soon_notified,omitempty"`
+}
+
+func (s *RoomSchedule) Validate() error {
+	if s.Room == "" {
+		return ErrRoomNameEmpty
+	}
+	if s.EndTime != 0 && s.EndTime <= s.StartTime {
+		return ErrRoomScheduleInvalid
+	}
+	return nil
+}
+
+// CheckOpen returns an error when participants can not join the room at the given time
+func (s *RoomSchedule) CheckOpen(now time.Time) error {
+	if s.StartTime != 0 && now.Unix() < s.StartTime {
+		return ErrRoomNotOpen
+	}
+	if s.EndTime != 0 && now.Unix() >= s.EndTime {
+		return ErrRoomClosed
+	}
+	return nil
+}
+
+// CheckRoomSchedules sends room_starting_soon webhooks for rooms about to open, and counts down rooms hosted
+// on this node to their end time, closing them when it is reached
+func (r *RoomManager) CheckRoomSchedules() {
+	ctx := context.Background()
+	schedules, err := r.roomStore.ListRoomSchedules(ctx)
+	if err != nil {
+		logger.Errorw("could not list room schedules", err)
+		return
+	}
+
+	now := time.Now()
+	for _, schedule := range schedules {
+		roomName := livekit.RoomName(schedule.Room)
+		if schedule.EndTime != 0 && now.Unix()-schedule.EndTime > roomPurgeSeconds {
+			if err := r.roomStore.DeleteRoomSchedule(ctx, roomName); err != nil {
+				logger.Warnw("could not delete room schedule", err, "room", roomName)
+			}
+			continue
+		}
+
+		if schedule.StartTime != 0 && !schedule.StartingSoonNotified &&
+			now.Unix() < schedule.StartTime &&
+			now.Add(r.config.Room.Schedule.StartingSoonNotice).Unix() >= schedule.StartTime {
+			r.notifyRoomStartingSoon(ctx, roomName)
+		}
+
+		if schedule.EndTime == 0 {
+			continue
+		}
+		room := r.GetRoom(ctx, roomName)
+		if room == nil {
+			continue
+		}
+		if now.Add(r.config.Room.Schedule.CloseGracePeriod).Unix() >= schedule.EndTime {
+			r.closeRoomAt(room, time.Unix(schedule.EndTime, 0), roomClosingReasonScheduleEnded)
+		}
+	}
+}
+
+func (r *RoomManager) notifyRoomStartingSoon(ctx context.Context, roomName livekit.RoomName) {
+	// every node checks schedules, the first to claim the notification sends it
+	token, err := r.roomStore.LockRoom(ctx, roomName, roomScheduleLockDuration)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = r.roomStore.UnlockRoom(ctx, roomName, token)
+	}()
+
+	schedule, err := r.roomStore.LoadRoomSchedule(ctx, roomName)
+	if err != nil || schedule.StartingSoonNotified {
+		return
+	}
+	schedule.StartingSoonNotified = true
+	if err = r.roomStore.StoreRoomSchedule(ctx, schedule); err != nil {
+		logger.Errorw("could not store room schedule", err, "room", roomName)
+		return
+	}
+
+	logger.Infow("scheduled room starting soon", "room", roomName, "startTime", time.Unix(schedule.StartTime, 0))
+	room, _, err := r.roomStore.LoadRoom(ctx, roomName, false)
+	if err != nil {
+		room = &livekit.Room{Name: string(roomName)}
+	}
+	r.telemetry.RoomStartingSoon(ctx, room)
+}
@@ -0,0 +1,47 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoomSchedule(t *testing.T) {
+	now := time.Now()
+
+	t.Run("validate", func(t *testing.T) {
+		require.ErrorIs(t, (&RoomSchedule{StartTime: now.Unix()}).Validate(), ErrRoomNameEmpty)
+		require.ErrorIs(t, (&RoomSchedule{Room: "room", StartTime: now.Unix(), EndTime: now.Unix()}).Validate(), ErrRoomScheduleInvalid)
+		require.NoError(t, (&RoomSchedule{Room: "room", StartTime: now.Unix()}).Validate())
+		require.NoError(t, (&RoomSchedule{Room: "room", EndTime: now.Unix()}).Validate())
+	})
+
+	t.Run("open window", func(t *testing.T) {
+		schedule := &RoomSchedule{
+			Room:      "room",
+			StartTime: now.Add(time.Minute).Unix(),
+			EndTime:   now.Add(time.Hour).Unix(),
+		}
+		require.ErrorIs(t, schedule.CheckOpen(now), ErrRoomNotOpen)
+		require.NoError(t, schedule.CheckOpen(now.Add(time.Minute)))
+		require.ErrorIs(t, schedule.CheckOpen(now.Add(time.Hour)), ErrRoomClosed)
+
+		schedule.StartTime = 0
+		require.NoError(t, schedule.CheckOpen(now))
+	})
+}
@@ -33,6 +33,9 @@ import (
 	"github.com/livekit/protocol/rpc"
 )
 
+// SCTP max message size advertised by the SFU, larger data channel messages are dropped
+const maxDataMessageSize = 65536
+
 // A rooms service that supports a single node
 type RoomService struct {
 	roomConf       config.RoomConfig
@@ -41,6 +44,8 @@ type RoomService struct {
 	roomAllocator  RoomAllocator
 	roomStore      ServiceStore
 	egressLauncher rtc.EgressLauncher
+
+	metadataValidator *rtc.MetadataValidator
 }
 
 func NewRoomService(
@@ -59,6 +64,7 @@ func NewRoomService(
 		roomStore:      serviceStore,
 		egressLauncher: egressLauncher,
 	}
+	svc.metadataValidator, err = rtc.NewMetadataValidator(roomConf.MetadataSchema)
 	return
 }
 
@@ -69,6 +75,9 @@ func (s *RoomService) CreateRoom(ctx context.Context, req *livekit.CreateRoomReq
 	} else if req.Egress != nil && s.egressLauncher == nil {
 		return nil, ErrEgressNotConnected
 	}
+	if err := s.metadataValidator.ValidateRoomMetadata(req.Metadata); err != nil {
+		return nil, twirp.InvalidArgumentError("metadata", err.Error())
+	}
 
 	rm, err := s.roomAllocator.CreateRoom(ctx, req)
 	if err != nil {
@@ -155,6 +164,11 @@ func (s *RoomService) DeleteRoom(ctx context.Context, req *livekit.DeleteRoomReq
 		return nil, err
 	}
 
+	if s.roomConf.Close.DeleteGracePeriod > 0 {
+		// participants are disconnected at the end of the grace period, the room is deleted then
+		return &livekit.DeleteRoomResponse{}, nil
+	}
+
 	// we should not return until when the room is confirmed deleted
 	err = s.confirmExecution(func() error {
 		_, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false)
@@ -288,6 +302,9 @@ func (s *RoomService) UpdateParticipant(ctx context.Context, req *livekit.Update
 	if maxMetadataSize > 0 && len(req.Metadata) > maxMetadataSize {
 		return nil, twirp.InvalidArgumentError(ErrMetadataExceedsLimits.Error(), strconv.Itoa(maxMetadataSize))
 	}
+	if err := s.metadataValidator.ValidateParticipantMetadata(req.Metadata); err != nil {
+		return nil, twirp.InvalidArgumentError("metadata", err.Error())
+	}
 
 	err := s.writeParticipantMessage(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity), &livekit.RTCNodeMessage{
 		Message: &livekit.RTCNodeMessage_UpdateParticipant{
@@ -343,11 +360,31 @@ func (s *RoomService) UpdateSubscriptions(ctx context.Context, req *livekit.Upda
 
 func (s *RoomService) SendData(ctx context.Context, req *livekit.SendDataRequest) (*livekit.SendDataResponse, error) {
 	roomName := livekit.RoomName(req.Room)
-	AppendLogFields(ctx, "room", roomName, "size", len(req.Data))
+	AppendLogFields(ctx, "room", roomName, "size", len(req.Data), "kind", req.Kind, "topic", req.GetTopic(), "destinations", req.DestinationIdentities)
 	if err := EnsureAdminPermission(ctx, roomName); err != nil {
 		return nil, twirpAuthError(err)
 	}
 
+	// the packet is delivered as a single data channel message
+	size := proto.Size(&livekit.DataPacket{
+		Kind: req.Kind,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				Payload:               req.Data,
+				DestinationSids:       req.DestinationSids,
+				DestinationIdentities: req.DestinationIdentities,
+				Topic:                 req.Topic,
+			},
+		},
+	})
+	if size > maxDataMessageSize {
+		return nil, twirp.InvalidArgumentError(ErrDataExceedsLimits.Error(), strconv.Itoa(maxDataMessageSize))
+	}
+
+	if _, _, err := s.roomStore.LoadRoom(ctx, roomName, false); err != nil {
+		return nil, err
+	}
+
 	err := s.router.WriteRoomRTC(ctx, roomName, &livekit.RTCNodeMessage{
 		Message: &livekit.RTCNodeMessage_SendData{
 			SendData: req,
@@ -366,6 +403,9 @@ func (s *RoomService) UpdateRoomMetadata(ctx context.Context, req *livekit.Updat
 	if maxMetadataSize > 0 && len(req.Metadata) > maxMetadataSize {
 		return nil, twirp.InvalidArgumentError(ErrMetadataExceedsLimits.Error(), strconv.Itoa(maxMetadataSize))
 	}
+	if err := s.metadataValidator.ValidateRoomMetadata(req.Metadata); err != nil {
+		return nil, twirp.InvalidArgumentError("metadata", err.Error())
+	}
 
 	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
 		return nil, twirpAuthError(err)
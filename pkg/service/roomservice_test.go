@@ -16,6 +16,8 @@ package service_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -122,6 +124,101 @@ func TestMetaDataLimits(t *testing.T) {
 	}
 }
 
+func TestMetadataSchema(t *testing.T) {
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaFile, []byte(`{
+		"type": "object",
+		"properties": {"topic": {"type": "string", "maxLength": 10}},
+		"required": ["topic"],
+		"additionalProperties": false
+	}`), 0644))
+
+	svc := newTestRoomService(config.RoomConfig{
+		MetadataSchema: config.MetadataSchemaConfig{
+			RoomSchemaFile:        schemaFile,
+			ParticipantSchemaFile: schemaFile,
+		},
+	})
+	grant := &auth.ClaimGrants{
+		Video: &auth.VideoGrant{},
+	}
+	ctx := service.WithGrants(context.Background(), grant)
+
+	for _, metadata := range []string{"not json", `{"topic": 1}`, `{"other": "a"}`, `{"topic": "too long for schema"}`} {
+		_, err := svc.UpdateParticipant(ctx, &livekit.UpdateParticipantRequest{
+			Room:     "testroom",
+			Identity: "123",
+			Metadata: metadata,
+		})
+		terr, ok := err.(twirp.Error)
+		require.True(t, ok)
+		require.Equal(t, twirp.InvalidArgument, terr.Code(), metadata)
+
+		_, err = svc.UpdateRoomMetadata(ctx, &livekit.UpdateRoomMetadataRequest{
+			Room:     "testroom",
+			Metadata: metadata,
+		})
+		terr, ok = err.(twirp.Error)
+		require.True(t, ok)
+		require.Equal(t, twirp.InvalidArgument, terr.Code(), metadata)
+	}
+
+	_, err := svc.UpdateRoomMetadata(ctx, &livekit.UpdateRoomMetadataRequest{
+		Room:     "testroom",
+		Metadata: `{"topic": "ok"}`,
+	})
+	terr, ok := err.(twirp.Error)
+	require.True(t, ok)
+	require.NotEqual(t, twirp.InvalidArgument, terr.Code())
+}
+
+func TestSendData(t *testing.T) {
+	svc := newTestRoomService(config.RoomConfig{})
+	grant := &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomAdmin: true, Room: "testroom"},
+	}
+	ctx := service.WithGrants(context.Background(), grant)
+	topic := "chat"
+
+	t.Run("too large", func(t *testing.T) {
+		_, err := svc.SendData(ctx, &livekit.SendDataRequest{
+			Room: "testroom",
+			Data: make([]byte, 65536),
+			Kind: livekit.DataPacket_RELIABLE,
+		})
+		terr, ok := err.(twirp.Error)
+		require.True(t, ok)
+		require.Equal(t, twirp.InvalidArgument, terr.Code())
+	})
+
+	t.Run("room not found", func(t *testing.T) {
+		svc.store.LoadRoomReturns(nil, nil, service.ErrRoomNotFound)
+		defer svc.store.LoadRoomReturns(nil, nil, nil)
+
+		_, err := svc.SendData(ctx, &livekit.SendDataRequest{
+			Room: "testroom",
+			Data: []byte("hello"),
+		})
+		require.ErrorIs(t, err, service.ErrRoomNotFound)
+		require.Zero(t, svc.router.WriteRoomRTCCallCount())
+	})
+
+	t.Run("sent to room", func(t *testing.T) {
+		_, err := svc.SendData(ctx, &livekit.SendDataRequest{
+			Room:                  "testroom",
+			Data:                  []byte("hello"),
+			Kind:                  livekit.DataPacket_LOSSY,
+			DestinationIdentities: []string{"a"},
+			Topic:                 &topic,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, svc.router.WriteRoomRTCCallCount())
+		_, roomName, msg := svc.router.WriteRoomRTCArgsForCall(0)
+		require.Equal(t, livekit.RoomName("testroom"), roomName)
+		require.Equal(t, "chat", msg.GetSendData().GetTopic())
+	})
+}
+
 func newTestRoomService(conf config.RoomConfig) *TestRoomService {
 	router := &routingfakes.FakeRouter{}
 	allocator := &servicefakes.FakeRoomAllocator{}
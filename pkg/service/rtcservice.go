@@ -56,8 +56,17 @@ type RTCService struct {
 	parser        *uaparser.Parser
 	telemetry     telemetry.TelemetryService
 
+	originValidator *OriginValidator
+
 	mu          sync.Mutex
-	connections map[*websocket.Conn]struct{}
+	connections map[SignalConnection]struct{}
+}
+
+// SignalConnection carries signal messages between a client and the server
+type SignalConnection interface {
+	ReadRequest() (*livekit.SignalRequest, int, error)
+	WriteResponse(msg *livekit.SignalResponse) (int, error)
+	Close() error
 }
 
 func NewRTCService(
@@ -72,20 +81,22 @@ func NewRTCService(
 		router:        router,
 		roomAllocator: ra,
 		store:         store,
-		upgrader:      websocket.Upgrader{},
+		upgrader:      websocket.Upgrader{EnableCompression: conf.Signal.Compression},
 		currentNode:   currentNode,
 		config:        conf,
 		isDev:         conf.Development,
 		limits:        conf.Limit,
 		parser:        uaparser.NewFromSaved(),
 		telemetry:     telemetry,
-		connections:   map[*websocket.Conn]struct{}{},
+		connections:   map[SignalConnection]struct{}{},
 	}
 
-	// allow connections from any origin, since script may be hosted anywhere
+	// allow connections from any origin unless restricted, since script may be hosted anywhere
 	// security is enforced by access tokens
+	s.originValidator = NewOriginValidator(conf.CORS)
 	s.upgrader.CheckOrigin = func(r *http.Request) bool {
-		return true
+		allowed, _ := s.originValidator.Allowed(r.Header.Get("Origin"))
+		return allowed
 	}
 
 	return s
@@ -131,6 +142,13 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 		roomName = onlyName
 	}
 
+	if err = checkParticipantBanned(r.Context(), s.store, roomName, livekit.ParticipantIdentity(claims.Identity), GetClientIP(r)); err != nil {
+		if errors.Is(err, ErrParticipantBanned) {
+			return "", pi, http.StatusForbidden, err
+		}
+		return "", pi, http.StatusInternalServerError, err
+	}
+
 	// this is new connection for existing participant -  with publish only permissions
 	if publishParam != "" {
 		// Make sure grant has GetCanPublish set,
@@ -147,6 +165,8 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 	if err != nil {
 		if errors.Is(err, ErrRoomNotFound) {
 			return "", pi, http.StatusNotFound, err
+		} else if errors.Is(err, ErrRoomNotOpen) || errors.Is(err, ErrRoomClosed) {
+			return "", pi, http.StatusForbidden, err
 		} else {
 			return "", pi, http.StatusInternalServerError, err
 		}
@@ -154,6 +174,14 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 
 	region := ""
 	if router, ok := s.router.(routing.Router); ok {
+		if err = checkTokenRegion(r.Context(), router, roomName); err != nil {
+			if errors.Is(err, ErrRegionMismatch) {
+				return "", pi, http.StatusForbidden, err
+			} else if errors.Is(err, ErrRegionUnavailable) {
+				return "", pi, http.StatusServiceUnavailable, err
+			}
+			return "", pi, http.StatusInternalServerError, err
+		}
 		region = router.GetRegion()
 		if foundNode, err := router.GetNodeForRoom(r.Context(), roomName); err == nil {
 			if selector.LimitsReached(s.limits, foundNode.Stats) {
@@ -174,6 +202,15 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 	}
 	if pi.Reconnect {
 		pi.ID = livekit.ParticipantID(participantID)
+
+		if s.config.Auth.Refresh.RequireLatest {
+			if err = checkResumeToken(r.Context(), s.store, roomName, pi.Identity, GetTokenID(r.Context())); err != nil {
+				if errors.Is(err, ErrTokenNotLatest) {
+					return "", routing.ParticipantInit{}, http.StatusUnauthorized, err
+				}
+				return "", routing.ParticipantInit{}, http.StatusInternalServerError, err
+			}
+		}
 	}
 
 	if autoSubParam != "" {
@@ -197,6 +234,24 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.serveSignal(w, r, func() (SignalConnection, error) {
+		conn, err := s.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return nil, err
+		}
+		if level := s.config.Signal.CompressionLevel; level != 0 {
+			// only takes effect when the client negotiated permessage-deflate
+			if err := conn.SetCompressionLevel(level); err != nil {
+				logger.Warnw("could not set signal compression level", err, "level", level)
+			}
+		}
+		return NewWSSignalConnection(conn, s.config.Signal.BinaryOnly), nil
+	})
+}
+
+// serveSignal starts a session for the request and relays signal messages until the connection closes.
+// upgrade is called once the session has been started.
+func (s *RTCService) serveSignal(w http.ResponseWriter, r *http.Request, upgrade func() (SignalConnection, error)) {
 	roomName, pi, code, err := s.validate(r)
 	if err != nil {
 		handleError(w, code, err)
@@ -270,24 +325,23 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	// upgrade only once the basics are good to go
-	conn, err := s.upgrader.Upgrade(w, r, nil)
+	sigConn, err := upgrade()
 	if err != nil {
 		handleError(w, http.StatusInternalServerError, err, loggerFields...)
 		return
 	}
 
 	s.mu.Lock()
-	s.connections[conn] = struct{}{}
+	s.connections[sigConn] = struct{}{}
 	s.mu.Unlock()
 
 	defer func() {
 		s.mu.Lock()
-		delete(s.connections, conn)
+		delete(s.connections, sigConn)
 		s.mu.Unlock()
 	}()
 
-	// websocket established
-	sigConn := NewWSSignalConnection(conn)
+	// signal connection established
 	count, err := sigConn.WriteResponse(initialResponse)
 	if err != nil {
 		pLogger.Warnw("could not write initial response", err)
@@ -308,7 +362,7 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			// when the source is terminated, this means Participant.Close had been called and RTC connection is done
 			// we would terminate the signal connection as well
-			_ = conn.Close()
+			_ = sigConn.Close()
 		}()
 		defer func() {
 			if r := rtc.Recover(pLogger); r != nil {
@@ -357,6 +411,27 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
+	// tokens revoked while the participant is connected are found by checking them periodically
+	if tokenID := GetTokenRevocationID(r.Context()); tokenID != "" && s.config.Auth.Revocation.CheckInterval > 0 {
+		go watchTokenRevocation(s.store, tokenID, s.config.Auth.Revocation.CheckInterval, done, pLogger, func() {
+			// the leave is relayed to the RTC node hosting the participant, which removes it from the room
+			leave := &livekit.SignalRequest{
+				Message: &livekit.SignalRequest_Leave{
+					Leave: &livekit.LeaveRequest{Reason: livekit.DisconnectReason_PARTICIPANT_REMOVED},
+				},
+			}
+			if err := cr.RequestSink.WriteMessage(leave); err != nil {
+				pLogger.Warnw("could not remove participant with revoked token", err)
+			}
+			_, _ = sigConn.WriteResponse(&livekit.SignalResponse{
+				Message: &livekit.SignalResponse_Leave{
+					Leave: &livekit.LeaveRequest{Reason: livekit.DisconnectReason_PARTICIPANT_REMOVED},
+				},
+			})
+			_ = sigConn.Close()
+		})
+	}
+
 	// handle incoming requests from websocket
 	for {
 		req, count, err := sigConn.ReadRequest()
@@ -0,0 +1,175 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+)
+
+// testSignalConnection stands in for a WebSocket or WebTransport connection
+type testSignalConnection struct {
+	requests  chan *livekit.SignalRequest
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	lock      sync.Mutex
+	responses []*livekit.SignalResponse
+}
+
+func newTestSignalConnection() *testSignalConnection {
+	return &testSignalConnection{
+		requests: make(chan *livekit.SignalRequest, 10),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (c *testSignalConnection) ReadRequest() (*livekit.SignalRequest, int, error) {
+	select {
+	case req := <-c.requests:
+		return req, proto.Size(req), nil
+	case <-c.closed:
+		return nil, 0, io.EOF
+	}
+}
+
+func (c *testSignalConnection) WriteResponse(msg *livekit.SignalResponse) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.responses = append(c.responses, msg)
+	return proto.Size(msg), nil
+}
+
+func (c *testSignalConnection) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+func (c *testSignalConnection) written() []*livekit.SignalResponse {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]*livekit.SignalResponse{}, c.responses...)
+}
+
+func newTestSignalRequest(claims *auth.ClaimGrants) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/rtc", nil)
+	if claims != nil {
+		r = r.WithContext(WithGrants(r.Context(), claims))
+	}
+	return r
+}
+
+func TestServeSignal(t *testing.T) {
+	claims := &auth.ClaimGrants{
+		Identity: "participant",
+		Video:    &auth.VideoGrant{RoomJoin: true, Room: "room"},
+	}
+
+	t.Run("relays signal messages", func(t *testing.T) {
+		requestSink := routing.NewMessageChannel("CO_test", 10)
+		responseSource := routing.NewMessageChannel("CO_test", 10)
+		_ = responseSource.WriteMessage(&livekit.SignalResponse{
+			Message: &livekit.SignalResponse_Join{Join: &livekit.JoinResponse{}},
+		})
+		router := &routingfakes.FakeRouter{}
+		router.GetNodeForRoomReturns(nil, routing.ErrNotFound)
+		router.StartParticipantSignalReturns("CO_test", requestSink, responseSource, nil)
+		s := &RTCService{
+			router:        router,
+			roomAllocator: testRoomAllocator{},
+			store:         NewLocalStore(),
+			connections:   map[SignalConnection]struct{}{},
+		}
+
+		conn := newTestSignalConnection()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			s.serveSignal(httptest.NewRecorder(), newTestSignalRequest(claims), func() (SignalConnection, error) {
+				return conn, nil
+			})
+		}()
+
+		// join response is sent once the connection is upgraded
+		require.Eventually(t, func() bool {
+			return len(conn.written()) == 1
+		}, time.Second, 10*time.Millisecond)
+		require.NotNil(t, conn.written()[0].GetJoin())
+		_, name, _ := router.StartParticipantSignalArgsForCall(0)
+		require.Equal(t, livekit.RoomName("room"), name)
+
+		// requests are answered or passed on to the room
+		conn.requests <- &livekit.SignalRequest{Message: &livekit.SignalRequest_Ping{Ping: 123}}
+		leave := &livekit.SignalRequest{Message: &livekit.SignalRequest_Leave{Leave: &livekit.LeaveRequest{}}}
+		conn.requests <- leave
+		require.Eventually(t, func() bool {
+			written := conn.written()
+			return len(written) == 2 && written[1].GetPong() != 0
+		}, time.Second, 10*time.Millisecond)
+		<-requestSink.ReadChan() // ping
+		require.True(t, proto.Equal(leave, <-requestSink.ReadChan()))
+
+		// responses from the room are sent to the client
+		_ = responseSource.WriteMessage(&livekit.SignalResponse{
+			Message: &livekit.SignalResponse_Leave{Leave: &livekit.LeaveRequest{}},
+		})
+		require.Eventually(t, func() bool {
+			written := conn.written()
+			return len(written) == 3 && written[2].GetLeave() != nil
+		}, time.Second, 10*time.Millisecond)
+
+		// the session ends with the connection
+		_ = conn.Close()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("signal session did not end with the connection")
+		}
+		require.True(t, requestSink.IsClosed())
+		require.Empty(t, s.connections)
+	})
+
+	t.Run("not upgraded without a session", func(t *testing.T) {
+		router := &routingfakes.FakeRouter{}
+		s := &RTCService{router: router, roomAllocator: testRoomAllocator{}, store: NewLocalStore()}
+
+		w := httptest.NewRecorder()
+		upgraded := false
+		s.serveSignal(w, newTestSignalRequest(nil), func() (SignalConnection, error) {
+			upgraded = true
+			return nil, nil
+		})
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.False(t, upgraded)
+		require.Equal(t, 0, router.StartParticipantSignalCallCount())
+	})
+}
@@ -0,0 +1,149 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/recorder"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+// StartRTMPPush forwards the microphone and camera (or screen share) of a participant in a room hosted on this node
+// to an RTMP server. Video has to be published as H.264
+func (r *RoomManager) StartRTMPPush(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	url string,
+) (recorder.RTMPPushInfo, error) {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return recorder.RTMPPushInfo{}, ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return recorder.RTMPPushInfo{}, ErrParticipantNotFound
+	}
+
+	params := recorder.RTMPPushParams{
+		URL:      url,
+		Room:     roomName,
+		Identity: identity,
+		Logger:   logger.GetLogger().WithValues("room", roomName, "participant", identity),
+	}
+	if track := publishedTrackBySource(participant, livekit.TrackSource_MICROPHONE); track != nil {
+		params.AudioTrackID = track.ID()
+		params.Audio = trackReceiverForCodec(track, webrtc.MimeTypeOpus)
+	}
+	video := publishedTrackBySource(participant, livekit.TrackSource_CAMERA)
+	if video == nil {
+		video = publishedTrackBySource(participant, livekit.TrackSource_SCREEN_SHARE)
+	}
+	if video != nil {
+		params.VideoTrackID = video.ID()
+		params.Video = trackReceiverForCodec(video, webrtc.MimeTypeH264)
+	}
+
+	push, err := recorder.NewRTMPPush(params)
+	switch {
+	case errors.Is(err, recorder.ErrInvalidRTMPURL):
+		return recorder.RTMPPushInfo{}, ErrRTMPURLInvalid
+	case errors.Is(err, recorder.ErrNoTracksToPush):
+		return recorder.RTMPPushInfo{}, ErrTrackNotFound
+	case errors.Is(err, recorder.ErrUnsupportedCodec):
+		return recorder.RTMPPushInfo{}, ErrRecordingCodecUnsupported
+	case err != nil:
+		return recorder.RTMPPushInfo{}, err
+	}
+
+	id := push.Info().ID
+	r.lock.Lock()
+	r.rtmpPushes[id] = push
+	r.lock.Unlock()
+
+	onFinished := func(info recorder.RTMPPushInfo) {
+		r.lock.Lock()
+		delete(r.rtmpPushes, info.ID)
+		r.lock.Unlock()
+	}
+	if err = push.Start(ctx, onFinished); err != nil {
+		onFinished(push.Info())
+		return recorder.RTMPPushInfo{}, psrpc.NewError(psrpc.Unavailable, err)
+	}
+	return push.Info(), nil
+}
+
+// StopRTMPPush stops a push and waits for it to end
+func (r *RoomManager) StopRTMPPush(ctx context.Context, pushID string) (recorder.RTMPPushInfo, error) {
+	r.lock.RLock()
+	push := r.rtmpPushes[pushID]
+	r.lock.RUnlock()
+	if push == nil {
+		return recorder.RTMPPushInfo{}, ErrRTMPPushNotFound
+	}
+
+	push.Stop()
+	select {
+	case <-push.Finished():
+	case <-ctx.Done():
+		return recorder.RTMPPushInfo{}, ctx.Err()
+	}
+	return push.Info(), nil
+}
+
+// GetRTMPPush returns a push that is in progress on this node
+func (r *RoomManager) GetRTMPPush(pushID string) (recorder.RTMPPushInfo, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	push := r.rtmpPushes[pushID]
+	if push == nil {
+		return recorder.RTMPPushInfo{}, ErrRTMPPushNotFound
+	}
+	return push.Info(), nil
+}
+
+func publishedTrackBySource(participant types.LocalParticipant, source livekit.TrackSource) types.MediaTrack {
+	for _, track := range participant.GetPublishedTracks() {
+		if track.Source() == source {
+			return track
+		}
+	}
+	return nil
+}
+
+// trackReceiverForCodec prefers the receiver of the given codec when a track is published with several codecs
+func trackReceiverForCodec(track types.MediaTrack, mimeType string) sfu.TrackReceiver {
+	receivers := track.Receivers()
+	if len(receivers) == 0 {
+		return nil
+	}
+	for _, receiver := range receivers {
+		if strings.EqualFold(receiver.Codec().MimeType, mimeType) {
+			return receiver
+		}
+	}
+	return receivers[0].GetPrimaryReceiverForRed()
+}
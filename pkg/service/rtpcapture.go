@@ -0,0 +1,268 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const RTPCapturePrefix = "RC_"
+
+type RTPCaptureFile struct {
+	Layer   int32  `json:"layer"`
+	SSRC    uint32 `json:"ssrc"`
+	Path    string `json:"path"`
+	Packets int    `json:"packets"`
+	Bytes   int64  `json:"bytes"`
+	// set when the capture stopped early, e.g. at the size limit
+	Error string `json:"error,omitempty"`
+}
+
+type RTPCaptureInfo struct {
+	ID        string           `json:"id"`
+	Room      string           `json:"room"`
+	TrackID   string           `json:"track_id"`
+	Format    string           `json:"format"`
+	Payloads  bool             `json:"payloads"`
+	Files     []RTPCaptureFile `json:"files"`
+	StartedAt int64            `json:"started_at"`
+	EndedAt   int64            `json:"ended_at,omitempty"`
+}
+
+type rtpCaptureLayer struct {
+	buff    *buffer.Buffer
+	capture *buffer.Capture
+	file    *os.File
+}
+
+type rtpCapture struct {
+	lock     sync.Mutex
+	info     RTPCaptureInfo
+	layers   []rtpCaptureLayer
+	timer    *time.Timer
+	stopOnce sync.Once
+}
+
+// StartRTPCapture writes the packets received on every layer of a track to files in the debug trace directory,
+// each with a .json file holding the metadata needed by sfu.ReplayCapture. Payloads are left out unless requested
+func (r *RoomManager) StartRTPCapture(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	trackID livekit.TrackID,
+	format buffer.CaptureFormat,
+	payloads bool,
+) (RTPCaptureInfo, error) {
+	if !r.config.Debug.Enabled {
+		return RTPCaptureInfo{}, ErrRTPCaptureDisabled
+	}
+	if format == "" {
+		format = buffer.CaptureFormatRTPDump
+	}
+	if format.Validate() != nil {
+		return RTPCaptureInfo{}, ErrRTPCaptureFormatInvalid
+	}
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return RTPCaptureInfo{}, ErrRoomNotFound
+	}
+
+	var receivers []*sfu.WebRTCReceiver
+	for _, p := range room.GetParticipants() {
+		if track := p.GetPublishedTrack(trackID); track != nil {
+			for _, receiver := range track.Receivers() {
+				if wr, ok := receiver.(*sfu.WebRTCReceiver); ok {
+					receivers = append(receivers, wr)
+				}
+			}
+			break
+		}
+	}
+	if len(receivers) == 0 {
+		return RTPCaptureInfo{}, ErrTrackNotFound
+	}
+
+	dir := r.config.Debug.TraceDirectory
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	c := &rtpCapture{
+		info: RTPCaptureInfo{
+			ID:        utils.NewGuid(RTPCapturePrefix),
+			Room:      string(roomName),
+			TrackID:   string(trackID),
+			Format:    string(format),
+			Payloads:  payloads,
+			StartedAt: time.Now().Unix(),
+		},
+	}
+	for _, wr := range receivers {
+		codec := wr.Codec()
+		for layer, buff := range wr.Buffers() {
+			meta := &buffer.CaptureMetadata{
+				SSRC:             wr.SSRC(int(layer)),
+				Layer:            layer,
+				Codec:            codec,
+				HeaderExtensions: wr.HeaderExtensions(),
+			}
+			_, codecName, _ := strings.Cut(strings.ToLower(codec.MimeType), "/")
+			name := fmt.Sprintf("%s-%s-%d%s", c.info.ID, codecName, layer, format.Extension())
+			cl, err := newRTPCaptureLayer(filepath.Join(dir, name), buff, meta, buffer.CaptureParams{
+				Format:   format,
+				Payloads: payloads,
+				MaxBytes: r.config.Debug.MaxRTPCaptureSize,
+			})
+			if err != nil {
+				c.stop()
+				for _, file := range c.info.Files {
+					_ = os.Remove(file.Path)
+					_ = os.Remove(file.Path + ".json")
+				}
+				return RTPCaptureInfo{}, err
+			}
+			c.layers = append(c.layers, cl)
+			c.info.Files = append(c.info.Files, RTPCaptureFile{
+				Layer: layer,
+				SSRC:  meta.SSRC,
+				Path:  cl.file.Name(),
+			})
+		}
+	}
+
+	r.lock.Lock()
+	r.rtpCaptures[c.info.ID] = c
+	r.lock.Unlock()
+
+	if maxDuration := r.config.Debug.MaxCaptureDuration; maxDuration > 0 {
+		c.timer = time.AfterFunc(maxDuration, func() {
+			_, _ = r.StopRTPCapture(c.info.ID)
+		})
+	}
+	for _, cl := range c.layers {
+		cl.buff.SetCapture(cl.capture)
+	}
+	logger.Infow("started rtp capture", "room", roomName, "trackID", trackID, "captureID", c.info.ID, "format", format, "payloads", payloads)
+	return c.info, nil
+}
+
+func newRTPCaptureLayer(path string, buff *buffer.Buffer, meta *buffer.CaptureMetadata, params buffer.CaptureParams) (rtpCaptureLayer, error) {
+	metaJSON, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return rtpCaptureLayer{}, err
+	}
+	if err = os.WriteFile(path+".json", metaJSON, 0644); err != nil {
+		return rtpCaptureLayer{}, err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return rtpCaptureLayer{}, err
+	}
+	capture, err := buffer.NewCapture(f, params)
+	if err != nil {
+		_ = f.Close()
+		return rtpCaptureLayer{}, err
+	}
+	return rtpCaptureLayer{
+		buff:    buff,
+		capture: capture,
+		file:    f,
+	}, nil
+}
+
+// StopRTPCapture detaches a capture from the track's buffers and closes its files
+func (r *RoomManager) StopRTPCapture(captureID string) (RTPCaptureInfo, error) {
+	r.lock.Lock()
+	c := r.rtpCaptures[captureID]
+	delete(r.rtpCaptures, captureID)
+	r.lock.Unlock()
+	if c == nil {
+		return RTPCaptureInfo{}, ErrRTPCaptureNotFound
+	}
+
+	info := c.stop()
+	logger.Infow("stopped rtp capture", "room", info.Room, "trackID", info.TrackID, "captureID", info.ID)
+	return info, nil
+}
+
+// GetRTPCapture returns a capture that is in progress on this node
+func (r *RoomManager) GetRTPCapture(captureID string) (RTPCaptureInfo, error) {
+	r.lock.RLock()
+	c := r.rtpCaptures[captureID]
+	r.lock.RUnlock()
+	if c == nil {
+		return RTPCaptureInfo{}, ErrRTPCaptureNotFound
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.info, nil
+}
+
+func (r *RoomManager) stopRTPCaptures() {
+	r.lock.Lock()
+	captures := r.rtpCaptures
+	r.rtpCaptures = make(map[string]*rtpCapture)
+	r.lock.Unlock()
+
+	for _, c := range captures {
+		c.stop()
+	}
+}
+
+func (c *rtpCapture) stop() RTPCaptureInfo {
+	c.stopOnce.Do(func() {
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		for i, cl := range c.layers {
+			cl.buff.SetCapture(nil)
+			err := cl.capture.Flush()
+			if cerr := cl.file.Close(); err == nil {
+				err = cerr
+			}
+			packets, bytes, captureErr := cl.capture.Stats()
+			if captureErr != nil {
+				err = captureErr
+			}
+			c.info.Files[i].Packets = packets
+			c.info.Files[i].Bytes = bytes
+			if err != nil {
+				c.info.Files[i].Error = err.Error()
+			}
+		}
+		c.info.EndedAt = time.Now().Unix()
+	})
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.info
+}
@@ -0,0 +1,308 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/rtsp"
+)
+
+const (
+	RTSPIngestPrefix = "RI_"
+
+	RTSPIngestConnecting   = "connecting"
+	RTSPIngestPublishing   = "publishing"
+	RTSPIngestReconnecting = "reconnecting"
+	RTSPIngestEnded        = "ended"
+
+	h264HighProfileIDC = 0x64
+)
+
+type RTSPIngestInfo struct {
+	ID          string   `json:"id"`
+	Room        string   `json:"room"`
+	Identity    string   `json:"identity"`
+	Name        string   `json:"name,omitempty"`
+	URL         string   `json:"url"`
+	Repacketize bool     `json:"repacketize"`
+	Tracks      []string `json:"tracks"`
+	State       string   `json:"state"`
+	Error       string   `json:"error,omitempty"`
+	StartedAt   int64    `json:"started_at"`
+	EndedAt     int64    `json:"ended_at,omitempty"`
+}
+
+type RTSPIngestParams struct {
+	Room        livekit.RoomName
+	Identity    livekit.ParticipantIdentity
+	Name        livekit.ParticipantName
+	URL         string
+	Repacketize bool
+}
+
+// RTSPIngestManager pulls streams from IP cameras and publishes them into rooms as participants, signaled the
+// same way as WHIP sessions. Camera failures are retried without the participant leaving the room.
+type RTSPIngestManager struct {
+	rtcService *RTCService
+	sessions   *httpSignalSessions
+
+	lock    sync.RWMutex
+	ingests map[string]*rtspIngest
+}
+
+func NewRTSPIngestManager(rtcService *RTCService) *RTSPIngestManager {
+	return &RTSPIngestManager{
+		rtcService: rtcService,
+		sessions:   newHTTPSignalSessions(),
+		ingests:    make(map[string]*rtspIngest),
+	}
+}
+
+type rtspIngest struct {
+	lock   sync.Mutex
+	info   RTSPIngestInfo
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Create connects to the camera and joins the room, it returns once the participant is publishing
+func (m *RTSPIngestManager) Create(ctx context.Context, params RTSPIngestParams) (RTSPIngestInfo, error) {
+	id := utils.NewGuid(RTSPIngestPrefix)
+	if params.Identity == "" {
+		params.Identity = livekit.ParticipantIdentity(id)
+	}
+	l := logger.GetLogger().WithValues("room", params.Room, "participant", params.Identity, "ingestID", id)
+
+	source, err := rtsp.NewSource(ctx, rtsp.SourceParams{
+		URL:         params.URL,
+		Repacketize: params.Repacketize,
+		Logger:      l,
+	})
+	switch {
+	case errors.Is(err, rtsp.ErrInvalidURL):
+		return RTSPIngestInfo{}, ErrRTSPURLInvalid
+	case errors.Is(err, rtsp.ErrNoSupportedMedia):
+		return RTSPIngestInfo{}, ErrRTSPMediaUnsupported
+	case err != nil:
+		return RTSPIngestInfo{}, psrpc.NewError(psrpc.Unavailable, err)
+	}
+
+	ingest := &rtspIngest{
+		info: RTSPIngestInfo{
+			ID:          id,
+			Room:        string(params.Room),
+			Identity:    string(params.Identity),
+			Name:        string(params.Name),
+			URL:         redactRTSPURL(params.URL),
+			Repacketize: params.Repacketize,
+			State:       RTSPIngestConnecting,
+			StartedAt:   time.Now().UnixNano(),
+		},
+		done: make(chan struct{}),
+	}
+	for _, t := range source.Tracks() {
+		ingest.info.Tracks = append(ingest.info.Tracks, t.MimeType)
+	}
+
+	// the ingest outlives the request that created it
+	runCtx, cancel := context.WithCancel(context.Background())
+	ingest.cancel = cancel
+	publisher, writers, err := m.publish(runCtx, id, params, source)
+	if err != nil {
+		cancel()
+		source.Close()
+		return RTSPIngestInfo{}, err
+	}
+	ingest.setState(RTSPIngestPublishing, nil)
+
+	m.lock.Lock()
+	m.ingests[id] = ingest
+	m.lock.Unlock()
+
+	go func() {
+		<-publisher.Done()
+		cancel()
+	}()
+	go func() {
+		defer func() {
+			publisher.Close()
+			ingest.setState(RTSPIngestEnded, nil)
+			close(ingest.done)
+		}()
+		source.Run(runCtx, func(t *rtsp.SourceTrack, pkt *rtp.Packet) {
+			if ingest.state() == RTSPIngestReconnecting {
+				ingest.setState(RTSPIngestPublishing, nil)
+			}
+			if err := writers[t].WriteRTP(pkt); err != nil && !errors.Is(err, webrtc.ErrConnectionClosed) {
+				l.Debugw("could not write RTSP packet", "error", err)
+			}
+		}, func(err error) {
+			ingest.setState(RTSPIngestReconnecting, err)
+		})
+	}()
+
+	l.Infow("RTSP ingest started", "tracks", ingest.info.Tracks, "repacketize", params.Repacketize)
+	return ingest.getInfo(), nil
+}
+
+// publish creates the tracks for the camera's media and joins the room with them
+func (m *RTSPIngestManager) publish(
+	ctx context.Context,
+	id string,
+	params RTSPIngestParams,
+	source *rtsp.Source,
+) (*localPublisher, map[*rtsp.SourceTrack]*webrtc.TrackLocalStaticRTP, error) {
+	writers := make(map[*rtsp.SourceTrack]*webrtc.TrackLocalStaticRTP)
+	var tracks []localTrack
+	for _, t := range source.Tracks() {
+		capability := webrtc.RTPCodecCapability{MimeType: t.MimeType, ClockRate: t.ClockRate}
+		trackSource := livekit.TrackSource_MICROPHONE
+		if t.Kind == "video" {
+			capability.SDPFmtpLine = h264Fmtp(t.Fmtp)
+			trackSource = livekit.TrackSource_CAMERA
+		} else {
+			capability.Channels = 2
+		}
+
+		track, err := webrtc.NewTrackLocalStaticRTP(capability, utils.NewGuid(utils.TrackPrefix), id)
+		if err != nil {
+			return nil, nil, err
+		}
+		writers[t] = track
+		tracks = append(tracks, localTrack{track: track, source: trackSource})
+	}
+
+	pi := localParticipantInit(params.Room, params.Identity, params.Name, false)
+	publisher, err := publishLocalTracks(ctx, m.rtcService, m.sessions, params.Room, pi, tracks)
+	if err != nil {
+		return nil, nil, err
+	}
+	return publisher, writers, nil
+}
+
+// List returns the ingests publishing into the room, or all when the room is empty
+func (m *RTSPIngestManager) List(roomName livekit.RoomName) []RTSPIngestInfo {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	infos := make([]RTSPIngestInfo, 0, len(m.ingests))
+	for _, ingest := range m.ingests {
+		info := ingest.getInfo()
+		if roomName == "" || info.Room == string(roomName) {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+func (m *RTSPIngestManager) Get(ingestID string) (RTSPIngestInfo, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	ingest := m.ingests[ingestID]
+	if ingest == nil {
+		return RTSPIngestInfo{}, ErrRTSPIngestNotFound
+	}
+	return ingest.getInfo(), nil
+}
+
+// Delete stops an ingest, its participant leaves the room
+func (m *RTSPIngestManager) Delete(ctx context.Context, ingestID string) (RTSPIngestInfo, error) {
+	m.lock.Lock()
+	ingest := m.ingests[ingestID]
+	delete(m.ingests, ingestID)
+	m.lock.Unlock()
+	if ingest == nil {
+		return RTSPIngestInfo{}, ErrRTSPIngestNotFound
+	}
+
+	ingest.cancel()
+	select {
+	case <-ingest.done:
+	case <-ctx.Done():
+		return RTSPIngestInfo{}, ctx.Err()
+	}
+	return ingest.getInfo(), nil
+}
+
+func (i *rtspIngest) state() string {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.info.State
+}
+
+func (i *rtspIngest) setState(state string, err error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.info.State == RTSPIngestEnded {
+		return
+	}
+	i.info.State = state
+	i.info.Error = ""
+	if err != nil {
+		i.info.Error = err.Error()
+	}
+	if state == RTSPIngestEnded {
+		i.info.EndedAt = time.Now().UnixNano()
+	}
+}
+
+func (i *rtspIngest) getInfo() RTSPIngestInfo {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.info
+}
+
+// h264Fmtp declares the server's high profile for high profile cameras and constrained baseline otherwise
+func h264Fmtp(cameraFmtp string) string {
+	for _, param := range strings.Split(cameraFmtp, ";") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(param), "profile-level-id=")
+		if !ok || len(value) != 6 {
+			continue
+		}
+		if profileIDC, err := strconv.ParseUint(value[:2], 16, 8); err == nil && profileIDC == h264HighProfileIDC {
+			return "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640032"
+		}
+	}
+	return "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f"
+}
+
+// redactRTSPURL removes the camera's password
+func redactRTSPURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if u.User != nil {
+		u.User = url.User(u.User.Username())
+	}
+	return u.String()
+}
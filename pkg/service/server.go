@@ -29,6 +29,8 @@ import (
 
 	"github.com/pion/turn/v2"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/webtransport-go"
 	"github.com/rs/cors"
 	"github.com/twitchtv/twirp"
 	"github.com/urfave/negroni/v3"
@@ -36,6 +38,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/mixer"
 	"github.com/livekit/livekit-server/pkg/routing"
 	sutils "github.com/livekit/livekit-server/pkg/utils"
 	"github.com/livekit/livekit-server/version"
@@ -48,10 +51,16 @@ type LivekitServer struct {
 	config       *config.Config
 	ioService    *IOInfoService
 	rtcService   *RTCService
+	adminService *AdminService
 	httpServer   *http.Server
 	promServer   *http.Server
+	wtServer     *webtransport.Server
 	router       routing.Router
 	roomManager  *RoomManager
+	sipMix       *SIPMixManager
+	sipCalls     *SIPCallManager
+	composites   *AudioCompositeManager
+	egressStates *egressStateRouter
 	signalServer *SignalServer
 	turnServer   *turn.Server
 	currentNode  routing.LocalNode
@@ -66,9 +75,15 @@ func NewLivekitServer(conf *config.Config,
 	ingressService *IngressService,
 	ioService *IOInfoService,
 	rtcService *RTCService,
+	adminService *AdminService,
+	whipService *WHIPService,
+	whepService *WHEPService,
 	keyProvider auth.KeyProvider,
 	router routing.Router,
 	roomManager *RoomManager,
+	sipMix *SIPMixManager,
+	sipCalls *SIPCallManager,
+	composites *AudioCompositeManager,
 	signalServer *SignalServer,
 	turnServer *turn.Server,
 	currentNode routing.LocalNode,
@@ -77,8 +92,12 @@ func NewLivekitServer(conf *config.Config,
 		config:       conf,
 		ioService:    ioService,
 		rtcService:   rtcService,
+		adminService: adminService,
 		router:       router,
 		roomManager:  roomManager,
+		sipMix:       sipMix,
+		sipCalls:     sipCalls,
+		composites:   composites,
 		signalServer: signalServer,
 		// turn server starts automatically
 		turnServer:  turnServer,
@@ -86,21 +105,49 @@ func NewLivekitServer(conf *config.Config,
 		closedChan:  make(chan struct{}),
 	}
 
+	// reflect egress start/stop in room recording state, on the node hosting the room
+	if s.egressStates, err = newEgressStateRouter(router, currentNode, ioService.bus, roomManager.UpdateEgressState); err != nil {
+		return nil, err
+	}
+	ioService.OnEgressUpdated(s.egressStates.UpdateEgressState)
+
+	originValidator := NewOriginValidator(conf.CORS)
 	middlewares := []negroni.Handler{
 		// always first
 		negroni.NewRecovery(),
-		// CORS is allowed, we rely on token authentication to prevent improper use
+		// CORS is allowed from any origin unless restricted, we rely on token authentication to prevent improper use
 		cors.New(cors.Options{
 			AllowOriginFunc: func(origin string) bool {
-				return true
+				allowed, _ := originValidator.Allowed(origin)
+				return allowed
 			},
 			AllowedHeaders: []string{"*"},
+			// WHIP/WHEP sessions are updated and ended with PATCH and DELETE
+			AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodHead, http.MethodPatch, http.MethodDelete},
+			// WHIP/WHEP clients need the session URL
+			ExposedHeaders: []string{"Location"},
 			// allow preflight to be cached for a day
 			MaxAge: 86400,
 		}),
 	}
+	if originValidator != nil {
+		middlewares = append(middlewares, originValidator)
+	}
+	// requests are limited per IP before they are authenticated, and per API key after
+	rateLimitMiddleware := NewRateLimitMiddleware(conf.APIRateLimit)
+	if rateLimitMiddleware != nil {
+		middlewares = append(middlewares, rateLimitMiddleware)
+	}
+	var authMiddleware negroni.Handler
 	if keyProvider != nil {
-		middlewares = append(middlewares, NewAPIKeyAuthMiddleware(keyProvider))
+		apiKeyMiddleware := NewAPIKeyAuthMiddleware(keyProvider)
+		apiKeyMiddleware.SetResumeGrace(conf.Auth.Refresh.ResumeGrace)
+		apiKeyMiddleware.SetTokenRevocations(roomManager.roomStore)
+		authMiddleware = apiKeyMiddleware
+		middlewares = append(middlewares, authMiddleware)
+	}
+	if rateLimitMiddleware != nil {
+		middlewares = append(middlewares, rateLimitMiddleware.APIKeyLimiter())
 	}
 
 	twirpLoggingHook := TwirpLogger(logger.GetLogger().WithComponent(sutils.ComponentAPI))
@@ -126,12 +173,40 @@ func NewLivekitServer(conf *config.Config,
 	mux.Handle(ingressServer.PathPrefix(), ingressServer)
 	mux.Handle("/rtc", rtcService)
 	mux.HandleFunc("/rtc/validate", rtcService.Validate)
+	mux.Handle(adminService.PathPrefix(), adminService)
+	if keyProvider != nil {
+		introspectionService := NewTokenIntrospectionService(keyProvider)
+		mux.Handle(introspectionService.PathPrefix(), introspectionService)
+	}
+	mux.Handle(whipService.PathPrefix(), whipService)
+	mux.Handle(whipService.PathPrefix()+"/", whipService)
+	mux.Handle(whepService.PathPrefix(), whepService)
+	mux.Handle(whepService.PathPrefix()+"/", whepService)
+	hlsService := NewHLSService(roomManager)
+	mux.Handle(hlsService.PathPrefix(), hlsService)
+	diagnosticsService := NewClientDiagnosticsService(roomManager)
+	mux.Handle(diagnosticsService.PathPrefix(), diagnosticsService)
+	if debugService := NewDebugService(conf.Debug); debugService != nil {
+		mux.Handle(debugService.PathPrefix(), debugService)
+	}
 	mux.HandleFunc("/", s.defaultHandler)
 
 	s.httpServer = &http.Server{
 		Handler: configureMiddlewares(mux, middlewares...),
 	}
 
+	if conf.WebTransport.Port > 0 {
+		var bindAddress string
+		if len(conf.BindAddresses) > 0 {
+			bindAddress = conf.BindAddresses[0]
+		}
+		s.wtServer = NewWebTransportServer(
+			net.JoinHostPort(bindAddress, strconv.Itoa(int(conf.WebTransport.Port))),
+			rtcService,
+			authMiddleware,
+		)
+	}
+
 	if conf.PrometheusPort > 0 {
 		s.promServer = &http.Server{
 			Handler: promhttp.Handler(),
@@ -153,6 +228,22 @@ func (s *LivekitServer) Node() *livekit.Node {
 	return s.currentNode
 }
 
+// SetAudioCodec enables the features that decode and encode Opus on the server, the server does not include a codec
+func (s *LivekitServer) SetAudioCodec(codec mixer.Codec) error {
+	s.composites.SetCodec(codec)
+	if s.config.Audio.SIPMix.IdentityPrefix != "" {
+		if err := s.sipMix.Start(codec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetMP3Encoder enables MP3 files for built-in audio composites, which require an audio codec as well
+func (s *LivekitServer) SetMP3Encoder(newEncoder MP3EncoderFactory) {
+	s.composites.SetMP3Encoder(newEncoder)
+}
+
 func (s *LivekitServer) HTTPPort() int {
 	return int(s.config.Port)
 }
@@ -230,6 +321,9 @@ func (s *LivekitServer) Start() error {
 	if s.config.PrometheusPort != 0 {
 		values = append(values, "portPrometheus", s.config.PrometheusPort)
 	}
+	if s.wtServer != nil {
+		values = append(values, "portWebTransport", s.config.WebTransport.Port)
+	}
 	if s.config.Region != "" {
 		values = append(values, "region", s.config.Region)
 	}
@@ -260,6 +354,15 @@ func (s *LivekitServer) Start() error {
 		}
 	}()
 
+	if s.wtServer != nil {
+		go func() {
+			err := s.wtServer.ListenAndServeTLS(s.config.WebTransport.CertFile, s.config.WebTransport.KeyFile)
+			if err != nil && err != http.ErrServerClosed && err != quic.ErrServerClosed {
+				logger.Errorw("could not start WebTransport server", err)
+			}
+		}()
+	}
+
 	go s.backgroundWorker()
 
 	// give time for Serve goroutine to start
@@ -273,14 +376,22 @@ func (s *LivekitServer) Start() error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 	_ = s.httpServer.Shutdown(ctx)
+	if s.wtServer != nil {
+		_ = s.wtServer.Close()
+	}
 
 	if s.turnServer != nil {
 		_ = s.turnServer.Close()
 	}
 
+	s.composites.StopAll()
+	s.sipCalls.Stop()
+	s.sipMix.Stop()
 	s.roomManager.Stop()
+	s.adminService.Stop()
 	s.signalServer.Stop()
 	s.ioService.Stop()
+	s.egressStates.Stop()
 
 	close(s.closedChan)
 	return nil
@@ -352,6 +463,11 @@ func (s *LivekitServer) healthCheck(w http.ResponseWriter, _ *http.Request) {
 		_, _ = w.Write([]byte(fmt.Sprintf("Not Ready\nNode Updated At %s", updatedAt)))
 		return
 	}
+	if ready, report := s.roomManager.ICEServersReady(); !ready {
+		w.WriteHeader(http.StatusNotAcceptable)
+		_, _ = w.Write([]byte(fmt.Sprintf("Not Ready\nICE servers unhealthy\n%s", report)))
+		return
+	}
 
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte("OK"))
@@ -361,12 +477,16 @@ func (s *LivekitServer) healthCheck(w http.ResponseWriter, _ *http.Request) {
 func (s *LivekitServer) backgroundWorker() {
 	roomTicker := time.NewTicker(1 * time.Second)
 	defer roomTicker.Stop()
+	scheduleTicker := time.NewTicker(roomScheduleCheckInterval)
+	defer scheduleTicker.Stop()
 	for {
 		select {
 		case <-s.doneChan:
 			return
 		case <-roomTicker.C:
 			s.roomManager.CloseIdleRooms()
+		case <-scheduleTicker.C:
+			s.roomManager.CheckRoomSchedules()
 		}
 	}
 }
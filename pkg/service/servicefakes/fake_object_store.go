@@ -6,11 +6,25 @@ import (
 	"sync"
 	"time"
 
+	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/service"
 	"github.com/livekit/protocol/livekit"
 )
 
 type FakeObjectStore struct {
+	AppendRoomJournalStub        func(context.Context, livekit.RoomName, *rtc.JournalEntry) error
+	appendRoomJournalMutex       sync.RWMutex
+	appendRoomJournalArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 *rtc.JournalEntry
+	}
+	appendRoomJournalReturns struct {
+		result1 error
+	}
+	appendRoomJournalReturnsOnCall map[int]struct {
+		result1 error
+	}
 	DeleteParticipantStub        func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) error
 	deleteParticipantMutex       sync.RWMutex
 	deleteParticipantArgsForCall []struct {
@@ -24,6 +38,19 @@ type FakeObjectStore struct {
 	deleteParticipantReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DeleteParticipantBanStub        func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) error
+	deleteParticipantBanMutex       sync.RWMutex
+	deleteParticipantBanArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}
+	deleteParticipantBanReturns struct {
+		result1 error
+	}
+	deleteParticipantBanReturnsOnCall map[int]struct {
+		result1 error
+	}
 	DeleteRoomStub        func(context.Context, livekit.RoomName) error
 	deleteRoomMutex       sync.RWMutex
 	deleteRoomArgsForCall []struct {
@@ -36,6 +63,97 @@ type FakeObjectStore struct {
 	deleteRoomReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DeleteRoomLockedStub        func(context.Context, livekit.RoomName) error
+	deleteRoomLockedMutex       sync.RWMutex
+	deleteRoomLockedArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	deleteRoomLockedReturns struct {
+		result1 error
+	}
+	deleteRoomLockedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteRoomScheduleStub        func(context.Context, livekit.RoomName) error
+	deleteRoomScheduleMutex       sync.RWMutex
+	deleteRoomScheduleArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	deleteRoomScheduleReturns struct {
+		result1 error
+	}
+	deleteRoomScheduleReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteTokenRevocationStub        func(context.Context, string) error
+	deleteTokenRevocationMutex       sync.RWMutex
+	deleteTokenRevocationArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	deleteTokenRevocationReturns struct {
+		result1 error
+	}
+	deleteTokenRevocationReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteTokenRolloverStub        func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) error
+	deleteTokenRolloverMutex       sync.RWMutex
+	deleteTokenRolloverArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}
+	deleteTokenRolloverReturns struct {
+		result1 error
+	}
+	deleteTokenRolloverReturnsOnCall map[int]struct {
+		result1 error
+	}
+	IsRoomLockedStub        func(context.Context, livekit.RoomName) (bool, error)
+	isRoomLockedMutex       sync.RWMutex
+	isRoomLockedArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	isRoomLockedReturns struct {
+		result1 bool
+		result2 error
+	}
+	isRoomLockedReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	IsTokenRevokedStub        func(context.Context, string) (bool, error)
+	isTokenRevokedMutex       sync.RWMutex
+	isTokenRevokedArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	isTokenRevokedReturns struct {
+		result1 bool
+		result2 error
+	}
+	isTokenRevokedReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	ListParticipantBansStub        func(context.Context, livekit.RoomName) ([]*service.ParticipantBan, error)
+	listParticipantBansMutex       sync.RWMutex
+	listParticipantBansArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	listParticipantBansReturns struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}
+	listParticipantBansReturnsOnCall map[int]struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}
 	ListParticipantsStub        func(context.Context, livekit.RoomName) ([]*livekit.ParticipantInfo, error)
 	listParticipantsMutex       sync.RWMutex
 	listParticipantsArgsForCall []struct {
@@ -50,6 +168,19 @@ type FakeObjectStore struct {
 		result1 []*livekit.ParticipantInfo
 		result2 error
 	}
+	ListRoomSchedulesStub        func(context.Context) ([]*service.RoomSchedule, error)
+	listRoomSchedulesMutex       sync.RWMutex
+	listRoomSchedulesArgsForCall []struct {
+		arg1 context.Context
+	}
+	listRoomSchedulesReturns struct {
+		result1 []*service.RoomSchedule
+		result2 error
+	}
+	listRoomSchedulesReturnsOnCall map[int]struct {
+		result1 []*service.RoomSchedule
+		result2 error
+	}
 	ListRoomsStub        func(context.Context, []livekit.RoomName) ([]*livekit.Room, error)
 	listRoomsMutex       sync.RWMutex
 	listRoomsArgsForCall []struct {
@@ -96,6 +227,55 @@ type FakeObjectStore struct {
 		result2 *livekit.RoomInternal
 		result3 error
 	}
+	LoadRoomJournalStub        func(context.Context, livekit.RoomName, uint64, int) ([]rtc.JournalEntry, uint64, bool, error)
+	loadRoomJournalMutex       sync.RWMutex
+	loadRoomJournalArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 uint64
+		arg4 int
+	}
+	loadRoomJournalReturns struct {
+		result1 []rtc.JournalEntry
+		result2 uint64
+		result3 bool
+		result4 error
+	}
+	loadRoomJournalReturnsOnCall map[int]struct {
+		result1 []rtc.JournalEntry
+		result2 uint64
+		result3 bool
+		result4 error
+	}
+	LoadRoomScheduleStub        func(context.Context, livekit.RoomName) (*service.RoomSchedule, error)
+	loadRoomScheduleMutex       sync.RWMutex
+	loadRoomScheduleArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	loadRoomScheduleReturns struct {
+		result1 *service.RoomSchedule
+		result2 error
+	}
+	loadRoomScheduleReturnsOnCall map[int]struct {
+		result1 *service.RoomSchedule
+		result2 error
+	}
+	LoadTokenRolloverStub        func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) (*service.TokenRollover, error)
+	loadTokenRolloverMutex       sync.RWMutex
+	loadTokenRolloverArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}
+	loadTokenRolloverReturns struct {
+		result1 *service.TokenRollover
+		result2 error
+	}
+	loadTokenRolloverReturnsOnCall map[int]struct {
+		result1 *service.TokenRollover
+		result2 error
+	}
 	LockRoomStub        func(context.Context, livekit.RoomName, time.Duration) (string, error)
 	lockRoomMutex       sync.RWMutex
 	lockRoomArgsForCall []struct {
@@ -124,6 +304,18 @@ type FakeObjectStore struct {
 	storeParticipantReturnsOnCall map[int]struct {
 		result1 error
 	}
+	StoreParticipantBanStub        func(context.Context, *service.ParticipantBan) error
+	storeParticipantBanMutex       sync.RWMutex
+	storeParticipantBanArgsForCall []struct {
+		arg1 context.Context
+		arg2 *service.ParticipantBan
+	}
+	storeParticipantBanReturns struct {
+		result1 error
+	}
+	storeParticipantBanReturnsOnCall map[int]struct {
+		result1 error
+	}
 	StoreRoomStub        func(context.Context, *livekit.Room, *livekit.RoomInternal) error
 	storeRoomMutex       sync.RWMutex
 	storeRoomArgsForCall []struct {
@@ -137,6 +329,54 @@ type FakeObjectStore struct {
 	storeRoomReturnsOnCall map[int]struct {
 		result1 error
 	}
+	StoreRoomLockedStub        func(context.Context, livekit.RoomName) error
+	storeRoomLockedMutex       sync.RWMutex
+	storeRoomLockedArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	storeRoomLockedReturns struct {
+		result1 error
+	}
+	storeRoomLockedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StoreRoomScheduleStub        func(context.Context, *service.RoomSchedule) error
+	storeRoomScheduleMutex       sync.RWMutex
+	storeRoomScheduleArgsForCall []struct {
+		arg1 context.Context
+		arg2 *service.RoomSchedule
+	}
+	storeRoomScheduleReturns struct {
+		result1 error
+	}
+	storeRoomScheduleReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StoreTokenRevocationStub        func(context.Context, *service.TokenRevocation) error
+	storeTokenRevocationMutex       sync.RWMutex
+	storeTokenRevocationArgsForCall []struct {
+		arg1 context.Context
+		arg2 *service.TokenRevocation
+	}
+	storeTokenRevocationReturns struct {
+		result1 error
+	}
+	storeTokenRevocationReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StoreTokenRolloverStub        func(context.Context, *service.TokenRollover) error
+	storeTokenRolloverMutex       sync.RWMutex
+	storeTokenRolloverArgsForCall []struct {
+		arg1 context.Context
+		arg2 *service.TokenRollover
+	}
+	storeTokenRolloverReturns struct {
+		result1 error
+	}
+	storeTokenRolloverReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UnlockRoomStub        func(context.Context, livekit.RoomName, string) error
 	unlockRoomMutex       sync.RWMutex
 	unlockRoomArgsForCall []struct {
@@ -154,6 +394,69 @@ type FakeObjectStore struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeObjectStore) AppendRoomJournal(arg1 context.Context, arg2 livekit.RoomName, arg3 *rtc.JournalEntry) error {
+	fake.appendRoomJournalMutex.Lock()
+	ret, specificReturn := fake.appendRoomJournalReturnsOnCall[len(fake.appendRoomJournalArgsForCall)]
+	fake.appendRoomJournalArgsForCall = append(fake.appendRoomJournalArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 *rtc.JournalEntry
+	}{arg1, arg2, arg3})
+	stub := fake.AppendRoomJournalStub
+	fakeReturns := fake.appendRoomJournalReturns
+	fake.recordInvocation("AppendRoomJournal", []interface{}{arg1, arg2, arg3})
+	fake.appendRoomJournalMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) AppendRoomJournalCallCount() int {
+	fake.appendRoomJournalMutex.RLock()
+	defer fake.appendRoomJournalMutex.RUnlock()
+	return len(fake.appendRoomJournalArgsForCall)
+}
+
+func (fake *FakeObjectStore) AppendRoomJournalCalls(stub func(context.Context, livekit.RoomName, *rtc.JournalEntry) error) {
+	fake.appendRoomJournalMutex.Lock()
+	defer fake.appendRoomJournalMutex.Unlock()
+	fake.AppendRoomJournalStub = stub
+}
+
+func (fake *FakeObjectStore) AppendRoomJournalArgsForCall(i int) (context.Context, livekit.RoomName, *rtc.JournalEntry) {
+	fake.appendRoomJournalMutex.RLock()
+	defer fake.appendRoomJournalMutex.RUnlock()
+	argsForCall := fake.appendRoomJournalArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeObjectStore) AppendRoomJournalReturns(result1 error) {
+	fake.appendRoomJournalMutex.Lock()
+	defer fake.appendRoomJournalMutex.Unlock()
+	fake.AppendRoomJournalStub = nil
+	fake.appendRoomJournalReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) AppendRoomJournalReturnsOnCall(i int, result1 error) {
+	fake.appendRoomJournalMutex.Lock()
+	defer fake.appendRoomJournalMutex.Unlock()
+	fake.AppendRoomJournalStub = nil
+	if fake.appendRoomJournalReturnsOnCall == nil {
+		fake.appendRoomJournalReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.appendRoomJournalReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeObjectStore) DeleteParticipant(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.ParticipantIdentity) error {
 	fake.deleteParticipantMutex.Lock()
 	ret, specificReturn := fake.deleteParticipantReturnsOnCall[len(fake.deleteParticipantArgsForCall)]
@@ -217,6 +520,69 @@ func (fake *FakeObjectStore) DeleteParticipantReturnsOnCall(i int, result1 error
 	}{result1}
 }
 
+func (fake *FakeObjectStore) DeleteParticipantBan(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.ParticipantIdentity) error {
+	fake.deleteParticipantBanMutex.Lock()
+	ret, specificReturn := fake.deleteParticipantBanReturnsOnCall[len(fake.deleteParticipantBanArgsForCall)]
+	fake.deleteParticipantBanArgsForCall = append(fake.deleteParticipantBanArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteParticipantBanStub
+	fakeReturns := fake.deleteParticipantBanReturns
+	fake.recordInvocation("DeleteParticipantBan", []interface{}{arg1, arg2, arg3})
+	fake.deleteParticipantBanMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) DeleteParticipantBanCallCount() int {
+	fake.deleteParticipantBanMutex.RLock()
+	defer fake.deleteParticipantBanMutex.RUnlock()
+	return len(fake.deleteParticipantBanArgsForCall)
+}
+
+func (fake *FakeObjectStore) DeleteParticipantBanCalls(stub func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) error) {
+	fake.deleteParticipantBanMutex.Lock()
+	defer fake.deleteParticipantBanMutex.Unlock()
+	fake.DeleteParticipantBanStub = stub
+}
+
+func (fake *FakeObjectStore) DeleteParticipantBanArgsForCall(i int) (context.Context, livekit.RoomName, livekit.ParticipantIdentity) {
+	fake.deleteParticipantBanMutex.RLock()
+	defer fake.deleteParticipantBanMutex.RUnlock()
+	argsForCall := fake.deleteParticipantBanArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeObjectStore) DeleteParticipantBanReturns(result1 error) {
+	fake.deleteParticipantBanMutex.Lock()
+	defer fake.deleteParticipantBanMutex.Unlock()
+	fake.DeleteParticipantBanStub = nil
+	fake.deleteParticipantBanReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) DeleteParticipantBanReturnsOnCall(i int, result1 error) {
+	fake.deleteParticipantBanMutex.Lock()
+	defer fake.deleteParticipantBanMutex.Unlock()
+	fake.DeleteParticipantBanStub = nil
+	if fake.deleteParticipantBanReturnsOnCall == nil {
+		fake.deleteParticipantBanReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteParticipantBanReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeObjectStore) DeleteRoom(arg1 context.Context, arg2 livekit.RoomName) error {
 	fake.deleteRoomMutex.Lock()
 	ret, specificReturn := fake.deleteRoomReturnsOnCall[len(fake.deleteRoomArgsForCall)]
@@ -279,6 +645,450 @@ func (fake *FakeObjectStore) DeleteRoomReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeObjectStore) DeleteRoomLocked(arg1 context.Context, arg2 livekit.RoomName) error {
+	fake.deleteRoomLockedMutex.Lock()
+	ret, specificReturn := fake.deleteRoomLockedReturnsOnCall[len(fake.deleteRoomLockedArgsForCall)]
+	fake.deleteRoomLockedArgsForCall = append(fake.deleteRoomLockedArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.DeleteRoomLockedStub
+	fakeReturns := fake.deleteRoomLockedReturns
+	fake.recordInvocation("DeleteRoomLocked", []interface{}{arg1, arg2})
+	fake.deleteRoomLockedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) DeleteRoomLockedCallCount() int {
+	fake.deleteRoomLockedMutex.RLock()
+	defer fake.deleteRoomLockedMutex.RUnlock()
+	return len(fake.deleteRoomLockedArgsForCall)
+}
+
+func (fake *FakeObjectStore) DeleteRoomLockedCalls(stub func(context.Context, livekit.RoomName) error) {
+	fake.deleteRoomLockedMutex.Lock()
+	defer fake.deleteRoomLockedMutex.Unlock()
+	fake.DeleteRoomLockedStub = stub
+}
+
+func (fake *FakeObjectStore) DeleteRoomLockedArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.deleteRoomLockedMutex.RLock()
+	defer fake.deleteRoomLockedMutex.RUnlock()
+	argsForCall := fake.deleteRoomLockedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) DeleteRoomLockedReturns(result1 error) {
+	fake.deleteRoomLockedMutex.Lock()
+	defer fake.deleteRoomLockedMutex.Unlock()
+	fake.DeleteRoomLockedStub = nil
+	fake.deleteRoomLockedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) DeleteRoomLockedReturnsOnCall(i int, result1 error) {
+	fake.deleteRoomLockedMutex.Lock()
+	defer fake.deleteRoomLockedMutex.Unlock()
+	fake.DeleteRoomLockedStub = nil
+	if fake.deleteRoomLockedReturnsOnCall == nil {
+		fake.deleteRoomLockedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteRoomLockedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) DeleteRoomSchedule(arg1 context.Context, arg2 livekit.RoomName) error {
+	fake.deleteRoomScheduleMutex.Lock()
+	ret, specificReturn := fake.deleteRoomScheduleReturnsOnCall[len(fake.deleteRoomScheduleArgsForCall)]
+	fake.deleteRoomScheduleArgsForCall = append(fake.deleteRoomScheduleArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.DeleteRoomScheduleStub
+	fakeReturns := fake.deleteRoomScheduleReturns
+	fake.recordInvocation("DeleteRoomSchedule", []interface{}{arg1, arg2})
+	fake.deleteRoomScheduleMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) DeleteRoomScheduleCallCount() int {
+	fake.deleteRoomScheduleMutex.RLock()
+	defer fake.deleteRoomScheduleMutex.RUnlock()
+	return len(fake.deleteRoomScheduleArgsForCall)
+}
+
+func (fake *FakeObjectStore) DeleteRoomScheduleCalls(stub func(context.Context, livekit.RoomName) error) {
+	fake.deleteRoomScheduleMutex.Lock()
+	defer fake.deleteRoomScheduleMutex.Unlock()
+	fake.DeleteRoomScheduleStub = stub
+}
+
+func (fake *FakeObjectStore) DeleteRoomScheduleArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.deleteRoomScheduleMutex.RLock()
+	defer fake.deleteRoomScheduleMutex.RUnlock()
+	argsForCall := fake.deleteRoomScheduleArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) DeleteRoomScheduleReturns(result1 error) {
+	fake.deleteRoomScheduleMutex.Lock()
+	defer fake.deleteRoomScheduleMutex.Unlock()
+	fake.DeleteRoomScheduleStub = nil
+	fake.deleteRoomScheduleReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) DeleteRoomScheduleReturnsOnCall(i int, result1 error) {
+	fake.deleteRoomScheduleMutex.Lock()
+	defer fake.deleteRoomScheduleMutex.Unlock()
+	fake.DeleteRoomScheduleStub = nil
+	if fake.deleteRoomScheduleReturnsOnCall == nil {
+		fake.deleteRoomScheduleReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteRoomScheduleReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) DeleteTokenRevocation(arg1 context.Context, arg2 string) error {
+	fake.deleteTokenRevocationMutex.Lock()
+	ret, specificReturn := fake.deleteTokenRevocationReturnsOnCall[len(fake.deleteTokenRevocationArgsForCall)]
+	fake.deleteTokenRevocationArgsForCall = append(fake.deleteTokenRevocationArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DeleteTokenRevocationStub
+	fakeReturns := fake.deleteTokenRevocationReturns
+	fake.recordInvocation("DeleteTokenRevocation", []interface{}{arg1, arg2})
+	fake.deleteTokenRevocationMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) DeleteTokenRevocationCallCount() int {
+	fake.deleteTokenRevocationMutex.RLock()
+	defer fake.deleteTokenRevocationMutex.RUnlock()
+	return len(fake.deleteTokenRevocationArgsForCall)
+}
+
+func (fake *FakeObjectStore) DeleteTokenRevocationCalls(stub func(context.Context, string) error) {
+	fake.deleteTokenRevocationMutex.Lock()
+	defer fake.deleteTokenRevocationMutex.Unlock()
+	fake.DeleteTokenRevocationStub = stub
+}
+
+func (fake *FakeObjectStore) DeleteTokenRevocationArgsForCall(i int) (context.Context, string) {
+	fake.deleteTokenRevocationMutex.RLock()
+	defer fake.deleteTokenRevocationMutex.RUnlock()
+	argsForCall := fake.deleteTokenRevocationArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) DeleteTokenRevocationReturns(result1 error) {
+	fake.deleteTokenRevocationMutex.Lock()
+	defer fake.deleteTokenRevocationMutex.Unlock()
+	fake.DeleteTokenRevocationStub = nil
+	fake.deleteTokenRevocationReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) DeleteTokenRevocationReturnsOnCall(i int, result1 error) {
+	fake.deleteTokenRevocationMutex.Lock()
+	defer fake.deleteTokenRevocationMutex.Unlock()
+	fake.DeleteTokenRevocationStub = nil
+	if fake.deleteTokenRevocationReturnsOnCall == nil {
+		fake.deleteTokenRevocationReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteTokenRevocationReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) DeleteTokenRollover(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.ParticipantIdentity) error {
+	fake.deleteTokenRolloverMutex.Lock()
+	ret, specificReturn := fake.deleteTokenRolloverReturnsOnCall[len(fake.deleteTokenRolloverArgsForCall)]
+	fake.deleteTokenRolloverArgsForCall = append(fake.deleteTokenRolloverArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteTokenRolloverStub
+	fakeReturns := fake.deleteTokenRolloverReturns
+	fake.recordInvocation("DeleteTokenRollover", []interface{}{arg1, arg2, arg3})
+	fake.deleteTokenRolloverMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) DeleteTokenRolloverCallCount() int {
+	fake.deleteTokenRolloverMutex.RLock()
+	defer fake.deleteTokenRolloverMutex.RUnlock()
+	return len(fake.deleteTokenRolloverArgsForCall)
+}
+
+func (fake *FakeObjectStore) DeleteTokenRolloverCalls(stub func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) error) {
+	fake.deleteTokenRolloverMutex.Lock()
+	defer fake.deleteTokenRolloverMutex.Unlock()
+	fake.DeleteTokenRolloverStub = stub
+}
+
+func (fake *FakeObjectStore) DeleteTokenRolloverArgsForCall(i int) (context.Context, livekit.RoomName, livekit.ParticipantIdentity) {
+	fake.deleteTokenRolloverMutex.RLock()
+	defer fake.deleteTokenRolloverMutex.RUnlock()
+	argsForCall := fake.deleteTokenRolloverArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeObjectStore) DeleteTokenRolloverReturns(result1 error) {
+	fake.deleteTokenRolloverMutex.Lock()
+	defer fake.deleteTokenRolloverMutex.Unlock()
+	fake.DeleteTokenRolloverStub = nil
+	fake.deleteTokenRolloverReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) DeleteTokenRolloverReturnsOnCall(i int, result1 error) {
+	fake.deleteTokenRolloverMutex.Lock()
+	defer fake.deleteTokenRolloverMutex.Unlock()
+	fake.DeleteTokenRolloverStub = nil
+	if fake.deleteTokenRolloverReturnsOnCall == nil {
+		fake.deleteTokenRolloverReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteTokenRolloverReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) IsRoomLocked(arg1 context.Context, arg2 livekit.RoomName) (bool, error) {
+	fake.isRoomLockedMutex.Lock()
+	ret, specificReturn := fake.isRoomLockedReturnsOnCall[len(fake.isRoomLockedArgsForCall)]
+	fake.isRoomLockedArgsForCall = append(fake.isRoomLockedArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.IsRoomLockedStub
+	fakeReturns := fake.isRoomLockedReturns
+	fake.recordInvocation("IsRoomLocked", []interface{}{arg1, arg2})
+	fake.isRoomLockedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) IsRoomLockedCallCount() int {
+	fake.isRoomLockedMutex.RLock()
+	defer fake.isRoomLockedMutex.RUnlock()
+	return len(fake.isRoomLockedArgsForCall)
+}
+
+func (fake *FakeObjectStore) IsRoomLockedCalls(stub func(context.Context, livekit.RoomName) (bool, error)) {
+	fake.isRoomLockedMutex.Lock()
+	defer fake.isRoomLockedMutex.Unlock()
+	fake.IsRoomLockedStub = stub
+}
+
+func (fake *FakeObjectStore) IsRoomLockedArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.isRoomLockedMutex.RLock()
+	defer fake.isRoomLockedMutex.RUnlock()
+	argsForCall := fake.isRoomLockedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) IsRoomLockedReturns(result1 bool, result2 error) {
+	fake.isRoomLockedMutex.Lock()
+	defer fake.isRoomLockedMutex.Unlock()
+	fake.IsRoomLockedStub = nil
+	fake.isRoomLockedReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) IsRoomLockedReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.isRoomLockedMutex.Lock()
+	defer fake.isRoomLockedMutex.Unlock()
+	fake.IsRoomLockedStub = nil
+	if fake.isRoomLockedReturnsOnCall == nil {
+		fake.isRoomLockedReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.isRoomLockedReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) IsTokenRevoked(arg1 context.Context, arg2 string) (bool, error) {
+	fake.isTokenRevokedMutex.Lock()
+	ret, specificReturn := fake.isTokenRevokedReturnsOnCall[len(fake.isTokenRevokedArgsForCall)]
+	fake.isTokenRevokedArgsForCall = append(fake.isTokenRevokedArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.IsTokenRevokedStub
+	fakeReturns := fake.isTokenRevokedReturns
+	fake.recordInvocation("IsTokenRevoked", []interface{}{arg1, arg2})
+	fake.isTokenRevokedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) IsTokenRevokedCallCount() int {
+	fake.isTokenRevokedMutex.RLock()
+	defer fake.isTokenRevokedMutex.RUnlock()
+	return len(fake.isTokenRevokedArgsForCall)
+}
+
+func (fake *FakeObjectStore) IsTokenRevokedCalls(stub func(context.Context, string) (bool, error)) {
+	fake.isTokenRevokedMutex.Lock()
+	defer fake.isTokenRevokedMutex.Unlock()
+	fake.IsTokenRevokedStub = stub
+}
+
+func (fake *FakeObjectStore) IsTokenRevokedArgsForCall(i int) (context.Context, string) {
+	fake.isTokenRevokedMutex.RLock()
+	defer fake.isTokenRevokedMutex.RUnlock()
+	argsForCall := fake.isTokenRevokedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) IsTokenRevokedReturns(result1 bool, result2 error) {
+	fake.isTokenRevokedMutex.Lock()
+	defer fake.isTokenRevokedMutex.Unlock()
+	fake.IsTokenRevokedStub = nil
+	fake.isTokenRevokedReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) IsTokenRevokedReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.isTokenRevokedMutex.Lock()
+	defer fake.isTokenRevokedMutex.Unlock()
+	fake.IsTokenRevokedStub = nil
+	if fake.isTokenRevokedReturnsOnCall == nil {
+		fake.isTokenRevokedReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.isTokenRevokedReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) ListParticipantBans(arg1 context.Context, arg2 livekit.RoomName) ([]*service.ParticipantBan, error) {
+	fake.listParticipantBansMutex.Lock()
+	ret, specificReturn := fake.listParticipantBansReturnsOnCall[len(fake.listParticipantBansArgsForCall)]
+	fake.listParticipantBansArgsForCall = append(fake.listParticipantBansArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.ListParticipantBansStub
+	fakeReturns := fake.listParticipantBansReturns
+	fake.recordInvocation("ListParticipantBans", []interface{}{arg1, arg2})
+	fake.listParticipantBansMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) ListParticipantBansCallCount() int {
+	fake.listParticipantBansMutex.RLock()
+	defer fake.listParticipantBansMutex.RUnlock()
+	return len(fake.listParticipantBansArgsForCall)
+}
+
+func (fake *FakeObjectStore) ListParticipantBansCalls(stub func(context.Context, livekit.RoomName) ([]*service.ParticipantBan, error)) {
+	fake.listParticipantBansMutex.Lock()
+	defer fake.listParticipantBansMutex.Unlock()
+	fake.ListParticipantBansStub = stub
+}
+
+func (fake *FakeObjectStore) ListParticipantBansArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.listParticipantBansMutex.RLock()
+	defer fake.listParticipantBansMutex.RUnlock()
+	argsForCall := fake.listParticipantBansArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) ListParticipantBansReturns(result1 []*service.ParticipantBan, result2 error) {
+	fake.listParticipantBansMutex.Lock()
+	defer fake.listParticipantBansMutex.Unlock()
+	fake.ListParticipantBansStub = nil
+	fake.listParticipantBansReturns = struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) ListParticipantBansReturnsOnCall(i int, result1 []*service.ParticipantBan, result2 error) {
+	fake.listParticipantBansMutex.Lock()
+	defer fake.listParticipantBansMutex.Unlock()
+	fake.ListParticipantBansStub = nil
+	if fake.listParticipantBansReturnsOnCall == nil {
+		fake.listParticipantBansReturnsOnCall = make(map[int]struct {
+			result1 []*service.ParticipantBan
+			result2 error
+		})
+	}
+	fake.listParticipantBansReturnsOnCall[i] = struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeObjectStore) ListParticipants(arg1 context.Context, arg2 livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
 	fake.listParticipantsMutex.Lock()
 	ret, specificReturn := fake.listParticipantsReturnsOnCall[len(fake.listParticipantsArgsForCall)]
@@ -344,6 +1154,70 @@ func (fake *FakeObjectStore) ListParticipantsReturnsOnCall(i int, result1 []*liv
 	}{result1, result2}
 }
 
+func (fake *FakeObjectStore) ListRoomSchedules(arg1 context.Context) ([]*service.RoomSchedule, error) {
+	fake.listRoomSchedulesMutex.Lock()
+	ret, specificReturn := fake.listRoomSchedulesReturnsOnCall[len(fake.listRoomSchedulesArgsForCall)]
+	fake.listRoomSchedulesArgsForCall = append(fake.listRoomSchedulesArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.ListRoomSchedulesStub
+	fakeReturns := fake.listRoomSchedulesReturns
+	fake.recordInvocation("ListRoomSchedules", []interface{}{arg1})
+	fake.listRoomSchedulesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) ListRoomSchedulesCallCount() int {
+	fake.listRoomSchedulesMutex.RLock()
+	defer fake.listRoomSchedulesMutex.RUnlock()
+	return len(fake.listRoomSchedulesArgsForCall)
+}
+
+func (fake *FakeObjectStore) ListRoomSchedulesCalls(stub func(context.Context) ([]*service.RoomSchedule, error)) {
+	fake.listRoomSchedulesMutex.Lock()
+	defer fake.listRoomSchedulesMutex.Unlock()
+	fake.ListRoomSchedulesStub = stub
+}
+
+func (fake *FakeObjectStore) ListRoomSchedulesArgsForCall(i int) context.Context {
+	fake.listRoomSchedulesMutex.RLock()
+	defer fake.listRoomSchedulesMutex.RUnlock()
+	argsForCall := fake.listRoomSchedulesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeObjectStore) ListRoomSchedulesReturns(result1 []*service.RoomSchedule, result2 error) {
+	fake.listRoomSchedulesMutex.Lock()
+	defer fake.listRoomSchedulesMutex.Unlock()
+	fake.ListRoomSchedulesStub = nil
+	fake.listRoomSchedulesReturns = struct {
+		result1 []*service.RoomSchedule
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) ListRoomSchedulesReturnsOnCall(i int, result1 []*service.RoomSchedule, result2 error) {
+	fake.listRoomSchedulesMutex.Lock()
+	defer fake.listRoomSchedulesMutex.Unlock()
+	fake.ListRoomSchedulesStub = nil
+	if fake.listRoomSchedulesReturnsOnCall == nil {
+		fake.listRoomSchedulesReturnsOnCall = make(map[int]struct {
+			result1 []*service.RoomSchedule
+			result2 error
+		})
+	}
+	fake.listRoomSchedulesReturnsOnCall[i] = struct {
+		result1 []*service.RoomSchedule
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeObjectStore) ListRooms(arg1 context.Context, arg2 []livekit.RoomName) ([]*livekit.Room, error) {
 	var arg2Copy []livekit.RoomName
 	if arg2 != nil {
@@ -549,6 +1423,210 @@ func (fake *FakeObjectStore) LoadRoomReturnsOnCall(i int, result1 *livekit.Room,
 	}{result1, result2, result3}
 }
 
+func (fake *FakeObjectStore) LoadRoomJournal(arg1 context.Context, arg2 livekit.RoomName, arg3 uint64, arg4 int) ([]rtc.JournalEntry, uint64, bool, error) {
+	fake.loadRoomJournalMutex.Lock()
+	ret, specificReturn := fake.loadRoomJournalReturnsOnCall[len(fake.loadRoomJournalArgsForCall)]
+	fake.loadRoomJournalArgsForCall = append(fake.loadRoomJournalArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 uint64
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.LoadRoomJournalStub
+	fakeReturns := fake.loadRoomJournalReturns
+	fake.recordInvocation("LoadRoomJournal", []interface{}{arg1, arg2, arg3, arg4})
+	fake.loadRoomJournalMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3, ret.result4
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3, fakeReturns.result4
+}
+
+func (fake *FakeObjectStore) LoadRoomJournalCallCount() int {
+	fake.loadRoomJournalMutex.RLock()
+	defer fake.loadRoomJournalMutex.RUnlock()
+	return len(fake.loadRoomJournalArgsForCall)
+}
+
+func (fake *FakeObjectStore) LoadRoomJournalCalls(stub func(context.Context, livekit.RoomName, uint64, int) ([]rtc.JournalEntry, uint64, bool, error)) {
+	fake.loadRoomJournalMutex.Lock()
+	defer fake.loadRoomJournalMutex.Unlock()
+	fake.LoadRoomJournalStub = stub
+}
+
+func (fake *FakeObjectStore) LoadRoomJournalArgsForCall(i int) (context.Context, livekit.RoomName, uint64, int) {
+	fake.loadRoomJournalMutex.RLock()
+	defer fake.loadRoomJournalMutex.RUnlock()
+	argsForCall := fake.loadRoomJournalArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeObjectStore) LoadRoomJournalReturns(result1 []rtc.JournalEntry, result2 uint64, result3 bool, result4 error) {
+	fake.loadRoomJournalMutex.Lock()
+	defer fake.loadRoomJournalMutex.Unlock()
+	fake.LoadRoomJournalStub = nil
+	fake.loadRoomJournalReturns = struct {
+		result1 []rtc.JournalEntry
+		result2 uint64
+		result3 bool
+		result4 error
+	}{result1, result2, result3, result4}
+}
+
+func (fake *FakeObjectStore) LoadRoomJournalReturnsOnCall(i int, result1 []rtc.JournalEntry, result2 uint64, result3 bool, result4 error) {
+	fake.loadRoomJournalMutex.Lock()
+	defer fake.loadRoomJournalMutex.Unlock()
+	fake.LoadRoomJournalStub = nil
+	if fake.loadRoomJournalReturnsOnCall == nil {
+		fake.loadRoomJournalReturnsOnCall = make(map[int]struct {
+			result1 []rtc.JournalEntry
+			result2 uint64
+			result3 bool
+			result4 error
+		})
+	}
+	fake.loadRoomJournalReturnsOnCall[i] = struct {
+		result1 []rtc.JournalEntry
+		result2 uint64
+		result3 bool
+		result4 error
+	}{result1, result2, result3, result4}
+}
+
+func (fake *FakeObjectStore) LoadRoomSchedule(arg1 context.Context, arg2 livekit.RoomName) (*service.RoomSchedule, error) {
+	fake.loadRoomScheduleMutex.Lock()
+	ret, specificReturn := fake.loadRoomScheduleReturnsOnCall[len(fake.loadRoomScheduleArgsForCall)]
+	fake.loadRoomScheduleArgsForCall = append(fake.loadRoomScheduleArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.LoadRoomScheduleStub
+	fakeReturns := fake.loadRoomScheduleReturns
+	fake.recordInvocation("LoadRoomSchedule", []interface{}{arg1, arg2})
+	fake.loadRoomScheduleMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) LoadRoomScheduleCallCount() int {
+	fake.loadRoomScheduleMutex.RLock()
+	defer fake.loadRoomScheduleMutex.RUnlock()
+	return len(fake.loadRoomScheduleArgsForCall)
+}
+
+func (fake *FakeObjectStore) LoadRoomScheduleCalls(stub func(context.Context, livekit.RoomName) (*service.RoomSchedule, error)) {
+	fake.loadRoomScheduleMutex.Lock()
+	defer fake.loadRoomScheduleMutex.Unlock()
+	fake.LoadRoomScheduleStub = stub
+}
+
+func (fake *FakeObjectStore) LoadRoomScheduleArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.loadRoomScheduleMutex.RLock()
+	defer fake.loadRoomScheduleMutex.RUnlock()
+	argsForCall := fake.loadRoomScheduleArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) LoadRoomScheduleReturns(result1 *service.RoomSchedule, result2 error) {
+	fake.loadRoomScheduleMutex.Lock()
+	defer fake.loadRoomScheduleMutex.Unlock()
+	fake.LoadRoomScheduleStub = nil
+	fake.loadRoomScheduleReturns = struct {
+		result1 *service.RoomSchedule
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) LoadRoomScheduleReturnsOnCall(i int, result1 *service.RoomSchedule, result2 error) {
+	fake.loadRoomScheduleMutex.Lock()
+	defer fake.loadRoomScheduleMutex.Unlock()
+	fake.LoadRoomScheduleStub = nil
+	if fake.loadRoomScheduleReturnsOnCall == nil {
+		fake.loadRoomScheduleReturnsOnCall = make(map[int]struct {
+			result1 *service.RoomSchedule
+			result2 error
+		})
+	}
+	fake.loadRoomScheduleReturnsOnCall[i] = struct {
+		result1 *service.RoomSchedule
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) LoadTokenRollover(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.ParticipantIdentity) (*service.TokenRollover, error) {
+	fake.loadTokenRolloverMutex.Lock()
+	ret, specificReturn := fake.loadTokenRolloverReturnsOnCall[len(fake.loadTokenRolloverArgsForCall)]
+	fake.loadTokenRolloverArgsForCall = append(fake.loadTokenRolloverArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}{arg1, arg2, arg3})
+	stub := fake.LoadTokenRolloverStub
+	fakeReturns := fake.loadTokenRolloverReturns
+	fake.recordInvocation("LoadTokenRollover", []interface{}{arg1, arg2, arg3})
+	fake.loadTokenRolloverMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) LoadTokenRolloverCallCount() int {
+	fake.loadTokenRolloverMutex.RLock()
+	defer fake.loadTokenRolloverMutex.RUnlock()
+	return len(fake.loadTokenRolloverArgsForCall)
+}
+
+func (fake *FakeObjectStore) LoadTokenRolloverCalls(stub func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) (*service.TokenRollover, error)) {
+	fake.loadTokenRolloverMutex.Lock()
+	defer fake.loadTokenRolloverMutex.Unlock()
+	fake.LoadTokenRolloverStub = stub
+}
+
+func (fake *FakeObjectStore) LoadTokenRolloverArgsForCall(i int) (context.Context, livekit.RoomName, livekit.ParticipantIdentity) {
+	fake.loadTokenRolloverMutex.RLock()
+	defer fake.loadTokenRolloverMutex.RUnlock()
+	argsForCall := fake.loadTokenRolloverArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeObjectStore) LoadTokenRolloverReturns(result1 *service.TokenRollover, result2 error) {
+	fake.loadTokenRolloverMutex.Lock()
+	defer fake.loadTokenRolloverMutex.Unlock()
+	fake.LoadTokenRolloverStub = nil
+	fake.loadTokenRolloverReturns = struct {
+		result1 *service.TokenRollover
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) LoadTokenRolloverReturnsOnCall(i int, result1 *service.TokenRollover, result2 error) {
+	fake.loadTokenRolloverMutex.Lock()
+	defer fake.loadTokenRolloverMutex.Unlock()
+	fake.LoadTokenRolloverStub = nil
+	if fake.loadTokenRolloverReturnsOnCall == nil {
+		fake.loadTokenRolloverReturnsOnCall = make(map[int]struct {
+			result1 *service.TokenRollover
+			result2 error
+		})
+	}
+	fake.loadTokenRolloverReturnsOnCall[i] = struct {
+		result1 *service.TokenRollover
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeObjectStore) LockRoom(arg1 context.Context, arg2 livekit.RoomName, arg3 time.Duration) (string, error) {
 	fake.lockRoomMutex.Lock()
 	ret, specificReturn := fake.lockRoomReturnsOnCall[len(fake.lockRoomArgsForCall)]
@@ -678,6 +1756,68 @@ func (fake *FakeObjectStore) StoreParticipantReturnsOnCall(i int, result1 error)
 	}{result1}
 }
 
+func (fake *FakeObjectStore) StoreParticipantBan(arg1 context.Context, arg2 *service.ParticipantBan) error {
+	fake.storeParticipantBanMutex.Lock()
+	ret, specificReturn := fake.storeParticipantBanReturnsOnCall[len(fake.storeParticipantBanArgsForCall)]
+	fake.storeParticipantBanArgsForCall = append(fake.storeParticipantBanArgsForCall, struct {
+		arg1 context.Context
+		arg2 *service.ParticipantBan
+	}{arg1, arg2})
+	stub := fake.StoreParticipantBanStub
+	fakeReturns := fake.storeParticipantBanReturns
+	fake.recordInvocation("StoreParticipantBan", []interface{}{arg1, arg2})
+	fake.storeParticipantBanMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) StoreParticipantBanCallCount() int {
+	fake.storeParticipantBanMutex.RLock()
+	defer fake.storeParticipantBanMutex.RUnlock()
+	return len(fake.storeParticipantBanArgsForCall)
+}
+
+func (fake *FakeObjectStore) StoreParticipantBanCalls(stub func(context.Context, *service.ParticipantBan) error) {
+	fake.storeParticipantBanMutex.Lock()
+	defer fake.storeParticipantBanMutex.Unlock()
+	fake.StoreParticipantBanStub = stub
+}
+
+func (fake *FakeObjectStore) StoreParticipantBanArgsForCall(i int) (context.Context, *service.ParticipantBan) {
+	fake.storeParticipantBanMutex.RLock()
+	defer fake.storeParticipantBanMutex.RUnlock()
+	argsForCall := fake.storeParticipantBanArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) StoreParticipantBanReturns(result1 error) {
+	fake.storeParticipantBanMutex.Lock()
+	defer fake.storeParticipantBanMutex.Unlock()
+	fake.StoreParticipantBanStub = nil
+	fake.storeParticipantBanReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreParticipantBanReturnsOnCall(i int, result1 error) {
+	fake.storeParticipantBanMutex.Lock()
+	defer fake.storeParticipantBanMutex.Unlock()
+	fake.StoreParticipantBanStub = nil
+	if fake.storeParticipantBanReturnsOnCall == nil {
+		fake.storeParticipantBanReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeParticipantBanReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeObjectStore) StoreRoom(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.RoomInternal) error {
 	fake.storeRoomMutex.Lock()
 	ret, specificReturn := fake.storeRoomReturnsOnCall[len(fake.storeRoomArgsForCall)]
@@ -741,6 +1881,254 @@ func (fake *FakeObjectStore) StoreRoomReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeObjectStore) StoreRoomLocked(arg1 context.Context, arg2 livekit.RoomName) error {
+	fake.storeRoomLockedMutex.Lock()
+	ret, specificReturn := fake.storeRoomLockedReturnsOnCall[len(fake.storeRoomLockedArgsForCall)]
+	fake.storeRoomLockedArgsForCall = append(fake.storeRoomLockedArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.StoreRoomLockedStub
+	fakeReturns := fake.storeRoomLockedReturns
+	fake.recordInvocation("StoreRoomLocked", []interface{}{arg1, arg2})
+	fake.storeRoomLockedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) StoreRoomLockedCallCount() int {
+	fake.storeRoomLockedMutex.RLock()
+	defer fake.storeRoomLockedMutex.RUnlock()
+	return len(fake.storeRoomLockedArgsForCall)
+}
+
+func (fake *FakeObjectStore) StoreRoomLockedCalls(stub func(context.Context, livekit.RoomName) error) {
+	fake.storeRoomLockedMutex.Lock()
+	defer fake.storeRoomLockedMutex.Unlock()
+	fake.StoreRoomLockedStub = stub
+}
+
+func (fake *FakeObjectStore) StoreRoomLockedArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.storeRoomLockedMutex.RLock()
+	defer fake.storeRoomLockedMutex.RUnlock()
+	argsForCall := fake.storeRoomLockedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) StoreRoomLockedReturns(result1 error) {
+	fake.storeRoomLockedMutex.Lock()
+	defer fake.storeRoomLockedMutex.Unlock()
+	fake.StoreRoomLockedStub = nil
+	fake.storeRoomLockedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreRoomLockedReturnsOnCall(i int, result1 error) {
+	fake.storeRoomLockedMutex.Lock()
+	defer fake.storeRoomLockedMutex.Unlock()
+	fake.StoreRoomLockedStub = nil
+	if fake.storeRoomLockedReturnsOnCall == nil {
+		fake.storeRoomLockedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeRoomLockedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreRoomSchedule(arg1 context.Context, arg2 *service.RoomSchedule) error {
+	fake.storeRoomScheduleMutex.Lock()
+	ret, specificReturn := fake.storeRoomScheduleReturnsOnCall[len(fake.storeRoomScheduleArgsForCall)]
+	fake.storeRoomScheduleArgsForCall = append(fake.storeRoomScheduleArgsForCall, struct {
+		arg1 context.Context
+		arg2 *service.RoomSchedule
+	}{arg1, arg2})
+	stub := fake.StoreRoomScheduleStub
+	fakeReturns := fake.storeRoomScheduleReturns
+	fake.recordInvocation("StoreRoomSchedule", []interface{}{arg1, arg2})
+	fake.storeRoomScheduleMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) StoreRoomScheduleCallCount() int {
+	fake.storeRoomScheduleMutex.RLock()
+	defer fake.storeRoomScheduleMutex.RUnlock()
+	return len(fake.storeRoomScheduleArgsForCall)
+}
+
+func (fake *FakeObjectStore) StoreRoomScheduleCalls(stub func(context.Context, *service.RoomSchedule) error) {
+	fake.storeRoomScheduleMutex.Lock()
+	defer fake.storeRoomScheduleMutex.Unlock()
+	fake.StoreRoomScheduleStub = stub
+}
+
+func (fake *FakeObjectStore) StoreRoomScheduleArgsForCall(i int) (context.Context, *service.RoomSchedule) {
+	fake.storeRoomScheduleMutex.RLock()
+	defer fake.storeRoomScheduleMutex.RUnlock()
+	argsForCall := fake.storeRoomScheduleArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) StoreRoomScheduleReturns(result1 error) {
+	fake.storeRoomScheduleMutex.Lock()
+	defer fake.storeRoomScheduleMutex.Unlock()
+	fake.StoreRoomScheduleStub = nil
+	fake.storeRoomScheduleReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreRoomScheduleReturnsOnCall(i int, result1 error) {
+	fake.storeRoomScheduleMutex.Lock()
+	defer fake.storeRoomScheduleMutex.Unlock()
+	fake.StoreRoomScheduleStub = nil
+	if fake.storeRoomScheduleReturnsOnCall == nil {
+		fake.storeRoomScheduleReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeRoomScheduleReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreTokenRevocation(arg1 context.Context, arg2 *service.TokenRevocation) error {
+	fake.storeTokenRevocationMutex.Lock()
+	ret, specificReturn := fake.storeTokenRevocationReturnsOnCall[len(fake.storeTokenRevocationArgsForCall)]
+	fake.storeTokenRevocationArgsForCall = append(fake.storeTokenRevocationArgsForCall, struct {
+		arg1 context.Context
+		arg2 *service.TokenRevocation
+	}{arg1, arg2})
+	stub := fake.StoreTokenRevocationStub
+	fakeReturns := fake.storeTokenRevocationReturns
+	fake.recordInvocation("StoreTokenRevocation", []interface{}{arg1, arg2})
+	fake.storeTokenRevocationMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) StoreTokenRevocationCallCount() int {
+	fake.storeTokenRevocationMutex.RLock()
+	defer fake.storeTokenRevocationMutex.RUnlock()
+	return len(fake.storeTokenRevocationArgsForCall)
+}
+
+func (fake *FakeObjectStore) StoreTokenRevocationCalls(stub func(context.Context, *service.TokenRevocation) error) {
+	fake.storeTokenRevocationMutex.Lock()
+	defer fake.storeTokenRevocationMutex.Unlock()
+	fake.StoreTokenRevocationStub = stub
+}
+
+func (fake *FakeObjectStore) StoreTokenRevocationArgsForCall(i int) (context.Context, *service.TokenRevocation) {
+	fake.storeTokenRevocationMutex.RLock()
+	defer fake.storeTokenRevocationMutex.RUnlock()
+	argsForCall := fake.storeTokenRevocationArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) StoreTokenRevocationReturns(result1 error) {
+	fake.storeTokenRevocationMutex.Lock()
+	defer fake.storeTokenRevocationMutex.Unlock()
+	fake.StoreTokenRevocationStub = nil
+	fake.storeTokenRevocationReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreTokenRevocationReturnsOnCall(i int, result1 error) {
+	fake.storeTokenRevocationMutex.Lock()
+	defer fake.storeTokenRevocationMutex.Unlock()
+	fake.StoreTokenRevocationStub = nil
+	if fake.storeTokenRevocationReturnsOnCall == nil {
+		fake.storeTokenRevocationReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeTokenRevocationReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreTokenRollover(arg1 context.Context, arg2 *service.TokenRollover) error {
+	fake.storeTokenRolloverMutex.Lock()
+	ret, specificReturn := fake.storeTokenRolloverReturnsOnCall[len(fake.storeTokenRolloverArgsForCall)]
+	fake.storeTokenRolloverArgsForCall = append(fake.storeTokenRolloverArgsForCall, struct {
+		arg1 context.Context
+		arg2 *service.TokenRollover
+	}{arg1, arg2})
+	stub := fake.StoreTokenRolloverStub
+	fakeReturns := fake.storeTokenRolloverReturns
+	fake.recordInvocation("StoreTokenRollover", []interface{}{arg1, arg2})
+	fake.storeTokenRolloverMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) StoreTokenRolloverCallCount() int {
+	fake.storeTokenRolloverMutex.RLock()
+	defer fake.storeTokenRolloverMutex.RUnlock()
+	return len(fake.storeTokenRolloverArgsForCall)
+}
+
+func (fake *FakeObjectStore) StoreTokenRolloverCalls(stub func(context.Context, *service.TokenRollover) error) {
+	fake.storeTokenRolloverMutex.Lock()
+	defer fake.storeTokenRolloverMutex.Unlock()
+	fake.StoreTokenRolloverStub = stub
+}
+
+func (fake *FakeObjectStore) StoreTokenRolloverArgsForCall(i int) (context.Context, *service.TokenRollover) {
+	fake.storeTokenRolloverMutex.RLock()
+	defer fake.storeTokenRolloverMutex.RUnlock()
+	argsForCall := fake.storeTokenRolloverArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) StoreTokenRolloverReturns(result1 error) {
+	fake.storeTokenRolloverMutex.Lock()
+	defer fake.storeTokenRolloverMutex.Unlock()
+	fake.StoreTokenRolloverStub = nil
+	fake.storeTokenRolloverReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreTokenRolloverReturnsOnCall(i int, result1 error) {
+	fake.storeTokenRolloverMutex.Lock()
+	defer fake.storeTokenRolloverMutex.Unlock()
+	fake.StoreTokenRolloverStub = nil
+	if fake.storeTokenRolloverReturnsOnCall == nil {
+		fake.storeTokenRolloverReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeTokenRolloverReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeObjectStore) UnlockRoom(arg1 context.Context, arg2 livekit.RoomName, arg3 string) error {
 	fake.unlockRoomMutex.Lock()
 	ret, specificReturn := fake.unlockRoomReturnsOnCall[len(fake.unlockRoomArgsForCall)]
@@ -807,24 +2195,60 @@ func (fake *FakeObjectStore) UnlockRoomReturnsOnCall(i int, result1 error) {
 func (fake *FakeObjectStore) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.appendRoomJournalMutex.RLock()
+	defer fake.appendRoomJournalMutex.RUnlock()
 	fake.deleteParticipantMutex.RLock()
 	defer fake.deleteParticipantMutex.RUnlock()
+	fake.deleteParticipantBanMutex.RLock()
+	defer fake.deleteParticipantBanMutex.RUnlock()
 	fake.deleteRoomMutex.RLock()
 	defer fake.deleteRoomMutex.RUnlock()
+	fake.deleteRoomLockedMutex.RLock()
+	defer fake.deleteRoomLockedMutex.RUnlock()
+	fake.deleteRoomScheduleMutex.RLock()
+	defer fake.deleteRoomScheduleMutex.RUnlock()
+	fake.deleteTokenRevocationMutex.RLock()
+	defer fake.deleteTokenRevocationMutex.RUnlock()
+	fake.deleteTokenRolloverMutex.RLock()
+	defer fake.deleteTokenRolloverMutex.RUnlock()
+	fake.isRoomLockedMutex.RLock()
+	defer fake.isRoomLockedMutex.RUnlock()
+	fake.isTokenRevokedMutex.RLock()
+	defer fake.isTokenRevokedMutex.RUnlock()
+	fake.listParticipantBansMutex.RLock()
+	defer fake.listParticipantBansMutex.RUnlock()
 	fake.listParticipantsMutex.RLock()
 	defer fake.listParticipantsMutex.RUnlock()
+	fake.listRoomSchedulesMutex.RLock()
+	defer fake.listRoomSchedulesMutex.RUnlock()
 	fake.listRoomsMutex.RLock()
 	defer fake.listRoomsMutex.RUnlock()
 	fake.loadParticipantMutex.RLock()
 	defer fake.loadParticipantMutex.RUnlock()
 	fake.loadRoomMutex.RLock()
 	defer fake.loadRoomMutex.RUnlock()
+	fake.loadRoomJournalMutex.RLock()
+	defer fake.loadRoomJournalMutex.RUnlock()
+	fake.loadRoomScheduleMutex.RLock()
+	defer fake.loadRoomScheduleMutex.RUnlock()
+	fake.loadTokenRolloverMutex.RLock()
+	defer fake.loadTokenRolloverMutex.RUnlock()
 	fake.lockRoomMutex.RLock()
 	defer fake.lockRoomMutex.RUnlock()
 	fake.storeParticipantMutex.RLock()
 	defer fake.storeParticipantMutex.RUnlock()
+	fake.storeParticipantBanMutex.RLock()
+	defer fake.storeParticipantBanMutex.RUnlock()
 	fake.storeRoomMutex.RLock()
 	defer fake.storeRoomMutex.RUnlock()
+	fake.storeRoomLockedMutex.RLock()
+	defer fake.storeRoomLockedMutex.RUnlock()
+	fake.storeRoomScheduleMutex.RLock()
+	defer fake.storeRoomScheduleMutex.RUnlock()
+	fake.storeTokenRevocationMutex.RLock()
+	defer fake.storeTokenRevocationMutex.RUnlock()
+	fake.storeTokenRolloverMutex.RLock()
+	defer fake.storeTokenRolloverMutex.RUnlock()
 	fake.unlockRoomMutex.RLock()
 	defer fake.unlockRoomMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
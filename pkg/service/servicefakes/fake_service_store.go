@@ -5,11 +5,54 @@ import (
 	"context"
 	"sync"
 
+	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/service"
 	"github.com/livekit/protocol/livekit"
 )
 
 type FakeServiceStore struct {
+	IsRoomLockedStub        func(context.Context, livekit.RoomName) (bool, error)
+	isRoomLockedMutex       sync.RWMutex
+	isRoomLockedArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	isRoomLockedReturns struct {
+		result1 bool
+		result2 error
+	}
+	isRoomLockedReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	IsTokenRevokedStub        func(context.Context, string) (bool, error)
+	isTokenRevokedMutex       sync.RWMutex
+	isTokenRevokedArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	isTokenRevokedReturns struct {
+		result1 bool
+		result2 error
+	}
+	isTokenRevokedReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	ListParticipantBansStub        func(context.Context, livekit.RoomName) ([]*service.ParticipantBan, error)
+	listParticipantBansMutex       sync.RWMutex
+	listParticipantBansArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	listParticipantBansReturns struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}
+	listParticipantBansReturnsOnCall map[int]struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}
 	ListParticipantsStub        func(context.Context, livekit.RoomName) ([]*livekit.ParticipantInfo, error)
 	listParticipantsMutex       sync.RWMutex
 	listParticipantsArgsForCall []struct {
@@ -24,6 +67,19 @@ type FakeServiceStore struct {
 		result1 []*livekit.ParticipantInfo
 		result2 error
 	}
+	ListRoomSchedulesStub        func(context.Context) ([]*service.RoomSchedule, error)
+	listRoomSchedulesMutex       sync.RWMutex
+	listRoomSchedulesArgsForCall []struct {
+		arg1 context.Context
+	}
+	listRoomSchedulesReturns struct {
+		result1 []*service.RoomSchedule
+		result2 error
+	}
+	listRoomSchedulesReturnsOnCall map[int]struct {
+		result1 []*service.RoomSchedule
+		result2 error
+	}
 	ListRoomsStub        func(context.Context, []livekit.RoomName) ([]*livekit.Room, error)
 	listRoomsMutex       sync.RWMutex
 	listRoomsArgsForCall []struct {
@@ -70,10 +126,254 @@ type FakeServiceStore struct {
 		result2 *livekit.RoomInternal
 		result3 error
 	}
+	LoadRoomJournalStub        func(context.Context, livekit.RoomName, uint64, int) ([]rtc.JournalEntry, uint64, bool, error)
+	loadRoomJournalMutex       sync.RWMutex
+	loadRoomJournalArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 uint64
+		arg4 int
+	}
+	loadRoomJournalReturns struct {
+		result1 []rtc.JournalEntry
+		result2 uint64
+		result3 bool
+		result4 error
+	}
+	loadRoomJournalReturnsOnCall map[int]struct {
+		result1 []rtc.JournalEntry
+		result2 uint64
+		result3 bool
+		result4 error
+	}
+	LoadRoomScheduleStub        func(context.Context, livekit.RoomName) (*service.RoomSchedule, error)
+	loadRoomScheduleMutex       sync.RWMutex
+	loadRoomScheduleArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	loadRoomScheduleReturns struct {
+		result1 *service.RoomSchedule
+		result2 error
+	}
+	loadRoomScheduleReturnsOnCall map[int]struct {
+		result1 *service.RoomSchedule
+		result2 error
+	}
+	LoadTokenRolloverStub        func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) (*service.TokenRollover, error)
+	loadTokenRolloverMutex       sync.RWMutex
+	loadTokenRolloverArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}
+	loadTokenRolloverReturns struct {
+		result1 *service.TokenRollover
+		result2 error
+	}
+	loadTokenRolloverReturnsOnCall map[int]struct {
+		result1 *service.TokenRollover
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeServiceStore) IsRoomLocked(arg1 context.Context, arg2 livekit.RoomName) (bool, error) {
+	fake.isRoomLockedMutex.Lock()
+	ret, specificReturn := fake.isRoomLockedReturnsOnCall[len(fake.isRoomLockedArgsForCall)]
+	fake.isRoomLockedArgsForCall = append(fake.isRoomLockedArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.IsRoomLockedStub
+	fakeReturns := fake.isRoomLockedReturns
+	fake.recordInvocation("IsRoomLocked", []interface{}{arg1, arg2})
+	fake.isRoomLockedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeServiceStore) IsRoomLockedCallCount() int {
+	fake.isRoomLockedMutex.RLock()
+	defer fake.isRoomLockedMutex.RUnlock()
+	return len(fake.isRoomLockedArgsForCall)
+}
+
+func (fake *FakeServiceStore) IsRoomLockedCalls(stub func(context.Context, livekit.RoomName) (bool, error)) {
+	fake.isRoomLockedMutex.Lock()
+	defer fake.isRoomLockedMutex.Unlock()
+	fake.IsRoomLockedStub = stub
+}
+
+func (fake *FakeServiceStore) IsRoomLockedArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.isRoomLockedMutex.RLock()
+	defer fake.isRoomLockedMutex.RUnlock()
+	argsForCall := fake.isRoomLockedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeServiceStore) IsRoomLockedReturns(result1 bool, result2 error) {
+	fake.isRoomLockedMutex.Lock()
+	defer fake.isRoomLockedMutex.Unlock()
+	fake.IsRoomLockedStub = nil
+	fake.isRoomLockedReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) IsRoomLockedReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.isRoomLockedMutex.Lock()
+	defer fake.isRoomLockedMutex.Unlock()
+	fake.IsRoomLockedStub = nil
+	if fake.isRoomLockedReturnsOnCall == nil {
+		fake.isRoomLockedReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.isRoomLockedReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) IsTokenRevoked(arg1 context.Context, arg2 string) (bool, error) {
+	fake.isTokenRevokedMutex.Lock()
+	ret, specificReturn := fake.isTokenRevokedReturnsOnCall[len(fake.isTokenRevokedArgsForCall)]
+	fake.isTokenRevokedArgsForCall = append(fake.isTokenRevokedArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.IsTokenRevokedStub
+	fakeReturns := fake.isTokenRevokedReturns
+	fake.recordInvocation("IsTokenRevoked", []interface{}{arg1, arg2})
+	fake.isTokenRevokedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeServiceStore) IsTokenRevokedCallCount() int {
+	fake.isTokenRevokedMutex.RLock()
+	defer fake.isTokenRevokedMutex.RUnlock()
+	return len(fake.isTokenRevokedArgsForCall)
+}
+
+func (fake *FakeServiceStore) IsTokenRevokedCalls(stub func(context.Context, string) (bool, error)) {
+	fake.isTokenRevokedMutex.Lock()
+	defer fake.isTokenRevokedMutex.Unlock()
+	fake.IsTokenRevokedStub = stub
+}
+
+func (fake *FakeServiceStore) IsTokenRevokedArgsForCall(i int) (context.Context, string) {
+	fake.isTokenRevokedMutex.RLock()
+	defer fake.isTokenRevokedMutex.RUnlock()
+	argsForCall := fake.isTokenRevokedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeServiceStore) IsTokenRevokedReturns(result1 bool, result2 error) {
+	fake.isTokenRevokedMutex.Lock()
+	defer fake.isTokenRevokedMutex.Unlock()
+	fake.IsTokenRevokedStub = nil
+	fake.isTokenRevokedReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) IsTokenRevokedReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.isTokenRevokedMutex.Lock()
+	defer fake.isTokenRevokedMutex.Unlock()
+	fake.IsTokenRevokedStub = nil
+	if fake.isTokenRevokedReturnsOnCall == nil {
+		fake.isTokenRevokedReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.isTokenRevokedReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) ListParticipantBans(arg1 context.Context, arg2 livekit.RoomName) ([]*service.ParticipantBan, error) {
+	fake.listParticipantBansMutex.Lock()
+	ret, specificReturn := fake.listParticipantBansReturnsOnCall[len(fake.listParticipantBansArgsForCall)]
+	fake.listParticipantBansArgsForCall = append(fake.listParticipantBansArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.ListParticipantBansStub
+	fakeReturns := fake.listParticipantBansReturns
+	fake.recordInvocation("ListParticipantBans", []interface{}{arg1, arg2})
+	fake.listParticipantBansMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeServiceStore) ListParticipantBansCallCount() int {
+	fake.listParticipantBansMutex.RLock()
+	defer fake.listParticipantBansMutex.RUnlock()
+	return len(fake.listParticipantBansArgsForCall)
+}
+
+func (fake *FakeServiceStore) ListParticipantBansCalls(stub func(context.Context, livekit.RoomName) ([]*service.ParticipantBan, error)) {
+	fake.listParticipantBansMutex.Lock()
+	defer fake.listParticipantBansMutex.Unlock()
+	fake.ListParticipantBansStub = stub
+}
+
+func (fake *FakeServiceStore) ListParticipantBansArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.listParticipantBansMutex.RLock()
+	defer fake.listParticipantBansMutex.RUnlock()
+	argsForCall := fake.listParticipantBansArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeServiceStore) ListParticipantBansReturns(result1 []*service.ParticipantBan, result2 error) {
+	fake.listParticipantBansMutex.Lock()
+	defer fake.listParticipantBansMutex.Unlock()
+	fake.ListParticipantBansStub = nil
+	fake.listParticipantBansReturns = struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) ListParticipantBansReturnsOnCall(i int, result1 []*service.ParticipantBan, result2 error) {
+	fake.listParticipantBansMutex.Lock()
+	defer fake.listParticipantBansMutex.Unlock()
+	fake.ListParticipantBansStub = nil
+	if fake.listParticipantBansReturnsOnCall == nil {
+		fake.listParticipantBansReturnsOnCall = make(map[int]struct {
+			result1 []*service.ParticipantBan
+			result2 error
+		})
+	}
+	fake.listParticipantBansReturnsOnCall[i] = struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeServiceStore) ListParticipants(arg1 context.Context, arg2 livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
 	fake.listParticipantsMutex.Lock()
 	ret, specificReturn := fake.listParticipantsReturnsOnCall[len(fake.listParticipantsArgsForCall)]
@@ -139,6 +439,70 @@ func (fake *FakeServiceStore) ListParticipantsReturnsOnCall(i int, result1 []*li
 	}{result1, result2}
 }
 
+func (fake *FakeServiceStore) ListRoomSchedules(arg1 context.Context) ([]*service.RoomSchedule, error) {
+	fake.listRoomSchedulesMutex.Lock()
+	ret, specificReturn := fake.listRoomSchedulesReturnsOnCall[len(fake.listRoomSchedulesArgsForCall)]
+	fake.listRoomSchedulesArgsForCall = append(fake.listRoomSchedulesArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.ListRoomSchedulesStub
+	fakeReturns := fake.listRoomSchedulesReturns
+	fake.recordInvocation("ListRoomSchedules", []interface{}{arg1})
+	fake.listRoomSchedulesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeServiceStore) ListRoomSchedulesCallCount() int {
+	fake.listRoomSchedulesMutex.RLock()
+	defer fake.listRoomSchedulesMutex.RUnlock()
+	return len(fake.listRoomSchedulesArgsForCall)
+}
+
+func (fake *FakeServiceStore) ListRoomSchedulesCalls(stub func(context.Context) ([]*service.RoomSchedule, error)) {
+	fake.listRoomSchedulesMutex.Lock()
+	defer fake.listRoomSchedulesMutex.Unlock()
+	fake.ListRoomSchedulesStub = stub
+}
+
+func (fake *FakeServiceStore) ListRoomSchedulesArgsForCall(i int) context.Context {
+	fake.listRoomSchedulesMutex.RLock()
+	defer fake.listRoomSchedulesMutex.RUnlock()
+	argsForCall := fake.listRoomSchedulesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeServiceStore) ListRoomSchedulesReturns(result1 []*service.RoomSchedule, result2 error) {
+	fake.listRoomSchedulesMutex.Lock()
+	defer fake.listRoomSchedulesMutex.Unlock()
+	fake.ListRoomSchedulesStub = nil
+	fake.listRoomSchedulesReturns = struct {
+		result1 []*service.RoomSchedule
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) ListRoomSchedulesReturnsOnCall(i int, result1 []*service.RoomSchedule, result2 error) {
+	fake.listRoomSchedulesMutex.Lock()
+	defer fake.listRoomSchedulesMutex.Unlock()
+	fake.ListRoomSchedulesStub = nil
+	if fake.listRoomSchedulesReturnsOnCall == nil {
+		fake.listRoomSchedulesReturnsOnCall = make(map[int]struct {
+			result1 []*service.RoomSchedule
+			result2 error
+		})
+	}
+	fake.listRoomSchedulesReturnsOnCall[i] = struct {
+		result1 []*service.RoomSchedule
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeServiceStore) ListRooms(arg1 context.Context, arg2 []livekit.RoomName) ([]*livekit.Room, error) {
 	var arg2Copy []livekit.RoomName
 	if arg2 != nil {
@@ -344,17 +708,235 @@ func (fake *FakeServiceStore) LoadRoomReturnsOnCall(i int, result1 *livekit.Room
 	}{result1, result2, result3}
 }
 
+func (fake *FakeServiceStore) LoadRoomJournal(arg1 context.Context, arg2 livekit.RoomName, arg3 uint64, arg4 int) ([]rtc.JournalEntry, uint64, bool, error) {
+	fake.loadRoomJournalMutex.Lock()
+	ret, specificReturn := fake.loadRoomJournalReturnsOnCall[len(fake.loadRoomJournalArgsForCall)]
+	fake.loadRoomJournalArgsForCall = append(fake.loadRoomJournalArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 uint64
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.LoadRoomJournalStub
+	fakeReturns := fake.loadRoomJournalReturns
+	fake.recordInvocation("LoadRoomJournal", []interface{}{arg1, arg2, arg3, arg4})
+	fake.loadRoomJournalMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3, ret.result4
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3, fakeReturns.result4
+}
+
+func (fake *FakeServiceStore) LoadRoomJournalCallCount() int {
+	fake.loadRoomJournalMutex.RLock()
+	defer fake.loadRoomJournalMutex.RUnlock()
+	return len(fake.loadRoomJournalArgsForCall)
+}
+
+func (fake *FakeServiceStore) LoadRoomJournalCalls(stub func(context.Context, livekit.RoomName, uint64, int) ([]rtc.JournalEntry, uint64, bool, error)) {
+	fake.loadRoomJournalMutex.Lock()
+	defer fake.loadRoomJournalMutex.Unlock()
+	fake.LoadRoomJournalStub = stub
+}
+
+func (fake *FakeServiceStore) LoadRoomJournalArgsForCall(i int) (context.Context, livekit.RoomName, uint64, int) {
+	fake.loadRoomJournalMutex.RLock()
+	defer fake.loadRoomJournalMutex.RUnlock()
+	argsForCall := fake.loadRoomJournalArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeServiceStore) LoadRoomJournalReturns(result1 []rtc.JournalEntry, result2 uint64, result3 bool, result4 error) {
+	fake.loadRoomJournalMutex.Lock()
+	defer fake.loadRoomJournalMutex.Unlock()
+	fake.LoadRoomJournalStub = nil
+	fake.loadRoomJournalReturns = struct {
+		result1 []rtc.JournalEntry
+		result2 uint64
+		result3 bool
+		result4 error
+	}{result1, result2, result3, result4}
+}
+
+func (fake *FakeServiceStore) LoadRoomJournalReturnsOnCall(i int, result1 []rtc.JournalEntry, result2 uint64, result3 bool, result4 error) {
+	fake.loadRoomJournalMutex.Lock()
+	defer fake.loadRoomJournalMutex.Unlock()
+	fake.LoadRoomJournalStub = nil
+	if fake.loadRoomJournalReturnsOnCall == nil {
+		fake.loadRoomJournalReturnsOnCall = make(map[int]struct {
+			result1 []rtc.JournalEntry
+			result2 uint64
+			result3 bool
+			result4 error
+		})
+	}
+	fake.loadRoomJournalReturnsOnCall[i] = struct {
+		result1 []rtc.JournalEntry
+		result2 uint64
+		result3 bool
+		result4 error
+	}{result1, result2, result3, result4}
+}
+
+func (fake *FakeServiceStore) LoadRoomSchedule(arg1 context.Context, arg2 livekit.RoomName) (*service.RoomSchedule, error) {
+	fake.loadRoomScheduleMutex.Lock()
+	ret, specificReturn := fake.loadRoomScheduleReturnsOnCall[len(fake.loadRoomScheduleArgsForCall)]
+	fake.loadRoomScheduleArgsForCall = append(fake.loadRoomScheduleArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.LoadRoomScheduleStub
+	fakeReturns := fake.loadRoomScheduleReturns
+	fake.recordInvocation("LoadRoomSchedule", []interface{}{arg1, arg2})
+	fake.loadRoomScheduleMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeServiceStore) LoadRoomScheduleCallCount() int {
+	fake.loadRoomScheduleMutex.RLock()
+	defer fake.loadRoomScheduleMutex.RUnlock()
+	return len(fake.loadRoomScheduleArgsForCall)
+}
+
+func (fake *FakeServiceStore) LoadRoomScheduleCalls(stub func(context.Context, livekit.RoomName) (*service.RoomSchedule, error)) {
+	fake.loadRoomScheduleMutex.Lock()
+	defer fake.loadRoomScheduleMutex.Unlock()
+	fake.LoadRoomScheduleStub = stub
+}
+
+func (fake *FakeServiceStore) LoadRoomScheduleArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.loadRoomScheduleMutex.RLock()
+	defer fake.loadRoomScheduleMutex.RUnlock()
+	argsForCall := fake.loadRoomScheduleArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeServiceStore) LoadRoomScheduleReturns(result1 *service.RoomSchedule, result2 error) {
+	fake.loadRoomScheduleMutex.Lock()
+	defer fake.loadRoomScheduleMutex.Unlock()
+	fake.LoadRoomScheduleStub = nil
+	fake.loadRoomScheduleReturns = struct {
+		result1 *service.RoomSchedule
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) LoadRoomScheduleReturnsOnCall(i int, result1 *service.RoomSchedule, result2 error) {
+	fake.loadRoomScheduleMutex.Lock()
+	defer fake.loadRoomScheduleMutex.Unlock()
+	fake.LoadRoomScheduleStub = nil
+	if fake.loadRoomScheduleReturnsOnCall == nil {
+		fake.loadRoomScheduleReturnsOnCall = make(map[int]struct {
+			result1 *service.RoomSchedule
+			result2 error
+		})
+	}
+	fake.loadRoomScheduleReturnsOnCall[i] = struct {
+		result1 *service.RoomSchedule
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) LoadTokenRollover(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.ParticipantIdentity) (*service.TokenRollover, error) {
+	fake.loadTokenRolloverMutex.Lock()
+	ret, specificReturn := fake.loadTokenRolloverReturnsOnCall[len(fake.loadTokenRolloverArgsForCall)]
+	fake.loadTokenRolloverArgsForCall = append(fake.loadTokenRolloverArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}{arg1, arg2, arg3})
+	stub := fake.LoadTokenRolloverStub
+	fakeReturns := fake.loadTokenRolloverReturns
+	fake.recordInvocation("LoadTokenRollover", []interface{}{arg1, arg2, arg3})
+	fake.loadTokenRolloverMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeServiceStore) LoadTokenRolloverCallCount() int {
+	fake.loadTokenRolloverMutex.RLock()
+	defer fake.loadTokenRolloverMutex.RUnlock()
+	return len(fake.loadTokenRolloverArgsForCall)
+}
+
+func (fake *FakeServiceStore) LoadTokenRolloverCalls(stub func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) (*service.TokenRollover, error)) {
+	fake.loadTokenRolloverMutex.Lock()
+	defer fake.loadTokenRolloverMutex.Unlock()
+	fake.LoadTokenRolloverStub = stub
+}
+
+func (fake *FakeServiceStore) LoadTokenRolloverArgsForCall(i int) (context.Context, livekit.RoomName, livekit.ParticipantIdentity) {
+	fake.loadTokenRolloverMutex.RLock()
+	defer fake.loadTokenRolloverMutex.RUnlock()
+	argsForCall := fake.loadTokenRolloverArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeServiceStore) LoadTokenRolloverReturns(result1 *service.TokenRollover, result2 error) {
+	fake.loadTokenRolloverMutex.Lock()
+	defer fake.loadTokenRolloverMutex.Unlock()
+	fake.LoadTokenRolloverStub = nil
+	fake.loadTokenRolloverReturns = struct {
+		result1 *service.TokenRollover
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) LoadTokenRolloverReturnsOnCall(i int, result1 *service.TokenRollover, result2 error) {
+	fake.loadTokenRolloverMutex.Lock()
+	defer fake.loadTokenRolloverMutex.Unlock()
+	fake.LoadTokenRolloverStub = nil
+	if fake.loadTokenRolloverReturnsOnCall == nil {
+		fake.loadTokenRolloverReturnsOnCall = make(map[int]struct {
+			result1 *service.TokenRollover
+			result2 error
+		})
+	}
+	fake.loadTokenRolloverReturnsOnCall[i] = struct {
+		result1 *service.TokenRollover
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeServiceStore) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.isRoomLockedMutex.RLock()
+	defer fake.isRoomLockedMutex.RUnlock()
+	fake.isTokenRevokedMutex.RLock()
+	defer fake.isTokenRevokedMutex.RUnlock()
+	fake.listParticipantBansMutex.RLock()
+	defer fake.listParticipantBansMutex.RUnlock()
 	fake.listParticipantsMutex.RLock()
 	defer fake.listParticipantsMutex.RUnlock()
+	fake.listRoomSchedulesMutex.RLock()
+	defer fake.listRoomSchedulesMutex.RUnlock()
 	fake.listRoomsMutex.RLock()
 	defer fake.listRoomsMutex.RUnlock()
 	fake.loadParticipantMutex.RLock()
 	defer fake.loadParticipantMutex.RUnlock()
 	fake.loadRoomMutex.RLock()
 	defer fake.loadRoomMutex.RUnlock()
+	fake.loadRoomJournalMutex.RLock()
+	defer fake.loadRoomJournalMutex.RUnlock()
+	fake.loadRoomScheduleMutex.RLock()
+	defer fake.loadRoomScheduleMutex.RUnlock()
+	fake.loadTokenRolloverMutex.RLock()
+	defer fake.loadTokenRolloverMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,351 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+const (
+	// SIPTransferTopic is the topic of the data message asking a SIP bridge to move a call to another room
+	SIPTransferTopic = "lk.sip.transfer"
+	// the source call leg is ended when the bridge has not left the room after being asked to transfer
+	sipTransferTimeout = 10 * time.Second
+
+	// the hidden participant playing hold music to a participant is named after it
+	sipHoldIdentitySuffix = "#hold"
+	sipHoldCheckInterval  = time.Second
+)
+
+// SIPTransferRequest is the payload of the transfer data message, the bridge joins Room with Token and leaves
+// the room it is in, like a SIP call is moved with REFER
+type SIPTransferRequest struct {
+	Room  livekit.RoomName `json:"room"`
+	Token string           `json:"token"`
+}
+
+// SIPCallManager transfers SIP participants between rooms and places them on hold, for rooms hosted on this node
+type SIPCallManager struct {
+	conf        config.SIPMixConfig
+	roomManager *RoomManager
+	rtcService  *RTCService
+	sessions    *httpSignalSessions
+	telemetry   telemetry.TelemetryService
+	logger      logger.Logger
+
+	lock  sync.Mutex
+	holds map[livekit.ParticipantID]*sipHold
+}
+
+type sipHold struct {
+	identity livekit.ParticipantIdentity
+	muted    []livekit.TrackID
+	done     chan struct{}
+
+	// set once hold music has been published
+	publisher *localPublisher
+}
+
+func NewSIPCallManager(
+	conf *config.Config,
+	roomManager *RoomManager,
+	rtcService *RTCService,
+	telemetry telemetry.TelemetryService,
+) *SIPCallManager {
+	return &SIPCallManager{
+		conf:        conf.Audio.SIPMix,
+		roomManager: roomManager,
+		rtcService:  rtcService,
+		sessions:    newHTTPSignalSessions(),
+		telemetry:   telemetry,
+		logger:      logger.GetLogger().WithComponent("sipcall"),
+		holds:       make(map[livekit.ParticipantID]*sipHold),
+	}
+}
+
+// Transfer asks the participant to move to another room by sending it a join token for that room. The participant
+// is removed from its current room when it has not left by itself in time
+func (m *SIPCallManager) Transfer(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	toRoomName livekit.RoomName,
+) error {
+	if toRoomName == "" || toRoomName == roomName {
+		return ErrTransferRoomInvalid
+	}
+	room, participant, err := m.getParticipant(ctx, roomName, identity)
+	if err != nil {
+		return err
+	}
+
+	key, secret, err := m.roomManager.getFirstKeyPair()
+	if err != nil {
+		return err
+	}
+	grants := participant.ClaimGrants().Clone()
+	grants.Video.Room = string(toRoomName)
+	token := auth.NewAccessToken(key, secret)
+	token.SetName(grants.Name).
+		SetIdentity(string(identity)).
+		SetValidFor(tokenDefaultTTL).
+		SetMetadata(grants.Metadata).
+		AddGrant(grants.Video)
+	jwt, err := token.ToJWT()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(&SIPTransferRequest{Room: toRoomName, Token: jwt})
+	if err != nil {
+		return err
+	}
+
+	// the call is leaving, hold music is not needed anymore
+	m.releaseHold(participant.ID())
+
+	topic := SIPTransferTopic
+	room.SendDataPacket(&livekit.UserPacket{
+		Payload:               payload,
+		DestinationIdentities: []string{string(identity)},
+		Topic:                 &topic,
+	}, livekit.DataPacket_RELIABLE)
+	m.logger.Infow("transferring participant", "room", roomName, "participant", identity, "toRoom", toRoomName)
+
+	toRoom := &livekit.Room{Name: string(toRoomName)}
+	if r := m.roomManager.GetRoom(ctx, toRoomName); r != nil {
+		toRoom = r.ToProto()
+	}
+	m.telemetry.ParticipantTransferred(ctx, toRoom, participant.ToProto())
+
+	participantID := participant.ID()
+	time.AfterFunc(sipTransferTimeout, func() {
+		if p := room.GetParticipant(identity); p != nil && p.ID() == participantID {
+			room.RemoveParticipant(identity, participantID, types.ParticipantCloseReasonServiceRequestRemoveParticipant)
+		}
+	})
+	return nil
+}
+
+// Hold places the participant on hold or takes it off hold. On hold its published tracks are muted, its
+// subscriptions are paused and the configured hold music is played to it
+func (m *SIPCallManager) Hold(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	onHold bool,
+) error {
+	room, participant, err := m.getParticipant(ctx, roomName, identity)
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	hold := m.holds[participant.ID()]
+	if onHold == (hold != nil) {
+		m.lock.Unlock()
+		return nil
+	}
+	if onHold {
+		hold = &sipHold{identity: identity, done: make(chan struct{})}
+		m.holds[participant.ID()] = hold
+	} else {
+		delete(m.holds, participant.ID())
+	}
+	m.lock.Unlock()
+
+	if onHold {
+		for _, track := range participant.GetPublishedTracks() {
+			if !track.IsMuted() {
+				participant.SetTrackMuted(track.ID(), true, true)
+				hold.muted = append(hold.muted, track.ID())
+			}
+		}
+		setSubscriptionsEnabled(participant, false)
+		go m.watchHold(room, participant, hold)
+	} else {
+		m.stopHold(hold)
+		for _, trackID := range hold.muted {
+			participant.SetTrackMuted(trackID, false, true)
+		}
+		setSubscriptionsEnabled(participant, true)
+	}
+
+	m.logger.Infow("participant hold changed", "room", roomName, "participant", identity, "onHold", onHold)
+	m.telemetry.ParticipantHoldChanged(ctx, room.ToProto(), participant.ToProto(), onHold)
+	return nil
+}
+
+func (m *SIPCallManager) Stop() {
+	m.lock.Lock()
+	holds := m.holds
+	m.holds = make(map[livekit.ParticipantID]*sipHold)
+	m.lock.Unlock()
+
+	for _, hold := range holds {
+		m.stopHold(hold)
+	}
+}
+
+func (m *SIPCallManager) getParticipant(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+) (*rtc.Room, types.LocalParticipant, error) {
+	room := m.roomManager.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil, nil, ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return nil, nil, ErrParticipantNotFound
+	}
+	return room, participant, nil
+}
+
+// releaseHold stops the hold of a participant without restoring its tracks
+func (m *SIPCallManager) releaseHold(participantID livekit.ParticipantID) {
+	m.lock.Lock()
+	hold := m.holds[participantID]
+	delete(m.holds, participantID)
+	m.lock.Unlock()
+
+	if hold != nil {
+		m.stopHold(hold)
+	}
+}
+
+func (m *SIPCallManager) stopHold(hold *sipHold) {
+	m.lock.Lock()
+	select {
+	case <-hold.done:
+		m.lock.Unlock()
+		return
+	default:
+		close(hold.done)
+	}
+	publisher := hold.publisher
+	m.lock.Unlock()
+
+	if publisher != nil {
+		publisher.Close()
+	}
+}
+
+// watchHold plays the hold music and releases the hold once the participant leaves
+func (m *SIPCallManager) watchHold(room *rtc.Room, participant types.LocalParticipant, hold *sipHold) {
+	if m.conf.HoldMusic != "" {
+		go m.playHoldMusic(room.Name(), hold)
+	}
+
+	ticker := time.NewTicker(sipHoldCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-hold.done:
+			return
+		case <-ticker.C:
+			if participant.State() == livekit.ParticipantInfo_DISCONNECTED {
+				m.releaseHold(participant.ID())
+				return
+			}
+		}
+	}
+}
+
+func (m *SIPCallManager) playHoldMusic(roomName livekit.RoomName, hold *sipHold) {
+	l := m.logger.WithValues("room", roomName, "participant", hold.identity)
+	packets, err := loadHoldMusic(m.conf.HoldMusic)
+	if err != nil {
+		l.Warnw("could not load hold music", err, "path", m.conf.HoldMusic)
+		return
+	}
+	track, err := webrtc.NewTrackLocalStaticSample(
+		webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+		utils.NewGuid(utils.TrackPrefix),
+		string(hold.identity)+sipHoldIdentitySuffix,
+	)
+	if err != nil {
+		l.Warnw("could not create hold music track", err)
+		return
+	}
+
+	holdIdentity := hold.identity + sipHoldIdentitySuffix
+	pi := localParticipantInit(roomName, holdIdentity, "", true)
+	publisher, err := publishLocalTracks(context.Background(), m.rtcService, m.sessions, roomName, pi, []localTrack{
+		{track: track, source: livekit.TrackSource_MICROPHONE},
+	})
+	if err != nil {
+		l.Warnw("could not publish hold music", err)
+		return
+	}
+
+	m.lock.Lock()
+	select {
+	case <-hold.done:
+		m.lock.Unlock()
+		publisher.Close()
+		return
+	default:
+		hold.publisher = publisher
+	}
+	m.lock.Unlock()
+
+	go func() {
+		if !subscribeToLocalPublisher(m.roomManager, publisher, roomName, holdIdentity, hold.identity) {
+			l.Warnw("could not subscribe to hold music", nil)
+		}
+	}()
+
+	next := time.Now()
+	for {
+		for _, packet := range packets {
+			_ = track.WriteSample(media.Sample{Data: packet.data, Duration: packet.duration})
+			next = next.Add(packet.duration)
+			select {
+			case <-hold.done:
+				return
+			case <-publisher.Done():
+				return
+			case <-time.After(time.Until(next)):
+			}
+		}
+	}
+}
+
+// setSubscriptionsEnabled pauses or resumes forwarding of every track the participant is subscribed to
+func setSubscriptionsEnabled(participant types.LocalParticipant, enabled bool) {
+	for _, track := range participant.GetSubscribedTracks() {
+		track.UpdateSubscriberSettings(&livekit.UpdateTrackSettings{
+			TrackSids: []string{string(track.ID())},
+			Disabled:  !enabled,
+		})
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/agent"
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/mixer"
+)
+
+const (
+	sipMixAgentName = "sip-mix"
+	// the hidden participant publishing a SIP participant's mix is named after it
+	sipMixIdentitySuffix = "#mix"
+	sipMixMaxGain        = 4
+)
+
+var ErrSIPMixDisabled = errors.New("SIP mixing requires an identity prefix")
+
+// SIPMixManager sends each SIP participant a single mix of every other participant's audio instead of separate
+// tracks, so telephony bridges do not have to mix. The mix of a SIP participant is published by a hidden participant
+// and subscribed to by the SIP participant only. Decoding and encoding needs an Opus codec registered with Start.
+type SIPMixManager struct {
+	conf        config.SIPMixConfig
+	roomManager *RoomManager
+	rtcService  *RTCService
+	sessions    *httpSignalSessions
+	logger      logger.Logger
+
+	lock  sync.Mutex
+	codec mixer.Codec
+	agent *agent.Agent
+	rooms map[livekit.RoomName]*roomMix
+	gains map[livekit.RoomName]map[livekit.ParticipantIdentity]float64
+}
+
+type roomMix struct {
+	mixer   *mixer.Mixer
+	outputs map[livekit.ParticipantIdentity]*sipMixOutput
+}
+
+type sipMixOutput struct {
+	publisher *localPublisher
+	closed    bool
+}
+
+func NewSIPMixManager(conf *config.Config, roomManager *RoomManager, rtcService *RTCService) *SIPMixManager {
+	return &SIPMixManager{
+		conf:        conf.Audio.SIPMix,
+		roomManager: roomManager,
+		rtcService:  rtcService,
+		sessions:    newHTTPSignalSessions(),
+		logger:      logger.GetLogger().WithComponent("sipmix"),
+		rooms:       make(map[livekit.RoomName]*roomMix),
+		gains:       make(map[livekit.RoomName]map[livekit.ParticipantIdentity]float64),
+	}
+}
+
+// Start mixes with the given codec, participants joining afterwards with the configured identity prefix
+// are not subscribed to other participants' tracks automatically
+func (m *SIPMixManager) Start(codec mixer.Codec) error {
+	if m.conf.IdentityPrefix == "" {
+		return ErrSIPMixDisabled
+	}
+
+	m.lock.Lock()
+	m.codec = codec
+	m.lock.Unlock()
+
+	a, err := m.roomManager.Agents().Register(agent.Registration{
+		Name:   sipMixAgentName,
+		Format: agent.AudioFormatPCM,
+		NewDecoder: func(_ agent.TrackInfo) (agent.Decoder, error) {
+			return codec.NewDecoder(mixer.SampleRate, mixer.Channels)
+		},
+		OnAudio:      m.onAudio,
+		OnTrackEnded: m.onTrackEnded,
+	})
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	m.agent = a
+	m.lock.Unlock()
+	m.roomManager.sipMixPrefix.Store(m.conf.IdentityPrefix)
+	m.logger.Infow("SIP mixing started", "identityPrefix", m.conf.IdentityPrefix)
+	return nil
+}
+
+func (m *SIPMixManager) Stop() {
+	m.roomManager.sipMixPrefix.Store("")
+
+	m.lock.Lock()
+	a := m.agent
+	m.agent = nil
+	var publishers []*localPublisher
+	for _, rm := range m.rooms {
+		rm.mixer.Stop()
+		for _, output := range rm.outputs {
+			output.closed = true
+			if output.publisher != nil {
+				publishers = append(publishers, output.publisher)
+			}
+		}
+	}
+	m.rooms = make(map[livekit.RoomName]*roomMix)
+	m.lock.Unlock()
+
+	if a != nil {
+		a.Close()
+	}
+	for _, publisher := range publishers {
+		publisher.Close()
+	}
+}
+
+// SetGain sets the gain of a participant's audio in the mixes of a room hosted on this node, from 0 (muted) to 4
+func (m *SIPMixManager) SetGain(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, gain float64) error {
+	if gain < 0 || gain > sipMixMaxGain {
+		return ErrMixGainInvalid
+	}
+	if m.roomManager.GetRoom(ctx, roomName) == nil {
+		return ErrRoomNotFound
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	gains := m.gains[roomName]
+	if gains == nil {
+		gains = make(map[livekit.ParticipantIdentity]float64)
+		m.gains[roomName] = gains
+	}
+	gains[identity] = gain
+	if rm := m.rooms[roomName]; rm != nil {
+		rm.mixer.SetGain(identity, gain)
+	}
+	return nil
+}
+
+func (m *SIPMixManager) isSIP(identity livekit.ParticipantIdentity) bool {
+	return strings.HasPrefix(string(identity), m.conf.IdentityPrefix)
+}
+
+func (m *SIPMixManager) onAudio(frame *agent.AudioFrame) {
+	track := frame.Track
+
+	m.lock.Lock()
+	rm := m.rooms[track.Room]
+	if rm == nil {
+		rm = &roomMix{
+			mixer:   mixer.NewMixer(),
+			outputs: make(map[livekit.ParticipantIdentity]*sipMixOutput),
+		}
+		for identity, gain := range m.gains[track.Room] {
+			rm.mixer.SetGain(identity, gain)
+		}
+		rm.mixer.Start()
+		m.rooms[track.Room] = rm
+	}
+	var output *sipMixOutput
+	if m.isSIP(track.Identity) && rm.outputs[track.Identity] == nil {
+		output = &sipMixOutput{}
+		rm.outputs[track.Identity] = output
+	}
+	codec := m.codec
+	m.lock.Unlock()
+
+	rm.mixer.Push(track.Identity, frame.PCM, frame.Silence)
+	if output != nil {
+		go m.startOutput(codec, rm, track.Room, track.Identity, output)
+	}
+}
+
+func (m *SIPMixManager) onTrackEnded(track agent.TrackInfo) {
+	m.lock.Lock()
+	rm := m.rooms[track.Room]
+	if rm == nil {
+		m.lock.Unlock()
+		return
+	}
+	rm.mixer.RemoveSource(track.Identity)
+	var publisher *localPublisher
+	if output := rm.outputs[track.Identity]; output != nil {
+		delete(rm.outputs, track.Identity)
+		rm.mixer.RemoveOutput(string(track.Identity))
+		output.closed = true
+		publisher = output.publisher
+	}
+	if rm.mixer.IsEmpty() {
+		rm.mixer.Stop()
+		delete(m.rooms, track.Room)
+		if m.roomManager.GetRoom(context.Background(), track.Room) == nil {
+			delete(m.gains, track.Room)
+		}
+	}
+	m.lock.Unlock()
+
+	if publisher != nil {
+		publisher.Close()
+	}
+}
+
+// startOutput publishes the mix of a SIP participant and subscribes the participant to it
+func (m *SIPMixManager) startOutput(
+	codec mixer.Codec,
+	rm *roomMix,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	output *sipMixOutput,
+) {
+	l := m.logger.WithValues("room", roomName, "participant", identity)
+	encoder, err := codec.NewEncoder(mixer.SampleRate, mixer.Channels)
+	if err != nil {
+		l.Warnw("could not create mix encoder", err)
+		return
+	}
+	track, err := webrtc.NewTrackLocalStaticSample(
+		webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+		utils.NewGuid(utils.TrackPrefix),
+		string(identity)+sipMixIdentitySuffix,
+	)
+	if err != nil {
+		l.Warnw("could not create mix track", err)
+		return
+	}
+
+	mixIdentity := identity + sipMixIdentitySuffix
+	pi := localParticipantInit(roomName, mixIdentity, "", true)
+	publisher, err := publishLocalTracks(context.Background(), m.rtcService, m.sessions, roomName, pi, []localTrack{
+		{track: track, source: livekit.TrackSource_MICROPHONE},
+	})
+	if err != nil {
+		l.Warnw("could not publish mix", err)
+		return
+	}
+
+	m.lock.Lock()
+	if output.closed {
+		m.lock.Unlock()
+		publisher.Close()
+		return
+	}
+	output.publisher = publisher
+	m.lock.Unlock()
+
+	rm.mixer.AddOutput(string(identity), identity, func(pcm []int16, _ bool) {
+		data, err := encoder.Encode(pcm)
+		if err != nil {
+			l.Debugw("could not encode mix", "error", err)
+			return
+		}
+		_ = track.WriteSample(media.Sample{Data: data, Duration: mixer.FrameDuration})
+	})
+	l.Infow("publishing SIP mix")
+
+	if !subscribeToLocalPublisher(m.roomManager, publisher, roomName, mixIdentity, identity) {
+		l.Warnw("could not subscribe to mix", nil)
+	}
+}
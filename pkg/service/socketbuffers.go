@@ -0,0 +1,212 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+// udpSocket is a UDP socket of the process with the number of packets the kernel dropped on it
+type udpSocket struct {
+	inode     uint64
+	fd        int
+	localAddr string
+	drops     uint64
+}
+
+type socketDrops struct {
+	drops  uint64
+	streak int
+	capped bool
+}
+
+// SocketBufferMonitor periodically reads the kernel's drop counters of the process' UDP sockets.
+// Receive drops are counted per socket, send buffer errors only per node. When a maximum size is configured,
+// the buffers of sockets dropping packets are doubled until drops stop or the maximum is reached.
+type SocketBufferMonitor struct {
+	conf config.SocketBuffersConfig
+
+	sockets    map[uint64]*socketDrops
+	sendErrors uint64
+	sendStreak int
+	sendCapped bool
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewSocketBufferMonitor returns nil when disabled or not supported on the platform
+func NewSocketBufferMonitor(conf config.SocketBuffersConfig) *SocketBufferMonitor {
+	if !conf.Enabled || !socketStatsSupported {
+		return nil
+	}
+
+	return &SocketBufferMonitor{
+		conf:    conf,
+		sockets: make(map[uint64]*socketDrops),
+		done:    make(chan struct{}),
+	}
+}
+
+func (m *SocketBufferMonitor) Start() {
+	if m == nil {
+		return
+	}
+	go m.worker()
+}
+
+func (m *SocketBufferMonitor) Stop() {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+}
+
+func (m *SocketBufferMonitor) worker() {
+	// errors existing before start are not ours to report
+	if sendErrors, err := udpSendBufferErrors(); err == nil {
+		m.sendErrors = sendErrors
+	}
+
+	ticker := time.NewTicker(m.conf.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+func (m *SocketBufferMonitor) check() {
+	sockets, err := listUDPSockets()
+	if err != nil {
+		logger.Warnw("could not read UDP socket stats", err)
+		return
+	}
+
+	seen := make(map[uint64]*socketDrops, len(sockets))
+	for _, s := range sockets {
+		sd := m.sockets[s.inode]
+		if sd == nil {
+			sd = &socketDrops{}
+		}
+		seen[s.inode] = sd
+
+		if s.drops <= sd.drops {
+			sd.streak = 0
+			continue
+		}
+
+		dropped := s.drops - sd.drops
+		sd.drops = s.drops
+		sd.streak++
+		prometheus.AddSocketDrops(prometheus.Incoming, dropped)
+
+		size, raised := m.raiseBuffer(s, false, &sd.capped)
+		if m.conf.WarnAfter > 0 && sd.streak%m.conf.WarnAfter == 0 {
+			logger.Warnw("UDP socket dropping received packets", nil,
+				"localAddr", s.localAddr,
+				"dropped", dropped,
+				"totalDropped", s.drops,
+				"checks", sd.streak,
+				"bufferSize", size,
+				"raised", raised,
+			)
+		}
+	}
+	// forget closed sockets
+	m.sockets = seen
+
+	sendErrors, err := udpSendBufferErrors()
+	if err != nil {
+		return
+	}
+	if sendErrors <= m.sendErrors {
+		m.sendErrors = sendErrors
+		m.sendStreak = 0
+		return
+	}
+
+	dropped := sendErrors - m.sendErrors
+	m.sendErrors = sendErrors
+	m.sendStreak++
+	prometheus.AddSocketDrops(prometheus.Outgoing, dropped)
+
+	var size int
+	var raised bool
+	for _, s := range sockets {
+		size, raised = m.raiseBuffer(s, true, &m.sendCapped)
+	}
+	if m.conf.WarnAfter > 0 && m.sendStreak%m.conf.WarnAfter == 0 {
+		// the counter is shared by all processes of the network namespace
+		logger.Warnw("UDP send buffer errors on node", nil,
+			"dropped", dropped,
+			"checks", m.sendStreak,
+			"bufferSize", size,
+			"raised", raised,
+		)
+	}
+}
+
+// raiseBuffer doubles a buffer of the socket up to the configured maximum,
+// returning the size of the buffer and whether it was raised
+func (m *SocketBufferMonitor) raiseBuffer(s udpSocket, send bool, capped *bool) (int, bool) {
+	size, err := socketBufferSize(s.fd, s.inode, send)
+	if err != nil || m.conf.MaxBufferSize <= 0 || size >= m.conf.MaxBufferSize {
+		return size, false
+	}
+
+	target := size * 2
+	if target > m.conf.MaxBufferSize {
+		target = m.conf.MaxBufferSize
+	}
+	newSize, err := setSocketBufferSize(s.fd, s.inode, send, target)
+	if err != nil {
+		logger.Warnw("could not raise UDP socket buffer", err, "localAddr", s.localAddr, "send", send, "size", target)
+		return size, false
+	}
+	if newSize <= size {
+		if !*capped {
+			*capped = true
+			logger.Warnw("UDP socket buffer capped by the kernel, raise net.core.rmem_max/wmem_max or grant CAP_NET_ADMIN", nil,
+				"localAddr", s.localAddr,
+				"send", send,
+				"size", size,
+				"requested", target,
+			)
+		}
+		return size, false
+	}
+
+	direction := prometheus.Incoming
+	if send {
+		direction = prometheus.Outgoing
+	}
+	prometheus.IncrementSocketBufferRaises(direction)
+	logger.Infow("raised UDP socket buffer", "localAddr", s.localAddr, "send", send, "from", size, "to", newSize)
+	return newSize, true
+}
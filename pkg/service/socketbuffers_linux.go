@@ -0,0 +1,235 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package service
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const socketStatsSupported = true
+
+var errSocketReplaced = errors.New("socket closed or replaced")
+
+// listUDPSockets returns the UDP sockets owned by the process, matching the inodes of its
+// file descriptors against the sockets of its network namespace
+func listUDPSockets() ([]udpSocket, error) {
+	fds, err := socketInodes()
+	if err != nil {
+		return nil, err
+	}
+
+	var sockets []udpSocket
+	for _, name := range []string{"/proc/self/net/udp", "/proc/self/net/udp6"} {
+		f, err := os.Open(name)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// no IPv6
+				continue
+			}
+			return nil, err
+		}
+		s, err := parseProcNetUDP(f, fds)
+		_ = f.Close()
+		if err != nil {
+			return nil, err
+		}
+		sockets = append(sockets, s...)
+	}
+	return sockets, nil
+}
+
+// socketInodes maps the inodes of the process' sockets to their file descriptors
+func socketInodes() (map[uint64]int, error) {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return nil, err
+	}
+
+	inodes := make(map[uint64]int)
+	for _, e := range entries {
+		link, err := os.Readlink("/proc/self/fd/" + e.Name())
+		if err != nil || !strings.HasPrefix(link, "socket:[") {
+			continue
+		}
+		inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+		if err != nil {
+			continue
+		}
+		fd, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		inodes[inode] = fd
+	}
+	return inodes, nil
+}
+
+// parseProcNetUDP reads the sockets in /proc/net/udp format whose inodes are in fds
+//
+//	sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+func parseProcNetUDP(r io.Reader, fds map[uint64]int) ([]udpSocket, error) {
+	var sockets []udpSocket
+	scanner := bufio.NewScanner(r)
+	header := true
+	for scanner.Scan() {
+		if header {
+			header = false
+			continue
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 13 {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			continue
+		}
+		fd, ok := fds[inode]
+		if !ok {
+			continue
+		}
+		drops, err := strconv.ParseUint(fields[12], 10, 64)
+		if err != nil {
+			continue
+		}
+		sockets = append(sockets, udpSocket{
+			inode:     inode,
+			fd:        fd,
+			localAddr: procNetAddr(fields[1]),
+			drops:     drops,
+		})
+	}
+	return sockets, scanner.Err()
+}
+
+// procNetAddr formats an address of /proc/net/udp, the IP is made of host order 32 bit words
+func procNetAddr(s string) string {
+	host, port, ok := strings.Cut(s, ":")
+	if !ok {
+		return s
+	}
+	b, err := hex.DecodeString(host)
+	if err != nil || len(b)%4 != 0 {
+		return s
+	}
+	for i := 0; i < len(b); i += 4 {
+		b[i], b[i+1], b[i+2], b[i+3] = b[i+3], b[i+2], b[i+1], b[i]
+	}
+	p, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return s
+	}
+	return net.JoinHostPort(net.IP(b).String(), strconv.FormatUint(p, 10))
+}
+
+// udpSendBufferErrors returns the SndbufErrors of the network namespace
+func udpSendBufferErrors() (uint64, error) {
+	f, err := os.Open("/proc/self/net/snmp")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return parseSNMPUDP(f, "SndbufErrors")
+}
+
+// parseSNMPUDP reads a counter of the Udp lines of /proc/net/snmp, a line of names followed by a line of values
+func parseSNMPUDP(r io.Reader, name string) (uint64, error) {
+	scanner := bufio.NewScanner(r)
+	var names []string
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "Udp:" {
+			continue
+		}
+		if names == nil {
+			names = fields
+			continue
+		}
+		for i := 1; i < len(names) && i < len(fields); i++ {
+			if names[i] == name {
+				return strconv.ParseUint(fields[i], 10, 64)
+			}
+		}
+		break
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no Udp %s counter", name)
+}
+
+// socketBufferSize returns the size of a buffer as it would have been set, the kernel doubles set sizes for bookkeeping
+func socketBufferSize(fd int, inode uint64, send bool) (int, error) {
+	if err := checkSocketInode(fd, inode); err != nil {
+		return 0, err
+	}
+	size, err := unix.GetsockoptInt(fd, unix.SOL_SOCKET, bufferOption(send, false))
+	if err != nil {
+		return 0, err
+	}
+	return size / 2, nil
+}
+
+func setSocketBufferSize(fd int, inode uint64, send bool, size int) (int, error) {
+	if err := checkSocketInode(fd, inode); err != nil {
+		return 0, err
+	}
+	// the forced option ignores the system limits but requires CAP_NET_ADMIN
+	if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, bufferOption(send, true), size); err != nil {
+		if err = unix.SetsockoptInt(fd, unix.SOL_SOCKET, bufferOption(send, false), size); err != nil {
+			return 0, err
+		}
+	}
+	return socketBufferSize(fd, inode, send)
+}
+
+func bufferOption(send bool, force bool) int {
+	switch {
+	case send && force:
+		return unix.SO_SNDBUFFORCE
+	case send:
+		return unix.SO_SNDBUF
+	case force:
+		return unix.SO_RCVBUFFORCE
+	default:
+		return unix.SO_RCVBUF
+	}
+}
+
+// checkSocketInode guards against the descriptor having been closed and reused since the sockets were listed
+func checkSocketInode(fd int, inode uint64) error {
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err != nil {
+		return err
+	}
+	if st.Ino != inode {
+		return errSocketReplaced
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package service
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProcNetUDP(t *testing.T) {
+	procNetUDP := `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  120: 0100007F:1B58 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 4242 2 0000000000000000 17
+  121: 00000000:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 999 2 0000000000000000 3
+`
+	sockets, err := parseProcNetUDP(strings.NewReader(procNetUDP), map[uint64]int{4242: 12})
+	require.NoError(t, err)
+	require.Equal(t, []udpSocket{{inode: 4242, fd: 12, localAddr: "127.0.0.1:7000", drops: 17}}, sockets)
+
+	require.Equal(t, "[::1]:7000", procNetAddr("00000000000000000000000001000000:1B58"))
+}
+
+func TestParseSNMPUDP(t *testing.T) {
+	snmp := `Ip: Forwarding DefaultTTL
+Ip: 1 64
+Udp: InDatagrams NoPorts InErrors OutDatagrams RcvbufErrors SndbufErrors InCsumErrors IgnoredMulti MemErrors
+Udp: 1000 2 5 900 4 7 0 0 0
+`
+	sndbufErrors, err := parseSNMPUDP(strings.NewReader(snmp), "SndbufErrors")
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), sndbufErrors)
+
+	_, err = parseSNMPUDP(strings.NewReader(snmp), "Missing")
+	require.Error(t, err)
+}
+
+func TestSocketBufferSize(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sockets, err := listUDPSockets()
+	require.NoError(t, err)
+	var socket *udpSocket
+	for i := range sockets {
+		if sockets[i].localAddr == conn.LocalAddr().String() {
+			socket = &sockets[i]
+		}
+	}
+	require.NotNil(t, socket)
+
+	size, err := socketBufferSize(socket.fd, socket.inode, false)
+	require.NoError(t, err)
+	require.NotZero(t, size)
+
+	// within the default limits
+	newSize, err := setSocketBufferSize(socket.fd, socket.inode, false, 4096)
+	require.NoError(t, err)
+	require.Equal(t, 4096, newSize)
+
+	_, err = socketBufferSize(socket.fd, socket.inode+1, false)
+	require.ErrorIs(t, err, errSocketReplaced)
+}
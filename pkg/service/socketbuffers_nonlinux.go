@@ -0,0 +1,42 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+
+package service
+
+import (
+	"errors"
+)
+
+// socket drop counters are read from /proc, linux only
+const socketStatsSupported = false
+
+var errSocketStatsUnsupported = errors.New("socket stats are not supported on this platform")
+
+func listUDPSockets() ([]udpSocket, error) {
+	return nil, errSocketStatsUnsupported
+}
+
+func udpSendBufferErrors() (uint64, error) {
+	return 0, errSocketStatsUnsupported
+}
+
+func socketBufferSize(_ int, _ uint64, _ bool) (int, error) {
+	return 0, errSocketStatsUnsupported
+}
+
+func setSocketBufferSize(_ int, _ uint64, _ bool, _ int) (int, error) {
+	return 0, errSocketStatsUnsupported
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+
+	"github.com/livekit/protocol/auth"
+)
+
+const tokenIntrospectionPath = "/auth/introspect"
+
+var ErrTokenMissing = errors.New("token is required")
+
+// TokenIntrospectionService validates access tokens for clients that do not hold the API secret,
+// such as frontends and proxies checking a token before connecting with it. The token itself
+// authorizes the request, callers learn no more than what the token already encodes.
+type TokenIntrospectionService struct {
+	keyProvider auth.KeyProvider
+}
+
+func NewTokenIntrospectionService(keyProvider auth.KeyProvider) *TokenIntrospectionService {
+	return &TokenIntrospectionService{
+		keyProvider: keyProvider,
+	}
+}
+
+func (s *TokenIntrospectionService) PathPrefix() string {
+	return tokenIntrospectionPath
+}
+
+type TokenIntrospectionRequest struct {
+	Token string `json:"token"`
+}
+
+type TokenIntrospectionResponse struct {
+	Valid bool `json:"valid"`
+	// reason the token is not valid
+	Error string `json:"error,omitempty"`
+
+	APIKey    string            `json:"api_key,omitempty"`
+	Identity  string            `json:"identity,omitempty"`
+	Room      string            `json:"room,omitempty"`
+	IssuedAt  int64             `json:"issued_at,omitempty"`
+	NotBefore int64             `json:"not_before,omitempty"`
+	ExpiresAt int64             `json:"expires_at,omitempty"`
+	Grants    *auth.ClaimGrants `json:"grants,omitempty"`
+}
+
+func (s *TokenIntrospectionService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		handleError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	var req TokenIntrospectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Token == "" {
+		handleError(w, http.StatusBadRequest, ErrTokenMissing)
+		return
+	}
+
+	writeJSON(w, s.Introspect(req.Token))
+}
+
+// Introspect verifies a token, invalid tokens are reported in the response
+func (s *TokenIntrospectionService) Introspect(token string) *TokenIntrospectionResponse {
+	v, err := auth.ParseAPIToken(token)
+	if err != nil {
+		return &TokenIntrospectionResponse{Error: ErrInvalidAuthorizationToken.Error()}
+	}
+
+	res := &TokenIntrospectionResponse{
+		APIKey:   v.APIKey(),
+		Identity: v.Identity(),
+	}
+	secret := s.keyProvider.GetSecret(v.APIKey())
+	if secret == "" {
+		res.Error = ErrInvalidAPIKey.Error()
+		return res
+	}
+
+	grants, err := v.Verify(secret)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	// signature has been verified, read the registered claims for their times
+	claims, _ := parseTokenClaims(token)
+
+	res.Valid = true
+	res.IssuedAt = unixTime(claims.IssuedAt)
+	res.NotBefore = unixTime(claims.NotBefore)
+	res.ExpiresAt = unixTime(claims.Expiry)
+	res.Grants = grants
+	if grants.Video != nil {
+		res.Room = grants.Video.Room
+	}
+	return res
+}
+
+func unixTime(d *jwt.NumericDate) int64 {
+	if d == nil {
+		return 0
+	}
+	return d.Time().Unix()
+}
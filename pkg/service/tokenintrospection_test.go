@@ -0,0 +1,79 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/auth/authfakes"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestTokenIntrospection(t *testing.T) {
+	api := "APIabcdefg"
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+	s := service.NewTokenIntrospectionService(provider)
+
+	t.Run("valid", func(t *testing.T) {
+		token, err := auth.NewAccessToken(api, secret).
+			SetIdentity("alice").
+			SetValidFor(time.Hour).
+			AddGrant(&auth.VideoGrant{Room: "myroom", RoomJoin: true}).
+			ToJWT()
+		require.NoError(t, err)
+
+		res := s.Introspect(token)
+		require.True(t, res.Valid, res.Error)
+		require.Equal(t, api, res.APIKey)
+		require.Equal(t, "alice", res.Identity)
+		require.Equal(t, "myroom", res.Room)
+		require.True(t, res.Grants.Video.RoomJoin)
+		require.InDelta(t, time.Now().Add(time.Hour).Unix(), res.ExpiresAt, 5)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		token, err := auth.NewAccessToken(api, "anothersecretencodedinbase62").
+			SetIdentity("alice").
+			AddGrant(&auth.VideoGrant{Room: "myroom", RoomJoin: true}).
+			ToJWT()
+		require.NoError(t, err)
+
+		res := s.Introspect(token)
+		require.False(t, res.Valid)
+		require.NotEmpty(t, res.Error)
+		require.Nil(t, res.Grants)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/introspect", strings.NewReader(`{}`)))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/introspect", strings.NewReader(`{"token": "garbage"}`)))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), `"valid":false`)
+	})
+}
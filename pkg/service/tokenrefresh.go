@@ -0,0 +1,175 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// prefix of the IDs of tokens sent to connected participants
+const refreshTokenPrefix = "TK_"
+
+// TokenRollover records the latest token sent to a participant, so that resuming the session can require it
+type TokenRollover struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// ID of the latest token
+	TokenID string `json:"token_id"`
+	// ID of the token replaced by the latest one, accepted until PreviousValidUntil in case the client missed the update
+	PreviousTokenID    string `json:"previous_token_id,omitempty"`
+	PreviousValidUntil int64  `json:"previous_valid_until,omitempty"`
+	// unix seconds, once the latest token cannot resume the session anymore the rollover is forgotten
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+func (t *TokenRollover) Expired(now time.Time) bool {
+	return now.Unix() >= t.ExpiresAt
+}
+
+// Accepts returns true when a token with the given ID may resume the participant's session
+func (t *TokenRollover) Accepts(tokenID string, now time.Time) bool {
+	if tokenID == "" {
+		return false
+	}
+	return tokenID == t.TokenID || (tokenID == t.PreviousTokenID && now.Unix() <= t.PreviousValidUntil)
+}
+
+// tokenRefreshConfig returns the refresh settings, falling back to defaults for configs not loaded from YAML
+func tokenRefreshConfig(conf *config.Config) config.TokenRefreshConfig {
+	refresh := conf.Auth.Refresh
+	if refresh.Interval <= 0 || refresh.TokenTTL <= refresh.Interval {
+		defaults := config.DefaultConfig.Auth.Refresh
+		refresh.Interval = defaults.Interval
+		refresh.TokenTTL = defaults.TokenTTL
+	}
+	return refresh
+}
+
+// newRefreshToken creates a token for a connected participant, unlike tokens created with auth.AccessToken
+// it has an ID so that it can be required on resume and revoked
+func newRefreshToken(
+	apiKey, secret, tokenID string,
+	identity livekit.ParticipantIdentity,
+	grants *auth.ClaimGrants,
+	validFor time.Duration,
+) (string, error) {
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	cl := jwt.Claims{
+		ID:        tokenID,
+		Issuer:    apiKey,
+		NotBefore: jwt.NewNumericDate(now),
+		Expiry:    jwt.NewNumericDate(now.Add(validFor)),
+		Subject:   string(identity),
+	}
+	claims := &auth.ClaimGrants{
+		Name:     grants.Name,
+		Video:    grants.Video,
+		Metadata: grants.Metadata,
+	}
+	return jwt.Signed(sig).Claims(cl).Claims(claims).CompactSerialize()
+}
+
+// checkResumeToken returns ErrTokenNotLatest when a session is resumed with a token other than the latest one sent
+// to the participant. sessions that have not been sent a token yet can be resumed with any valid token
+func checkResumeToken(
+	ctx context.Context,
+	store ServiceStore,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	tokenID string,
+) error {
+	rollover, err := store.LoadTokenRollover(ctx, roomName, identity)
+	if errors.Is(err, ErrTokenRolloverNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !rollover.Accepts(tokenID, time.Now()) {
+		return ErrTokenNotLatest
+	}
+	return nil
+}
+
+// isResumeRequest returns true for signal connections resuming an existing session
+func isResumeRequest(r *http.Request) bool {
+	return r.URL != nil && r.URL.Path == "/rtc" && boolValue(r.FormValue("reconnect"))
+}
+
+// verifyTokenWithLeeway verifies a token like auth.APIKeyTokenVerifier, accepting tokens that expired within leeway
+// on top of the clock skew allowed for all tokens
+func verifyTokenWithLeeway(token, apiKey, secret string, leeway time.Duration) (*auth.ClaimGrants, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, err
+	}
+	out := jwt.Claims{}
+	claims := &auth.ClaimGrants{}
+	if err = parsed.Claims([]byte(secret), &out, claims); err != nil {
+		return nil, err
+	}
+	if err = out.ValidateWithLeeway(jwt.Expected{Issuer: apiKey, Time: time.Now()}, jwt.DefaultLeeway+leeway); err != nil {
+		return nil, err
+	}
+
+	claims.Identity = out.Subject
+	if claims.Identity == "" {
+		claims.Identity = out.ID
+	}
+	return claims, nil
+}
+
+// rollToken records the ID of the latest token sent to a participant
+func (r *RoomManager) rollToken(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	tokenID string,
+	refresh config.TokenRefreshConfig,
+) error {
+	now := time.Now()
+	rollover := &TokenRollover{
+		Room:      string(roomName),
+		Identity:  string(identity),
+		TokenID:   tokenID,
+		ExpiresAt: now.Add(refresh.TokenTTL + refresh.ResumeGrace).Unix(),
+	}
+	previous, err := r.roomStore.LoadTokenRollover(ctx, roomName, identity)
+	switch {
+	case err == nil:
+		rollover.PreviousTokenID = previous.TokenID
+		rollover.PreviousValidUntil = now.Add(refresh.ResumeGrace).Unix()
+	case !errors.Is(err, ErrTokenRolloverNotFound):
+		return err
+	}
+	return r.roomStore.StoreTokenRollover(ctx, rollover)
+}
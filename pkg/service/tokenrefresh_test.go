@@ -0,0 +1,126 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/auth/authfakes"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	testAPIKey    = "APIabcdefg"
+	testAPISecret = "somesecretencodedinbase62"
+)
+
+func TestRefreshToken(t *testing.T) {
+	grants := &auth.ClaimGrants{
+		Name:     "Alice",
+		Video:    &auth.VideoGrant{Room: "myroom", RoomJoin: true},
+		Metadata: "metadata",
+	}
+	token, err := newRefreshToken(testAPIKey, testAPISecret, "TK_id", "alice", grants, time.Minute)
+	require.NoError(t, err)
+
+	v, err := auth.ParseAPIToken(token)
+	require.NoError(t, err)
+	require.Equal(t, "alice", v.Identity())
+	verified, err := v.Verify(testAPISecret)
+	require.NoError(t, err)
+	require.Equal(t, "Alice", verified.Name)
+	require.Equal(t, "metadata", verified.Metadata)
+	require.Equal(t, "myroom", verified.Video.Room)
+	require.Equal(t, "TK_id", parseTokenID(token))
+}
+
+func TestTokenRollover(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore()
+	r := &RoomManager{roomStore: store}
+	refresh := config.TokenRefreshConfig{
+		Interval:    time.Minute,
+		TokenTTL:    2 * time.Minute,
+		ResumeGrace: time.Minute,
+	}
+
+	// any token resumes sessions that have not been sent one
+	require.NoError(t, checkResumeToken(ctx, store, "room", "alice", "app-token"))
+
+	require.NoError(t, r.rollToken(ctx, "room", "alice", "TK_1", refresh))
+	require.NoError(t, checkResumeToken(ctx, store, "room", "alice", "TK_1"))
+	require.ErrorIs(t, checkResumeToken(ctx, store, "room", "alice", "app-token"), ErrTokenNotLatest)
+	require.ErrorIs(t, checkResumeToken(ctx, store, "room", "alice", ""), ErrTokenNotLatest)
+
+	// the replaced token is accepted during the grace period
+	require.NoError(t, r.rollToken(ctx, "room", "alice", "TK_2", refresh))
+	require.NoError(t, checkResumeToken(ctx, store, "room", "alice", "TK_2"))
+	require.NoError(t, checkResumeToken(ctx, store, "room", "alice", "TK_1"))
+
+	rollover, err := store.LoadTokenRollover(ctx, "room", "alice")
+	require.NoError(t, err)
+	require.False(t, rollover.Accepts("TK_1", time.Now().Add(2*time.Minute)))
+	require.True(t, rollover.Accepts("TK_2", time.Now().Add(2*time.Minute)))
+
+	require.NoError(t, store.DeleteTokenRollover(ctx, "room", "alice"))
+	require.NoError(t, checkResumeToken(ctx, store, "room", "alice", "app-token"))
+}
+
+func TestResumeGrace(t *testing.T) {
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(testAPISecret)
+	m := NewAPIKeyAuthMiddleware(provider)
+	m.SetResumeGrace(time.Minute)
+
+	// expired beyond the minute of clock skew allowed for all tokens
+	token, err := newRefreshToken(testAPIKey, testAPISecret, "TK_id", "alice", &auth.ClaimGrants{
+		Video: &auth.VideoGrant{Room: "myroom", RoomJoin: true},
+	}, -90*time.Second)
+	require.NoError(t, err)
+
+	serve := func(target string) (int, *auth.ClaimGrants) {
+		var grants *auth.ClaimGrants
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			grants = GetGrants(r.Context())
+			w.WriteHeader(http.StatusOK)
+		})
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		SetAuthorizationToken(r, token)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, handler)
+		return w.Code, grants
+	}
+
+	code, grants := serve("/rtc?reconnect=1")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "alice", grants.Identity)
+	require.Equal(t, "myroom", grants.Video.Room)
+
+	// new sessions require a valid token
+	code, _ = serve("/rtc")
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	m.SetResumeGrace(10 * time.Second)
+	code, _ = serve("/rtc?reconnect=1")
+	require.Equal(t, http.StatusUnauthorized, code)
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/selector"
+)
+
+// tokenRegionClaims holds the private claim pinning a participant to a region, for data residency the
+// participant's media must not be processed by a node outside of it
+type tokenRegionClaims struct {
+	Region string `json:"region,omitempty"`
+}
+
+// parseTokenRegion reads the region claim of a token without verifying it
+func parseTokenRegion(token string) string {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return ""
+	}
+	var claims tokenRegionClaims
+	if err = parsed.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return ""
+	}
+	return claims.Region
+}
+
+// nodesInRegion returns the nodes located in the given region
+func nodesInRegion(nodes []*livekit.Node, region string) []*livekit.Node {
+	var inRegion []*livekit.Node
+	for _, node := range nodes {
+		if node.Region == region {
+			inRegion = append(inRegion, node)
+		}
+	}
+	return inRegion
+}
+
+// checkTokenRegion returns ErrRegionMismatch when the room is hosted outside the region the token is pinned to,
+// and ErrRegionUnavailable when a new room could not be placed in that region
+func checkTokenRegion(ctx context.Context, router routing.Router, roomName livekit.RoomName) error {
+	region := GetRegion(ctx)
+	if region == "" {
+		return nil
+	}
+
+	node, err := router.GetNodeForRoom(ctx, roomName)
+	if err != nil && err != routing.ErrNotFound {
+		return err
+	}
+	if err == nil && selector.IsAvailable(node) {
+		if node.Region != region {
+			return ErrRegionMismatch
+		}
+		return nil
+	}
+
+	nodes, err := router.ListNodes()
+	if err != nil {
+		return err
+	}
+	for _, n := range nodesInRegion(nodes, region) {
+		if selector.IsAvailable(n) {
+			return nil
+		}
+	}
+	return ErrRegionUnavailable
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+)
+
+func TestParseTokenRegion(t *testing.T) {
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("secret")}, nil)
+	require.NoError(t, err)
+	token, err := jwt.Signed(sig).
+		Claims(jwt.Claims{Issuer: "api-key"}).
+		Claims(tokenRegionClaims{Region: "eu-central"}).
+		CompactSerialize()
+	require.NoError(t, err)
+	require.Equal(t, "eu-central", parseTokenRegion(token))
+
+	token, err = jwt.Signed(sig).Claims(jwt.Claims{Issuer: "api-key"}).CompactSerialize()
+	require.NoError(t, err)
+	require.Equal(t, "", parseTokenRegion(token))
+	require.Equal(t, "", parseTokenRegion("invalid token"))
+}
+
+func TestCheckTokenRegion(t *testing.T) {
+	euNode := &livekit.Node{Id: "eu", Region: "eu-central"}
+	usNode := &livekit.Node{Id: "us", Region: "us-east"}
+	ctx := WithRegion(context.Background(), "eu-central")
+
+	t.Run("unpinned tokens are not checked", func(t *testing.T) {
+		router := &routingfakes.FakeRouter{}
+		router.GetNodeForRoomReturns(usNode, nil)
+		require.NoError(t, checkTokenRegion(context.Background(), router, "myroom"))
+		require.Zero(t, router.GetNodeForRoomCallCount())
+	})
+
+	t.Run("room hosted in the pinned region", func(t *testing.T) {
+		router := &routingfakes.FakeRouter{}
+		router.GetNodeForRoomReturns(euNode, nil)
+		require.NoError(t, checkTokenRegion(ctx, router, "myroom"))
+	})
+
+	t.Run("room hosted outside the pinned region", func(t *testing.T) {
+		router := &routingfakes.FakeRouter{}
+		router.GetNodeForRoomReturns(usNode, nil)
+		require.ErrorIs(t, checkTokenRegion(ctx, router, "myroom"), ErrRegionMismatch)
+	})
+
+	t.Run("new room requires a node in the pinned region", func(t *testing.T) {
+		router := &routingfakes.FakeRouter{}
+		router.GetNodeForRoomReturns(nil, routing.ErrNotFound)
+		router.ListNodesReturns([]*livekit.Node{usNode, euNode}, nil)
+		require.NoError(t, checkTokenRegion(ctx, router, "myroom"))
+
+		router.ListNodesReturns([]*livekit.Node{usNode}, nil)
+		require.ErrorIs(t, checkTokenRegion(ctx, router, "myroom"), ErrRegionUnavailable)
+	})
+}
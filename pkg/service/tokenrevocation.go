@@ -0,0 +1,118 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+
+	"github.com/livekit/protocol/logger"
+)
+
+const tokenHashPrefix = "sha256:"
+
+// TokenRevocation prevents an access token from being used until it expires, tokens are identified by their
+// jti claim, or by the hash of the token when it has no jti
+type TokenRevocation struct {
+	TokenID string `json:"token_id"`
+	Reason  string `json:"reason,omitempty"`
+	// unix seconds, the revocation is forgotten once the token has expired
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+func (t *TokenRevocation) Expired(now time.Time) bool {
+	return now.Unix() >= t.ExpiresAt
+}
+
+// parseTokenClaims reads the registered claims of a token without verifying it
+func parseTokenClaims(token string) (jwt.Claims, error) {
+	var claims jwt.Claims
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return claims, err
+	}
+	err = parsed.UnsafeClaimsWithoutVerification(&claims)
+	return claims, err
+}
+
+func parseTokenID(token string) string {
+	claims, err := parseTokenClaims(token)
+	if err != nil {
+		return ""
+	}
+	return claims.ID
+}
+
+// tokenRevocationID returns the ID revocations of the token are stored under. auth.AccessToken does not set a jti,
+// those tokens are identified by their hash
+func tokenRevocationID(token string) string {
+	if tokenID := parseTokenID(token); tokenID != "" {
+		return tokenID
+	}
+	sum := sha256.Sum256([]byte(token))
+	return tokenHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// checkTokenRevoked returns ErrTokenRevoked when the token has been revoked
+func checkTokenRevoked(ctx context.Context, store ServiceStore, tokenID string) error {
+	if tokenID == "" {
+		return nil
+	}
+	revoked, err := store.IsTokenRevoked(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// watchTokenRevocation checks the token of a connected participant every interval until done is closed.
+// onRevoked is called once when the token has been revoked
+func watchTokenRevocation(
+	store ServiceStore,
+	tokenID string,
+	interval time.Duration,
+	done <-chan struct{},
+	pLogger logger.Logger,
+	onRevoked func(),
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			err := checkTokenRevoked(context.Background(), store, tokenID)
+			switch err {
+			case nil:
+			case ErrTokenRevoked:
+				pLogger.Infow("disconnecting participant with revoked token", "tokenID", tokenID)
+				onRevoked()
+				return
+			default:
+				// keep the participant connected when revocations cannot be checked
+				pLogger.Warnw("could not check token revocation", err, "tokenID", tokenID)
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/auth/authfakes"
+	"github.com/livekit/protocol/logger"
+)
+
+func TestTokenRevocation(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore()
+	now := time.Now()
+
+	require.NoError(t, store.StoreTokenRevocation(ctx, &TokenRevocation{
+		TokenID:   "revoked",
+		ExpiresAt: now.Add(time.Hour).Unix(),
+	}))
+	require.NoError(t, store.StoreTokenRevocation(ctx, &TokenRevocation{
+		TokenID:   "expired",
+		ExpiresAt: now.Add(-time.Second).Unix(),
+	}))
+
+	require.ErrorIs(t, checkTokenRevoked(ctx, store, "revoked"), ErrTokenRevoked)
+	require.NoError(t, checkTokenRevoked(ctx, store, "expired"))
+	require.NoError(t, checkTokenRevoked(ctx, store, "other"))
+
+	require.NoError(t, store.DeleteTokenRevocation(ctx, "revoked"))
+	require.NoError(t, checkTokenRevoked(ctx, store, "revoked"))
+}
+
+func TestWatchTokenRevocation(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore()
+	done := make(chan struct{})
+	defer close(done)
+
+	revoked := make(chan struct{})
+	go watchTokenRevocation(store, "token", 10*time.Millisecond, done, logger.GetLogger(), func() {
+		close(revoked)
+	})
+
+	select {
+	case <-revoked:
+		t.Fatal("token is not revoked yet")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, store.StoreTokenRevocation(ctx, &TokenRevocation{
+		TokenID:   "token",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}))
+	select {
+	case <-revoked:
+	case <-time.After(time.Second):
+		t.Fatal("revocation was not noticed")
+	}
+}
+
+func TestParseTokenID(t *testing.T) {
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("secret")}, nil)
+	require.NoError(t, err)
+	token, err := jwt.Signed(sig).Claims(jwt.Claims{ID: "token-id", Issuer: "api-key"}).CompactSerialize()
+	require.NoError(t, err)
+
+	require.Equal(t, "token-id", parseTokenID(token))
+	require.Equal(t, "", parseTokenID("invalid token"))
+}
+
+func TestTokenRevocationID(t *testing.T) {
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("secret")}, nil)
+	require.NoError(t, err)
+	token, err := jwt.Signed(sig).Claims(jwt.Claims{ID: "token-id", Issuer: "api-key"}).CompactSerialize()
+	require.NoError(t, err)
+	require.Equal(t, "token-id", tokenRevocationID(token))
+
+	// tokens without a jti are identified by their hash
+	sdkToken, err := auth.NewAccessToken("api-key", "secret").SetIdentity("identity").ToJWT()
+	require.NoError(t, err)
+	revocationID := tokenRevocationID(sdkToken)
+	require.True(t, strings.HasPrefix(revocationID, tokenHashPrefix))
+	require.Equal(t, revocationID, tokenRevocationID(sdkToken))
+	require.NotEqual(t, revocationID, tokenRevocationID(token))
+}
+
+func TestAuthMiddlewareTokenRevocation(t *testing.T) {
+	ctx := context.Background()
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+	store := NewLocalStore()
+
+	m := NewAPIKeyAuthMiddleware(provider)
+	m.SetTokenRevocations(store)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	token, err := auth.NewAccessToken("APIabcdefg", secret).
+		AddGrant(&auth.VideoGrant{RoomList: true}).
+		ToJWT()
+	require.NoError(t, err)
+	serve := func() int {
+		r := httptest.NewRequest(http.MethodPost, "/twirp/livekit.RoomService/ListRooms", nil)
+		SetAuthorizationToken(r, token)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, handler)
+		return w.Code
+	}
+	require.Equal(t, http.StatusOK, serve())
+
+	require.NoError(t, store.StoreTokenRevocation(ctx, &TokenRevocation{
+		TokenID:   tokenRevocationID(token),
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}))
+	require.Equal(t, http.StatusUnauthorized, serve())
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/recorder"
+)
+
+// StartTrackForward sends a copy of the RTP packets of a track published to a room hosted on this node to a UDP
+// address, so that external systems can receive it without joining the room
+func (r *RoomManager) StartTrackForward(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	trackID livekit.TrackID,
+	params recorder.TrackForwarderParams,
+) (recorder.TrackForwarderInfo, error) {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return recorder.TrackForwarderInfo{}, ErrRoomNotFound
+	}
+	params.Receiver = nil
+	for _, p := range room.GetParticipants() {
+		if track := p.GetPublishedTrack(trackID); track != nil {
+			if receivers := track.Receivers(); len(receivers) != 0 {
+				// the primary codec of simulcast codec publications, RED is forwarded as the underlying opus stream
+				params.Receiver = receivers[0].GetPrimaryReceiverForRed()
+			}
+			break
+		}
+	}
+	if params.Receiver == nil {
+		return recorder.TrackForwarderInfo{}, ErrTrackNotFound
+	}
+	params.Room = roomName
+	params.TrackID = trackID
+	params.Logger = logger.GetLogger().WithValues("room", roomName, "trackID", trackID)
+
+	forwarder, err := recorder.NewTrackForwarder(params)
+	switch {
+	case errors.Is(err, recorder.ErrInvalidForwardAddress),
+		errors.Is(err, recorder.ErrInvalidPayloadType),
+		errors.Is(err, recorder.ErrInvalidSRTPProfile),
+		errors.Is(err, recorder.ErrInvalidSRTPKey):
+		return recorder.TrackForwarderInfo{}, psrpc.NewError(psrpc.InvalidArgument, err)
+	case err != nil:
+		return recorder.TrackForwarderInfo{}, err
+	}
+
+	id := forwarder.Info().ID
+	r.lock.Lock()
+	r.trackForwarders[id] = forwarder
+	r.lock.Unlock()
+
+	onFinished := func(info recorder.TrackForwarderInfo) {
+		r.lock.Lock()
+		delete(r.trackForwarders, info.ID)
+		r.lock.Unlock()
+	}
+	if err = forwarder.Start(onFinished); err != nil {
+		onFinished(forwarder.Info())
+		return recorder.TrackForwarderInfo{}, psrpc.NewError(psrpc.Unavailable, err)
+	}
+	return forwarder.Info(), nil
+}
+
+// StopTrackForward stops a forwarder and waits for it to end
+func (r *RoomManager) StopTrackForward(ctx context.Context, forwarderID string) (recorder.TrackForwarderInfo, error) {
+	r.lock.RLock()
+	forwarder := r.trackForwarders[forwarderID]
+	r.lock.RUnlock()
+	if forwarder == nil {
+		return recorder.TrackForwarderInfo{}, ErrTrackForwarderNotFound
+	}
+
+	forwarder.Stop()
+	select {
+	case <-forwarder.Finished():
+	case <-ctx.Done():
+		return recorder.TrackForwarderInfo{}, ctx.Err()
+	}
+	return forwarder.Info(), nil
+}
+
+// GetTrackForward returns a forwarder that is running on this node
+func (r *RoomManager) GetTrackForward(forwarderID string) (recorder.TrackForwarderInfo, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	forwarder := r.trackForwarders[forwarderID]
+	if forwarder == nil {
+		return recorder.TrackForwarderInfo{}, ErrTrackForwarderNotFound
+	}
+	return forwarder.Info(), nil
+}
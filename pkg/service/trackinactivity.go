@@ -0,0 +1,214 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+const TrackStalledTopic = "lk.track_stalled"
+
+// TrackStalledMessage is sent to the subscribers of a track when it stops receiving media, and again when it
+// receives media or is muted
+type TrackStalledMessage struct {
+	TrackSid            string `json:"track_sid"`
+	ParticipantIdentity string `json:"participant_identity"`
+	Stalled             bool   `json:"stalled"`
+	// milliseconds since the track last received media
+	InactiveMs int64 `json:"inactive_ms,omitempty"`
+}
+
+// TrackInactivityMonitor periodically checks when published tracks last received media.
+// Muted tracks, and video tracks without subscribers which publishers may pause, are not expected to carry media
+type TrackInactivityMonitor struct {
+	conf  config.TrackInactivityConfig
+	rooms func() []*rtc.Room
+
+	tracks map[livekit.TrackID]*trackActivity
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewTrackInactivityMonitor returns nil when no inactivity threshold is configured
+func NewTrackInactivityMonitor(conf config.TrackInactivityConfig, rooms func() []*rtc.Room) *TrackInactivityMonitor {
+	if !conf.Enabled() {
+		return nil
+	}
+
+	return &TrackInactivityMonitor{
+		conf:   conf,
+		rooms:  rooms,
+		tracks: make(map[livekit.TrackID]*trackActivity),
+		done:   make(chan struct{}),
+	}
+}
+
+func (m *TrackInactivityMonitor) Start() {
+	if m == nil {
+		return
+	}
+	go m.worker()
+}
+
+func (m *TrackInactivityMonitor) Stop() {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+}
+
+func (m *TrackInactivityMonitor) worker() {
+	ticker := time.NewTicker(m.conf.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.check(time.Now())
+		}
+	}
+}
+
+func (m *TrackInactivityMonitor) check(now time.Time) {
+	tracks := make(map[livekit.TrackID]*trackActivity, len(m.tracks))
+	for _, room := range m.rooms() {
+		for _, p := range room.GetParticipants() {
+			for _, track := range p.GetPublishedTracks() {
+				activity := m.tracks[track.ID()]
+				if activity == nil {
+					activity = &trackActivity{}
+				}
+				tracks[track.ID()] = activity
+
+				threshold := m.threshold(track.Kind())
+				lastPacket, ok := lastPacketTime(track)
+				expected := ok && threshold > 0 && !track.IsMuted() &&
+					(track.Kind() == livekit.TrackType_AUDIO || track.GetNumSubscribers() > 0)
+				if activity.update(now, lastPacket, expected, threshold) {
+					m.notify(room, p, track, activity)
+				}
+			}
+		}
+	}
+	m.tracks = tracks
+}
+
+func (m *TrackInactivityMonitor) threshold(kind livekit.TrackType) time.Duration {
+	if kind == livekit.TrackType_AUDIO {
+		return m.conf.Audio
+	}
+	return m.conf.Video
+}
+
+func (m *TrackInactivityMonitor) notify(room *rtc.Room, p types.LocalParticipant, track types.MediaTrack, activity *trackActivity) {
+	msg := &TrackStalledMessage{
+		TrackSid:            string(track.ID()),
+		ParticipantIdentity: string(p.Identity()),
+		Stalled:             activity.stalled,
+	}
+	if activity.stalled {
+		msg.InactiveMs = activity.inactive.Milliseconds()
+		p.GetLogger().Infow("published track stalled", "trackID", track.ID(), "kind", track.Kind(), "inactive", activity.inactive)
+		prometheus.RecordTrackStall(track.Kind().String(), "stalled")
+	} else {
+		p.GetLogger().Infow("published track resumed", "trackID", track.ID(), "kind", track.Kind())
+		prometheus.RecordTrackStall(track.Kind().String(), "resumed")
+	}
+
+	subscribers := track.GetAllSubscribers()
+	if len(subscribers) == 0 {
+		return
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	destinations := make([]string, 0, len(subscribers))
+	for _, sub := range subscribers {
+		destinations = append(destinations, string(sub))
+	}
+	topic := TrackStalledTopic
+	room.SendDataPacket(&livekit.UserPacket{
+		Payload:         payload,
+		DestinationSids: destinations,
+		Topic:           &topic,
+	}, livekit.DataPacket_RELIABLE)
+}
+
+// lastPacketTime returns when any of the track's receivers last received media, ok is false when the
+// track has no local receivers
+func lastPacketTime(track types.MediaTrack) (last time.Time, ok bool) {
+	for _, r := range track.Receivers() {
+		wr, isLocal := r.(*sfu.WebRTCReceiver)
+		if !isLocal {
+			continue
+		}
+		ok = true
+		if at := wr.LastPacketTime(); at.After(last) {
+			last = at
+		}
+	}
+	return
+}
+
+type trackActivity struct {
+	// when the track last became expected to carry media
+	since time.Time
+	// time without media as of the latest update
+	inactive time.Duration
+	stalled  bool
+}
+
+// update returns true when the track became stalled or recovered
+func (a *trackActivity) update(now time.Time, lastPacket time.Time, expected bool, threshold time.Duration) bool {
+	if !expected {
+		a.since = time.Time{}
+		a.inactive = 0
+		if a.stalled {
+			a.stalled = false
+			return true
+		}
+		return false
+	}
+
+	if a.since.IsZero() {
+		a.since = now
+	}
+	if lastPacket.Before(a.since) {
+		lastPacket = a.since
+	}
+	a.inactive = now.Sub(lastPacket)
+	stalled := a.inactive >= threshold
+	if stalled == a.stalled {
+		return false
+	}
+	a.stalled = stalled
+	return true
+}
@@ -0,0 +1,51 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackActivity(t *testing.T) {
+	threshold := 5 * time.Second
+	start := time.Now()
+	a := &trackActivity{}
+
+	// inactivity is counted from when media is expected, not from the last packet before that
+	require.False(t, a.update(start, start.Add(-time.Minute), true, threshold))
+	require.False(t, a.update(start.Add(4*time.Second), start.Add(-time.Minute), true, threshold))
+
+	require.True(t, a.update(start.Add(6*time.Second), start.Add(-time.Minute), true, threshold))
+	require.True(t, a.stalled)
+	require.Equal(t, 6*time.Second, a.inactive)
+	require.False(t, a.update(start.Add(7*time.Second), start.Add(-time.Minute), true, threshold))
+
+	// media resumes
+	require.True(t, a.update(start.Add(8*time.Second), start.Add(8*time.Second), true, threshold))
+	require.False(t, a.stalled)
+
+	// stalls again, then is muted
+	require.True(t, a.update(start.Add(14*time.Second), start.Add(8*time.Second), true, threshold))
+	require.True(t, a.update(start.Add(15*time.Second), start.Add(8*time.Second), false, threshold))
+	require.False(t, a.stalled)
+	require.False(t, a.update(start.Add(30*time.Second), start.Add(8*time.Second), false, threshold))
+
+	// unmuting restarts the wait
+	require.False(t, a.update(start.Add(31*time.Second), start.Add(8*time.Second), true, threshold))
+	require.True(t, a.update(start.Add(36*time.Second), start.Add(8*time.Second), true, threshold))
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/recorder"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// StartTrackRecording records a track published to a room hosted on this node to disk, or to S3 when configured.
+// When audioOnly is set and trackID is empty, every audio track published to the room is recorded, each to its own file.
+// format is empty for the native container of each codec, or recorder.RecordingFormatMP4
+func (r *RoomManager) StartTrackRecording(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	trackID livekit.TrackID,
+	audioOnly bool,
+	format string,
+) ([]recorder.RecordingInfo, error) {
+	if r.config.Recorder.Directory == "" {
+		return nil, ErrRecorderDisabled
+	}
+	if format != "" && format != recorder.RecordingFormatMP4 {
+		return nil, ErrRecordingFormatInvalid
+	}
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	var tracks []types.MediaTrack
+	for _, p := range room.GetParticipants() {
+		if trackID != "" {
+			if track := p.GetPublishedTrack(trackID); track != nil {
+				tracks = append(tracks, track)
+			}
+			continue
+		}
+		if audioOnly {
+			for _, track := range p.GetPublishedTracks() {
+				if track.Kind() == livekit.TrackType_AUDIO {
+					tracks = append(tracks, track)
+				}
+			}
+		}
+	}
+	if len(tracks) == 0 {
+		return nil, ErrTrackNotFound
+	}
+
+	var infos []recorder.RecordingInfo
+	for _, track := range tracks {
+		info, err := r.startTrackRecorder(roomName, track, format)
+		if err != nil {
+			if trackID != "" {
+				return nil, err
+			}
+			logger.Warnw("could not record track", err, "room", roomName, "trackID", track.ID())
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (r *RoomManager) startTrackRecorder(roomName livekit.RoomName, track types.MediaTrack, format string) (recorder.RecordingInfo, error) {
+	receivers := track.Receivers()
+	if len(receivers) == 0 {
+		return recorder.RecordingInfo{}, ErrTrackNotFound
+	}
+	// record the primary codec of simulcast codec publications, RED is recorded as the underlying opus stream
+	receiver := receivers[0].GetPrimaryReceiverForRed()
+
+	rec, err := recorder.NewTrackRecorder(recorder.TrackRecorderParams{
+		Config:   r.config.Recorder,
+		Room:     roomName,
+		TrackID:  track.ID(),
+		Receiver: receiver,
+		Format:   format,
+		Logger:   logger.GetLogger().WithValues("room", roomName, "trackID", track.ID()),
+	})
+	switch {
+	case errors.Is(err, recorder.ErrUnsupportedCodec):
+		return recorder.RecordingInfo{}, ErrRecordingCodecUnsupported
+	case err != nil:
+		return recorder.RecordingInfo{}, err
+	}
+
+	id := rec.Info().ID
+	r.lock.Lock()
+	r.trackRecorders[id] = rec
+	r.lock.Unlock()
+
+	err = rec.Start(func(info recorder.RecordingInfo) {
+		r.lock.Lock()
+		delete(r.trackRecorders, info.ID)
+		r.lock.Unlock()
+	})
+	if err != nil {
+		return recorder.RecordingInfo{}, err
+	}
+	return rec.Info(), nil
+}
+
+// StopTrackRecording stops a recording and waits for it to be stored
+func (r *RoomManager) StopTrackRecording(ctx context.Context, recordingID string) (recorder.RecordingInfo, error) {
+	r.lock.RLock()
+	rec := r.trackRecorders[recordingID]
+	r.lock.RUnlock()
+	if rec == nil {
+		return recorder.RecordingInfo{}, ErrRecordingNotFound
+	}
+
+	rec.Stop()
+	select {
+	case <-rec.Finished():
+	case <-ctx.Done():
+		return recorder.RecordingInfo{}, ctx.Err()
+	}
+	return rec.Info(), nil
+}
+
+// GetTrackRecording returns a recording that is in progress on this node
+func (r *RoomManager) GetTrackRecording(recordingID string) (recorder.RecordingInfo, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	rec := r.trackRecorders[recordingID]
+	if rec == nil {
+		return recorder.RecordingInfo{}, ErrRecordingNotFound
+	}
+	return rec.Info(), nil
+}
@@ -0,0 +1,286 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+const TrackSilenceTopic = "lk.track_silence"
+
+// tracks that have not received media for this long are stalled rather than silent
+const silenceMediaTimeout = 2 * time.Second
+
+// TrackSilenceMessage is sent to the publisher of an unmuted track when it has carried silent audio or static video
+// for a while, so that apps can ask whether the user meant to mute it, and again when that is no longer the case
+type TrackSilenceMessage struct {
+	TrackSid string `json:"track_sid"`
+	// audio or video
+	Kind   string `json:"kind"`
+	Silent bool   `json:"silent"`
+	// milliseconds the track has been silent or static
+	SilentMs int64 `json:"silent_ms,omitempty"`
+}
+
+// TrackSilenceMonitor periodically checks whether unmuted tracks that receive media carry any content.
+// Changes are sent to the publisher and as webhooks, the tracks themselves are left alone
+type TrackSilenceMonitor struct {
+	conf      config.TrackSilenceConfig
+	telemetry telemetry.TelemetryService
+	rooms     func() []*rtc.Room
+
+	tracks map[livekit.TrackID]*trackSilence
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewTrackSilenceMonitor returns nil when no silence threshold is configured
+func NewTrackSilenceMonitor(conf config.TrackSilenceConfig, ts telemetry.TelemetryService, rooms func() []*rtc.Room) *TrackSilenceMonitor {
+	if !conf.Enabled() {
+		return nil
+	}
+
+	return &TrackSilenceMonitor{
+		conf:      conf,
+		telemetry: ts,
+		rooms:     rooms,
+		tracks:    make(map[livekit.TrackID]*trackSilence),
+		done:      make(chan struct{}),
+	}
+}
+
+func (m *TrackSilenceMonitor) Start() {
+	if m == nil {
+		return
+	}
+	go m.worker()
+}
+
+func (m *TrackSilenceMonitor) Stop() {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+}
+
+func (m *TrackSilenceMonitor) worker() {
+	ticker := time.NewTicker(m.conf.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.check(time.Now())
+		}
+	}
+}
+
+func (m *TrackSilenceMonitor) check(now time.Time) {
+	tracks := make(map[livekit.TrackID]*trackSilence, len(m.tracks))
+	for _, room := range m.rooms() {
+		for _, p := range room.GetParticipants() {
+			for _, track := range p.GetPublishedTracks() {
+				silence := m.tracks[track.ID()]
+				if silence == nil {
+					silence = &trackSilence{}
+				}
+				tracks[track.ID()] = silence
+
+				var changed bool
+				lastPacket, ok := lastPacketTime(track)
+				expected := ok && !track.IsMuted() && now.Sub(lastPacket) < silenceMediaTimeout
+				switch track.Kind() {
+				case livekit.TrackType_AUDIO:
+					expected = expected && m.conf.Audio > 0
+					changed = silence.updateAudio(now, lastVoiceActivity(track), expected, m.conf.Audio)
+				case livekit.TrackType_VIDEO:
+					expected = expected && m.conf.Video > 0
+					changed = silence.updateVideo(now, videoContent(track, now), expected, m.conf.Video, m.conf.StaticFrameBytes)
+				}
+				if changed {
+					m.notify(room, p, track, silence)
+				}
+			}
+		}
+	}
+	m.tracks = tracks
+}
+
+func (m *TrackSilenceMonitor) notify(room *rtc.Room, p types.LocalParticipant, track types.MediaTrack, silence *trackSilence) {
+	msg := &TrackSilenceMessage{
+		TrackSid: string(track.ID()),
+		Kind:     strings.ToLower(track.Kind().String()),
+		Silent:   silence.silent,
+	}
+	if silence.silent {
+		msg.SilentMs = silence.silentFor.Milliseconds()
+		p.GetLogger().Infow("published track silent", "trackID", track.ID(), "kind", track.Kind(), "silent", silence.silentFor)
+	} else {
+		p.GetLogger().Infow("published track no longer silent", "trackID", track.ID(), "kind", track.Kind())
+	}
+
+	m.telemetry.TrackSilenceChanged(context.Background(), room.ToProto(), p.ToProto(), track.ToProto(), silence.silent)
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	topic := TrackSilenceTopic
+	room.SendDataPacket(&livekit.UserPacket{
+		Payload:         payload,
+		DestinationSids: []string{string(p.ID())},
+		Topic:           &topic,
+	}, livekit.DataPacket_RELIABLE)
+}
+
+// lastVoiceActivity returns when the audio level of any of the track's receivers was last at or above the active level
+func lastVoiceActivity(track types.MediaTrack) (last time.Time) {
+	for _, r := range track.Receivers() {
+		if wr, ok := r.(*sfu.WebRTCReceiver); ok {
+			if at := wr.LastVoiceActivity(); at.After(last) {
+				last = at
+			}
+		}
+	}
+	return
+}
+
+// videoContent returns the media received so far on all layers of the track's receivers
+func videoContent(track types.MediaTrack, now time.Time) contentSample {
+	sample := contentSample{at: now}
+	for _, r := range track.Receivers() {
+		wr, ok := r.(*sfu.WebRTCReceiver)
+		if !ok {
+			continue
+		}
+		for _, buff := range wr.Buffers() {
+			if stats := buff.GetStats(); stats != nil {
+				sample.bytes += stats.Bytes
+				sample.frames += stats.Frames
+			}
+		}
+	}
+	return sample
+}
+
+type contentSample struct {
+	at     time.Time
+	bytes  uint64
+	frames uint32
+}
+
+type trackSilence struct {
+	// when the track last became expected to carry content
+	since time.Time
+	// video received over the last threshold
+	samples []contentSample
+	// when the video became static
+	staticSince time.Time
+	// time without content as of the latest update
+	silentFor time.Duration
+	silent    bool
+}
+
+// updateAudio returns true when the track became silent or carries voice again
+func (s *trackSilence) updateAudio(now time.Time, lastVoice time.Time, expected bool, threshold time.Duration) bool {
+	if !expected {
+		return s.reset()
+	}
+
+	if s.since.IsZero() {
+		s.since = now
+	}
+	if lastVoice.Before(s.since) {
+		lastVoice = s.since
+	}
+	s.silentFor = now.Sub(lastVoice)
+	return s.setSilent(s.silentFor >= threshold)
+}
+
+// updateVideo returns true when the track became static or carries a changing picture again.
+// The picture is static when the average frame size over the whole threshold stays below maxFrameBytes,
+// so that occasional key frames of a static picture do not count as content
+func (s *trackSilence) updateVideo(now time.Time, sample contentSample, expected bool, threshold time.Duration, maxFrameBytes uint32) bool {
+	if !expected {
+		return s.reset()
+	}
+
+	if s.since.IsZero() {
+		s.since = now
+	}
+	if n := len(s.samples); n != 0 && (sample.bytes < s.samples[n-1].bytes || sample.frames < s.samples[n-1].frames) {
+		// layers have been replaced, counters start over
+		s.samples = s.samples[:0]
+	}
+	s.samples = append(s.samples, sample)
+
+	// keep the latest sample taken at or before the start of the window
+	windowStart := now.Add(-threshold)
+	drop := 0
+	for drop+1 < len(s.samples) && !s.samples[drop+1].at.After(windowStart) {
+		drop++
+	}
+	s.samples = s.samples[drop:]
+
+	first := s.samples[0]
+	if first.at.After(windowStart) {
+		return s.setSilent(false)
+	}
+
+	frames := uint64(sample.frames - first.frames)
+	static := sample.bytes-first.bytes < frames*uint64(maxFrameBytes) || frames == 0
+	if static && !s.silent {
+		s.staticSince = first.at
+	}
+	if static {
+		s.silentFor = now.Sub(s.staticSince)
+	}
+	return s.setSilent(static)
+}
+
+func (s *trackSilence) reset() bool {
+	s.since = time.Time{}
+	s.samples = nil
+	s.silentFor = 0
+	return s.setSilent(false)
+}
+
+func (s *trackSilence) setSilent(silent bool) bool {
+	if silent == s.silent {
+		return false
+	}
+	s.silent = silent
+	if !silent {
+		s.silentFor = 0
+	}
+	return true
+}
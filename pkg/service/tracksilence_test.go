@@ -0,0 +1,101 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackSilence(t *testing.T) {
+	threshold := 10 * time.Second
+	start := time.Now()
+
+	t.Run("audio", func(t *testing.T) {
+		s := &trackSilence{}
+
+		// silence is counted from when content is expected, not from the last voice activity before that
+		require.False(t, s.updateAudio(start, time.Time{}, true, threshold))
+		require.False(t, s.updateAudio(start.Add(9*time.Second), time.Time{}, true, threshold))
+		require.True(t, s.updateAudio(start.Add(11*time.Second), time.Time{}, true, threshold))
+		require.True(t, s.silent)
+		require.Equal(t, 11*time.Second, s.silentFor)
+		require.False(t, s.updateAudio(start.Add(12*time.Second), time.Time{}, true, threshold))
+
+		// voice is detected
+		require.True(t, s.updateAudio(start.Add(13*time.Second), start.Add(13*time.Second), true, threshold))
+		require.False(t, s.silent)
+		require.False(t, s.updateAudio(start.Add(20*time.Second), start.Add(13*time.Second), true, threshold))
+
+		// silent again, then muted
+		require.True(t, s.updateAudio(start.Add(23*time.Second), start.Add(13*time.Second), true, threshold))
+		require.True(t, s.updateAudio(start.Add(24*time.Second), start.Add(13*time.Second), false, threshold))
+		require.False(t, s.silent)
+
+		// unmuting restarts the wait
+		require.False(t, s.updateAudio(start.Add(40*time.Second), start.Add(13*time.Second), true, threshold))
+		require.True(t, s.updateAudio(start.Add(50*time.Second), start.Add(13*time.Second), true, threshold))
+	})
+
+	t.Run("video", func(t *testing.T) {
+		const maxFrameBytes = 200
+		s := &trackSilence{}
+		sample := contentSample{}
+		advance := func(d time.Duration, frameBytes uint64, keyFrameBytes uint64) contentSample {
+			frames := uint32(d / (time.Second / 30))
+			sample = contentSample{
+				at:     sample.at.Add(d),
+				bytes:  sample.bytes + uint64(frames)*frameBytes + keyFrameBytes,
+				frames: sample.frames + frames,
+			}
+			return sample
+		}
+		sample.at = start
+
+		// a changing picture
+		require.False(t, s.updateVideo(sample.at, sample, true, threshold, maxFrameBytes))
+		for i := 0; i < 20; i++ {
+			require.False(t, s.updateVideo(sample.at, advance(time.Second, 2000, 0), true, threshold, maxFrameBytes))
+		}
+
+		// a static picture with a key frame every few seconds is detected once the whole window is static
+		changed := false
+		for i := 0; i < 10 && !changed; i++ {
+			var keyFrame uint64
+			if i%5 == 0 {
+				keyFrame = 10000
+			}
+			changed = s.updateVideo(sample.at, advance(time.Second, 50, keyFrame), true, threshold, maxFrameBytes)
+			require.True(t, changed == (i == 9), "changed at %d", i)
+		}
+		require.True(t, s.silent)
+		require.Equal(t, threshold, s.silentFor)
+
+		// the picture changes again
+		require.True(t, s.updateVideo(sample.at, advance(time.Second, 10000, 0), true, threshold, maxFrameBytes))
+		require.False(t, s.silent)
+
+		// no longer expected, samples start over
+		require.False(t, s.updateVideo(sample.at, advance(time.Second, 0, 0), false, threshold, maxFrameBytes))
+		require.Empty(t, s.samples)
+		require.False(t, s.updateVideo(sample.at, advance(time.Second, 0, 0), true, threshold, maxFrameBytes))
+		for i := 0; i < 9; i++ {
+			require.False(t, s.updateVideo(sample.at, advance(time.Second, 0, 0), true, threshold, maxFrameBytes))
+		}
+		require.True(t, s.updateVideo(sample.at, advance(time.Second, 0, 0), true, threshold, maxFrameBytes))
+	})
+}
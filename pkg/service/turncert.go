@@ -0,0 +1,301 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	turnCertCheckInterval = time.Minute
+	ocspFetchTimeout      = 10 * time.Second
+	ocspRetryInterval     = 10 * time.Minute
+	maxOCSPResponseSize   = 1 << 20
+)
+
+// turnCertificate provides the TURN/TLS certificate, provisioned with ACME or loaded from files that are
+// reloaded when they change, with the issuer's OCSP response stapled when one is available
+type turnCertificate struct {
+	conf       config.TURNConfig
+	acme       *autocert.Manager
+	httpClient *http.Client
+
+	lock        sync.Mutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	checkedAt   time.Time
+	staples     map[string]*ocspStaple
+}
+
+type ocspStaple struct {
+	response  []byte
+	refreshAt time.Time
+	fetching  bool
+}
+
+func newTURNCertificate(conf config.TURNConfig) (*turnCertificate, error) {
+	c := &turnCertificate{
+		conf:       conf,
+		httpClient: &http.Client{Timeout: ocspFetchTimeout},
+		staples:    make(map[string]*ocspStaple),
+	}
+
+	if conf.ACME.Enabled {
+		c.acme = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(conf.Domain),
+			Email:      conf.ACME.Email,
+		}
+		if conf.ACME.CacheDir != "" {
+			c.acme.Cache = autocert.DirCache(conf.ACME.CacheDir)
+		}
+		if conf.ACME.DirectoryURL != "" {
+			c.acme.Client = &acme.Client{DirectoryURL: conf.ACME.DirectoryURL}
+		}
+		return c, nil
+	}
+
+	if err := c.loadFromFiles(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *turnCertificate) TLSConfig() *tls.Config {
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: c.getCertificate,
+	}
+	if c.acme != nil {
+		// answers TLS-ALPN-01 challenges
+		tlsConfig.NextProtos = []string{acme.ALPNProto}
+	}
+	return tlsConfig
+}
+
+// serveChallenges answers ACME HTTP-01 challenges when configured to
+func (c *turnCertificate) serveChallenges() error {
+	if c.acme == nil || c.conf.ACME.HTTPPort == 0 {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(c.conf.ACME.HTTPPort))
+	if err != nil {
+		return errors.Wrap(err, "could not listen on ACME challenge port")
+	}
+	go func() {
+		server := &http.Server{
+			Handler:           c.acme.HTTPHandler(nil),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorw("ACME challenge server stopped", err)
+		}
+	}()
+	return nil
+}
+
+func (c *turnCertificate) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	var cert *tls.Certificate
+	if c.acme != nil {
+		if hello.ServerName == "" {
+			// some TURN clients connect by IP, the certificate is for the configured domain
+			h := *hello
+			h.ServerName = c.conf.Domain
+			hello = &h
+		}
+		var err error
+		if cert, err = c.acme.GetCertificate(hello); err != nil {
+			return nil, err
+		}
+		if len(hello.SupportedProtos) == 1 && hello.SupportedProtos[0] == acme.ALPNProto {
+			// challenge certificate
+			return cert, nil
+		}
+	} else {
+		cert = c.reloadIfChanged()
+	}
+
+	return c.withStaple(cert), nil
+}
+
+func (c *turnCertificate) loadFromFiles() error {
+	cert, err := tls.LoadX509KeyPair(c.conf.CertFile, c.conf.KeyFile)
+	if err != nil {
+		return errors.Wrap(err, "TURN tls cert required")
+	}
+	modTime, err := c.filesModTime()
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.cert = &cert
+	c.certModTime = modTime
+	c.checkedAt = time.Now()
+	c.lock.Unlock()
+	return nil
+}
+
+func (c *turnCertificate) filesModTime() (time.Time, error) {
+	var modTime time.Time
+	for _, f := range []string{c.conf.CertFile, c.conf.KeyFile} {
+		info, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return modTime, nil
+}
+
+func (c *turnCertificate) reloadIfChanged() *tls.Certificate {
+	c.lock.Lock()
+	cert := c.cert
+	if time.Since(c.checkedAt) < turnCertCheckInterval {
+		c.lock.Unlock()
+		return cert
+	}
+	c.checkedAt = time.Now()
+	certModTime := c.certModTime
+	c.lock.Unlock()
+
+	modTime, err := c.filesModTime()
+	if err != nil || !modTime.After(certModTime) {
+		return cert
+	}
+	if err = c.loadFromFiles(); err != nil {
+		logger.Warnw("could not reload TURN certificate, keeping current one", err)
+		return cert
+	}
+	logger.Infow("reloaded TURN certificate")
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.cert
+}
+
+// withStaple returns the certificate with the cached OCSP response, fetching one in the background when due
+func (c *turnCertificate) withStaple(cert *tls.Certificate) *tls.Certificate {
+	if len(cert.Certificate) < 2 {
+		// issuer is needed to verify the response
+		return cert
+	}
+	leaf := cert.Leaf
+	if leaf == nil {
+		var err error
+		if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return cert
+		}
+	}
+	if len(leaf.OCSPServer) == 0 {
+		return cert
+	}
+
+	serial := leaf.SerialNumber.String()
+	c.lock.Lock()
+	staple := c.staples[serial]
+	if staple == nil {
+		// certificates are only replaced when renewed, drop the responses of previous ones
+		c.staples = map[string]*ocspStaple{}
+		staple = &ocspStaple{}
+		c.staples[serial] = staple
+	}
+	if !staple.fetching && time.Now().After(staple.refreshAt) {
+		staple.fetching = true
+		go c.fetchOCSP(staple, leaf, cert.Certificate[1])
+	}
+	response := staple.response
+	c.lock.Unlock()
+
+	if response == nil {
+		return cert
+	}
+	stapled := *cert
+	stapled.OCSPStaple = response
+	return &stapled
+}
+
+func (c *turnCertificate) fetchOCSP(staple *ocspStaple, leaf *x509.Certificate, issuerDER []byte) {
+	response, nextUpdate, err := c.requestOCSP(leaf, issuerDER)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	staple.fetching = false
+	if err != nil {
+		logger.Warnw("could not fetch OCSP response for TURN certificate", err)
+		staple.refreshAt = time.Now().Add(ocspRetryInterval)
+		return
+	}
+	staple.response = response
+	// refresh half way to the next update, as recommended for stapling servers
+	staple.refreshAt = time.Now().Add(time.Until(nextUpdate) / 2)
+	if time.Until(staple.refreshAt) < ocspRetryInterval {
+		staple.refreshAt = time.Now().Add(ocspRetryInterval)
+	}
+}
+
+func (c *turnCertificate) requestOCSP(leaf *x509.Certificate, issuerDER []byte) ([]byte, time.Time, error) {
+	issuer, err := x509.ParseCertificate(issuerDER)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	req, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	res, err := c.httpClient.Post(leaf.OCSPServer[0], "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, time.Time{}, errors.Errorf("OCSP responder returned %d", res.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxOCSPResponseSize))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	parsed, err := ocsp.ParseResponseForCert(body, leaf, issuer)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if parsed.Status != ocsp.Good {
+		return nil, time.Time{}, errors.Errorf("OCSP status %d", parsed.Status)
+	}
+	return body, parsed.NextUpdate, nil
+}
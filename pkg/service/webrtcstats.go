@@ -0,0 +1,259 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+// id of the transport stats object pion reports for the ICE transport of a peer connection
+const rtcStatsTransportID = "iceTransport"
+
+// RTCStatsReport holds the stats of a peer connection in the shape of a W3C RTCStatsReport
+// (https://www.w3.org/TR/webrtc-stats/), keyed by stats object id
+type RTCStatsReport map[string]interface{}
+
+// ParticipantRTCStats holds the stats of the peer connections of a participant as seen from the server.
+// Media received from the participant is reported as inbound-rtp of the publisher connection,
+// media sent to it as outbound-rtp of the subscriber connection
+type ParticipantRTCStats struct {
+	Room       string         `json:"room"`
+	Identity   string         `json:"identity"`
+	Publisher  RTCStatsReport `json:"publisher"`
+	Subscriber RTCStatsReport `json:"subscriber"`
+}
+
+type rtcInboundRTPStats struct {
+	ID                  string                `json:"id"`
+	Timestamp           webrtc.StatsTimestamp `json:"timestamp"`
+	Type                webrtc.StatsType      `json:"type"`
+	SSRC                uint32                `json:"ssrc"`
+	Kind                string                `json:"kind"`
+	TransportID         string                `json:"transportId"`
+	CodecID             string                `json:"codecId,omitempty"`
+	TrackIdentifier     string                `json:"trackIdentifier"`
+	PacketsReceived     uint32                `json:"packetsReceived"`
+	BytesReceived       uint64                `json:"bytesReceived"`
+	HeaderBytesReceived uint64                `json:"headerBytesReceived"`
+	PacketsLost         int64                 `json:"packetsLost"`
+	Jitter              float64               `json:"jitter"`
+	NackCount           uint32                `json:"nackCount"`
+	PliCount            uint32                `json:"pliCount,omitempty"`
+	FirCount            uint32                `json:"firCount,omitempty"`
+	FramesReceived      uint32                `json:"framesReceived,omitempty"`
+	KeyFramesReceived   uint32                `json:"keyFramesReceived,omitempty"`
+	FramesPerSecond     float64               `json:"framesPerSecond,omitempty"`
+	FrameWidth          uint32                `json:"frameWidth,omitempty"`
+	FrameHeight         uint32                `json:"frameHeight,omitempty"`
+}
+
+type rtcOutboundRTPStats struct {
+	ID                       string                `json:"id"`
+	Timestamp                webrtc.StatsTimestamp `json:"timestamp"`
+	Type                     webrtc.StatsType      `json:"type"`
+	SSRC                     uint32                `json:"ssrc"`
+	Kind                     string                `json:"kind"`
+	TransportID              string                `json:"transportId"`
+	CodecID                  string                `json:"codecId,omitempty"`
+	RemoteID                 string                `json:"remoteId"`
+	TrackID                  string                `json:"trackId"`
+	PacketsSent              uint32                `json:"packetsSent"`
+	BytesSent                uint64                `json:"bytesSent"`
+	HeaderBytesSent          uint64                `json:"headerBytesSent"`
+	RetransmittedPacketsSent uint32                `json:"retransmittedPacketsSent"`
+	RetransmittedBytesSent   uint64                `json:"retransmittedBytesSent"`
+	NackCount                uint32                `json:"nackCount"`
+	PliCount                 uint32                `json:"pliCount,omitempty"`
+	FirCount                 uint32                `json:"firCount,omitempty"`
+	FramesSent               uint32                `json:"framesSent,omitempty"`
+	FramesPerSecond          float64               `json:"framesPerSecond,omitempty"`
+}
+
+type rtcRemoteInboundRTPStats struct {
+	ID            string                `json:"id"`
+	Timestamp     webrtc.StatsTimestamp `json:"timestamp"`
+	Type          webrtc.StatsType      `json:"type"`
+	SSRC          uint32                `json:"ssrc"`
+	Kind          string                `json:"kind"`
+	TransportID   string                `json:"transportId"`
+	CodecID       string                `json:"codecId,omitempty"`
+	LocalID       string                `json:"localId"`
+	PacketsLost   int64                 `json:"packetsLost"`
+	Jitter        float64               `json:"jitter"`
+	RoundTripTime float64               `json:"roundTripTime"`
+}
+
+// GetParticipantRTCStats returns the stats of the peer connections of a participant hosted on this node,
+// in the shape of the W3C getStats() API so that tooling built for it can consume them
+func (r *RoomManager) GetParticipantRTCStats(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*ParticipantRTCStats, error) {
+	room := r.GetRoom(ctx, roomName)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	p, ok := room.GetParticipant(identity).(*rtc.ParticipantImpl)
+	if !ok {
+		return nil, ErrParticipantNotFound
+	}
+
+	stats := &ParticipantRTCStats{
+		Room:       string(roomName),
+		Identity:   string(identity),
+		Publisher:  newRTCStatsReport(p.GetTransportStats(livekit.SignalTarget_PUBLISHER)),
+		Subscriber: newRTCStatsReport(p.GetTransportStats(livekit.SignalTarget_SUBSCRIBER)),
+	}
+	now := webrtc.StatsTimestamp(float64(time.Now().UnixNano()) / float64(time.Millisecond))
+
+	for _, track := range p.GetPublishedTracks() {
+		for _, receiver := range track.Receivers() {
+			wr, ok := receiver.(*sfu.WebRTCReceiver)
+			if !ok {
+				continue
+			}
+			codec := wr.Codec()
+			codecID := stats.Publisher.codecID(codec.MimeType, codec.PayloadType)
+			for _, buff := range wr.Buffers() {
+				stats.Publisher.addInboundRTP(now, track, codecID, buff)
+			}
+		}
+	}
+
+	for _, st := range p.GetSubscribedTracks() {
+		dt := st.DownTrack()
+		if dt == nil {
+			continue
+		}
+		codecID := stats.Subscriber.codecID(dt.Codec().MimeType, 0)
+		stats.Subscriber.addOutboundRTP(now, st.ID(), codecID, dt)
+	}
+	return stats, nil
+}
+
+func newRTCStatsReport(report webrtc.StatsReport) RTCStatsReport {
+	r := make(RTCStatsReport, len(report))
+	for id, s := range report {
+		r[id] = s
+	}
+	return r
+}
+
+// codecID returns the id of the codec stats object for mime, matching payload type when not 0
+func (r RTCStatsReport) codecID(mime string, payloadType webrtc.PayloadType) string {
+	for id, s := range r {
+		cs, ok := s.(webrtc.CodecStats)
+		if !ok || !strings.EqualFold(cs.MimeType, mime) {
+			continue
+		}
+		if payloadType == 0 || cs.PayloadType == payloadType {
+			return id
+		}
+	}
+	return ""
+}
+
+func (r RTCStatsReport) addInboundRTP(now webrtc.StatsTimestamp, track types.MediaTrack, codecID string, buff *buffer.Buffer) {
+	rtpStats := buff.GetStats()
+	if rtpStats == nil {
+		return
+	}
+
+	ssrc := buff.GetMediaSSRC()
+	s := rtcInboundRTPStats{
+		ID:                  fmt.Sprintf("inbound-rtp-%d", ssrc),
+		Timestamp:           now,
+		Type:                webrtc.StatsTypeInboundRTP,
+		SSRC:                ssrc,
+		Kind:                strings.ToLower(track.Kind().String()),
+		TransportID:         rtcStatsTransportID,
+		CodecID:             codecID,
+		TrackIdentifier:     string(track.ID()),
+		PacketsReceived:     rtpStats.Packets,
+		BytesReceived:       rtpStats.Bytes,
+		HeaderBytesReceived: rtpStats.HeaderBytes,
+		PacketsLost:         int64(rtpStats.PacketsLost),
+		Jitter:              rtpStats.JitterCurrent / 1e6,
+		NackCount:           rtpStats.Nacks,
+		PliCount:            rtpStats.Plis,
+		FirCount:            rtpStats.Firs,
+		FramesReceived:      rtpStats.Frames,
+		KeyFramesReceived:   rtpStats.KeyFrames,
+	}
+	for _, spec := range buff.GetLayerSpecs() {
+		// the highest layer of the stream, an SVC stream carries all of them
+		if spec.Width >= s.FrameWidth {
+			s.FramesPerSecond = float64(spec.FrameRate)
+			s.FrameWidth = spec.Width
+			s.FrameHeight = spec.Height
+		}
+	}
+	r[s.ID] = s
+}
+
+func (r RTCStatsReport) addOutboundRTP(now webrtc.StatsTimestamp, trackID livekit.TrackID, codecID string, dt *sfu.DownTrack) {
+	rtpStats := dt.GetTrackStats()
+	if rtpStats == nil {
+		return
+	}
+
+	ssrc := dt.SSRC()
+	kind := dt.Kind().String()
+	out := rtcOutboundRTPStats{
+		ID:                       fmt.Sprintf("outbound-rtp-%d", ssrc),
+		Timestamp:                now,
+		Type:                     webrtc.StatsTypeOutboundRTP,
+		SSRC:                     ssrc,
+		Kind:                     kind,
+		TransportID:              rtcStatsTransportID,
+		CodecID:                  codecID,
+		RemoteID:                 fmt.Sprintf("remote-inbound-rtp-%d", ssrc),
+		TrackID:                  string(trackID),
+		PacketsSent:              rtpStats.Packets,
+		BytesSent:                rtpStats.Bytes,
+		HeaderBytesSent:          rtpStats.HeaderBytes,
+		RetransmittedPacketsSent: rtpStats.PacketsDuplicate,
+		RetransmittedBytesSent:   rtpStats.BytesDuplicate,
+		NackCount:                rtpStats.Nacks,
+		PliCount:                 rtpStats.Plis,
+		FirCount:                 rtpStats.Firs,
+		FramesSent:               rtpStats.Frames,
+		FramesPerSecond:          rtpStats.FrameRate,
+	}
+	remote := rtcRemoteInboundRTPStats{
+		ID:            out.RemoteID,
+		Timestamp:     now,
+		Type:          webrtc.StatsTypeRemoteInboundRTP,
+		SSRC:          ssrc,
+		Kind:          kind,
+		TransportID:   rtcStatsTransportID,
+		CodecID:       codecID,
+		LocalID:       out.ID,
+		PacketsLost:   int64(rtpStats.PacketsLost),
+		Jitter:        rtpStats.JitterCurrent / 1e6,
+		RoundTripTime: float64(rtpStats.RttCurrent) / 1e3,
+	}
+	r[out.ID] = out
+	r[remote.ID] = remote
+}
@@ -0,0 +1,44 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRTCStatsReport(t *testing.T) {
+	r := newRTCStatsReport(webrtc.StatsReport{
+		"RTPCodec-1":   webrtc.CodecStats{Type: webrtc.StatsTypeCodec, ID: "RTPCodec-1", PayloadType: 96, MimeType: webrtc.MimeTypeVP8},
+		"RTPCodec-2":   webrtc.CodecStats{Type: webrtc.StatsTypeCodec, ID: "RTPCodec-2", PayloadType: 111, MimeType: webrtc.MimeTypeOpus},
+		"iceTransport": webrtc.TransportStats{Type: webrtc.StatsTypeTransport, ID: "iceTransport", BytesSent: 100},
+	})
+
+	require.Equal(t, "RTPCodec-1", r.codecID("video/vp8", 96))
+	require.Equal(t, "RTPCodec-2", r.codecID("audio/opus", 0))
+	require.Empty(t, r.codecID("video/vp8", 97))
+	require.Empty(t, r.codecID("video/h264", 0))
+
+	// objects keep the W3C shape when rendered
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+	var rendered map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &rendered))
+	require.Equal(t, "transport", rendered["iceTransport"]["type"])
+	require.Equal(t, "video/VP8", rendered["RTPCodec-1"]["mimeType"])
+}
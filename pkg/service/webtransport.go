@@ -0,0 +1,144 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
+	"github.com/urfave/negroni/v3"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	webTransportStreamTimeout = 5 * time.Second
+	webTransportKeepAlive     = 10 * time.Second
+	// signal messages are small, anything larger indicates a broken stream
+	webTransportMaxMessageSize = 1 << 20
+)
+
+// NewWebTransportServer creates an HTTP/3 server that accepts signal connections over WebTransport.
+// Clients connect to /rtc with the same query parameters as the WebSocket endpoint, then open a
+// bidirectional stream that carries length prefixed (uint32, big endian) protobuf signal messages.
+func NewWebTransportServer(addr string, rtcService *RTCService, authMiddleware negroni.Handler) *webtransport.Server {
+	server := &webtransport.Server{
+		H3: http3.Server{
+			Addr: addr,
+			QUICConfig: &quic.Config{
+				KeepAlivePeriod: webTransportKeepAlive,
+			},
+		},
+		// security is enforced by access tokens, same as WebSocket. WebTransport does not go through
+		// the HTTP middlewares, so origins are checked here
+		CheckOrigin: rtcService.originValidator.CheckOrigin,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rtc", func(w http.ResponseWriter, r *http.Request) {
+		rtcService.serveSignal(w, r, func() (SignalConnection, error) {
+			session, err := server.Upgrade(w, r)
+			if err != nil {
+				return nil, err
+			}
+
+			// client opens the signal stream as soon as the session is established
+			ctx, cancel := context.WithTimeout(r.Context(), webTransportStreamTimeout)
+			defer cancel()
+			stream, err := session.AcceptStream(ctx)
+			if err != nil {
+				_ = session.CloseWithError(0, "signal stream not opened")
+				return nil, err
+			}
+			return NewWTSignalConnection(session, stream), nil
+		})
+	})
+	server.H3.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// middlewares are invoked directly, upgrading requires the unwrapped http3 response writer
+		if authMiddleware != nil {
+			authMiddleware.ServeHTTP(w, r, mux.ServeHTTP)
+		} else {
+			mux.ServeHTTP(w, r)
+		}
+	})
+
+	return server
+}
+
+type WTSignalConnection struct {
+	session *webtransport.Session
+	stream  webtransport.Stream
+	reader  *bufio.Reader
+	mu      sync.Mutex
+}
+
+func NewWTSignalConnection(session *webtransport.Session, stream webtransport.Stream) *WTSignalConnection {
+	return &WTSignalConnection{
+		session: session,
+		stream:  stream,
+		reader:  bufio.NewReader(stream),
+	}
+}
+
+func (c *WTSignalConnection) ReadRequest() (*livekit.SignalRequest, int, error) {
+	var size uint32
+	if err := binary.Read(c.reader, binary.BigEndian, &size); err != nil {
+		return nil, 0, err
+	}
+	if size > webTransportMaxMessageSize {
+		return nil, 0, fmt.Errorf("signal message too large: %d", size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return nil, 0, err
+	}
+
+	msg := &livekit.SignalRequest{}
+	err := proto.Unmarshal(payload, msg)
+	return msg, len(payload), err
+}
+
+func (c *WTSignalConnection) WriteResponse(msg *livekit.SignalResponse) (int, error) {
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	frame := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[4:], payload)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err = c.stream.Write(frame); err != nil {
+		return 0, err
+	}
+	return len(payload), nil
+}
+
+func (c *WTSignalConnection) Close() error {
+	return c.session.CloseWithError(0, "")
+}
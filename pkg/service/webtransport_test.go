@@ -0,0 +1,109 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/quic-go/webtransport-go"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+type testWebTransportStream struct {
+	webtransport.Stream
+
+	read    *bytes.Buffer
+	written bytes.Buffer
+}
+
+func (s *testWebTransportStream) Read(p []byte) (int, error) {
+	return s.read.Read(p)
+}
+
+func (s *testWebTransportStream) Write(p []byte) (int, error) {
+	return s.written.Write(p)
+}
+
+func frameSignalMessage(t *testing.T, msg proto.Message) []byte {
+	payload, err := proto.Marshal(msg)
+	require.NoError(t, err)
+
+	frame := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	return append(frame, payload...)
+}
+
+func TestWTSignalConnection(t *testing.T) {
+	ping := &livekit.SignalRequest{Message: &livekit.SignalRequest_Ping{Ping: 123}}
+	leave := &livekit.SignalRequest{Message: &livekit.SignalRequest_Leave{Leave: &livekit.LeaveRequest{}}}
+
+	t.Run("reads length prefixed requests", func(t *testing.T) {
+		// several frames arriving in one read
+		stream := &testWebTransportStream{
+			read: bytes.NewBuffer(append(frameSignalMessage(t, ping), frameSignalMessage(t, leave)...)),
+		}
+		c := NewWTSignalConnection(nil, stream)
+
+		req, count, err := c.ReadRequest()
+		require.NoError(t, err)
+		require.True(t, proto.Equal(ping, req))
+		require.Equal(t, proto.Size(ping), count)
+
+		req, _, err = c.ReadRequest()
+		require.NoError(t, err)
+		require.True(t, proto.Equal(leave, req))
+
+		_, _, err = c.ReadRequest()
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("truncated request", func(t *testing.T) {
+		frame := frameSignalMessage(t, ping)
+		stream := &testWebTransportStream{read: bytes.NewBuffer(frame[:len(frame)-1])}
+		c := NewWTSignalConnection(nil, stream)
+
+		_, _, err := c.ReadRequest()
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+
+	t.Run("oversized request", func(t *testing.T) {
+		frame := binary.BigEndian.AppendUint32(nil, webTransportMaxMessageSize+1)
+		stream := &testWebTransportStream{read: bytes.NewBuffer(frame)}
+		c := NewWTSignalConnection(nil, stream)
+
+		_, _, err := c.ReadRequest()
+		require.ErrorContains(t, err, "signal message too large")
+	})
+
+	t.Run("writes length prefixed responses", func(t *testing.T) {
+		stream := &testWebTransportStream{read: &bytes.Buffer{}}
+		c := NewWTSignalConnection(nil, stream)
+
+		pong := &livekit.SignalResponse{Message: &livekit.SignalResponse_Pong{Pong: 123}}
+		count, err := c.WriteResponse(pong)
+		require.NoError(t, err)
+		require.Equal(t, proto.Size(pong), count)
+		_, err = c.WriteResponse(pong)
+		require.NoError(t, err)
+
+		frame := frameSignalMessage(t, pong)
+		require.Equal(t, append(frame, frame...), stream.written.Bytes())
+	})
+}
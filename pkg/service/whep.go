@@ -0,0 +1,185 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	whepPathPrefix = "/whep"
+)
+
+// WHEPService implements the WebRTC-HTTP Egress Protocol for players that only view media. The player either
+// makes the offer, or sends an empty body to have the server make it.
+//
+//	POST   /whep                        - SDP offer in, SDP answer out, session URL in the Location header
+//	POST   /whep                        - empty body in, SDP offer out, session URL in the Location header
+//	POST   /whep?participant=<identity> - either, only viewing the tracks of the given participant
+//	PATCH  /whep/{id}                   - SDP answer in, for a server offer
+//	DELETE /whep/{id}                   - ends the session
+//
+// The viewer joins the room of its access token, which has to be hosted on this node, a room hosted on another
+// node is answered with 421 Misdirected Request so that the player can be sent to that node. The player cannot
+// renegotiate, so tracks published after the session starts are sent on spare transceivers, as long as there
+// are media sections left over.
+type WHEPService struct {
+	rtcService  *RTCService
+	roomManager *RoomManager
+	sessions    *httpSignalSessions
+}
+
+func NewWHEPService(rtcService *RTCService, roomManager *RoomManager) *WHEPService {
+	return &WHEPService{
+		rtcService:  rtcService,
+		roomManager: roomManager,
+		sessions:    newHTTPSignalSessions(),
+	}
+}
+
+func (s *WHEPService) PathPrefix() string {
+	return whepPathPrefix
+}
+
+func (s *WHEPService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == whepPathPrefix:
+		s.subscribe(w, r)
+	case r.Method == http.MethodPatch:
+		s.answer(w, r)
+	case r.Method == http.MethodDelete:
+		s.sessions.stop(w, r, whepPathPrefix)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *WHEPService) subscribe(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSDPSize))
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	var offer *sdpBody
+	if len(body) != 0 {
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), sdpContentType) {
+			handleError(w, http.StatusUnsupportedMediaType, ErrSDPContentType)
+			return
+		}
+		if offer, err = parseSDP(webrtc.SDPTypeOffer, body); err != nil {
+			handleError(w, http.StatusBadRequest, err)
+			return
+		}
+		if !offer.hasMedia() {
+			handleError(w, http.StatusBadRequest, ErrNoMediaInOffer)
+			return
+		}
+	}
+
+	roomName, pi, code, err := s.rtcService.validate(r)
+	if err != nil {
+		handleError(w, code, err)
+		return
+	}
+	if !pi.Grants.Video.GetCanSubscribe() {
+		handleError(w, http.StatusUnauthorized, rtc.ErrPermissionDenied)
+		return
+	}
+
+	room := s.roomManager.GetRoom(r.Context(), roomName)
+	if room == nil {
+		if s.isRoomOnOtherNode(r.Context(), roomName) {
+			handleError(w, http.StatusMisdirectedRequest, ErrRoomOnOtherNode, "room", roomName)
+			return
+		}
+		handleError(w, http.StatusNotFound, ErrRoomNotFound, "room", roomName)
+		return
+	}
+	publisher := livekit.ParticipantIdentity(r.Form.Get("participant"))
+	if publisher != "" && room.GetParticipant(publisher) == nil {
+		handleError(w, http.StatusNotFound, ErrParticipantNotFound, "room", roomName, "participant", publisher)
+		return
+	}
+
+	// view only, with the subscriber connection as primary since it is the only one established
+	pi.AutoSubscribe = true
+	pi.Client.Protocol = types.CurrentProtocol
+	pi.Grants.Video.SetCanPublish(false)
+	pi.Grants.Video.SetCanPublishData(false)
+	opts := rtc.ParticipantOptions{
+		AutoSubscribe:          true,
+		AutoSubscribePublisher: publisher,
+		SingleNegotiation:      true,
+	}
+	if offer != nil {
+		opts.SubscriberOffer = &offer.sd
+	}
+	s.roomManager.SetJoinOptions(roomName, pi.Identity, opts)
+
+	// the answer to the player's offer, or the server's offer
+	session, sd, err := s.sessions.start(r.Context(), s.rtcService, roomName, pi, nil, livekit.SignalTarget_SUBSCRIBER)
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err, "room", roomName, "participant", pi.Identity)
+		return
+	}
+
+	session.logger.Infow("WHEP session started", "publisher", publisher, "clientOffer", offer != nil)
+	writeSDP(w, whepPathPrefix, session.id, sd)
+}
+
+// the join options of the viewer are kept by the node it connects to, the session cannot be started for a room
+// hosted elsewhere
+func (s *WHEPService) isRoomOnOtherNode(ctx context.Context, roomName livekit.RoomName) bool {
+	router, ok := s.rtcService.router.(routing.Router)
+	if !ok {
+		return false
+	}
+	node, err := router.GetNodeForRoom(ctx, roomName)
+	return err == nil && node.Id != s.rtcService.currentNode.Id
+}
+
+func (s *WHEPService) answer(w http.ResponseWriter, r *http.Request) {
+	session := s.sessions.get(w, r, whepPathPrefix)
+	if session == nil {
+		return
+	}
+
+	answer, code, err := readSDP(r, webrtc.SDPTypeAnswer)
+	if err != nil {
+		handleError(w, code, err)
+		return
+	}
+
+	err = session.cr.RequestSink.WriteMessage(&livekit.SignalRequest{
+		Message: &livekit.SignalRequest_Answer{
+			Answer: rtc.ToProtoSessionDescription(answer.sd),
+		},
+	})
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
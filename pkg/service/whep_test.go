@@ -0,0 +1,69 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+)
+
+func TestWHEPRoomNotLocal(t *testing.T) {
+	subscribe := func(router *routingfakes.FakeRouter) *httptest.ResponseRecorder {
+		rtcService := &RTCService{
+			router:        router,
+			roomAllocator: testRoomAllocator{},
+			store:         NewLocalStore(),
+			currentNode:   &livekit.Node{Id: "ND_local"},
+		}
+		s := NewWHEPService(rtcService, &RoomManager{})
+
+		r := httptest.NewRequest(http.MethodPost, whepPathPrefix, nil)
+		r = r.WithContext(WithGrants(r.Context(), &auth.ClaimGrants{
+			Identity: "viewer",
+			Video:    &auth.VideoGrant{RoomJoin: true, Room: "room"},
+		}))
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("hosted on another node", func(t *testing.T) {
+		router := &routingfakes.FakeRouter{}
+		router.GetNodeForRoomReturns(&livekit.Node{Id: "ND_other"}, nil)
+
+		w := subscribe(router)
+		require.Equal(t, http.StatusMisdirectedRequest, w.Code)
+		require.Contains(t, w.Body.String(), "room is hosted on another node")
+		require.Equal(t, 0, router.StartParticipantSignalCallCount())
+	})
+
+	t.Run("not hosted", func(t *testing.T) {
+		router := &routingfakes.FakeRouter{}
+		router.GetNodeForRoomReturns(nil, routing.ErrNotFound)
+
+		w := subscribe(router)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, 0, router.StartParticipantSignalCallCount())
+	})
+}
@@ -0,0 +1,481 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	whipPathPrefix = "/whip"
+
+	sdpContentType = "application/sdp"
+	maxSDPSize     = 64 * 1024
+
+	httpSignalConnectionTimeout = 10 * time.Second
+	httpSignalAnswerTimeout     = 10 * time.Second
+	// candidates are trickled after the answer, collect them until none arrive for this long
+	httpSignalCandidateIdle    = 250 * time.Millisecond
+	httpSignalCandidateTimeout = 2 * time.Second
+	httpSignalKeepAlive        = 10 * time.Second
+)
+
+var (
+	ErrSDPContentType   = errors.New("content type must be application/sdp")
+	ErrNoMediaInOffer   = errors.New("offer does not contain audio or video")
+	ErrSessionNotFound  = errors.New("session does not exist")
+	ErrSDPNotReady      = errors.New("timed out waiting for session description")
+	ErrSignalConnClosed = errors.New("connection closed by media")
+)
+
+// WHIPService implements the WebRTC-HTTP Ingestion Protocol. A WHIP client publishes into the room
+// of its access token as a participant, without needing a LiveKit client SDK.
+//
+//	POST   /whip       - SDP offer in, SDP answer out, session URL in the Location header
+//	DELETE /whip/{id}  - ends the session
+type WHIPService struct {
+	rtcService *RTCService
+	sessions   *httpSignalSessions
+}
+
+func NewWHIPService(rtcService *RTCService) *WHIPService {
+	return &WHIPService{
+		rtcService: rtcService,
+		sessions:   newHTTPSignalSessions(),
+	}
+}
+
+func (s *WHIPService) PathPrefix() string {
+	return whipPathPrefix
+}
+
+func (s *WHIPService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == whipPathPrefix:
+		s.publish(w, r)
+	case r.Method == http.MethodDelete:
+		s.sessions.stop(w, r, whipPathPrefix)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *WHIPService) publish(w http.ResponseWriter, r *http.Request) {
+	offer, code, err := readSDP(r, webrtc.SDPTypeOffer)
+	if err != nil {
+		handleError(w, code, err)
+		return
+	}
+
+	roomName, pi, code, err := s.rtcService.validate(r)
+	if err != nil {
+		handleError(w, code, err)
+		return
+	}
+	if !pi.Grants.Video.GetCanPublish() {
+		handleError(w, http.StatusUnauthorized, rtc.ErrPermissionDenied)
+		return
+	}
+	// ingest only, never receives media
+	pi.AutoSubscribe = false
+	pi.Grants.Video.SetCanSubscribe(false)
+
+	var requests []*livekit.SignalRequest
+	for _, md := range offer.parsed.MediaDescriptions {
+		trackType, source := livekit.TrackType_AUDIO, livekit.TrackSource_MICROPHONE
+		switch md.MediaName.Media {
+		case "audio":
+		case "video":
+			trackType, source = livekit.TrackType_VIDEO, livekit.TrackSource_CAMERA
+		default:
+			continue
+		}
+		requests = append(requests, &livekit.SignalRequest{
+			Message: &livekit.SignalRequest_AddTrack{
+				AddTrack: &livekit.AddTrackRequest{
+					Cid:    mediaTrackID(md),
+					Name:   md.MediaName.Media,
+					Type:   trackType,
+					Source: source,
+				},
+			},
+		})
+	}
+	if len(requests) == 0 {
+		handleError(w, http.StatusBadRequest, ErrNoMediaInOffer)
+		return
+	}
+	requests = append(requests, &livekit.SignalRequest{
+		Message: &livekit.SignalRequest_Offer{
+			Offer: rtc.ToProtoSessionDescription(offer.sd),
+		},
+	})
+
+	session, answer, err := s.sessions.start(r.Context(), s.rtcService, roomName, pi, requests, livekit.SignalTarget_PUBLISHER)
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err, "room", roomName, "participant", pi.Identity)
+		return
+	}
+
+	session.logger.Infow("WHIP session started")
+	writeSDP(w, whipPathPrefix, session.id, answer)
+}
+
+type sdpBody struct {
+	sd     webrtc.SessionDescription
+	parsed *sdp.SessionDescription
+}
+
+func readSDP(r *http.Request, sdpType webrtc.SDPType) (*sdpBody, int, error) {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), sdpContentType) {
+		return nil, http.StatusUnsupportedMediaType, ErrSDPContentType
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSDPSize))
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	b, err := parseSDP(sdpType, body)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return b, http.StatusOK, nil
+}
+
+func parseSDP(sdpType webrtc.SDPType, body []byte) (*sdpBody, error) {
+	b := &sdpBody{
+		sd: webrtc.SessionDescription{Type: sdpType, SDP: string(body)},
+	}
+	var err error
+	if b.parsed, err = b.sd.Unmarshal(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// hasMedia returns whether the session description has an audio or video section
+func (b *sdpBody) hasMedia() bool {
+	for _, md := range b.parsed.MediaDescriptions {
+		if md.MediaName.Media == "audio" || md.MediaName.Media == "video" {
+			return true
+		}
+	}
+	return false
+}
+
+func writeSDP(w http.ResponseWriter, pathPrefix string, sessionID string, sd string) {
+	w.Header().Set("Content-Type", sdpContentType)
+	w.Header().Set("Location", pathPrefix+"/"+sessionID)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte(sd))
+}
+
+// mediaTrackID returns the track id from msid, falling back to mid
+func mediaTrackID(md *sdp.MediaDescription) string {
+	if msid, ok := md.Attribute("msid"); ok {
+		if parts := strings.Fields(msid); len(parts) == 2 {
+			return parts[1]
+		}
+	}
+	mid, _ := md.Attribute(sdp.AttrKeyMID)
+	return mid
+}
+
+// httpSignalSession drives a participant's signal connection on behalf of an HTTP based client
+// that exchanges a single offer/answer, such as WHIP and WHEP
+type httpSignalSession struct {
+	id       string
+	room     livekit.RoomName
+	identity livekit.ParticipantIdentity
+	cr       connectionResult
+	logger   logger.Logger
+	// cancels the signal stream, which is owned by the session rather than the request that started it
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type httpSignalSessions struct {
+	mu       sync.Mutex
+	sessions map[string]*httpSignalSession
+}
+
+func newHTTPSignalSessions() *httpSignalSessions {
+	return &httpSignalSessions{
+		sessions: make(map[string]*httpSignalSession),
+	}
+}
+
+// start joins the participant, sends the given requests and returns the server's session description for the
+// target, an answer for the publisher, or an offer or answer for the subscriber, with the server's candidates
+// included since HTTP clients do not take trickled candidates
+func (m *httpSignalSessions) start(
+	ctx context.Context,
+	rtcService *RTCService,
+	roomName livekit.RoomName,
+	pi routing.ParticipantInit,
+	requests []*livekit.SignalRequest,
+	target livekit.SignalTarget,
+) (*httpSignalSession, string, error) {
+	// the signal stream has to outlive the request starting the session, it is cancelled when the session ends
+	sessionCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	cr, _, err := rtcService.startConnection(sessionCtx, roomName, pi, httpSignalConnectionTimeout)
+	if err != nil {
+		cancel()
+		return nil, "", err
+	}
+
+	session := &httpSignalSession{
+		id:       string(cr.ConnectionID),
+		room:     roomName,
+		identity: pi.Identity,
+		cr:       cr,
+		logger: rtc.LoggerWithParticipant(
+			rtc.LoggerWithRoom(logger.GetLogger(), roomName, livekit.RoomID(cr.Room.Sid)),
+			pi.Identity,
+			pi.ID,
+			false,
+		).WithValues("connID", cr.ConnectionID),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	for _, req := range requests {
+		if err = cr.RequestSink.WriteMessage(req); err != nil {
+			session.close()
+			return nil, "", err
+		}
+	}
+
+	sd, err := session.readSessionDescription(target)
+	if err != nil {
+		session.close()
+		return nil, "", err
+	}
+
+	m.mu.Lock()
+	m.sessions[session.id] = session
+	m.mu.Unlock()
+
+	go func() {
+		session.run()
+		m.mu.Lock()
+		delete(m.sessions, session.id)
+		m.mu.Unlock()
+	}()
+
+	return session, sd, nil
+}
+
+// get returns the session addressed by the request, writing the error response if there isn't one
+func (m *httpSignalSessions) get(w http.ResponseWriter, r *http.Request, pathPrefix string) *httpSignalSession {
+	id := strings.TrimPrefix(r.URL.Path, pathPrefix+"/")
+
+	m.mu.Lock()
+	session := m.sessions[id]
+	m.mu.Unlock()
+
+	if session == nil {
+		handleError(w, http.StatusNotFound, ErrSessionNotFound)
+		return nil
+	}
+
+	// only the participant that started the session may modify it, identities are only unique within a room
+	claims := GetGrants(r.Context())
+	if claims == nil || claims.Video == nil ||
+		livekit.RoomName(claims.Video.Room) != session.room ||
+		livekit.ParticipantIdentity(claims.Identity) != session.identity {
+		handleError(w, http.StatusUnauthorized, ErrPermissionDenied)
+		return nil
+	}
+	return session
+}
+
+func (m *httpSignalSessions) stop(w http.ResponseWriter, r *http.Request, pathPrefix string) {
+	session := m.get(w, r, pathPrefix)
+	if session == nil {
+		return
+	}
+
+	session.logger.Infow("ending session on request")
+	_ = session.cr.RequestSink.WriteMessage(&livekit.SignalRequest{
+		Message: &livekit.SignalRequest_Leave{
+			Leave: &livekit.LeaveRequest{},
+		},
+	})
+	session.close()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *httpSignalSession) readSessionDescription(target livekit.SignalTarget) (string, error) {
+	var parsed *sdp.SessionDescription
+	var candidates []webrtc.ICECandidateInit
+
+	timeout := time.NewTimer(httpSignalAnswerTimeout)
+	defer timeout.Stop()
+	var idle <-chan time.Time
+	var candidatesDeadline <-chan time.Time
+
+	for {
+		select {
+		case <-timeout.C:
+			if parsed == nil {
+				return "", ErrSDPNotReady
+			}
+			return addCandidates(parsed, candidates)
+
+		case <-idle:
+			return addCandidates(parsed, candidates)
+
+		case <-candidatesDeadline:
+			return addCandidates(parsed, candidates)
+
+		case msg := <-s.cr.ResponseSource.ReadChan():
+			if msg == nil {
+				return "", ErrSignalConnClosed
+			}
+			res, ok := msg.(*livekit.SignalResponse)
+			if !ok {
+				continue
+			}
+
+			var sd *livekit.SessionDescription
+			switch m := res.Message.(type) {
+			case *livekit.SignalResponse_Answer:
+				// viewers that offer receive the answer of the subscriber connection, they never publish
+				sd = m.Answer
+
+			case *livekit.SignalResponse_Offer:
+				if target == livekit.SignalTarget_SUBSCRIBER {
+					sd = m.Offer
+				}
+
+			case *livekit.SignalResponse_Trickle:
+				if m.Trickle.Target != target {
+					continue
+				}
+				candidate, err := rtc.FromProtoTrickle(m.Trickle)
+				if err != nil {
+					s.logger.Warnw("could not parse candidate", err)
+					continue
+				}
+				candidates = append(candidates, candidate)
+				if parsed != nil {
+					idle = time.After(httpSignalCandidateIdle)
+				}
+
+			case *livekit.SignalResponse_Leave:
+				return "", ErrSignalConnClosed
+			}
+
+			if sd != nil && parsed == nil {
+				desc := rtc.FromProtoSessionDescription(sd)
+				var err error
+				if parsed, err = desc.Unmarshal(); err != nil {
+					return "", err
+				}
+				candidatesDeadline = time.After(httpSignalCandidateTimeout)
+				idle = time.After(httpSignalCandidateIdle)
+			}
+		}
+	}
+}
+
+// run keeps the participant alive until the session is ended by the client or the server
+func (s *httpSignalSession) run() {
+	defer s.close()
+
+	ticker := time.NewTicker(httpSignalKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+
+		case <-ticker.C:
+			err := s.cr.RequestSink.WriteMessage(&livekit.SignalRequest{
+				Message: &livekit.SignalRequest_Ping{
+					Ping: time.Now().UnixMilli(),
+				},
+			})
+			if err != nil {
+				s.logger.Warnw("could not send keep alive", err)
+				return
+			}
+
+		case msg := <-s.cr.ResponseSource.ReadChan():
+			if msg == nil {
+				s.logger.Infow("session closed by media")
+				return
+			}
+			res, ok := msg.(*livekit.SignalResponse)
+			if !ok {
+				continue
+			}
+			if res.GetLeave() != nil {
+				s.logger.Infow("session closed by server")
+				return
+			}
+			if res.GetOffer() != nil {
+				s.logger.Infow("ignoring offer, HTTP signaled sessions do not renegotiate")
+			}
+		}
+	}
+}
+
+func (s *httpSignalSession) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.cr.RequestSink.Close()
+		s.cr.ResponseSource.Close()
+		s.cancel()
+	})
+}
+
+func addCandidates(parsed *sdp.SessionDescription, candidates []webrtc.ICECandidateInit) (string, error) {
+	for _, md := range parsed.MediaDescriptions {
+		mid, _ := md.Attribute(sdp.AttrKeyMID)
+		for _, c := range candidates {
+			if c.SDPMid != nil && *c.SDPMid != "" && *c.SDPMid != mid {
+				continue
+			}
+			md.WithValueAttribute(sdp.AttrKeyCandidate, strings.TrimPrefix(c.Candidate, "candidate:"))
+		}
+		if len(candidates) != 0 {
+			md.WithPropertyAttribute(sdp.AttrKeyEndOfCandidates)
+		}
+	}
+
+	data, err := parsed.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
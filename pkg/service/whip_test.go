@@ -0,0 +1,188 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+)
+
+const testOffer = "v=0\r\n" +
+	"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+	"s=-\r\n" +
+	"t=0 0\r\n" +
+	"a=group:BUNDLE 0 1\r\n" +
+	"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\n" +
+	"c=IN IP4 0.0.0.0\r\n" +
+	"a=mid:0\r\n" +
+	"a=msid:stream audio-track\r\n" +
+	"a=sendonly\r\n" +
+	"a=rtpmap:111 opus/48000/2\r\n" +
+	"m=video 9 UDP/TLS/RTP/SAVPF 96\r\n" +
+	"c=IN IP4 0.0.0.0\r\n" +
+	"a=mid:1\r\n" +
+	"a=sendonly\r\n" +
+	"a=rtpmap:96 VP8/90000\r\n"
+
+func TestReadSDP(t *testing.T) {
+	t.Run("requires sdp content type", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/whip", strings.NewReader(testOffer))
+		_, code, err := readSDP(r, webrtc.SDPTypeOffer)
+		require.ErrorIs(t, err, ErrSDPContentType)
+		require.Equal(t, http.StatusUnsupportedMediaType, code)
+	})
+
+	t.Run("track ids from msid or mid", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/whip", strings.NewReader(testOffer))
+		r.Header.Set("Content-Type", sdpContentType)
+		offer, _, err := readSDP(r, webrtc.SDPTypeOffer)
+		require.NoError(t, err)
+		require.Len(t, offer.parsed.MediaDescriptions, 2)
+		require.Equal(t, "audio-track", mediaTrackID(offer.parsed.MediaDescriptions[0]))
+		require.Equal(t, "1", mediaTrackID(offer.parsed.MediaDescriptions[1]))
+	})
+}
+
+func TestAddCandidates(t *testing.T) {
+	sd := webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: testOffer}
+	parsed, err := sd.Unmarshal()
+	require.NoError(t, err)
+
+	mid := "0"
+	answer, err := addCandidates(parsed, []webrtc.ICECandidateInit{
+		{Candidate: "candidate:1 1 udp 2130706431 10.0.0.1 7882 typ host", SDPMid: &mid},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(answer, "a=candidate:1 1 udp 2130706431 10.0.0.1 7882 typ host"))
+	require.Equal(t, 2, strings.Count(answer, "a=end-of-candidates"))
+}
+
+func TestHTTPSignalSessionOutlivesRequest(t *testing.T) {
+	var streamCtx context.Context
+	router := &routingfakes.FakeRouter{}
+	router.StartParticipantSignalStub = func(ctx context.Context, _ livekit.RoomName, _ routing.ParticipantInit) (livekit.ConnectionID, routing.MessageSink, routing.MessageSource, error) {
+		streamCtx = ctx
+		responses := routing.NewMessageChannel("CO_test", 10)
+		_ = responses.WriteMessage(&livekit.SignalResponse{
+			Message: &livekit.SignalResponse_Join{Join: &livekit.JoinResponse{}},
+		})
+		_ = responses.WriteMessage(&livekit.SignalResponse{
+			Message: &livekit.SignalResponse_Answer{Answer: &livekit.SessionDescription{Type: "answer", Sdp: testOffer}},
+		})
+		return "CO_test", routing.NewMessageChannel("CO_test", 10), responses, nil
+	}
+	rtcService := &RTCService{router: router, roomAllocator: testRoomAllocator{}}
+
+	sessions := newHTTPSignalSessions()
+	reqCtx, endRequest := context.WithCancel(context.Background())
+	session, answer, err := sessions.start(reqCtx, rtcService, "room", routing.ParticipantInit{Identity: "publisher"}, nil, livekit.SignalTarget_PUBLISHER)
+	require.NoError(t, err)
+	require.NotEmpty(t, answer)
+
+	// the handler returning must not end the session
+	endRequest()
+	require.NoError(t, streamCtx.Err())
+
+	r := httptest.NewRequest(http.MethodDelete, whipPathPrefix+"/"+session.id, nil)
+	r = r.WithContext(WithGrants(r.Context(), &auth.ClaimGrants{Identity: "publisher", Video: &auth.VideoGrant{Room: "room"}}))
+	w := httptest.NewRecorder()
+	sessions.stop(w, r, whipPathPrefix)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	select {
+	case <-streamCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("signal stream not cancelled when the session ended")
+	}
+}
+
+func TestHTTPSignalSessionPermission(t *testing.T) {
+	router := &routingfakes.FakeRouter{}
+	router.StartParticipantSignalStub = func(ctx context.Context, _ livekit.RoomName, _ routing.ParticipantInit) (livekit.ConnectionID, routing.MessageSink, routing.MessageSource, error) {
+		responses := routing.NewMessageChannel("CO_test", 10)
+		_ = responses.WriteMessage(&livekit.SignalResponse{
+			Message: &livekit.SignalResponse_Join{Join: &livekit.JoinResponse{}},
+		})
+		_ = responses.WriteMessage(&livekit.SignalResponse{
+			Message: &livekit.SignalResponse_Answer{Answer: &livekit.SessionDescription{Type: "answer", Sdp: testOffer}},
+		})
+		return "CO_test", routing.NewMessageChannel("CO_test", 10), responses, nil
+	}
+	rtcService := &RTCService{router: router, roomAllocator: testRoomAllocator{}}
+
+	sessions := newHTTPSignalSessions()
+	session, _, err := sessions.start(context.Background(), rtcService, "room", routing.ParticipantInit{Identity: "publisher"}, nil, livekit.SignalTarget_PUBLISHER)
+	require.NoError(t, err)
+	defer session.close()
+
+	get := func(claims *auth.ClaimGrants) int {
+		r := httptest.NewRequest(http.MethodPatch, whipPathPrefix+"/"+session.id, nil)
+		r = r.WithContext(WithGrants(r.Context(), claims))
+		w := httptest.NewRecorder()
+		sessions.get(w, r, whipPathPrefix)
+		return w.Code
+	}
+
+	// the same identity in another room does not own the session
+	require.Equal(t, http.StatusUnauthorized, get(&auth.ClaimGrants{Identity: "publisher", Video: &auth.VideoGrant{Room: "other"}}))
+	require.Equal(t, http.StatusUnauthorized, get(&auth.ClaimGrants{Identity: "publisher"}))
+	require.Equal(t, http.StatusUnauthorized, get(&auth.ClaimGrants{Identity: "other", Video: &auth.VideoGrant{Room: "room"}}))
+	require.Equal(t, http.StatusOK, get(&auth.ClaimGrants{Identity: "publisher", Video: &auth.VideoGrant{Room: "room"}}))
+}
+
+func TestHTTPSignalSessionSubscriberAnswer(t *testing.T) {
+	router := &routingfakes.FakeRouter{}
+	router.StartParticipantSignalStub = func(ctx context.Context, _ livekit.RoomName, _ routing.ParticipantInit) (livekit.ConnectionID, routing.MessageSink, routing.MessageSource, error) {
+		responses := routing.NewMessageChannel("CO_test", 10)
+		_ = responses.WriteMessage(&livekit.SignalResponse{
+			Message: &livekit.SignalResponse_Join{Join: &livekit.JoinResponse{}},
+		})
+		// answer to a viewer's offer for the subscriber connection
+		_ = responses.WriteMessage(&livekit.SignalResponse{
+			Message: &livekit.SignalResponse_Answer{Answer: &livekit.SessionDescription{Type: "answer", Sdp: testOffer}},
+		})
+		return "CO_test", routing.NewMessageChannel("CO_test", 10), responses, nil
+	}
+	rtcService := &RTCService{router: router, roomAllocator: testRoomAllocator{}}
+
+	sessions := newHTTPSignalSessions()
+	session, answer, err := sessions.start(context.Background(), rtcService, "room", routing.ParticipantInit{Identity: "viewer"}, nil, livekit.SignalTarget_SUBSCRIBER)
+	require.NoError(t, err)
+	require.Contains(t, answer, "m=video")
+	session.close()
+}
+
+type testRoomAllocator struct{}
+
+func (testRoomAllocator) CreateRoom(_ context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+	return &livekit.Room{Name: req.Name, Sid: "RM_test"}, nil
+}
+
+func (testRoomAllocator) ValidateCreateRoom(context.Context, livekit.RoomName) error {
+	return nil
+}
@@ -62,6 +62,7 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		getEgressStore,
 		NewEgressLauncher,
 		NewEgressService,
+		NewAudioCompositeManager,
 		rpc.NewIngressClient,
 		getIngressStore,
 		getIngressConfig,
@@ -69,6 +70,12 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		NewRoomAllocator,
 		NewRoomService,
 		NewRTCService,
+		NewRTSPIngestManager,
+		NewSIPMixManager,
+		NewSIPCallManager,
+		NewAdminService,
+		NewWHIPService,
+		NewWHEPService,
 		getSignalRelayConfig,
 		NewDefaultSignalServer,
 		routing.NewSignalClient,
@@ -133,18 +140,32 @@ func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webh
 	if len(wc.URLs) == 0 {
 		return nil, nil
 	}
-	secret := provider.GetSecret(wc.APIKey)
-	if secret == "" {
-		return nil, ErrWebHookMissingAPIKey
+	keys := make([]telemetry.WebhookSigningKey, 0, len(wc.SigningKeys)+1)
+	for _, apiKey := range append([]string{wc.APIKey}, wc.SigningKeys...) {
+		if len(keys) != 0 && apiKey == wc.APIKey {
+			continue
+		}
+		secret := provider.GetSecret(apiKey)
+		if secret == "" {
+			return nil, ErrWebHookMissingAPIKey
+		}
+		keys = append(keys, telemetry.WebhookSigningKey{ID: apiKey, Secret: secret})
 	}
 
-	return webhook.NewDefaultNotifier(wc.APIKey, secret, wc.URLs), nil
+	return telemetry.NewWebhookNotifier(telemetry.WebhookNotifierParams{
+		URLs:         wc.URLs,
+		Keys:         keys,
+		ReplayWindow: wc.ReplayWindow,
+	}), nil
 }
 
 func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
 	if !conf.Redis.IsConfigured() {
 		return nil, nil
 	}
+	if conf.InternalTLS.Enabled {
+		return createMTLSRedisClient(&conf.Redis, conf.InternalTLS)
+	}
 	return redisLiveKit.GetRedisClient(&conf.Redis)
 }
 
@@ -155,11 +176,11 @@ func createStore(rc redis.UniversalClient) ObjectStore {
 	return NewLocalStore()
 }
 
-func getMessageBus(rc redis.UniversalClient) psrpc.MessageBus {
+func getMessageBus(rc redis.UniversalClient, conf *config.Config) psrpc.MessageBus {
 	if rc == nil {
-		return psrpc.NewLocalMessageBus()
+		return newChaosMessageBus(psrpc.NewLocalMessageBus(), conf.Chaos)
 	}
-	return psrpc.NewRedisMessageBus(rc)
+	return newChaosMessageBus(psrpc.NewRedisMessageBus(rc), conf.Chaos)
 }
 
 func getEgressStore(s ObjectStore) EgressStore {
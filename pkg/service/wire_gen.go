@@ -40,7 +40,7 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		return nil, err
 	}
 	nodeID := getNodeID(currentNode)
-	messageBus := getMessageBus(universalClient)
+	messageBus := getMessageBus(universalClient, conf)
 	signalRelayConfig := getSignalRelayConfig(conf)
 	signalClient, err := routing.NewSignalClient(nodeID, messageBus, signalRelayConfig)
 	if err != nil {
@@ -72,7 +72,6 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	egressService := NewEgressService(egressClient, objectStore, egressStore, roomService, telemetryService, rtcEgressLauncher)
 	ingressConfig := getIngressConfig(conf)
 	ingressClient, err := rpc.NewIngressClient(nodeID, messageBus)
 	if err != nil {
@@ -92,6 +91,17 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
+	audioCompositeManager := NewAudioCompositeManager(conf, roomManager, egressStore, telemetryService)
+	egressService := NewEgressService(egressClient, objectStore, egressStore, roomService, telemetryService, rtcEgressLauncher, audioCompositeManager)
+	rtspIngestManager := NewRTSPIngestManager(rtcService)
+	sipMixManager := NewSIPMixManager(conf, roomManager, rtcService)
+	sipCallManager := NewSIPCallManager(conf, roomManager, rtcService, telemetryService)
+	adminService, err := NewAdminService(roomManager, objectStore, rtspIngestManager, sipMixManager, sipCallManager, audioCompositeManager, router, currentNode, messageBus)
+	if err != nil {
+		return nil, err
+	}
+	whipService := NewWHIPService(rtcService)
+	whepService := NewWHEPService(rtcService, roomManager)
 	signalServer, err := NewDefaultSignalServer(currentNode, messageBus, signalRelayConfig, router, roomManager)
 	if err != nil {
 		return nil, err
@@ -101,7 +111,7 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	livekitServer, err := NewLivekitServer(conf, roomService, egressService, ingressService, ioInfoService, rtcService, keyProvider, router, roomManager, signalServer, server, currentNode)
+	livekitServer, err := NewLivekitServer(conf, roomService, egressService, ingressService, ioInfoService, rtcService, adminService, whipService, whepService, keyProvider, router, roomManager, sipMixManager, sipCallManager, audioCompositeManager, signalServer, server, currentNode)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +124,7 @@ func InitializeRouter(conf *config.Config, currentNode routing.LocalNode) (routi
 		return nil, err
 	}
 	nodeID := getNodeID(currentNode)
-	messageBus := getMessageBus(universalClient)
+	messageBus := getMessageBus(universalClient, conf)
 	signalRelayConfig := getSignalRelayConfig(conf)
 	signalClient, err := routing.NewSignalClient(nodeID, messageBus, signalRelayConfig)
 	if err != nil {
@@ -164,18 +174,32 @@ func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webh
 	if len(wc.URLs) == 0 {
 		return nil, nil
 	}
-	secret := provider.GetSecret(wc.APIKey)
-	if secret == "" {
-		return nil, ErrWebHookMissingAPIKey
+	keys := make([]telemetry.WebhookSigningKey, 0, len(wc.SigningKeys)+1)
+	for _, apiKey := range append([]string{wc.APIKey}, wc.SigningKeys...) {
+		if len(keys) != 0 && apiKey == wc.APIKey {
+			continue
+		}
+		secret := provider.GetSecret(apiKey)
+		if secret == "" {
+			return nil, ErrWebHookMissingAPIKey
+		}
+		keys = append(keys, telemetry.WebhookSigningKey{ID: apiKey, Secret: secret})
 	}
 
-	return webhook.NewDefaultNotifier(wc.APIKey, secret, wc.URLs), nil
+	return telemetry.NewWebhookNotifier(telemetry.WebhookNotifierParams{
+		URLs:         wc.URLs,
+		Keys:         keys,
+		ReplayWindow: wc.ReplayWindow,
+	}), nil
 }
 
 func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
 	if !conf.Redis.IsConfigured() {
 		return nil, nil
 	}
+	if conf.InternalTLS.Enabled {
+		return createMTLSRedisClient(&conf.Redis, conf.InternalTLS)
+	}
 	return redis2.GetRedisClient(&conf.Redis)
 }
 
@@ -186,11 +210,11 @@ func createStore(rc redis.UniversalClient) ObjectStore {
 	return NewLocalStore()
 }
 
-func getMessageBus(rc redis.UniversalClient) psrpc.MessageBus {
+func getMessageBus(rc redis.UniversalClient, conf *config.Config) psrpc.MessageBus {
 	if rc == nil {
-		return psrpc.NewLocalMessageBus()
+		return newChaosMessageBus(psrpc.NewLocalMessageBus(), conf.Chaos)
 	}
-	return psrpc.NewRedisMessageBus(rc)
+	return newChaosMessageBus(psrpc.NewRedisMessageBus(rc), conf.Chaos)
 }
 
 func getEgressStore(s ObjectStore) EgressStore {
@@ -37,13 +37,16 @@ type WSSignalConnection struct {
 	conn    types.WebsocketClient
 	mu      sync.Mutex
 	useJSON bool
+	// reject JSON messages instead of switching to JSON responses
+	binaryOnly bool
 }
 
-func NewWSSignalConnection(conn types.WebsocketClient) *WSSignalConnection {
+func NewWSSignalConnection(conn types.WebsocketClient, binaryOnly bool) *WSSignalConnection {
 	wsc := &WSSignalConnection{
-		conn:    conn,
-		mu:      sync.Mutex{},
-		useJSON: false,
+		conn:       conn,
+		mu:         sync.Mutex{},
+		useJSON:    false,
+		binaryOnly: binaryOnly,
 	}
 	go wsc.pingWorker()
 	return wsc
@@ -70,6 +73,14 @@ func (c *WSSignalConnection) ReadRequest() (*livekit.SignalRequest, int, error)
 			err := proto.Unmarshal(payload, msg)
 			return msg, len(payload), err
 		case websocket.TextMessage:
+			if c.binaryOnly {
+				_ = c.conn.WriteControl(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseUnsupportedData, ErrJSONSignalDisabled.Error()),
+					time.Now().Add(pingTimeout),
+				)
+				return nil, len(payload), ErrJSONSignalDisabled
+			}
 			c.mu.Lock()
 			// json encoded, also write back JSON
 			c.useJSON = true
@@ -105,6 +116,10 @@ func (c *WSSignalConnection) WriteResponse(msg *livekit.SignalResponse) (int, er
 	return len(payload), c.conn.WriteMessage(msgType, payload)
 }
 
+func (c *WSSignalConnection) Close() error {
+	return c.conn.Close()
+}
+
 func (c *WSSignalConnection) pingWorker() {
 	for {
 		<-time.After(pingFrequency)
@@ -0,0 +1,85 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+type testWebsocketClient struct {
+	messageType int
+	payload     []byte
+
+	written      []int
+	controlTypes []int
+}
+
+func (c *testWebsocketClient) ReadMessage() (int, []byte, error) {
+	return c.messageType, c.payload, nil
+}
+
+func (c *testWebsocketClient) WriteMessage(messageType int, _ []byte) error {
+	c.written = append(c.written, messageType)
+	return nil
+}
+
+func (c *testWebsocketClient) WriteControl(messageType int, _ []byte, _ time.Time) error {
+	c.controlTypes = append(c.controlTypes, messageType)
+	return nil
+}
+
+func (c *testWebsocketClient) Close() error {
+	return nil
+}
+
+func TestWSSignalConnection(t *testing.T) {
+	payload, err := protojson.Marshal(&livekit.SignalRequest{
+		Message: &livekit.SignalRequest_Ping{Ping: 1},
+	})
+	require.NoError(t, err)
+
+	t.Run("JSON requests switch responses to JSON", func(t *testing.T) {
+		client := &testWebsocketClient{messageType: websocket.TextMessage, payload: payload}
+		conn := NewWSSignalConnection(client, false)
+
+		req, _, err := conn.ReadRequest()
+		require.NoError(t, err)
+		require.Equal(t, int64(1), req.GetPing())
+
+		_, err = conn.WriteResponse(&livekit.SignalResponse{Message: &livekit.SignalResponse_Pong{Pong: 1}})
+		require.NoError(t, err)
+		require.Equal(t, []int{websocket.TextMessage}, client.written)
+	})
+
+	t.Run("binary only rejects JSON", func(t *testing.T) {
+		client := &testWebsocketClient{messageType: websocket.TextMessage, payload: payload}
+		conn := NewWSSignalConnection(client, true)
+
+		_, _, err := conn.ReadRequest()
+		require.ErrorIs(t, err, ErrJSONSignalDisabled)
+		require.Equal(t, []int{websocket.CloseMessage}, client.controlTypes)
+
+		_, err = conn.WriteResponse(&livekit.SignalResponse{Message: &livekit.SignalResponse_Pong{Pong: 1}})
+		require.NoError(t, err)
+		require.Equal(t, []int{websocket.BinaryMessage}, client.written)
+	})
+}
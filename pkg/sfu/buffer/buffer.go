@@ -36,11 +36,12 @@ import (
 	"github.com/livekit/mediatransportutil"
 	"github.com/livekit/mediatransportutil/pkg/bucket"
 	"github.com/livekit/mediatransportutil/pkg/nack"
-	"github.com/livekit/mediatransportutil/pkg/twcc"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 
 	dd "github.com/livekit/livekit-server/pkg/sfu/dependencydescriptor"
+	"github.com/livekit/livekit-server/pkg/sfu/twcc"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 const (
@@ -62,6 +63,13 @@ type ExtPacket struct {
 	KeyFrame             bool
 	RawPacket            []byte
 	DependencyDescriptor *ExtDependencyDescriptor
+	// holds RawPacket and Packet.Payload when read from a Buffer, must be cleared when the payload is replaced
+	Buffer *PacketBuffer
+	// set on packets sampled for forwarding latency, when the receiver read it from the buffer
+	SampledAt time.Time
+	// set on the first packet after the publisher restarted sequence numbers and timestamps, e. g. on replacing
+	// the source of a track, to continue the outgoing timeline from it
+	SourceSwitch bool
 }
 
 // Buffer contains all packets
@@ -83,10 +91,55 @@ type Buffer struct {
 	bound         bool
 	closed        atomic.Bool
 	mime          string
+	lastPacketAt  atomic.Int64
+	// arrival time of the latest packet at or above the active audio level
+	lastVoiceAt atomic.Int64
+
+	// highest sequence number of a RED stream, to find packets to recover from redundant encodings
+	redInitialized bool
+	redHighestSN   uint16
+
+	// spacing of receiver reports, the next one is due reportInterval after lastReport
+	rtcpSchedule   RTCPSchedule
+	reportInterval time.Duration
+	reportBytes    int
+
+	// reordering waited out before NACKing, see SetReorderTolerance
+	reorderTolerance ReorderTolerance
+	pendingNACKs     []uint64
+
+	// the next packet handed to readers starts a new source
+	pendingSourceSwitch bool
+	onSourceSwitch      func()
+
+	// when set, video packets are held back until their frame is complete
+	frameAssembler *frameAssembler
+
+	// per interval stats kept by rtpStats, see SetRTPStatsHistory
+	rtpStatsHistoryInterval time.Duration
+	rtpStatsHistorySize     int
+
+	// measured frame rate and resolution of video layers
+	layerSpecs *layerSpecs
 
 	snRangeMap       *utils.RangeMap[uint64, uint64]
 	paddingOnlyDrops uint64
 
+	// negotiated identity of the stream, packets not matching it are dropped
+	mid                string
+	rid                string
+	midExt             uint8
+	ridExt             uint8
+	rridExt            uint8
+	invalidStreamDrops uint64
+
+	// payload type of received packets, given to packets restored from the RTX stream
+	payloadType uint8
+	// buffer whose stream is repaired by the RTX stream of this one
+	rtxPrimary *Buffer
+	// finds the buffer repaired by an RTX packet, set by the factory
+	resolveRTX func(h *rtp.Header) *Buffer
+
 	latestTSForAudioLevelInitialized bool
 	latestTSForAudioLevel            uint32
 
@@ -105,11 +158,12 @@ type Buffer struct {
 	lastFractionLostToReport uint8 // Last fraction lost from subscribers, should report to publisher; Audio only
 
 	// callbacks
-	onClose            func()
-	onRtcpFeedback     func([]rtcp.Packet)
-	onRtcpSenderReport func()
-	onFpsChanged       func()
-	onFinalRtpStats    func(*livekit.RTPStats)
+	onClose             func()
+	onRtcpFeedback      func([]rtcp.Packet)
+	onRtcpSenderReport  func()
+	onFpsChanged        func()
+	onLayerSpecsChanged func([]LayerSpec)
+	onFinalRtpStats     func(stats *livekit.RTPStats, history []*livekit.RTPStats)
 
 	// logger
 	logger logger.Logger
@@ -124,18 +178,22 @@ type Buffer struct {
 
 	packetNotFoundCount atomic.Uint32
 	packetTooOldCount   atomic.Uint32
+
+	capture *Capture
 }
 
 // NewBuffer constructs a new Buffer
 func NewBuffer(ssrc uint32, vp, ap *sync.Pool) *Buffer {
 	l := logger.GetLogger() // will be reset with correct context via SetLogger
 	b := &Buffer{
-		mediaSSRC:   ssrc,
-		videoPool:   vp,
-		audioPool:   ap,
-		snRangeMap:  utils.NewRangeMap[uint64, uint64](100),
-		pliThrottle: int64(500 * time.Millisecond),
-		logger:      l.WithComponent(sutils.ComponentPub).WithComponent(sutils.ComponentSFU),
+		mediaSSRC:      ssrc,
+		videoPool:      vp,
+		audioPool:      ap,
+		snRangeMap:     utils.NewRangeMap[uint64, uint64](100),
+		pliThrottle:    int64(500 * time.Millisecond),
+		rtcpSchedule:   RTCPSchedule{Interval: ReportDelta},
+		reportInterval: ReportDelta,
+		logger:         l.WithComponent(sutils.ComponentPub).WithComponent(sutils.ComponentSFU),
 	}
 	b.extPackets.SetMinCapacity(7)
 	return b
@@ -158,6 +216,49 @@ func (b *Buffer) SetPaused(paused bool) {
 	b.paused = paused
 }
 
+// SetRTCPSchedule sets the spacing of receiver reports, it applies from the next report
+func (b *Buffer) SetRTCPSchedule(schedule RTCPSchedule) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.rtcpSchedule = schedule
+}
+
+// SetRTPStatsHistory keeps the stats of up to size recent intervals of the given length, it cannot be changed
+// once enabled
+func (b *Buffer) SetRTPStatsHistory(interval time.Duration, size int) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.rtpStatsHistoryInterval = interval
+	b.rtpStatsHistorySize = size
+	if b.rtpStats != nil {
+		b.rtpStats.EnableHistory(interval, size)
+	}
+}
+
+// SetFrameAssembly holds back packets until their frame is complete, for at most maxDelay. 0 disables it
+func (b *Buffer) SetFrameAssembly(maxDelay time.Duration) {
+	b.Lock()
+	defer b.Unlock()
+
+	if maxDelay <= 0 {
+		if b.frameAssembler != nil {
+			for _, ep := range b.frameAssembler.flush() {
+				b.extPackets.PushBack(ep)
+			}
+			b.frameAssembler = nil
+		}
+		return
+	}
+
+	if b.frameAssembler == nil {
+		b.frameAssembler = newFrameAssembler(maxDelay)
+	} else {
+		b.frameAssembler.maxDelay = maxDelay
+	}
+}
+
 func (b *Buffer) SetTWCC(twcc *twcc.Responder) {
 	b.Lock()
 	defer b.Unlock()
@@ -172,6 +273,16 @@ func (b *Buffer) SetAudioLevelParams(audioLevelParams audio.AudioLevelParams) {
 	b.audioLevelParams = audioLevelParams
 }
 
+// SetStreamIdentity sets the MID and RID negotiated for this stream, it should be called before Bind.
+// Packets carrying a MID or RID header extension that does not match are dropped.
+func (b *Buffer) SetStreamIdentity(mid string, rid string) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.mid = mid
+	b.rid = rid
+}
+
 func (b *Buffer) Bind(params webrtc.RTPParameters, codec webrtc.RTPCodecCapability) {
 	b.Lock()
 	defer b.Unlock()
@@ -185,6 +296,7 @@ func (b *Buffer) Bind(params webrtc.RTPParameters, codec webrtc.RTPCodecCapabili
 	})
 	b.rrSnapshotId = b.rtpStats.NewSnapshotId()
 	b.deltaStatsSnapshotId = b.rtpStats.NewSnapshotId()
+	b.rtpStats.EnableHistory(b.rtpStatsHistoryInterval, b.rtpStatsHistorySize)
 
 	b.clockRate = codec.ClockRate
 	b.lastReport = time.Now()
@@ -205,6 +317,15 @@ func (b *Buffer) Bind(params webrtc.RTPParameters, codec webrtc.RTPCodecCapabili
 		case sdp.AudioLevelURI:
 			b.audioLevelExt = uint8(ext.ID)
 			b.audioLevel = audio.NewAudioLevel(b.audioLevelParams)
+
+		case sdp.SDESMidURI:
+			b.midExt = uint8(ext.ID)
+
+		case sdp.SDESRTPStreamIDURI:
+			b.ridExt = uint8(ext.ID)
+
+		case RepairedRTPStreamIDURI:
+			b.rridExt = uint8(ext.ID)
 		}
 	}
 
@@ -215,6 +336,7 @@ func (b *Buffer) Bind(params webrtc.RTPParameters, codec webrtc.RTPCodecCapabili
 	case strings.HasPrefix(b.mime, "video/"):
 		b.codecType = webrtc.RTPCodecTypeVideo
 		b.bucket = bucket.NewBucket(b.videoPool.Get().(*[]byte))
+		b.layerSpecs = newLayerSpecs(b.mime)
 		if b.frameRateCalculator[0] == nil {
 			if strings.EqualFold(codec.MimeType, webrtc.MimeTypeVP8) {
 				b.frameRateCalculator[0] = NewFrameRateCalculatorVP8(b.clockRate, b.logger)
@@ -255,7 +377,7 @@ func (b *Buffer) Bind(params webrtc.RTPParameters, codec webrtc.RTPCodecCapabili
 				break
 			}
 			b.logger.Debugw("Setting feedback", "type", webrtc.TypeRTCPFBNACK)
-			b.nacker = nack.NewNACKQueue(nack.NackQueueParamsDefault)
+			b.nacker = nack.NewNACKQueue(b.nackQueueParams())
 		}
 	}
 
@@ -266,30 +388,92 @@ func (b *Buffer) Bind(params webrtc.RTPParameters, codec webrtc.RTPCodecCapabili
 	b.bound = true
 }
 
+// SetCapture records packets written to the buffer, nil stops recording
+func (b *Buffer) SetCapture(capture *Capture) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.capture = capture
+}
+
 // Write adds an RTP Packet, out of order, new packet may be arrived later
 func (b *Buffer) Write(pkt []byte) (n int, err error) {
+	if primary := b.primaryForRTX(pkt); primary != nil {
+		primary.writeRTX(pkt, time.Now())
+		return
+	}
+
 	b.Lock()
 	defer b.Unlock()
 
+	return b.write(pkt, time.Now())
+}
+
+func (b *Buffer) write(pkt []byte, arrivalTime time.Time) (n int, err error) {
 	if b.closed.Load() {
 		err = io.EOF
 		return
 	}
+	b.lastPacketAt.Store(arrivalTime.UnixNano())
+
+	if b.capture != nil {
+		if cerr := b.capture.WritePacket(pkt, arrivalTime); cerr != nil && !errors.Is(cerr, ErrCaptureLimitReached) {
+			b.logger.Warnw("could not capture packet", cerr)
+			b.capture = nil
+		}
+	}
 
 	if !b.bound {
 		packet := make([]byte, len(pkt))
 		copy(packet, pkt)
 		b.pPackets = append(b.pPackets, pendingPacket{
 			packet:      packet,
-			arrivalTime: time.Now(),
+			arrivalTime: arrivalTime,
 		})
 		return
 	}
 
-	b.calc(pkt, time.Now())
+	b.calc(pkt, arrivalTime)
 	return
 }
 
+// Replay writes captured packets with their recorded arrival times, handing every packet that becomes
+// available to onPacket instead of ReadExtended. The buffer has to be bound and should not be read concurrently,
+// packets passed to onPacket are released when it returns
+func (b *Buffer) Replay(cr *CaptureReader, onPacket func(ep *ExtPacket)) (int, error) {
+	packets := 0
+	for {
+		cp, err := cr.Next()
+		if err == io.EOF {
+			return packets, nil
+		}
+		if err != nil {
+			return packets, err
+		}
+
+		b.Lock()
+		if _, err = b.write(cp.Packet(), cp.Arrival); err != nil {
+			b.Unlock()
+			return packets, err
+		}
+		packets++
+
+		for b.extPackets.Len() > 0 {
+			pb := GetPacketBuffer(bucket.MaxPktSize)
+			ep := b.patchExtPacket(b.extPackets.PopFront(), pb)
+			if ep == nil {
+				pb.Release()
+				continue
+			}
+			b.Unlock()
+			onPacket(ep)
+			pb.Release()
+			b.Lock()
+		}
+		b.Unlock()
+	}
+}
+
 func (b *Buffer) Read(buff []byte) (n int, err error) {
 	for {
 		if b.closed.Load() {
@@ -314,15 +498,21 @@ func (b *Buffer) Read(buff []byte) (n int, err error) {
 	}
 }
 
-func (b *Buffer) ReadExtended(buf []byte) (*ExtPacket, error) {
+// ReadExtended reads the next packet into pb, which should fit bucket.MaxPktSize. The returned packet references pb
+func (b *Buffer) ReadExtended(pb *PacketBuffer) (*ExtPacket, error) {
 	for {
 		if b.closed.Load() {
 			return nil, io.EOF
 		}
 		b.Lock()
+		if b.extPackets.Len() == 0 && b.frameAssembler != nil {
+			for _, ep := range b.frameAssembler.expire(time.Now()) {
+				b.extPackets.PushBack(ep)
+			}
+		}
 		if b.extPackets.Len() > 0 {
 			ep := b.extPackets.PopFront()
-			ep = b.patchExtPacket(ep, buf)
+			ep = b.patchExtPacket(ep, pb)
 			if ep == nil {
 				b.Unlock()
 				continue
@@ -354,7 +544,7 @@ func (b *Buffer) Close() error {
 			b.rtpStats.Stop()
 			b.logger.Infow("rtp stats", "direction", "upstream", "stats", b.rtpStats.ToString())
 			if b.onFinalRtpStats != nil {
-				b.onFinalRtpStats(b.rtpStats.ToProto())
+				b.onFinalRtpStats(b.rtpStats.ToProto(), b.rtpStats.History())
 			}
 		}
 
@@ -365,6 +555,23 @@ func (b *Buffer) Close() error {
 	return nil
 }
 
+// LastPacketTime returns the arrival time of the latest packet, zero when none has arrived
+func (b *Buffer) LastPacketTime() time.Time {
+	if at := b.lastPacketAt.Load(); at != 0 {
+		return time.Unix(0, at)
+	}
+	return time.Time{}
+}
+
+// LastVoiceActivity returns the arrival time of the latest audio packet at or above the active level,
+// zero when there has been none
+func (b *Buffer) LastVoiceActivity() time.Time {
+	if at := b.lastVoiceAt.Load(); at != 0 {
+		return time.Unix(0, at)
+	}
+	return time.Time{}
+}
+
 func (b *Buffer) OnClose(fn func()) {
 	b.onClose = fn
 }
@@ -420,12 +627,35 @@ func (b *Buffer) calc(pkt []byte, arrivalTime time.Time) {
 		b.doReports(arrivalTime)
 	}()
 
+	b.reportBytes += len(pkt)
+
 	var rtpPacket rtp.Packet
 	if err := rtpPacket.Unmarshal(pkt); err != nil {
 		b.logger.Errorw("could not unmarshal RTP packet", err)
 		return
 	}
 
+	if reason := b.validateStream(&rtpPacket); reason != "" {
+		b.invalidStreamDrops++
+		prometheus.IncrementInvalidStreamPackets(reason)
+		if b.invalidStreamDrops%100 == 1 {
+			b.logger.Warnw(
+				"dropping packet not matching negotiated stream", nil,
+				"reason", reason,
+				"ssrc", rtpPacket.SSRC,
+				"mid", b.mid,
+				"rid", b.rid,
+				"count", b.invalidStreamDrops,
+			)
+		}
+		return
+	}
+	b.payloadType = rtpPacket.PayloadType
+
+	if b.mime == "audio/red" && len(rtpPacket.Payload) != 0 {
+		b.recoverRED(&rtpPacket, arrivalTime)
+	}
+
 	flowState := b.updateStreamState(&rtpPacket, arrivalTime)
 	// process header extensions always as padding packets could be used for probing
 	b.processHeaderExtensions(&rtpPacket, arrivalTime)
@@ -484,12 +714,28 @@ func (b *Buffer) calc(pkt []byte, arrivalTime time.Time) {
 	if ep == nil {
 		return
 	}
-	b.extPackets.PushBack(ep)
+	if b.pendingSourceSwitch {
+		ep.SourceSwitch = true
+		b.pendingSourceSwitch = false
+	}
+	if b.layerSpecs != nil && b.layerSpecs.observe(ep) {
+		if f := b.onLayerSpecsChanged; f != nil {
+			go f(b.layerSpecs.specs(arrivalTime))
+		}
+	}
+	if b.frameAssembler != nil {
+		for _, ready := range b.frameAssembler.push(ep) {
+			b.extPackets.PushBack(ready)
+		}
+	} else {
+		b.extPackets.PushBack(ep)
+	}
 
 	b.doFpsCalc(ep)
 }
 
-func (b *Buffer) patchExtPacket(ep *ExtPacket, buf []byte) *ExtPacket {
+func (b *Buffer) patchExtPacket(ep *ExtPacket, pb *PacketBuffer) *ExtPacket {
+	buf := pb.data[:cap(pb.data)]
 	n, err := b.getPacket(buf, ep.Packet.SequenceNumber)
 	if err != nil {
 		packetNotFoundCount := b.packetNotFoundCount.Inc()
@@ -510,6 +756,8 @@ func (b *Buffer) patchExtPacket(ep *ExtPacket, buf []byte) *ExtPacket {
 	}
 	pkt.Payload = buf[payloadStart:payloadEnd]
 	ep.Packet = &pkt
+	pb.Truncate(n)
+	ep.Buffer = pb
 
 	return ep
 }
@@ -552,19 +800,51 @@ func (b *Buffer) updateStreamState(p *rtp.Packet, arrivalTime time.Time) RTPFlow
 		int(p.PaddingSize),
 	)
 
+	if flowState.IsSourceSwitch {
+		b.handleSourceSwitch()
+	}
+
 	if b.nacker != nil {
 		b.nacker.Remove(p.SequenceNumber)
-
-		if flowState.HasLoss {
-			for lost := flowState.LossStartInclusive; lost != flowState.LossEndExclusive; lost++ {
-				b.nacker.Push(uint16(lost))
-			}
-		}
+		b.queueNACKs(flowState)
 	}
 
 	return flowState
 }
 
+// handleSourceSwitch drops what is kept of the previous source when the publisher restarted sequence numbers and
+// timestamps, as they do not relate to those of the new source
+func (b *Buffer) handleSourceSwitch() {
+	b.bucket.ResyncOnNextPacket()
+	if b.nacker != nil {
+		b.nacker = nack.NewNACKQueue(b.nackQueueParams())
+		b.pendingNACKs = nil
+	}
+
+	b.pendingSourceSwitch = true
+	if f := b.onSourceSwitch; f != nil {
+		go f()
+	}
+}
+
+// validateStream returns the reason a packet does not belong to this stream, or an empty string if it does.
+// Packets are already demultiplexed by SSRC, this catches an SSRC claiming a different MID or RID.
+func (b *Buffer) validateStream(p *rtp.Packet) string {
+	// MID and RID are typically only sent until the remote receives an RTCP receiver report,
+	// so only packets carrying them can be checked
+	if b.midExt != 0 && b.mid != "" {
+		if ext := p.GetExtension(b.midExt); ext != nil && string(ext) != b.mid {
+			return "mid"
+		}
+	}
+	if b.ridExt != 0 && b.rid != "" {
+		if ext := p.GetExtension(b.ridExt); ext != nil && string(ext) != b.rid {
+			return "rid"
+		}
+	}
+	return ""
+}
+
 func (b *Buffer) processHeaderExtensions(p *rtp.Packet, arrivalTime time.Time) {
 	// submit to TWCC even if it is a padding only packet. Clients use padding only packets as probes
 	// for bandwidth estimation
@@ -582,6 +862,9 @@ func (b *Buffer) processHeaderExtensions(p *rtp.Packet, arrivalTime time.Time) {
 		if e := p.GetExtension(b.audioLevelExt); e != nil {
 			ext := rtp.AudioLevelExtension{}
 			if err := ext.Unmarshal(e); err == nil {
+				if ext.Level <= b.audioLevelParams.ActiveLevel {
+					b.lastVoiceAt.Store(arrivalTime.UnixNano())
+				}
 				if (p.Timestamp - b.latestTSForAudioLevel) < (1 << 31) {
 					duration := (int64(p.Timestamp) - int64(b.latestTSForAudioLevel)) * 1e3 / int64(b.clockRate)
 					if duration > 0 {
@@ -683,14 +966,20 @@ func (b *Buffer) doNACKs() {
 }
 
 func (b *Buffer) doReports(arrivalTime time.Time) {
-	if time.Since(b.lastReport) < ReportDelta {
+	if time.Since(b.lastReport) < b.reportInterval {
 		return
 	}
 
+	var bitrate float64
+	if elapsed := arrivalTime.Sub(b.lastReport); elapsed > 0 {
+		bitrate = float64(b.reportBytes*8) / elapsed.Seconds()
+	}
 	b.lastReport = arrivalTime
+	b.reportBytes = 0
 
 	// RTCP reports
 	pkts := b.getRTCP()
+	b.reportInterval = b.rtcpSchedule.Next(RTCPSize(pkts), bitrate)
 	if pkts != nil && b.onRtcpFeedback != nil {
 		b.onRtcpFeedback(pkts)
 	}
@@ -790,7 +1079,9 @@ func (b *Buffer) OnRtcpSenderReport(fn func()) {
 	b.onRtcpSenderReport = fn
 }
 
-func (b *Buffer) OnFinalRtpStats(fn func(*livekit.RTPStats)) {
+// OnFinalRtpStats is called with the lifetime stats of the stream when the buffer closes, along with the stats
+// of recent intervals when enabled with SetRTPStatsHistory
+func (b *Buffer) OnFinalRtpStats(fn func(stats *livekit.RTPStats, history []*livekit.RTPStats)) {
 	b.onFinalRtpStats = fn
 }
 
@@ -804,6 +1095,17 @@ func (b *Buffer) GetClockRate() uint32 {
 	return b.clockRate
 }
 
+// MemoryUsage is the size of the packet history of the buffer, in bytes
+func (b *Buffer) MemoryUsage() int {
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.bucket == nil {
+		return 0
+	}
+	return len(*b.bucket.Src())
+}
+
 func (b *Buffer) GetStats() *livekit.RTPStats {
 	b.RLock()
 	defer b.RUnlock()
@@ -815,6 +1117,22 @@ func (b *Buffer) GetStats() *livekit.RTPStats {
 	return b.rtpStats.ToProto()
 }
 
+// GetRTPStatsDebugInfo returns the full state of the receive side RTP stats
+func (b *Buffer) GetRTPStatsDebugInfo() map[string]interface{} {
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.rtpStats == nil {
+		return nil
+	}
+
+	info := DebugMarshal(b.rtpStats)
+	if info != nil && b.layerSpecs != nil {
+		info["LayerSpecs"] = b.layerSpecs.specs(time.Now())
+	}
+	return info
+}
+
 func (b *Buffer) GetDeltaStats() *StreamStatsWithLayers {
 	b.RLock()
 	defer b.RUnlock()
@@ -853,6 +1171,32 @@ func (b *Buffer) OnFpsChanged(f func()) {
 	b.Unlock()
 }
 
+// OnLayerSpecsChanged is called with the measured specs of all layers when the resolution of one changes
+func (b *Buffer) OnLayerSpecsChanged(f func([]LayerSpec)) {
+	b.Lock()
+	b.onLayerSpecsChanged = f
+	b.Unlock()
+}
+
+// OnSourceSwitch is called when the publisher restarts sequence numbers and timestamps on the stream,
+// e. g. when the source of the track is replaced
+func (b *Buffer) OnSourceSwitch(f func()) {
+	b.Lock()
+	b.onSourceSwitch = f
+	b.Unlock()
+}
+
+// GetLayerSpecs returns the frame rate and resolution measured on each layer of a video stream
+func (b *Buffer) GetLayerSpecs() []LayerSpec {
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.layerSpecs == nil {
+		return nil
+	}
+	return b.layerSpecs.specs(time.Now())
+}
+
 func (b *Buffer) GetTemporalLayerFpsForSpatial(layer int32) []float32 {
 	if int(layer) >= len(b.frameRateCalculator) {
 		return nil
@@ -15,6 +15,7 @@
 package buffer
 
 import (
+	"encoding/binary"
 	"math"
 	"sync"
 	"testing"
@@ -22,10 +23,14 @@ import (
 
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/transport/v2/packetio"
 	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/require"
 
 	"github.com/livekit/mediatransportutil/pkg/nack"
+
+	"github.com/livekit/livekit-server/pkg/sfu/audio"
 )
 
 var vp8Codec = webrtc.RTPCodecParameters{
@@ -261,3 +266,309 @@ func TestFractionLostReport(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestStreamIdentityValidation(t *testing.T) {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 1500)
+			return &b
+		},
+	}
+	buff := NewBuffer(123, pool, pool)
+	require.NotNil(t, buff)
+	buff.SetStreamIdentity("0", "f")
+	buff.Bind(webrtc.RTPParameters{
+		HeaderExtensions: []webrtc.RTPHeaderExtensionParameter{
+			{URI: sdp.SDESMidURI, ID: 1},
+			{URI: sdp.SDESRTPStreamIDURI, ID: 2},
+		},
+		Codecs: []webrtc.RTPCodecParameters{vp8Codec},
+	}, vp8Codec.RTPCodecCapability)
+
+	write := func(sn uint16, mid string, rid string) {
+		pkt := rtp.Packet{
+			Header:  rtp.Header{SSRC: 123, SequenceNumber: sn, Timestamp: uint32(sn)},
+			Payload: []byte{0xff, 0xff, 0xff, 0xfd, 0xb4, 0x9f, 0x94, 0x1},
+		}
+		if mid != "" {
+			require.NoError(t, pkt.Header.SetExtension(1, []byte(mid)))
+		}
+		if rid != "" {
+			require.NoError(t, pkt.Header.SetExtension(2, []byte(rid)))
+		}
+		b, err := pkt.Marshal()
+		require.NoError(t, err)
+		_, err = buff.Write(b)
+		require.NoError(t, err)
+	}
+
+	write(1, "0", "f")
+	write(2, "1", "f")
+	write(3, "0", "h")
+	write(4, "", "")
+
+	buff.RLock()
+	defer buff.RUnlock()
+	require.Equal(t, uint64(2), buff.invalidStreamDrops)
+	require.Equal(t, 2, buff.extPackets.Len())
+}
+
+func TestREDRecovery(t *testing.T) {
+	redCodec := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{
+			MimeType:  "audio/red",
+			ClockRate: 48000,
+		},
+		PayloadType: 63,
+	}
+	pool := &sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 100*1500)
+			return &b
+		},
+	}
+	buff := NewBuffer(123, pool, pool)
+	require.NotNil(t, buff)
+	buff.Bind(webrtc.RTPParameters{
+		Codecs: []webrtc.RTPCodecParameters{redCodec},
+	}, redCodec.RTPCodecCapability)
+
+	opus := func(sn uint16) []byte {
+		return []byte{0xfc, byte(sn), byte(sn)}
+	}
+	// write sends sn with the encodings of the given earlier sequence numbers as redundancy, oldest first
+	write := func(sn uint16, redundant ...uint16) {
+		var payload []byte
+		for _, r := range redundant {
+			header := uint32(1)<<31 | uint32(111)<<24 | uint32(sn-r)*960<<10 | uint32(len(opus(r)))
+			payload = binary.BigEndian.AppendUint32(payload, header)
+		}
+		payload = append(payload, 111)
+		for _, r := range redundant {
+			payload = append(payload, opus(r)...)
+		}
+		payload = append(payload, opus(sn)...)
+
+		pkt := rtp.Packet{
+			Header:  rtp.Header{Version: 2, PayloadType: 63, SSRC: 123, SequenceNumber: sn, Timestamp: uint32(sn) * 960},
+			Payload: payload,
+		}
+		b, err := pkt.Marshal()
+		require.NoError(t, err)
+		_, err = buff.Write(b)
+		require.NoError(t, err)
+	}
+
+	write(1)
+	write(2, 1)
+	write(5, 3, 4)
+	// only two encodings of redundancy, 6 cannot be recovered
+	write(9, 7, 8)
+	// out of order packets are not used for recovery
+	write(6, 4, 5)
+
+	buff.Lock()
+	var sns []uint16
+	for buff.extPackets.Len() > 0 {
+		ep := buff.extPackets.PopFront()
+		sns = append(sns, ep.Packet.SequenceNumber)
+		if ep.Packet.SequenceNumber == 3 {
+			require.Equal(t, uint32(3*960), ep.Packet.Timestamp)
+			require.Equal(t, append([]byte{111}, opus(3)...), ep.Packet.Payload)
+		}
+	}
+	buff.Unlock()
+	require.Equal(t, []uint16{1, 2, 3, 4, 5, 7, 8, 9, 6}, sns)
+	require.Equal(t, uint64(4), buff.rtpStats.PacketsRecovered())
+}
+
+func TestRTXAssociation(t *testing.T) {
+	vp8Payload := []byte{0xff, 0xff, 0xff, 0xfd, 0xb4, 0x9f, 0x94, 0x1}
+
+	// newLayer binds a primary buffer of simulcast layer rid
+	newLayer := func(f *Factory, ssrc uint32, rid string) *Buffer {
+		buff := f.GetOrNew(packetio.RTPBufferPacket, ssrc).(*Buffer)
+		buff.SetStreamIdentity("0", rid)
+		buff.Bind(webrtc.RTPParameters{
+			HeaderExtensions: []webrtc.RTPHeaderExtensionParameter{
+				{URI: sdp.SDESMidURI, ID: 1},
+				{URI: sdp.SDESRTPStreamIDURI, ID: 2},
+				{URI: RepairedRTPStreamIDURI, ID: 3},
+			},
+			Codecs: []webrtc.RTPCodecParameters{vp8Codec},
+		}, vp8Codec.RTPCodecCapability)
+		return buff
+	}
+	write := func(buff *Buffer, pkt rtp.Packet) {
+		b, err := pkt.Marshal()
+		require.NoError(t, err)
+		_, err = buff.Write(b)
+		require.NoError(t, err)
+	}
+	writeMedia := func(buff *Buffer, sn uint16) {
+		write(buff, rtp.Packet{
+			Header:  rtp.Header{Version: 2, PayloadType: 96, SSRC: buff.GetMediaSSRC(), SequenceNumber: sn, Timestamp: uint32(sn)},
+			Payload: vp8Payload,
+		})
+	}
+	// writeRTX retransmits osn on the RTX stream, with the MID and repaired RID when rrid is set
+	writeRTX := func(buff *Buffer, sn uint16, osn uint16, rrid string) {
+		pkt := rtp.Packet{
+			Header:  rtp.Header{Version: 2, PayloadType: 97, SSRC: buff.GetMediaSSRC(), SequenceNumber: sn, Timestamp: uint32(osn)},
+			Payload: append(binary.BigEndian.AppendUint16(nil, osn), vp8Payload...),
+		}
+		if rrid != "" {
+			require.NoError(t, pkt.Header.SetExtension(1, []byte("0")))
+			require.NoError(t, pkt.Header.SetExtension(3, []byte(rrid)))
+		}
+		write(buff, pkt)
+	}
+	sequenceNumbers := func(buff *Buffer) []uint16 {
+		buff.Lock()
+		defer buff.Unlock()
+
+		var sns []uint16
+		for buff.extPackets.Len() > 0 {
+			sns = append(sns, buff.extPackets.PopFront().Packet.SequenceNumber)
+		}
+		return sns
+	}
+
+	t.Run("ssrc", func(t *testing.T) {
+		f := NewFactoryOfBufferFactory(100).CreateBufferFactory()
+		f.SetRTXPair(456, 123)
+		primary := newLayer(f, 123, "")
+		writeMedia(primary, 1)
+		writeMedia(primary, 3)
+
+		rtx := f.GetOrNew(packetio.RTPBufferPacket, 456).(*Buffer)
+		writeRTX(rtx, 10, 2, "")
+		// padding only probe
+		write(rtx, rtp.Packet{Header: rtp.Header{Version: 2, PayloadType: 97, SSRC: 456, SequenceNumber: 11}})
+
+		require.Equal(t, []uint16{1, 3, 2}, sequenceNumbers(primary))
+		require.Equal(t, uint64(1), primary.rtpStats.PacketsRepaired())
+	})
+
+	t.Run("rid", func(t *testing.T) {
+		f := NewFactoryOfBufferFactory(100).CreateBufferFactory()
+		high := newLayer(f, 123, "h")
+		low := newLayer(f, 124, "q")
+		for _, buff := range []*Buffer{high, low} {
+			writeMedia(buff, 1)
+			writeMedia(buff, 4)
+		}
+
+		rtxLow := f.GetOrNew(packetio.RTPBufferPacket, 457).(*Buffer)
+		writeRTX(rtxLow, 20, 2, "q")
+		rtxHigh := f.GetOrNew(packetio.RTPBufferPacket, 456).(*Buffer)
+		writeRTX(rtxHigh, 10, 3, "h")
+		// RID is only sent until acknowledged, association holds for the rest of the stream
+		writeRTX(rtxHigh, 11, 2, "")
+
+		require.Equal(t, []uint16{1, 4, 3, 2}, sequenceNumbers(high))
+		require.Equal(t, uint64(2), high.rtpStats.PacketsRepaired())
+		require.Equal(t, []uint16{1, 4, 2}, sequenceNumbers(low))
+		require.Equal(t, uint64(1), low.rtpStats.PacketsRepaired())
+	})
+}
+
+func TestReorderTolerance(t *testing.T) {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 100*1500)
+			return &b
+		},
+	}
+	buff := NewBuffer(123, pool, pool)
+	require.NotNil(t, buff)
+	buff.SetReorderTolerance(ReorderTolerance{Time: time.Millisecond, Packets: 2})
+	buff.Bind(webrtc.RTPParameters{
+		Codecs: []webrtc.RTPCodecParameters{vp8Codec},
+	}, vp8Codec.RTPCodecCapability)
+
+	write := func(sn uint16) {
+		pkt := rtp.Packet{
+			Header:  rtp.Header{Version: 2, PayloadType: 96, SSRC: 123, SequenceNumber: sn, Timestamp: uint32(sn)},
+			Payload: []byte{0xff, 0xff, 0xff, 0xfd, 0xb4, 0x9f, 0x94, 0x1},
+		}
+		b, err := pkt.Marshal()
+		require.NoError(t, err)
+		_, err = buff.Write(b)
+		require.NoError(t, err)
+	}
+	nacked := func() []uint16 {
+		time.Sleep(2 * time.Millisecond)
+
+		buff.Lock()
+		defer buff.Unlock()
+
+		pairs, _ := buff.nacker.Pairs()
+		var sns []uint16
+		for _, pair := range pairs {
+			sns = append(sns, pair.PacketList()...)
+		}
+		return sns
+	}
+
+	// 2 arrives late, within tolerance
+	write(1)
+	write(3)
+	write(2)
+	write(4)
+	write(5)
+	require.Empty(t, nacked())
+
+	// 6 is missing for two packets
+	write(7)
+	require.Empty(t, nacked())
+	write(8)
+	require.Equal(t, []uint16{6}, nacked())
+}
+
+func TestLastVoiceActivity(t *testing.T) {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 1500*AudioTrackingPackets)
+			return &b
+		},
+	}
+	buff := NewBuffer(123, pool, pool)
+	require.NotNil(t, buff)
+	buff.SetAudioLevelParams(audio.AudioLevelParams{
+		ActiveLevel:     35,
+		MinPercentile:   40,
+		ObserveDuration: 500,
+	})
+	buff.Bind(webrtc.RTPParameters{
+		HeaderExtensions: []webrtc.RTPHeaderExtensionParameter{
+			{URI: sdp.AudioLevelURI, ID: 1},
+		},
+		Codecs: []webrtc.RTPCodecParameters{opusCodec},
+	}, opusCodec.RTPCodecCapability)
+
+	write := func(sn uint16, level uint8) {
+		pkt := rtp.Packet{
+			Header:  rtp.Header{Version: 2, PayloadType: 96, SSRC: 123, SequenceNumber: sn, Timestamp: uint32(sn) * 960},
+			Payload: []byte{0xfc, 0xff, 0xfe},
+		}
+		ext, err := rtp.AudioLevelExtension{Level: level}.Marshal()
+		require.NoError(t, err)
+		require.NoError(t, pkt.Header.SetExtension(1, ext))
+		b, err := pkt.Marshal()
+		require.NoError(t, err)
+		_, err = buff.Write(b)
+		require.NoError(t, err)
+	}
+
+	// quieter than the active level
+	write(1, 60)
+	require.True(t, buff.LastVoiceActivity().IsZero())
+
+	write(2, 20)
+	voiceAt := buff.LastVoiceActivity()
+	require.False(t, voiceAt.IsZero())
+
+	write(3, 127)
+	require.Equal(t, voiceAt, buff.LastVoiceActivity())
+}
@@ -0,0 +1,369 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+)
+
+type CaptureFormat string
+
+const (
+	// rtpdump as written by rtptools, https://github.com/irtlab/rtptools
+	CaptureFormatRTPDump CaptureFormat = "rtpdump"
+	// pcap with raw IPv4/UDP framing, decode as RTP in Wireshark
+	CaptureFormatPCAP CaptureFormat = "pcap"
+)
+
+const (
+	rtpdumpPreamble   = "#!rtpplay1.0 "
+	rtpdumpHeaderSize = 16
+	rtpdumpPacketSize = 8
+
+	pcapMagic          = 0xa1b2c3d4
+	pcapLinkTypeRaw    = 101
+	pcapSnapLen        = 65535
+	pcapHeaderSize     = 24
+	pcapPacketSize     = 16
+	pcapIPv4HeaderSize = 20
+	pcapUDPHeaderSize  = 8
+	pcapPort           = 5004
+)
+
+var (
+	ErrUnknownCaptureFormat = errors.New("unknown capture format")
+	ErrCaptureLimitReached  = errors.New("capture size limit reached")
+)
+
+func (f CaptureFormat) Extension() string {
+	return "." + string(f)
+}
+
+func (f CaptureFormat) Validate() error {
+	switch f {
+	case CaptureFormatRTPDump, CaptureFormatPCAP:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownCaptureFormat, f)
+}
+
+// CaptureMetadata describes the captured stream, needed to bind a Buffer when replaying the capture
+type CaptureMetadata struct {
+	SSRC             uint32                               `json:"ssrc"`
+	Layer            int32                                `json:"layer"`
+	Codec            webrtc.RTPCodecParameters            `json:"codec"`
+	HeaderExtensions []webrtc.RTPHeaderExtensionParameter `json:"header_extensions,omitempty"`
+}
+
+func (m *CaptureMetadata) RTPParameters() webrtc.RTPParameters {
+	return webrtc.RTPParameters{
+		HeaderExtensions: m.HeaderExtensions,
+		Codecs:           []webrtc.RTPCodecParameters{m.Codec},
+	}
+}
+
+type CaptureParams struct {
+	Format CaptureFormat
+	// when false, only RTP headers (including extensions) are written, replays fill payloads with zeros
+	Payloads bool
+	// bytes written to the capture before further packets are dropped, unlimited when 0
+	MaxBytes int64
+}
+
+// Capture writes received RTP packets to a pcap or rtpdump file
+type Capture struct {
+	params CaptureParams
+
+	lock    sync.Mutex
+	w       *bufio.Writer
+	start   time.Time
+	packets int
+	bytes   int64
+	err     error
+}
+
+func NewCapture(w io.Writer, params CaptureParams) (*Capture, error) {
+	if err := params.Format.Validate(); err != nil {
+		return nil, err
+	}
+
+	c := &Capture{
+		params: params,
+		w:      bufio.NewWriterSize(w, 64*1024),
+		start:  time.Now(),
+	}
+	if err := c.writeHeader(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Capture) writeHeader() error {
+	var hdr []byte
+	switch c.params.Format {
+	case CaptureFormatRTPDump:
+		hdr = make([]byte, 0, len(rtpdumpPreamble)+32+rtpdumpHeaderSize)
+		hdr = fmt.Appendf(hdr, "%s127.0.0.1/%d\n", rtpdumpPreamble, pcapPort)
+		hdr = binary.BigEndian.AppendUint32(hdr, uint32(c.start.Unix()))
+		hdr = binary.BigEndian.AppendUint32(hdr, uint32(c.start.Nanosecond()/1000))
+		hdr = binary.BigEndian.AppendUint32(hdr, 0x7f000001)
+		hdr = binary.BigEndian.AppendUint16(hdr, pcapPort)
+		hdr = binary.BigEndian.AppendUint16(hdr, 0)
+
+	case CaptureFormatPCAP:
+		hdr = make([]byte, pcapHeaderSize)
+		binary.LittleEndian.PutUint32(hdr[0:], pcapMagic)
+		binary.LittleEndian.PutUint16(hdr[4:], 2)
+		binary.LittleEndian.PutUint16(hdr[6:], 4)
+		binary.LittleEndian.PutUint32(hdr[16:], pcapSnapLen)
+		binary.LittleEndian.PutUint32(hdr[20:], pcapLinkTypeRaw)
+	}
+	_, err := c.w.Write(hdr)
+	c.bytes += int64(len(hdr))
+	return err
+}
+
+// WritePacket records an RTP packet received at arrivalTime, truncated to its header unless payloads are captured
+func (c *Capture) WritePacket(pkt []byte, arrivalTime time.Time) error {
+	captured := pkt
+	if !c.params.Payloads {
+		var hdr rtp.Header
+		n, err := hdr.Unmarshal(pkt)
+		if err != nil {
+			return err
+		}
+		captured = pkt[:n]
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+
+	var framing []byte
+	switch c.params.Format {
+	case CaptureFormatRTPDump:
+		framing = make([]byte, rtpdumpPacketSize)
+		binary.BigEndian.PutUint16(framing[0:], uint16(rtpdumpPacketSize+len(captured)))
+		binary.BigEndian.PutUint16(framing[2:], uint16(len(pkt)))
+		binary.BigEndian.PutUint32(framing[4:], uint32(arrivalTime.Sub(c.start).Milliseconds()))
+
+	case CaptureFormatPCAP:
+		framing = make([]byte, pcapPacketSize+pcapIPv4HeaderSize+pcapUDPHeaderSize)
+		binary.LittleEndian.PutUint32(framing[0:], uint32(arrivalTime.Unix()))
+		binary.LittleEndian.PutUint32(framing[4:], uint32(arrivalTime.Nanosecond()/1000))
+		binary.LittleEndian.PutUint32(framing[8:], uint32(pcapIPv4HeaderSize+pcapUDPHeaderSize+len(captured)))
+		binary.LittleEndian.PutUint32(framing[12:], uint32(pcapIPv4HeaderSize+pcapUDPHeaderSize+len(pkt)))
+		putIPv4UDPHeader(framing[pcapPacketSize:], len(pkt))
+	}
+
+	size := int64(len(framing) + len(captured))
+	if c.params.MaxBytes > 0 && c.bytes+size > c.params.MaxBytes {
+		c.err = ErrCaptureLimitReached
+		return c.err
+	}
+
+	if _, err := c.w.Write(framing); err != nil {
+		c.err = err
+		return err
+	}
+	if _, err := c.w.Write(captured); err != nil {
+		c.err = err
+		return err
+	}
+	c.packets++
+	c.bytes += size
+	return nil
+}
+
+// Stats returns the number of packets and bytes written, and the error that stopped the capture if any
+func (c *Capture) Stats() (int, int64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.packets, c.bytes, c.err
+}
+
+// Flush writes buffered packets to the underlying writer, which remains owned by the caller
+func (c *Capture) Flush() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.w.Flush()
+}
+
+func putIPv4UDPHeader(b []byte, payloadLen int) {
+	ip := b[:pcapIPv4HeaderSize]
+	ip[0] = 0x45 // version 4, 5 word header
+	binary.BigEndian.PutUint16(ip[2:], uint16(pcapIPv4HeaderSize+pcapUDPHeaderSize+payloadLen))
+	ip[8] = 64 // ttl
+	ip[9] = 17 // udp
+	copy(ip[12:], []byte{127, 0, 0, 1})
+	copy(ip[16:], []byte{127, 0, 0, 1})
+	var sum uint32
+	for i := 0; i < len(ip); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(ip[i:]))
+	}
+	for sum > 0xffff {
+		sum = (sum >> 16) + (sum & 0xffff)
+	}
+	binary.BigEndian.PutUint16(ip[10:], ^uint16(sum))
+
+	udp := b[pcapIPv4HeaderSize:]
+	binary.BigEndian.PutUint16(udp[0:], pcapPort)
+	binary.BigEndian.PutUint16(udp[2:], pcapPort)
+	binary.BigEndian.PutUint16(udp[4:], uint16(pcapUDPHeaderSize+payloadLen))
+}
+
+// ------------------------------------------------------
+
+type CapturedPacket struct {
+	Arrival time.Time
+	// captured bytes, shorter than Length when only the header was captured
+	Data   []byte
+	Length int
+}
+
+// Packet returns the packet as received, with a zero filled payload when it was not captured
+func (p *CapturedPacket) Packet() []byte {
+	if len(p.Data) >= p.Length {
+		return p.Data
+	}
+	pkt := make([]byte, p.Length)
+	copy(pkt, p.Data)
+	return pkt
+}
+
+// CaptureReader reads packets written by a Capture, the format is detected from the file header
+type CaptureReader struct {
+	r      *bufio.Reader
+	format CaptureFormat
+	start  time.Time
+}
+
+func NewCaptureReader(r io.Reader) (*CaptureReader, error) {
+	cr := &CaptureReader{
+		r: bufio.NewReader(r),
+	}
+
+	preamble, err := cr.r.Peek(len(rtpdumpPreamble))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.Equal(preamble, []byte(rtpdumpPreamble)):
+		cr.format = CaptureFormatRTPDump
+		if _, err = cr.r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		hdr := make([]byte, rtpdumpHeaderSize)
+		if _, err = io.ReadFull(cr.r, hdr); err != nil {
+			return nil, err
+		}
+		cr.start = time.Unix(int64(binary.BigEndian.Uint32(hdr[0:])), int64(binary.BigEndian.Uint32(hdr[4:]))*1000)
+
+	case binary.LittleEndian.Uint32(preamble) == pcapMagic:
+		cr.format = CaptureFormatPCAP
+		hdr := make([]byte, pcapHeaderSize)
+		if _, err = io.ReadFull(cr.r, hdr); err != nil {
+			return nil, err
+		}
+		if linkType := binary.LittleEndian.Uint32(hdr[20:]); linkType != pcapLinkTypeRaw {
+			return nil, fmt.Errorf("%w: pcap link type %d", ErrUnknownCaptureFormat, linkType)
+		}
+
+	default:
+		return nil, ErrUnknownCaptureFormat
+	}
+	return cr, nil
+}
+
+func (cr *CaptureReader) Format() CaptureFormat {
+	return cr.format
+}
+
+// Next returns the next captured packet, or io.EOF at the end of the capture
+func (cr *CaptureReader) Next() (*CapturedPacket, error) {
+	switch cr.format {
+	case CaptureFormatRTPDump:
+		return cr.nextRTPDump()
+	default:
+		return cr.nextPCAP()
+	}
+}
+
+func (cr *CaptureReader) nextRTPDump() (*CapturedPacket, error) {
+	hdr := make([]byte, rtpdumpPacketSize)
+	if _, err := io.ReadFull(cr.r, hdr); err != nil {
+		return nil, err
+	}
+	length := int(binary.BigEndian.Uint16(hdr[0:]))
+	if length < rtpdumpPacketSize {
+		return nil, io.ErrUnexpectedEOF
+	}
+	data := make([]byte, length-rtpdumpPacketSize)
+	if _, err := io.ReadFull(cr.r, data); err != nil {
+		return nil, noEOF(err)
+	}
+	return &CapturedPacket{
+		Arrival: cr.start.Add(time.Duration(binary.BigEndian.Uint32(hdr[4:])) * time.Millisecond),
+		Data:    data,
+		Length:  int(binary.BigEndian.Uint16(hdr[2:])),
+	}, nil
+}
+
+func (cr *CaptureReader) nextPCAP() (*CapturedPacket, error) {
+	hdr := make([]byte, pcapPacketSize)
+	if _, err := io.ReadFull(cr.r, hdr); err != nil {
+		return nil, err
+	}
+	inclLen := int(binary.LittleEndian.Uint32(hdr[8:]))
+	origLen := int(binary.LittleEndian.Uint32(hdr[12:]))
+	data := make([]byte, inclLen)
+	if _, err := io.ReadFull(cr.r, data); err != nil {
+		return nil, noEOF(err)
+	}
+	if len(data) < pcapIPv4HeaderSize || data[0]>>4 != 4 {
+		return nil, fmt.Errorf("%w: not an IPv4 packet", ErrUnknownCaptureFormat)
+	}
+	framing := int(data[0]&0x0f)*4 + pcapUDPHeaderSize
+	if len(data) < framing || origLen < framing {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return &CapturedPacket{
+		Arrival: time.Unix(int64(binary.LittleEndian.Uint32(hdr[0:])), int64(binary.LittleEndian.Uint32(hdr[4:]))*1000),
+		Data:    data[framing:],
+		Length:  origLen - framing,
+	}, nil
+}
+
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureRoundTrip(t *testing.T) {
+	start := time.Now()
+	packets := make([][]byte, 0, 10)
+	for i := 0; i < 10; i++ {
+		pkt := rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    111,
+				SequenceNumber: uint16(65530 + i),
+				Timestamp:      uint32(960 * i),
+				SSRC:           1234,
+			},
+			Payload: bytes.Repeat([]byte{byte(i + 1)}, 50+i),
+		}
+		require.NoError(t, pkt.Header.SetExtension(1, []byte{0x30}))
+		b, err := pkt.Marshal()
+		require.NoError(t, err)
+		packets = append(packets, b)
+	}
+
+	for _, format := range []CaptureFormat{CaptureFormatRTPDump, CaptureFormatPCAP} {
+		for _, payloads := range []bool{true, false} {
+			var buf bytes.Buffer
+			c, err := NewCapture(&buf, CaptureParams{Format: format, Payloads: payloads})
+			require.NoError(t, err)
+			for i, pkt := range packets {
+				require.NoError(t, c.WritePacket(pkt, start.Add(time.Duration(i)*20*time.Millisecond)))
+			}
+			require.NoError(t, c.Flush())
+			written, size, err := c.Stats()
+			require.NoError(t, err)
+			require.Equal(t, len(packets), written)
+			require.Equal(t, int64(buf.Len()), size)
+
+			cr, err := NewCaptureReader(&buf)
+			require.NoError(t, err)
+			require.Equal(t, format, cr.Format())
+			for i, pkt := range packets {
+				cp, err := cr.Next()
+				require.NoError(t, err, format)
+				require.Equal(t, len(pkt), cp.Length)
+				require.WithinDuration(t, start.Add(time.Duration(i)*20*time.Millisecond), cp.Arrival, time.Millisecond)
+
+				var p rtp.Packet
+				require.NoError(t, p.Unmarshal(cp.Packet()))
+				require.Equal(t, uint16(65530+i), p.SequenceNumber)
+				require.Equal(t, []byte{0x30}, p.GetExtension(1))
+				if payloads {
+					require.Equal(t, pkt, cp.Data)
+				} else {
+					require.Less(t, len(cp.Data), len(pkt))
+					require.Equal(t, make([]byte, len(p.Payload)), p.Payload)
+				}
+			}
+			_, err = cr.Next()
+			require.ErrorIs(t, err, io.EOF)
+		}
+	}
+}
+
+func TestCaptureMaxBytes(t *testing.T) {
+	pkt, err := (&rtp.Packet{
+		Header:  rtp.Header{Version: 2, SSRC: 1234},
+		Payload: make([]byte, 100),
+	}).Marshal()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	c, err := NewCapture(&buf, CaptureParams{Format: CaptureFormatRTPDump, Payloads: true, MaxBytes: 300})
+	require.NoError(t, err)
+	require.NoError(t, c.WritePacket(pkt, time.Now()))
+	require.NoError(t, c.WritePacket(pkt, time.Now()))
+	require.ErrorIs(t, c.WritePacket(pkt, time.Now()), ErrCaptureLimitReached)
+
+	written, size, err := c.Stats()
+	require.ErrorIs(t, err, ErrCaptureLimitReached)
+	require.Equal(t, 2, written)
+	require.LessOrEqual(t, size, int64(300))
+}
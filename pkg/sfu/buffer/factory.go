@@ -18,11 +18,15 @@ import (
 	"io"
 	"sync"
 
+	"github.com/pion/rtp"
 	"github.com/pion/transport/v2/packetio"
 
 	"github.com/livekit/mediatransportutil/pkg/bucket"
 )
 
+// packets of history kept for audio tracks, regardless of the configured packet buffer size
+const AudioTrackingPackets = 200
+
 type FactoryOfBufferFactory struct {
 	videoPool *sync.Pool
 	audioPool *sync.Pool
@@ -38,7 +42,7 @@ func NewFactoryOfBufferFactory(trackingPackets int) *FactoryOfBufferFactory {
 		},
 		audioPool: &sync.Pool{
 			New: func() interface{} {
-				b := make([]byte, bucket.MaxPktSize*200)
+				b := make([]byte, bucket.MaxPktSize*AudioTrackingPackets)
 				return &b
 			},
 		},
@@ -51,6 +55,7 @@ func (f *FactoryOfBufferFactory) CreateBufferFactory() *Factory {
 		audioPool:   f.audioPool,
 		rtpBuffers:  make(map[uint32]*Buffer),
 		rtcpReaders: make(map[uint32]*RTCPReader),
+		rtxPairs:    make(map[uint32]uint32),
 	}
 }
 
@@ -60,13 +65,15 @@ type Factory struct {
 	audioPool   *sync.Pool
 	rtpBuffers  map[uint32]*Buffer
 	rtcpReaders map[uint32]*RTCPReader
+	// RTX stream SSRC to the SSRC of the stream it repairs
+	rtxPairs map[uint32]uint32
 }
 
 func (f *Factory) GetOrNew(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
-	f.Lock()
-	defer f.Unlock()
 	switch packetType {
 	case packetio.RTCPBufferPacket:
+		f.Lock()
+		defer f.Unlock()
 		if reader, ok := f.rtcpReaders[ssrc]; ok {
 			return reader
 		}
@@ -79,21 +86,75 @@ func (f *Factory) GetOrNew(packetType packetio.BufferPacketType, ssrc uint32) io
 		})
 		return reader
 	case packetio.RTPBufferPacket:
+		f.Lock()
 		if reader, ok := f.rtpBuffers[ssrc]; ok {
+			f.Unlock()
 			return reader
 		}
 		buffer := NewBuffer(ssrc, f.videoPool, f.audioPool)
+		buffer.resolveRTX = f.primaryForRTX
 		f.rtpBuffers[ssrc] = buffer
 		buffer.OnClose(func() {
 			f.Lock()
 			delete(f.rtpBuffers, ssrc)
+			delete(f.rtxPairs, ssrc)
 			f.Unlock()
 		})
+		repair, primary := f.rtxPairForSSRC(ssrc)
+		f.Unlock()
+
+		// buffers lock the factory when closing, so they are linked outside of its lock
+		if repair != nil && primary != nil {
+			repair.SetPrimaryBufferForRTX(primary)
+		}
 		return buffer
 	}
 	return nil
 }
 
+// SetRTXPair associates an RTX stream with the stream it repairs by SSRC, as signalled by an FID ssrc-group.
+// Their buffers are linked once both exist
+func (f *Factory) SetRTXPair(repairSSRC uint32, primarySSRC uint32) {
+	f.Lock()
+	f.rtxPairs[repairSSRC] = primarySSRC
+	repair, primary := f.rtxPairForSSRC(repairSSRC)
+	f.Unlock()
+
+	if repair != nil && primary != nil {
+		repair.SetPrimaryBufferForRTX(primary)
+	}
+}
+
+// rtxPairForSSRC returns the RTX and primary buffers of the pair ssrc belongs to, nil for the ones not created yet
+func (f *Factory) rtxPairForSSRC(ssrc uint32) (*Buffer, *Buffer) {
+	if primarySSRC, ok := f.rtxPairs[ssrc]; ok {
+		return f.rtpBuffers[ssrc], f.rtpBuffers[primarySSRC]
+	}
+	for repairSSRC, primarySSRC := range f.rtxPairs {
+		if primarySSRC == ssrc {
+			return f.rtpBuffers[repairSSRC], f.rtpBuffers[ssrc]
+		}
+	}
+	return nil, nil
+}
+
+// primaryForRTX finds the buffer of the simulcast layer an RTX packet repairs, by its MID and repaired RID
+func (f *Factory) primaryForRTX(h *rtp.Header) *Buffer {
+	f.RLock()
+	buffers := make([]*Buffer, 0, len(f.rtpBuffers))
+	for _, b := range f.rtpBuffers {
+		buffers = append(buffers, b)
+	}
+	f.RUnlock()
+
+	for _, b := range buffers {
+		if b.repairs(h) {
+			return b
+		}
+	}
+	return nil
+}
+
 func (f *Factory) GetBufferPair(ssrc uint32) (*Buffer, *RTCPReader) {
 	f.RLock()
 	defer f.RUnlock()
@@ -0,0 +1,114 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"sort"
+	"time"
+)
+
+// pending packets are all released when more than this many are held back
+const frameAssemblyMaxPending = 512
+
+// frameAssembler holds back video packets until every packet of a frame has arrived,
+// releasing whole frames in sequence number order to absorb reordering on jittery uplinks.
+// Frames still missing packets after maxDelay are released as they are, later arrivals pass through
+type frameAssembler struct {
+	maxDelay time.Duration
+
+	initialized bool
+	nextSN      uint64
+	pending     map[uint64]*ExtPacket
+}
+
+func newFrameAssembler(maxDelay time.Duration) *frameAssembler {
+	return &frameAssembler{
+		maxDelay: maxDelay,
+		pending:  make(map[uint64]*ExtPacket),
+	}
+}
+
+// push adds a packet, returning the packets ready to be forwarded
+func (f *frameAssembler) push(ep *ExtPacket) []*ExtPacket {
+	if !f.initialized {
+		f.initialized = true
+		f.nextSN = ep.ExtSequenceNumber
+	}
+	if ep.ExtSequenceNumber < f.nextSN {
+		// frame was already released, do not hold back a late packet
+		return []*ExtPacket{ep}
+	}
+	if _, ok := f.pending[ep.ExtSequenceNumber]; ok {
+		return nil
+	}
+	f.pending[ep.ExtSequenceNumber] = ep
+
+	if len(f.pending) > frameAssemblyMaxPending {
+		return f.flush()
+	}
+	return append(f.release(), f.expire(ep.Arrival)...)
+}
+
+// expire releases everything held back if the oldest packet waited longer than maxDelay
+func (f *frameAssembler) expire(now time.Time) []*ExtPacket {
+	for _, ep := range f.pending {
+		if now.Sub(ep.Arrival) > f.maxDelay {
+			return f.flush()
+		}
+	}
+	return nil
+}
+
+// release returns the complete frames at the head of the pending packets
+func (f *frameAssembler) release() []*ExtPacket {
+	var ready []*ExtPacket
+	for {
+		end := f.nextSN
+		for {
+			ep, ok := f.pending[end]
+			if !ok {
+				return ready
+			}
+			if ep.Packet.Marker {
+				break
+			}
+			end++
+		}
+
+		for sn := f.nextSN; sn <= end; sn++ {
+			ready = append(ready, f.pending[sn])
+			delete(f.pending, sn)
+		}
+		f.nextSN = end + 1
+	}
+}
+
+// flush returns all pending packets, skipping over the ones still missing
+func (f *frameAssembler) flush() []*ExtPacket {
+	if len(f.pending) == 0 {
+		return nil
+	}
+
+	flushed := make([]*ExtPacket, 0, len(f.pending))
+	for _, ep := range f.pending {
+		flushed = append(flushed, ep)
+	}
+	sort.Slice(flushed, func(i, j int) bool {
+		return flushed[i].ExtSequenceNumber < flushed[j].ExtSequenceNumber
+	})
+	clear(f.pending)
+	f.nextSN = flushed[len(flushed)-1].ExtSequenceNumber + 1
+	return flushed
+}
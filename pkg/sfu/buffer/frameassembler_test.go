@@ -0,0 +1,54 @@
+package buffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameAssembler(t *testing.T) {
+	start := time.Now()
+	packet := func(sn uint64, marker bool, at time.Duration) *ExtPacket {
+		return &ExtPacket{
+			Arrival:           start.Add(at),
+			ExtSequenceNumber: sn,
+			Packet:            &rtp.Packet{Header: rtp.Header{SequenceNumber: uint16(sn), Marker: marker}},
+		}
+	}
+	sns := func(eps []*ExtPacket) []uint64 {
+		var out []uint64
+		for _, ep := range eps {
+			out = append(out, ep.ExtSequenceNumber)
+		}
+		return out
+	}
+
+	fa := newFrameAssembler(50 * time.Millisecond)
+
+	// frame of three packets, reordered
+	require.Empty(t, fa.push(packet(10, false, 0)))
+	require.Empty(t, fa.push(packet(12, true, time.Millisecond)))
+	require.Equal(t, []uint64{10, 11, 12}, sns(fa.push(packet(11, false, 2*time.Millisecond))))
+
+	// later frame completes first, both released in order once the earlier one does
+	require.Empty(t, fa.push(packet(15, true, 3*time.Millisecond)))
+	require.Empty(t, fa.push(packet(14, false, 4*time.Millisecond)))
+	require.Equal(t, []uint64{13, 14, 15}, sns(fa.push(packet(13, true, 5*time.Millisecond))))
+
+	// lost packet, frame is released when the wait expires
+	require.Empty(t, fa.push(packet(16, false, 10*time.Millisecond)))
+	require.Empty(t, fa.push(packet(18, true, 11*time.Millisecond)))
+	require.Empty(t, fa.expire(start.Add(40*time.Millisecond)))
+	require.Equal(t, []uint64{16, 18}, sns(fa.expire(start.Add(70*time.Millisecond))))
+
+	// retransmission arriving after the frame was released passes through
+	require.Equal(t, []uint64{17}, sns(fa.push(packet(17, false, 80*time.Millisecond))))
+	require.Equal(t, []uint64{19}, sns(fa.push(packet(19, true, 81*time.Millisecond))))
+
+	// duplicate is dropped
+	require.Empty(t, fa.push(packet(20, false, 82*time.Millisecond)))
+	require.Empty(t, fa.push(packet(20, false, 83*time.Millisecond)))
+	require.Equal(t, []uint64{20, 21}, sns(fa.push(packet(21, true, 84*time.Millisecond))))
+}
@@ -0,0 +1,491 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pion/rtp/codecs"
+)
+
+const (
+	// frame rate is measured over windows of this duration
+	layerSpecWindow = time.Second
+	// a layer without frames for this long is reported with no frame rate
+	layerSpecStaleAfter = 2 * layerSpecWindow
+)
+
+var errSPSTooShort = errors.New("sps too short")
+
+// LayerSpec is the frame rate and resolution measured on a spatial layer of a received stream.
+// Spatial is InvalidLayerSpatial for streams not carrying spatial layers, i. e. a simulcast layer.
+// Width and Height are 0 until a key frame carrying them was received
+type LayerSpec struct {
+	Spatial   int32
+	FrameRate float32
+	Width     uint32
+	Height    uint32
+}
+
+type layerSpecTracker struct {
+	spatial int32
+
+	initialized bool
+	lastTS      uint64
+	lastFrameAt time.Time
+	windowStart time.Time
+	frames      int
+	frameRate   float32
+
+	width  uint32
+	height uint32
+}
+
+// observe counts frames and picks up the resolution from key frames, returning true when the resolution changed
+func (l *layerSpecTracker) observe(ep *ExtPacket, width, height uint32) bool {
+	if !l.initialized || ep.ExtTimestamp > l.lastTS {
+		l.initialized = true
+		l.lastTS = ep.ExtTimestamp
+		l.lastFrameAt = ep.Arrival
+		if l.windowStart.IsZero() {
+			l.windowStart = ep.Arrival
+		}
+		if elapsed := ep.Arrival.Sub(l.windowStart); elapsed >= layerSpecWindow {
+			l.frameRate = float32(float64(l.frames) / elapsed.Seconds())
+			l.frames = 0
+			l.windowStart = ep.Arrival
+		}
+		l.frames++
+	}
+
+	return l.observeResolution(width, height)
+}
+
+func (l *layerSpecTracker) observeResolution(width, height uint32) bool {
+	if width == 0 || height == 0 || (width == l.width && height == l.height) {
+		return false
+	}
+	l.width = width
+	l.height = height
+	return true
+}
+
+func (l *layerSpecTracker) spec(now time.Time) LayerSpec {
+	spec := LayerSpec{
+		Spatial: l.spatial,
+		Width:   l.width,
+		Height:  l.height,
+	}
+	if now.Sub(l.lastFrameAt) < layerSpecStaleAfter {
+		spec.FrameRate = l.frameRate
+	}
+	return spec
+}
+
+// layerSpecs measures the delivered frame rate of each spatial layer of a video stream and reads their
+// resolution from the codec headers of key frames. Resolution is available for VP8, for VP9 streams
+// carrying the scalability structure and for H.264 key frames carrying the SPS
+type layerSpecs struct {
+	mime     string
+	trackers [DefaultMaxLayerSpatial + 2]*layerSpecTracker
+}
+
+func newLayerSpecs(mime string) *layerSpecs {
+	return &layerSpecs{
+		mime: mime,
+	}
+}
+
+// observe returns true when the resolution of a layer changed
+func (s *layerSpecs) observe(ep *ExtPacket) bool {
+	if len(ep.Packet.Payload) == 0 || ep.Spatial > DefaultMaxLayerSpatial {
+		return false
+	}
+
+	var resolutions map[int32][2]uint32
+	if ep.KeyFrame {
+		resolutions = s.keyFrameResolutions(ep)
+	}
+
+	changed := false
+	for spatial, res := range resolutions {
+		if spatial != ep.Spatial {
+			// scalability structure describes all spatial layers
+			if s.tracker(spatial).observeResolution(res[0], res[1]) {
+				changed = true
+			}
+		}
+	}
+	res := resolutions[ep.Spatial]
+	if s.tracker(ep.Spatial).observe(ep, res[0], res[1]) {
+		changed = true
+	}
+	return changed
+}
+
+func (s *layerSpecs) tracker(spatial int32) *layerSpecTracker {
+	// slot 0 holds streams without spatial layers
+	idx := spatial + 1
+	if idx < 0 {
+		idx = 0
+	}
+	if s.trackers[idx] == nil {
+		s.trackers[idx] = &layerSpecTracker{spatial: spatial}
+	}
+	return s.trackers[idx]
+}
+
+func (s *layerSpecs) specs(now time.Time) []LayerSpec {
+	var specs []LayerSpec
+	for _, t := range s.trackers {
+		if t != nil {
+			specs = append(specs, t.spec(now))
+		}
+	}
+	return specs
+}
+
+func (s *layerSpecs) keyFrameResolutions(ep *ExtPacket) map[int32][2]uint32 {
+	payload := ep.Packet.Payload
+	switch s.mime {
+	case "video/vp8":
+		vp8, ok := ep.Payload.(VP8)
+		if !ok {
+			return nil
+		}
+		if w, h, ok := vp8Resolution(payload[vp8.HeaderSize:]); ok {
+			return map[int32][2]uint32{ep.Spatial: {w, h}}
+		}
+
+	case "video/vp9":
+		var vp9 codecs.VP9Packet
+		if _, err := vp9.Unmarshal(payload); err != nil || !vp9.V {
+			return nil
+		}
+		resolutions := make(map[int32][2]uint32, len(vp9.Width))
+		for i := range vp9.Width {
+			if i < len(vp9.Height) {
+				resolutions[int32(i)] = [2]uint32{uint32(vp9.Width[i]), uint32(vp9.Height[i])}
+			}
+		}
+		if ep.Spatial == InvalidLayerSpatial && len(vp9.Width) == 1 {
+			resolutions[InvalidLayerSpatial] = resolutions[0]
+		}
+		return resolutions
+
+	case "video/h264":
+		if sps := h264SPS(payload); sps != nil {
+			if w, h, err := h264Resolution(sps); err == nil {
+				return map[int32][2]uint32{ep.Spatial: {w, h}}
+			}
+		}
+	}
+	return nil
+}
+
+// vp8Resolution reads the dimensions from the header of a VP8 key frame
+func vp8Resolution(frame []byte) (uint32, uint32, bool) {
+	// 3 byte frame tag, 3 byte start code, then 14 bit width and height with 2 bit scaling each
+	if len(frame) < 10 || frame[3] != 0x9d || frame[4] != 0x01 || frame[5] != 0x2a {
+		return 0, 0, false
+	}
+	width := uint32(frame[6]) | uint32(frame[7]&0x3f)<<8
+	height := uint32(frame[8]) | uint32(frame[9]&0x3f)<<8
+	return width, height, true
+}
+
+// h264SPS returns the SPS in a single NAL unit or STAP-A packet, without the NAL unit header
+func h264SPS(payload []byte) []byte {
+	if len(payload) < 2 {
+		return nil
+	}
+	switch nalu := payload[0] & 0x1f; {
+	case nalu == 7:
+		return payload[1:]
+	case nalu == 24:
+		for i := 1; i+2 < len(payload); {
+			length := int(payload[i])<<8 | int(payload[i+1])
+			i += 2
+			if length < 2 || i+length > len(payload) {
+				return nil
+			}
+			if payload[i]&0x1f == 7 {
+				return payload[i+1 : i+length]
+			}
+			i += length
+		}
+	}
+	return nil
+}
+
+// h264Resolution reads the cropped picture dimensions from an SPS, as laid out in ITU-T H.264 7.3.2.1.1
+func h264Resolution(sps []byte) (uint32, uint32, error) {
+	r := newRBSPReader(sps)
+	profileIDC, err := r.bits(8)
+	if err != nil {
+		return 0, 0, err
+	}
+	// constraint flags and level
+	if _, err = r.bits(16); err != nil {
+		return 0, 0, err
+	}
+	// seq_parameter_set_id
+	if _, err = r.ue(); err != nil {
+		return 0, 0, err
+	}
+
+	chromaFormatIDC := uint32(1)
+	separateColourPlane := uint32(0)
+	switch profileIDC {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		if chromaFormatIDC, err = r.ue(); err != nil {
+			return 0, 0, err
+		}
+		if chromaFormatIDC == 3 {
+			if separateColourPlane, err = r.bits(1); err != nil {
+				return 0, 0, err
+			}
+		}
+		// bit depths of luma and chroma
+		if _, err = r.ue(); err != nil {
+			return 0, 0, err
+		}
+		if _, err = r.ue(); err != nil {
+			return 0, 0, err
+		}
+		// qpprime_y_zero_transform_bypass_flag
+		if _, err = r.bits(1); err != nil {
+			return 0, 0, err
+		}
+		scalingMatrixPresent, err := r.bits(1)
+		if err != nil {
+			return 0, 0, err
+		}
+		if scalingMatrixPresent == 1 {
+			lists := 8
+			if chromaFormatIDC == 3 {
+				lists = 12
+			}
+			for i := 0; i < lists; i++ {
+				present, err := r.bits(1)
+				if err != nil {
+					return 0, 0, err
+				}
+				if present == 0 {
+					continue
+				}
+				size := 16
+				if i >= 6 {
+					size = 64
+				}
+				if err = r.skipScalingList(size); err != nil {
+					return 0, 0, err
+				}
+			}
+		}
+	}
+
+	// log2_max_frame_num_minus4
+	if _, err = r.ue(); err != nil {
+		return 0, 0, err
+	}
+	picOrderCntType, err := r.ue()
+	if err != nil {
+		return 0, 0, err
+	}
+	switch picOrderCntType {
+	case 0:
+		// log2_max_pic_order_cnt_lsb_minus4
+		if _, err = r.ue(); err != nil {
+			return 0, 0, err
+		}
+	case 1:
+		// delta_pic_order_always_zero_flag, offset_for_non_ref_pic, offset_for_top_to_bottom_field
+		if _, err = r.bits(1); err != nil {
+			return 0, 0, err
+		}
+		if _, err = r.ue(); err != nil {
+			return 0, 0, err
+		}
+		if _, err = r.ue(); err != nil {
+			return 0, 0, err
+		}
+		cycle, err := r.ue()
+		if err != nil {
+			return 0, 0, err
+		}
+		for i := uint32(0); i < cycle; i++ {
+			if _, err = r.ue(); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+
+	// max_num_ref_frames, gaps_in_frame_num_value_allowed_flag
+	if _, err = r.ue(); err != nil {
+		return 0, 0, err
+	}
+	if _, err = r.bits(1); err != nil {
+		return 0, 0, err
+	}
+	widthInMbsMinus1, err := r.ue()
+	if err != nil {
+		return 0, 0, err
+	}
+	heightInMapUnitsMinus1, err := r.ue()
+	if err != nil {
+		return 0, 0, err
+	}
+	frameMbsOnly, err := r.bits(1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if frameMbsOnly == 0 {
+		// mb_adaptive_frame_field_flag
+		if _, err = r.bits(1); err != nil {
+			return 0, 0, err
+		}
+	}
+	// direct_8x8_inference_flag
+	if _, err = r.bits(1); err != nil {
+		return 0, 0, err
+	}
+
+	width := (widthInMbsMinus1 + 1) * 16
+	height := (2 - frameMbsOnly) * (heightInMapUnitsMinus1 + 1) * 16
+
+	cropping, err := r.bits(1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if cropping == 1 {
+		var crop [4]uint32
+		for i := range crop {
+			if crop[i], err = r.ue(); err != nil {
+				return 0, 0, err
+			}
+		}
+		cropUnitX, cropUnitY := uint32(1), 2-frameMbsOnly
+		if separateColourPlane == 0 && chromaFormatIDC != 0 {
+			if chromaFormatIDC != 3 {
+				cropUnitX = 2
+			}
+			if chromaFormatIDC == 1 {
+				cropUnitY *= 2
+			}
+		}
+		width -= (crop[0] + crop[1]) * cropUnitX
+		height -= (crop[2] + crop[3]) * cropUnitY
+	}
+	return width, height, nil
+}
+
+// rbspReader reads bits of a NAL unit payload, skipping emulation prevention bytes
+type rbspReader struct {
+	data  []byte
+	pos   int
+	zeros int
+	cur   byte
+	left  int
+}
+
+func newRBSPReader(data []byte) *rbspReader {
+	return &rbspReader{data: data}
+}
+
+func (r *rbspReader) bit() (uint32, error) {
+	if r.left == 0 {
+		if r.pos >= len(r.data) {
+			return 0, errSPSTooShort
+		}
+		b := r.data[r.pos]
+		r.pos++
+		if r.zeros >= 2 && b == 0x03 {
+			r.zeros = 0
+			if r.pos >= len(r.data) {
+				return 0, errSPSTooShort
+			}
+			b = r.data[r.pos]
+			r.pos++
+		}
+		if b == 0 {
+			r.zeros++
+		} else {
+			r.zeros = 0
+		}
+		r.cur = b
+		r.left = 8
+	}
+	r.left--
+	return uint32(r.cur>>r.left) & 1, nil
+}
+
+func (r *rbspReader) bits(n int) (uint32, error) {
+	var v uint32
+	for i := 0; i < n; i++ {
+		b, err := r.bit()
+		if err != nil {
+			return 0, err
+		}
+		v = v<<1 | b
+	}
+	return v, nil
+}
+
+// ue reads an unsigned Exp-Golomb code
+func (r *rbspReader) ue() (uint32, error) {
+	leadingZeros := 0
+	for {
+		b, err := r.bit()
+		if err != nil {
+			return 0, err
+		}
+		if b == 1 {
+			break
+		}
+		leadingZeros++
+		if leadingZeros > 31 {
+			return 0, errSPSTooShort
+		}
+	}
+	v, err := r.bits(leadingZeros)
+	if err != nil {
+		return 0, err
+	}
+	return (1<<leadingZeros - 1) + v, nil
+}
+
+func (r *rbspReader) skipScalingList(size int) error {
+	last, next := int32(8), int32(8)
+	for i := 0; i < size; i++ {
+		if next != 0 {
+			code, err := r.ue()
+			if err != nil {
+				return err
+			}
+			// se(v) mapping of the delta
+			delta := int32((code + 1) / 2)
+			if code%2 == 0 {
+				delta = -delta
+			}
+			next = (last + delta + 256) % 256
+		}
+		if next != 0 {
+			last = next
+		}
+	}
+	return nil
+}
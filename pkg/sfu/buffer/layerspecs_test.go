@@ -0,0 +1,71 @@
+package buffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayerSpecs(t *testing.T) {
+	t.Run("vp8", func(t *testing.T) {
+		ls := newLayerSpecs("video/vp8")
+		start := time.Now()
+
+		// payload descriptor, then key frame tag, start code and 640x360
+		payload := []byte{0x10, 0x00, 0x00, 0x00, 0x9d, 0x01, 0x2a, 0x80, 0x02, 0x68, 0x01}
+		var vp8 VP8
+		require.NoError(t, vp8.Unmarshal(payload))
+		require.True(t, vp8.IsKeyFrame)
+
+		frame := func(i int, keyFrame bool) *ExtPacket {
+			ep := &ExtPacket{
+				Arrival:      start.Add(time.Duration(i) * 40 * time.Millisecond),
+				ExtTimestamp: uint64(i) * 3600,
+				Packet:       &rtp.Packet{Payload: payload},
+				KeyFrame:     keyFrame,
+				Payload:      vp8,
+				VideoLayer:   VideoLayer{Spatial: InvalidLayerSpatial},
+			}
+			return ep
+		}
+
+		require.True(t, ls.observe(frame(0, true)))
+		for i := 1; i <= 25; i++ {
+			require.False(t, ls.observe(frame(i, false)))
+		}
+		// same resolution on the next key frame
+		require.False(t, ls.observe(frame(26, true)))
+
+		specs := ls.specs(start.Add(time.Second))
+		require.Len(t, specs, 1)
+		require.Equal(t, InvalidLayerSpatial, specs[0].Spatial)
+		require.Equal(t, uint32(640), specs[0].Width)
+		require.Equal(t, uint32(360), specs[0].Height)
+		require.InDelta(t, 25, specs[0].FrameRate, 0.5)
+
+		// stale layer reports no frame rate
+		require.Zero(t, ls.specs(start.Add(5 * time.Second))[0].FrameRate)
+	})
+
+	t.Run("h264 sps", func(t *testing.T) {
+		sps := []byte{0x67, 0x64, 0x00, 0x1f, 0xac, 0xd9, 0x40, 0x50, 0x05, 0xbb, 0x01, 0x10, 0x00, 0x00, 0x03, 0x00, 0x10, 0x00, 0x00, 0x03, 0x03, 0xc0, 0xf1, 0x83, 0x19, 0x60}
+		pps := []byte{0x68, 0xeb, 0xe3, 0xcb, 0x22, 0xc0}
+
+		// STAP-A with SPS and PPS
+		payload := []byte{0x18, 0x00, byte(len(sps))}
+		payload = append(payload, sps...)
+		payload = append(payload, 0x00, byte(len(pps)))
+		payload = append(payload, pps...)
+		require.True(t, IsH264KeyFrame(payload))
+
+		width, height, err := h264Resolution(h264SPS(payload))
+		require.NoError(t, err)
+		require.Equal(t, uint32(1280), width)
+		require.Equal(t, uint32(720), height)
+
+		_, _, err = h264Resolution(sps[1:6])
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"math/bits"
+	"time"
+)
+
+const (
+	// log-linear buckets of microseconds, four per power of two, spanning ~30 seconds
+	cPacingDelaySubBucketBits = 2
+	cPacingDelaySubBuckets    = 1 << cPacingDelaySubBucketBits
+	cPacingDelayBuckets       = 96
+)
+
+// pacingDelayStats accumulates the time packets spend queued in the pacer,
+// keeping a coarse histogram so that the median can be reported without retaining samples
+type pacingDelayStats struct {
+	count   uint32
+	max     time.Duration
+	buckets [cPacingDelayBuckets]uint32
+}
+
+func (p *pacingDelayStats) add(delay time.Duration) {
+	if delay < 0 {
+		delay = 0
+	}
+
+	p.count++
+	if delay > p.max {
+		p.max = delay
+	}
+	p.buckets[pacingDelayBucket(uint64(delay/time.Microsecond))]++
+}
+
+func (p *pacingDelayStats) reset() {
+	*p = pacingDelayStats{}
+}
+
+func (p *pacingDelayStats) median() time.Duration {
+	if p.count == 0 {
+		return 0
+	}
+
+	half := (p.count + 1) / 2
+	seen := uint32(0)
+	for idx, count := range p.buckets {
+		seen += count
+		if seen >= half {
+			low, high := pacingDelayBucketBounds(idx)
+			return min(time.Duration((low+high)/2)*time.Microsecond, p.max)
+		}
+	}
+	return p.max
+}
+
+func pacingDelayBucket(us uint64) int {
+	if us < cPacingDelaySubBuckets {
+		return int(us)
+	}
+
+	exp := bits.Len64(us) - 1
+	sub := int(us>>(exp-cPacingDelaySubBucketBits)) & (cPacingDelaySubBuckets - 1)
+	return min((exp-cPacingDelaySubBucketBits+1)*cPacingDelaySubBuckets+sub, cPacingDelayBuckets-1)
+}
+
+// pacingDelayBucketBounds returns the [low, high) range of a bucket in microseconds
+func pacingDelayBucketBounds(idx int) (uint64, uint64) {
+	if idx < cPacingDelaySubBuckets {
+		return uint64(idx), uint64(idx + 1)
+	}
+
+	shift := idx/cPacingDelaySubBuckets - 1
+	sub := uint64(idx % cPacingDelaySubBuckets)
+	return (cPacingDelaySubBuckets + sub) << shift, (cPacingDelaySubBuckets + sub + 1) << shift
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacingDelayBuckets(t *testing.T) {
+	for _, us := range []uint64{0, 1, 3, 4, 7, 8, 9, 10, 999, 1000, 1023, 1024, 123456, 29_000_000} {
+		low, high := pacingDelayBucketBounds(pacingDelayBucket(us))
+		require.LessOrEqual(t, low, us, "us: %d", us)
+		require.Greater(t, high, us, "us: %d", us)
+	}
+
+	// beyond the last bucket is clamped
+	require.Equal(t, cPacingDelayBuckets-1, pacingDelayBucket(1<<40))
+}
+
+func TestPacingDelayStats(t *testing.T) {
+	var p pacingDelayStats
+	require.Zero(t, p.median())
+
+	for i := 0; i < 9; i++ {
+		p.add(time.Millisecond)
+	}
+	p.add(50 * time.Millisecond)
+
+	require.Equal(t, uint32(10), p.count)
+	require.Equal(t, 50*time.Millisecond, p.max)
+	// median is within bucket precision
+	require.InDelta(t, float64(time.Millisecond), float64(p.median()), float64(130*time.Microsecond))
+
+	p.reset()
+	require.Zero(t, p.count)
+	require.Zero(t, p.median())
+}
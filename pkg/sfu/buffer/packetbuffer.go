@@ -0,0 +1,105 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"sync"
+
+	"go.uber.org/atomic"
+
+	"github.com/livekit/mediatransportutil/pkg/bucket"
+	"github.com/livekit/protocol/logger"
+)
+
+// size classes of pooled packet buffers, the largest fits any packet received
+var packetBufferSizes = [...]int{128, 256, 512, 1024, bucket.MaxPktSize}
+
+var packetBufferPools [len(packetBufferSizes)]sync.Pool
+
+func init() {
+	for class, size := range packetBufferSizes {
+		class, size := class, size
+		packetBufferPools[class].New = func() interface{} {
+			return &PacketBuffer{
+				data:  make([]byte, size),
+				class: class,
+			}
+		}
+	}
+}
+
+// PacketBuffer is a reference counted buffer holding an RTP packet or payload, shared by the receive path,
+// the down tracks forwarding it and the pacers sending it. GetPacketBuffer returns it with one reference,
+// every holder that keeps it beyond the call it was passed to takes another reference with Retain, and
+// the buffer goes back to its pool when the last reference is released.
+type PacketBuffer struct {
+	data  []byte
+	class int
+	refs  atomic.Int32
+}
+
+// GetPacketBuffer returns a buffer of the given length from the smallest size class that fits it
+func GetPacketBuffer(size int) *PacketBuffer {
+	for class, classSize := range packetBufferSizes {
+		if size <= classSize {
+			pb := packetBufferPools[class].Get().(*PacketBuffer)
+			pb.data = pb.data[:size]
+			pb.refs.Store(1)
+			return pb
+		}
+	}
+
+	// larger than any size class, not pooled
+	pb := &PacketBuffer{
+		data:  make([]byte, size),
+		class: -1,
+	}
+	pb.refs.Store(1)
+	return pb
+}
+
+func (p *PacketBuffer) Bytes() []byte {
+	return p.data
+}
+
+// Truncate shortens the buffer to n bytes, after a read of unknown length
+func (p *PacketBuffer) Truncate(n int) {
+	p.data = p.data[:n]
+}
+
+func (p *PacketBuffer) Retain() {
+	if p == nil {
+		return
+	}
+
+	p.refs.Inc()
+}
+
+// Release drops a reference, the buffer must not be used by the caller afterwards
+func (p *PacketBuffer) Release() {
+	if p == nil {
+		return
+	}
+
+	switch refs := p.refs.Dec(); {
+	case refs == 0:
+		if p.class >= 0 {
+			p.data = p.data[:cap(p.data)]
+			packetBufferPools[p.class].Put(p)
+		}
+	case refs < 0:
+		logger.Errorw("packet buffer released too many times", nil, "refs", refs)
+	}
+}
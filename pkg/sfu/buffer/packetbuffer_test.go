@@ -0,0 +1,69 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacketBuffer(t *testing.T) {
+	t.Run("size classes", func(t *testing.T) {
+		for size, expectedCap := range map[int]int{
+			1:    128,
+			128:  128,
+			129:  256,
+			1000: 1024,
+			1500: 1500,
+		} {
+			pb := GetPacketBuffer(size)
+			require.Len(t, pb.Bytes(), size)
+			require.Equal(t, expectedCap, cap(pb.Bytes()))
+			pb.Release()
+		}
+
+		pb := GetPacketBuffer(2000)
+		require.Len(t, pb.Bytes(), 2000)
+		require.Equal(t, -1, pb.class)
+		pb.Release()
+	})
+
+	t.Run("reference counting", func(t *testing.T) {
+		pb := GetPacketBuffer(100)
+		pb.Retain()
+		pb.Retain()
+		require.Equal(t, int32(3), pb.refs.Load())
+
+		pb.Release()
+		pb.Release()
+		require.Equal(t, int32(1), pb.refs.Load())
+		require.Len(t, pb.Bytes(), 100)
+
+		pb.Release()
+		require.Zero(t, pb.refs.Load())
+		// returned to the pool at full size
+		require.Len(t, pb.data, 128)
+
+		// extra releases are logged and don't return the buffer again
+		pb.Release()
+		require.Equal(t, int32(-1), pb.refs.Load())
+	})
+
+	t.Run("nil release", func(t *testing.T) {
+		var pb *PacketBuffer
+		require.NotPanics(t, pb.Release)
+	})
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+var (
+	errIncompleteREDHeader = errors.New("incomplete red block header")
+	errIncompleteREDBlock  = errors.New("incomplete red block payload")
+)
+
+type redBlock struct {
+	pt       uint8
+	tsOffset uint32
+	payload  []byte
+}
+
+// redundantBlocks returns the redundant encodings of a RED payload (RFC 2198), oldest first
+func redundantBlocks(payload []byte) ([]redBlock, error) {
+	var blocks []redBlock
+	var blockLength int
+	for {
+		if len(payload) == 0 {
+			return nil, errIncompleteREDHeader
+		}
+		if payload[0]&0x80 == 0 {
+			// last header is the primary encoding's, 1 byte
+			payload = payload[1:]
+			break
+		}
+		if len(payload) < 4 {
+			return nil, errIncompleteREDHeader
+		}
+		blockHead := binary.BigEndian.Uint32(payload)
+		length := int(blockHead & 0x03FF)
+		blocks = append(blocks, redBlock{
+			pt:       uint8(blockHead>>24) & 0x7F,
+			tsOffset: (blockHead >> 10) & 0x3FFF,
+			payload:  make([]byte, length),
+		})
+		blockLength += length
+		payload = payload[4:]
+	}
+
+	if len(payload) < blockLength {
+		return nil, errIncompleteREDBlock
+	}
+	for i := range blocks {
+		payload = payload[copy(blocks[i].payload, payload):]
+	}
+	return blocks, nil
+}
+
+// recoverRED reconstructs packets lost just before an in-order RED packet from its redundant encodings, and
+// processes them ahead of it. Recovered packets carry their encoding as the primary of a RED payload without header
+// extensions, so they are forwarded like received ones
+func (b *Buffer) recoverRED(p *rtp.Packet, arrivalTime time.Time) {
+	if !b.redInitialized {
+		b.redInitialized = true
+		b.redHighestSN = p.SequenceNumber
+		return
+	}
+
+	diff := p.SequenceNumber - b.redHighestSN
+	if diff == 0 || diff > 1<<15 {
+		// duplicate or out of order
+		return
+	}
+	b.redHighestSN = p.SequenceNumber
+	if diff == 1 {
+		return
+	}
+
+	blocks, err := redundantBlocks(p.Payload)
+	if err != nil {
+		b.logger.Debugw("could not parse red payload", "error", err, "sn", p.SequenceNumber)
+		return
+	}
+	for i, block := range blocks {
+		distance := uint16(len(blocks) - i)
+		if distance >= diff || len(block.payload) == 0 {
+			// received already, or nothing to recover
+			continue
+		}
+
+		recovered := rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    p.PayloadType,
+				SequenceNumber: p.SequenceNumber - distance,
+				Timestamp:      p.Timestamp - block.tsOffset,
+				SSRC:           p.SSRC,
+			},
+			Payload: append([]byte{block.pt}, block.payload...),
+		}
+		raw, err := recovered.Marshal()
+		if err != nil {
+			continue
+		}
+		b.calc(raw, arrivalTime)
+		b.rtpStats.RecordRecovered()
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"time"
+
+	"github.com/livekit/mediatransportutil/pkg/nack"
+)
+
+// ReorderTolerance is how much reordering a buffer waits out before NACKing missing packets. Paths with a high RTT,
+// such as satellite or cross-continent links, reorder more and would otherwise request packets that are only late
+type ReorderTolerance struct {
+	// time a missing packet is waited for, the default minimum NACK interval when 0
+	Time time.Duration
+	// packets received after a missing one before it is NACKed
+	Packets int
+}
+
+// SetReorderTolerance sets the reordering waited out before NACKing, it should be called before Bind
+func (b *Buffer) SetReorderTolerance(tolerance ReorderTolerance) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.reorderTolerance = tolerance
+}
+
+func (b *Buffer) nackQueueParams() nack.NackQueueParams {
+	params := nack.NackQueueParamsDefault
+	if b.reorderTolerance.Time > 0 {
+		params.MinInterval = b.reorderTolerance.Time
+		if params.MaxInterval < params.MinInterval {
+			params.MaxInterval = params.MinInterval
+		}
+	}
+	return params
+}
+
+// queueNACKs hands missing packets to the NACK queue once reorderTolerance.Packets have been received after them
+func (b *Buffer) queueNACKs(flowState RTPFlowState) {
+	if b.reorderTolerance.Packets <= 0 {
+		if flowState.HasLoss {
+			for lost := flowState.LossStartInclusive; lost != flowState.LossEndExclusive; lost++ {
+				b.nacker.Push(uint16(lost))
+			}
+		}
+		return
+	}
+
+	if flowState.IsOutOfOrder {
+		for i, esn := range b.pendingNACKs {
+			if esn == flowState.ExtSequenceNumber {
+				b.pendingNACKs = append(b.pendingNACKs[:i], b.pendingNACKs[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+
+	if flowState.HasLoss {
+		for lost := flowState.LossStartInclusive; lost != flowState.LossEndExclusive; lost++ {
+			b.pendingNACKs = append(b.pendingNACKs, lost)
+		}
+	}
+	for len(b.pendingNACKs) > 0 {
+		esn := b.pendingNACKs[0]
+		if esn+uint64(b.reorderTolerance.Packets) > flowState.ExtSequenceNumber && len(b.pendingNACKs) <= nack.NackQueueParamsDefault.MaxNacks {
+			break
+		}
+		b.nacker.Push(uint16(esn))
+		b.pendingNACKs = b.pendingNACKs[1:]
+	}
+}
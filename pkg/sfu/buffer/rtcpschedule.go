@@ -0,0 +1,63 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+const (
+	// IPv4 and UDP headers of a report, counted towards the RTCP bandwidth as in RFC 3550 section 6.2
+	rtcpPacketOverhead = 28
+
+	// bandwidth based intervals are capped to this many nominal intervals to keep RTT and loss fresh
+	rtcpMaxIntervalFactor = 5
+)
+
+// RTCPSchedule spaces the RTCP reports of a stream following RFC 3550 section 6.2. Reports are sent at most every
+// Interval and use at most BandwidthFraction of the stream bitrate. Each interval is randomized by Jitter so that
+// the reports of many streams of a transport don't synchronize into bursts.
+type RTCPSchedule struct {
+	Interval time.Duration
+	// share of the stream bitrate reports may use, intervals are not bandwidth based when 0
+	BandwidthFraction float64
+	// intervals are drawn uniformly from [1-Jitter, 1+Jitter] times the computed interval
+	Jitter float64
+}
+
+// Next returns the time until the next report of reportSize bytes on a stream of bitrate bps
+func (s RTCPSchedule) Next(reportSize int, bitrate float64) time.Duration {
+	interval := s.Interval
+	if s.BandwidthFraction > 0 && bitrate > 0 {
+		seconds := float64((reportSize+rtcpPacketOverhead)*8) / (s.BandwidthFraction * bitrate)
+		interval = max(interval, min(time.Duration(seconds*float64(time.Second)), rtcpMaxIntervalFactor*s.Interval))
+	}
+	if s.Jitter > 0 {
+		interval = time.Duration(float64(interval) * (1 - s.Jitter + 2*s.Jitter*rand.Float64()))
+	}
+	return interval
+}
+
+// RTCPSize is the size in bytes of the compound packet made of pkts
+func RTCPSize(pkts []rtcp.Packet) int {
+	raw, err := rtcp.Marshal(pkts)
+	if err != nil {
+		return 0
+	}
+	return len(raw)
+}
@@ -0,0 +1,34 @@
+package buffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRTCPSchedule(t *testing.T) {
+	s := RTCPSchedule{Interval: time.Second}
+	require.Equal(t, time.Second, s.Next(32, 0))
+
+	// 5% of 64 kbps leaves 400 bytes per second, a 72 byte report fits in the nominal interval
+	s.BandwidthFraction = 0.05
+	require.Equal(t, time.Second, s.Next(72, 64000))
+
+	// 5% of 8 kbps leaves 50 bytes per second, a 72 byte report is sent every two seconds
+	require.Equal(t, 2*time.Second, s.Next(72, 8000))
+
+	// bandwidth based intervals are capped
+	require.Equal(t, 5*time.Second, s.Next(72, 100))
+
+	s.BandwidthFraction = 0
+	s.Jitter = 0.5
+	var sum time.Duration
+	for i := 0; i < 1000; i++ {
+		interval := s.Next(32, 0)
+		require.GreaterOrEqual(t, interval, 500*time.Millisecond)
+		require.LessOrEqual(t, interval, 1500*time.Millisecond)
+		sum += interval
+	}
+	require.InDelta(t, time.Second, sum/1000, float64(100*time.Millisecond))
+}
@@ -19,11 +19,14 @@ import (
 	"sync"
 	"time"
 
+	"go.uber.org/zap/zapcore"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/livekit/mediatransportutil"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/utils"
 )
 
 const (
@@ -72,6 +75,9 @@ type RTPDeltaInfo struct {
 	Nacks                uint32
 	Plis                 uint32
 	Firs                 uint32
+	// time spent queued in the pacer, only for sent streams
+	PacingDelayMedian time.Duration
+	PacingDelayMax    time.Duration
 }
 
 type snapshot struct {
@@ -118,11 +124,16 @@ type RTCPSenderReportData struct {
 type RTPStatsParams struct {
 	ClockRate uint32
 	Logger    logger.Logger
+	// packets of send history kept to attribute receiver reports to, a power of 2, senders only
+	SnInfoSize int
+	// time source of stats, the wall clock when nil
+	Clock utils.Clock
 }
 
 type rtpStatsBase struct {
 	params RTPStatsParams
 	logger logger.Logger
+	clock  utils.Clock
 
 	lock sync.RWMutex
 
@@ -182,12 +193,23 @@ type rtpStatsBase struct {
 
 	nextSnapshotID uint32
 	snapshots      []snapshot
+
+	// stats of recent intervals, oldest first, see EnableHistory
+	historyInterval   time.Duration
+	historySize       int
+	historySnapshotID uint32
+	history           []*livekit.RTPStats
 }
 
 func newRTPStatsBase(params RTPStatsParams) *rtpStatsBase {
+	clock := params.Clock
+	if clock == nil {
+		clock = utils.SystemClock
+	}
 	return &rtpStatsBase{
 		params:         params,
 		logger:         params.Logger,
+		clock:          clock,
 		nextSnapshotID: cFirstSnapshotID,
 		snapshots:      make([]snapshot, 2),
 	}
@@ -276,7 +298,7 @@ func (r *rtpStatsBase) Stop() {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	r.endTime = time.Now()
+	r.endTime = r.clock.Now()
 }
 
 func (r *rtpStatsBase) newSnapshotID(extStartSN uint64) uint32 {
@@ -290,7 +312,7 @@ func (r *rtpStatsBase) newSnapshotID(extStartSN uint64) uint32 {
 	}
 
 	if r.initialized {
-		r.snapshots[id-cFirstSnapshotID] = r.initSnapshot(time.Now(), extStartSN)
+		r.snapshots[id-cFirstSnapshotID] = r.initSnapshot(r.clock.Now(), extStartSN)
 	}
 	return id
 }
@@ -365,7 +387,7 @@ func (r *rtpStatsBase) UpdatePliTime() {
 }
 
 func (r *rtpStatsBase) updatePliTimeLocked() {
-	r.lastPli = time.Now()
+	r.lastPli = r.clock.Now()
 }
 
 func (r *rtpStatsBase) LastPli() time.Time {
@@ -379,7 +401,7 @@ func (r *rtpStatsBase) TimeSinceLastPli() int64 {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
 
-	return time.Now().UnixNano() - r.lastPli.UnixNano()
+	return r.clock.Now().UnixNano() - r.lastPli.UnixNano()
 }
 
 func (r *rtpStatsBase) UpdateLayerLockPliAndTime(pliCount uint32) {
@@ -391,7 +413,7 @@ func (r *rtpStatsBase) UpdateLayerLockPliAndTime(pliCount uint32) {
 	}
 
 	r.layerLockPlis += pliCount
-	r.lastLayerLockPli = time.Now()
+	r.lastLayerLockPli = r.clock.Now()
 }
 
 func (r *rtpStatsBase) UpdateFir(firCount uint32) {
@@ -413,7 +435,7 @@ func (r *rtpStatsBase) UpdateFirTime() {
 		return
 	}
 
-	r.lastFir = time.Now()
+	r.lastFir = r.clock.Now()
 }
 
 func (r *rtpStatsBase) UpdateKeyFrame(kfCount uint32) {
@@ -425,7 +447,7 @@ func (r *rtpStatsBase) UpdateKeyFrame(kfCount uint32) {
 	}
 
 	r.keyFrames += kfCount
-	r.lastKeyFrame = time.Now()
+	r.lastKeyFrame = r.clock.Now()
 }
 
 func (r *rtpStatsBase) UpdateRtt(rtt uint32) {
@@ -457,7 +479,7 @@ func (r *rtpStatsBase) GetRtt() uint32 {
 }
 
 func (r *rtpStatsBase) maybeAdjustFirstPacketTime(ets uint64, extStartTS uint64) {
-	if time.Since(r.startTime) > cFirstPacketTimeAdjustWindow {
+	if r.clock.Now().Sub(r.startTime) > cFirstPacketTimeAdjustWindow {
 		return
 	}
 
@@ -474,7 +496,7 @@ func (r *rtpStatsBase) maybeAdjustFirstPacketTime(ets uint64, extStartTS uint64)
 	}
 
 	samplesDuration := time.Duration(float64(samplesDiff) / float64(r.params.ClockRate) * float64(time.Second))
-	now := time.Now()
+	now := r.clock.Now()
 	firstTime := now.Add(-samplesDuration)
 	if firstTime.Before(r.firstTime) {
 		r.logger.Debugw(
@@ -524,6 +546,10 @@ func (r *rtpStatsBase) deltaInfo(snapshotID uint32, extStartSN uint64, extHighes
 		return nil
 	}
 
+	return r.deltaInfoBetween(then, now)
+}
+
+func (r *rtpStatsBase) deltaInfoBetween(then *snapshot, now *snapshot) *RTPDeltaInfo {
 	startTime := then.startTime
 	endTime := now.startTime
 
@@ -638,6 +664,100 @@ func (r *rtpStatsBase) toString(
 	return str
 }
 
+// DebugMarshal renders an object marshaler, such as RTP stats, into a map that can be encoded as JSON
+func DebugMarshal(m zapcore.ObjectMarshaler) map[string]interface{} {
+	e := zapcore.NewMapObjectEncoder()
+	if err := m.MarshalLogObject(e); err != nil {
+		return nil
+	}
+	return e.Fields
+}
+
+func (r *RTCPSenderReportData) MarshalLogObject(e zapcore.ObjectEncoder) error {
+	if r == nil {
+		return nil
+	}
+
+	e.AddUint32("RTPTimestamp", r.RTPTimestamp)
+	e.AddUint64("RTPTimestampExt", r.RTPTimestampExt)
+	e.AddTime("NTPTimestamp", r.NTPTimestamp.Time())
+	e.AddUint32("PacketCount", r.PacketCount)
+	e.AddUint64("PacketCountExt", r.PacketCountExt)
+	e.AddUint64("PaddingOnlyDrops", r.PaddingOnlyDrops)
+	e.AddTime("At", r.At)
+	return nil
+}
+
+func (r *rtpStatsBase) marshalLogObject(
+	e zapcore.ObjectEncoder,
+	extStartSN, extHighestSN, extStartTS, extHighestTS uint64,
+	packetsLost uint64,
+	jitter, maxJitter float64,
+) error {
+	e.AddUint32("ClockRate", r.params.ClockRate)
+	e.AddBool("Initialized", r.initialized)
+	e.AddTime("StartTime", r.startTime)
+	e.AddTime("EndTime", r.endTime)
+	e.AddTime("FirstTime", r.firstTime)
+	e.AddTime("HighestTime", r.highestTime)
+
+	e.AddUint64("ExtStartSN", extStartSN)
+	e.AddUint64("ExtHighestSN", extHighestSN)
+	e.AddUint64("ExtStartTS", extStartTS)
+	e.AddUint64("ExtHighestTS", extHighestTS)
+	e.AddUint64("LastTransit", r.lastTransit)
+	e.AddUint64("LastJitterExtTimestamp", r.lastJitterExtTimestamp)
+
+	e.AddUint64("Packets", r.getTotalPacketsPrimary(extStartSN, extHighestSN))
+	e.AddUint64("Bytes", r.bytes)
+	e.AddUint64("HeaderBytes", r.headerBytes)
+	e.AddUint64("PacketsDuplicate", r.packetsDuplicate)
+	e.AddUint64("BytesDuplicate", r.bytesDuplicate)
+	e.AddUint64("HeaderBytesDuplicate", r.headerBytesDuplicate)
+	e.AddUint64("PacketsPadding", r.packetsPadding)
+	e.AddUint64("BytesPadding", r.bytesPadding)
+	e.AddUint64("HeaderBytesPadding", r.headerBytesPadding)
+	e.AddUint64("PacketsOutOfOrder", r.packetsOutOfOrder)
+	e.AddUint64("PacketsLost", packetsLost)
+	e.AddUint32("Frames", r.frames)
+
+	e.AddFloat64("Jitter", jitter)
+	e.AddFloat64("MaxJitter", maxJitter)
+
+	gapHistogram := make(map[string]interface{})
+	for burst, count := range r.gapHistogram {
+		if count != 0 {
+			gapHistogram[fmt.Sprintf("%d", burst+1)] = count
+		}
+	}
+	_ = e.AddReflected("GapHistogram", gapHistogram)
+
+	e.AddUint32("Nacks", r.nacks)
+	e.AddUint32("NackAcks", r.nackAcks)
+	e.AddUint32("NackMisses", r.nackMisses)
+	e.AddUint32("NackRepeated", r.nackRepeated)
+
+	e.AddUint32("Plis", r.plis)
+	e.AddTime("LastPli", r.lastPli)
+	e.AddUint32("LayerLockPlis", r.layerLockPlis)
+	e.AddTime("LastLayerLockPli", r.lastLayerLockPli)
+	e.AddUint32("Firs", r.firs)
+	e.AddTime("LastFir", r.lastFir)
+	e.AddUint32("KeyFrames", r.keyFrames)
+	e.AddTime("LastKeyFrame", r.lastKeyFrame)
+
+	e.AddUint32("Rtt", r.rtt)
+	e.AddUint32("MaxRtt", r.maxRtt)
+
+	if r.srFirst != nil {
+		_ = e.AddObject("SrFirst", r.srFirst)
+	}
+	if r.srNewest != nil {
+		_ = e.AddObject("SrNewest", r.srNewest)
+	}
+	return nil
+}
+
 func (r *rtpStatsBase) toProto(
 	extStartSN, extHighestSN, extStartTS, extHighestTS uint64,
 	packetsLost uint64,
@@ -649,7 +769,7 @@ func (r *rtpStatsBase) toProto(
 
 	endTime := r.endTime
 	if endTime.IsZero() {
-		endTime = time.Now()
+		endTime = r.clock.Now()
 	}
 	elapsed := endTime.Sub(r.startTime).Seconds()
 	if elapsed == 0.0 {
@@ -796,7 +916,7 @@ func (r *rtpStatsBase) getAndResetSnapshot(snapshotID uint32, extStartSN uint64,
 	}
 
 	// snapshot now
-	now := r.getSnapshot(time.Now(), extHighestSN+1)
+	now := r.getSnapshot(r.clock.Now(), extHighestSN+1)
 	r.snapshots[idx] = now
 	return &then, &now
 }
@@ -1091,6 +1211,10 @@ func AggregateRTPDeltaInfo(deltaInfoList []*RTPDeltaInfo) *RTPDeltaInfo {
 	plis := uint32(0)
 	firs := uint32(0)
 
+	// medians cannot be combined without the samples, the worst one is reported
+	pacingDelayMedian := time.Duration(0)
+	pacingDelayMax := time.Duration(0)
+
 	for _, deltaInfo := range deltaInfoList {
 		if deltaInfo == nil {
 			continue
@@ -1134,6 +1258,9 @@ func AggregateRTPDeltaInfo(deltaInfoList []*RTPDeltaInfo) *RTPDeltaInfo {
 		nacks += deltaInfo.Nacks
 		plis += deltaInfo.Plis
 		firs += deltaInfo.Firs
+
+		pacingDelayMedian = max(pacingDelayMedian, deltaInfo.PacingDelayMedian)
+		pacingDelayMax = max(pacingDelayMax, deltaInfo.PacingDelayMax)
 	}
 	if startTime.IsZero() || endTime.IsZero() {
 		return nil
@@ -1160,6 +1287,8 @@ func AggregateRTPDeltaInfo(deltaInfoList []*RTPDeltaInfo) *RTPDeltaInfo {
 		Nacks:                nacks,
 		Plis:                 plis,
 		Firs:                 firs,
+		PacingDelayMedian:    pacingDelayMedian,
+		PacingDelayMax:       pacingDelayMax,
 	}
 }
 
@@ -0,0 +1,119 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// enableHistory starts keeping the stats of consecutive intervals of at least the given length, up to size of the
+// most recent ones. Intervals end on the first packet after they are due, so that idle streams do not accumulate
+// empty intervals.
+func (r *rtpStatsBase) enableHistory(interval time.Duration, size int, extStartSN uint64) {
+	if interval <= 0 || size <= 0 || r.historySnapshotID != 0 {
+		return
+	}
+
+	r.historyInterval = interval
+	r.historySize = size
+	r.historySnapshotID = r.newSnapshotID(extStartSN)
+}
+
+func (r *rtpStatsBase) maybeRecordHistory(extStartSN uint64, extHighestSN uint64) {
+	if r.historySnapshotID == 0 || !r.initialized {
+		return
+	}
+
+	startTime := r.startTime
+	if then := r.snapshots[r.historySnapshotID-cFirstSnapshotID]; then.isValid {
+		startTime = then.startTime
+	}
+	if r.clock.Now().Sub(startTime) < r.historyInterval {
+		return
+	}
+
+	delta := r.deltaInfo(r.historySnapshotID, extStartSN, extHighestSN)
+	if delta == nil {
+		return
+	}
+	r.history = append(r.history, deltaInfoToProto(delta))
+	if len(r.history) > r.historySize {
+		r.history = r.history[len(r.history)-r.historySize:]
+	}
+}
+
+// historyToProto returns the recorded intervals followed by the one in progress
+func (r *rtpStatsBase) historyToProto(extStartSN uint64, extHighestSN uint64) []*livekit.RTPStats {
+	if r.historySnapshotID == 0 || !r.initialized {
+		return nil
+	}
+
+	history := make([]*livekit.RTPStats, 0, len(r.history)+1)
+	history = append(history, r.history...)
+
+	then := r.snapshots[r.historySnapshotID-cFirstSnapshotID]
+	if !then.isValid {
+		then = r.initSnapshot(r.startTime, extStartSN)
+	}
+	now := r.getSnapshot(r.clock.Now(), extHighestSN+1)
+	if !r.endTime.IsZero() {
+		now.startTime = r.endTime
+	}
+	if delta := r.deltaInfoBetween(&then, &now); delta != nil && delta.Duration > 0 {
+		history = append(history, deltaInfoToProto(delta))
+	}
+	return history
+}
+
+func deltaInfoToProto(delta *RTPDeltaInfo) *livekit.RTPStats {
+	p := &livekit.RTPStats{
+		StartTime:         timestamppb.New(delta.StartTime),
+		EndTime:           timestamppb.New(delta.StartTime.Add(delta.Duration)),
+		Duration:          delta.Duration.Seconds(),
+		Packets:           delta.Packets,
+		Bytes:             delta.Bytes,
+		HeaderBytes:       delta.HeaderBytes,
+		PacketsLost:       delta.PacketsLost,
+		PacketsDuplicate:  delta.PacketsDuplicate,
+		BytesDuplicate:    delta.BytesDuplicate,
+		PacketsPadding:    delta.PacketsPadding,
+		BytesPadding:      delta.BytesPadding,
+		PacketsOutOfOrder: delta.PacketsOutOfOrder,
+		Frames:            delta.Frames,
+		JitterMax:         delta.JitterMax,
+		RttMax:            delta.RttMax,
+		Nacks:             delta.Nacks,
+		Plis:              delta.Plis,
+		Firs:              delta.Firs,
+	}
+	if p.Duration > 0 {
+		p.PacketRate = float64(delta.Packets) / p.Duration
+		p.Bitrate = float64(delta.Bytes) * 8 / p.Duration
+		p.PacketLossRate = float64(delta.PacketsLost) / p.Duration
+		p.PacketDuplicateRate = float64(delta.PacketsDuplicate) / p.Duration
+		p.BitrateDuplicate = float64(delta.BytesDuplicate) * 8 / p.Duration
+		p.PacketPaddingRate = float64(delta.PacketsPadding) / p.Duration
+		p.BitratePadding = float64(delta.BytesPadding) * 8 / p.Duration
+		p.FrameRate = float64(delta.Frames) / p.Duration
+	}
+	if expected := delta.Packets + delta.PacketsPadding; expected > 0 {
+		p.PacketLossPercentage = float32(delta.PacketsLost) / float32(expected) * 100
+	}
+	return p
+}
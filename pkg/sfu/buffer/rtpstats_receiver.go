@@ -16,9 +16,11 @@ package buffer
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/pion/rtcp"
+	"go.uber.org/zap/zapcore"
 
 	"github.com/livekit/livekit-server/pkg/sfu/utils"
 	"github.com/livekit/protocol/livekit"
@@ -27,6 +29,11 @@ import (
 
 const (
 	cHistorySize = 4096
+
+	// timestamps further off than this from what arrival times suggest start a new source
+	cSourceSwitchThreshold = 5 * time.Second
+	// older sequence numbers than this are late packets of the current source, e. g. retransmissions
+	cSourceSwitchMinSNJump = 1 << 10
 )
 
 type RTPFlowState struct {
@@ -38,6 +45,8 @@ type RTPFlowState struct {
 
 	IsDuplicate  bool
 	IsOutOfOrder bool
+	// first packet after the publisher restarted sequence numbers and timestamps, e. g. replacing the source of a track
+	IsSourceSwitch bool
 
 	ExtSequenceNumber uint64
 	ExtTimestamp      uint64
@@ -54,6 +63,16 @@ type RTPStatsReceiver struct {
 	timestamp *utils.WrapAround[uint32, uint64]
 
 	history *protoutils.Bitmap[uint64]
+
+	// packets reconstructed from redundant encodings instead of being received
+	packetsRecovered uint64
+	// packets restored from the RTX stream
+	packetsRepaired uint64
+
+	// sequence numbers skipped over when the publisher restarted them on a source switch, not counted as expected
+	snSkipped        uint64
+	sourceSwitches   uint32
+	lastSourceSwitch time.Time
 }
 
 func NewRTPStatsReceiver(params RTPStatsParams) *RTPStatsReceiver {
@@ -72,6 +91,22 @@ func (r *RTPStatsReceiver) NewSnapshotId() uint32 {
 	return r.newSnapshotID(r.sequenceNumber.GetExtendedHighest())
 }
 
+// EnableHistory keeps the stats of up to size recent intervals of at least the given length, see History
+func (r *RTPStatsReceiver) EnableHistory(interval time.Duration, size int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.enableHistory(interval, size, r.extHighestSNForStats())
+}
+
+// History returns the stats of recent intervals, oldest first, including the one in progress
+func (r *RTPStatsReceiver) History() []*livekit.RTPStats {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.historyToProto(r.sequenceNumber.GetExtendedStart(), r.extHighestSNForStats())
+}
+
 func (r *RTPStatsReceiver) Update(
 	packetTime time.Time,
 	sequenceNumber uint16,
@@ -89,11 +124,18 @@ func (r *RTPStatsReceiver) Update(
 		return
 	}
 
+	r.maybeRecordHistory(r.sequenceNumber.GetExtendedStart(), r.extHighestSNForStats())
+
 	if r.resyncOnNextPacket {
 		r.resyncOnNextPacket = false
 		r.resync(packetTime, sequenceNumber, timestamp)
 	}
 
+	if payloadSize != 0 && r.isSourceSwitch(packetTime, sequenceNumber, timestamp) {
+		r.rebaseOnSourceSwitch(packetTime, sequenceNumber, timestamp)
+		flowState.IsSourceSwitch = true
+	}
+
 	var resSN utils.WrapAroundUpdateResult[uint64]
 	var resTS utils.WrapAroundUpdateResult[uint64]
 	if !r.initialized {
@@ -105,7 +147,7 @@ func (r *RTPStatsReceiver) Update(
 
 		r.initialized = true
 
-		r.startTime = time.Now()
+		r.startTime = r.clock.Now()
 
 		r.firstTime = packetTime
 		r.highestTime = packetTime
@@ -235,6 +277,104 @@ func (r *RTPStatsReceiver) Update(
 	return
 }
 
+// RecordRecovered counts a lost packet reconstructed from redundancy in a later one
+func (r *RTPStatsReceiver) RecordRecovered() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.packetsRecovered++
+}
+
+func (r *RTPStatsReceiver) PacketsRecovered() uint64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.packetsRecovered
+}
+
+// RecordRepaired counts a packet restored from a retransmission on the RTX stream
+func (r *RTPStatsReceiver) RecordRepaired() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.packetsRepaired++
+}
+
+func (r *RTPStatsReceiver) PacketsRepaired() uint64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.packetsRepaired
+}
+
+// SourceSwitches returns how many times the publisher restarted sequence numbers and timestamps, and when it last did
+func (r *RTPStatsReceiver) SourceSwitches() (uint32, time.Time) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.sourceSwitches, r.lastSourceSwitch
+}
+
+// isSourceSwitch returns true when a media packet does not continue the timeline of the stream, its timestamp being
+// further off than cSourceSwitchThreshold from what its arrival time suggests. Clients replacing the source of a
+// track may restart sequence numbers and timestamps on the same SSRC, that should not show as loss or jitter
+func (r *RTPStatsReceiver) isSourceSwitch(packetTime time.Time, sn uint16, ts uint32) bool {
+	if !r.initialized || r.params.ClockRate == 0 {
+		return false
+	}
+
+	snDiff := int16(sn - r.sequenceNumber.GetHighest())
+	if snDiff <= 0 && snDiff > -cSourceSwitchMinSNJump {
+		return false
+	}
+
+	expectedTSDiff := packetTime.Sub(r.highestTime).Seconds() * float64(r.params.ClockRate)
+	tsDiff := float64(int32(ts - r.timestamp.GetHighest()))
+	return math.Abs(tsDiff-expectedTSDiff) > cSourceSwitchThreshold.Seconds()*float64(r.params.ClockRate)
+}
+
+// rebaseOnSourceSwitch continues extended sequence numbers and timestamps from the packet starting a new source,
+// without counting the jump as lost packets or jitter
+func (r *RTPStatsReceiver) rebaseOnSourceSwitch(packetTime time.Time, sn uint16, ts uint32) {
+	extHighestSN := r.sequenceNumber.GetExtendedHighest()
+	extSN := extHighestSN&^0xFFFF + uint64(sn)
+	if extSN <= extHighestSN {
+		extSN += 1 << 16
+	}
+	r.snSkipped += extSN - extHighestSN - 1
+	r.sequenceNumber.ResetHighest(extSN - 1)
+
+	extHighestTS := r.timestamp.GetExtendedHighest()
+	extTS := extHighestTS&^0xFFFF_FFFF + uint64(ts)
+	if extTS <= extHighestTS {
+		extTS += 1 << 32
+	}
+	r.timestamp.ResetHighest(extTS)
+	r.highestTime = packetTime
+
+	// restart jitter calculation on the new timeline
+	r.lastTransit = 0
+
+	r.sourceSwitches++
+	r.lastSourceSwitch = packetTime
+	r.logger.Infow(
+		"source switch",
+		"rtpSN", sn,
+		"beforeExtHighestSN", extHighestSN,
+		"afterExtHighestSN", r.sequenceNumber.GetExtendedHighest(),
+		"rtpTS", ts,
+		"beforeExtHighestTS", extHighestTS,
+		"afterExtHighestTS", r.timestamp.GetExtendedHighest(),
+		"count", r.sourceSwitches,
+	)
+}
+
+// extHighestSNForStats is the extended highest sequence number for lifetime and history stats, which do not expect
+// packets for sequence numbers skipped over on source switches
+func (r *RTPStatsReceiver) extHighestSNForStats() uint64 {
+	return r.sequenceNumber.GetExtendedHighest() - r.snSkipped
+}
+
 func (r *RTPStatsReceiver) ResyncOnNextPacket(shouldDiscountPaddingOnlyDrops bool) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -432,7 +572,7 @@ func (r *RTPStatsReceiver) GetRtcpReceptionReport(ssrc uint32, proxyFracLost uin
 	if r.srNewest != nil {
 		lastSR = uint32(r.srNewest.NTPTimestamp >> 16)
 		if !r.srNewest.At.IsZero() {
-			delayMS := uint32(time.Since(r.srNewest.At).Milliseconds())
+			delayMS := uint32(r.clock.Now().Sub(r.srNewest.At).Milliseconds())
 			dlsr = (delayMS / 1e3) << 16
 			dlsr |= (delayMS % 1e3) * 65536 / 1000
 		}
@@ -461,7 +601,29 @@ func (r *RTPStatsReceiver) ToString() string {
 	defer r.lock.RUnlock()
 
 	return r.toString(
-		r.sequenceNumber.GetExtendedStart(), r.sequenceNumber.GetExtendedHighest(), r.timestamp.GetExtendedStart(), r.timestamp.GetExtendedHighest(),
+		r.sequenceNumber.GetExtendedStart(), r.extHighestSNForStats(), r.timestamp.GetExtendedStart(), r.timestamp.GetExtendedHighest(),
+		r.packetsLost,
+		r.jitter, r.maxJitter,
+	)
+}
+
+func (r *RTPStatsReceiver) MarshalLogObject(e zapcore.ObjectEncoder) error {
+	if r == nil {
+		return nil
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	e.AddBool("ResyncOnNextPacket", r.resyncOnNextPacket)
+	e.AddBool("ShouldDiscountPaddingOnlyDrops", r.shouldDiscountPaddingOnlyDrops)
+	e.AddUint64("PacketsRecovered", r.packetsRecovered)
+	e.AddUint64("PacketsRepaired", r.packetsRepaired)
+	e.AddUint32("SourceSwitches", r.sourceSwitches)
+	e.AddTime("LastSourceSwitch", r.lastSourceSwitch)
+	return r.marshalLogObject(
+		e,
+		r.sequenceNumber.GetExtendedStart(), r.extHighestSNForStats(), r.timestamp.GetExtendedStart(), r.timestamp.GetExtendedHighest(),
 		r.packetsLost,
 		r.jitter, r.maxJitter,
 	)
@@ -472,7 +634,7 @@ func (r *RTPStatsReceiver) ToProto() *livekit.RTPStats {
 	defer r.lock.RUnlock()
 
 	return r.toProto(
-		r.sequenceNumber.GetExtendedStart(), r.sequenceNumber.GetExtendedHighest(), r.timestamp.GetExtendedStart(), r.timestamp.GetExtendedHighest(),
+		r.sequenceNumber.GetExtendedStart(), r.extHighestSNForStats(), r.timestamp.GetExtendedStart(), r.timestamp.GetExtendedHighest(),
 		r.packetsLost,
 		r.jitter, r.maxJitter,
 	)
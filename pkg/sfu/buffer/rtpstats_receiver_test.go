@@ -23,6 +23,8 @@ import (
 	"github.com/livekit/protocol/logger"
 	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/sfu/utils"
 )
 
 func getPacket(sn uint16, ts uint32, payloadSize int) *rtp.Packet {
@@ -37,9 +39,11 @@ func getPacket(sn uint16, ts uint32, payloadSize int) *rtp.Packet {
 
 func Test_RTPStatsReceiver(t *testing.T) {
 	clockRate := uint32(90000)
+	clock := utils.NewManualClock(time.Now())
 	r := NewRTPStatsReceiver(RTPStatsParams{
 		ClockRate: clockRate,
 		Logger:    logger.GetLogger(),
+		Clock:     clock,
 	})
 
 	totalDuration := 5 * time.Second
@@ -52,7 +56,7 @@ func Test_RTPStatsReceiver(t *testing.T) {
 
 	sequenceNumber := uint16(rand.Float64() * float64(1<<16))
 	timestamp := uint32(rand.Float64() * float64(1<<32))
-	now := time.Now()
+	now := clock.Now()
 	startTime := now
 	lastFrameTime := now
 	for now.Sub(startTime) < totalDuration {
@@ -60,7 +64,7 @@ func Test_RTPStatsReceiver(t *testing.T) {
 		for i := 0; i < packetsPerFrame; i++ {
 			packet := getPacket(sequenceNumber, timestamp, packetSize)
 			r.Update(
-				time.Now(),
+				now,
 				packet.Header.SequenceNumber,
 				packet.Header.Timestamp,
 				packet.Header.Marker,
@@ -77,12 +81,68 @@ func Test_RTPStatsReceiver(t *testing.T) {
 		}
 
 		lastFrameTime = now
-		time.Sleep(time.Duration(sleep) * time.Millisecond)
-		now = time.Now()
+		now = clock.Advance(time.Duration(sleep) * time.Millisecond)
 	}
 
 	r.Stop()
 	fmt.Printf("%s\n", r.ToString())
+	require.Equal(t, clock.Now().Sub(startTime), r.ToProto().EndTime.AsTime().Sub(r.ToProto().StartTime.AsTime()))
+}
+
+func Test_RTPStatsReceiver_History(t *testing.T) {
+	clock := utils.NewManualClock(time.Now())
+	r := NewRTPStatsReceiver(RTPStatsParams{
+		ClockRate: 90000,
+		Logger:    logger.GetLogger(),
+		Clock:     clock,
+	})
+	r.EnableHistory(10*time.Second, 2)
+
+	// a packet every 100ms for 35s
+	startTime := clock.Now()
+	sequenceNumber := uint16(1000)
+	timestamp := uint32(0)
+	for i := 0; i < 350; i++ {
+		packet := getPacket(sequenceNumber, timestamp, 100)
+		r.Update(
+			clock.Now(),
+			packet.Header.SequenceNumber,
+			packet.Header.Timestamp,
+			packet.Header.Marker,
+			packet.Header.MarshalSize(),
+			len(packet.Payload),
+			0,
+		)
+		sequenceNumber++
+		timestamp += 9000
+		clock.Advance(100 * time.Millisecond)
+	}
+	r.Stop()
+
+	// the first interval is dropped, the one in progress is included
+	history := r.History()
+	require.Len(t, history, 3)
+	for i, expected := range []struct {
+		start   time.Duration
+		packets uint32
+	}{
+		{start: 10 * time.Second, packets: 100},
+		{start: 20 * time.Second, packets: 100},
+		{start: 30 * time.Second, packets: 50},
+	} {
+		require.True(t, startTime.Add(expected.start).Equal(history[i].StartTime.AsTime()), "interval %d", i)
+		require.Equal(t, expected.packets, history[i].Packets, "interval %d", i)
+		require.Equal(t, uint64(expected.packets)*(12+100), history[i].Bytes, "interval %d", i) // header and payload
+		require.InDelta(t, 10.0, history[i].PacketRate, 0.01, "interval %d", i)
+	}
+	require.Equal(t, r.ToProto().EndTime.AsTime(), history[2].EndTime.AsTime())
+
+	// not kept unless enabled
+	r = NewRTPStatsReceiver(RTPStatsParams{
+		ClockRate: 90000,
+		Logger:    logger.GetLogger(),
+	})
+	require.Nil(t, r.History())
 }
 
 func Test_RTPStatsReceiver_Update(t *testing.T) {
@@ -265,3 +325,89 @@ func Test_RTPStatsReceiver_Update(t *testing.T) {
 
 	r.Stop()
 }
+
+func Test_RTPStatsReceiver_SourceSwitch(t *testing.T) {
+	clockRate := uint32(90000)
+	r := NewRTPStatsReceiver(RTPStatsParams{
+		ClockRate: clockRate,
+		Logger:    logger.GetLogger(),
+	})
+
+	frameDuration := time.Second / 30
+	frameTS := clockRate / 30
+	now := time.Now()
+	sequenceNumber := uint16(1000)
+	timestamp := uint32(1 << 31)
+	update := func() RTPFlowState {
+		packet := getPacket(sequenceNumber, timestamp, 1000)
+		return r.Update(
+			now,
+			packet.Header.SequenceNumber,
+			packet.Header.Timestamp,
+			packet.Header.Marker,
+			packet.Header.MarshalSize(),
+			len(packet.Payload),
+			0,
+		)
+	}
+	send := func(frames int) {
+		for i := 0; i < frames; i++ {
+			flowState := update()
+			require.False(t, flowState.IsSourceSwitch)
+			require.False(t, flowState.HasLoss)
+			sequenceNumber++
+			timestamp += frameTS
+			now = now.Add(frameDuration)
+		}
+	}
+
+	send(100)
+
+	// a pause with timestamps following arrival times is not a switch
+	now = now.Add(10 * time.Second)
+	timestamp += 10 * clockRate
+	send(100)
+
+	// restarted sequence numbers and timestamps on the same SSRC
+	sequenceNumber = 50
+	timestamp = 12345
+	flowState := update()
+	require.True(t, flowState.IsSourceSwitch)
+	require.False(t, flowState.HasLoss)
+	require.False(t, flowState.IsOutOfOrder)
+	sequenceNumber++
+	timestamp += frameTS
+	now = now.Add(frameDuration)
+	send(99)
+
+	switches, at := r.SourceSwitches()
+	require.Equal(t, uint32(1), switches)
+	require.False(t, at.IsZero())
+
+	stats := r.ToProto()
+	require.Equal(t, uint32(300), stats.Packets)
+	require.Zero(t, stats.PacketsLost)
+	require.Zero(t, stats.PacketsOutOfOrder)
+	require.Less(t, r.maxJitter, float64(10))
+
+	r.Stop()
+}
+
+func Test_RTPStatsReceiver_DebugMarshal(t *testing.T) {
+	r := NewRTPStatsReceiver(RTPStatsParams{
+		ClockRate: 90000,
+		Logger:    logger.GetLogger(),
+	})
+
+	r.Update(time.Now(), 100, 1000, false, 12, 1000, 0)
+	// skip two packets to record loss
+	r.Update(time.Now(), 103, 1000, true, 12, 1000, 0)
+
+	info := DebugMarshal(r)
+	require.Equal(t, uint32(90000), info["ClockRate"])
+	require.Equal(t, uint64(100), info["ExtStartSN"])
+	require.Equal(t, uint64(103), info["ExtHighestSN"])
+	require.Equal(t, uint64(2), info["PacketsLost"])
+	require.Equal(t, uint64(2024), info["Bytes"])
+	require.Equal(t, uint64(24), info["HeaderBytes"])
+}
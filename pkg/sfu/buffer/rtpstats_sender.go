@@ -18,8 +18,10 @@ import (
 	"errors"
 	"fmt"
 	"time"
+	"unsafe"
 
 	"github.com/pion/rtcp"
+	"go.uber.org/zap/zapcore"
 
 	"github.com/livekit/mediatransportutil"
 	"github.com/livekit/protocol/livekit"
@@ -27,7 +29,9 @@ import (
 
 const (
 	cSnInfoSize = 4096
-	cSnInfoMask = cSnInfoSize - 1
+
+	// audio is sent at a far lower packet rate, a shorter history covers the same receiver report intervals
+	SnInfoSizeAudio = 512
 )
 
 type snInfoFlag byte
@@ -110,6 +114,8 @@ type senderSnapshot struct {
 
 	extLastRRSN   uint64
 	intervalStats intervalStats
+
+	pacingDelay pacingDelayStats
 }
 
 type RTPStatsSender struct {
@@ -130,15 +136,24 @@ type RTPStatsSender struct {
 	jitterFromRR    float64
 	maxJitterFromRR float64
 
-	snInfos [cSnInfoSize]snInfo
+	maxPacingDelay time.Duration
+
+	snInfos    []snInfo
+	snInfoMask uint64
 
 	nextSenderSnapshotID uint32
 	senderSnapshots      []senderSnapshot
 }
 
 func NewRTPStatsSender(params RTPStatsParams) *RTPStatsSender {
+	snInfoSize := params.SnInfoSize
+	if snInfoSize <= 0 || snInfoSize&(snInfoSize-1) != 0 {
+		snInfoSize = cSnInfoSize
+	}
 	return &RTPStatsSender{
 		rtpStatsBase:         newRTPStatsBase(params),
+		snInfos:              make([]snInfo, snInfoSize),
+		snInfoMask:           uint64(snInfoSize - 1),
 		nextSenderSnapshotID: cFirstSnapshotID,
 		senderSnapshots:      make([]senderSnapshot, 2),
 	}
@@ -167,13 +182,22 @@ func (r *RTPStatsSender) Seed(from *RTPStatsSender) {
 	r.jitterFromRR = from.jitterFromRR
 	r.maxJitterFromRR = from.maxJitterFromRR
 
-	r.snInfos = from.snInfos
+	r.maxPacingDelay = from.maxPacingDelay
+
+	if len(r.snInfos) == len(from.snInfos) {
+		copy(r.snInfos, from.snInfos)
+	}
 
 	r.nextSenderSnapshotID = from.nextSenderSnapshotID
 	r.senderSnapshots = make([]senderSnapshot, cap(from.senderSnapshots))
 	copy(r.senderSnapshots, from.senderSnapshots)
 }
 
+// MemoryUsage is the size of the send history, in bytes
+func (r *RTPStatsSender) MemoryUsage() int {
+	return len(r.snInfos) * int(unsafe.Sizeof(snInfo{}))
+}
+
 func (r *RTPStatsSender) NewSnapshotId() uint32 {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -195,11 +219,27 @@ func (r *RTPStatsSender) NewSenderSnapshotId() uint32 {
 	}
 
 	if r.initialized {
-		r.senderSnapshots[id-cFirstSnapshotID] = r.initSenderSnapshot(time.Now(), r.extHighestSN)
+		r.senderSnapshots[id-cFirstSnapshotID] = r.initSenderSnapshot(r.clock.Now(), r.extHighestSN)
 	}
 	return id
 }
 
+// EnableHistory keeps the stats of up to size recent intervals of at least the given length, see History
+func (r *RTPStatsSender) EnableHistory(interval time.Duration, size int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.enableHistory(interval, size, r.extHighestSN)
+}
+
+// History returns the stats of recent intervals, oldest first, including the one in progress
+func (r *RTPStatsSender) History() []*livekit.RTPStats {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.historyToProto(r.extStartSN, r.extHighestSN)
+}
+
 func (r *RTPStatsSender) Update(
 	packetTime time.Time,
 	extSequenceNumber uint64,
@@ -216,6 +256,8 @@ func (r *RTPStatsSender) Update(
 		return
 	}
 
+	r.maybeRecordHistory(r.extStartSN, r.extHighestSN)
+
 	if !r.initialized {
 		if payloadSize == 0 {
 			// do not start on a padding only packet
@@ -224,7 +266,7 @@ func (r *RTPStatsSender) Update(
 
 		r.initialized = true
 
-		r.startTime = time.Now()
+		r.startTime = r.clock.Now()
 
 		r.firstTime = packetTime
 		r.highestTime = packetTime
@@ -344,6 +386,21 @@ func (r *RTPStatsSender) Update(
 	}
 }
 
+// UpdatePacingDelay records the time a packet spent queued in the pacer before being written,
+// i.e. latency added by the SFU on the send side, independent of network RTT
+func (r *RTPStatsSender) UpdatePacingDelay(delay time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if delay > r.maxPacingDelay {
+		r.maxPacingDelay = delay
+	}
+
+	for i := uint32(0); i < r.nextSenderSnapshotID-cFirstSnapshotID; i++ {
+		r.senderSnapshots[i].pacingDelay.add(delay)
+	}
+}
+
 func (r *RTPStatsSender) GetTotalPacketsPrimary() uint64 {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
@@ -388,7 +445,7 @@ func (r *RTPStatsSender) UpdateFromReceiverReport(rr rtcp.ReceptionReport) (rtt
 			fmt.Sprintf("receiver report potentially out of order, highestSN: existing: %d, received: %d", r.extHighestSNFromRR, extHighestSNFromRR),
 			"lastRRTime", r.lastRRTime,
 			"lastRR", r.lastRR,
-			"sinceLastRR", time.Since(r.lastRRTime),
+			"sinceLastRR", r.clock.Now().Sub(r.lastRRTime),
 			"receivedRR", rr,
 		)
 		return
@@ -440,7 +497,7 @@ func (r *RTPStatsSender) UpdateFromReceiverReport(rr rtcp.ReceptionReport) (rtt
 		s.extLastRRSN = extLastRRSN
 	}
 
-	r.lastRRTime = time.Now()
+	r.lastRRTime = r.clock.Now()
 	r.lastRR = rr
 	return
 }
@@ -483,7 +540,7 @@ func (r *RTPStatsSender) GetRtcpSenderReport(ssrc uint32, calculatedClockRate ui
 	}
 
 	// construct current time based on monotonic clock
-	timeSinceFirst := time.Since(r.firstTime)
+	timeSinceFirst := r.clock.Now().Sub(r.firstTime)
 	now := r.firstTime.Add(timeSinceFirst)
 	nowNTP := mediatransportutil.ToNtpTime(now)
 
@@ -524,7 +581,7 @@ func (r *RTPStatsSender) GetRtcpSenderReport(ssrc uint32, calculatedClockRate ui
 			"currTSExt", nowRTPExt,
 			"currRTP", nowRTP,
 			"currNTP", nowNTP.Time().String(),
-			"timeNow", time.Now().String(),
+			"timeNow", r.clock.Now().String(),
 			"firstTime", r.firstTime.String(),
 			"timeSinceFirst", timeSinceFirst,
 			"highestTime", r.highestTime.String(),
@@ -643,6 +700,8 @@ func (r *RTPStatsSender) DeltaInfoSender(senderSnapshotID uint32) *RTPDeltaInfo
 		Nacks:                now.nacks - then.nacks,
 		Plis:                 now.plis - then.plis,
 		Firs:                 now.firs - then.firs,
+		PacingDelayMedian:    then.pacingDelay.median(),
+		PacingDelayMax:       then.pacingDelay.max,
 	}
 }
 
@@ -657,6 +716,32 @@ func (r *RTPStatsSender) ToString() string {
 	)
 }
 
+func (r *RTPStatsSender) MarshalLogObject(e zapcore.ObjectEncoder) error {
+	if r == nil {
+		return nil
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	e.AddUint64("ExtHighestSNFromRR", r.extHighestSNFromRR)
+	e.AddTime("LastRRTime", r.lastRRTime)
+	e.AddUint32("LastRRFractionLost", uint32(r.lastRR.FractionLost))
+	e.AddUint32("LastRRTotalLost", r.lastRR.TotalLost)
+	e.AddUint32("LastRRLastSequenceNumber", r.lastRR.LastSequenceNumber)
+	e.AddUint32("LastRRJitter", r.lastRR.Jitter)
+	e.AddUint64("PacketsLostFromRR", r.packetsLostFromRR)
+	e.AddFloat64("JitterFromRR", r.jitterFromRR)
+	e.AddFloat64("MaxJitterFromRR", r.maxJitterFromRR)
+	e.AddDuration("MaxPacingDelay", r.maxPacingDelay)
+	return r.marshalLogObject(
+		e,
+		r.extStartSN, r.extHighestSN, r.extStartTS, r.extHighestTS,
+		r.packetsLost,
+		r.jitter, r.maxJitter,
+	)
+}
+
 func (r *RTPStatsSender) ToProto() *livekit.RTPStats {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
@@ -727,12 +812,12 @@ func (r *RTPStatsSender) getSenderSnapshot(startTime time.Time, s *senderSnapsho
 
 func (r *RTPStatsSender) getSnInfoOutOfOrderSlot(esn uint64, ehsn uint64) int {
 	offset := int64(ehsn - esn)
-	if offset >= cSnInfoSize || offset < 0 {
+	if offset >= int64(len(r.snInfos)) || offset < 0 {
 		// too old OR too new (i. e. ahead of highest)
 		return -1
 	}
 
-	return int(esn & cSnInfoMask)
+	return int(esn & r.snInfoMask)
 }
 
 func (r *RTPStatsSender) setSnInfo(esn uint64, ehsn uint64, pktSize uint16, hdrSize uint8, payloadSize uint16, marker bool, isOutOfOrder bool) {
@@ -743,7 +828,7 @@ func (r *RTPStatsSender) setSnInfo(esn uint64, ehsn uint64, pktSize uint16, hdrS
 			return
 		}
 	} else {
-		slot = int(esn & cSnInfoMask)
+		slot = int(esn & r.snInfoMask)
 	}
 
 	snInfo := &r.snInfos[slot]
@@ -766,7 +851,7 @@ func (r *RTPStatsSender) clearSnInfos(extStartInclusive uint64, extEndExclusive
 	}
 
 	for esn := extStartInclusive; esn != extEndExclusive; esn++ {
-		snInfo := &r.snInfos[esn&cSnInfoMask]
+		snInfo := &r.snInfos[esn&r.snInfoMask]
 		snInfo.pktSize = 0
 		snInfo.hdrSize = 0
 		snInfo.flags = 0
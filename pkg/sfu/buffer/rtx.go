@@ -0,0 +1,125 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// RepairedRTPStreamIDURI is the header extension carrying the RID of the stream an RTX packet repairs (RFC 8852)
+const RepairedRTPStreamIDURI = "urn:ietf:params:rtp-hdrext:sdes:repaired-rtp-stream-id"
+
+// SetPrimaryBufferForRTX makes this buffer receive the RTX stream (RFC 4588) repairing the stream of primary.
+// Retransmitted packets are restored and processed by primary, including the ones already pending here
+func (b *Buffer) SetPrimaryBufferForRTX(primary *Buffer) {
+	b.Lock()
+	if b.rtxPrimary != nil || b.bound {
+		b.Unlock()
+		return
+	}
+	b.rtxPrimary = primary
+	// pending packets are kept for Read, the RTX stream is drained by pion too
+	pending := b.pPackets
+	b.Unlock()
+
+	b.logger.Debugw("associated rtx stream", "primarySSRC", primary.GetMediaSSRC())
+	for _, pp := range pending {
+		primary.writeRTX(pp.packet, pp.arrivalTime)
+	}
+}
+
+// primaryForRTX returns the buffer whose stream this one repairs. While unassociated and unbound, it tries to find it
+// by the MID and repaired RID of pkt
+func (b *Buffer) primaryForRTX(pkt []byte) *Buffer {
+	if b.closed.Load() {
+		return nil
+	}
+
+	b.RLock()
+	primary, bound, resolve := b.rtxPrimary, b.bound, b.resolveRTX
+	b.RUnlock()
+	if primary != nil || bound || resolve == nil {
+		return primary
+	}
+
+	var h rtp.Header
+	if _, err := h.Unmarshal(pkt); err != nil || !h.Extension {
+		return nil
+	}
+	if primary = resolve(&h); primary != nil {
+		b.SetPrimaryBufferForRTX(primary)
+	}
+	return primary
+}
+
+// repairs returns true when an RTX packet names the stream of this buffer as the one it repairs
+func (b *Buffer) repairs(h *rtp.Header) bool {
+	b.RLock()
+	defer b.RUnlock()
+
+	if !b.bound || b.rid == "" || b.rridExt == 0 {
+		return false
+	}
+	if string(h.GetExtension(b.rridExt)) != b.rid {
+		return false
+	}
+	if b.midExt != 0 && b.mid != "" {
+		if mid := h.GetExtension(b.midExt); mid != nil && string(mid) != b.mid {
+			return false
+		}
+	}
+	return true
+}
+
+// writeRTX restores a packet of the RTX stream to the one it retransmits, which is then processed like a received one
+func (b *Buffer) writeRTX(pkt []byte, arrivalTime time.Time) {
+	b.Lock()
+	defer b.Unlock()
+
+	// payload type of the primary stream is learnt from its packets, video never uses 0
+	if b.closed.Load() || !b.bound || b.payloadType == 0 {
+		return
+	}
+
+	var rtxPacket rtp.Packet
+	if err := rtxPacket.Unmarshal(pkt); err != nil {
+		b.logger.Debugw("could not unmarshal RTX packet", "error", err)
+		return
+	}
+	if len(rtxPacket.Payload) < 2 {
+		// padding only, used for probing
+		b.processHeaderExtensions(&rtxPacket, arrivalTime)
+		return
+	}
+
+	restored := rtp.Packet{
+		Header:  rtxPacket.Header,
+		Payload: rtxPacket.Payload[2:],
+	}
+	restored.PayloadType = b.payloadType
+	restored.SequenceNumber = binary.BigEndian.Uint16(rtxPacket.Payload)
+	restored.SSRC = b.mediaSSRC
+	restored.Padding = false
+	restored.PaddingSize = 0
+	raw, err := restored.Marshal()
+	if err != nil {
+		return
+	}
+	b.calc(raw, arrivalTime)
+	b.rtpStats.RecordRepaired()
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pion/transport/v2/packetio"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+// ReplayedPacket is the outcome of forwarding a captured packet
+type ReplayedPacket struct {
+	Arrival        time.Time
+	SequenceNumber uint16
+	Timestamp      uint32
+	Layer          buffer.VideoLayer
+	KeyFrame       bool
+	Dropped        bool
+	Resuming       bool
+	Switching      bool
+	// munged values sent to the subscriber, set when the packet is not dropped
+	OutSequenceNumber uint16
+	OutTimestamp      uint32
+}
+
+type ReplayResult struct {
+	Packets []ReplayedPacket
+	// captured packets written to the buffer, including those it did not release for forwarding
+	Written int
+}
+
+func (r *ReplayResult) Forwarded() int {
+	forwarded := 0
+	for _, p := range r.Packets {
+		if !p.Dropped {
+			forwarded++
+		}
+	}
+	return forwarded
+}
+
+// ReplayCapture feeds a capture through a Buffer and a Forwarder for offline reproduction of receive and
+// munging issues. Video is forwarded at the captured layer with all temporal layers
+func ReplayCapture(cr *buffer.CaptureReader, meta *buffer.CaptureMetadata, l logger.Logger) (*ReplayResult, error) {
+	if l == nil {
+		l = logger.GetLogger()
+	}
+
+	bf := buffer.NewFactoryOfBufferFactory(500).CreateBufferFactory()
+	buff := bf.GetOrNew(packetio.RTPBufferPacket, meta.SSRC).(*buffer.Buffer)
+	buff.SetLogger(l)
+	buff.Bind(meta.RTPParameters(), meta.Codec.RTPCodecCapability)
+	defer buff.Close()
+
+	kind := webrtc.RTPCodecTypeAudio
+	if strings.HasPrefix(strings.ToLower(meta.Codec.MimeType), "video/") {
+		kind = webrtc.RTPCodecTypeVideo
+	}
+	f := NewForwarder(kind, l, nil, nil)
+	f.DetermineCodec(meta.Codec.RTPCodecCapability, meta.HeaderExtensions)
+	if kind == webrtc.RTPCodecTypeVideo {
+		f.vls.SetTarget(buffer.VideoLayer{Spatial: meta.Layer, Temporal: buffer.DefaultMaxLayerTemporal})
+	}
+
+	res := &ReplayResult{}
+	var replayErr error
+	res.Written, replayErr = buff.Replay(cr, func(ep *buffer.ExtPacket) {
+		rp := ReplayedPacket{
+			Arrival:        ep.Arrival,
+			SequenceNumber: ep.Packet.SequenceNumber,
+			Timestamp:      ep.Packet.Timestamp,
+			Layer:          ep.VideoLayer,
+			KeyFrame:       ep.KeyFrame,
+		}
+		tp, err := f.GetTranslationParams(ep, meta.Layer)
+		if err != nil || tp.shouldDrop || tp.rtp == nil {
+			rp.Dropped = true
+		} else {
+			rp.Resuming = tp.isResuming
+			rp.Switching = tp.isSwitching
+			rp.OutSequenceNumber = uint16(tp.rtp.extSequenceNumber)
+			rp.OutTimestamp = uint32(tp.rtp.extTimestamp)
+		}
+		res.Packets = append(res.Packets, rp)
+	})
+	return res, replayErr
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+func TestReplayCapture(t *testing.T) {
+	var capture bytes.Buffer
+	c, err := buffer.NewCapture(&capture, buffer.CaptureParams{Format: buffer.CaptureFormatPCAP})
+	require.NoError(t, err)
+
+	// a reordered packet and a gap, as captured from a lossy publisher
+	start := time.Now()
+	for i, sn := range []uint16{100, 101, 103, 102, 104, 107, 108} {
+		pkt, err := (&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    111,
+				SequenceNumber: sn,
+				Timestamp:      uint32(sn) * 960,
+				SSRC:           5678,
+			},
+			Payload: []byte{0xf8, 0xff, 0xfe},
+		}).Marshal()
+		require.NoError(t, err)
+		require.NoError(t, c.WritePacket(pkt, start.Add(time.Duration(i)*20*time.Millisecond)))
+	}
+	require.NoError(t, c.Flush())
+
+	cr, err := buffer.NewCaptureReader(&capture)
+	require.NoError(t, err)
+	res, err := ReplayCapture(cr, &buffer.CaptureMetadata{
+		SSRC: 5678,
+		Codec: webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+			PayloadType:        111,
+		},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 7, res.Written)
+	require.Len(t, res.Packets, 7)
+	require.Equal(t, 7, res.Forwarded())
+
+	var sns []uint16
+	for _, p := range res.Packets {
+		sns = append(sns, p.SequenceNumber)
+	}
+	require.Equal(t, []uint16{100, 101, 103, 102, 104, 107, 108}, sns)
+
+	// munged sequence numbers keep the offsets of the received stream
+	first := res.Packets[0].OutSequenceNumber
+	for _, p := range res.Packets {
+		require.Equal(t, p.SequenceNumber-100, p.OutSequenceNumber-first)
+	}
+}
@@ -534,6 +534,19 @@ func scoreToConnectionQuality(score float64) livekit.ConnectionQuality {
 	return livekit.ConnectionQuality_POOR
 }
 
+// MOSToConnectionQuality maps a mean opinion score to the quality tier of the score it is derived from
+func MOSToConnectionQuality(mos float32) livekit.ConnectionQuality {
+	if mos > scoreToMOS(80.0) {
+		return livekit.ConnectionQuality_EXCELLENT
+	}
+
+	if mos > scoreToMOS(40.0) {
+		return livekit.ConnectionQuality_GOOD
+	}
+
+	return livekit.ConnectionQuality_POOR
+}
+
 // ------------------------------------------
 
 func scoreToMOS(score float64) float32 {
@@ -30,14 +30,18 @@ import (
 	"github.com/pion/webrtc/v3"
 	"go.uber.org/atomic"
 
+	"github.com/livekit/mediatransportutil/pkg/bucket"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
 	dd "github.com/livekit/livekit-server/pkg/sfu/dependencydescriptor"
 	"github.com/livekit/livekit-server/pkg/sfu/pacer"
 	"github.com/livekit/livekit-server/pkg/sfu/rtpextension"
+	"github.com/livekit/livekit-server/pkg/sfu/utils"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 // TrackSender defines an interface send media to remote peer
@@ -47,6 +51,7 @@ type TrackSender interface {
 	UpTrackMaxPublishedLayerChange(maxPublishedLayer int32)
 	UpTrackMaxTemporalLayerSeenChange(maxTemporalLayerSeen int32)
 	UpTrackBitrateReport(availableLayers []int32, bitrates Bitrates)
+	UpTrackSSRCChange(oldSSRC uint32)
 	WriteRTP(p *buffer.ExtPacket, layer int32) error
 	Close()
 	IsClosed() bool
@@ -71,8 +76,15 @@ const (
 	keyFrameIntervalMax = 1000
 	flushTimeout        = 1 * time.Second
 
+	// after a gap has been concealed by replaying packets, key frame requests from the subscriber
+	// are redundant for this long, the replayed packets let its decoder recover
+	keyFrameSuppressionAfterReplay = 500 * time.Millisecond
+
 	waitBeforeSendPaddingOnMute = 100 * time.Millisecond
 	maxPaddingOnMuteDuration    = 5 * time.Second
+
+	// NACKed sequence numbers waiting for retransmission per down track
+	maxPendingNacks = 1024
 )
 
 // -------------------------------------------------------------------
@@ -199,6 +211,15 @@ type DowntrackParams struct {
 	Pacer             pacer.Pacer
 	Logger            logger.Logger
 	Trailer           []byte
+	FeedbackThrottle  config.FeedbackThrottleConfig
+	// no retransmission history is kept, NACKs from the subscriber are ignored
+	DisableNACK bool
+	// time source of the track's stats and sequencer, the wall clock when nil
+	Clock utils.Clock
+	// the max layer reported to the publisher follows the stream allocation, see Forwarder.SetDemandFromAllocation
+	DemandFromAllocation bool
+	// stats of recent intervals kept along with the lifetime stats, see GetTrackStatsHistory
+	RTPStatsHistory config.RTPStatsHistoryConfig
 }
 
 // DownTrack implements TrackLocal, is the track used to write packets
@@ -255,6 +276,19 @@ type DownTrack struct {
 
 	isNACKThrottled atomic.Bool
 
+	// retransmissions run on at most one goroutine per down track, NACKs arriving meanwhile wait for it
+	retransmitLock   sync.Mutex
+	pendingNacks     []uint16
+	isRetransmitting bool
+
+	// packets forwarded sharing the receive buffer and packets that needed their payload copied
+	sharedPayloadPackets atomic.Uint64
+	copiedPayloadPackets atomic.Uint64
+
+	feedbackThrottle *FeedbackThrottle
+
+	keyFrameRequestsSuppressedUntil atomic.Int64
+
 	activePaddingOnMuteUpTrack atomic.Bool
 
 	streamAllocatorLock             sync.RWMutex
@@ -290,15 +324,17 @@ func NewDownTrack(params DowntrackParams) (*DownTrack, error) {
 	default:
 		kind = webrtc.RTPCodecType(0)
 	}
+	if params.Clock == nil {
+		params.Clock = utils.SystemClock
+	}
 
 	d := &DownTrack{
-		params:             params,
-		id:                 params.Receiver.TrackID(),
-		upstreamCodecs:     codecs,
-		kind:               kind,
-		codec:              codecs[0].RTPCodecCapability,
-		pacer:              params.Pacer,
-		maxLayerNotifierCh: make(chan struct{}, 1),
+		params:         params,
+		id:             params.Receiver.TrackID(),
+		upstreamCodecs: codecs,
+		kind:           kind,
+		codec:          codecs[0].RTPCodecCapability,
+		pacer:          params.Pacer,
 	}
 	d.forwarder = NewForwarder(
 		d.kind,
@@ -306,12 +342,23 @@ func NewDownTrack(params DowntrackParams) (*DownTrack, error) {
 		d.params.Receiver.GetReferenceLayerRTPTimestamp,
 		d.getExpectedRTPTimestamp,
 	)
+	d.forwarder.SetDemandFromAllocation(params.DemandFromAllocation)
+
+	d.feedbackThrottle = NewFeedbackThrottle(params.FeedbackThrottle, params.Logger)
 
+	// audio heavy rooms have many more down tracks, keep their per track history to what audio needs
+	var snInfoSize int
+	if d.kind == webrtc.RTPCodecTypeAudio {
+		snInfoSize = buffer.SnInfoSizeAudio
+	}
 	d.rtpStats = buffer.NewRTPStatsSender(buffer.RTPStatsParams{
-		ClockRate: d.codec.ClockRate,
-		Logger:    params.Logger,
+		ClockRate:  d.codec.ClockRate,
+		Logger:     params.Logger,
+		SnInfoSize: snInfoSize,
+		Clock:      params.Clock,
 	})
 	d.deltaStatsSenderSnapshotId = d.rtpStats.NewSenderSnapshotId()
+	d.rtpStats.EnableHistory(params.RTPStatsHistory.Interval, params.RTPStatsHistory.Size)
 
 	d.connectionStats = connectionquality.NewConnectionStats(connectionquality.ConnectionStatsParams{
 		MimeType:       codecs[0].MimeType, // LK-TODO have to notify on codec change
@@ -343,6 +390,7 @@ func NewDownTrack(params DowntrackParams) (*DownTrack, error) {
 		}
 	}
 	if d.kind == webrtc.RTPCodecTypeVideo {
+		d.maxLayerNotifierCh = make(chan struct{}, 1)
 		go d.maxLayerNotifierWorker()
 	}
 
@@ -397,7 +445,14 @@ func (d *DownTrack) Bind(t webrtc.TrackLocalContext) (webrtc.RTPCodecParameters,
 		d.rtcpReader = rr
 	}
 
-	d.sequencer = newSequencer(d.params.MaxTrack, d.kind == webrtc.RTPCodecTypeVideo, d.params.Logger)
+	sequencerSize := d.params.MaxTrack
+	if d.kind == webrtc.RTPCodecTypeAudio && sequencerSize > buffer.AudioTrackingPackets {
+		// older audio packets are no longer in the publisher's buffer and cannot be retransmitted
+		sequencerSize = buffer.AudioTrackingPackets
+	}
+	if !d.params.DisableNACK {
+		d.sequencer = newSequencer(sequencerSize, d.kind == webrtc.RTPCodecTypeVideo, d.params.Clock, d.params.Logger)
+	}
 
 	d.codec = codec.RTPCodecCapability
 	if d.onBinding != nil {
@@ -567,6 +622,11 @@ func (d *DownTrack) SSRC() uint32 {
 	return d.ssrc
 }
 
+// IsWritable returns true once the track is bound to the subscriber's connection and packets can be sent
+func (d *DownTrack) IsWritable() bool {
+	return d.writable.Load()
+}
+
 func (d *DownTrack) Stop() error {
 	if tr := d.transceiver.Load(); tr != nil {
 		return tr.Stop()
@@ -647,6 +707,14 @@ func (d *DownTrack) postMaxLayerNotifierEvent() {
 	}
 }
 
+// maybePostAllocationDemandEvent re-evaluates the max subscribed layer after an allocation when it follows
+// the allocation, the publisher side ignores notifications that do not change its layers
+func (d *DownTrack) maybePostAllocationDemandEvent() {
+	if d.forwarder.DemandFromAllocation() {
+		d.postMaxLayerNotifierEvent()
+	}
+}
+
 func (d *DownTrack) maxLayerNotifierWorker() {
 	more := true
 	for more {
@@ -678,24 +746,36 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 	}
 
 	var payload []byte
-	poolEntity := PacketFactory.Get().(*[]byte)
+	var pb *buffer.PacketBuffer
 	if len(tp.codecBytes) != 0 {
 		incomingVP8, ok := extPkt.Payload.(buffer.VP8)
 		if ok {
-			payload = d.translateVP8PacketTo(extPkt.Packet, &incomingVP8, tp.codecBytes, poolEntity)
+			pb = buffer.GetPacketBuffer(len(extPkt.Packet.Payload) + len(tp.codecBytes) - incomingVP8.HeaderSize)
+			payload = d.translateVP8PacketTo(extPkt.Packet, &incomingVP8, tp.codecBytes, pb.Bytes())
 		}
 	}
 	if payload == nil {
-		payload = (*poolEntity)[:len(extPkt.Packet.Payload)]
-		copy(payload, extPkt.Packet.Payload)
+		if extPkt.Buffer != nil {
+			// zero copy, forward the payload from the receive buffer shared by all subscribers,
+			// holding it until the packet is sent
+			pb = extPkt.Buffer
+			pb.Retain()
+			payload = extPkt.Packet.Payload
+			d.sharedPayloadPackets.Inc()
+		} else {
+			pb = buffer.GetPacketBuffer(len(extPkt.Packet.Payload))
+			payload = pb.Bytes()
+			copy(payload, extPkt.Packet.Payload)
+			d.copiedPayloadPackets.Inc()
+		}
+	} else {
+		d.copiedPayloadPackets.Inc()
 	}
 
 	hdr, err := d.getTranslatedRTPHeader(extPkt, tp)
 	if err != nil {
 		d.params.Logger.Errorw("write rtp packet failed", err)
-		if poolEntity != nil {
-			PacketFactory.Put(poolEntity)
-		}
+		pb.Release()
 		return err
 	}
 
@@ -733,15 +813,24 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 			tp:                tp,
 		},
 	)
+	queuedAt := time.Now()
+	var enqueuedAt time.Time
+	if !extPkt.SampledAt.IsZero() {
+		enqueuedAt = queuedAt
+		prometheus.RecordForwardingLatency(prometheus.ForwardingStageForward, enqueuedAt.Sub(extPkt.SampledAt))
+	}
 	d.pacer.Enqueue(pacer.Packet{
-		Header:             hdr,
-		Extensions:         extensions,
-		Payload:            payload,
-		AbsSendTimeExtID:   uint8(d.absSendTimeExtID),
-		TransportWideExtID: uint8(d.transportWideExtID),
-		WriteStream:        d.writeStream,
-		Pool:               PacketFactory,
-		PoolEntity:         poolEntity,
+		Header:              hdr,
+		Extensions:          extensions,
+		Payload:             payload,
+		AbsSendTimeExtID:    uint8(d.absSendTimeExtID),
+		TransportWideExtID:  uint8(d.transportWideExtID),
+		WriteStream:         d.writeStream,
+		Buffer:              pb,
+		Arrival:             extPkt.Arrival,
+		EnqueuedAt:          enqueuedAt,
+		QueuedAt:            queuedAt,
+		PacingDelayRecorder: d.rtpStats,
 	})
 	return nil
 }
@@ -974,7 +1063,9 @@ func (d *DownTrack) CloseWithFlush(flush bool) {
 	d.rtpStats.Stop()
 	d.params.Logger.Infow("rtp stats", "direction", "downstream", "mime", d.mime, "ssrc", d.ssrc, "stats", d.rtpStats.ToString())
 
-	close(d.maxLayerNotifierCh)
+	if d.maxLayerNotifierCh != nil {
+		close(d.maxLayerNotifierCh)
+	}
 
 	if onCloseHandler := d.getOnCloseHandler(); onCloseHandler != nil {
 		onCloseHandler(!flush)
@@ -1055,6 +1146,14 @@ func (d *DownTrack) UpTrackMaxTemporalLayerSeenChange(maxTemporalLayerSeen int32
 	}
 }
 
+// UpTrackSSRCChange keeps sequence numbers and timestamps continuous when the publisher moves the feed
+// with oldSSRC to a new SSRC
+func (d *DownTrack) UpTrackSSRCChange(oldSSRC uint32) {
+	if d.forwarder.RebindSource(oldSSRC) {
+		d.params.Logger.Debugw("up track SSRC changed, rebinding", "oldSSRC", oldSSRC)
+	}
+}
+
 func (d *DownTrack) maybeAddTransition(_bitrate int64, distance float64, pauseReason VideoPauseReason) {
 	if d.kind == webrtc.RTPCodecTypeAudio {
 		return
@@ -1166,6 +1265,7 @@ func (d *DownTrack) AllocateOptimal(allowOvershoot bool) VideoAllocation {
 	allocation := d.forwarder.AllocateOptimal(al, brs, allowOvershoot)
 	d.maybeStartKeyFrameRequester()
 	d.maybeAddTransition(allocation.BandwidthNeeded, allocation.DistanceToDesired, allocation.PauseReason)
+	d.maybePostAllocationDemandEvent()
 	return allocation
 }
 
@@ -1208,6 +1308,7 @@ func (d *DownTrack) ProvisionalAllocateCommit() VideoAllocation {
 	allocation := d.forwarder.ProvisionalAllocateCommit()
 	d.maybeStartKeyFrameRequester()
 	d.maybeAddTransition(allocation.BandwidthNeeded, allocation.DistanceToDesired, allocation.PauseReason)
+	d.maybePostAllocationDemandEvent()
 	return allocation
 }
 
@@ -1216,6 +1317,7 @@ func (d *DownTrack) AllocateNextHigher(availableChannelCapacity int64, allowOver
 	allocation, available := d.forwarder.AllocateNextHigher(availableChannelCapacity, al, brs, allowOvershoot)
 	d.maybeStartKeyFrameRequester()
 	d.maybeAddTransition(allocation.BandwidthNeeded, allocation.DistanceToDesired, allocation.PauseReason)
+	d.maybePostAllocationDemandEvent()
 	return allocation, available
 }
 
@@ -1237,6 +1339,7 @@ func (d *DownTrack) Pause() VideoAllocation {
 	allocation := d.forwarder.Pause(al, brs)
 	d.maybeStartKeyFrameRequester()
 	d.maybeAddTransition(allocation.BandwidthNeeded, allocation.DistanceToDesired, allocation.PauseReason)
+	d.maybePostAllocationDemandEvent()
 	return allocation
 }
 
@@ -1244,6 +1347,46 @@ func (d *DownTrack) Resync() {
 	d.forwarder.Resync()
 }
 
+// ReplayGap conceals a media gap caused by a subscriber transport interruption starting at `since`.
+// Packets sent during the gap are retransmitted from the sequencer when all of them are still available.
+// Audio replays whatever is still available.
+// Returns true if the gap could be filled by replaying packets, video needs a key frame otherwise,
+// see RequestGapKeyFrame.
+func (d *DownTrack) ReplayGap(since time.Time) bool {
+	if d.sequencer == nil || since.IsZero() {
+		return false
+	}
+
+	seqNos, covered := d.sequencer.getSeqNosSince(since)
+	if covered && d.kind == webrtc.RTPCodecTypeVideo {
+		d.keyFrameRequestsSuppressedUntil.Store(d.params.Clock.Now().Add(keyFrameSuppressionAfterReplay).UnixNano())
+	}
+	if covered || d.kind == webrtc.RTPCodecTypeAudio {
+		// audio can tolerate partial loss, so replay whatever is available
+		d.params.Logger.Debugw("replaying packets to conceal gap", "since", since, "numPackets", len(seqNos), "covered", covered)
+		if len(seqNos) != 0 {
+			d.queueRetransmit(seqNos)
+		}
+	}
+	return covered
+}
+
+// RequestGapKeyFrame requests a key frame from the publisher for a video gap that could not be replayed,
+// the subscriber will not be able to decode without one.
+func (d *DownTrack) RequestGapKeyFrame() {
+	if d.kind != webrtc.RTPCodecTypeVideo {
+		return
+	}
+
+	_, layer := d.forwarder.CheckSync()
+	if layer != buffer.InvalidLayerSpatial && !d.forwarder.IsAnyMuted() {
+		// not forced, subscribers reconnecting together share the key frame
+		d.params.Logger.Debugw("gap exceeds sequencer, sending PLI", "layer", layer)
+		d.params.Receiver.SendPLI(layer, false)
+		d.rtpStats.UpdatePliTime()
+	}
+}
+
 func (d *DownTrack) CreateSourceDescriptionChunks() []rtcp.SourceDescriptionChunk {
 	if !d.bound.Load() || d.transceiver.Load() == nil {
 		return nil
@@ -1453,6 +1596,10 @@ func (d *DownTrack) handleRTCP(bytes []byte) {
 
 	pliOnce := true
 	sendPliOnce := func() {
+		if pliOnce && d.params.Clock.Now().UnixNano() < d.keyFrameRequestsSuppressedUntil.Load() {
+			d.params.Logger.Debugw("ignoring key frame request, gap was replayed")
+			pliOnce = false
+		}
 		if pliOnce {
 			_, layer := d.forwarder.CheckSync()
 			if layer != buffer.InvalidLayerSpatial && !d.forwarder.IsAnyMuted() {
@@ -1467,18 +1614,29 @@ func (d *DownTrack) handleRTCP(bytes []byte) {
 
 	rttToReport := uint32(0)
 
+	now := time.Now()
 	var numNACKs uint32
 	var numPLIs uint32
 	var numFIRs uint32
+	var numThrottledKeyFrameRequests uint32
+	var numThrottledNACKs uint32
 	for _, pkt := range pkts {
 		switch p := pkt.(type) {
 		case *rtcp.PictureLossIndication:
 			numPLIs++
-			sendPliOnce()
+			if d.feedbackThrottle.AllowKeyFrameRequest(now) {
+				sendPliOnce()
+			} else {
+				numThrottledKeyFrameRequests++
+			}
 
 		case *rtcp.FullIntraRequest:
 			numFIRs++
-			sendPliOnce()
+			if d.feedbackThrottle.AllowKeyFrameRequest(now) {
+				sendPliOnce()
+			} else {
+				numThrottledKeyFrameRequests++
+			}
 
 		case *rtcp.ReceiverEstimatedMaximumBitrate:
 			if sal := d.getStreamAllocatorListener(); sal != nil {
@@ -1523,7 +1681,11 @@ func (d *DownTrack) handleRTCP(bytes []byte) {
 				numNACKs += uint32(len(packetList))
 				nacks = append(nacks, packetList...)
 			}
-			go d.retransmitPackets(nacks)
+			if d.feedbackThrottle.AllowNacks(len(nacks), now) {
+				d.queueRetransmit(nacks)
+			} else {
+				numThrottledNACKs += uint32(len(nacks))
+			}
 
 		case *rtcp.TransportLayerCC:
 			if p.MediaSSRC == d.ssrc {
@@ -1537,6 +1699,9 @@ func (d *DownTrack) handleRTCP(bytes []byte) {
 	d.rtpStats.UpdateNack(numNACKs)
 	d.rtpStats.UpdatePli(numPLIs)
 	d.rtpStats.UpdateFir(numFIRs)
+	if numThrottledKeyFrameRequests != 0 || numThrottledNACKs != 0 {
+		prometheus.IncrementRTCPThrottled(numThrottledKeyFrameRequests, numThrottledNACKs)
+	}
 
 	if rttToReport != 0 {
 		if d.sequencer != nil {
@@ -1562,6 +1727,44 @@ func (d *DownTrack) SetActivePaddingOnMuteUpTrack() {
 	d.activePaddingOnMuteUpTrack.Store(true)
 }
 
+// SetSingleLayerSVC forwards a single operating point of SVC video to the subscriber, see Forwarder.SetSingleLayerSVC
+func (d *DownTrack) SetSingleLayerSVC(enabled bool) {
+	d.forwarder.SetSingleLayerSVC(enabled)
+}
+
+func (d *DownTrack) queueRetransmit(nacks []uint16) {
+	d.retransmitLock.Lock()
+	d.pendingNacks = append(d.pendingNacks, nacks...)
+	if len(d.pendingNacks) > maxPendingNacks {
+		// oldest are least likely to still be useful
+		d.pendingNacks = d.pendingNacks[len(d.pendingNacks)-maxPendingNacks:]
+	}
+	if d.isRetransmitting {
+		d.retransmitLock.Unlock()
+		return
+	}
+	d.isRetransmitting = true
+	d.retransmitLock.Unlock()
+
+	go d.retransmitWorker()
+}
+
+func (d *DownTrack) retransmitWorker() {
+	for {
+		d.retransmitLock.Lock()
+		nacks := d.pendingNacks
+		d.pendingNacks = nil
+		if len(nacks) == 0 {
+			d.isRetransmitting = false
+			d.retransmitLock.Unlock()
+			return
+		}
+		d.retransmitLock.Unlock()
+
+		d.retransmitPackets(nacks)
+	}
+}
+
 func (d *DownTrack) retransmitPackets(nacks []uint16) {
 	if d.sequencer == nil {
 		return
@@ -1576,14 +1779,17 @@ func (d *DownTrack) retransmitPackets(nacks []uint16) {
 		return
 	}
 
-	src := PacketFactory.Get().(*[]byte)
-	defer PacketFactory.Put(src)
+	src := buffer.GetPacketBuffer(bucket.MaxPktSize)
+	defer src.Release()
 
 	nackAcks := uint32(0)
 	nackMisses := uint32(0)
 	numRepeatedNACKs := uint32(0)
 	nackInfos := make([]NackInfo, 0, len(filtered))
-	for _, epm := range d.sequencer.getExtPacketMetas(filtered) {
+	epms := d.sequencer.borrowExtPacketMetas(filtered)
+	defer d.sequencer.releaseExtPacketMetas(epms)
+
+	for _, epm := range epms {
 		if disallowedLayers[epm.layer] {
 			continue
 		}
@@ -1595,7 +1801,7 @@ func (d *DownTrack) retransmitPackets(nacks []uint16) {
 			Attempts:       epm.nacked,
 		})
 
-		pktBuff := *src
+		pktBuff := src.Bytes()
 		n, err := d.params.Receiver.ReadRTP(pktBuff, uint8(epm.layer), epm.sourceSeqNo)
 		if err != nil {
 			if err == io.EOF {
@@ -1621,21 +1827,25 @@ func (d *DownTrack) retransmitPackets(nacks []uint16) {
 		pkt.Header.PayloadType = d.payloadType
 
 		var payload []byte
-		poolEntity := PacketFactory.Get().(*[]byte)
+		var pb *buffer.PacketBuffer
 		if d.mime == "video/vp8" && len(pkt.Payload) > 0 && len(epm.codecBytes) != 0 {
 			var incomingVP8 buffer.VP8
 			if err = incomingVP8.Unmarshal(pkt.Payload); err != nil {
 				d.params.Logger.Errorw("unmarshalling VP8 packet err", err)
-				PacketFactory.Put(poolEntity)
 				continue
 			}
 
-			payload = d.translateVP8PacketTo(&pkt, &incomingVP8, epm.codecBytes, poolEntity)
+			pb = buffer.GetPacketBuffer(len(pkt.Payload) + len(epm.codecBytes) - incomingVP8.HeaderSize + len(epm.ddBytes))
+			payload = d.translateVP8PacketTo(&pkt, &incomingVP8, epm.codecBytes, pb.Bytes())
 		}
 		if payload == nil {
-			payload = (*poolEntity)[:len(pkt.Payload)]
+			pb = buffer.GetPacketBuffer(len(pkt.Payload) + len(epm.ddBytes))
+			payload = pb.Bytes()[:len(pkt.Payload)]
 			copy(payload, pkt.Payload)
 		}
+		// the borrowed dependency descriptor is released before the pacer sends, carry a copy after the payload
+		ddBytes := pb.Bytes()[len(payload):]
+		copy(ddBytes, epm.ddBytes)
 
 		d.sendingPacket(
 			&pkt.Header,
@@ -1649,14 +1859,15 @@ func (d *DownTrack) retransmitPackets(nacks []uint16) {
 			},
 		)
 		d.pacer.Enqueue(pacer.Packet{
-			Header:             &pkt.Header,
-			Extensions:         []pacer.ExtensionData{{ID: uint8(d.dependencyDescriptorExtID), Payload: epm.ddBytes}},
-			Payload:            payload,
-			AbsSendTimeExtID:   uint8(d.absSendTimeExtID),
-			TransportWideExtID: uint8(d.transportWideExtID),
-			WriteStream:        d.writeStream,
-			Pool:               PacketFactory,
-			PoolEntity:         poolEntity,
+			Header:              &pkt.Header,
+			Extensions:          []pacer.ExtensionData{{ID: uint8(d.dependencyDescriptorExtID), Payload: ddBytes}},
+			Payload:             payload,
+			AbsSendTimeExtID:    uint8(d.absSendTimeExtID),
+			TransportWideExtID:  uint8(d.transportWideExtID),
+			WriteStream:         d.writeStream,
+			Buffer:              pb,
+			QueuedAt:            time.Now(),
+			PacingDelayRecorder: d.rtpStats,
 		})
 	}
 
@@ -1694,8 +1905,8 @@ func (d *DownTrack) getTranslatedRTPHeader(extPkt *buffer.ExtPacket, tp *Transla
 	return &hdr, nil
 }
 
-func (d *DownTrack) translateVP8PacketTo(pkt *rtp.Packet, incomingVP8 *buffer.VP8, translatedVP8 []byte, outbuf *[]byte) []byte {
-	buf := (*outbuf)[:len(pkt.Payload)+len(translatedVP8)-incomingVP8.HeaderSize]
+func (d *DownTrack) translateVP8PacketTo(pkt *rtp.Packet, incomingVP8 *buffer.VP8, translatedVP8 []byte, outbuf []byte) []byte {
+	buf := outbuf[:len(pkt.Payload)+len(translatedVP8)-incomingVP8.HeaderSize]
 	srcPayload := pkt.Payload[incomingVP8.HeaderSize:]
 	dstPayload := buf[len(translatedVP8):]
 	copy(dstPayload, srcPayload)
@@ -1704,11 +1915,35 @@ func (d *DownTrack) translateVP8PacketTo(pkt *rtp.Packet, incomingVP8 *buffer.VP
 	return buf
 }
 
+// MemoryUsage is the approximate size of the retransmission and stats history of the down track, in bytes
+func (d *DownTrack) MemoryUsage() int {
+	usage := d.rtpStats.MemoryUsage()
+	if d.sequencer != nil {
+		usage += d.sequencer.memoryUsage()
+	}
+	return usage
+}
+
+// ForwardedPackets is the number of media packets forwarded, excluding padding and retransmissions
+func (d *DownTrack) ForwardedPackets() uint64 {
+	return d.sharedPayloadPackets.Load() + d.copiedPayloadPackets.Load()
+}
+
 func (d *DownTrack) DebugInfo() map[string]interface{} {
 	stats := map[string]interface{}{
 		"LastPli": d.rtpStats.LastPli(),
 	}
 	stats["RTPMunger"] = d.forwarder.RTPMungerDebugInfo()
+	stats["SharedPayloadPackets"] = d.sharedPayloadPackets.Load()
+	stats["CopiedPayloadPackets"] = d.copiedPayloadPackets.Load()
+	if d.feedbackThrottle != nil {
+		throttledKeyFrameRequests, throttledNacks := d.feedbackThrottle.ThrottledCounts()
+		stats["FeedbackThrottle"] = map[string]interface{}{
+			"Level":                     d.feedbackThrottle.Level().String(),
+			"ThrottledKeyFrameRequests": throttledKeyFrameRequests,
+			"ThrottledNacks":            throttledNacks,
+		}
+	}
 
 	senderReport := d.CreateSenderReport()
 	if senderReport != nil {
@@ -1743,6 +1978,16 @@ func (d *DownTrack) GetTrackStats() *livekit.RTPStats {
 	return d.rtpStats.ToProto()
 }
 
+// GetTrackStatsHistory returns the stats of recent intervals of the track, oldest first, when enabled
+func (d *DownTrack) GetTrackStatsHistory() []*livekit.RTPStats {
+	return d.rtpStats.History()
+}
+
+// RTPStatsDebugInfo returns the full state of the send side RTP stats
+func (d *DownTrack) RTPStatsDebugInfo() map[string]interface{} {
+	return buffer.DebugMarshal(d.rtpStats)
+}
+
 func (d *DownTrack) deltaStats(ds *buffer.RTPDeltaInfo) map[uint32]*buffer.StreamStatsWithLayers {
 	if ds == nil {
 		return nil
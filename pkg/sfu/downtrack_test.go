@@ -0,0 +1,278 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/mediatransportutil/pkg/bucket"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/pacer"
+	"github.com/livekit/livekit-server/pkg/sfu/testutils"
+	"github.com/livekit/livekit-server/pkg/sfu/utils"
+)
+
+// testReceiver keeps the packets forwarded to the down track so that they can be retransmitted,
+// and records key frame requests sent to the publisher
+type testReceiver struct {
+	TrackReceiver
+
+	lock    sync.Mutex
+	packets map[uint16][]byte
+	plis    []bool
+}
+
+func newTestReceiver() *testReceiver {
+	return &testReceiver{
+		packets: make(map[uint16][]byte),
+	}
+}
+
+func (r *testReceiver) TrackID() livekit.TrackID {
+	return "TR_test"
+}
+
+func (r *testReceiver) HeaderExtensions() []webrtc.RTPHeaderExtensionParameter {
+	return nil
+}
+
+func (r *testReceiver) DeleteDownTrack(_ livekit.ParticipantID) {
+}
+
+func (r *testReceiver) ReadRTP(buf []byte, _ uint8, sn uint16) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	pkt, ok := r.packets[sn]
+	if !ok {
+		return 0, bucket.ErrPacketMismatch
+	}
+	return copy(buf, pkt), nil
+}
+
+func (r *testReceiver) SendPLI(_ int32, force bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.plis = append(r.plis, force)
+}
+
+func (r *testReceiver) keyFrameRequests() []bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]bool{}, r.plis...)
+}
+
+func (r *testReceiver) store(extPkt *buffer.ExtPacket) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.packets[extPkt.Packet.SequenceNumber] = extPkt.RawPacket
+}
+
+type testTrackLocalContext struct {
+	webrtc.TrackLocalContext
+
+	writeStream *testWriteStream
+}
+
+func (c *testTrackLocalContext) CodecParameters() []webrtc.RTPCodecParameters {
+	return []webrtc.RTPCodecParameters{{RTPCodecCapability: testutils.TestVP8Codec, PayloadType: 96}}
+}
+
+func (c *testTrackLocalContext) SSRC() webrtc.SSRC {
+	return 0x87654321
+}
+
+func (c *testTrackLocalContext) WriteStream() webrtc.TrackLocalWriter {
+	return c.writeStream
+}
+
+// testWriteStream records the sequence numbers of packets sent to the subscriber
+type testWriteStream struct {
+	lock   sync.Mutex
+	seqNos []uint16
+}
+
+func (w *testWriteStream) WriteRTP(header *rtp.Header, payload []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.seqNos = append(w.seqNos, header.SequenceNumber)
+	return header.MarshalSize() + len(payload), nil
+}
+
+func (w *testWriteStream) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *testWriteStream) sent() []uint16 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return append([]uint16{}, w.seqNos...)
+}
+
+func (w *testWriteStream) reset() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.seqNos = nil
+}
+
+func newTestVideoDownTrack(t *testing.T, clock utils.Clock) (*DownTrack, *testReceiver, *testWriteStream) {
+	receiver := newTestReceiver()
+	dt, err := NewDownTrack(DowntrackParams{
+		Codecs:        []webrtc.RTPCodecParameters{{RTPCodecCapability: testutils.TestVP8Codec, PayloadType: 96}},
+		Receiver:      receiver,
+		BufferFactory: buffer.NewFactoryOfBufferFactory(500).CreateBufferFactory(),
+		SubID:         "PA_test",
+		StreamID:      "test",
+		MaxTrack:      16,
+		Pacer:         pacer.NewPassThrough(logger.GetLogger()),
+		Logger:        logger.GetLogger(),
+		Clock:         clock,
+	})
+	require.NoError(t, err)
+	t.Cleanup(dt.Close)
+
+	writeStream := &testWriteStream{}
+	_, err = dt.Bind(&testTrackLocalContext{writeStream: writeStream})
+	require.NoError(t, err)
+	dt.SetConnected()
+
+	dt.forwarder.setTargetLayer(buffer.VideoLayer{Spatial: 0, Temporal: 0}, 0)
+	return dt, receiver, writeStream
+}
+
+func writeTestVP8Packets(t *testing.T, dt *DownTrack, receiver *testReceiver, clock *utils.ManualClock, sn uint16, count int) {
+	for i := 0; i < count; i++ {
+		params := &testutils.TestExtPacketParams{
+			SequenceNumber: sn + uint16(i),
+			Timestamp:      0xabcdef + uint32(i)*3000,
+			SSRC:           0x12345678,
+			PayloadSize:    20,
+			SetMarker:      true,
+			ArrivalTime:    clock.Now(),
+		}
+		vp8 := &buffer.VP8{
+			FirstByte:  25,
+			I:          true,
+			M:          true,
+			PictureID:  13467 + uint16(i),
+			L:          true,
+			TL0PICIDX:  233,
+			T:          true,
+			TID:        0,
+			Y:          true,
+			K:          true,
+			KEYIDX:     23,
+			HeaderSize: 6,
+			IsKeyFrame: sn == 1 && i == 0,
+		}
+		extPkt, err := testutils.GetTestExtPacketVP8(params, vp8)
+		require.NoError(t, err)
+		receiver.store(extPkt)
+		require.NoError(t, dt.WriteRTP(extPkt, 0))
+		clock.Advance(30 * time.Millisecond)
+	}
+}
+
+func sendTestPLI(t *testing.T, dt *DownTrack) {
+	b, err := rtcp.Marshal([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: dt.ssrc}})
+	require.NoError(t, err)
+	dt.handleRTCP(b)
+}
+
+func TestDownTrackReplayGap(t *testing.T) {
+	t.Run("state survives reconnect", func(t *testing.T) {
+		clock := utils.NewManualClock(time.Now())
+		dt, receiver, writeStream := newTestVideoDownTrack(t, clock)
+
+		// subscriber receives packets before the network change
+		writeTestVP8Packets(t, dt, receiver, clock, 1, 5)
+		sentBefore := writeStream.sent()
+		require.Len(t, sentBefore, 5)
+
+		// packets forwarded while the subscriber is switching networks are lost
+		since := clock.Now()
+		writeTestVP8Packets(t, dt, receiver, clock, 6, 5)
+		lost := writeStream.sent()[5:]
+		writeStream.reset()
+		clock.Advance(time.Second)
+
+		// on reconnect, the lost packets are replayed with the sequence numbers the subscriber expects
+		require.True(t, dt.ReplayGap(since))
+		require.Eventually(t, func() bool {
+			return len(writeStream.sent()) == len(lost)
+		}, time.Second, 10*time.Millisecond)
+		require.Equal(t, lost, writeStream.sent())
+
+		// forwarding continues the sequence of the down track, it is not restarted
+		writeStream.reset()
+		writeTestVP8Packets(t, dt, receiver, clock, 11, 1)
+		require.Equal(t, []uint16{lost[len(lost)-1] + 1}, writeStream.sent())
+	})
+
+	t.Run("key frame requests suppressed after replay", func(t *testing.T) {
+		clock := utils.NewManualClock(time.Now())
+		dt, receiver, _ := newTestVideoDownTrack(t, clock)
+
+		writeTestVP8Packets(t, dt, receiver, clock, 1, 5)
+		since := clock.Now()
+		writeTestVP8Packets(t, dt, receiver, clock, 6, 5)
+		clock.Advance(time.Second)
+		require.True(t, dt.ReplayGap(since))
+
+		// the replayed packets let the decoder recover, repeated requests do not reach the publisher
+		sendTestPLI(t, dt)
+		sendTestPLI(t, dt)
+		require.Empty(t, receiver.keyFrameRequests())
+
+		clock.Advance(keyFrameSuppressionAfterReplay)
+		sendTestPLI(t, dt)
+		require.Equal(t, []bool{false}, receiver.keyFrameRequests())
+	})
+
+	t.Run("key frame when gap cannot be replayed", func(t *testing.T) {
+		clock := utils.NewManualClock(time.Now())
+		dt, receiver, _ := newTestVideoDownTrack(t, clock)
+
+		writeTestVP8Packets(t, dt, receiver, clock, 1, 5)
+		since := clock.Now()
+		writeTestVP8Packets(t, dt, receiver, clock, 6, 20)
+		clock.Advance(time.Second)
+
+		// more packets were lost than the sequencer keeps
+		require.False(t, dt.ReplayGap(since))
+		dt.RequestGapKeyFrame()
+		require.Equal(t, []bool{false}, receiver.keyFrameRequests())
+
+		// not suppressed, the subscriber still needs a key frame
+		sendTestPLI(t, dt)
+		require.Equal(t, []bool{false, false}, receiver.keyFrameRequests())
+	})
+}
@@ -0,0 +1,207 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+type FeedbackThrottleLevel int
+
+const (
+	FeedbackThrottleLevelNone FeedbackThrottleLevel = iota
+	FeedbackThrottleLevelThrottled
+	FeedbackThrottleLevelIgnored
+)
+
+func (l FeedbackThrottleLevel) String() string {
+	switch l {
+	case FeedbackThrottleLevelNone:
+		return "NONE"
+	case FeedbackThrottleLevelThrottled:
+		return "THROTTLED"
+	case FeedbackThrottleLevelIgnored:
+		return "IGNORED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// key frame requests are never ignored completely, a subscriber without key frames cannot recover
+const defaultIgnoredKeyFrameInterval = 10 * time.Second
+
+// FeedbackThrottle applies an escalating policy to key frame requests and NACKs from a single subscriber.
+// Exceeding a per second limit moves it one level up. While throttled, key frame requests are forwarded
+// at most once per ThrottledKeyFrameInterval and NACKs beyond the limit are dropped. While ignored, NACKs
+// are dropped and key frame requests are forwarded at most once per IgnoredKeyFrameInterval, so that the
+// subscriber still recovers from losses. Each DecayInterval without a violation moves it one level down.
+type FeedbackThrottle struct {
+	conf   config.FeedbackThrottleConfig
+	logger logger.Logger
+
+	lock                 sync.Mutex
+	level                FeedbackThrottleLevel
+	windowStart          time.Time
+	keyFrameRequests     int
+	nacks                int
+	violated             bool
+	lastViolation        time.Time
+	lastKeyFrameRequest  time.Time
+	numThrottledKeyFrame uint32
+	numThrottledNacks    uint32
+}
+
+// NewFeedbackThrottle returns nil when throttling is disabled, a nil throttle allows all feedback
+func NewFeedbackThrottle(conf config.FeedbackThrottleConfig, logger logger.Logger) *FeedbackThrottle {
+	if !conf.Enabled {
+		return nil
+	}
+	return &FeedbackThrottle{
+		conf:   conf,
+		logger: logger,
+	}
+}
+
+// AllowKeyFrameRequest records a PLI/FIR and returns whether it should be forwarded to the publisher
+func (f *FeedbackThrottle) AllowKeyFrameRequest(at time.Time) bool {
+	if f == nil {
+		return true
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.advanceLocked(at)
+	f.keyFrameRequests++
+	exceeded := f.conf.KeyFrameRequestsPerSecond > 0 && f.keyFrameRequests > f.conf.KeyFrameRequestsPerSecond
+	if exceeded {
+		f.violateLocked(at, "key frame requests")
+	}
+
+	allowed := true
+	switch f.level {
+	case FeedbackThrottleLevelThrottled:
+		allowed = !exceeded && at.Sub(f.lastKeyFrameRequest) >= f.conf.ThrottledKeyFrameInterval
+	case FeedbackThrottleLevelIgnored:
+		interval := f.conf.IgnoredKeyFrameInterval
+		if interval <= 0 {
+			interval = defaultIgnoredKeyFrameInterval
+		}
+		allowed = at.Sub(f.lastKeyFrameRequest) >= interval
+	}
+	if !allowed {
+		f.numThrottledKeyFrame++
+		return false
+	}
+
+	f.lastKeyFrameRequest = at
+	return true
+}
+
+// AllowNacks records NACKed sequence numbers and returns whether they should be retransmitted
+func (f *FeedbackThrottle) AllowNacks(count int, at time.Time) bool {
+	if f == nil {
+		return true
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.advanceLocked(at)
+	f.nacks += count
+	exceeded := f.conf.NacksPerSecond > 0 && f.nacks > f.conf.NacksPerSecond
+	if exceeded {
+		f.violateLocked(at, "nacks")
+	}
+
+	allowed := true
+	switch f.level {
+	case FeedbackThrottleLevelThrottled:
+		allowed = !exceeded
+	case FeedbackThrottleLevelIgnored:
+		allowed = false
+	}
+	if !allowed {
+		f.numThrottledNacks += uint32(count)
+		return false
+	}
+	return true
+}
+
+func (f *FeedbackThrottle) Level() FeedbackThrottleLevel {
+	if f == nil {
+		return FeedbackThrottleLevelNone
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.level
+}
+
+// ThrottledCounts returns the number of key frame requests and NACKed sequence numbers dropped so far
+func (f *FeedbackThrottle) ThrottledCounts() (keyFrameRequests uint32, nacks uint32) {
+	if f == nil {
+		return
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.numThrottledKeyFrame, f.numThrottledNacks
+}
+
+func (f *FeedbackThrottle) advanceLocked(at time.Time) {
+	if at.Sub(f.windowStart) >= time.Second {
+		f.windowStart = at
+		f.keyFrameRequests = 0
+		f.nacks = 0
+		f.violated = false
+	}
+
+	if f.level != FeedbackThrottleLevelNone && at.Sub(f.lastViolation) >= f.conf.DecayInterval {
+		f.level--
+		// the next step down needs another clean interval
+		f.lastViolation = at
+		f.logger.Infow("relaxing rtcp feedback throttle", "level", f.level)
+	}
+}
+
+func (f *FeedbackThrottle) violateLocked(at time.Time, kind string) {
+	f.lastViolation = at
+	// escalate at most once per window
+	if f.violated {
+		return
+	}
+	f.violated = true
+
+	if f.level < FeedbackThrottleLevelIgnored {
+		f.level++
+		f.logger.Infow(
+			"escalating rtcp feedback throttle",
+			"level", f.level,
+			"kind", kind,
+			"keyFrameRequests", f.keyFrameRequests,
+			"nacks", f.nacks,
+			"throttledKeyFrameRequests", f.numThrottledKeyFrame,
+			"throttledNacks", f.numThrottledNacks,
+		)
+	}
+}
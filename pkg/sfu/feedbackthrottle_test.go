@@ -0,0 +1,109 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func newTestFeedbackThrottle() *FeedbackThrottle {
+	return NewFeedbackThrottle(config.FeedbackThrottleConfig{
+		Enabled:                   true,
+		KeyFrameRequestsPerSecond: 2,
+		NacksPerSecond:            100,
+		ThrottledKeyFrameInterval: 2 * time.Second,
+		IgnoredKeyFrameInterval:   5 * time.Second,
+		DecayInterval:             10 * time.Second,
+	}, logger.GetLogger())
+}
+
+func TestFeedbackThrottle(t *testing.T) {
+	t.Run("disabled allows everything", func(t *testing.T) {
+		f := NewFeedbackThrottle(config.FeedbackThrottleConfig{}, logger.GetLogger())
+		require.Nil(t, f)
+		require.True(t, f.AllowKeyFrameRequest(time.Now()))
+		require.True(t, f.AllowNacks(1_000_000, time.Now()))
+		require.Equal(t, FeedbackThrottleLevelNone, f.Level())
+	})
+
+	t.Run("key frame requests escalate", func(t *testing.T) {
+		f := newTestFeedbackThrottle()
+		now := time.Now()
+
+		require.True(t, f.AllowKeyFrameRequest(now))
+		require.True(t, f.AllowKeyFrameRequest(now))
+		require.False(t, f.AllowKeyFrameRequest(now))
+		require.Equal(t, FeedbackThrottleLevelThrottled, f.Level())
+
+		// throttled, one request per interval
+		now = now.Add(time.Second)
+		require.False(t, f.AllowKeyFrameRequest(now))
+		now = now.Add(2 * time.Second)
+		require.True(t, f.AllowKeyFrameRequest(now))
+		require.False(t, f.AllowKeyFrameRequest(now))
+
+		// still abusive, ignored
+		require.False(t, f.AllowKeyFrameRequest(now))
+		require.Equal(t, FeedbackThrottleLevelIgnored, f.Level())
+		now = now.Add(time.Second)
+		require.False(t, f.AllowKeyFrameRequest(now))
+
+		// a minimum rate of key frame requests is kept while ignored
+		now = now.Add(4 * time.Second)
+		require.True(t, f.AllowKeyFrameRequest(now))
+		require.False(t, f.AllowKeyFrameRequest(now))
+		require.Equal(t, FeedbackThrottleLevelIgnored, f.Level())
+
+		keyFrameRequests, nacks := f.ThrottledCounts()
+		require.Equal(t, uint32(6), keyFrameRequests)
+		require.Zero(t, nacks)
+	})
+
+	t.Run("nacks escalate and decay", func(t *testing.T) {
+		f := newTestFeedbackThrottle()
+		now := time.Now()
+
+		require.True(t, f.AllowNacks(60, now))
+		require.False(t, f.AllowNacks(60, now))
+		require.Equal(t, FeedbackThrottleLevelThrottled, f.Level())
+
+		// throttled allows nacks within the limit
+		now = now.Add(time.Second)
+		require.True(t, f.AllowNacks(50, now))
+		require.False(t, f.AllowNacks(60, now))
+		require.Equal(t, FeedbackThrottleLevelIgnored, f.Level())
+
+		now = now.Add(time.Second)
+		require.False(t, f.AllowNacks(1, now))
+
+		// steps down one level per clean interval
+		now = now.Add(10 * time.Second)
+		require.True(t, f.AllowNacks(1, now))
+		require.Equal(t, FeedbackThrottleLevelThrottled, f.Level())
+		now = now.Add(10 * time.Second)
+		require.True(t, f.AllowNacks(1, now))
+		require.Equal(t, FeedbackThrottleLevelNone, f.Level())
+
+		_, nacks := f.ThrottledCounts()
+		require.Equal(t, uint32(121), nacks)
+	})
+}
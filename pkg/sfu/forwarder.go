@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
 	"github.com/pion/webrtc/v3"
 
 	"github.com/livekit/protocol/logger"
@@ -201,6 +202,23 @@ type Forwarder struct {
 	referenceLayerSpatial int32
 	refTSOffset           uint64
 
+	// feed moving to a new SSRC, switching away from it continues the outgoing timeline
+	rebindSSRC uint32
+	// feed restarted sequence numbers and timestamps on the same SSRC, the next forwarded packet continues the
+	// outgoing timeline
+	restartPending bool
+
+	// the max subscribed layer follows what the stream allocator can forward when it is deficient
+	demandFromAllocation bool
+
+	// only the current spatial layer of an SVC stream is forwarded, for subscribers decoding SVC poorly
+	singleLayerSVC bool
+	// RTP timestamp of the last VP9 key picture, its lower spatial layers are needed to decode the current one
+	svcKeyPictureTS      uint32
+	svcKeyPictureTSValid bool
+	// the publisher predicts spatial layers from lower ones beyond key pictures, lower layers are always forwarded
+	svcInterLayerPredicted bool
+
 	provisional *VideoAllocationProvisional
 
 	lastAllocation VideoAllocation
@@ -514,6 +532,18 @@ func (f *Forwarder) GetMaxSubscribedSpatial() int32 {
 	if !f.muted {
 		layer = f.vls.GetMax().Spatial
 
+		if f.demandFromAllocation && f.lastAllocation.IsDeficient {
+			// keep the layer above the allocated one so that the allocator is able to move up to it
+			// when the channel recovers
+			allocated := f.lastAllocation.TargetLayer.Spatial
+			if !f.lastAllocation.TargetLayer.IsValid() {
+				allocated = buffer.InvalidLayerSpatial
+			}
+			if allocated+1 < layer {
+				layer = allocated + 1
+			}
+		}
+
 		// If current is higher, mark the current layer as max subscribed layer
 		// to prevent the current layer from stopping before forwarder switches
 		// to the new and lower max layer,
@@ -1382,9 +1412,78 @@ func (f *Forwarder) Resync() {
 	f.resyncLocked()
 }
 
+// RebindSource prepares for the feed with ssrc to continue on a new SSRC with unrelated sequence numbers and timestamps.
+// Returns true if the feed is being forwarded
+func (f *Forwarder) RebindSource(ssrc uint32) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if !f.started || f.lastSSRC != ssrc {
+		return false
+	}
+	f.rebindSSRC = ssrc
+	return true
+}
+
+// SetSingleLayerSVC extracts a single operating point of SVC streams for subscribers whose decoders handle only
+// single layer streams well. Lower spatial layers are only forwarded where the current layer depends on them,
+// which for VP9 without dependency descriptor is assumed to be key pictures, and the dependency descriptor
+// is not sent.
+func (f *Forwarder) SetSingleLayerSVC(enabled bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.singleLayerSVC = enabled
+}
+
+// SetDemandFromAllocation caps the max subscribed layer reported to the publisher at one layer above what the
+// stream allocator forwards while it is deficient, so that publishers stop sending layers the subscriber
+// cannot receive
+func (f *Forwarder) SetDemandFromAllocation(enabled bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.demandFromAllocation = enabled
+}
+
+// DemandFromAllocation returns whether the max subscribed layer follows the stream allocation
+func (f *Forwarder) DemandFromAllocation() bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.demandFromAllocation
+}
+
+// isSingleLayerSVCSelected returns whether a packet selected by the layer selector is part of the single operating point.
+// Dependency descriptor streams select frames by decode target already, which includes lower layers only where needed.
+func (f *Forwarder) isSingleLayerSVCSelected(extPkt *buffer.ExtPacket) bool {
+	vp9, ok := extPkt.Payload.(codecs.VP9Packet)
+	if !ok || extPkt.DependencyDescriptor != nil {
+		return true
+	}
+
+	if extPkt.KeyFrame {
+		f.svcKeyPictureTS = extPkt.Packet.Timestamp
+		f.svcKeyPictureTSValid = true
+	}
+	isKeyPicture := f.svcKeyPictureTSValid && extPkt.Packet.Timestamp == f.svcKeyPictureTS
+
+	current := f.vls.GetCurrent()
+	if extPkt.VideoLayer.Spatial >= current.Spatial {
+		if vp9.D && !isKeyPicture && !f.svcInterLayerPredicted {
+			f.svcInterLayerPredicted = true
+			f.logger.Infow("publisher uses inter-layer prediction beyond key pictures, forwarding lower spatial layers")
+		}
+		return true
+	}
+	return isKeyPicture || f.svcInterLayerPredicted
+}
+
 func (f *Forwarder) resyncLocked() {
 	f.vls.SetCurrent(buffer.InvalidLayer)
 	f.lastSSRC = 0
+	f.rebindSSRC = 0
+	f.restartPending = false
 	if f.pubMuted {
 		f.resumeBehindThreshold = ResumeBehindThresholdSeconds
 	}
@@ -1476,6 +1575,10 @@ func (f *Forwarder) processSourceSwitch(extPkt *buffer.ExtPacket, layer int32) e
 		)
 	}
 
+	if f.rebindSSRC != 0 && f.rebindSSRC == f.lastSSRC {
+		return f.processRebindLocked(extPkt, layer)
+	}
+
 	logTransition := func(message string, extExpectedTS, extRefTS, extLastTS uint64, diffSeconds float64) {
 		f.logger.Debugw(
 			message,
@@ -1628,6 +1731,38 @@ func (f *Forwarder) processSourceSwitch(extPkt *buffer.ExtPacket, layer int32) e
 	return nil
 }
 
+// processRebindLocked continues the outgoing stream when the feed being forwarded moved to a new SSRC.
+// Timestamps of the new feed do not relate to the old one, so the next timestamp is based on elapsed time
+func (f *Forwarder) processRebindLocked(extPkt *buffer.ExtPacket, layer int32) error {
+	f.rebindSSRC = 0
+
+	rtpMungerState := f.rtpMunger.GetLast()
+	extLastTS := rtpMungerState.ExtLastTS
+	extNextTS := extLastTS + 1
+	if f.getExpectedRTPTimestamp != nil {
+		if extExpectedTS, err := f.getExpectedRTPTimestamp(time.Now()); err == nil && int64(extExpectedTS-extLastTS) > 0 {
+			extNextTS = extExpectedTS
+		}
+	}
+	if layer == f.referenceLayerSpatial {
+		// keep reference layer timestamps mapping on to the outgoing timeline for later layer switches
+		f.refTSOffset = extNextTS - extPkt.ExtTimestamp
+	}
+	f.logger.Debugw(
+		"rebinding feed",
+		"layer", layer,
+		"extLastTS", extLastTS,
+		"extNextTS", extNextTS,
+		"nextSN", rtpMungerState.ExtLastSN+1,
+		"extIncomingSN", extPkt.ExtSequenceNumber,
+		"extIncomingTS", extPkt.ExtTimestamp,
+	)
+
+	f.rtpMunger.UpdateSnTsOffsets(extPkt, 1, extNextTS-extLastTS)
+	f.codecMunger.UpdateOffsets(extPkt)
+	return nil
+}
+
 // should be called with lock held
 func (f *Forwarder) getTranslationParamsCommon(extPkt *buffer.ExtPacket, layer int32, tp *TranslationParams) (*TranslationParams, error) {
 	if f.lastSSRC != extPkt.Packet.SSRC {
@@ -1637,6 +1772,14 @@ func (f *Forwarder) getTranslationParamsCommon(extPkt *buffer.ExtPacket, layer i
 		}
 		f.logger.Debugw("switching feed", "from", f.lastSSRC, "to", extPkt.Packet.SSRC)
 		f.lastSSRC = extPkt.Packet.SSRC
+		f.restartPending = false
+	} else if extPkt.SourceSwitch || f.restartPending {
+		// the feed restarted sequence numbers and timestamps on the same SSRC
+		f.restartPending = false
+		if err := f.processRebindLocked(extPkt, layer); err != nil {
+			tp.shouldDrop = true
+			return tp, nil
+		}
 	}
 
 	tpRTP, err := f.rtpMunger.UpdateAndGetSnTs(extPkt)
@@ -1673,11 +1816,18 @@ func (f *Forwarder) getTranslationParamsVideo(extPkt *buffer.ExtPacket, layer in
 	}
 
 	result := f.vls.Select(extPkt, layer)
+	if result.IsSelected && f.singleLayerSVC && !f.isSingleLayerSVCSelected(extPkt) {
+		maybeRollback(result.IsSwitching)
+		result.IsSelected = false
+	}
 	if !result.IsSelected {
 		tp.shouldDrop = true
 		if f.started && result.IsRelevant {
-			// call to update highest incoming sequence number and other internal structures
-			if _, err := f.rtpMunger.UpdateAndGetSnTs(extPkt); err == nil {
+			if (extPkt.SourceSwitch && extPkt.Packet.SSRC == f.lastSSRC) || f.restartPending {
+				// numbering of the restarted feed does not relate to the munger's, offsets are set on the next forwarded packet
+				f.restartPending = true
+			} else if _, err := f.rtpMunger.UpdateAndGetSnTs(extPkt); err == nil {
+				// call to update highest incoming sequence number and other internal structures
 				f.rtpMunger.PacketDropped(extPkt)
 			}
 		}
@@ -1685,7 +1835,9 @@ func (f *Forwarder) getTranslationParamsVideo(extPkt *buffer.ExtPacket, layer in
 	}
 	tp.isResuming = result.IsResuming
 	tp.isSwitching = result.IsSwitching
-	tp.ddBytes = result.DependencyDescriptorExtension
+	if !f.singleLayerSVC {
+		tp.ddBytes = result.DependencyDescriptorExtension
+	}
 	tp.marker = result.RTPMarker
 
 	if FlagPauseOnDowngrade && f.isDeficientLocked() && f.vls.GetTarget().Spatial < f.vls.GetCurrent().Spatial {
@@ -17,6 +17,7 @@ package sfu
 import (
 	"testing"
 
+	"github.com/pion/rtp/codecs"
 	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/require"
 
@@ -1182,6 +1183,34 @@ func TestForwarderPauseMute(t *testing.T) {
 	require.Equal(t, buffer.InvalidLayer, f.TargetLayer())
 }
 
+func TestForwarderDemandFromAllocation(t *testing.T) {
+	f := newForwarder(testutils.TestVP8Codec, webrtc.RTPCodecTypeVideo)
+	f.SetMaxSpatialLayer(buffer.DefaultMaxLayerSpatial)
+	f.lastAllocation = VideoAllocation{
+		IsDeficient: true,
+		TargetLayer: buffer.VideoLayer{Spatial: 0, Temporal: 2},
+	}
+
+	// subscriber preference when not enabled
+	require.Equal(t, buffer.DefaultMaxLayerSpatial, f.GetMaxSubscribedSpatial())
+
+	// one layer above the allocation while deficient
+	f.SetDemandFromAllocation(true)
+	require.Equal(t, int32(1), f.GetMaxSubscribedSpatial())
+
+	// lowest layer while paused
+	f.lastAllocation.TargetLayer = buffer.InvalidLayer
+	require.Equal(t, int32(0), f.GetMaxSubscribedSpatial())
+
+	// layer being forwarded is kept
+	f.vls.SetCurrent(buffer.VideoLayer{Spatial: 1, Temporal: 0})
+	require.Equal(t, int32(1), f.GetMaxSubscribedSpatial())
+
+	// subscriber preference when not deficient
+	f.lastAllocation.IsDeficient = false
+	require.Equal(t, buffer.DefaultMaxLayerSpatial, f.GetMaxSubscribedSpatial())
+}
+
 func TestForwarderGetTranslationParamsMuted(t *testing.T) {
 	f := newForwarder(testutils.TestVP8Codec, webrtc.RTPCodecTypeVideo)
 	f.Mute(true, true)
@@ -1355,6 +1384,113 @@ func TestForwarderGetTranslationParamsAudio(t *testing.T) {
 	require.Equal(t, f.lastSSRC, params.SSRC)
 }
 
+func TestForwarderRebindSource(t *testing.T) {
+	f := newForwarder(testutils.TestOpusCodec, webrtc.RTPCodecTypeAudio)
+
+	// nothing to rebind before forwarding
+	require.False(t, f.RebindSource(0x12345678))
+
+	forward := func(sn uint16, ts uint32, ssrc uint32) *TranslationParamsRTP {
+		extPkt, _ := testutils.GetTestExtPacket(&testutils.TestExtPacketParams{
+			SequenceNumber: sn,
+			Timestamp:      ts,
+			SSRC:           ssrc,
+			PayloadSize:    20,
+		})
+		tp, err := f.GetTranslationParams(extPkt, 0)
+		require.NoError(t, err)
+		require.False(t, tp.shouldDrop)
+		return tp.rtp
+	}
+
+	forward(100, 1000, 0x12345678)
+	require.False(t, f.RebindSource(0x87654321))
+	require.True(t, f.RebindSource(0x12345678))
+
+	// packets of the previous feed still in flight are forwarded as before
+	tp := forward(101, 1960, 0x12345678)
+	require.Equal(t, uint64(101), tp.extSequenceNumber)
+	require.Equal(t, uint64(1960), tp.extTimestamp)
+
+	// new feed continues the outgoing sequence numbers and timestamps
+	tp = forward(5000, 0xfedcba, 0x87654321)
+	require.Equal(t, SequenceNumberOrderingContiguous, tp.snOrdering)
+	require.Equal(t, uint64(102), tp.extSequenceNumber)
+	require.Equal(t, uint64(1961), tp.extTimestamp)
+	require.Equal(t, uint32(0x87654321), f.lastSSRC)
+	require.Zero(t, f.rebindSSRC)
+
+	tp = forward(5001, 0xfedcba+960, 0x87654321)
+	require.Equal(t, uint64(103), tp.extSequenceNumber)
+	require.Equal(t, uint64(1961+960), tp.extTimestamp)
+}
+
+func TestForwarderSingleLayerSVC(t *testing.T) {
+	f := newForwarder(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9, ClockRate: 90000}, webrtc.RTPCodecTypeVideo)
+	f.SetSingleLayerSVC(true)
+	f.vls.SetCurrent(buffer.VideoLayer{Spatial: 1, Temporal: 0})
+
+	packet := func(ts uint32, spatial int32, keyFrame bool, d bool) *buffer.ExtPacket {
+		extPkt, _ := testutils.GetTestExtPacket(&testutils.TestExtPacketParams{
+			IsKeyFrame:  keyFrame,
+			Timestamp:   ts,
+			SSRC:        0x12345678,
+			PayloadSize: 20,
+			VideoLayer:  buffer.VideoLayer{Spatial: spatial},
+		})
+		extPkt.Payload = codecs.VP9Packet{B: true, E: true, D: d}
+		return extPkt
+	}
+
+	// all layers of the key picture are needed to decode the operating point
+	require.True(t, f.isSingleLayerSVCSelected(packet(1000, 0, true, false)))
+	require.True(t, f.isSingleLayerSVCSelected(packet(1000, 1, false, true)))
+
+	// lower layers of subsequent pictures are not part of the operating point
+	require.False(t, f.isSingleLayerSVCSelected(packet(4000, 0, false, false)))
+	require.True(t, f.isSingleLayerSVCSelected(packet(4000, 1, false, false)))
+
+	// inter-layer prediction outside key pictures requires lower layers from then on
+	require.True(t, f.isSingleLayerSVCSelected(packet(7000, 1, false, true)))
+	require.True(t, f.svcInterLayerPredicted)
+	require.True(t, f.isSingleLayerSVCSelected(packet(10000, 0, false, false)))
+
+	// non-VP9 payloads are not filtered
+	extPkt := packet(13000, 0, false, false)
+	extPkt.Payload = nil
+	require.True(t, f.isSingleLayerSVCSelected(extPkt))
+}
+
+func TestForwarderSourceRestart(t *testing.T) {
+	f := newForwarder(testutils.TestOpusCodec, webrtc.RTPCodecTypeAudio)
+
+	forward := func(params *testutils.TestExtPacketParams, sourceSwitch bool) *TranslationParamsRTP {
+		params.SSRC = 0x12345678
+		params.PayloadSize = 20
+		extPkt, _ := testutils.GetTestExtPacket(params)
+		extPkt.SourceSwitch = sourceSwitch
+		tp, err := f.GetTranslationParams(extPkt, 0)
+		require.NoError(t, err)
+		require.False(t, tp.shouldDrop)
+		return tp.rtp
+	}
+
+	forward(&testutils.TestExtPacketParams{SequenceNumber: 100, Timestamp: 1000}, false)
+	forward(&testutils.TestExtPacketParams{SequenceNumber: 101, Timestamp: 1960}, false)
+
+	// the publisher restarted sequence numbers and timestamps, the buffer continues extended ones past the previous
+	tp := forward(&testutils.TestExtPacketParams{SequenceNumber: 50, SNCycles: 1, Timestamp: 12345, TSCycles: 1}, true)
+	require.Equal(t, SequenceNumberOrderingContiguous, tp.snOrdering)
+	require.Equal(t, uint64(102), tp.extSequenceNumber)
+	require.Equal(t, uint64(1961), tp.extTimestamp)
+	require.False(t, f.restartPending)
+
+	tp = forward(&testutils.TestExtPacketParams{SequenceNumber: 51, SNCycles: 1, Timestamp: 12345 + 960, TSCycles: 1}, false)
+	require.Equal(t, SequenceNumberOrderingContiguous, tp.snOrdering)
+	require.Equal(t, uint64(103), tp.extSequenceNumber)
+	require.Equal(t, uint64(1961+960), tp.extTimestamp)
+}
+
 func TestForwarderGetTranslationParamsVideo(t *testing.T) {
 	f := newForwarder(testutils.TestVP8Codec, webrtc.RTPCodecTypeVideo)
 
@@ -0,0 +1,47 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"time"
+)
+
+// keyFrameWatchdog detects a layer going longer than the maximum interval without a key frame, which happens
+// when encoders stop honoring key frame requests. Requests are limited to one per interval
+type keyFrameWatchdog struct {
+	maxInterval  time.Duration
+	lastKeyFrame time.Time
+	lastRequest  time.Time
+}
+
+func newKeyFrameWatchdog(maxInterval time.Duration) *keyFrameWatchdog {
+	return &keyFrameWatchdog{
+		maxInterval: maxInterval,
+	}
+}
+
+// observe returns true when a key frame should be requested
+func (k *keyFrameWatchdog) observe(at time.Time, keyFrame bool) bool {
+	if keyFrame || k.lastKeyFrame.IsZero() {
+		// the interval is counted from the first packet of the layer
+		k.lastKeyFrame = at
+		return false
+	}
+	if at.Sub(k.lastKeyFrame) < k.maxInterval || at.Sub(k.lastRequest) < k.maxInterval {
+		return false
+	}
+	k.lastRequest = at
+	return true
+}
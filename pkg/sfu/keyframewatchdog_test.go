@@ -0,0 +1,40 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyFrameWatchdog(t *testing.T) {
+	k := newKeyFrameWatchdog(10 * time.Second)
+	start := time.Now()
+
+	require.False(t, k.observe(start, false))
+	require.False(t, k.observe(start.Add(9*time.Second), false))
+	require.True(t, k.observe(start.Add(10*time.Second), false))
+
+	// rate limited to one request per interval
+	require.False(t, k.observe(start.Add(15*time.Second), false))
+	require.True(t, k.observe(start.Add(20*time.Second), false))
+
+	// a key frame restarts the interval
+	require.False(t, k.observe(start.Add(21*time.Second), true))
+	require.False(t, k.observe(start.Add(30*time.Second), false))
+	require.True(t, k.observe(start.Add(31*time.Second), false))
+}
@@ -21,6 +21,8 @@ import (
 
 	"github.com/livekit/protocol/logger"
 	"github.com/pion/rtp"
+
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 type Base struct {
@@ -43,36 +45,84 @@ func (b *Base) SetBitrate(_bitrate int) {
 }
 
 func (b *Base) SendPacket(p *Packet) (int, error) {
-	defer func() {
-		if p.Pool != nil && p.PoolEntity != nil {
-			p.Pool.Put(p.PoolEntity)
+	defer p.Buffer.Release()
+
+	start := time.Now()
+	written, err := b.writePacket(p)
+	if err != nil {
+		return 0, err
+	}
+	recordPacingDelay(p, start)
+	recordForwardingLatency(p, time.Now())
+	return written, nil
+}
+
+// SendBatch protects the packets of a pacer flush back to back while the batch writers hold their socket
+// writes, and then sends them together. The protection time is only measured when socket writes are held.
+func (b *Base) SendBatch(packets []Packet) {
+	held := holdBatchWriters()
+	start := time.Now()
+	sent := 0
+	for i := range packets {
+		if _, err := b.writePacket(&packets[i]); err != nil {
+			// not sampled for latency
+			packets[i].EnqueuedAt = time.Time{}
+			packets[i].QueuedAt = time.Time{}
+			continue
 		}
-	}()
+		sent++
+	}
+	protected := time.Since(start)
+	releaseBatchWriters(held)
 
+	now := time.Now()
+	if len(held) != 0 {
+		prometheus.AddSRTPProtect(sent, protected)
+	}
+	for i := range packets {
+		recordPacingDelay(&packets[i], start)
+		recordForwardingLatency(&packets[i], now)
+		packets[i].Buffer.Release()
+	}
+}
+
+func (b *Base) writePacket(p *Packet) (int, error) {
 	_, err := b.writeRTPHeaderExtensions(p)
 	if err != nil {
 		b.logger.Errorw("writing rtp header extensions err", err)
 		return 0, err
 	}
 
-	var written int
-	written, err = p.WriteStream.WriteRTP(p.Header, p.Payload)
+	written, err := p.WriteStream.WriteRTP(p.Header, p.Payload)
 	if err != nil {
 		if !errors.Is(err, io.ErrClosedPipe) {
 			b.logger.Errorw("write rtp packet failed", err)
 		}
 		return 0, err
 	}
-
 	return written, nil
 }
 
+func recordPacingDelay(p *Packet, dequeuedAt time.Time) {
+	if p.PacingDelayRecorder != nil && !p.QueuedAt.IsZero() {
+		p.PacingDelayRecorder.UpdatePacingDelay(dequeuedAt.Sub(p.QueuedAt))
+	}
+}
+
+func recordForwardingLatency(p *Packet, sentAt time.Time) {
+	if !p.EnqueuedAt.IsZero() {
+		prometheus.RecordForwardingLatency(prometheus.ForwardingStagePacer, sentAt.Sub(p.EnqueuedAt))
+		prometheus.RecordForwardingLatency(prometheus.ForwardingStageTotal, sentAt.Sub(p.Arrival))
+	}
+}
+
 // writes RTP header extensions of track
 func (b *Base) writeRTPHeaderExtensions(p *Packet) (time.Time, error) {
-	// clear out extensions that may have been in the forwarded header
+	// clear out extensions that may have been in the forwarded header,
+	// the slice is shared with the incoming packet and other subscribers, so it is replaced, not reused
 	p.Header.Extension = false
 	p.Header.ExtensionProfile = 0
-	p.Header.Extensions = []rtp.Extension{}
+	p.Header.Extensions = nil
 
 	for _, ext := range p.Extensions {
 		if ext.ID == 0 || len(ext.Payload) == 0 {
@@ -0,0 +1,69 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pacer
+
+import (
+	"sync"
+)
+
+// BatchWriter is a socket that queues writes. Writes made while it is held are sent together on release,
+// unless its queue fills up first.
+type BatchWriter interface {
+	HoldWrites()
+	// ReleaseWrites sends the queued writes once no flush holds them anymore
+	ReleaseWrites()
+}
+
+var (
+	batchWritersLock sync.RWMutex
+	batchWriters     []BatchWriter
+)
+
+// RegisterBatchWriter makes pacer flushes hold the writes of w
+func RegisterBatchWriter(w BatchWriter) {
+	batchWritersLock.Lock()
+	defer batchWritersLock.Unlock()
+
+	batchWriters = append(batchWriters, w)
+}
+
+func UnregisterBatchWriter(w BatchWriter) {
+	batchWritersLock.Lock()
+	defer batchWritersLock.Unlock()
+
+	for i, bw := range batchWriters {
+		if bw == w {
+			batchWriters = append(batchWriters[:i:i], batchWriters[i+1:]...)
+			return
+		}
+	}
+}
+
+func holdBatchWriters() []BatchWriter {
+	batchWritersLock.RLock()
+	held := batchWriters
+	batchWritersLock.RUnlock()
+
+	for _, w := range held {
+		w.HoldWrites()
+	}
+	return held
+}
+
+func releaseBatchWriters(held []BatchWriter) {
+	for _, w := range held {
+		w.ReleaseWrites()
+	}
+}
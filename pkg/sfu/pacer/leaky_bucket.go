@@ -73,6 +73,10 @@ func (l *LeakyBucket) Stop() {
 	}
 
 	l.isStopped = true
+	for l.packets.Len() > 0 {
+		p := l.packets.PopFront()
+		p.Buffer.Release()
+	}
 	l.lock.Unlock()
 }
 
@@ -80,9 +84,11 @@ func (l *LeakyBucket) Enqueue(p Packet) {
 	l.lock.Lock()
 	defer l.lock.Unlock()
 
-	if !l.isStopped {
-		l.packets.PushBack(p)
+	if l.isStopped {
+		p.Buffer.Release()
+		return
 	}
+	l.packets.PushBack(p)
 }
 
 func (l *LeakyBucket) sendWorker() {
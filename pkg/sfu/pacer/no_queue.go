@@ -53,6 +53,10 @@ func (n *NoQueue) Stop() {
 
 	close(n.wake)
 	n.isStopped = true
+	for n.packets.Len() > 0 {
+		p := n.packets.PopFront()
+		p.Buffer.Release()
+	}
 	n.lock.Unlock()
 }
 
@@ -60,8 +64,12 @@ func (n *NoQueue) Enqueue(p Packet) {
 	n.lock.Lock()
 	defer n.lock.Unlock()
 
+	if n.isStopped {
+		p.Buffer.Release()
+		return
+	}
 	n.packets.PushBack(p)
-	if n.packets.Len() == 1 && !n.isStopped {
+	if n.packets.Len() == 1 {
 		select {
 		case n.wake <- struct{}{}:
 		default:
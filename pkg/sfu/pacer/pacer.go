@@ -15,11 +15,12 @@
 package pacer
 
 import (
-	"sync"
 	"time"
 
 	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 )
 
 type ExtensionData struct {
@@ -27,6 +28,11 @@ type ExtensionData struct {
 	Payload []byte
 }
 
+// PacingDelayRecorder is told how long a packet waited in the pacer queue before being written
+type PacingDelayRecorder interface {
+	UpdatePacingDelay(delay time.Duration)
+}
+
 type Packet struct {
 	Header             *rtp.Header
 	Extensions         []ExtensionData
@@ -34,8 +40,14 @@ type Packet struct {
 	AbsSendTimeExtID   uint8
 	TransportWideExtID uint8
 	WriteStream        webrtc.TrackLocalWriter
-	Pool               *sync.Pool
-	PoolEntity         *[]byte
+	// holds Payload, released once the packet is sent or dropped
+	Buffer *buffer.PacketBuffer
+	// set on packets sampled for forwarding latency
+	Arrival    time.Time
+	EnqueuedAt time.Time
+	// set on packets whose pacer queue residence time is reported
+	QueuedAt            time.Time
+	PacingDelayRecorder PacingDelayRecorder
 }
 
 type Pacer interface {
@@ -0,0 +1,232 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pacer
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/gammazero/deque"
+	"github.com/livekit/protocol/logger"
+	"go.uber.org/atomic"
+)
+
+const (
+	// packets a worker sends for a connection before moving on to the next one
+	workerQueueBatchSize   = 16
+	defaultWorkerQueueSize = 1024
+)
+
+// WorkerPool sends packets of many connections on a bounded number of workers instead of a goroutine
+// per connection. Each connection gets a WorkerQueue pinned to one worker, so its packets are always
+// sent in order by the same goroutine, and workers take turns between their connections in batches.
+type WorkerPool struct {
+	queueSize int
+	workers   []*worker
+	next      atomic.Uint32
+}
+
+func NewWorkerPool(numWorkers int, queueSize int) *WorkerPool {
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	if queueSize <= 0 {
+		queueSize = defaultWorkerQueueSize
+	}
+
+	p := &WorkerPool{
+		queueSize: queueSize,
+	}
+	for i := 0; i < numWorkers; i++ {
+		w := &worker{
+			wake: make(chan struct{}, 1),
+		}
+		w.ready.SetMinCapacity(4)
+		p.workers = append(p.workers, w)
+
+		go w.run()
+	}
+	return p
+}
+
+func (p *WorkerPool) Stop() {
+	for _, w := range p.workers {
+		w.stop()
+	}
+}
+
+// NewQueue returns the pacer of a connection, connections are spread over the workers round robin
+func (p *WorkerPool) NewQueue(logger logger.Logger) *WorkerQueue {
+	w := p.workers[(p.next.Inc()-1)%uint32(len(p.workers))]
+	q := &WorkerQueue{
+		Base:      NewBase(logger),
+		logger:    logger,
+		worker:    w,
+		queueSize: p.queueSize,
+	}
+	q.packets.SetMinCapacity(9)
+	return q
+}
+
+// ------------------------------------------------
+
+type worker struct {
+	lock      sync.Mutex
+	ready     deque.Deque[*WorkerQueue]
+	wake      chan struct{}
+	isStopped bool
+}
+
+func (w *worker) schedule(q *WorkerQueue) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.isStopped {
+		return
+	}
+
+	w.ready.PushBack(q)
+	if w.ready.Len() == 1 {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *worker) stop() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.isStopped {
+		return
+	}
+
+	w.isStopped = true
+	close(w.wake)
+}
+
+func (w *worker) run() {
+	for range w.wake {
+		for {
+			w.lock.Lock()
+			if w.isStopped {
+				w.lock.Unlock()
+				return
+			}
+
+			if w.ready.Len() == 0 {
+				w.lock.Unlock()
+				break
+			}
+			q := w.ready.PopFront()
+			w.lock.Unlock()
+
+			if q.sendBatch() {
+				// more to send, back of the line to be fair to other connections
+				w.schedule(q)
+			}
+		}
+	}
+}
+
+// ------------------------------------------------
+
+// WorkerQueue is the pacer of a connection served by a WorkerPool
+type WorkerQueue struct {
+	*Base
+
+	logger    logger.Logger
+	worker    *worker
+	queueSize int
+
+	lock        sync.Mutex
+	packets     deque.Deque[Packet]
+	isScheduled bool
+	isStopped   bool
+	numDropped  int
+}
+
+func (q *WorkerQueue) Stop() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.isStopped {
+		return
+	}
+
+	q.isStopped = true
+	for q.packets.Len() > 0 {
+		p := q.packets.PopFront()
+		p.Buffer.Release()
+	}
+}
+
+func (q *WorkerQueue) Enqueue(p Packet) {
+	q.lock.Lock()
+	if q.isStopped {
+		q.lock.Unlock()
+		p.Buffer.Release()
+		return
+	}
+
+	if q.packets.Len() >= q.queueSize {
+		// connection is not keeping up, drop the oldest to bound memory
+		dropped := q.packets.PopFront()
+		dropped.Buffer.Release()
+		q.numDropped++
+		if q.numDropped%100 == 1 {
+			q.logger.Warnw("worker queue full, dropping packets", nil, "dropped", q.numDropped, "queueSize", q.queueSize)
+		}
+	}
+	q.packets.PushBack(p)
+
+	schedule := !q.isScheduled
+	q.isScheduled = true
+	q.lock.Unlock()
+
+	if schedule {
+		q.worker.schedule(q)
+	}
+}
+
+// sendBatch sends up to a batch of packets, returning whether more are queued
+func (q *WorkerQueue) sendBatch() bool {
+	var batch [workerQueueBatchSize]Packet
+	n := 0
+
+	q.lock.Lock()
+	for !q.isStopped && n < workerQueueBatchSize && q.packets.Len() != 0 {
+		batch[n] = q.packets.PopFront()
+		n++
+	}
+	if n == 0 {
+		q.isScheduled = false
+		q.lock.Unlock()
+		return false
+	}
+	q.lock.Unlock()
+
+	q.Base.SendBatch(batch[:n])
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.isStopped || q.packets.Len() == 0 {
+		q.isScheduled = false
+		return false
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pacer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+)
+
+type testWriteStream struct {
+	lock    sync.Mutex
+	seqNos  []uint16
+	blockCh chan struct{}
+}
+
+func (w *testWriteStream) WriteRTP(header *rtp.Header, _payload []byte) (int, error) {
+	if w.blockCh != nil {
+		<-w.blockCh
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.seqNos = append(w.seqNos, header.SequenceNumber)
+	return 0, nil
+}
+
+func (w *testWriteStream) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *testWriteStream) sent() []uint16 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return append([]uint16{}, w.seqNos...)
+}
+
+func TestWorkerQueue(t *testing.T) {
+	t.Run("sends in order per connection", func(t *testing.T) {
+		pool := NewWorkerPool(2, 0)
+		defer pool.Stop()
+
+		streams := make([]*testWriteStream, 5)
+		for i := range streams {
+			streams[i] = &testWriteStream{}
+			q := pool.NewQueue(logger.GetLogger())
+			for sn := uint16(0); sn < 100; sn++ {
+				q.Enqueue(Packet{
+					Header:      &rtp.Header{SequenceNumber: sn},
+					WriteStream: streams[i],
+				})
+			}
+		}
+
+		for _, stream := range streams {
+			require.Eventually(t, func() bool { return len(stream.sent()) == 100 }, time.Second, 10*time.Millisecond)
+			for i, sn := range stream.sent() {
+				require.Equal(t, uint16(i), sn)
+			}
+		}
+	})
+
+	t.Run("drops oldest when full", func(t *testing.T) {
+		pool := NewWorkerPool(1, 4)
+		defer pool.Stop()
+
+		stream := &testWriteStream{blockCh: make(chan struct{})}
+		q := pool.NewQueue(logger.GetLogger())
+
+		// first packet is picked up by the worker and blocks it
+		q.Enqueue(Packet{Header: &rtp.Header{SequenceNumber: 0}, WriteStream: stream})
+		require.Eventually(t, func() bool {
+			q.lock.Lock()
+			defer q.lock.Unlock()
+			return q.packets.Len() == 0
+		}, time.Second, time.Millisecond)
+
+		for sn := uint16(1); sn < 10; sn++ {
+			q.Enqueue(Packet{Header: &rtp.Header{SequenceNumber: sn}, WriteStream: stream})
+		}
+		close(stream.blockCh)
+
+		require.Eventually(t, func() bool { return len(stream.sent()) == 5 }, time.Second, 10*time.Millisecond)
+		require.Equal(t, []uint16{0, 6, 7, 8, 9}, stream.sent())
+	})
+
+	t.Run("no sends after stop", func(t *testing.T) {
+		pool := NewWorkerPool(1, 0)
+		defer pool.Stop()
+
+		stream := &testWriteStream{}
+		q := pool.NewQueue(logger.GetLogger())
+		q.Stop()
+		q.Enqueue(Packet{Header: &rtp.Header{SequenceNumber: 0}, WriteStream: stream})
+
+		time.Sleep(50 * time.Millisecond)
+		require.Empty(t, stream.sent())
+	})
+}
@@ -17,6 +17,7 @@ package sfu
 import (
 	"errors"
 	"io"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -26,7 +27,6 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/livekit/mediatransportutil/pkg/bucket"
-	"github.com/livekit/mediatransportutil/pkg/twcc"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 
@@ -35,8 +35,13 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
 	dd "github.com/livekit/livekit-server/pkg/sfu/dependencydescriptor"
+	"github.com/livekit/livekit-server/pkg/sfu/twcc"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
+// one in this many forwarded packets of a layer has its latency through each stage of forwarding recorded
+const forwardingLatencySampleInterval = 64
+
 var (
 	ErrReceiverClosed        = errors.New("receiver closed")
 	ErrDownTrackAlreadyExist = errors.New("DownTrack already exist")
@@ -69,6 +74,8 @@ type TrackReceiver interface {
 	DeleteDownTrack(participantID livekit.ParticipantID)
 
 	DebugInfo() map[string]interface{}
+	RTPStatsDebugInfo() map[string]interface{}
+	LayerMappings() []LayerMapping
 
 	TrackInfo() *livekit.TrackInfo
 
@@ -84,12 +91,24 @@ type TrackReceiver interface {
 	GetReferenceLayerRTPTimestamp(ets uint64, layer int32, referenceLayer int32) (uint64, error)
 }
 
+// LayerMapping ties a layer of a track to the SSRC and rid the publisher sends it with, so that client side
+// WebRTC stats can be matched with the SFU's
+type LayerMapping struct {
+	Layer int32 `json:"layer"`
+	// quality of simulcast video layers, empty for audio and SVC
+	Quality string `json:"quality,omitempty"`
+	SSRC    uint32 `json:"ssrc"`
+	RID     string `json:"rid,omitempty"`
+}
+
 // WebRTCReceiver receives a media track
 type WebRTCReceiver struct {
 	logger logger.Logger
 
 	pliThrottleConfig config.PLIThrottleConfig
 	audioConfig       config.AudioConfig
+	// layers going longer than this without a key frame get one requested
+	maxKeyFrameInterval time.Duration
 
 	trackID        livekit.TrackID
 	streamID       string
@@ -111,12 +130,17 @@ type WebRTCReceiver struct {
 	bufferMu sync.RWMutex
 	buffers  [buffer.DefaultMaxLayerSpatial + 1]*buffer.Buffer
 	rtt      uint32
+	// video packets are held back until their frame is complete for at most this long, 0 when disabled
+	frameAssemblyDelay time.Duration
 
 	upTrackMu sync.RWMutex
 	upTracks  [buffer.DefaultMaxLayerSpatial + 1]*webrtc.TrackRemote
 
 	lbThreshold int
 
+	// spacing of receiver reports sent to the publisher, buffer defaults are used when zero
+	rtcpSchedule buffer.RTCPSchedule
+
 	streamTrackerManager *StreamTrackerManager
 
 	downTrackSpreader *DownTrackSpreader
@@ -129,6 +153,9 @@ type WebRTCReceiver struct {
 	primaryReceiver atomic.Pointer[RedPrimaryReceiver]
 	redReceiver     atomic.Pointer[RedReceiver]
 	redPktWriter    func(pkt *buffer.ExtPacket, spatialLayer int32)
+
+	// called when the publisher replaces the source of a layer
+	onSourceChange func(layer int32)
 }
 
 // SVC-TODO: Have to use more conditions to differentiate between
@@ -167,6 +194,30 @@ func WithAudioConfig(audioConfig config.AudioConfig) ReceiverOpts {
 	}
 }
 
+// WithMaxKeyFrameInterval requests a key frame from layers going longer than maxInterval without one
+func WithMaxKeyFrameInterval(maxInterval time.Duration) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.maxKeyFrameInterval = maxInterval
+		return w
+	}
+}
+
+// WithFrameAssembly holds back video packets until their frame is complete, for at most maxDelay
+func WithFrameAssembly(maxDelay time.Duration) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.frameAssemblyDelay = maxDelay
+		return w
+	}
+}
+
+// WithRTCPSchedule spaces the receiver reports of the track according to schedule
+func WithRTCPSchedule(schedule buffer.RTCPSchedule) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.rtcpSchedule = schedule
+		return w
+	}
+}
+
 // WithStreamTrackers enables StreamTracker use for simulcast
 func WithStreamTrackers() ReceiverOpts {
 	return func(w *WebRTCReceiver) *WebRTCReceiver {
@@ -262,6 +313,24 @@ func (w *WebRTCReceiver) OnMaxLayerChange(fn func(maxLayer int32)) {
 	w.upTrackMu.Unlock()
 }
 
+// OnSourceChange is called when the publisher replaces the source of a layer, moving it to a new SSRC or restarting
+// sequence numbers and timestamps on the same one. Forwarding continues seamlessly either way
+func (w *WebRTCReceiver) OnSourceChange(fn func(layer int32)) {
+	w.upTrackMu.Lock()
+	w.onSourceChange = fn
+	w.upTrackMu.Unlock()
+}
+
+func (w *WebRTCReceiver) sourceChanged(layer int32) {
+	w.upTrackMu.RLock()
+	onSourceChange := w.onSourceChange
+	w.upTrackMu.RUnlock()
+
+	if onSourceChange != nil {
+		onSourceChange(layer)
+	}
+}
+
 func (w *WebRTCReceiver) GetConnectionScoreAndQuality() (float32, livekit.ConnectionQuality) {
 	return w.connectionStats.GetScoreAndQuality()
 }
@@ -290,6 +359,27 @@ func (w *WebRTCReceiver) SetRTT(rtt uint32) {
 	}
 }
 
+// SetFrameAssembly holds back video packets of all layers until their frame is complete, for at most maxDelay.
+// 0 disables it
+func (w *WebRTCReceiver) SetFrameAssembly(maxDelay time.Duration) {
+	if w.kind != webrtc.RTPCodecTypeVideo {
+		return
+	}
+
+	w.bufferMu.Lock()
+	w.frameAssemblyDelay = maxDelay
+	buffers := w.buffers
+	w.bufferMu.Unlock()
+
+	for _, buff := range buffers {
+		if buff == nil {
+			continue
+		}
+
+		buff.SetFrameAssembly(maxDelay)
+	}
+}
+
 func (w *WebRTCReceiver) StreamID() string {
 	return w.streamID
 }
@@ -320,9 +410,10 @@ func (w *WebRTCReceiver) Kind() webrtc.RTPCodecType {
 	return w.kind
 }
 
-func (w *WebRTCReceiver) AddUpTrack(track *webrtc.TrackRemote, buff *buffer.Buffer) {
+// AddUpTrack adds the feed of a layer. Returns true if it replaced the feed of a layer the publisher moved to a new SSRC
+func (w *WebRTCReceiver) AddUpTrack(track *webrtc.TrackRemote, buff *buffer.Buffer) bool {
 	if w.closed.Load() {
-		return
+		return false
 	}
 
 	layer := int32(0)
@@ -338,6 +429,12 @@ func (w *WebRTCReceiver) AddUpTrack(track *webrtc.TrackRemote, buff *buffer.Buff
 		SmoothIntervals: w.audioConfig.SmoothIntervals,
 	})
 	buff.OnRtcpFeedback(w.sendRTCP)
+	buff.OnSourceSwitch(func() {
+		w.logger.Infow("publisher restarted stream, continuing", "layer", layer)
+		// sender reports of the previous source do not relate to timestamps of the new one
+		w.streamTrackerManager.ResetSenderReportData(layer)
+		w.sourceChanged(layer)
+	})
 	buff.OnRtcpSenderReport(func() {
 		srFirst, srNewest := buff.GetSenderReportData()
 		w.streamTrackerManager.SetRTCPSenderReportData(layer, srFirst, srNewest)
@@ -363,21 +460,70 @@ func (w *WebRTCReceiver) AddUpTrack(track *webrtc.TrackRemote, buff *buffer.Buff
 	}
 
 	w.upTrackMu.Lock()
+	prevTrack := w.upTracks[layer]
 	w.upTracks[layer] = track
 	w.upTrackMu.Unlock()
 
 	w.bufferMu.Lock()
+	prevBuff := w.buffers[layer]
 	w.buffers[layer] = buff
 	rtt := w.rtt
+	frameAssemblyDelay := w.frameAssemblyDelay
 	w.bufferMu.Unlock()
 	buff.SetRTT(rtt)
+	if w.rtcpSchedule.Interval > 0 {
+		buff.SetRTCPSchedule(w.rtcpSchedule)
+	}
+	if w.Kind() == webrtc.RTPCodecTypeVideo {
+		buff.SetFrameAssembly(frameAssemblyDelay)
+	}
 	buff.SetPaused(w.streamTrackerManager.IsPaused())
 
+	if prevBuff != nil && prevBuff != buff {
+		w.rebindUpTrack(layer, prevTrack, prevBuff, buff)
+		return true
+	}
+
 	if w.Kind() == webrtc.RTPCodecTypeVideo && w.useTrackers {
 		w.streamTrackerManager.AddTracker(layer)
 	}
 
 	go w.forwardRTP(layer)
+	return false
+}
+
+// rebindUpTrack moves a layer on to the feed of the SSRC the publisher switched to. The forwarding goroutine of the
+// layer continues on the new buffer, keeping down tracks, and with them subscriptions and sequencer state, in place
+func (w *WebRTCReceiver) rebindUpTrack(layer int32, prevTrack *webrtc.TrackRemote, prevBuff *buffer.Buffer, buff *buffer.Buffer) {
+	var prevSSRC uint32
+	if prevTrack != nil {
+		prevSSRC = uint32(prevTrack.SSRC())
+	}
+	w.logger.Infow("publisher changed SSRC, rebinding layer", "layer", layer, "prevSSRC", prevSSRC, "ssrc", buff.GetMediaSSRC())
+
+	// sender reports of the previous feed do not relate to timestamps of the new one
+	w.streamTrackerManager.ResetSenderReportData(layer)
+
+	rebind := func(dt TrackSender) {
+		dt.UpTrackSSRCChange(prevSSRC)
+	}
+	w.downTrackSpreader.Broadcast(rebind)
+	if pr := w.primaryReceiver.Load(); pr != nil {
+		pr.downTrackSpreader.Broadcast(rebind)
+	}
+	if rr := w.redReceiver.Load(); rr != nil {
+		rr.downTrackSpreader.Broadcast(rebind)
+	}
+
+	// unblocks the forwarding goroutine reading from the previous buffer
+	_ = prevBuff.Close()
+
+	if w.Kind() == webrtc.RTPCodecTypeVideo {
+		// decoders of subscribers need a key frame of the new feed
+		buff.SendPLI(true)
+	}
+
+	w.sourceChanged(layer)
 }
 
 // SetUpTrackPaused indicates upstream will not be sending any data.
@@ -525,6 +671,58 @@ func (w *WebRTCReceiver) SetRTCPCh(ch chan []rtcp.Packet) {
 	w.rtcpCh = ch
 }
 
+// LastPacketTime returns the arrival time of the latest packet on any layer
+func (w *WebRTCReceiver) LastPacketTime() time.Time {
+	w.bufferMu.RLock()
+	defer w.bufferMu.RUnlock()
+
+	var last time.Time
+	for _, buff := range w.buffers {
+		if buff == nil {
+			continue
+		}
+		if at := buff.LastPacketTime(); at.After(last) {
+			last = at
+		}
+	}
+	return last
+}
+
+// LastVoiceActivity returns when the audio level of the track was last at or above the active level
+func (w *WebRTCReceiver) LastVoiceActivity() time.Time {
+	if w.Kind() == webrtc.RTPCodecTypeVideo {
+		return time.Time{}
+	}
+
+	w.bufferMu.RLock()
+	defer w.bufferMu.RUnlock()
+
+	var last time.Time
+	for _, buff := range w.buffers {
+		if buff == nil {
+			continue
+		}
+		if at := buff.LastVoiceActivity(); at.After(last) {
+			last = at
+		}
+	}
+	return last
+}
+
+// Buffers returns the receive buffer of every published layer, keyed by spatial layer
+func (w *WebRTCReceiver) Buffers() map[int32]*buffer.Buffer {
+	w.bufferMu.RLock()
+	defer w.bufferMu.RUnlock()
+
+	buffers := make(map[int32]*buffer.Buffer, len(w.buffers))
+	for layer, buff := range w.buffers {
+		if buff != nil {
+			buffers[int32(layer)] = buff
+		}
+	}
+	return buffers
+}
+
 func (w *WebRTCReceiver) getBuffer(layer int32) *buffer.Buffer {
 	w.bufferMu.RLock()
 	defer w.bufferMu.RUnlock()
@@ -623,7 +821,6 @@ func (w *WebRTCReceiver) GetDeltaStats() map[uint32]*buffer.StreamStatsWithLayer
 }
 
 func (w *WebRTCReceiver) forwardRTP(layer int32) {
-	pktBuf := make([]byte, bucket.MaxPktSize)
 	tracker := w.streamTrackerManager.GetTracker(layer)
 
 	defer func() {
@@ -644,16 +841,38 @@ func (w *WebRTCReceiver) forwardRTP(layer int32) {
 		}
 	}()
 
+	var watchdog *keyFrameWatchdog
+	if w.kind == webrtc.RTPCodecTypeVideo && w.maxKeyFrameInterval > 0 {
+		watchdog = newKeyFrameWatchdog(w.maxKeyFrameInterval)
+	}
+
+	numPackets := 0
 	for {
 		w.bufferMu.RLock()
 		buf := w.buffers[layer]
 		redPktWriter := w.redPktWriter
 		w.bufferMu.RUnlock()
-		pkt, err := buf.ReadExtended(pktBuf)
+		pb := buffer.GetPacketBuffer(bucket.MaxPktSize)
+		pkt, err := buf.ReadExtended(pb)
 		if err == io.EOF {
+			pb.Release()
+
+			w.bufferMu.RLock()
+			rebound := w.buffers[layer] != buf
+			w.bufferMu.RUnlock()
+			if rebound && !w.closed.Load() {
+				// layer moved to the feed of a new SSRC
+				continue
+			}
 			return
 		}
 
+		numPackets++
+		if numPackets%forwardingLatencySampleInterval == 0 {
+			pkt.SampledAt = time.Now()
+			prometheus.RecordForwardingLatency(prometheus.ForwardingStageReceive, pkt.SampledAt.Sub(pkt.Arrival))
+		}
+
 		spatialTracker := tracker
 		spatialLayer := layer
 		if pkt.Spatial >= 0 {
@@ -669,6 +888,12 @@ func (w *WebRTCReceiver) forwardRTP(layer int32) {
 			_ = dt.WriteRTP(pkt, spatialLayer)
 		})
 
+		if watchdog != nil && watchdog.observe(pkt.Arrival, pkt.KeyFrame) && w.downTrackSpreader.DownTrackCount() != 0 {
+			w.logger.Infow("no key frame within interval, requesting one", "layer", layer, "maxInterval", w.maxKeyFrameInterval)
+			prometheus.IncrementKeyFrameWatchdog()
+			w.SendPLI(layer, false)
+		}
+
 		if redPktWriter != nil {
 			redPktWriter(pkt, spatialLayer)
 		}
@@ -683,6 +908,9 @@ func (w *WebRTCReceiver) forwardRTP(layer int32) {
 				pkt.DependencyDescriptor,
 			)
 		}
+
+		// down tracks hold their own references while the packet is queued for sending
+		pb.Release()
 	}
 }
 
@@ -698,6 +926,20 @@ func (w *WebRTCReceiver) closeTracks() {
 	}
 }
 
+// MemoryUsage is the size of the packet history of all layers, in bytes
+func (w *WebRTCReceiver) MemoryUsage() int {
+	w.bufferMu.RLock()
+	defer w.bufferMu.RUnlock()
+
+	usage := 0
+	for _, buff := range w.buffers {
+		if buff != nil {
+			usage += buff.MemoryUsage()
+		}
+	}
+	return usage
+}
+
 func (w *WebRTCReceiver) DebugInfo() map[string]interface{} {
 	info := map[string]interface{}{
 		"SVC":       w.isSVC,
@@ -709,10 +951,11 @@ func (w *WebRTCReceiver) DebugInfo() map[string]interface{} {
 	for layer, ut := range w.upTracks {
 		if ut != nil {
 			upTrackInfo = append(upTrackInfo, map[string]interface{}{
-				"Layer": layer,
-				"SSRC":  ut.SSRC(),
-				"Msid":  ut.Msid(),
-				"RID":   ut.RID(),
+				"Layer":   layer,
+				"Quality": w.layerQuality(int32(layer)),
+				"SSRC":    ut.SSRC(),
+				"Msid":    ut.Msid(),
+				"RID":     ut.RID(),
 			})
 		}
 	}
@@ -722,6 +965,66 @@ func (w *WebRTCReceiver) DebugInfo() map[string]interface{} {
 	return info
 }
 
+// RTPStatsDebugInfo returns the receive side RTP stats of each layer, keyed by SSRC
+func (w *WebRTCReceiver) RTPStatsDebugInfo() map[string]interface{} {
+	w.bufferMu.RLock()
+	defer w.bufferMu.RUnlock()
+
+	info := make(map[string]interface{}, len(w.buffers))
+	for layer, buff := range w.buffers {
+		if buff == nil {
+			continue
+		}
+
+		stats := buff.GetRTPStatsDebugInfo()
+		if stats == nil {
+			continue
+		}
+		stats["Layer"] = layer
+		stats["Quality"] = w.layerQuality(int32(layer))
+		stats["RID"] = w.rid(layer)
+		info[strconv.FormatUint(uint64(w.SSRC(layer)), 10)] = stats
+	}
+	return info
+}
+
+// LayerMappings returns the SSRC and rid of each layer being received
+func (w *WebRTCReceiver) LayerMappings() []LayerMapping {
+	w.upTrackMu.RLock()
+	defer w.upTrackMu.RUnlock()
+
+	var mappings []LayerMapping
+	for layer, ut := range w.upTracks {
+		if ut == nil {
+			continue
+		}
+		mappings = append(mappings, LayerMapping{
+			Layer:   int32(layer),
+			Quality: w.layerQuality(int32(layer)),
+			SSRC:    uint32(ut.SSRC()),
+			RID:     ut.RID(),
+		})
+	}
+	return mappings
+}
+
+func (w *WebRTCReceiver) rid(layer int) string {
+	w.upTrackMu.RLock()
+	defer w.upTrackMu.RUnlock()
+
+	if track := w.upTracks[layer]; track != nil {
+		return track.RID()
+	}
+	return ""
+}
+
+func (w *WebRTCReceiver) layerQuality(layer int32) string {
+	if w.Kind() != webrtc.RTPCodecTypeVideo || w.isSVC {
+		return ""
+	}
+	return buffer.SpatialLayerToVideoQuality(layer, w.trackInfo).String()
+}
+
 func (w *WebRTCReceiver) GetPrimaryReceiverForRed() TrackReceiver {
 	if !w.isRED || w.closed.Load() {
 		return w
@@ -71,6 +71,7 @@ func (r *RedPrimaryReceiver) ForwardRTP(pkt *buffer.ExtPacket, spatialLayer int3
 
 	for _, sendPkt := range pkts {
 		pPkt := *pkt
+		// payloads of the extracted packets are in the RED payload, so they share its packet buffer
 		pPkt.Packet = sendPkt
 
 		// not modify the ExtPacket.RawPacket here for performance since it is not used by the DownTrack,
@@ -44,7 +44,8 @@ type RedReceiver struct {
 	logger            logger.Logger
 	closed            atomic.Bool
 	pktBuff           [maxRedCount]*rtp.Packet
-	redPayloadBuf     [mtuSize]byte
+	// packet buffers holding the payloads in pktBuff
+	pktBuffers [maxRedCount]*buffer.PacketBuffer
 }
 
 func NewRedReceiver(receiver TrackReceiver, dsp DownTrackSpreaderParams) *RedReceiver {
@@ -60,16 +61,22 @@ func (r *RedReceiver) ForwardRTP(pkt *buffer.ExtPacket, spatialLayer int32) {
 	if r.downTrackSpreader.DownTrackCount() == 0 {
 		return
 	}
-	redLen, err := r.encodeRedForPrimary(pkt.Packet, r.redPayloadBuf[:])
+	// encode into a packet buffer shared by all down tracks, they hold it until the packet is sent
+	pb := buffer.GetPacketBuffer(mtuSize)
+	defer pb.Release()
+
+	redLen, err := r.encodeRedForPrimary(pkt.Packet, pkt.Buffer, pb.Bytes())
 	if err != nil {
 		r.logger.Errorw("red encoding failed", err)
 		return
 	}
+	pb.Truncate(redLen)
 
 	pPkt := *pkt
 	redRtpPacket := *pkt.Packet
-	redRtpPacket.Payload = r.redPayloadBuf[:redLen]
+	redRtpPacket.Payload = pb.Bytes()
 	pPkt.Packet = &redRtpPacket
+	pPkt.Buffer = pb
 
 	// not modify the ExtPacket.RawPacket here for performance since it is not used by the DownTrack,
 	// otherwise it should be set to the correct value (marshal the primary rtp packet)
@@ -117,7 +124,7 @@ func (r *RedReceiver) ReadRTP(buf []byte, layer uint8, sn uint16) (int, error) {
 	return 0, bucket.ErrPacketMismatch
 }
 
-func (r *RedReceiver) encodeRedForPrimary(pkt *rtp.Packet, redPayload []byte) (int, error) {
+func (r *RedReceiver) encodeRedForPrimary(pkt *rtp.Packet, pb *buffer.PacketBuffer, redPayload []byte) (int, error) {
 	redLength := len(r.pktBuff)
 	redPkts := make([]*rtp.Packet, 0, redLength+1)
 	lastNilPkt := -1
@@ -141,14 +148,19 @@ func (r *RedReceiver) encodeRedForPrimary(pkt *rtp.Packet, redPayload []byte) (i
 	// insert primary packet in history buffer
 	// NOTE: packet is copied from retransmission buffer and used in forwarding path. So, not making another
 	// copy here and just maintaining pointer to the packet as the forwarding path should not alter the packet.
+	// The packet buffer holding its payload is retained while it is in history.
 	for i := redLength - 1; i >= 0; i-- {
 		if r.pktBuff[i] == nil || // history is empty
 			pkt.SequenceNumber-r.pktBuff[i].SequenceNumber < (1<<15) { // received packet has more recent sequence number
 			// age out older ones
+			r.pktBuffers[0].Release()
 			for j := 0; j < i; j++ {
 				r.pktBuff[j] = r.pktBuff[j+1]
+				r.pktBuffers[j] = r.pktBuffers[j+1]
 			}
 			r.pktBuff[i] = pkt
+			pb.Retain()
+			r.pktBuffers[i] = pb
 			break
 		}
 	}
@@ -33,6 +33,8 @@ type dummyDowntrack struct {
 }
 
 func (dt *dummyDowntrack) WriteRTP(p *buffer.ExtPacket, _ int32) error {
+	// packets are kept, hold their buffers like a down track queueing them would
+	p.Buffer.Retain()
 	dt.lastReceivedPkt = p.Packet
 	dt.receivedPkts = append(dt.receivedPkts, p.Packet)
 	return nil
@@ -63,6 +65,30 @@ func TestRedReceiver(t *testing.T) {
 		}
 	})
 
+	t.Run("history holds packet buffers", func(t *testing.T) {
+		w := &WebRTCReceiver{kind: webrtc.RTPCodecTypeAudio}
+		red := w.GetRedReceiver().(*RedReceiver)
+		require.NoError(t, red.AddDownTrack(dt))
+
+		header := rtp.Header{SequenceNumber: 100, Timestamp: 1000, PayloadType: 111}
+		var pbs []*buffer.PacketBuffer
+		for _, pkt := range generatePkts(header, maxRedCount+2, tsStep) {
+			pb := buffer.GetPacketBuffer(len(pkt.Payload))
+			copy(pb.Bytes(), pkt.Payload)
+			pkt.Payload = pb.Bytes()
+			pbs = append(pbs, pb)
+
+			red.ForwardRTP(&buffer.ExtPacket{
+				Packet: pkt,
+				Buffer: pb,
+			}, 0)
+			// released by the receive path after forwarding
+			pb.Release()
+		}
+
+		require.Equal(t, pbs[len(pbs)-maxRedCount:], red.pktBuffers[:])
+	})
+
 	t.Run("packet lost and jump", func(t *testing.T) {
 		w := &WebRTCReceiver{kind: webrtc.RTPCodecTypeAudio}
 		red := w.GetRedReceiver().(*RedReceiver)
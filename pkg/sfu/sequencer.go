@@ -18,6 +18,7 @@ import (
 	"math"
 	"sync"
 	"time"
+	"unsafe"
 
 	"github.com/livekit/livekit-server/pkg/sfu/utils"
 	"github.com/livekit/protocol/logger"
@@ -62,6 +63,8 @@ type packetMeta struct {
 	// the same packet.
 	// The resolution is 1 ms counting after the sequencer start time.
 	lastNack uint32
+	// The time this packet was sequenced, in the same resolution as lastNack.
+	sentAt uint32
 	// number of NACKs this packet has received
 	nacked uint8
 	// Spatial layer of packet
@@ -70,12 +73,19 @@ type packetMeta struct {
 	codecBytes []byte
 	// Dependency Descriptor of packet
 	ddBytes []byte
+	// codecBytes and ddBytes storage is reused by the next packet in this slot,
+	// unless a retransmission still borrows it
+	borrows int
+	// incremented each time the slot is overwritten, so that stale borrows are not returned to a newer packet
+	generation uint32
 }
 
+// extPacketMeta is borrowed from the sequencer, its codecBytes and ddBytes stay valid until it is released
 type extPacketMeta struct {
 	packetMeta
 	extSequenceNumber uint64
 	extTimestamp      uint64
+	slot              uint64
 }
 
 // Sequencer stores the packet sequence received by the down track
@@ -84,20 +94,22 @@ type sequencer struct {
 	size         int
 	startTime    int64
 	initialized  bool
+	extStartSN   uint64
 	extHighestSN uint64
 	snOffset     uint64
 	extHighestTS uint64
 	meta         []packetMeta
 	snRangeMap   *utils.RangeMap[uint64, uint64]
 	rtt          uint32
+	clock        utils.Clock
 	logger       logger.Logger
 }
 
-func newSequencer(size int, maybeSparse bool, logger logger.Logger) *sequencer {
+func newSequencer(size int, maybeSparse bool, clock utils.Clock, logger logger.Logger) *sequencer {
 	s := &sequencer{
 		size:      size,
-		startTime: time.Now().UnixMilli(),
-		meta:      make([]packetMeta, size),
+		clock:     clock,
+		startTime: clock.Now().UnixMilli(),
 		rtt:       defaultRtt,
 		logger:    logger,
 	}
@@ -108,6 +120,13 @@ func newSequencer(size int, maybeSparse bool, logger logger.Logger) *sequencer {
 	return s
 }
 
+func (s *sequencer) memoryUsage() int {
+	s.Lock()
+	defer s.Unlock()
+
+	return len(s.meta) * int(unsafe.Sizeof(packetMeta{}))
+}
+
 func (s *sequencer) setRTT(rtt uint32) {
 	s.Lock()
 	defer s.Unlock()
@@ -133,6 +152,9 @@ func (s *sequencer) push(
 
 	if !s.initialized {
 		s.initialized = true
+		// allocated with the first packet, down tracks of tracks that stay muted never need it
+		s.meta = make([]packetMeta, s.size)
+		s.extStartSN = extModifiedSN
 		s.extHighestSN = extModifiedSN - 1
 		s.extHighestTS = extModifiedTS
 		s.updateSNOffset()
@@ -173,15 +195,24 @@ func (s *sequencer) push(
 	}
 
 	slot := (extModifiedSN - snOffset) % uint64(s.size)
-	s.meta[slot] = packetMeta{
+	refTime := s.getRefTime(packetTime)
+	meta := &s.meta[slot]
+	codecStorage, ddStorage := meta.codecBytes[:0], meta.ddBytes[:0]
+	if meta.borrows != 0 {
+		// a retransmission still references the previous bytes, leave them to it
+		codecStorage, ddStorage = nil, nil
+	}
+	*meta = packetMeta{
 		sourceSeqNo: uint16(extIncomingSN),
 		targetSeqNo: uint16(extModifiedSN),
 		timestamp:   uint32(extModifiedTS),
 		marker:      marker,
 		layer:       layer,
-		codecBytes:  append([]byte{}, codecBytes...),
-		ddBytes:     append([]byte{}, ddBytes...),
-		lastNack:    s.getRefTime(packetTime), // delay retransmissions after the original transmission
+		codecBytes:  append(codecStorage, codecBytes...),
+		ddBytes:     append(ddStorage, ddBytes...),
+		sentAt:      refTime,
+		lastNack:    refTime, // delay retransmissions after the original transmission
+		generation:  meta.generation + 1,
 	}
 }
 
@@ -204,6 +235,10 @@ func (s *sequencer) pushPadding(extStartSNInclusive uint64, extEndSNInclusive ui
 		// a few packets at a time.
 		s.logger.Warnw("cannot exclude old range", nil, "extHighestSN", s.extHighestSN, "startSN", extStartSNInclusive, "endSN", extEndSNInclusive)
 
+		if !s.initialized {
+			return
+		}
+
 		// if exclusion range is before what has already been sequenced, invalidate exclusion range slots
 		for sn := extStartSNInclusive; sn != extEndSNInclusive+1; sn++ {
 			diff := int64(sn - s.extHighestSN)
@@ -222,6 +257,7 @@ func (s *sequencer) pushPadding(extStartSNInclusive uint64, extEndSNInclusive ui
 			s.meta[slot] = packetMeta{
 				sourceSeqNo: 0,
 				targetSeqNo: 0,
+				generation:  s.meta[slot].generation + 1,
 			}
 		}
 		return
@@ -236,14 +272,21 @@ func (s *sequencer) pushPadding(extStartSNInclusive uint64, extEndSNInclusive ui
 	s.updateSNOffset()
 }
 
-func (s *sequencer) getExtPacketMetas(seqNo []uint16) []extPacketMeta {
+// borrowExtPacketMetas returns the metadata of packets to retransmit without copying their codec and
+// dependency descriptor bytes. Those bytes are only valid until the metas are given back with
+// releaseExtPacketMetas, anything outliving that has to make its own copy.
+func (s *sequencer) borrowExtPacketMetas(seqNo []uint16) []extPacketMeta {
 	s.Lock()
 	defer s.Unlock()
 
+	if !s.initialized {
+		return nil
+	}
+
 	snOffset := uint64(0)
 	var err error
 	extPacketMetas := make([]extPacketMeta, 0, len(seqNo))
-	refTime := s.getRefTime(time.Now())
+	refTime := s.getRefTime(s.clock.Now())
 	highestSN := uint16(s.extHighestSN)
 	highestTS := uint32(s.extHighestTS)
 	for _, sn := range seqNo {
@@ -286,20 +329,84 @@ func (s *sequencer) getExtPacketMetas(seqNo []uint16) []extPacketMeta {
 			if meta.timestamp > highestTS {
 				extTS -= (1 << 32)
 			}
-			epm := extPacketMeta{
+			meta.borrows++
+			extPacketMetas = append(extPacketMetas, extPacketMeta{
 				packetMeta:        *meta,
 				extSequenceNumber: extSN,
 				extTimestamp:      extTS,
-			}
-			epm.codecBytes = append([]byte{}, meta.codecBytes...)
-			epm.ddBytes = append([]byte{}, meta.ddBytes...)
-			extPacketMetas = append(extPacketMetas, epm)
+				slot:              slot,
+			})
 		}
 	}
 
 	return extPacketMetas
 }
 
+func (s *sequencer) releaseExtPacketMetas(epms []extPacketMeta) {
+	s.Lock()
+	defer s.Unlock()
+
+	for i := range epms {
+		meta := &s.meta[epms[i].slot]
+		if meta.generation == epms[i].generation && meta.borrows > 0 {
+			meta.borrows--
+		}
+	}
+}
+
+// getSeqNosSince returns sequence numbers (in increasing order) of packets sequenced at or after `since`.
+// The returned bool indicates if the sequencer still holds every packet sent since then,
+// i. e. if the sequence numbers returned are sufficient to fill a gap starting at `since`.
+func (s *sequencer) getSeqNosSince(since time.Time) ([]uint16, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	if !s.initialized {
+		return nil, true
+	}
+
+	sinceRef := int64(0)
+	if since.UnixMilli() > s.startTime {
+		sinceRef = int64(s.getRefTime(since))
+	}
+
+	var seqNos []uint16
+	covered := false
+	for i := uint64(0); i < uint64(s.size); i++ {
+		if i > s.extHighestSN-s.extStartSN {
+			// reached the first packet sequenced
+			covered = true
+			break
+		}
+
+		extSN := s.extHighestSN - i
+		snOffset := uint64(0)
+		if s.snRangeMap != nil {
+			var err error
+			snOffset, err = s.snRangeMap.GetValue(extSN)
+			if err != nil {
+				// padding only packets are not sequenced
+				continue
+			}
+		}
+
+		meta := &s.meta[(extSN-snOffset)%uint64(s.size)]
+		if meta.targetSeqNo != uint16(extSN) {
+			continue
+		}
+		if int64(meta.sentAt) < sinceRef {
+			covered = true
+			break
+		}
+		seqNos = append(seqNos, meta.targetSeqNo)
+	}
+
+	for i, j := 0, len(seqNos)-1; i < j; i, j = i+1, j-1 {
+		seqNos[i], seqNos[j] = seqNos[j], seqNos[i]
+	}
+	return seqNos, covered
+}
+
 func (s *sequencer) getRefTime(at time.Time) uint32 {
 	return uint32(at.UnixMilli() - s.startTime)
 }
@@ -22,26 +22,29 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/utils"
 )
 
 func Test_sequencer(t *testing.T) {
-	seq := newSequencer(500, false, logger.GetLogger())
+	clock := utils.NewManualClock(time.Now())
+	seq := newSequencer(500, false, clock, logger.GetLogger())
 	off := uint16(15)
 
 	for i := uint64(1); i < 518; i++ {
-		seq.push(time.Now(), i, i+uint64(off), 123, true, 2, nil, nil)
+		seq.push(clock.Now(), i, i+uint64(off), 123, true, 2, nil, nil)
 	}
 	// send the last two out-of-order
-	seq.push(time.Now(), 519, 519+uint64(off), 123, false, 2, nil, nil)
-	seq.push(time.Now(), 518, 518+uint64(off), 123, true, 2, nil, nil)
+	seq.push(clock.Now(), 519, 519+uint64(off), 123, false, 2, nil, nil)
+	seq.push(clock.Now(), 518, 518+uint64(off), 123, true, 2, nil, nil)
 
 	req := []uint16{57, 58, 62, 63, 513, 514, 515, 516, 517}
-	res := seq.getExtPacketMetas(req)
+	res := seq.borrowExtPacketMetas(req)
 	// nothing should be returned as not enough time has elapsed since sending packet
 	require.Equal(t, 0, len(res))
 
-	time.Sleep((ignoreRetransmission + 10) * time.Millisecond)
-	res = seq.getExtPacketMetas(req)
+	clock.Advance((ignoreRetransmission + 10) * time.Millisecond)
+	res = seq.borrowExtPacketMetas(req)
 	require.Equal(t, len(req), len(res))
 	for i, val := range res {
 		require.Equal(t, val.targetSeqNo, req[i])
@@ -50,10 +53,10 @@ func Test_sequencer(t *testing.T) {
 		require.Equal(t, val.extSequenceNumber, uint64(req[i]))
 		require.Equal(t, val.extTimestamp, uint64(123))
 	}
-	res = seq.getExtPacketMetas(req)
+	res = seq.borrowExtPacketMetas(req)
 	require.Equal(t, 0, len(res))
-	time.Sleep((ignoreRetransmission + 10) * time.Millisecond)
-	res = seq.getExtPacketMetas(req)
+	clock.Advance((ignoreRetransmission + 10) * time.Millisecond)
+	res = seq.borrowExtPacketMetas(req)
 	require.Equal(t, len(req), len(res))
 	for i, val := range res {
 		require.Equal(t, val.targetSeqNo, req[i])
@@ -63,18 +66,18 @@ func Test_sequencer(t *testing.T) {
 		require.Equal(t, val.extTimestamp, uint64(123))
 	}
 
-	seq.push(time.Now(), 521, 521+uint64(off), 123, true, 1, nil, nil)
-	m := seq.getExtPacketMetas([]uint16{521 + off})
+	seq.push(clock.Now(), 521, 521+uint64(off), 123, true, 1, nil, nil)
+	m := seq.borrowExtPacketMetas([]uint16{521 + off})
 	require.Equal(t, 0, len(m))
-	time.Sleep((ignoreRetransmission + 10) * time.Millisecond)
-	m = seq.getExtPacketMetas([]uint16{521 + off})
+	clock.Advance((ignoreRetransmission + 10) * time.Millisecond)
+	m = seq.borrowExtPacketMetas([]uint16{521 + off})
 	require.Equal(t, 1, len(m))
 
-	seq.push(time.Now(), 505, 505+uint64(off), 123, false, 1, nil, nil)
-	m = seq.getExtPacketMetas([]uint16{505 + off})
+	seq.push(clock.Now(), 505, 505+uint64(off), 123, false, 1, nil, nil)
+	m = seq.borrowExtPacketMetas([]uint16{505 + off})
 	require.Equal(t, 0, len(m))
-	time.Sleep((ignoreRetransmission + 10) * time.Millisecond)
-	m = seq.getExtPacketMetas([]uint16{505 + off})
+	clock.Advance((ignoreRetransmission + 10) * time.Millisecond)
+	m = seq.borrowExtPacketMetas([]uint16{505 + off})
 	require.Equal(t, 1, len(m))
 }
 
@@ -137,22 +140,23 @@ func Test_sequencer_getNACKSeqNo_exclusion(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			n := newSequencer(5, true, logger.GetLogger())
+			clock := utils.NewManualClock(time.Now())
+			n := newSequencer(5, true, clock, logger.GetLogger())
 
 			for _, i := range tt.fields.inputs {
 				if i.isPadding {
 					n.pushPadding(i.seqNo+tt.fields.offset, i.seqNo+tt.fields.offset)
 				} else {
 					if i.seqNo%2 == 0 {
-						n.push(time.Now(), i.seqNo, i.seqNo+tt.fields.offset, 123, tt.fields.markerEven, 3, tt.fields.codecBytesEven, tt.fields.ddBytesEven)
+						n.push(clock.Now(), i.seqNo, i.seqNo+tt.fields.offset, 123, tt.fields.markerEven, 3, tt.fields.codecBytesEven, tt.fields.ddBytesEven)
 					} else {
-						n.push(time.Now(), i.seqNo, i.seqNo+tt.fields.offset, 123, tt.fields.markerOdd, 3, tt.fields.codecBytesOdd, tt.fields.ddBytesOdd)
+						n.push(clock.Now(), i.seqNo, i.seqNo+tt.fields.offset, 123, tt.fields.markerOdd, 3, tt.fields.codecBytesOdd, tt.fields.ddBytesOdd)
 					}
 				}
 			}
 
-			time.Sleep((ignoreRetransmission + 10) * time.Millisecond)
-			g := n.getExtPacketMetas(tt.args.seqNo)
+			clock.Advance((ignoreRetransmission + 10) * time.Millisecond)
+			g := n.borrowExtPacketMetas(tt.args.seqNo)
 			var got []uint16
 			for _, sn := range g {
 				got = append(got, sn.sourceSeqNo)
@@ -167,7 +171,7 @@ func Test_sequencer_getNACKSeqNo_exclusion(t *testing.T) {
 				}
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("getExtPacketMetas() = %v, want %v", got, tt.want)
+				t.Errorf("borrowExtPacketMetas() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -231,22 +235,23 @@ func Test_sequencer_getNACKSeqNo_no_exclusion(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			n := newSequencer(5, false, logger.GetLogger())
+			clock := utils.NewManualClock(time.Now())
+			n := newSequencer(5, false, clock, logger.GetLogger())
 
 			for _, i := range tt.fields.inputs {
 				if i.isPadding {
 					n.pushPadding(i.seqNo+tt.fields.offset, i.seqNo+tt.fields.offset)
 				} else {
 					if i.seqNo%2 == 0 {
-						n.push(time.Now(), i.seqNo, i.seqNo+tt.fields.offset, 123, tt.fields.markerEven, 3, tt.fields.codecBytesEven, tt.fields.ddBytesEven)
+						n.push(clock.Now(), i.seqNo, i.seqNo+tt.fields.offset, 123, tt.fields.markerEven, 3, tt.fields.codecBytesEven, tt.fields.ddBytesEven)
 					} else {
-						n.push(time.Now(), i.seqNo, i.seqNo+tt.fields.offset, 123, tt.fields.markerOdd, 3, tt.fields.codecBytesOdd, tt.fields.ddBytesOdd)
+						n.push(clock.Now(), i.seqNo, i.seqNo+tt.fields.offset, 123, tt.fields.markerOdd, 3, tt.fields.codecBytesOdd, tt.fields.ddBytesOdd)
 					}
 				}
 			}
 
-			time.Sleep((ignoreRetransmission + 10) * time.Millisecond)
-			g := n.getExtPacketMetas(tt.args.seqNo)
+			clock.Advance((ignoreRetransmission + 10) * time.Millisecond)
+			g := n.borrowExtPacketMetas(tt.args.seqNo)
 			var got []uint16
 			for _, sn := range g {
 				got = append(got, sn.sourceSeqNo)
@@ -261,8 +266,83 @@ func Test_sequencer_getNACKSeqNo_no_exclusion(t *testing.T) {
 				}
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("getExtPacketMetas() = %v, want %v", got, tt.want)
+				t.Errorf("borrowExtPacketMetas() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_sequencer_getSeqNosSince(t *testing.T) {
+	clock := utils.NewManualClock(time.Now())
+	seq := newSequencer(10, false, clock, logger.GetLogger())
+
+	seqNos, covered := seq.getSeqNosSince(clock.Now())
+	require.Empty(t, seqNos)
+	require.True(t, covered)
+
+	start := clock.Now()
+	for i := uint64(1); i <= 5; i++ {
+		seq.push(start.Add(time.Duration(i)*time.Millisecond), i, i+100, 123, true, 0, nil, nil)
+	}
+
+	// everything since the first packet is available
+	seqNos, covered = seq.getSeqNosSince(start)
+	require.Equal(t, []uint16{101, 102, 103, 104, 105}, seqNos)
+	require.True(t, covered)
+
+	// gap starts after the third packet
+	seqNos, covered = seq.getSeqNosSince(start.Add(3 * time.Millisecond))
+	require.Equal(t, []uint16{103, 104, 105}, seqNos)
+	require.True(t, covered)
+
+	// wrap the sequencer, packets from the start of the gap are no longer available
+	for i := uint64(6); i <= 20; i++ {
+		seq.push(start.Add(time.Duration(i)*time.Millisecond), i, i+100, 123, true, 0, nil, nil)
+	}
+	seqNos, covered = seq.getSeqNosSince(start.Add(3 * time.Millisecond))
+	require.Len(t, seqNos, 10)
+	require.Equal(t, uint16(120), seqNos[len(seqNos)-1])
+	require.False(t, covered)
+}
+
+func Test_sequencer_borrow(t *testing.T) {
+	clock := utils.NewManualClock(time.Now())
+	seq := newSequencer(10, false, clock, logger.GetLogger())
+
+	for i := uint64(1); i <= 10; i++ {
+		seq.push(clock.Now(), i, i, 123, true, 0, []byte{1, 2, byte(i)}, []byte{3, 4, byte(i)})
+	}
+	clock.Advance((ignoreRetransmission + 10) * time.Millisecond)
+
+	borrowed := seq.borrowExtPacketMetas([]uint16{5})
+	require.Equal(t, 1, len(borrowed))
+
+	// overwriting a borrowed slot must not touch the borrowed bytes
+	seq.push(clock.Now(), 15, 15, 123, true, 0, []byte{5, 6, 15}, []byte{7, 8, 15})
+	require.Equal(t, []byte{1, 2, 5}, borrowed[0].codecBytes)
+	require.Equal(t, []byte{3, 4, 5}, borrowed[0].ddBytes)
+
+	// stale release does not affect the packet now in the slot
+	seq.releaseExtPacketMetas(borrowed)
+	require.Equal(t, 0, seq.meta[5].borrows)
+
+	// released slots reuse their storage
+	borrowed = seq.borrowExtPacketMetas([]uint16{6})
+	require.Equal(t, 1, len(borrowed))
+	seq.releaseExtPacketMetas(borrowed)
+	require.Equal(t, 0, seq.meta[6].borrows)
+	allocs := testing.AllocsPerRun(10, func() {
+		seq.push(clock.Now(), 16, 16, 123, true, 0, []byte{5, 6, 16}, []byte{7, 8, 16})
+	})
+	require.Zero(t, allocs)
+}
+
+func Test_sequencer_lazy(t *testing.T) {
+	clock := utils.NewManualClock(time.Now())
+	seq := newSequencer(200, false, clock, logger.GetLogger())
+	require.Nil(t, seq.meta)
+	require.Empty(t, seq.borrowExtPacketMetas([]uint16{0, 1, 2}))
+
+	seq.push(clock.Now(), 1, 1, 123, true, 0, nil, nil)
+	require.Equal(t, 200, len(seq.meta))
+}
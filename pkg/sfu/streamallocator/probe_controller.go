@@ -210,7 +210,22 @@ func (p *ProbeController) InitProbe(probeGoalDeltaBps int64, expectedBandwidthUs
 	if desiredIncreaseBps < p.params.Config.MinBps {
 		desiredIncreaseBps = p.params.Config.MinBps
 	}
-	p.probeGoalBps = expectedBandwidthUsage + desiredIncreaseBps
+	probeClusterId := p.initProbeLocked(expectedBandwidthUsage+desiredIncreaseBps, expectedBandwidthUsage, p.probeDuration)
+	return probeClusterId, p.probeGoalBps
+}
+
+// InitJoinProbe starts a probe ramping up to goalBps irrespective of the current usage, to measure the channel
+// before tracks are allocated
+func (p *ProbeController) InitJoinProbe(goalBps int64, expectedBandwidthUsage int64, duration time.Duration) ProbeClusterId {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.lastProbeStartTime = time.Now()
+	return p.initProbeLocked(goalBps, expectedBandwidthUsage, duration)
+}
+
+func (p *ProbeController) initProbeLocked(goalBps int64, expectedBandwidthUsage int64, duration time.Duration) ProbeClusterId {
+	p.probeGoalBps = goalBps
 
 	p.doneProbeClusterInfo = ProbeClusterInfo{Id: ProbeClusterIdInvalid}
 	p.abortedProbeClusterId = ProbeClusterIdInvalid
@@ -224,11 +239,11 @@ func (p *ProbeController) InitProbe(probeGoalDeltaBps int64, expectedBandwidthUs
 		ProbeClusterModeUniform,
 		int(p.probeGoalBps),
 		int(expectedBandwidthUsage),
-		p.probeDuration,
-		time.Duration(float64(p.probeDuration.Milliseconds())*p.params.Config.DurationOverflowFactor)*time.Millisecond,
+		duration,
+		time.Duration(float64(duration.Milliseconds())*p.params.Config.DurationOverflowFactor)*time.Millisecond,
 	)
 
-	return p.probeClusterId, p.probeGoalBps
+	return p.probeClusterId
 }
 
 func (p *ProbeController) clearProbeLocked() {
@@ -86,6 +86,7 @@ const (
 	streamAllocatorSignalSetChannelCapacity
 	streamAllocatorSignalNACK
 	streamAllocatorSignalRTCPReceiverReport
+	streamAllocatorSignalJoinProbe
 )
 
 func (s streamAllocatorSignal) String() string {
@@ -114,6 +115,8 @@ func (s streamAllocatorSignal) String() string {
 		return "NACK"
 	case streamAllocatorSignalRTCPReceiverReport:
 		return "RTCP_RECEIVER_REPORT"
+	case streamAllocatorSignalJoinProbe:
+		return "JOIN_PROBE"
 	default:
 		return fmt.Sprintf("%d", int(s))
 	}
@@ -165,6 +168,11 @@ type StreamAllocator struct {
 
 	state streamAllocatorState
 
+	// set while the join probe waits for a track to carry it, allocation is held back until the probe is done
+	joinProbeDeadline time.Time
+	isJoinProbe       bool
+	joinProbed        bool
+
 	eventChMu sync.RWMutex
 	eventCh   chan Event
 
@@ -293,6 +301,14 @@ func (s *StreamAllocator) SetChannelCapacity(channelCapacity int64) {
 	})
 }
 
+// ProbeOnJoin measures the channel with a padding probe once the subscriber is connected,
+// so that tracks are first allocated with a realistic channel capacity
+func (s *StreamAllocator) ProbeOnJoin() {
+	s.postEvent(Event{
+		Signal: streamAllocatorSignalJoinProbe,
+	})
+}
+
 func (s *StreamAllocator) resetState() {
 	s.channelObserver = s.newChannelObserverNonProbe()
 	s.probeController.Reset()
@@ -614,6 +630,8 @@ func (s *StreamAllocator) handleEvent(event *Event) {
 		s.handleSignalNACK(event)
 	case streamAllocatorSignalRTCPReceiverReport:
 		s.handleSignalRTCPReceiverReport(event)
+	case streamAllocatorSignalJoinProbe:
+		s.handleSignalJoinProbe(event)
 	}
 }
 
@@ -625,6 +643,12 @@ func (s *StreamAllocator) handleSignalAllocateTrack(event *Event) {
 	}
 	s.videoTracksMu.Unlock()
 
+	if !s.joinProbeDeadline.IsZero() {
+		// allocated when the join probe is done
+		s.maybeJoinProbe()
+		return
+	}
+
 	if track != nil {
 		s.allocateTrack(track)
 	}
@@ -670,7 +694,9 @@ func (s *StreamAllocator) handleSignalPeriodicPing(event *Event) {
 	}
 
 	// probe if necessary and timing is right
-	if s.state == streamAllocatorStateDeficient {
+	if !s.joinProbeDeadline.IsZero() {
+		s.maybeJoinProbe()
+	} else if s.state == streamAllocatorStateDeficient {
 		s.maybeProbe()
 	}
 
@@ -685,7 +711,12 @@ func (s *StreamAllocator) handleSignalSendProbe(event *Event) {
 
 	bytesSent := 0
 	for _, track := range s.getTracks() {
-		sent := track.WritePaddingRTP(bytesToSend)
+		var sent int
+		if s.isJoinProbe {
+			sent = track.WriteJoinProbePaddingRTP(bytesToSend)
+		} else {
+			sent = track.WritePaddingRTP(bytesToSend)
+		}
 		bytesSent += sent
 		bytesToSend -= sent
 		if bytesToSend <= 0 {
@@ -717,6 +748,18 @@ func (s *StreamAllocator) handleSignalResume(event *Event) {
 	}
 }
 
+func (s *StreamAllocator) handleSignalJoinProbe(event *Event) {
+	if !s.params.Config.Enabled || !s.params.Config.JoinProbe.Enabled || s.overriddenChannelCapacity > 0 {
+		return
+	}
+	if s.joinProbed || !s.joinProbeDeadline.IsZero() {
+		return
+	}
+
+	s.joinProbeDeadline = time.Now().Add(s.params.Config.JoinProbe.Timeout)
+	s.maybeJoinProbe()
+}
+
 func (s *StreamAllocator) handleSignalSetAllowPause(event *Event) {
 	s.allowPause = event.Data.(bool)
 }
@@ -725,6 +768,14 @@ func (s *StreamAllocator) handleSignalSetChannelCapacity(event *Event) {
 	s.overriddenChannelCapacity = event.Data.(int64)
 	if s.overriddenChannelCapacity > 0 {
 		s.params.Logger.Infow("allocating on override channel capacity", "override", s.overriddenChannelCapacity)
+		if !s.joinProbeDeadline.IsZero() {
+			if s.isJoinProbe {
+				s.probeController.AbortProbe()
+			}
+			s.joinProbeDeadline = time.Time{}
+			s.isJoinProbe = false
+			s.joinProbed = true
+		}
 		s.allocateAllTracks()
 	} else {
 		s.params.Logger.Infow("clearing override channel capacity")
@@ -1037,6 +1088,11 @@ func (s *StreamAllocator) onProbeDone(isNotFailing bool, isGoalReached bool) {
 		"highestEstimate", highestEstimateInProbe,
 		"channel", channelObserverString,
 	)
+	if s.isJoinProbe {
+		s.onJoinProbeDone(isNotFailing, highestEstimateInProbe)
+		return
+	}
+
 	if !isNotFailing {
 		return
 	}
@@ -1309,6 +1365,82 @@ func (s *StreamAllocator) initProbe(probeGoalDeltaBps int64) {
 	)
 }
 
+// maybeJoinProbe starts the join probe once a track can carry the padding
+func (s *StreamAllocator) maybeJoinProbe() {
+	if s.isJoinProbe {
+		return
+	}
+	if time.Now().After(s.joinProbeDeadline) {
+		// no track to probe with, allocate as usual
+		s.params.Logger.Debugw("stream allocator: join probe timed out")
+		s.endJoinProbe(0)
+		return
+	}
+
+	tracks := s.getTracks()
+	writable := false
+	for _, track := range tracks {
+		if track.IsWritable() {
+			writable = true
+			break
+		}
+	}
+	if !writable || s.probeController.IsInProbe() {
+		return
+	}
+
+	expectedBandwidthUsage := s.getExpectedBandwidthUsage()
+	goalBps := s.params.Config.JoinProbe.GoalBps
+	if goalBps < expectedBandwidthUsage {
+		goalBps = expectedBandwidthUsage
+	}
+	probeClusterId := s.probeController.InitJoinProbe(goalBps, expectedBandwidthUsage, s.params.Config.JoinProbe.Duration)
+	s.isJoinProbe = true
+
+	s.channelObserver = s.newChannelObserverProbe()
+	s.channelObserver.SeedEstimate(s.lastReceivedEstimate)
+
+	s.params.Logger.Infow(
+		"stream allocator: starting join probe",
+		"probeClusterId", probeClusterId,
+		"tracks", len(tracks),
+		"lastReceived", s.lastReceivedEstimate,
+		"goalBps", goalBps,
+	)
+}
+
+func (s *StreamAllocator) onJoinProbeDone(isNotFailing bool, highestEstimate int64) {
+	// a failing probe congested the channel, the latest estimate is closer to its capacity than the highest
+	estimate := highestEstimate
+	if !isNotFailing {
+		estimate = s.lastReceivedEstimate
+	}
+	s.params.Logger.Infow(
+		"stream allocator: join probe done",
+		"isNotFailing", isNotFailing,
+		"highestEstimate", highestEstimate,
+		"lastReceived", s.lastReceivedEstimate,
+	)
+	s.endJoinProbe(estimate)
+}
+
+// endJoinProbe allocates the tracks held back by the join probe, with the channel capacity it measured if any
+func (s *StreamAllocator) endJoinProbe(estimate int64) {
+	s.joinProbeDeadline = time.Time{}
+	s.isJoinProbe = false
+	s.joinProbed = true
+
+	if estimate <= 0 {
+		for _, track := range s.getTracks() {
+			s.allocateTrack(track)
+		}
+		return
+	}
+
+	s.committedChannelCapacity = estimate
+	s.allocateAllTracks()
+}
+
 func (s *StreamAllocator) maybeProbe() {
 	if s.overriddenChannelCapacity > 0 {
 		// do not probe if channel capacity is overridden
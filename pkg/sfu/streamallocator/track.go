@@ -156,6 +156,16 @@ func (t *Track) WritePaddingRTP(bytesToSend int) int {
 	return t.downTrack.WritePaddingRTP(bytesToSend, false, false)
 }
 
+// WriteJoinProbePaddingRTP writes padding on a track that is not streaming yet,
+// the subscriber has negotiated the stream but may not have reported on it
+func (t *Track) WriteJoinProbePaddingRTP(bytesToSend int) int {
+	return t.downTrack.WritePaddingRTP(bytesToSend, true, false)
+}
+
+func (t *Track) IsWritable() bool {
+	return t.downTrack.IsWritable()
+}
+
 func (t *Track) AllocateOptimal(allowOvershoot bool) sfu.VideoAllocation {
 	return t.downTrack.AllocateOptimal(allowOvershoot)
 }
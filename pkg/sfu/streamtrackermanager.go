@@ -612,6 +612,23 @@ func (s *StreamTrackerManager) SetRTCPSenderReportData(layer int32, srFirst *buf
 	}
 }
 
+// ResetSenderReportData forgets the sender reports of a layer and the offsets to other layers derived from them,
+// for a layer continuing on a feed with unrelated timestamps
+func (s *StreamTrackerManager) ResetSenderReportData(layer int32) {
+	s.senderReportMu.Lock()
+	defer s.senderReportMu.Unlock()
+
+	if layer < 0 || int(layer) >= len(s.senderReports) {
+		return
+	}
+
+	s.senderReports[layer] = endsSenderReport{}
+	for i := range s.layerOffsets {
+		s.layerOffsets[layer][i] = 0
+		s.layerOffsets[i][layer] = 0
+	}
+}
+
 func (s *StreamTrackerManager) GetCalculatedClockRate(layer int32) uint32 {
 	s.senderReportMu.RLock()
 	defer s.senderReportMu.RUnlock()
@@ -0,0 +1,95 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package twcc
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	piontwcc "github.com/pion/interceptor/pkg/twcc"
+	"github.com/pion/rtcp"
+)
+
+// ResponderParams sets the cadence of transport wide congestion control feedback
+type ResponderParams struct {
+	// feedback is sent once this long has passed since the previous one
+	Interval time.Duration
+	// feedback is sent on the last packet of a frame once this long has passed since the previous one, 0 disables it
+	IntervalAfterMarker time.Duration
+	// feedback is held back until more than this many packets were received
+	MinPackets int
+	// feedback is sent as soon as more than this many packets were received
+	MaxPackets int
+}
+
+var ResponderParamsDefault = ResponderParams{
+	Interval:            100 * time.Millisecond,
+	IntervalAfterMarker: 50 * time.Millisecond,
+	MinPackets:          20,
+	MaxPackets:          100,
+}
+
+// Responder records the arrival of packets on a transport and periodically builds transport wide congestion
+// control feedback of them, a wrapper around pion/interceptor's TWCC recorder
+type Responder struct {
+	sync.Mutex
+
+	params     ResponderParams
+	mSSRC      uint32
+	sSSRC      uint32
+	lastReport int64
+	recorder   *piontwcc.Recorder
+
+	onFeedback func(packet []rtcp.Packet)
+}
+
+func NewTransportWideCCResponder(mSSRC uint32, params ResponderParams) *Responder {
+	sSSRC := rand.Uint32()
+	return &Responder{
+		params:   params,
+		sSSRC:    sSSRC,
+		mSSRC:    mSSRC,
+		recorder: piontwcc.NewRecorder(sSSRC),
+	}
+}
+
+// Push a sequence number read from rtp packet ext packet
+func (t *Responder) Push(sn uint16, timeNS int64, marker bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.recorder.Record(t.mSSRC, sn, timeNS/1000)
+
+	held := t.recorder.PacketsHeld()
+	if held <= t.params.MinPackets || t.mSSRC == 0 {
+		return
+	}
+
+	delta := time.Duration(timeNS - t.lastReport)
+	if delta >= t.params.Interval ||
+		held > t.params.MaxPackets ||
+		(marker && t.params.IntervalAfterMarker > 0 && delta >= t.params.IntervalAfterMarker) {
+		if pkts := t.recorder.BuildFeedbackPacket(); pkts != nil && t.onFeedback != nil {
+			t.onFeedback(pkts)
+		}
+		t.lastReport = timeNS
+	}
+}
+
+// OnFeedback sets the callback for the formed twcc feedback rtcp packet
+func (t *Responder) OnFeedback(f func(pkts []rtcp.Packet)) {
+	t.onFeedback = f
+}
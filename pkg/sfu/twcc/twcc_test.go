@@ -0,0 +1,59 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package twcc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponder(t *testing.T) {
+	push := func(params ResponderParams, numPackets int, spacing time.Duration, marker bool) int {
+		r := NewTransportWideCCResponder(1234, params)
+		feedbacks := 0
+		r.OnFeedback(func(pkts []rtcp.Packet) {
+			feedbacks++
+		})
+		start := time.Now().UnixNano()
+		for i := 0; i < numPackets; i++ {
+			r.Push(uint16(i), start+int64(i)*int64(spacing), marker)
+		}
+		return feedbacks
+	}
+
+	// audio at 50 packets per second, feedback is held back until more than 20 packets were received
+	require.Equal(t, 3, push(ResponderParamsDefault, 75, 20*time.Millisecond, false))
+
+	// longer interval for audio only rooms
+	params := ResponderParamsDefault
+	params.Interval = time.Second
+	require.Equal(t, 2, push(params, 75, 20*time.Millisecond, false))
+
+	// a burst of packets is reported once more than max packets are held
+	params = ResponderParamsDefault
+	params.MaxPackets = 40
+	require.Equal(t, 2, push(params, 100, 0, false))
+
+	// feedback is sent sooner at the end of frames, unless disabled
+	params = ResponderParamsDefault
+	params.MinPackets = 5
+	require.Equal(t, 10, push(params, 100, 10*time.Millisecond, false))
+	require.Equal(t, 16, push(params, 100, 10*time.Millisecond, true))
+	params.IntervalAfterMarker = 0
+	require.Equal(t, 10, push(params, 100, 10*time.Millisecond, true))
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	// uploads of a participant's client kept for reading back, the oldest are dropped past either limit
+	clientDiagnosticsHistorySize  = 10
+	clientDiagnosticsHistoryBytes = 1024 * 1024
+
+	ClientTrackDirectionPublish   = "publish"
+	ClientTrackDirectionSubscribe = "subscribe"
+)
+
+// Analytics event types of the stats clients report for their tracks. The protocol has no field for the source of
+// stats, so they are kept apart from the server's TRACK_PUBLISH_STATS and TRACK_SUBSCRIBE_STATS by types it does not
+// define, offset from those
+const (
+	AnalyticsEventTypeClientPublishStats   = livekit.AnalyticsEventType_TRACK_PUBLISH_STATS + 1000
+	AnalyticsEventTypeClientSubscribeStats = livekit.AnalyticsEventType_TRACK_SUBSCRIBE_STATS + 1000
+)
+
+// ClientTrackDiagnostics pairs the stats a client reports for one of its RTP streams with the server's stats of
+// the other end of the stream
+type ClientTrackDiagnostics struct {
+	TrackID string `json:"track_id"`
+	// publish for streams the client sends, subscribe for streams it receives
+	Direction string `json:"direction"`
+	Mime      string `json:"mime,omitempty"`
+	SSRC      uint32 `json:"ssrc"`
+	// outbound-rtp or inbound-rtp stats object reported by the client
+	Client map[string]interface{} `json:"client"`
+	Server *livekit.RTPStats      `json:"server,omitempty"`
+}
+
+// ClientEvent is an entry of a client's event log
+type ClientEvent struct {
+	// unix milliseconds
+	Timestamp int64           `json:"timestamp"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// ClientDiagnostics is an upload of a client's WebRTC stats and event log
+type ClientDiagnostics struct {
+	Room          string                    `json:"room"`
+	Identity      string                    `json:"identity"`
+	ParticipantID string                    `json:"participant_id"`
+	ReceivedAt    time.Time                 `json:"received_at"`
+	Tracks        []*ClientTrackDiagnostics `json:"tracks"`
+	// stats objects not matched to a track, such as candidate pairs and transports
+	Stats  map[string]interface{} `json:"stats,omitempty"`
+	Events []ClientEvent          `json:"events,omitempty"`
+
+	// encoded size, counted against the retained bytes of the participant
+	size int
+}
+
+// ClientDiagnosticsReported keeps the diagnostics with the participant's session and sends the client's view of
+// its tracks to analytics, as AnalyticsEventTypeClientPublishStats and AnalyticsEventTypeClientSubscribeStats events
+func (t *telemetryService) ClientDiagnosticsReported(
+	ctx context.Context,
+	participantID livekit.ParticipantID,
+	clientInfo *livekit.ClientInfo,
+	diagnostics *ClientDiagnostics,
+) {
+	t.enqueue(func() {
+		worker, ok := t.getWorker(participantID)
+		if !ok {
+			return
+		}
+		if data, err := json.Marshal(diagnostics); err == nil {
+			diagnostics.size = len(data)
+		}
+		worker.AddClientDiagnostics(diagnostics)
+
+		room := t.getRoomDetails(participantID)
+		for _, track := range diagnostics.Tracks {
+			eventType := AnalyticsEventTypeClientSubscribeStats
+			if track.Direction == ClientTrackDirectionPublish {
+				eventType = AnalyticsEventTypeClientPublishStats
+			}
+			ev := newRoomEvent(eventType, room)
+			ev.ParticipantId = string(participantID)
+			ev.TrackId = track.TrackID
+			ev.Mime = track.Mime
+			ev.ClientInfo = clientInfo
+			ev.RtpStats = clientRTPStats(track.Client)
+			t.SendEvent(ctx, ev)
+		}
+	})
+}
+
+// ClientDiagnostics returns the diagnostics recently uploaded by the client of a participant in a room hosted on
+// this node, oldest first
+func (t *telemetryService) ClientDiagnostics(roomName livekit.RoomName, identity livekit.ParticipantIdentity) []*ClientDiagnostics {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	var diagnostics []*ClientDiagnostics
+	for _, worker := range t.workers {
+		if worker.RoomName() == roomName && worker.participantIdentity == identity {
+			diagnostics = append(diagnostics, worker.ClientDiagnostics()...)
+		}
+	}
+	return diagnostics
+}
+
+// clientRTPStats converts an outbound-rtp or inbound-rtp stats object of the W3C getStats() API
+func clientRTPStats(stats map[string]interface{}) *livekit.RTPStats {
+	number := func(keys ...string) float64 {
+		for _, key := range keys {
+			if v, ok := stats[key].(float64); ok {
+				return v
+			}
+		}
+		return 0
+	}
+
+	return &livekit.RTPStats{
+		Packets:       uint32(number("packetsSent", "packetsReceived")),
+		Bytes:         uint64(number("bytesSent", "bytesReceived")),
+		HeaderBytes:   uint64(number("headerBytesSent", "headerBytesReceived")),
+		PacketsLost:   uint32(number("packetsLost")),
+		JitterCurrent: number("jitter") * 1e6,
+		Nacks:         uint32(number("nackCount")),
+		Plis:          uint32(number("pliCount")),
+		Firs:          uint32(number("firCount")),
+		Frames:        uint32(number("framesSent", "framesReceived")),
+		FrameRate:     number("framesPerSecond"),
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsWorkerClientDiagnostics(t *testing.T) {
+	t.Run("keeps the newest uploads", func(t *testing.T) {
+		worker := newStatsWorker(context.Background(), nil, "RM_id", "room", "PA_id", "identity")
+		for i := 0; i < clientDiagnosticsHistorySize+2; i++ {
+			worker.AddClientDiagnostics(&ClientDiagnostics{ParticipantID: string(rune('a' + i)), size: 1})
+		}
+
+		diagnostics := worker.ClientDiagnostics()
+		require.Len(t, diagnostics, clientDiagnosticsHistorySize)
+		require.Equal(t, "c", diagnostics[0].ParticipantID)
+	})
+
+	t.Run("limits retained bytes", func(t *testing.T) {
+		worker := newStatsWorker(context.Background(), nil, "RM_id", "room", "PA_id", "identity")
+		worker.AddClientDiagnostics(&ClientDiagnostics{ParticipantID: "a", size: clientDiagnosticsHistoryBytes / 2})
+		worker.AddClientDiagnostics(&ClientDiagnostics{ParticipantID: "b", size: clientDiagnosticsHistoryBytes / 2})
+		require.Len(t, worker.ClientDiagnostics(), 2)
+
+		worker.AddClientDiagnostics(&ClientDiagnostics{ParticipantID: "c", size: 1})
+		diagnostics := worker.ClientDiagnostics()
+		require.Len(t, diagnostics, 2)
+		require.Equal(t, "b", diagnostics[0].ParticipantID)
+
+		// an upload over the limit on its own is still kept
+		worker.AddClientDiagnostics(&ClientDiagnostics{ParticipantID: "d", size: clientDiagnosticsHistoryBytes + 1})
+		diagnostics = worker.ClientDiagnostics()
+		require.Len(t, diagnostics, 1)
+		require.Equal(t, "d", diagnostics[0].ParticipantID)
+	})
+}
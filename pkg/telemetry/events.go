@@ -27,6 +27,21 @@ import (
 	"github.com/livekit/protocol/webhook"
 )
 
+// webhook events that are not defined by the protocol
+const (
+	EventParticipantMetadataUpdated = "participant_metadata_updated"
+	EventParticipantQualityChanged  = "participant_quality_changed"
+	EventParticipantTransferred     = "participant_transferred"
+	EventParticipantHeld            = "participant_held"
+	EventParticipantUnheld          = "participant_unheld"
+	EventPublisherIPRejected        = "publisher_ip_rejected"
+	EventRoomQualityDegraded        = "room_quality_degraded"
+	EventRoomStartingSoon           = "room_starting_soon"
+	EventTrackSilenceDetected       = "track_silence_detected"
+	EventTrackSilenceEnded          = "track_silence_ended"
+	EventTrackSourceChanged         = "track_source_changed"
+)
+
 func (t *telemetryService) NotifyEvent(ctx context.Context, event *livekit.WebhookEvent) {
 	if t.notifier == nil {
 		return
@@ -133,6 +148,7 @@ func (t *telemetryService) ParticipantActive(
 			prometheus.AddParticipant()
 		}
 		worker.SetConnected()
+		worker.StartSpan(TimelineSpanSession, "", "", "")
 
 		ev := newParticipantEvent(livekit.AnalyticsEventType_PARTICIPANT_ACTIVE, room, participant)
 		ev.ClientMeta = clientMeta
@@ -148,6 +164,10 @@ func (t *telemetryService) ParticipantResumed(
 	reason livekit.ReconnectReason,
 ) {
 	t.enqueue(func() {
+		if worker, ok := t.getWorker(livekit.ParticipantID(participant.Sid)); ok {
+			worker.MarkSpan(TimelineSpanReconnect, "", "", reason.String())
+		}
+
 		ev := newParticipantEvent(livekit.AnalyticsEventType_PARTICIPANT_RESUMED, room, participant)
 		ev.ClientMeta = &livekit.AnalyticsClientMeta{
 			Node:            string(nodeID),
@@ -157,6 +177,148 @@ func (t *telemetryService) ParticipantResumed(
 	})
 }
 
+// ParticipantMetadataChange is an update to the name or metadata of a participant
+type ParticipantMetadataChange struct {
+	Field    string
+	OldValue string
+	NewValue string
+	// participant that made the change, empty when changed through the server API
+	Actor livekit.ParticipantIdentity
+}
+
+func (t *telemetryService) ParticipantMetadataUpdated(
+	ctx context.Context,
+	room *livekit.Room,
+	participant *livekit.ParticipantInfo,
+	changes []ParticipantMetadataChange,
+) {
+	t.enqueue(func() {
+		if worker, ok := t.getWorker(livekit.ParticipantID(participant.Sid)); ok {
+			for _, change := range changes {
+				worker.MarkSpan(TimelineSpanMetadataChange, "", "", metadataChangeDetail(change))
+			}
+		}
+
+		// webhooks have no fields for the changes, receivers get the updated participant
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event:       EventParticipantMetadataUpdated,
+			Room:        room,
+			Participant: participant,
+		})
+	})
+}
+
+func metadataChangeDetail(change ParticipantMetadataChange) string {
+	if change.Actor == "" {
+		return change.Field
+	}
+	return change.Field + " by " + string(change.Actor)
+}
+
+func (t *telemetryService) ParticipantQualityChanged(
+	ctx context.Context,
+	room *livekit.Room,
+	participant *livekit.ParticipantInfo,
+) {
+	t.enqueue(func() {
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event:       EventParticipantQualityChanged,
+			Room:        room,
+			Participant: participant,
+		})
+	})
+}
+
+func (t *telemetryService) ParticipantTransferred(
+	ctx context.Context,
+	room *livekit.Room,
+	participant *livekit.ParticipantInfo,
+) {
+	t.enqueue(func() {
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event:       EventParticipantTransferred,
+			Room:        room,
+			Participant: participant,
+		})
+	})
+}
+
+func (t *telemetryService) ParticipantHoldChanged(
+	ctx context.Context,
+	room *livekit.Room,
+	participant *livekit.ParticipantInfo,
+	onHold bool,
+) {
+	event := EventParticipantUnheld
+	if onHold {
+		event = EventParticipantHeld
+	}
+	t.enqueue(func() {
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event:       event,
+			Room:        room,
+			Participant: participant,
+		})
+	})
+}
+
+func (t *telemetryService) PublisherIPRejected(
+	ctx context.Context,
+	participantID livekit.ParticipantID,
+	participant *livekit.ParticipantInfo,
+	address string,
+) {
+	t.enqueue(func() {
+		prometheus.ServiceOperationCounter.WithLabelValues("publisher_ip", "rejected", "").Add(1)
+		logger.Infow("publisher IP rejected", "participant", participant.Identity, "pID", participantID, "address", address)
+
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event:       EventPublisherIPRejected,
+			Room:        t.getRoomDetails(participantID),
+			Participant: participant,
+		})
+	})
+}
+
+func (t *telemetryService) RoomStartingSoon(ctx context.Context, room *livekit.Room) {
+	t.enqueue(func() {
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: EventRoomStartingSoon,
+			Room:  room,
+		})
+	})
+}
+
+func (t *telemetryService) RoomQualityDegraded(ctx context.Context, room *livekit.Room) {
+	t.enqueue(func() {
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: EventRoomQualityDegraded,
+			Room:  room,
+		})
+	})
+}
+
+func (t *telemetryService) TrackSilenceChanged(
+	ctx context.Context,
+	room *livekit.Room,
+	participant *livekit.ParticipantInfo,
+	track *livekit.TrackInfo,
+	silent bool,
+) {
+	event := EventTrackSilenceEnded
+	if silent {
+		event = EventTrackSilenceDetected
+	}
+	t.enqueue(func() {
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event:       event,
+			Room:        room,
+			Participant: participant,
+			Track:       track,
+		})
+	})
+}
+
 func (t *telemetryService) ParticipantLeft(ctx context.Context,
 	room *livekit.Room,
 	participant *livekit.ParticipantInfo,
@@ -214,6 +376,9 @@ func (t *telemetryService) TrackPublished(
 	t.enqueue(func() {
 		prometheus.AddPublishedTrack(track.Type.String())
 		prometheus.AddPublishSuccess(track.Type.String())
+		if worker, ok := t.getWorker(participantID); ok {
+			worker.StartSpan(TimelineSpanPublish, livekit.TrackID(track.Sid), "", track.Type.String())
+		}
 
 		room := t.getRoomDetails(participantID)
 		participant := &livekit.ParticipantInfo{
@@ -248,6 +413,14 @@ func (t *telemetryService) TrackMaxSubscribedVideoQuality(
 	maxQuality livekit.VideoQuality,
 ) {
 	t.enqueue(func() {
+		if worker, ok := t.getWorker(participantID); ok {
+			if maxQuality == livekit.VideoQuality_OFF {
+				worker.EndSpan(TimelineSpanLayer, livekit.TrackID(track.Sid), mime)
+			} else {
+				worker.StartSpan(TimelineSpanLayer, livekit.TrackID(track.Sid), mime, maxQuality.String())
+			}
+		}
+
 		room := t.getRoomDetails(participantID)
 		ev := newTrackEvent(livekit.AnalyticsEventType_TRACK_MAX_SUBSCRIBED_VIDEO_QUALITY, room, participantID, track)
 		ev.MaxSubscribedVideoQuality = maxQuality
@@ -310,6 +483,22 @@ func (t *telemetryService) TrackSubscribeFailed(
 	})
 }
 
+func (t *telemetryService) TrackSubscriptionRevoked(
+	ctx context.Context,
+	participantID livekit.ParticipantID,
+	track *livekit.TrackInfo,
+	reason string,
+) {
+	t.enqueue(func() {
+		prometheus.ServiceOperationCounter.WithLabelValues("subscription", "revoked", reason).Add(1)
+
+		room := t.getRoomDetails(participantID)
+		ev := newTrackEvent(livekit.AnalyticsEventType_TRACK_SUBSCRIBE_FAILED, room, participantID, track)
+		ev.Error = "subscription revoked: " + reason
+		t.SendEvent(ctx, ev)
+	})
+}
+
 func (t *telemetryService) TrackUnsubscribed(
 	ctx context.Context,
 	participantID livekit.ParticipantID,
@@ -335,6 +524,9 @@ func (t *telemetryService) TrackUnpublished(
 ) {
 	t.enqueue(func() {
 		prometheus.SubPublishedTrack(track.Type.String())
+		if worker, ok := t.getWorker(participantID); ok {
+			worker.EndTrackSpans(livekit.TrackID(track.Sid))
+		}
 		if !shouldSendEvent {
 			return
 		}
@@ -361,6 +553,10 @@ func (t *telemetryService) TrackMuted(
 	track *livekit.TrackInfo,
 ) {
 	t.enqueue(func() {
+		if worker, ok := t.getWorker(participantID); ok {
+			worker.StartSpan(TimelineSpanMute, livekit.TrackID(track.Sid), "", "")
+		}
+
 		room := t.getRoomDetails(participantID)
 		t.SendEvent(ctx, newTrackEvent(livekit.AnalyticsEventType_TRACK_MUTED, room, participantID, track))
 	})
@@ -372,11 +568,39 @@ func (t *telemetryService) TrackUnmuted(
 	track *livekit.TrackInfo,
 ) {
 	t.enqueue(func() {
+		if worker, ok := t.getWorker(participantID); ok {
+			worker.EndSpan(TimelineSpanMute, livekit.TrackID(track.Sid), "")
+		}
+
 		room := t.getRoomDetails(participantID)
 		t.SendEvent(ctx, newTrackEvent(livekit.AnalyticsEventType_TRACK_UNMUTED, room, participantID, track))
 	})
 }
 
+func (t *telemetryService) TrackSourceChanged(
+	ctx context.Context,
+	participantID livekit.ParticipantID,
+	identity livekit.ParticipantIdentity,
+	track *livekit.TrackInfo,
+	mime string,
+) {
+	t.enqueue(func() {
+		if worker, ok := t.getWorker(participantID); ok {
+			worker.MarkSpan(TimelineSpanSourceChange, livekit.TrackID(track.Sid), mime, "")
+		}
+
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: EventTrackSourceChanged,
+			Room:  t.getRoomDetails(participantID),
+			Participant: &livekit.ParticipantInfo{
+				Sid:      string(participantID),
+				Identity: string(identity),
+			},
+			Track: track,
+		})
+	})
+}
+
 func (t *telemetryService) TrackPublishRTPStats(
 	ctx context.Context,
 	participantID livekit.ParticipantID,
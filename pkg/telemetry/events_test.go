@@ -22,6 +22,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/telemetry"
 )
 
 func Test_OnParticipantJoin_EventIsSent(t *testing.T) {
@@ -236,3 +238,37 @@ func Test_OnTrackSubscribed_EventIsSent(t *testing.T) {
 	require.Equal(t, publisherInfo.Identity, eventTrackSubscribed.Publisher.Identity)
 
 }
+
+func Test_OnClientDiagnostics_ClientStatsEventIsSent(t *testing.T) {
+	fixture := createFixture()
+
+	// prepare
+	room := &livekit.Room{Sid: "RoomSid", Name: "RoomName"}
+	partSID := "part1"
+	participantInfo := &livekit.ParticipantInfo{Sid: partSID, Identity: "alice"}
+	clientInfo := &livekit.ClientInfo{Sdk: livekit.ClientInfo_JS}
+	diagnostics := &telemetry.ClientDiagnostics{
+		Room:          room.Name,
+		Identity:      participantInfo.Identity,
+		ParticipantID: partSID,
+		Tracks: []*telemetry.ClientTrackDiagnostics{{
+			TrackID:   "TR_audio",
+			Direction: telemetry.ClientTrackDirectionPublish,
+			Client:    map[string]interface{}{"type": "outbound-rtp", "packetsSent": float64(100)},
+		}},
+	}
+
+	// do
+	fixture.sut.ParticipantJoined(context.Background(), room, participantInfo, clientInfo, &livekit.AnalyticsClientMeta{}, true)
+	fixture.sut.ClientDiagnosticsReported(context.Background(), livekit.ParticipantID(partSID), clientInfo, diagnostics)
+	time.Sleep(time.Millisecond * 500)
+
+	// test, client reported stats are not sent as the server's TRACK_PUBLISH_STATS
+	require.Equal(t, 2, fixture.analytics.SendEventCallCount())
+	_, event := fixture.analytics.SendEventArgsForCall(1)
+	require.Equal(t, telemetry.AnalyticsEventTypeClientPublishStats, event.Type)
+	require.NotEqual(t, livekit.AnalyticsEventType_TRACK_PUBLISH_STATS, event.Type)
+	require.Equal(t, "TR_audio", event.TrackId)
+	require.Equal(t, uint32(100), event.RtpStats.Packets)
+	require.Len(t, fixture.sut.ClientDiagnostics(livekit.RoomName(room.Name), "alice"), 1)
+}
@@ -40,6 +40,11 @@ var (
 	sysDroppedPacketsStart       uint32
 	promSysPacketGauge           *prometheus.GaugeVec
 	promSysDroppedPacketPctGauge prometheus.Gauge
+	promICEServerHealthy         *prometheus.GaugeVec
+	promAPIRateLimited           *prometheus.CounterVec
+	promOriginRequests           *prometheus.CounterVec
+	promChaosFaults              *prometheus.CounterVec
+	promChaosViolations          *prometheus.CounterVec
 )
 
 func Init(nodeID string, nodeType livekit.NodeType, env string) {
@@ -98,11 +103,71 @@ func Init(nodeID string, nodeType livekit.NodeType, env string) {
 		},
 	)
 
+	promICEServerHealthy = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace:   livekitNamespace,
+			Subsystem:   "node",
+			Name:        "ice_server_healthy",
+			ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+			Help:        "Whether an external STUN/TURN server passed its last probes from this node.",
+		},
+		[]string{"server"},
+	)
+
+	promAPIRateLimited = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   livekitNamespace,
+			Subsystem:   "node",
+			Name:        "api_rate_limited",
+			ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+			Help:        "API requests rejected by rate limits.",
+		},
+		[]string{"limit"},
+	)
+
+	promOriginRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   livekitNamespace,
+			Subsystem:   "node",
+			Name:        "origin_requests",
+			ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+			Help:        "Browser requests by allowed origin, rejected origins are counted as other.",
+		},
+		[]string{"origin", "status"},
+	)
+
+	promChaosFaults = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   livekitNamespace,
+			Subsystem:   "chaos",
+			Name:        "faults",
+			ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+			Help:        "Faults injected by chaos testing.",
+		},
+		[]string{"fault"},
+	)
+
+	promChaosViolations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   livekitNamespace,
+			Subsystem:   "chaos",
+			Name:        "invariant_violations",
+			ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+			Help:        "Invariants found broken while chaos testing.",
+		},
+		[]string{"invariant"},
+	)
+
 	prometheus.MustRegister(MessageCounter)
 	prometheus.MustRegister(ServiceOperationCounter)
 	prometheus.MustRegister(TwirpRequestStatusCounter)
 	prometheus.MustRegister(promSysPacketGauge)
 	prometheus.MustRegister(promSysDroppedPacketPctGauge)
+	prometheus.MustRegister(promICEServerHealthy)
+	prometheus.MustRegister(promAPIRateLimited)
+	prometheus.MustRegister(promOriginRequests)
+	prometheus.MustRegister(promChaosFaults)
+	prometheus.MustRegister(promChaosViolations)
 
 	sysPacketsStart, sysDroppedPacketsStart, _ = getTCStats()
 
@@ -246,3 +311,48 @@ func GetUpdatedNodeStats(prev *livekit.NodeStats, prevAverage *livekit.NodeStats
 func perSec(prev, curr uint64, secs int64) float32 {
 	return float32(curr-prev) / float32(secs)
 }
+
+func SetICEServerHealthy(server string, healthy bool) {
+	if !initialized.Load() {
+		return
+	}
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	promICEServerHealthy.WithLabelValues(server).Set(value)
+}
+
+func IncrementAPIRateLimited(limit string) {
+	if !initialized.Load() {
+		return
+	}
+	promAPIRateLimited.WithLabelValues(limit).Inc()
+}
+
+// IncrementOriginRequest counts a request by the configured origin it matched,
+// origin should be bounded, e.g. "other" for rejected origins
+func IncrementOriginRequest(origin string, allowed bool) {
+	if !initialized.Load() {
+		return
+	}
+	status := "allowed"
+	if !allowed {
+		status = "rejected"
+	}
+	promOriginRequests.WithLabelValues(origin, status).Inc()
+}
+
+func IncrementChaosFault(fault string) {
+	if !initialized.Load() {
+		return
+	}
+	promChaosFaults.WithLabelValues(fault).Inc()
+}
+
+func IncrementChaosViolation(invariant string) {
+	if !initialized.Load() {
+		return
+	}
+	promChaosViolations.WithLabelValues(invariant).Inc()
+}
@@ -15,6 +15,9 @@
 package prometheus
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/atomic"
 
@@ -30,6 +33,38 @@ const (
 	transmissionRetransmit           = "retransmit"
 )
 
+// ForwardingStage is a part of the path of a forwarded packet, from its arrival in the publisher's buffer to
+// being written to a subscriber's connection
+type ForwardingStage int
+
+const (
+	// waiting in the receive buffer until read by the receiver
+	ForwardingStageReceive ForwardingStage = iota
+	// from the receiver to the pacer of the subscriber, through the down track
+	ForwardingStageForward
+	// waiting in the pacer and being written to the subscriber's connection
+	ForwardingStagePacer
+	// from arrival until written
+	ForwardingStageTotal
+
+	numForwardingStages
+)
+
+func (s ForwardingStage) String() string {
+	switch s {
+	case ForwardingStageReceive:
+		return "receive"
+	case ForwardingStageForward:
+		return "forward"
+	case ForwardingStagePacer:
+		return "pacer"
+	case ForwardingStageTotal:
+		return "total"
+	default:
+		return fmt.Sprintf("%d", int(s))
+	}
+}
+
 var (
 	bytesIn                    atomic.Uint64
 	bytesOut                   atomic.Uint64
@@ -41,22 +76,39 @@ var (
 	participantSignalConnected atomic.Uint64
 	participantRTCConnected    atomic.Uint64
 	participantRTCInit         atomic.Uint64
+	batchReadCalls             atomic.Uint64
+	batchReadPackets           atomic.Uint64
+	batchWriteCalls            atomic.Uint64
+	batchWritePackets          atomic.Uint64
+	batchGSOCoalesced          atomic.Uint64
+	socketDropsIn              atomic.Uint64
+	socketDropsOut             atomic.Uint64
+	socketBufferRaisesIn       atomic.Uint64
+	socketBufferRaisesOut      atomic.Uint64
+	srtpProtectNanos           atomic.Uint64
+	srtpProtectPackets         atomic.Uint64
+	srtpAESHardware            atomic.Bool
 
-	promPacketLabels    = []string{"direction", "transmission"}
-	promPacketTotal     *prometheus.CounterVec
-	promPacketBytes     *prometheus.CounterVec
-	promRTCPLabels      = []string{"direction"}
-	promStreamLabels    = []string{"direction", "source", "type"}
-	promNackTotal       *prometheus.CounterVec
-	promPliTotal        *prometheus.CounterVec
-	promFirTotal        *prometheus.CounterVec
-	promPacketLossTotal *prometheus.CounterVec
-	promPacketLoss      *prometheus.HistogramVec
-	promJitter          *prometheus.HistogramVec
-	promRTT             *prometheus.HistogramVec
-	promParticipantJoin *prometheus.CounterVec
-	promConnections     *prometheus.GaugeVec
+	promPacketLabels     = []string{"direction", "transmission"}
+	promPacketTotal      *prometheus.CounterVec
+	promPacketBytes      *prometheus.CounterVec
+	promRTCPLabels       = []string{"direction"}
+	promStreamLabels     = []string{"direction", "source", "type"}
+	promNackTotal        *prometheus.CounterVec
+	promPliTotal         *prometheus.CounterVec
+	promFirTotal         *prometheus.CounterVec
+	promRTCPThrottled    *prometheus.CounterVec
+	promInvalidStream    *prometheus.CounterVec
+	promKeyFrameWatchdog prometheus.Counter
+	promPacketLossTotal  *prometheus.CounterVec
+	promPacketLoss       *prometheus.HistogramVec
+	promJitter           *prometheus.HistogramVec
+	promRTT              *prometheus.HistogramVec
+	promParticipantJoin  *prometheus.CounterVec
+	promConnections      *prometheus.GaugeVec
 
+	promForwardingLatency             *prometheus.HistogramVec
+	promForwardingLatencyStages       [numForwardingStages]prometheus.Observer
 	promPacketTotalIncomingInitial    prometheus.Counter
 	promPacketTotalIncomingRetransmit prometheus.Counter
 	promPacketTotalOutgoingInitial    prometheus.Counter
@@ -98,6 +150,24 @@ func initPacketStats(nodeID string, nodeType livekit.NodeType, env string) {
 		Name:        "total",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
 	}, promRTCPLabels)
+	promRTCPThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "rtcp_throttled",
+		Name:        "total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"type"})
+	promKeyFrameWatchdog = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "keyframe_watchdog",
+		Name:        "total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	})
+	promInvalidStream = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "packet",
+		Name:        "invalid_stream_total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"reason"})
 	promPacketLossTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "packet_loss",
@@ -131,6 +201,13 @@ func initPacketStats(nodeID string, nodeType livekit.NodeType, env string) {
 		Name:        "total",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
 	}, []string{"state"})
+	promForwardingLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "forwarding_latency",
+		Name:        "us",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Buckets:     []float64{50, 100, 250, 500, 1000, 2500, 5000, 10000, 25000, 50000, 100000},
+	}, []string{"stage"})
 	promConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "connection",
@@ -143,12 +220,19 @@ func initPacketStats(nodeID string, nodeType livekit.NodeType, env string) {
 	prometheus.MustRegister(promNackTotal)
 	prometheus.MustRegister(promPliTotal)
 	prometheus.MustRegister(promFirTotal)
+	prometheus.MustRegister(promRTCPThrottled)
+	prometheus.MustRegister(promInvalidStream)
+	prometheus.MustRegister(promKeyFrameWatchdog)
 	prometheus.MustRegister(promPacketLossTotal)
 	prometheus.MustRegister(promPacketLoss)
 	prometheus.MustRegister(promJitter)
 	prometheus.MustRegister(promRTT)
 	prometheus.MustRegister(promParticipantJoin)
 	prometheus.MustRegister(promConnections)
+	prometheus.MustRegister(promForwardingLatency)
+	initBatchIOStats(nodeID, nodeType, env)
+	initSRTPStats(nodeID, nodeType, env)
+	initSocketStats(nodeID, nodeType, env)
 
 	promPacketTotalIncomingInitial = promPacketTotal.WithLabelValues(string(Incoming), transmissionInitial)
 	promPacketTotalIncomingRetransmit = promPacketTotal.WithLabelValues(string(Incoming), transmissionRetransmit)
@@ -158,6 +242,121 @@ func initPacketStats(nodeID string, nodeType livekit.NodeType, env string) {
 	promPacketBytesIncomingRetransmit = promPacketBytes.WithLabelValues(string(Incoming), transmissionRetransmit)
 	promPacketBytesOutgoingInitial = promPacketBytes.WithLabelValues(string(Outgoing), transmissionInitial)
 	promPacketBytesOutgoingRetransmit = promPacketBytes.WithLabelValues(string(Outgoing), transmissionRetransmit)
+	for stage := ForwardingStage(0); stage < numForwardingStages; stage++ {
+		promForwardingLatencyStages[stage] = promForwardingLatency.WithLabelValues(stage.String())
+	}
+}
+
+func initBatchIOStats(nodeID string, nodeType livekit.NodeType, env string) {
+	counter := func(name string, direction Direction, value *atomic.Uint64) prometheus.CounterFunc {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: livekitNamespace,
+			Subsystem: "udp_batch",
+			Name:      name,
+			ConstLabels: prometheus.Labels{
+				"node_id":   nodeID,
+				"node_type": nodeType.String(),
+				"env":       env,
+				"direction": string(direction),
+			},
+		}, func() float64 { return float64(value.Load()) })
+	}
+
+	prometheus.MustRegister(counter("syscalls", Incoming, &batchReadCalls))
+	prometheus.MustRegister(counter("packets", Incoming, &batchReadPackets))
+	prometheus.MustRegister(counter("syscalls", Outgoing, &batchWriteCalls))
+	prometheus.MustRegister(counter("packets", Outgoing, &batchWritePackets))
+	prometheus.MustRegister(counter("gso_coalesced", Outgoing, &batchGSOCoalesced))
+}
+
+func initSocketStats(nodeID string, nodeType livekit.NodeType, env string) {
+	counter := func(name string, direction Direction, value *atomic.Uint64) prometheus.CounterFunc {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: livekitNamespace,
+			Subsystem: "udp_socket",
+			Name:      name,
+			ConstLabels: prometheus.Labels{
+				"node_id":   nodeID,
+				"node_type": nodeType.String(),
+				"env":       env,
+				"direction": string(direction),
+			},
+		}, func() float64 { return float64(value.Load()) })
+	}
+
+	prometheus.MustRegister(counter("drops", Incoming, &socketDropsIn))
+	prometheus.MustRegister(counter("drops", Outgoing, &socketDropsOut))
+	prometheus.MustRegister(counter("buffer_raises", Incoming, &socketBufferRaisesIn))
+	prometheus.MustRegister(counter("buffer_raises", Outgoing, &socketBufferRaisesOut))
+}
+
+// AddSocketDrops records packets dropped by the kernel because a socket buffer was full
+func AddSocketDrops(direction Direction, drops uint64) {
+	if direction == Incoming {
+		socketDropsIn.Add(drops)
+	} else {
+		socketDropsOut.Add(drops)
+	}
+}
+
+func IncrementSocketBufferRaises(direction Direction) {
+	if direction == Incoming {
+		socketBufferRaisesIn.Inc()
+	} else {
+		socketBufferRaisesOut.Inc()
+	}
+}
+
+func initSRTPStats(nodeID string, nodeType livekit.NodeType, env string) {
+	constLabels := prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env}
+	prometheus.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "srtp",
+		Name:        "protect_seconds",
+		Help:        "time spent protecting outgoing RTP packets of pacer flushes, socket writes are held and not included",
+		ConstLabels: constLabels,
+	}, func() float64 { return time.Duration(srtpProtectNanos.Load()).Seconds() }))
+	prometheus.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "srtp",
+		Name:        "protect_packets",
+		ConstLabels: constLabels,
+	}, func() float64 { return float64(srtpProtectPackets.Load()) }))
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "srtp",
+		Name:        "aes_hardware",
+		Help:        "1 when AES-GCM runs on hardware instructions",
+		ConstLabels: constLabels,
+	}, func() float64 {
+		if srtpAESHardware.Load() {
+			return 1
+		}
+		return 0
+	}))
+}
+
+// AddSRTPProtect records the time spent protecting a batch of packets
+func AddSRTPProtect(packets int, elapsed time.Duration) {
+	srtpProtectNanos.Add(uint64(elapsed))
+	srtpProtectPackets.Add(uint64(packets))
+}
+
+func SetSRTPAESHardware(hardware bool) {
+	srtpAESHardware.Store(hardware)
+}
+
+// AddBatchRead records a recvmmsg call that read a number of packets
+func AddBatchRead(packets int) {
+	batchReadCalls.Inc()
+	batchReadPackets.Add(uint64(packets))
+}
+
+// AddBatchWrite records sendmmsg calls that wrote a number of packets, some of them coalesced with UDP GSO
+func AddBatchWrite(calls int, packets int, coalesced int) {
+	batchWriteCalls.Add(uint64(calls))
+	batchWritePackets.Add(uint64(packets))
+	batchGSOCoalesced.Add(uint64(coalesced))
 }
 
 func IncrementPackets(direction Direction, count uint64, retransmit bool) {
@@ -223,6 +422,41 @@ func IncrementRTCP(direction Direction, nack, pli, fir uint32) {
 	}
 }
 
+// IncrementRTCPThrottled records subscriber feedback dropped by the feedback throttle,
+// nacks counts NACKed sequence numbers
+func IncrementRTCPThrottled(keyFrameRequests, nacks uint32) {
+	if keyFrameRequests > 0 {
+		promRTCPThrottled.WithLabelValues("key_frame_request").Add(float64(keyFrameRequests))
+	}
+	if nacks > 0 {
+		promRTCPThrottled.WithLabelValues("nack").Add(float64(nacks))
+	}
+}
+
+// IncrementInvalidStreamPackets records an incoming packet dropped for not matching its negotiated SSRC/MID/RID
+func IncrementInvalidStreamPackets(reason string) {
+	if !initialized.Load() {
+		return
+	}
+	promInvalidStream.WithLabelValues(reason).Inc()
+}
+
+// IncrementKeyFrameWatchdog records a key frame requested from a publisher layer that went too long without one
+func IncrementKeyFrameWatchdog() {
+	if !initialized.Load() {
+		return
+	}
+	promKeyFrameWatchdog.Inc()
+}
+
+// RecordForwardingLatency records the time a sampled packet spent in a stage of forwarding
+func RecordForwardingLatency(stage ForwardingStage, latency time.Duration) {
+	if !initialized.Load() {
+		return
+	}
+	promForwardingLatencyStages[stage].Observe(float64(latency.Microseconds()))
+}
+
 func RecordPacketLoss(direction Direction, trackSource livekit.TrackSource, trackType livekit.TrackType, lost, total uint32) {
 	if total > 0 {
 		promPacketLoss.WithLabelValues(string(direction), trackSource.String(), trackType.String()).Observe(float64(lost) / float64(total) * 100)
@@ -39,10 +39,16 @@ var (
 	promRoomCurrent            prometheus.Gauge
 	promRoomDuration           prometheus.Histogram
 	promParticipantCurrent     prometheus.Gauge
+	promParticipantConnection  *prometheus.CounterVec
 	promTrackPublishedCurrent  *prometheus.GaugeVec
 	promTrackSubscribedCurrent *prometheus.GaugeVec
 	promTrackPublishCounter    *prometheus.CounterVec
 	promTrackSubscribeCounter  *prometheus.CounterVec
+	promTrackByeCounter        *prometheus.CounterVec
+	promTrackStallCounter      *prometheus.CounterVec
+	promRoomSendBitrate        prometheus.Histogram
+	promRoomRetransmitRatio    prometheus.Histogram
+	promRoomPacketLoss         prometheus.Histogram
 )
 
 func initRoomStats(nodeID string, nodeType livekit.NodeType, env string) {
@@ -67,6 +73,12 @@ func initRoomStats(nodeID string, nodeType livekit.NodeType, env string) {
 		Name:        "total",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
 	})
+	promParticipantConnection = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "participant",
+		Name:        "connection_counter",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"type", "family"})
 	promTrackPublishedCurrent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "track",
@@ -91,14 +103,56 @@ func initRoomStats(nodeID string, nodeType livekit.NodeType, env string) {
 		Name:        "subscribe_counter",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
 	}, []string{"state", "error"})
+	promTrackByeCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "track",
+		Name:        "bye_counter",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"kind"})
+	promTrackStallCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "track",
+		Name:        "stall_counter",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"kind", "state"})
+	promRoomSendBitrate = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "room",
+		Name:        "send_bitrate",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Bits per second sent to the subscribers of a room, observed per room on every stats interval.",
+		Buckets:     prometheus.ExponentialBuckets(64_000, 4, 10),
+	})
+	promRoomRetransmitRatio = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "room",
+		Name:        "retransmit_ratio",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Fraction of bytes sent to the subscribers of a room that were retransmissions.",
+		Buckets:     []float64{0.005, 0.01, 0.02, 0.05, 0.1, 0.2, 0.5},
+	})
+	promRoomPacketLoss = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "room",
+		Name:        "packet_loss",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Help:        "Percentage of packets lost across the tracks of a room.",
+		Buckets:     []float64{0.5, 1, 2, 5, 10, 20, 40},
+	})
 
 	prometheus.MustRegister(promRoomCurrent)
 	prometheus.MustRegister(promRoomDuration)
 	prometheus.MustRegister(promParticipantCurrent)
+	prometheus.MustRegister(promParticipantConnection)
 	prometheus.MustRegister(promTrackPublishedCurrent)
 	prometheus.MustRegister(promTrackSubscribedCurrent)
 	prometheus.MustRegister(promTrackPublishCounter)
 	prometheus.MustRegister(promTrackSubscribeCounter)
+	prometheus.MustRegister(promTrackByeCounter)
+	prometheus.MustRegister(promTrackStallCounter)
+	prometheus.MustRegister(promRoomSendBitrate)
+	prometheus.MustRegister(promRoomRetransmitRatio)
+	prometheus.MustRegister(promRoomPacketLoss)
 }
 
 func RoomStarted() {
@@ -124,6 +178,12 @@ func SubParticipant() {
 	participantCurrent.Dec()
 }
 
+// RecordParticipantConnection counts participants by how their connection was established: udp, tcp or turn,
+// and by the address family of the selected candidate pair: ipv4, ipv6 or nat64
+func RecordParticipantConnection(connectionType string, addressFamily string) {
+	promParticipantConnection.WithLabelValues(connectionType, addressFamily).Inc()
+}
+
 func AddPublishedTrack(kind string) {
 	promTrackPublishedCurrent.WithLabelValues(kind).Add(1)
 	trackPublishedCurrent.Inc()
@@ -172,3 +232,30 @@ func RecordTrackSubscribeFailure(err error, isUserError bool) {
 		trackSubscribeUserError.Inc()
 	}
 }
+
+// RecordTrackBye counts published streams ended by an RTCP BYE from the publisher
+func RecordTrackBye(kind string) {
+	if !initialized.Load() {
+		return
+	}
+	promTrackByeCounter.WithLabelValues(kind).Inc()
+}
+
+// RecordTrackStall counts published tracks going without media and recovering, state is stalled or resumed
+func RecordTrackStall(kind string, state string) {
+	if !initialized.Load() {
+		return
+	}
+	promTrackStallCounter.WithLabelValues(kind, state).Inc()
+}
+
+// RecordRoomDeltaStats records the aggregate stats of a room's tracks over a stats interval,
+// loss is a percentage while the retransmit ratio is a fraction of the bytes sent
+func RecordRoomDeltaStats(sendBitrate float64, retransmitRatio float64, lossPercentage float64) {
+	if !initialized.Load() {
+		return
+	}
+	promRoomSendBitrate.Observe(sendBitrate)
+	promRoomRetransmitRatio.Observe(retransmitRatio)
+	promRoomPacketLoss.Observe(lossPercentage)
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+	"github.com/livekit/protocol/livekit"
+)
+
+// roomDeltaStats aggregates the stats of the tracks of a room over a reporting interval,
+// so that room level metrics can be exported without a time series per track
+type roomDeltaStats struct {
+	sendBitrate     float64
+	sendBytes       uint64
+	retransmitBytes uint64
+	packets         uint64
+	packetsLost     uint64
+}
+
+func (r *roomDeltaStats) add(stats []*livekit.AnalyticsStat, interval time.Duration) {
+	var sendBytes uint64
+	for _, stat := range stats {
+		for _, stream := range stat.Streams {
+			if stat.Kind == livekit.StreamType_DOWNSTREAM {
+				sendBytes += stream.PrimaryBytes + stream.RetransmitBytes + stream.PaddingBytes
+				r.retransmitBytes += stream.RetransmitBytes
+			}
+			r.packets += uint64(stream.PrimaryPackets + stream.PaddingPackets)
+			r.packetsLost += uint64(stream.PacketsLost)
+		}
+	}
+
+	r.sendBytes += sendBytes
+	if seconds := interval.Seconds(); seconds > 0 {
+		r.sendBitrate += float64(sendBytes*8) / seconds
+	}
+}
+
+// retransmitRatio returns the fraction of bytes sent to subscribers that were retransmissions
+func (r *roomDeltaStats) retransmitRatio() float64 {
+	if r.sendBytes == 0 {
+		return 0
+	}
+	return float64(r.retransmitBytes) / float64(r.sendBytes)
+}
+
+// lossPercentage returns the percentage of packets lost across the published and subscribed tracks
+func (r *roomDeltaStats) lossPercentage() float64 {
+	if r.packets == 0 {
+		return 0
+	}
+	return float64(r.packetsLost) / float64(r.packets) * 100
+}
+
+func (r *roomDeltaStats) record() {
+	prometheus.RecordRoomDeltaStats(r.sendBitrate, r.retransmitRatio(), r.lossPercentage())
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestRoomDeltaStats(t *testing.T) {
+	rds := &roomDeltaStats{}
+	rds.add([]*livekit.AnalyticsStat{
+		{
+			Kind:    livekit.StreamType_UPSTREAM,
+			Streams: []*livekit.AnalyticsStream{{PrimaryBytes: 1000, PrimaryPackets: 90, PacketsLost: 10}},
+		},
+		{
+			Kind:    livekit.StreamType_DOWNSTREAM,
+			Streams: []*livekit.AnalyticsStream{{PrimaryBytes: 900, RetransmitBytes: 100, PrimaryPackets: 110}},
+		},
+	}, 2*time.Second)
+	rds.add([]*livekit.AnalyticsStat{
+		{
+			Kind:    livekit.StreamType_DOWNSTREAM,
+			Streams: []*livekit.AnalyticsStream{{PrimaryBytes: 1000, PrimaryPackets: 100}},
+		},
+	}, time.Second)
+
+	// 1000 bytes over 2 seconds plus 1000 bytes over 1 second
+	require.Equal(t, float64(4000+8000), rds.sendBitrate)
+	require.InDelta(t, 0.05, rds.retransmitRatio(), 0.0001)
+	require.InDelta(t, 10.0/300*100, rds.lossPercentage(), 0.0001)
+
+	require.Zero(t, (&roomDeltaStats{}).retransmitRatio())
+	require.Zero(t, (&roomDeltaStats{}).lossPercentage())
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
+	"github.com/livekit/protocol/livekit"
+)
+
+// ParticipantStats aggregates the stats of a participant's tracks over a reporting interval
+type ParticipantStats struct {
+	ParticipantID string `json:"participant_id"`
+	Identity      string `json:"identity"`
+	// bits per second of the published and subscribed tracks
+	UpstreamBitrate   uint64 `json:"upstream_bitrate"`
+	DownstreamBitrate uint64 `json:"downstream_bitrate"`
+	// percentage of packets lost
+	UpstreamPacketLoss   float32 `json:"upstream_packet_loss"`
+	DownstreamPacketLoss float32 `json:"downstream_packet_loss"`
+	// mean opinion score of the participant's worst track, and its quality tier
+	Score   float32 `json:"score"`
+	Quality string  `json:"quality"`
+
+	IntervalStart time.Time `json:"interval_start"`
+	IntervalEnd   time.Time `json:"interval_end"`
+}
+
+type RoomStats struct {
+	Room         string              `json:"room"`
+	Participants []*ParticipantStats `json:"participants"`
+}
+
+func newParticipantStats(
+	participantID livekit.ParticipantID,
+	identity livekit.ParticipantIdentity,
+	start time.Time,
+	end time.Time,
+	stats []*livekit.AnalyticsStat,
+) *ParticipantStats {
+	ps := &ParticipantStats{
+		ParticipantID: string(participantID),
+		Identity:      string(identity),
+		IntervalStart: start,
+		IntervalEnd:   end,
+	}
+
+	var upBytes, downBytes uint64
+	var upPackets, downPackets, upLost, downLost uint32
+	minScore := float32(0)
+	for _, stat := range stats {
+		if stat.Score > 0 && (minScore == 0 || stat.Score < minScore) {
+			minScore = stat.Score
+		}
+		for _, stream := range stat.Streams {
+			bytes := stream.PrimaryBytes + stream.RetransmitBytes + stream.PaddingBytes
+			packets := stream.PrimaryPackets + stream.PaddingPackets
+			if stat.Kind == livekit.StreamType_DOWNSTREAM {
+				downBytes += bytes
+				downPackets += packets
+				downLost += stream.PacketsLost
+			} else {
+				upBytes += bytes
+				upPackets += packets
+				upLost += stream.PacketsLost
+			}
+		}
+	}
+
+	if seconds := end.Sub(start).Seconds(); seconds > 0 {
+		ps.UpstreamBitrate = uint64(float64(upBytes*8) / seconds)
+		ps.DownstreamBitrate = uint64(float64(downBytes*8) / seconds)
+	}
+	ps.UpstreamPacketLoss = lossPercentage(upLost, upPackets)
+	ps.DownstreamPacketLoss = lossPercentage(downLost, downPackets)
+	if minScore > 0 {
+		ps.Score = minScore
+		ps.Quality = connectionquality.MOSToConnectionQuality(minScore).String()
+	}
+	return ps
+}
+
+func lossPercentage(lost uint32, packets uint32) float32 {
+	if packets == 0 {
+		return 0
+	}
+	return float32(lost) / float32(packets) * 100
+}
+
+// RoomStats returns the stats of the participants of a room hosted on this node
+// for the most recent reporting interval
+func (t *telemetryService) RoomStats(roomName livekit.RoomName) *RoomStats {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	rs := &RoomStats{
+		Room: string(roomName),
+	}
+	for _, worker := range t.workers {
+		if worker.RoomName() != roomName || !worker.ClosedAt().IsZero() {
+			continue
+		}
+		if ps := worker.IntervalStats(); ps != nil {
+			rs.Participants = append(rs.Participants, ps)
+		}
+	}
+	return rs
+}
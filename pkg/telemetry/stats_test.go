@@ -618,3 +618,42 @@ func (f *telemetryServiceFixture) flush() {
 	time.Sleep(time.Millisecond * 500)
 	f.sut.FlushStats()
 }
+
+func Test_RoomStats(t *testing.T) {
+	fixture := createFixture()
+
+	// prepare
+	room := &livekit.Room{Sid: "RoomSid", Name: "RoomName"}
+	partSID := livekit.ParticipantID("part1")
+	participantInfo := &livekit.ParticipantInfo{Sid: string(partSID), Identity: "identity1"}
+	fixture.sut.ParticipantJoined(context.Background(), room, participantInfo, &livekit.ClientInfo{}, nil, true)
+
+	// do
+	upKey := telemetry.StatsKeyForTrack(livekit.StreamType_UPSTREAM, partSID, "track1", livekit.TrackSource_CAMERA, livekit.TrackType_VIDEO)
+	fixture.sut.TrackStats(upKey, &livekit.AnalyticsStat{
+		Score:   4.4,
+		Streams: []*livekit.AnalyticsStream{{PrimaryBytes: 1000, PrimaryPackets: 90, PacketsLost: 10}},
+	})
+	downKey := telemetry.StatsKeyForTrack(livekit.StreamType_DOWNSTREAM, partSID, "track2", livekit.TrackSource_MICROPHONE, livekit.TrackType_AUDIO)
+	fixture.sut.TrackStats(downKey, &livekit.AnalyticsStat{
+		Score:   2.0,
+		Streams: []*livekit.AnalyticsStream{{PrimaryBytes: 500, PrimaryPackets: 100}},
+	})
+
+	require.Empty(t, fixture.sut.RoomStats(livekit.RoomName(room.Name)).Participants)
+	fixture.flush()
+
+	// test
+	stats := fixture.sut.RoomStats(livekit.RoomName(room.Name))
+	require.Len(t, stats.Participants, 1)
+	ps := stats.Participants[0]
+	require.Equal(t, "identity1", ps.Identity)
+	require.NotZero(t, ps.UpstreamBitrate)
+	require.Greater(t, ps.UpstreamBitrate, ps.DownstreamBitrate)
+	require.InDelta(t, float32(10.0/90*100), ps.UpstreamPacketLoss, 0.01)
+	require.Zero(t, ps.DownstreamPacketLoss)
+	require.Equal(t, float32(2.0), ps.Score)
+	require.Equal(t, livekit.ConnectionQuality_POOR.String(), ps.Quality)
+
+	require.Empty(t, fixture.sut.RoomStats("other").Participants)
+}
@@ -41,6 +41,14 @@ type StatsWorker struct {
 	outgoingPerTrack map[livekit.TrackID][]*livekit.AnalyticsStat
 	incomingPerTrack map[livekit.TrackID][]*livekit.AnalyticsStat
 	closedAt         time.Time
+
+	flushedAt     time.Time
+	intervalStats *ParticipantStats
+
+	clientDiagnostics      []*ClientDiagnostics
+	clientDiagnosticsBytes int
+
+	timeline *sessionTimeline
 }
 
 func newStatsWorker(
@@ -60,7 +68,11 @@ func newStatsWorker(
 		participantIdentity: identity,
 		outgoingPerTrack:    make(map[livekit.TrackID][]*livekit.AnalyticsStat),
 		incomingPerTrack:    make(map[livekit.TrackID][]*livekit.AnalyticsStat),
+		flushedAt:           time.Now(),
 	}
+	s.timeline = newSessionTimeline(func(span *TimelineSpan) {
+		logTimelineSpan(roomName, participantID, identity, span)
+	})
 	return s
 }
 
@@ -91,7 +103,8 @@ func (s *StatsWorker) IsConnected() bool {
 	return s.isConnected
 }
 
-func (s *StatsWorker) Flush() {
+// Flush sends the stats collected since the previous flush, returning them along with the interval they cover
+func (s *StatsWorker) Flush() ([]*livekit.AnalyticsStat, time.Duration) {
 	ts := timestamppb.Now()
 
 	s.lock.Lock()
@@ -109,6 +122,26 @@ func (s *StatsWorker) Flush() {
 	if len(stats) > 0 {
 		s.t.SendStats(s.ctx, stats)
 	}
+
+	s.lock.Lock()
+	s.intervalStats = newParticipantStats(s.participantID, s.participantIdentity, s.flushedAt, ts.AsTime(), stats)
+	interval := ts.AsTime().Sub(s.flushedAt)
+	s.flushedAt = ts.AsTime()
+	s.lock.Unlock()
+
+	return stats, interval
+}
+
+// IntervalStats returns the participant's stats aggregated over the most recent reporting interval
+func (s *StatsWorker) IntervalStats() *ParticipantStats {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.intervalStats
+}
+
+func (s *StatsWorker) RoomName() livekit.RoomName {
+	return s.roomName
 }
 
 func (s *StatsWorker) Close() {
@@ -116,9 +149,64 @@ func (s *StatsWorker) Close() {
 
 	s.lock.Lock()
 	s.closedAt = time.Now()
+	s.timeline.endAll(s.closedAt)
+	s.lock.Unlock()
+}
+
+// StartSpan opens a span of the participant's session timeline, an open span of the same type and track
+// ends if its detail differs
+func (s *StatsWorker) StartSpan(spanType TimelineSpanType, trackID livekit.TrackID, mime string, detail string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// spans opened after the participant has left would never end
+	if !s.closedAt.IsZero() {
+		return
+	}
+	s.timeline.start(spanType, trackID, mime, detail, time.Now())
+}
+
+func (s *StatsWorker) EndSpan(spanType TimelineSpanType, trackID livekit.TrackID, mime string) {
+	s.lock.Lock()
+	s.timeline.end(spanType, trackID, mime, time.Now())
+	s.lock.Unlock()
+}
+
+// EndTrackSpans ends all open spans of a track
+func (s *StatsWorker) EndTrackSpans(trackID livekit.TrackID) {
+	s.lock.Lock()
+	s.timeline.endTrack(trackID, time.Now())
+	s.lock.Unlock()
+}
+
+// MarkSpan records a span without duration
+func (s *StatsWorker) MarkSpan(spanType TimelineSpanType, trackID livekit.TrackID, mime string, detail string) {
+	s.lock.Lock()
+	s.timeline.mark(spanType, trackID, mime, detail, time.Now())
 	s.lock.Unlock()
 }
 
+// AddClientDiagnostics keeps an upload of the participant's client, dropping the oldest past the count and size limits
+func (s *StatsWorker) AddClientDiagnostics(diagnostics *ClientDiagnostics) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.clientDiagnostics = append(s.clientDiagnostics, diagnostics)
+	s.clientDiagnosticsBytes += diagnostics.size
+	for len(s.clientDiagnostics) > 1 &&
+		(len(s.clientDiagnostics) > clientDiagnosticsHistorySize || s.clientDiagnosticsBytes > clientDiagnosticsHistoryBytes) {
+		s.clientDiagnosticsBytes -= s.clientDiagnostics[0].size
+		s.clientDiagnostics = s.clientDiagnostics[1:]
+	}
+}
+
+func (s *StatsWorker) ClientDiagnostics() []*ClientDiagnostics {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return append([]*ClientDiagnostics(nil), s.clientDiagnostics...)
+}
+
 func (s *StatsWorker) ClosedAt() time.Time {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
@@ -10,6 +10,26 @@ import (
 )
 
 type FakeTelemetryService struct {
+	ClientDiagnosticsStub        func(livekit.RoomName, livekit.ParticipantIdentity) []*telemetry.ClientDiagnostics
+	clientDiagnosticsMutex       sync.RWMutex
+	clientDiagnosticsArgsForCall []struct {
+		arg1 livekit.RoomName
+		arg2 livekit.ParticipantIdentity
+	}
+	clientDiagnosticsReturns struct {
+		result1 []*telemetry.ClientDiagnostics
+	}
+	clientDiagnosticsReturnsOnCall map[int]struct {
+		result1 []*telemetry.ClientDiagnostics
+	}
+	ClientDiagnosticsReportedStub        func(context.Context, livekit.ParticipantID, *livekit.ClientInfo, *telemetry.ClientDiagnostics)
+	clientDiagnosticsReportedMutex       sync.RWMutex
+	clientDiagnosticsReportedArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 *livekit.ClientInfo
+		arg4 *telemetry.ClientDiagnostics
+	}
 	EgressEndedStub        func(context.Context, *livekit.EgressInfo)
 	egressEndedMutex       sync.RWMutex
 	egressEndedArgsForCall []struct {
@@ -77,6 +97,14 @@ type FakeTelemetryService struct {
 		arg4 *livekit.AnalyticsClientMeta
 		arg5 bool
 	}
+	ParticipantHoldChangedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, bool)
+	participantHoldChangedMutex       sync.RWMutex
+	participantHoldChangedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+		arg4 bool
+	}
 	ParticipantJoinedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, *livekit.ClientInfo, *livekit.AnalyticsClientMeta, bool)
 	participantJoinedMutex       sync.RWMutex
 	participantJoinedArgsForCall []struct {
@@ -95,6 +123,21 @@ type FakeTelemetryService struct {
 		arg3 *livekit.ParticipantInfo
 		arg4 bool
 	}
+	ParticipantMetadataUpdatedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, []telemetry.ParticipantMetadataChange)
+	participantMetadataUpdatedMutex       sync.RWMutex
+	participantMetadataUpdatedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+		arg4 []telemetry.ParticipantMetadataChange
+	}
+	ParticipantQualityChangedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo)
+	participantQualityChangedMutex       sync.RWMutex
+	participantQualityChangedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}
 	ParticipantResumedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, livekit.NodeID, livekit.ReconnectReason)
 	participantResumedMutex       sync.RWMutex
 	participantResumedArgsForCall []struct {
@@ -104,18 +147,56 @@ type FakeTelemetryService struct {
 		arg4 livekit.NodeID
 		arg5 livekit.ReconnectReason
 	}
+	ParticipantTransferredStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo)
+	participantTransferredMutex       sync.RWMutex
+	participantTransferredArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}
+	PublisherIPRejectedStub        func(context.Context, livekit.ParticipantID, *livekit.ParticipantInfo, string)
+	publisherIPRejectedMutex       sync.RWMutex
+	publisherIPRejectedArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 *livekit.ParticipantInfo
+		arg4 string
+	}
 	RoomEndedStub        func(context.Context, *livekit.Room)
 	roomEndedMutex       sync.RWMutex
 	roomEndedArgsForCall []struct {
 		arg1 context.Context
 		arg2 *livekit.Room
 	}
+	RoomQualityDegradedStub        func(context.Context, *livekit.Room)
+	roomQualityDegradedMutex       sync.RWMutex
+	roomQualityDegradedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+	}
 	RoomStartedStub        func(context.Context, *livekit.Room)
 	roomStartedMutex       sync.RWMutex
 	roomStartedArgsForCall []struct {
 		arg1 context.Context
 		arg2 *livekit.Room
 	}
+	RoomStartingSoonStub        func(context.Context, *livekit.Room)
+	roomStartingSoonMutex       sync.RWMutex
+	roomStartingSoonArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+	}
+	RoomStatsStub        func(livekit.RoomName) *telemetry.RoomStats
+	roomStatsMutex       sync.RWMutex
+	roomStatsArgsForCall []struct {
+		arg1 livekit.RoomName
+	}
+	roomStatsReturns struct {
+		result1 *telemetry.RoomStats
+	}
+	roomStatsReturnsOnCall map[int]struct {
+		result1 *telemetry.RoomStats
+	}
 	SendEventStub        func(context.Context, *livekit.AnalyticsEvent)
 	sendEventMutex       sync.RWMutex
 	sendEventArgsForCall []struct {
@@ -177,6 +258,24 @@ type FakeTelemetryService struct {
 		arg2 livekit.ParticipantID
 		arg3 *livekit.TrackInfo
 	}
+	TrackSilenceChangedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, *livekit.TrackInfo, bool)
+	trackSilenceChangedMutex       sync.RWMutex
+	trackSilenceChangedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+		arg4 *livekit.TrackInfo
+		arg5 bool
+	}
+	TrackSourceChangedStub        func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, string)
+	trackSourceChangedMutex       sync.RWMutex
+	trackSourceChangedArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.TrackInfo
+		arg5 string
+	}
 	TrackStatsStub        func(telemetry.StatsKey, *livekit.AnalyticsStat)
 	trackStatsMutex       sync.RWMutex
 	trackStatsArgsForCall []struct {
@@ -217,6 +316,14 @@ type FakeTelemetryService struct {
 		arg4 *livekit.ParticipantInfo
 		arg5 bool
 	}
+	TrackSubscriptionRevokedStub        func(context.Context, livekit.ParticipantID, *livekit.TrackInfo, string)
+	trackSubscriptionRevokedMutex       sync.RWMutex
+	trackSubscriptionRevokedArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 *livekit.TrackInfo
+		arg4 string
+	}
 	TrackUnmutedStub        func(context.Context, livekit.ParticipantID, *livekit.TrackInfo)
 	trackUnmutedMutex       sync.RWMutex
 	trackUnmutedArgsForCall []struct {
@@ -245,6 +352,103 @@ type FakeTelemetryService struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeTelemetryService) ClientDiagnostics(arg1 livekit.RoomName, arg2 livekit.ParticipantIdentity) []*telemetry.ClientDiagnostics {
+	fake.clientDiagnosticsMutex.Lock()
+	ret, specificReturn := fake.clientDiagnosticsReturnsOnCall[len(fake.clientDiagnosticsArgsForCall)]
+	fake.clientDiagnosticsArgsForCall = append(fake.clientDiagnosticsArgsForCall, struct {
+		arg1 livekit.RoomName
+		arg2 livekit.ParticipantIdentity
+	}{arg1, arg2})
+	stub := fake.ClientDiagnosticsStub
+	fakeReturns := fake.clientDiagnosticsReturns
+	fake.recordInvocation("ClientDiagnostics", []interface{}{arg1, arg2})
+	fake.clientDiagnosticsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeTelemetryService) ClientDiagnosticsCallCount() int {
+	fake.clientDiagnosticsMutex.RLock()
+	defer fake.clientDiagnosticsMutex.RUnlock()
+	return len(fake.clientDiagnosticsArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ClientDiagnosticsCalls(stub func(livekit.RoomName, livekit.ParticipantIdentity) []*telemetry.ClientDiagnostics) {
+	fake.clientDiagnosticsMutex.Lock()
+	defer fake.clientDiagnosticsMutex.Unlock()
+	fake.ClientDiagnosticsStub = stub
+}
+
+func (fake *FakeTelemetryService) ClientDiagnosticsArgsForCall(i int) (livekit.RoomName, livekit.ParticipantIdentity) {
+	fake.clientDiagnosticsMutex.RLock()
+	defer fake.clientDiagnosticsMutex.RUnlock()
+	argsForCall := fake.clientDiagnosticsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeTelemetryService) ClientDiagnosticsReturns(result1 []*telemetry.ClientDiagnostics) {
+	fake.clientDiagnosticsMutex.Lock()
+	defer fake.clientDiagnosticsMutex.Unlock()
+	fake.ClientDiagnosticsStub = nil
+	fake.clientDiagnosticsReturns = struct {
+		result1 []*telemetry.ClientDiagnostics
+	}{result1}
+}
+
+func (fake *FakeTelemetryService) ClientDiagnosticsReturnsOnCall(i int, result1 []*telemetry.ClientDiagnostics) {
+	fake.clientDiagnosticsMutex.Lock()
+	defer fake.clientDiagnosticsMutex.Unlock()
+	fake.ClientDiagnosticsStub = nil
+	if fake.clientDiagnosticsReturnsOnCall == nil {
+		fake.clientDiagnosticsReturnsOnCall = make(map[int]struct {
+			result1 []*telemetry.ClientDiagnostics
+		})
+	}
+	fake.clientDiagnosticsReturnsOnCall[i] = struct {
+		result1 []*telemetry.ClientDiagnostics
+	}{result1}
+}
+
+func (fake *FakeTelemetryService) ClientDiagnosticsReported(arg1 context.Context, arg2 livekit.ParticipantID, arg3 *livekit.ClientInfo, arg4 *telemetry.ClientDiagnostics) {
+	fake.clientDiagnosticsReportedMutex.Lock()
+	fake.clientDiagnosticsReportedArgsForCall = append(fake.clientDiagnosticsReportedArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 *livekit.ClientInfo
+		arg4 *telemetry.ClientDiagnostics
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.ClientDiagnosticsReportedStub
+	fake.recordInvocation("ClientDiagnosticsReported", []interface{}{arg1, arg2, arg3, arg4})
+	fake.clientDiagnosticsReportedMutex.Unlock()
+	if stub != nil {
+		fake.ClientDiagnosticsReportedStub(arg1, arg2, arg3, arg4)
+	}
+}
+
+func (fake *FakeTelemetryService) ClientDiagnosticsReportedCallCount() int {
+	fake.clientDiagnosticsReportedMutex.RLock()
+	defer fake.clientDiagnosticsReportedMutex.RUnlock()
+	return len(fake.clientDiagnosticsReportedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ClientDiagnosticsReportedCalls(stub func(context.Context, livekit.ParticipantID, *livekit.ClientInfo, *telemetry.ClientDiagnostics)) {
+	fake.clientDiagnosticsReportedMutex.Lock()
+	defer fake.clientDiagnosticsReportedMutex.Unlock()
+	fake.ClientDiagnosticsReportedStub = stub
+}
+
+func (fake *FakeTelemetryService) ClientDiagnosticsReportedArgsForCall(i int) (context.Context, livekit.ParticipantID, *livekit.ClientInfo, *telemetry.ClientDiagnostics) {
+	fake.clientDiagnosticsReportedMutex.RLock()
+	defer fake.clientDiagnosticsReportedMutex.RUnlock()
+	argsForCall := fake.clientDiagnosticsReportedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
 func (fake *FakeTelemetryService) EgressEnded(arg1 context.Context, arg2 *livekit.EgressInfo) {
 	fake.egressEndedMutex.Lock()
 	fake.egressEndedArgsForCall = append(fake.egressEndedArgsForCall, struct {
@@ -602,6 +806,41 @@ func (fake *FakeTelemetryService) ParticipantActiveArgsForCall(i int) (context.C
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
+func (fake *FakeTelemetryService) ParticipantHoldChanged(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 bool) {
+	fake.participantHoldChangedMutex.Lock()
+	fake.participantHoldChangedArgsForCall = append(fake.participantHoldChangedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+		arg4 bool
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.ParticipantHoldChangedStub
+	fake.recordInvocation("ParticipantHoldChanged", []interface{}{arg1, arg2, arg3, arg4})
+	fake.participantHoldChangedMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantHoldChangedStub(arg1, arg2, arg3, arg4)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantHoldChangedCallCount() int {
+	fake.participantHoldChangedMutex.RLock()
+	defer fake.participantHoldChangedMutex.RUnlock()
+	return len(fake.participantHoldChangedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantHoldChangedCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo, bool)) {
+	fake.participantHoldChangedMutex.Lock()
+	defer fake.participantHoldChangedMutex.Unlock()
+	fake.ParticipantHoldChangedStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantHoldChangedArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo, bool) {
+	fake.participantHoldChangedMutex.RLock()
+	defer fake.participantHoldChangedMutex.RUnlock()
+	argsForCall := fake.participantHoldChangedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
 func (fake *FakeTelemetryService) ParticipantJoined(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 *livekit.ClientInfo, arg5 *livekit.AnalyticsClientMeta, arg6 bool) {
 	fake.participantJoinedMutex.Lock()
 	fake.participantJoinedArgsForCall = append(fake.participantJoinedArgsForCall, struct {
@@ -674,6 +913,80 @@ func (fake *FakeTelemetryService) ParticipantLeftArgsForCall(i int) (context.Con
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
+func (fake *FakeTelemetryService) ParticipantMetadataUpdated(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 []telemetry.ParticipantMetadataChange) {
+	var arg4Copy []telemetry.ParticipantMetadataChange
+	if arg4 != nil {
+		arg4Copy = make([]telemetry.ParticipantMetadataChange, len(arg4))
+		copy(arg4Copy, arg4)
+	}
+	fake.participantMetadataUpdatedMutex.Lock()
+	fake.participantMetadataUpdatedArgsForCall = append(fake.participantMetadataUpdatedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+		arg4 []telemetry.ParticipantMetadataChange
+	}{arg1, arg2, arg3, arg4Copy})
+	stub := fake.ParticipantMetadataUpdatedStub
+	fake.recordInvocation("ParticipantMetadataUpdated", []interface{}{arg1, arg2, arg3, arg4Copy})
+	fake.participantMetadataUpdatedMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantMetadataUpdatedStub(arg1, arg2, arg3, arg4)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantMetadataUpdatedCallCount() int {
+	fake.participantMetadataUpdatedMutex.RLock()
+	defer fake.participantMetadataUpdatedMutex.RUnlock()
+	return len(fake.participantMetadataUpdatedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantMetadataUpdatedCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo, []telemetry.ParticipantMetadataChange)) {
+	fake.participantMetadataUpdatedMutex.Lock()
+	defer fake.participantMetadataUpdatedMutex.Unlock()
+	fake.ParticipantMetadataUpdatedStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantMetadataUpdatedArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo, []telemetry.ParticipantMetadataChange) {
+	fake.participantMetadataUpdatedMutex.RLock()
+	defer fake.participantMetadataUpdatedMutex.RUnlock()
+	argsForCall := fake.participantMetadataUpdatedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeTelemetryService) ParticipantQualityChanged(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo) {
+	fake.participantQualityChangedMutex.Lock()
+	fake.participantQualityChangedArgsForCall = append(fake.participantQualityChangedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}{arg1, arg2, arg3})
+	stub := fake.ParticipantQualityChangedStub
+	fake.recordInvocation("ParticipantQualityChanged", []interface{}{arg1, arg2, arg3})
+	fake.participantQualityChangedMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantQualityChangedStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantQualityChangedCallCount() int {
+	fake.participantQualityChangedMutex.RLock()
+	defer fake.participantQualityChangedMutex.RUnlock()
+	return len(fake.participantQualityChangedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantQualityChangedCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo)) {
+	fake.participantQualityChangedMutex.Lock()
+	defer fake.participantQualityChangedMutex.Unlock()
+	fake.ParticipantQualityChangedStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantQualityChangedArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo) {
+	fake.participantQualityChangedMutex.RLock()
+	defer fake.participantQualityChangedMutex.RUnlock()
+	argsForCall := fake.participantQualityChangedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
 func (fake *FakeTelemetryService) ParticipantResumed(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 livekit.NodeID, arg5 livekit.ReconnectReason) {
 	fake.participantResumedMutex.Lock()
 	fake.participantResumedArgsForCall = append(fake.participantResumedArgsForCall, struct {
@@ -710,6 +1023,75 @@ func (fake *FakeTelemetryService) ParticipantResumedArgsForCall(i int) (context.
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
+func (fake *FakeTelemetryService) ParticipantTransferred(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo) {
+	fake.participantTransferredMutex.Lock()
+	fake.participantTransferredArgsForCall = append(fake.participantTransferredArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}{arg1, arg2, arg3})
+	stub := fake.ParticipantTransferredStub
+	fake.recordInvocation("ParticipantTransferred", []interface{}{arg1, arg2, arg3})
+	fake.participantTransferredMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantTransferredStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantTransferredCallCount() int {
+	fake.participantTransferredMutex.RLock()
+	defer fake.participantTransferredMutex.RUnlock()
+	return len(fake.participantTransferredArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantTransferredCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo)) {
+	fake.participantTransferredMutex.Lock()
+	defer fake.participantTransferredMutex.Unlock()
+	fake.ParticipantTransferredStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantTransferredArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo) {
+	fake.participantTransferredMutex.RLock()
+	defer fake.participantTransferredMutex.RUnlock()
+	argsForCall := fake.participantTransferredArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeTelemetryService) PublisherIPRejected(arg1 context.Context, arg2 livekit.ParticipantID, arg3 *livekit.ParticipantInfo, arg4 string) {
+	fake.publisherIPRejectedMutex.Lock()
+	fake.publisherIPRejectedArgsForCall = append(fake.publisherIPRejectedArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 *livekit.ParticipantInfo
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.PublisherIPRejectedStub
+	fake.recordInvocation("PublisherIPRejected", []interface{}{arg1, arg2, arg3, arg4})
+	fake.publisherIPRejectedMutex.Unlock()
+	if stub != nil {
+		fake.PublisherIPRejectedStub(arg1, arg2, arg3, arg4)
+	}
+}
+
+func (fake *FakeTelemetryService) PublisherIPRejectedCallCount() int {
+	fake.publisherIPRejectedMutex.RLock()
+	defer fake.publisherIPRejectedMutex.RUnlock()
+	return len(fake.publisherIPRejectedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) PublisherIPRejectedCalls(stub func(context.Context, livekit.ParticipantID, *livekit.ParticipantInfo, string)) {
+	fake.publisherIPRejectedMutex.Lock()
+	defer fake.publisherIPRejectedMutex.Unlock()
+	fake.PublisherIPRejectedStub = stub
+}
+
+func (fake *FakeTelemetryService) PublisherIPRejectedArgsForCall(i int) (context.Context, livekit.ParticipantID, *livekit.ParticipantInfo, string) {
+	fake.publisherIPRejectedMutex.RLock()
+	defer fake.publisherIPRejectedMutex.RUnlock()
+	argsForCall := fake.publisherIPRejectedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
 func (fake *FakeTelemetryService) RoomEnded(arg1 context.Context, arg2 *livekit.Room) {
 	fake.roomEndedMutex.Lock()
 	fake.roomEndedArgsForCall = append(fake.roomEndedArgsForCall, struct {
@@ -743,6 +1125,39 @@ func (fake *FakeTelemetryService) RoomEndedArgsForCall(i int) (context.Context,
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeTelemetryService) RoomQualityDegraded(arg1 context.Context, arg2 *livekit.Room) {
+	fake.roomQualityDegradedMutex.Lock()
+	fake.roomQualityDegradedArgsForCall = append(fake.roomQualityDegradedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+	}{arg1, arg2})
+	stub := fake.RoomQualityDegradedStub
+	fake.recordInvocation("RoomQualityDegraded", []interface{}{arg1, arg2})
+	fake.roomQualityDegradedMutex.Unlock()
+	if stub != nil {
+		fake.RoomQualityDegradedStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeTelemetryService) RoomQualityDegradedCallCount() int {
+	fake.roomQualityDegradedMutex.RLock()
+	defer fake.roomQualityDegradedMutex.RUnlock()
+	return len(fake.roomQualityDegradedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) RoomQualityDegradedCalls(stub func(context.Context, *livekit.Room)) {
+	fake.roomQualityDegradedMutex.Lock()
+	defer fake.roomQualityDegradedMutex.Unlock()
+	fake.RoomQualityDegradedStub = stub
+}
+
+func (fake *FakeTelemetryService) RoomQualityDegradedArgsForCall(i int) (context.Context, *livekit.Room) {
+	fake.roomQualityDegradedMutex.RLock()
+	defer fake.roomQualityDegradedMutex.RUnlock()
+	argsForCall := fake.roomQualityDegradedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeTelemetryService) RoomStarted(arg1 context.Context, arg2 *livekit.Room) {
 	fake.roomStartedMutex.Lock()
 	fake.roomStartedArgsForCall = append(fake.roomStartedArgsForCall, struct {
@@ -776,6 +1191,100 @@ func (fake *FakeTelemetryService) RoomStartedArgsForCall(i int) (context.Context
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeTelemetryService) RoomStartingSoon(arg1 context.Context, arg2 *livekit.Room) {
+	fake.roomStartingSoonMutex.Lock()
+	fake.roomStartingSoonArgsForCall = append(fake.roomStartingSoonArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+	}{arg1, arg2})
+	stub := fake.RoomStartingSoonStub
+	fake.recordInvocation("RoomStartingSoon", []interface{}{arg1, arg2})
+	fake.roomStartingSoonMutex.Unlock()
+	if stub != nil {
+		fake.RoomStartingSoonStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeTelemetryService) RoomStartingSoonCallCount() int {
+	fake.roomStartingSoonMutex.RLock()
+	defer fake.roomStartingSoonMutex.RUnlock()
+	return len(fake.roomStartingSoonArgsForCall)
+}
+
+func (fake *FakeTelemetryService) RoomStartingSoonCalls(stub func(context.Context, *livekit.Room)) {
+	fake.roomStartingSoonMutex.Lock()
+	defer fake.roomStartingSoonMutex.Unlock()
+	fake.RoomStartingSoonStub = stub
+}
+
+func (fake *FakeTelemetryService) RoomStartingSoonArgsForCall(i int) (context.Context, *livekit.Room) {
+	fake.roomStartingSoonMutex.RLock()
+	defer fake.roomStartingSoonMutex.RUnlock()
+	argsForCall := fake.roomStartingSoonArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeTelemetryService) RoomStats(arg1 livekit.RoomName) *telemetry.RoomStats {
+	fake.roomStatsMutex.Lock()
+	ret, specificReturn := fake.roomStatsReturnsOnCall[len(fake.roomStatsArgsForCall)]
+	fake.roomStatsArgsForCall = append(fake.roomStatsArgsForCall, struct {
+		arg1 livekit.RoomName
+	}{arg1})
+	stub := fake.RoomStatsStub
+	fakeReturns := fake.roomStatsReturns
+	fake.recordInvocation("RoomStats", []interface{}{arg1})
+	fake.roomStatsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeTelemetryService) RoomStatsCallCount() int {
+	fake.roomStatsMutex.RLock()
+	defer fake.roomStatsMutex.RUnlock()
+	return len(fake.roomStatsArgsForCall)
+}
+
+func (fake *FakeTelemetryService) RoomStatsCalls(stub func(livekit.RoomName) *telemetry.RoomStats) {
+	fake.roomStatsMutex.Lock()
+	defer fake.roomStatsMutex.Unlock()
+	fake.RoomStatsStub = stub
+}
+
+func (fake *FakeTelemetryService) RoomStatsArgsForCall(i int) livekit.RoomName {
+	fake.roomStatsMutex.RLock()
+	defer fake.roomStatsMutex.RUnlock()
+	argsForCall := fake.roomStatsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeTelemetryService) RoomStatsReturns(result1 *telemetry.RoomStats) {
+	fake.roomStatsMutex.Lock()
+	defer fake.roomStatsMutex.Unlock()
+	fake.RoomStatsStub = nil
+	fake.roomStatsReturns = struct {
+		result1 *telemetry.RoomStats
+	}{result1}
+}
+
+func (fake *FakeTelemetryService) RoomStatsReturnsOnCall(i int, result1 *telemetry.RoomStats) {
+	fake.roomStatsMutex.Lock()
+	defer fake.roomStatsMutex.Unlock()
+	fake.RoomStatsStub = nil
+	if fake.roomStatsReturnsOnCall == nil {
+		fake.roomStatsReturnsOnCall = make(map[int]struct {
+			result1 *telemetry.RoomStats
+		})
+	}
+	fake.roomStatsReturnsOnCall[i] = struct {
+		result1 *telemetry.RoomStats
+	}{result1}
+}
+
 func (fake *FakeTelemetryService) SendEvent(arg1 context.Context, arg2 *livekit.AnalyticsEvent) {
 	fake.sendEventMutex.Lock()
 	fake.sendEventArgsForCall = append(fake.sendEventArgsForCall, struct {
@@ -1058,6 +1567,78 @@ func (fake *FakeTelemetryService) TrackPublishedUpdateArgsForCall(i int) (contex
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
+func (fake *FakeTelemetryService) TrackSilenceChanged(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 *livekit.TrackInfo, arg5 bool) {
+	fake.trackSilenceChangedMutex.Lock()
+	fake.trackSilenceChangedArgsForCall = append(fake.trackSilenceChangedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+		arg4 *livekit.TrackInfo
+		arg5 bool
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.TrackSilenceChangedStub
+	fake.recordInvocation("TrackSilenceChanged", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.trackSilenceChangedMutex.Unlock()
+	if stub != nil {
+		fake.TrackSilenceChangedStub(arg1, arg2, arg3, arg4, arg5)
+	}
+}
+
+func (fake *FakeTelemetryService) TrackSilenceChangedCallCount() int {
+	fake.trackSilenceChangedMutex.RLock()
+	defer fake.trackSilenceChangedMutex.RUnlock()
+	return len(fake.trackSilenceChangedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) TrackSilenceChangedCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo, *livekit.TrackInfo, bool)) {
+	fake.trackSilenceChangedMutex.Lock()
+	defer fake.trackSilenceChangedMutex.Unlock()
+	fake.TrackSilenceChangedStub = stub
+}
+
+func (fake *FakeTelemetryService) TrackSilenceChangedArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo, *livekit.TrackInfo, bool) {
+	fake.trackSilenceChangedMutex.RLock()
+	defer fake.trackSilenceChangedMutex.RUnlock()
+	argsForCall := fake.trackSilenceChangedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeTelemetryService) TrackSourceChanged(arg1 context.Context, arg2 livekit.ParticipantID, arg3 livekit.ParticipantIdentity, arg4 *livekit.TrackInfo, arg5 string) {
+	fake.trackSourceChangedMutex.Lock()
+	fake.trackSourceChangedArgsForCall = append(fake.trackSourceChangedArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.TrackInfo
+		arg5 string
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.TrackSourceChangedStub
+	fake.recordInvocation("TrackSourceChanged", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.trackSourceChangedMutex.Unlock()
+	if stub != nil {
+		fake.TrackSourceChangedStub(arg1, arg2, arg3, arg4, arg5)
+	}
+}
+
+func (fake *FakeTelemetryService) TrackSourceChangedCallCount() int {
+	fake.trackSourceChangedMutex.RLock()
+	defer fake.trackSourceChangedMutex.RUnlock()
+	return len(fake.trackSourceChangedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) TrackSourceChangedCalls(stub func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, string)) {
+	fake.trackSourceChangedMutex.Lock()
+	defer fake.trackSourceChangedMutex.Unlock()
+	fake.TrackSourceChangedStub = stub
+}
+
+func (fake *FakeTelemetryService) TrackSourceChangedArgsForCall(i int) (context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, string) {
+	fake.trackSourceChangedMutex.RLock()
+	defer fake.trackSourceChangedMutex.RUnlock()
+	argsForCall := fake.trackSourceChangedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
 func (fake *FakeTelemetryService) TrackStats(arg1 telemetry.StatsKey, arg2 *livekit.AnalyticsStat) {
 	fake.trackStatsMutex.Lock()
 	fake.trackStatsArgsForCall = append(fake.trackStatsArgsForCall, struct {
@@ -1073,6 +1654,10 @@ func (fake *FakeTelemetryService) TrackStats(arg1 telemetry.StatsKey, arg2 *live
 }
 
 func (fake *FakeTelemetryService) TrackStatsCallCount() int {
+	fake.trackSilenceChangedMutex.RLock()
+	defer fake.trackSilenceChangedMutex.RUnlock()
+	fake.trackSourceChangedMutex.RLock()
+	defer fake.trackSourceChangedMutex.RUnlock()
 	fake.trackStatsMutex.RLock()
 	defer fake.trackStatsMutex.RUnlock()
 	return len(fake.trackStatsArgsForCall)
@@ -1233,6 +1818,41 @@ func (fake *FakeTelemetryService) TrackSubscribedArgsForCall(i int) (context.Con
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
+func (fake *FakeTelemetryService) TrackSubscriptionRevoked(arg1 context.Context, arg2 livekit.ParticipantID, arg3 *livekit.TrackInfo, arg4 string) {
+	fake.trackSubscriptionRevokedMutex.Lock()
+	fake.trackSubscriptionRevokedArgsForCall = append(fake.trackSubscriptionRevokedArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 *livekit.TrackInfo
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.TrackSubscriptionRevokedStub
+	fake.recordInvocation("TrackSubscriptionRevoked", []interface{}{arg1, arg2, arg3, arg4})
+	fake.trackSubscriptionRevokedMutex.Unlock()
+	if stub != nil {
+		fake.TrackSubscriptionRevokedStub(arg1, arg2, arg3, arg4)
+	}
+}
+
+func (fake *FakeTelemetryService) TrackSubscriptionRevokedCallCount() int {
+	fake.trackSubscriptionRevokedMutex.RLock()
+	defer fake.trackSubscriptionRevokedMutex.RUnlock()
+	return len(fake.trackSubscriptionRevokedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) TrackSubscriptionRevokedCalls(stub func(context.Context, livekit.ParticipantID, *livekit.TrackInfo, string)) {
+	fake.trackSubscriptionRevokedMutex.Lock()
+	defer fake.trackSubscriptionRevokedMutex.Unlock()
+	fake.TrackSubscriptionRevokedStub = stub
+}
+
+func (fake *FakeTelemetryService) TrackSubscriptionRevokedArgsForCall(i int) (context.Context, livekit.ParticipantID, *livekit.TrackInfo, string) {
+	fake.trackSubscriptionRevokedMutex.RLock()
+	defer fake.trackSubscriptionRevokedMutex.RUnlock()
+	argsForCall := fake.trackSubscriptionRevokedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
 func (fake *FakeTelemetryService) TrackUnmuted(arg1 context.Context, arg2 livekit.ParticipantID, arg3 *livekit.TrackInfo) {
 	fake.trackUnmutedMutex.Lock()
 	fake.trackUnmutedArgsForCall = append(fake.trackUnmutedArgsForCall, struct {
@@ -1341,6 +1961,10 @@ func (fake *FakeTelemetryService) TrackUnsubscribedArgsForCall(i int) (context.C
 func (fake *FakeTelemetryService) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.clientDiagnosticsMutex.RLock()
+	defer fake.clientDiagnosticsMutex.RUnlock()
+	fake.clientDiagnosticsReportedMutex.RLock()
+	defer fake.clientDiagnosticsReportedMutex.RUnlock()
 	fake.egressEndedMutex.RLock()
 	defer fake.egressEndedMutex.RUnlock()
 	fake.egressStartedMutex.RLock()
@@ -1363,16 +1987,32 @@ func (fake *FakeTelemetryService) Invocations() map[string][][]interface{} {
 	defer fake.notifyEventMutex.RUnlock()
 	fake.participantActiveMutex.RLock()
 	defer fake.participantActiveMutex.RUnlock()
+	fake.participantHoldChangedMutex.RLock()
+	defer fake.participantHoldChangedMutex.RUnlock()
 	fake.participantJoinedMutex.RLock()
 	defer fake.participantJoinedMutex.RUnlock()
 	fake.participantLeftMutex.RLock()
 	defer fake.participantLeftMutex.RUnlock()
+	fake.participantMetadataUpdatedMutex.RLock()
+	defer fake.participantMetadataUpdatedMutex.RUnlock()
+	fake.participantQualityChangedMutex.RLock()
+	defer fake.participantQualityChangedMutex.RUnlock()
 	fake.participantResumedMutex.RLock()
 	defer fake.participantResumedMutex.RUnlock()
+	fake.participantTransferredMutex.RLock()
+	defer fake.participantTransferredMutex.RUnlock()
+	fake.publisherIPRejectedMutex.RLock()
+	defer fake.publisherIPRejectedMutex.RUnlock()
 	fake.roomEndedMutex.RLock()
 	defer fake.roomEndedMutex.RUnlock()
+	fake.roomQualityDegradedMutex.RLock()
+	defer fake.roomQualityDegradedMutex.RUnlock()
 	fake.roomStartedMutex.RLock()
 	defer fake.roomStartedMutex.RUnlock()
+	fake.roomStartingSoonMutex.RLock()
+	defer fake.roomStartingSoonMutex.RUnlock()
+	fake.roomStatsMutex.RLock()
+	defer fake.roomStatsMutex.RUnlock()
 	fake.sendEventMutex.RLock()
 	defer fake.sendEventMutex.RUnlock()
 	fake.sendStatsMutex.RLock()
@@ -1399,6 +2039,8 @@ func (fake *FakeTelemetryService) Invocations() map[string][][]interface{} {
 	defer fake.trackSubscribeRequestedMutex.RUnlock()
 	fake.trackSubscribedMutex.RLock()
 	defer fake.trackSubscribedMutex.RUnlock()
+	fake.trackSubscriptionRevokedMutex.RLock()
+	defer fake.trackSubscriptionRevokedMutex.RUnlock()
 	fake.trackUnmutedMutex.RLock()
 	defer fake.trackUnmutedMutex.RUnlock()
 	fake.trackUnpublishedMutex.RLock()
@@ -33,12 +33,28 @@ type TelemetryService interface {
 	// events
 	RoomStarted(ctx context.Context, room *livekit.Room)
 	RoomEnded(ctx context.Context, room *livekit.Room)
+	// RoomStartingSoon - a scheduled room is about to open
+	RoomStartingSoon(ctx context.Context, room *livekit.Room)
 	// ParticipantJoined - a participant establishes signal connection to a room
 	ParticipantJoined(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, clientInfo *livekit.ClientInfo, clientMeta *livekit.AnalyticsClientMeta, shouldSendEvent bool)
 	// ParticipantActive - a participant establishes media connection
 	ParticipantActive(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, clientMeta *livekit.AnalyticsClientMeta, isMigration bool)
 	// ParticipantResumed - there has been an ICE restart or connection resume attempt, and we've received their signal connection
 	ParticipantResumed(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, nodeID livekit.NodeID, reason livekit.ReconnectReason)
+	// ParticipantMetadataUpdated - name or metadata of a participant has been updated, participant is the updated info
+	ParticipantMetadataUpdated(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, changes []ParticipantMetadataChange)
+	// ParticipantQualityChanged - connection quality of a participant has degraded or recovered
+	ParticipantQualityChanged(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo)
+	// ParticipantTransferred - a participant has been asked to move to another room, the room is the one it moves to
+	ParticipantTransferred(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo)
+	// ParticipantHoldChanged - a participant has been placed on hold or taken off hold
+	ParticipantHoldChanged(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, onHold bool)
+	// PublisherIPRejected - a participant has been removed for publishing from an address that is not allowed
+	PublisherIPRejected(ctx context.Context, participantID livekit.ParticipantID, participant *livekit.ParticipantInfo, address string)
+	// RoomQualityDegraded - connection quality of many participants of a room has degraded
+	RoomQualityDegraded(ctx context.Context, room *livekit.Room)
+	// TrackSilenceChanged - an unmuted track has started or stopped carrying silent audio or static video
+	TrackSilenceChanged(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, track *livekit.TrackInfo, silent bool)
 	// ParticipantLeft - the participant leaves the room, only sent if ParticipantActive has been called before
 	ParticipantLeft(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, shouldSendEvent bool)
 	// TrackPublishRequested - a publication attempt has been received
@@ -55,10 +71,14 @@ type TelemetryService interface {
 	TrackUnsubscribed(ctx context.Context, participantID livekit.ParticipantID, track *livekit.TrackInfo, shouldSendEvent bool)
 	// TrackSubscribeFailed - failure to subscribe to a track
 	TrackSubscribeFailed(ctx context.Context, participantID livekit.ParticipantID, trackID livekit.TrackID, err error, isUserError bool)
+	// TrackSubscriptionRevoked - a subscription has been torn down because permissions changed
+	TrackSubscriptionRevoked(ctx context.Context, participantID livekit.ParticipantID, track *livekit.TrackInfo, reason string)
 	// TrackMuted - the publisher has muted the Track
 	TrackMuted(ctx context.Context, participantID livekit.ParticipantID, track *livekit.TrackInfo)
 	// TrackUnmuted - the publisher has muted the Track
 	TrackUnmuted(ctx context.Context, participantID livekit.ParticipantID, track *livekit.TrackInfo)
+	// TrackSourceChanged - the publisher has replaced the source of the Track, e. g. switched cameras
+	TrackSourceChanged(ctx context.Context, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, track *livekit.TrackInfo, mime string)
 	// TrackPublishedUpdate - track metadata has been updated
 	TrackPublishedUpdate(ctx context.Context, participantID livekit.ParticipantID, track *livekit.TrackInfo)
 	// TrackMaxSubscribedVideoQuality - publisher is notified of the max quality subscribers desire
@@ -78,6 +98,10 @@ type TelemetryService interface {
 	AnalyticsService
 	NotifyEvent(ctx context.Context, event *livekit.WebhookEvent)
 	FlushStats()
+	RoomStats(roomName livekit.RoomName) *RoomStats
+	// ClientDiagnosticsReported stores an upload of a participant's client and sends its stats to analytics
+	ClientDiagnosticsReported(ctx context.Context, participantID livekit.ParticipantID, clientInfo *livekit.ClientInfo, diagnostics *ClientDiagnostics)
+	ClientDiagnostics(roomName livekit.RoomName, identity livekit.ParticipantIdentity) []*ClientDiagnostics
 }
 
 const (
@@ -113,8 +137,23 @@ func (t *telemetryService) FlushStats() {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	rooms := make(map[livekit.RoomName]*roomDeltaStats)
 	for _, worker := range t.workers {
-		worker.Flush()
+		stats, interval := worker.Flush()
+		if len(stats) == 0 {
+			continue
+		}
+
+		rds := rooms[worker.RoomName()]
+		if rds == nil {
+			rds = &roomDeltaStats{}
+			rooms[worker.RoomName()] = rds
+		}
+		rds.add(stats, interval)
+	}
+
+	for _, rds := range rooms {
+		rds.record()
 	}
 }
 
@@ -0,0 +1,156 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+type TimelineSpanType string
+
+const (
+	// TimelineSpanSession - the participant is active in the room
+	TimelineSpanSession TimelineSpanType = "session"
+	// TimelineSpanPublish - a track is published, detail is the track type
+	TimelineSpanPublish TimelineSpanType = "publish"
+	// TimelineSpanMute - a published track is muted
+	TimelineSpanMute TimelineSpanType = "mute"
+	// TimelineSpanLayer - subscribers of a published track want up to the quality in detail
+	TimelineSpanLayer TimelineSpanType = "layer"
+	// TimelineSpanReconnect - the participant resumed its session, detail is the reconnect reason.
+	// The server only sees the resumption, so these spans have no duration
+	TimelineSpanReconnect TimelineSpanType = "reconnect"
+	// TimelineSpanSourceChange - the publisher replaced the source of a track, e. g. switched cameras.
+	// Stats of the track may show a discontinuity at this point, these spans have no duration
+	TimelineSpanSourceChange TimelineSpanType = "source_change"
+	// TimelineSpanMetadataChange - the name or metadata of the participant changed, detail is the field and the
+	// participant that changed it. Values are not logged, these spans have no duration
+	TimelineSpanMetadataChange TimelineSpanType = "metadata_change"
+)
+
+// TimelineSpan is an interval of a participant's session
+type TimelineSpan struct {
+	Type    TimelineSpanType `json:"type"`
+	TrackID string           `json:"track_id,omitempty"`
+	Mime    string           `json:"mime,omitempty"`
+	Detail  string           `json:"detail,omitempty"`
+	Start   time.Time        `json:"start"`
+	End     time.Time        `json:"end"`
+}
+
+func (s *TimelineSpan) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+type timelineKey struct {
+	spanType TimelineSpanType
+	trackID  livekit.TrackID
+	mime     string
+}
+
+// sessionTimeline tracks the open spans of a participant's session, calling onSpan as they end
+type sessionTimeline struct {
+	open   map[timelineKey]*TimelineSpan
+	onSpan func(span *TimelineSpan)
+}
+
+func newSessionTimeline(onSpan func(span *TimelineSpan)) *sessionTimeline {
+	return &sessionTimeline{
+		open:   make(map[timelineKey]*TimelineSpan),
+		onSpan: onSpan,
+	}
+}
+
+// start opens a span, ending a span of the same type and track if its detail differs
+func (tl *sessionTimeline) start(spanType TimelineSpanType, trackID livekit.TrackID, mime string, detail string, at time.Time) {
+	key := timelineKey{spanType: spanType, trackID: trackID, mime: mime}
+	if span := tl.open[key]; span != nil {
+		if span.Detail == detail {
+			return
+		}
+		tl.end(spanType, trackID, mime, at)
+	}
+
+	tl.open[key] = &TimelineSpan{
+		Type:    spanType,
+		TrackID: string(trackID),
+		Mime:    mime,
+		Detail:  detail,
+		Start:   at,
+	}
+}
+
+func (tl *sessionTimeline) end(spanType TimelineSpanType, trackID livekit.TrackID, mime string, at time.Time) {
+	key := timelineKey{spanType: spanType, trackID: trackID, mime: mime}
+	span := tl.open[key]
+	if span == nil {
+		return
+	}
+
+	delete(tl.open, key)
+	span.End = at
+	tl.onSpan(span)
+}
+
+// endTrack ends all open spans of a track
+func (tl *sessionTimeline) endTrack(trackID livekit.TrackID, at time.Time) {
+	for key := range tl.open {
+		if key.trackID == trackID {
+			tl.end(key.spanType, key.trackID, key.mime, at)
+		}
+	}
+}
+
+// endAll ends all open spans, tracks first so that the session span comes last
+func (tl *sessionTimeline) endAll(at time.Time) {
+	for key := range tl.open {
+		if key.trackID != "" {
+			tl.end(key.spanType, key.trackID, key.mime, at)
+		}
+	}
+	for key := range tl.open {
+		tl.end(key.spanType, key.trackID, key.mime, at)
+	}
+}
+
+// mark records a span without duration
+func (tl *sessionTimeline) mark(spanType TimelineSpanType, trackID livekit.TrackID, mime string, detail string, at time.Time) {
+	tl.onSpan(&TimelineSpan{
+		Type:    spanType,
+		TrackID: string(trackID),
+		Mime:    mime,
+		Detail:  detail,
+		Start:   at,
+		End:     at,
+	})
+}
+
+func logTimelineSpan(roomName livekit.RoomName, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, span *TimelineSpan) {
+	logger.Infow("session timeline",
+		"room", roomName,
+		"pID", participantID,
+		"participant", identity,
+		"type", span.Type,
+		"trackID", span.TrackID,
+		"mime", span.Mime,
+		"detail", span.Detail,
+		"start", span.Start,
+		"end", span.End,
+		"duration", span.Duration(),
+	)
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionTimeline(t *testing.T) {
+	var spans []*TimelineSpan
+	tl := newSessionTimeline(func(span *TimelineSpan) {
+		spans = append(spans, span)
+	})
+
+	at := time.Now()
+	after := func(seconds int) time.Time {
+		return at.Add(time.Duration(seconds) * time.Second)
+	}
+
+	tl.start(TimelineSpanSession, "", "", "", at)
+	tl.start(TimelineSpanPublish, "track1", "", "VIDEO", after(1))
+	tl.start(TimelineSpanLayer, "track1", "video/vp8", "HIGH", after(2))
+	// same detail keeps the span open
+	tl.start(TimelineSpanLayer, "track1", "video/vp8", "HIGH", after(3))
+	require.Empty(t, spans)
+
+	// a different detail ends the previous span
+	tl.start(TimelineSpanLayer, "track1", "video/vp8", "LOW", after(4))
+	require.Len(t, spans, 1)
+	require.Equal(t, "HIGH", spans[0].Detail)
+	require.Equal(t, 2*time.Second, spans[0].Duration())
+
+	tl.start(TimelineSpanMute, "track1", "", "", after(5))
+	tl.end(TimelineSpanMute, "track1", "", after(7))
+	require.Len(t, spans, 2)
+	require.Equal(t, TimelineSpanMute, spans[1].Type)
+	require.Equal(t, 2*time.Second, spans[1].Duration())
+
+	tl.mark(TimelineSpanReconnect, "", "", "RR_SIGNAL_DISCONNECTED", after(8))
+	require.Len(t, spans, 3)
+	require.Zero(t, spans[2].Duration())
+
+	tl.endTrack("track1", after(9))
+	require.Len(t, spans, 5)
+	for _, span := range spans[3:] {
+		require.Equal(t, "track1", span.TrackID)
+		require.Equal(t, after(9), span.End)
+	}
+
+	tl.start(TimelineSpanPublish, "track2", "", "AUDIO", after(10))
+	tl.endAll(after(12))
+	require.Len(t, spans, 7)
+	require.Equal(t, TimelineSpanPublish, spans[5].Type)
+	require.Equal(t, TimelineSpanSession, spans[6].Type)
+	require.Equal(t, 12*time.Second, spans[6].Duration())
+	require.Empty(t, tl.open)
+}
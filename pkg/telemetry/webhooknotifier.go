@@ -0,0 +1,221 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frostbyte73/core"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/hashicorp/go-retryablehttp"
+	"go.uber.org/atomic"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	// WebhookSignatureHeader carries the signing time and a signature for each signing key,
+	// formatted as t=<unix seconds>,v1=<key id>:<hex hmac-sha256>,...
+	WebhookSignatureHeader = "Livekit-Webhook-Signature"
+	// WebhookIDHeader carries the event id, which is part of the signed payload
+	WebhookIDHeader = "Livekit-Webhook-Id"
+
+	webhookSignatureVersion    = "v1"
+	defaultWebhookReplayWindow = 5 * time.Minute
+	defaultWebhookQueueSize    = 100
+)
+
+// WebhookSigningKey is an API key pair signing webhooks, the key is sent as the kid of the signatures
+type WebhookSigningKey struct {
+	ID     string
+	Secret string
+}
+
+type WebhookNotifierParams struct {
+	URLs []string
+	// the first key signs the Authorization token, every key signs the signature header
+	Keys []WebhookSigningKey
+	// validity of the Authorization token and of the signature timestamp
+	ReplayWindow time.Duration
+	QueueSize    int
+	Logger       logger.Logger
+}
+
+// WebhookNotifier is a QueuedNotifier that POSTs events to each URL, signed with every active signing key.
+// the Authorization token is compatible with protocol webhook receivers
+type WebhookNotifier struct {
+	params   WebhookNotifierParams
+	client   *retryablehttp.Client
+	urlQueue []*webhookURLQueue
+}
+
+type webhookURLQueue struct {
+	url     string
+	dropped atomic.Int32
+	worker  core.QueueWorker
+}
+
+func NewWebhookNotifier(params WebhookNotifierParams) *WebhookNotifier {
+	if params.ReplayWindow == 0 {
+		params.ReplayWindow = defaultWebhookReplayWindow
+	}
+	if params.QueueSize == 0 {
+		params.QueueSize = defaultWebhookQueueSize
+	}
+	if params.Logger == nil {
+		params.Logger = logger.GetLogger()
+	}
+
+	n := &WebhookNotifier{
+		params: params,
+		client: retryablehttp.NewClient(),
+	}
+	n.client.Logger = nil
+	for _, url := range params.URLs {
+		q := &webhookURLQueue{url: url}
+		q.worker = core.NewQueueWorker(core.QueueWorkerParams{
+			QueueSize:    params.QueueSize,
+			DropWhenFull: true,
+			OnDropped:    func() { q.dropped.Inc() },
+		})
+		n.urlQueue = append(n.urlQueue, q)
+	}
+	return n
+}
+
+func (n *WebhookNotifier) QueueNotify(_ context.Context, event *livekit.WebhookEvent) error {
+	for _, q := range n.urlQueue {
+		q := q
+		// each URL reports its own drops
+		ev := proto.Clone(event).(*livekit.WebhookEvent)
+		q.worker.Submit(func() {
+			if err := n.send(q, ev); err != nil {
+				n.params.Logger.Warnw("failed to send webhook", err, "url", q.url, "event", ev.Event)
+				q.dropped.Add(ev.NumDropped + 1)
+			} else {
+				n.params.Logger.Infow("sent webhook", "url", q.url, "event", ev.Event)
+			}
+		})
+	}
+	return nil
+}
+
+func (n *WebhookNotifier) Stop(force bool) {
+	for _, q := range n.urlQueue {
+		if force {
+			q.worker.Kill()
+		} else {
+			q.worker.Drain()
+		}
+	}
+}
+
+func (n *WebhookNotifier) send(q *webhookURLQueue, event *livekit.WebhookEvent) error {
+	event.NumDropped = q.dropped.Swap(0)
+	encoded, err := protojson.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	r, err := retryablehttp.NewRequest("POST", q.url, bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	if err = signWebhook(r.Header, encoded, event.Id, n.params.Keys, n.params.ReplayWindow, time.Now()); err != nil {
+		return err
+	}
+	// use a custom mime type to ensure signature is checked prior to parsing
+	r.Header.Set("content-type", "application/webhook+json")
+	res, err := n.client.Do(r)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+	return nil
+}
+
+// signWebhook sets the Authorization token, signed by the first key, and the signature header, signed by all keys.
+// retries reuse the headers, so the signing time is that of the first attempt
+func signWebhook(
+	header http.Header,
+	body []byte,
+	eventID string,
+	keys []WebhookSigningKey,
+	validFor time.Duration,
+	now time.Time,
+) error {
+	if len(keys) == 0 {
+		return ErrWebhookNoSigningKey
+	}
+
+	sum := sha256.Sum256(body)
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(keys[0].Secret)},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", keys[0].ID))
+	if err != nil {
+		return err
+	}
+	cl := jwt.Claims{
+		ID:        eventID,
+		Issuer:    keys[0].ID,
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		Expiry:    jwt.NewNumericDate(now.Add(validFor)),
+	}
+	token, err := jwt.Signed(sig).
+		Claims(cl).
+		Claims(&auth.ClaimGrants{Sha256: base64.StdEncoding.EncodeToString(sum[:])}).
+		CompactSerialize()
+	if err != nil {
+		return err
+	}
+
+	timestamp := now.Unix()
+	parts := []string{fmt.Sprintf("t=%d", timestamp)}
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s:%s",
+			webhookSignatureVersion, key.ID, webhookSignature(key.Secret, eventID, timestamp, body)))
+	}
+
+	header.Set("Authorization", token)
+	header.Set(WebhookIDHeader, eventID)
+	header.Set(WebhookSignatureHeader, strings.Join(parts, ","))
+	return nil
+}
+
+// webhookSignature is the hex HMAC-SHA256 of "<event id>.<unix seconds>.<body>"
+func webhookSignature(secret, eventID string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(eventID))
+	mac.Write([]byte("."))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
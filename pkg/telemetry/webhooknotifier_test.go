@@ -0,0 +1,130 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func sendWebhook(t *testing.T, keys []telemetry.WebhookSigningKey, event *livekit.WebhookEvent) receivedWebhook {
+	received := make(chan receivedWebhook, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedWebhook{header: r.Header.Clone(), body: body}
+	}))
+	defer server.Close()
+
+	n := telemetry.NewWebhookNotifier(telemetry.WebhookNotifierParams{
+		URLs:         []string{server.URL},
+		Keys:         keys,
+		ReplayWindow: time.Minute,
+	})
+	defer n.Stop(true)
+	require.NoError(t, n.QueueNotify(context.Background(), event))
+
+	select {
+	case r := <-received:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not received")
+		return receivedWebhook{}
+	}
+}
+
+func TestWebhookSigning(t *testing.T) {
+	keys := []telemetry.WebhookSigningKey{
+		{ID: "old_key", Secret: "old_secret_with_enough_length"},
+		{ID: "new_key", Secret: "new_secret_with_enough_length"},
+	}
+	event := &livekit.WebhookEvent{
+		Id:    "EV_1",
+		Event: webhook.EventRoomStarted,
+		Room:  &livekit.Room{Name: "room"},
+	}
+	r := sendWebhook(t, keys, event)
+	eventID := r.header.Get(telemetry.WebhookIDHeader)
+	signature := r.header.Get(telemetry.WebhookSignatureHeader)
+	require.Equal(t, "EV_1", eventID)
+
+	t.Run("authorization token is signed by the first key", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/", bytes.NewReader(r.body))
+		require.NoError(t, err)
+		req.Header = r.header.Clone()
+		received, err := webhook.ReceiveWebhookEvent(req, auth.NewSimpleKeyProvider("old_key", "old_secret_with_enough_length"))
+		require.NoError(t, err)
+		require.Equal(t, "room", received.Room.Name)
+
+		_, err = webhook.Receive(req, auth.NewSimpleKeyProvider("new_key", "new_secret_with_enough_length"))
+		require.Error(t, err)
+	})
+
+	t.Run("any signing key is accepted", func(t *testing.T) {
+		for _, key := range keys {
+			v := telemetry.NewWebhookVerifier(auth.NewSimpleKeyProvider(key.ID, key.Secret), time.Minute)
+			require.NoError(t, v.Verify(r.body, eventID, signature, time.Now()))
+		}
+
+		v := telemetry.NewWebhookVerifier(auth.NewSimpleKeyProvider("other_key", "other_secret_with_enough_length"), time.Minute)
+		require.ErrorIs(t, v.Verify(r.body, eventID, signature, time.Now()), telemetry.ErrWebhookInvalidSignature)
+	})
+
+	t.Run("tampered deliveries are rejected", func(t *testing.T) {
+		v := telemetry.NewWebhookVerifier(auth.NewSimpleKeyProvider("new_key", "new_secret_with_enough_length"), time.Minute)
+		tampered := bytes.Replace(r.body, []byte("room"), []byte("moor"), 1)
+		require.ErrorIs(t, v.Verify(tampered, eventID, signature, time.Now()), telemetry.ErrWebhookInvalidSignature)
+		require.ErrorIs(t, v.Verify(r.body, "EV_2", signature, time.Now()), telemetry.ErrWebhookInvalidSignature)
+		require.ErrorIs(t, v.Verify(r.body, eventID, "", time.Now()), telemetry.ErrWebhookMissingSignature)
+	})
+
+	t.Run("replays are rejected", func(t *testing.T) {
+		v := telemetry.NewWebhookVerifier(auth.NewSimpleKeyProvider("new_key", "new_secret_with_enough_length"), time.Minute)
+		require.NoError(t, v.Verify(r.body, eventID, signature, time.Now()))
+		// retries of deliveries that were not handled are accepted
+		require.NoError(t, v.Verify(r.body, eventID, signature, time.Now()))
+		v.Ack(eventID)
+		require.ErrorIs(t, v.Verify(r.body, eventID, signature, time.Now()), telemetry.ErrWebhookReplayed)
+		require.ErrorIs(t, v.Verify(r.body, eventID, signature, time.Now().Add(2*time.Minute)), telemetry.ErrWebhookSignatureExpired)
+	})
+
+	t.Run("receive parses the event", func(t *testing.T) {
+		v := telemetry.NewWebhookVerifier(auth.NewSimpleKeyProvider("new_key", "new_secret_with_enough_length"), time.Minute)
+		req, err := http.NewRequest("POST", "/", bytes.NewReader(r.body))
+		require.NoError(t, err)
+		req.Header = r.header.Clone()
+		received, err := v.Receive(req)
+		require.NoError(t, err)
+		require.Equal(t, "EV_1", received.Id)
+		require.Equal(t, webhook.EventRoomStarted, received.Event)
+	})
+}
@@ -0,0 +1,174 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"crypto/hmac"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	ErrWebhookNoSigningKey     = errors.New("no webhook signing key configured")
+	ErrWebhookMissingSignature = errors.New("webhook signature could not be found")
+	ErrWebhookInvalidSignature = errors.New("could not verify webhook signature")
+	ErrWebhookSignatureExpired = errors.New("webhook signature is outside of the replay window")
+	ErrWebhookReplayed         = errors.New("webhook has already been received")
+)
+
+// WebhookVerifier checks the signature header of incoming webhooks. A delivery is accepted when it is signed
+// within the replay window by any key known to the provider, and its event has not been acknowledged in the window.
+// Events are acknowledged with Ack once handled, so that a retry of a delivery the receiver failed to handle is
+// still accepted.
+type WebhookVerifier struct {
+	provider auth.KeyProvider
+	window   time.Duration
+
+	lock sync.Mutex
+	// acknowledgement time of handled events
+	seen map[string]time.Time
+}
+
+func NewWebhookVerifier(provider auth.KeyProvider, window time.Duration) *WebhookVerifier {
+	if window == 0 {
+		window = defaultWebhookReplayWindow
+	}
+	return &WebhookVerifier{
+		provider: provider,
+		window:   window,
+		seen:     make(map[string]time.Time),
+	}
+}
+
+// Receive reads and verifies an incoming webhook, and returns the parsed event. closes body after reading.
+// The event has to be acknowledged with Ack once it has been handled.
+func (v *WebhookVerifier) Receive(r *http.Request) (*livekit.WebhookEvent, error) {
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	eventID := r.Header.Get(WebhookIDHeader)
+	if err = v.Verify(data, eventID, r.Header.Get(WebhookSignatureHeader), time.Now()); err != nil {
+		return nil, err
+	}
+
+	unmarshalOpts := protojson.UnmarshalOptions{
+		DiscardUnknown: true,
+		AllowPartial:   true,
+	}
+	event := &livekit.WebhookEvent{}
+	if err = unmarshalOpts.Unmarshal(data, event); err != nil {
+		return nil, err
+	}
+	if event.Id != eventID {
+		return nil, ErrWebhookInvalidSignature
+	}
+	return event, nil
+}
+
+// Verify checks the signature header of a body, and that the event has not been acknowledged already
+func (v *WebhookVerifier) Verify(body []byte, eventID string, signature string, now time.Time) error {
+	if eventID == "" || signature == "" {
+		return ErrWebhookMissingSignature
+	}
+	timestamp, signatures, err := parseWebhookSignature(signature)
+	if err != nil {
+		return err
+	}
+
+	// tolerate clock skew in both directions
+	signedAt := time.Unix(timestamp, 0)
+	if now.Sub(signedAt) > v.window || signedAt.Sub(now) > v.window {
+		return ErrWebhookSignatureExpired
+	}
+
+	valid := false
+	for keyID, sig := range signatures {
+		secret := v.provider.GetSecret(keyID)
+		if secret == "" {
+			continue
+		}
+		if hmac.Equal([]byte(sig), []byte(webhookSignature(secret, eventID, timestamp, body))) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return ErrWebhookInvalidSignature
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	// signatures are accepted up to a window either side of the signing time, which is at most a window after
+	// the acknowledgement. older events would be rejected as expired
+	for id, at := range v.seen {
+		if now.Sub(at) > 2*v.window {
+			delete(v.seen, id)
+		}
+	}
+	if _, ok := v.seen[eventID]; ok {
+		return ErrWebhookReplayed
+	}
+	return nil
+}
+
+// Ack records an event as handled, later deliveries of it are rejected as replays
+func (v *WebhookVerifier) Ack(eventID string) {
+	v.lock.Lock()
+	v.seen[eventID] = time.Now()
+	v.lock.Unlock()
+}
+
+// parseWebhookSignature returns the signing time and the signature of each key of a signature header
+func parseWebhookSignature(header string) (int64, map[string]string, error) {
+	var timestamp int64
+	signatures := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return 0, nil, ErrWebhookInvalidSignature
+		}
+		switch name {
+		case "t":
+			t, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return 0, nil, ErrWebhookInvalidSignature
+			}
+			timestamp = t
+		case webhookSignatureVersion:
+			keyID, sig, ok := strings.Cut(value, ":")
+			if !ok {
+				return 0, nil, ErrWebhookInvalidSignature
+			}
+			signatures[keyID] = sig
+		}
+	}
+	if timestamp == 0 || len(signatures) == 0 {
+		return 0, nil, ErrWebhookMissingSignature
+	}
+	return timestamp, signatures, nil
+}
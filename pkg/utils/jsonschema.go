@@ -0,0 +1,280 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSONSchema is a validator for a subset of JSON Schema (draft 7) that is sufficient to describe
+// structured metadata: type, enum, const, properties, required, additionalProperties, items,
+// minItems/maxItems, minLength/maxLength, pattern and minimum/maximum.
+// Schemas using other keywords, e. g. oneOf, $ref or format, are rejected rather than partially enforced.
+type JSONSchema struct {
+	Type                 schemaTypes            `json:"type,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Const                interface{}            `json:"const,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+
+	// annotations, accepted and ignored
+	ID          string        `json:"$id,omitempty"`
+	Schema      string        `json:"$schema,omitempty"`
+	Comment     string        `json:"$comment,omitempty"`
+	Title       string        `json:"title,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Default     interface{}   `json:"default,omitempty"`
+	Examples    []interface{} `json:"examples,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*t = multiple
+	return nil
+}
+
+// ReadJSONSchemaFile parses the schema in file, environment variables in the path are expanded.
+// It returns nil when file is empty
+func ReadJSONSchemaFile(file string) (*JSONSchema, error) {
+	if file == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(os.ExpandEnv(file))
+	if err != nil {
+		return nil, fmt.Errorf("could not read schema: %w", err)
+	}
+	schema, err := ParseJSONSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return schema, nil
+}
+
+func ParseJSONSchema(data []byte) (*JSONSchema, error) {
+	s := &JSONSchema{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// keywords that are not supported would otherwise be ignored, accepting documents the schema does not allow
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(s); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return nil, fmt.Errorf("unsupported schema keyword: %s", field)
+		}
+		return nil, fmt.Errorf("could not parse schema: %w", err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *JSONSchema) compile() error {
+	for _, t := range s.Type {
+		switch t {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return fmt.Errorf("unsupported schema type: %s", t)
+		}
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid schema pattern: %w", err)
+		}
+		s.pattern = re
+	}
+	for _, p := range s.Properties {
+		if err := p.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// Validate checks that data is a JSON document conforming to the schema
+func (s *JSONSchema) Validate(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if decoder.More() {
+		return fmt.Errorf("invalid JSON: unexpected data after document")
+	}
+	return s.validate("$", v)
+}
+
+func (s *JSONSchema) validate(path string, v interface{}) error {
+	if len(s.Type) != 0 {
+		matched := false
+		for _, t := range s.Type {
+			if matchesType(t, v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: expected type %v", path, []string(s.Type))
+		}
+	}
+
+	if s.Const != nil && !jsonEqual(s.Const, v) {
+		return fmt.Errorf("%s: expected %v", path, s.Const)
+	}
+	if len(s.Enum) != 0 {
+		found := false
+		for _, e := range s.Enum {
+			if jsonEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: must be one of %v", path, s.Enum)
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		for name, pv := range val {
+			ps, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+				continue
+			}
+			if err := ps.validate(path+"."+name, pv); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			return fmt.Errorf("%s: expected at least %d items", path, *s.MinItems)
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			return fmt.Errorf("%s: expected at most %d items", path, *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range val {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+
+	case string:
+		length := utf8.RuneCountInString(val)
+		if s.MinLength != nil && length < *s.MinLength {
+			return fmt.Errorf("%s: expected at least %d characters", path, *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return fmt.Errorf("%s: expected at most %d characters", path, *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			return fmt.Errorf("%s: does not match pattern %q", path, s.Pattern)
+		}
+
+	case json.Number:
+		f, err := val.Float64()
+		if err != nil {
+			return fmt.Errorf("%s: invalid number", path)
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return fmt.Errorf("%s: must be >= %v", path, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return fmt.Errorf("%s: must be <= %v", path, *s.Maximum)
+		}
+	}
+
+	return nil
+}
+
+func matchesType(t string, v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		if t == "integer" {
+			f, err := val.Float64()
+			return err == nil && f == math.Trunc(f)
+		}
+	}
+	return false
+}
+
+// compares a schema value (decoded as float64) with a document value (decoded as json.Number)
+func jsonEqual(expected, actual interface{}) bool {
+	if n, ok := actual.(json.Number); ok {
+		f, err := n.Float64()
+		if err != nil {
+			return false
+		}
+		e, ok := expected.(float64)
+		return ok && e == f
+	}
+	return reflect.DeepEqual(expected, actual)
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schema, err := ParseJSONSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"role": {"enum": ["host", "guest"]},
+			"seat": {"type": "integer", "minimum": 0, "maximum": 10},
+			"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}, "maxItems": 2},
+			"note": {"type": ["string", "null"]}
+		},
+		"required": ["role"],
+		"additionalProperties": false
+	}`))
+	require.NoError(t, err)
+
+	valid := []string{
+		`{"role": "host"}`,
+		`{"role": "guest", "seat": 3, "tags": ["a", "b"], "note": null}`,
+		`{"role": "guest", "note": "hello"}`,
+	}
+	for _, doc := range valid {
+		require.NoError(t, schema.Validate([]byte(doc)), doc)
+	}
+
+	invalid := []string{
+		``,
+		`[]`,
+		`{"role": "admin"}`,
+		`{"seat": 1}`,
+		`{"role": "host", "seat": 1.5}`,
+		`{"role": "host", "seat": 11}`,
+		`{"role": "host", "tags": ["A"]}`,
+		`{"role": "host", "tags": ["a", "b", "c"]}`,
+		`{"role": "host", "extra": true}`,
+		`{"role": "host"} {}`,
+	}
+	for _, doc := range invalid {
+		require.Error(t, schema.Validate([]byte(doc)), doc)
+	}
+
+	_, err = ParseJSONSchema([]byte(`{"type": "date"}`))
+	require.Error(t, err)
+	_, err = ParseJSONSchema([]byte(`{"type": "string", "pattern": "("}`))
+	require.Error(t, err)
+
+	// annotations are ignored, keywords that are not enforced are rejected, also in nested schemas
+	_, err = ParseJSONSchema([]byte(`{"$schema": "http://json-schema.org/draft-07/schema#", "title": "t", "description": "d", "type": "string", "default": "a", "examples": ["b"]}`))
+	require.NoError(t, err)
+	for _, schema := range []string{
+		`{"oneOf": [{"type": "string"}]}`,
+		`{"anyOf": [{"type": "string"}]}`,
+		`{"allOf": [{"type": "string"}]}`,
+		`{"$ref": "#/definitions/a"}`,
+		`{"type": "string", "format": "email"}`,
+		`{"properties": {"a": {"type": "string", "format": "uri"}}}`,
+		`{"items": {"not": {"type": "null"}}}`,
+	} {
+		_, err = ParseJSONSchema([]byte(schema))
+		require.ErrorContains(t, err, "unsupported schema keyword", schema)
+	}
+}
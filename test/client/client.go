@@ -82,8 +82,13 @@ type RTCClient struct {
 	refreshToken        string
 
 	// map of livekit.ParticipantID and last packet
-	lastPackets   map[livekit.ParticipantID]*rtp.Packet
-	bytesReceived map[livekit.ParticipantID]uint64
+	lastPackets     map[livekit.ParticipantID]*rtp.Packet
+	bytesReceived   map[livekit.ParticipantID]uint64
+	packetsReceived uint64
+	packetsLost     uint64
+
+	loopFiles  bool
+	sampleSize int
 
 	subscriptionResponse atomic.Pointer[livekit.SubscriptionResponse]
 }
@@ -111,6 +116,10 @@ type Options struct {
 	DisabledCodecs            []webrtc.RTPCodecCapability
 	SignalRequestInterceptor  SignalRequestInterceptor
 	SignalResponseInterceptor SignalResponseInterceptor
+	// restart published files at their end
+	LoopFiles bool
+	// size of the synthetic samples of video tracks published without a file
+	SampleSize int
 }
 
 func NewWebSocketConn(host, token string, opts *Options) (*websocket.Conn, error) {
@@ -292,6 +301,8 @@ func NewRTCClient(conn *websocket.Conn, opts *Options) (*RTCClient, error) {
 	if opts != nil {
 		c.signalRequestInterceptor = opts.SignalRequestInterceptor
 		c.signalResponseInterceptor = opts.SignalResponseInterceptor
+		c.loopFiles = opts.LoopFiles
+		c.sampleSize = opts.SampleSize
 	}
 
 	return c, nil
@@ -606,6 +617,8 @@ func (c *RTCClient) AddTrack(track *webrtc.TrackLocalStaticSample, path string)
 	c.trackSenders[ti.Sid] = sender
 	c.publisher.Negotiate(false)
 	writer = NewTrackWriter(c.ctx, track, path)
+	writer.loop = c.loopFiles
+	writer.sampleSize = c.sampleSize
 
 	// write tracks only after connection established
 	if c.hasPrimaryEverConnected() {
@@ -784,6 +797,8 @@ func (c *RTCClient) processTrack(track *webrtc.TrackRemote) {
 	}()
 
 	numBytes := 0
+	var lastSN uint16
+	started := false
 	for {
 		pkt, _, err := track.ReadRTP()
 		if c.ctx.Err() != nil {
@@ -797,6 +812,15 @@ func (c *RTCClient) processTrack(track *webrtc.TrackRemote) {
 			continue
 		}
 		c.lock.Lock()
+		if started {
+			// gaps in sequence numbers, reordered packets are not counted
+			if diff := pkt.SequenceNumber - lastSN; diff > 1 && diff < 0x8000 {
+				c.packetsLost += uint64(diff - 1)
+			}
+		}
+		lastSN = pkt.SequenceNumber
+		started = true
+		c.packetsReceived++
 		c.lastPackets[pId] = pkt
 		c.bytesReceived[pId] += uint64(pkt.MarshalSize())
 		c.lock.Unlock()
@@ -820,6 +844,13 @@ func (c *RTCClient) BytesReceived() uint64 {
 	return total
 }
 
+// PacketStats returns the number of packets received on subscribed tracks and the number missing from their sequences
+func (c *RTCClient) PacketStats() (received uint64, lost uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.packetsReceived, c.packetsLost
+}
+
 func (c *RTCClient) SendNacks(count int) {
 	var packets []rtcp.Packet
 	c.lock.Lock()
@@ -38,7 +38,12 @@ type TrackWriter struct {
 	track    *webrtc.TrackLocalStaticSample
 	filePath string
 	mime     string
+	// restart files at the end instead of stopping
+	loop bool
+	// size of the synthetic video samples written without a file
+	sampleSize int
 
+	file      *os.File
 	ogg       *oggreader.OggReader
 	ivfheader *ivfreader.IVFFileHeader
 	ivf       *ivfreader.IVFReader
@@ -66,6 +71,7 @@ func (w *TrackWriter) Start() error {
 	if err != nil {
 		return err
 	}
+	w.file = file
 
 	logger.Debugw("starting track writer",
 		"trackID", w.track.ID(),
@@ -97,9 +103,38 @@ func (w *TrackWriter) Stop() {
 	w.cancel()
 }
 
+// rewind starts the file over, returning false when not looping
+func (w *TrackWriter) rewind() bool {
+	if !w.loop {
+		return false
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		logger.Errorw("could not rewind file", err, "path", w.filePath)
+		return false
+	}
+
+	var err error
+	switch w.mime {
+	case webrtc.MimeTypeOpus:
+		w.ogg, _, err = oggreader.NewWith(w.file)
+	case webrtc.MimeTypeVP8:
+		w.ivf, w.ivfheader, err = ivfreader.NewWith(w.file)
+	}
+	if err != nil {
+		logger.Errorw("could not restart file", err, "path", w.filePath)
+		return false
+	}
+	return true
+}
+
 func (w *TrackWriter) writeNull() {
 	defer w.onWriteComplete()
 	sample := media.Sample{Data: []byte{0x0, 0xff, 0xff, 0xff, 0xff}, Duration: 30 * time.Millisecond}
+	if w.track.Kind() == webrtc.RTPCodecTypeVideo && w.sampleSize > len(sample.Data) {
+		data := make([]byte, w.sampleSize)
+		copy(data, sample.Data)
+		sample.Data = data
+	}
 	h264Sample := media.Sample{Data: []byte{0x00, 0x00, 0x00, 0x01, 0x7, 0xff, 0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x01, 0x8, 0xff, 0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x01, 0x5, 0xff, 0xff, 0xff, 0xff}, Duration: 30 * time.Millisecond}
 	for {
 		select {
@@ -123,6 +158,10 @@ func (w *TrackWriter) writeOgg() {
 			return
 		}
 		pageData, pageHeader, err := w.ogg.ParseNextPage()
+		if err == io.EOF && w.rewind() {
+			lastGranule = 0
+			continue
+		}
 		if err == io.EOF {
 			logger.Debugw("all audio samples parsed and sent")
 			w.onWriteComplete()
@@ -157,6 +196,9 @@ func (w *TrackWriter) writeVP8() {
 			return
 		}
 		frame, _, err := w.ivf.ParseNextFrame()
+		if err == io.EOF && w.rewind() {
+			continue
+		}
 		if err == io.EOF {
 			logger.Debugw("all video frames parsed and sent")
 			w.onWriteComplete()
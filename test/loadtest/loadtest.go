@@ -0,0 +1,328 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+	"github.com/livekit/livekit-server/test/client"
+)
+
+const (
+	// synthetic samples are written every 20ms
+	syntheticSampleRate = 50
+	// participants connecting at the same time
+	connectConcurrency = 10
+	subscribeTimeout   = 20 * time.Second
+)
+
+// Params of a load test, publishers and subscribers join the same room through the real signaling and media paths
+type Params struct {
+	// ws:// or wss:// address of the server
+	URL       string
+	APIKey    string
+	APISecret string
+	Room      string
+
+	Publishers  int
+	Subscribers int
+	// VP8 IVF and Opus OGG files published in a loop, synthetic samples are published instead when not set
+	VideoFile string
+	AudioFile string
+	// bitrate of the synthetic video in bits per second, no video is published when 0 and there is no file
+	VideoBitrate int
+	// measurement period once every subscriber has subscribed
+	Duration time.Duration
+}
+
+func (p *Params) validate() error {
+	switch {
+	case p.URL == "" || p.APIKey == "" || p.APISecret == "" || p.Room == "":
+		return errors.New("url, api key, api secret and room are required")
+	case p.Publishers <= 0:
+		return errors.New("at least one publisher is required")
+	case p.Subscribers < 0:
+		return errors.New("subscribers cannot be negative")
+	case p.Duration <= 0:
+		return errors.New("duration must be positive")
+	}
+	return nil
+}
+
+func (p *Params) tracksPerPublisher() int {
+	tracks := 1
+	if p.VideoFile != "" || p.VideoBitrate > 0 {
+		tracks++
+	}
+	return tracks
+}
+
+// SubscriberResult is what a subscriber received during the measurement period
+type SubscriberResult struct {
+	Identity        string
+	Tracks          int
+	Bitrate         float64
+	PacketsReceived uint64
+	PacketsLost     uint64
+}
+
+func (r *SubscriberResult) LossRate() float64 {
+	if total := r.PacketsReceived + r.PacketsLost; total != 0 {
+		return float64(r.PacketsLost) / float64(total)
+	}
+	return 0
+}
+
+type Result struct {
+	Duration time.Duration
+	// tracks every subscriber should receive
+	ExpectedTracks int
+	Subscribers    []SubscriberResult
+}
+
+// Summary aggregates the subscribers, bitrates in bits per second
+func (r *Result) Summary() (minBitrate float64, avgBitrate float64, lossRate float64, missingTracks int) {
+	var received, lost uint64
+	for i, s := range r.Subscribers {
+		if i == 0 || s.Bitrate < minBitrate {
+			minBitrate = s.Bitrate
+		}
+		avgBitrate += s.Bitrate
+		received += s.PacketsReceived
+		lost += s.PacketsLost
+		if s.Tracks < r.ExpectedTracks {
+			missingTracks += r.ExpectedTracks - s.Tracks
+		}
+	}
+	if len(r.Subscribers) != 0 {
+		avgBitrate /= float64(len(r.Subscribers))
+	}
+	if received+lost != 0 {
+		lossRate = float64(lost) / float64(received+lost)
+	}
+	return
+}
+
+type LoadTest struct {
+	params Params
+
+	lock    sync.Mutex
+	clients []*client.RTCClient
+}
+
+func NewLoadTest(params Params) (*LoadTest, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	// the client transports record metrics, does nothing when the process already initialized them
+	prometheus.Init("loadtest", livekit.NodeType_SERVER, "loadtest")
+	return &LoadTest{params: params}, nil
+}
+
+// Run connects the participants, waits for the subscriptions and measures what subscribers receive
+func (t *LoadTest) Run(ctx context.Context) (*Result, error) {
+	defer t.stop()
+
+	publishers := make([]*client.RTCClient, t.params.Publishers)
+	if err := t.connectAll(ctx, publishers, "publisher", false); err != nil {
+		return nil, err
+	}
+	for i, c := range publishers {
+		if err := t.publish(c, i); err != nil {
+			return nil, err
+		}
+	}
+
+	subscribers := make([]*client.RTCClient, t.params.Subscribers)
+	if err := t.connectAll(ctx, subscribers, "subscriber", true); err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Duration:       t.params.Duration,
+		ExpectedTracks: t.params.Publishers * t.params.tracksPerPublisher(),
+		Subscribers:    make([]SubscriberResult, len(subscribers)),
+	}
+	t.waitForSubscriptions(ctx, subscribers, result.ExpectedTracks)
+
+	type sample struct {
+		bytes, received, lost uint64
+	}
+	starts := make([]sample, len(subscribers))
+	for i, c := range subscribers {
+		received, lost := c.PacketStats()
+		starts[i] = sample{c.BytesReceived(), received, lost}
+	}
+	startedAt := time.Now()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(t.params.Duration):
+	}
+	elapsed := time.Since(startedAt)
+	result.Duration = elapsed
+
+	for i, c := range subscribers {
+		received, lost := c.PacketStats()
+		result.Subscribers[i] = SubscriberResult{
+			Identity:        participantIdentity("subscriber", i),
+			Tracks:          numTracks(c),
+			Bitrate:         float64(c.BytesReceived()-starts[i].bytes) * 8 / elapsed.Seconds(),
+			PacketsReceived: received - starts[i].received,
+			PacketsLost:     lost - starts[i].lost,
+		}
+	}
+	return result, ctx.Err()
+}
+
+func (t *LoadTest) connectAll(ctx context.Context, clients []*client.RTCClient, kind string, autoSubscribe bool) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(connectConcurrency)
+	for i := range clients {
+		i := i
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			identity := participantIdentity(kind, i)
+			c, err := t.connect(identity, autoSubscribe)
+			if err != nil {
+				return fmt.Errorf("%s could not connect: %w", identity, err)
+			}
+			clients[i] = c
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+func (t *LoadTest) connect(identity string, autoSubscribe bool) (*client.RTCClient, error) {
+	token, err := auth.NewAccessToken(t.params.APIKey, t.params.APISecret).
+		AddGrant(&auth.VideoGrant{RoomJoin: true, Room: t.params.Room}).
+		SetIdentity(identity).
+		SetValidFor(t.params.Duration + time.Hour).
+		ToJWT()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &client.Options{
+		AutoSubscribe: autoSubscribe,
+		LoopFiles:     true,
+		SampleSize:    t.params.VideoBitrate / 8 / syntheticSampleRate,
+	}
+	conn, err := client.NewWebSocketConn(t.params.URL, token, opts)
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.NewRTCClient(conn, opts)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	t.lock.Lock()
+	t.clients = append(t.clients, c)
+	t.lock.Unlock()
+
+	go func() {
+		_ = c.Run()
+	}()
+	if err = c.WaitUntilConnected(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (t *LoadTest) publish(c *client.RTCClient, index int) error {
+	var err error
+	if t.params.AudioFile != "" {
+		_, err = c.AddFileTrack(t.params.AudioFile, fmt.Sprintf("audio_%d", index), "loadtest")
+	} else {
+		_, err = c.AddStaticTrack(webrtc.MimeTypeOpus, fmt.Sprintf("audio_%d", index), "loadtest")
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case t.params.VideoFile != "":
+		_, err = c.AddFileTrack(t.params.VideoFile, fmt.Sprintf("video_%d", index), "loadtest")
+	case t.params.VideoBitrate > 0:
+		_, err = c.AddStaticTrack(webrtc.MimeTypeVP8, fmt.Sprintf("video_%d", index), "loadtest")
+	}
+	return err
+}
+
+func (t *LoadTest) waitForSubscriptions(ctx context.Context, subscribers []*client.RTCClient, expected int) {
+	deadline := time.After(subscribeTimeout)
+	for {
+		complete := true
+		for _, c := range subscribers {
+			if numTracks(c) < expected {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline:
+			logger.Warnw("not all tracks subscribed, measuring anyway", nil, "expected", expected)
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (t *LoadTest) stop() {
+	t.lock.Lock()
+	clients := t.clients
+	t.clients = nil
+	t.lock.Unlock()
+
+	for _, c := range clients {
+		c.Stop()
+	}
+}
+
+func numTracks(c *client.RTCClient) int {
+	tracks := 0
+	for _, ts := range c.SubscribedTracks() {
+		tracks += len(ts)
+	}
+	return tracks
+}
+
+func participantIdentity(kind string, i int) string {
+	return fmt.Sprintf("%s_%d", kind, i)
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/testutils"
 	testclient "github.com/livekit/livekit-server/test/client"
+	"github.com/livekit/livekit-server/test/loadtest"
 )
 
 const (
@@ -664,3 +665,73 @@ func TestSubscribeToCodecUnsupported(t *testing.T) {
 	})
 	require.Nil(t, c2.GetSubscriptionResponseAndClear())
 }
+
+func TestLoadTest(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	_, finish := setupSingleNodeTest("TestLoadTest")
+	defer finish()
+
+	lt, err := loadtest.NewLoadTest(loadtest.Params{
+		URL:          fmt.Sprintf("ws://localhost:%d", defaultServerPort),
+		APIKey:       testApiKey,
+		APISecret:    testApiSecret,
+		Room:         testRoom,
+		Publishers:   2,
+		Subscribers:  2,
+		VideoBitrate: 200_000,
+		Duration:     2 * time.Second,
+	})
+	require.NoError(t, err)
+
+	result, err := lt.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 4, result.ExpectedTracks)
+	require.Len(t, result.Subscribers, 2)
+
+	minBitrate, _, _, missingTracks := result.Summary()
+	require.Zero(t, missingTracks)
+	// both publishers' synthetic video
+	require.Greater(t, minBitrate, float64(300_000))
+}
+
+func TestSubscriberImpairment(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	_, finish := setupSingleNodeTestWithConfig("TestSubscriberImpairment", func(conf *config.Config) {
+		conf.RTC.Impairments = []config.ImpairmentConfig{
+			{IdentityPrefix: "lossy", Transport: "subscriber", Loss: 0.2, Seed: 1},
+		}
+	})
+	defer finish()
+
+	pub := createRTCClient("pub", defaultServerPort, nil)
+	lossy := createRTCClient("lossy", defaultServerPort, nil)
+	clean := createRTCClient("clean", defaultServerPort, nil)
+	waitUntilConnected(t, pub, lossy, clean)
+
+	writer, err := pub.AddStaticTrack("video/vp8", "video", "webcam")
+	require.NoError(t, err)
+	defer writer.Stop()
+
+	testutils.WithTimeout(t, func() string {
+		if received, _ := lossy.PacketStats(); received < 100 {
+			return "lossy subscriber did not receive enough packets"
+		}
+		if received, _ := clean.PacketStats(); received < 100 {
+			return "clean subscriber did not receive enough packets"
+		}
+		return ""
+	})
+
+	_, lost := lossy.PacketStats()
+	require.NotZero(t, lost)
+	_, lost = clean.PacketStats()
+	require.Zero(t, lost)
+}